@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aditya/go-comet/internal/middleware"
+)
+
+// client calls the API with the admin key, and the tenant key when ops act on a
+// white-label brand's rides through the app routes
+type client struct {
+	baseURL   string
+	adminKey  string
+	tenantKey string
+	http      *http.Client
+}
+
+func newClient(baseURL, adminKey, tenantKey string) *client {
+	return &client{
+		baseURL:   strings.TrimRight(baseURL, "/"),
+		adminKey:  adminKey,
+		tenantKey: tenantKey,
+		http:      &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// do sends body as JSON, if given, and returns the raw response body. Non-2xx
+// responses become errors carrying the API's error code and message.
+func (c *client) do(method, path string, body interface{}) (json.RawMessage, error) {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.adminKey != "" {
+		req.Header.Set(middleware.AdminKeyHeader, c.adminKey)
+	}
+	if c.tenantKey != "" {
+		req.Header.Set(middleware.TenantKeyHeader, c.tenantKey)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var apiErr struct {
+			Error   string `json:"error"`
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(raw, &apiErr) == nil && apiErr.Message != "" {
+			return nil, fmt.Errorf("%s %s: %d %s: %s", method, path, resp.StatusCode, apiErr.Error, apiErr.Message)
+		}
+		return nil, fmt.Errorf("%s %s: %s", method, path, resp.Status)
+	}
+	return raw, nil
+}
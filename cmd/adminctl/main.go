@@ -0,0 +1,173 @@
+// Command adminctl runs common ops tasks against the API: cancelling rides,
+// taking drivers offline, inspecting a ride's timeline, rematching and refunds.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+)
+
+const usage = `Usage: adminctl [flags] <command> [args]
+
+Commands:
+  cancel-ride <ride-id> [reason]   Cancel a ride on the system's behalf
+  offline-driver <driver-id>       Take a driver offline
+  timeline <ride-id> [at]          Show a ride's audit timeline, up to an RFC3339 time
+  rematch <ride-id>                Run a fresh dispatch round for a ride in matching
+  refund <payment-id>              Refund a payment
+
+Flags:
+`
+
+func main() {
+	api := flag.String("api", envOr("ADMINCTL_API_URL", "http://localhost:8080"), "API base URL")
+	adminKey := flag.String("key", os.Getenv("ADMIN_API_KEY"), "admin API key (defaults to $ADMIN_API_KEY)")
+	tenantKey := flag.String("tenant-key", os.Getenv("ADMINCTL_TENANT_KEY"), "tenant API key for rides and drivers of a white-label brand")
+	output := flag.String("o", "table", "output format: table or json")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *output != "table" && *output != "json" {
+		fail(fmt.Errorf("unknown output format %q", *output))
+	}
+	if *adminKey == "" {
+		fail(fmt.Errorf("an admin API key is required: pass -key or set ADMIN_API_KEY"))
+	}
+	args := flag.Args()
+	if len(args) < 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	c := newClient(*api, *adminKey, *tenantKey)
+	id := url.PathEscape(args[1])
+
+	var (
+		raw json.RawMessage
+		err error
+	)
+	switch args[0] {
+	case "cancel-ride":
+		req := models.CancelRideRequest{Reason: "cancelled by ops"}
+		if len(args) > 2 {
+			req.Reason = args[2]
+		}
+		raw, err = c.do("POST", "/v1/admin/rides/"+id+"/cancel", req)
+	case "offline-driver":
+		raw, err = c.do("POST", "/v1/drivers/"+id+"/offline", nil)
+	case "timeline":
+		path := "/v1/admin/rides/" + id + "/replay"
+		if len(args) > 2 {
+			if _, err := time.Parse(time.RFC3339, args[2]); err != nil {
+				fail(fmt.Errorf("at must be an RFC3339 timestamp"))
+			}
+			path += "?at=" + url.QueryEscape(args[2])
+		}
+		raw, err = c.do("GET", path, nil)
+		if err == nil && *output == "table" {
+			if err := printTimeline(os.Stdout, raw); err != nil {
+				fail(err)
+			}
+			return
+		}
+	case "rematch":
+		raw, err = c.do("POST", "/v1/admin/rides/"+id+"/rematch", nil)
+	case "refund":
+		raw, err = c.do("POST", "/v1/payments/"+id+"/refund", nil)
+	default:
+		fail(fmt.Errorf("unknown command %q", args[0]))
+	}
+	if err != nil {
+		fail(err)
+	}
+
+	if *output == "json" {
+		err = printJSON(os.Stdout, raw)
+	} else {
+		err = printFields(os.Stdout, raw)
+	}
+	if err != nil {
+		fail(err)
+	}
+}
+
+// printTimeline lists a ride's audit entries oldest first, with the status each
+// snapshot recorded
+func printTimeline(w io.Writer, raw json.RawMessage) error {
+	var replay models.RideReplay
+	if err := json.Unmarshal(raw, &replay); err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "RECORDED AT\tENTITY\tID\tOPERATION\tSTATUS")
+	for _, entry := range replay.Timeline {
+		var snapshot struct {
+			Status string `json:"status"`
+		}
+		_ = json.Unmarshal(entry.Snapshot, &snapshot)
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", entry.RecordedAt.Format(time.RFC3339),
+			entry.EntityType, entry.EntityID, entry.Operation, snapshot.Status)
+	}
+	return tw.Flush()
+}
+
+// printFields prints a JSON object's fields one per line, nested values as
+// compact JSON
+func printFields(w io.Writer, raw json.RawMessage) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return printJSON(w, raw)
+	}
+
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, k := range keys {
+		value := string(fields[k])
+		var s string
+		if json.Unmarshal(fields[k], &s) == nil {
+			value = s
+		}
+		fmt.Fprintf(tw, "%s\t%s\n", k, value)
+	}
+	return tw.Flush()
+}
+
+func printJSON(w io.Writer, raw json.RawMessage) error {
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "adminctl:", err)
+	os.Exit(1)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aditya/go-comet/internal/worker"
+)
+
+// lifecycle hands an instance's work over to the others during a rolling
+// deploy. Draining fails readiness so load balancers stop sending traffic, then
+// lets periodic jobs finish their current run and in-flight dispatch rounds
+// complete. Anything left is picked up from the database by the instances that
+// stay up: matching escalation re-dispatches rides, and jobs resume from the
+// rows they haven't processed.
+type lifecycle struct {
+	runner      *worker.Runner
+	stopWorkers context.CancelFunc
+	tasks       *worker.Tasks
+	// How long to keep serving after readiness fails, for load balancers to notice
+	delay time.Duration
+	// How long to wait for jobs and tasks to finish
+	timeout time.Duration
+
+	draining atomic.Bool
+	once     sync.Once
+}
+
+func (l *lifecycle) Draining() bool {
+	return l.draining.Load()
+}
+
+// Drain runs once; calls while it is running wait for it to finish
+func (l *lifecycle) Drain() {
+	l.once.Do(func() {
+		l.draining.Store(true)
+		log.Printf("Draining: failing readiness for %s", l.delay)
+		time.Sleep(l.delay)
+
+		l.stopWorkers()
+		ctx, cancel := context.WithTimeout(context.Background(), l.timeout)
+		defer cancel()
+		if err := l.tasks.Drain(ctx); err != nil {
+			log.Printf("Draining: gave up waiting for in-flight tasks: %v", err)
+		}
+		if err := l.runner.Drain(ctx); err != nil {
+			log.Printf("Draining: gave up waiting for workers: %v", err)
+		}
+		log.Println("Draining: done")
+	})
+}
+
+// GET|POST /prestop
+// Orchestrator pre-stop hook: responds once the instance has drained, so the
+// SIGTERM that follows only has to close connections
+func (l *lifecycle) handlePreStop(w http.ResponseWriter, r *http.Request) {
+	l.Drain()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "drained"})
+}
@@ -2,26 +2,42 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"flag"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"regexp"
 	"syscall"
 	"time"
 
+	"github.com/aditya/go-comet/internal/alerting"
 	"github.com/aditya/go-comet/internal/cache"
 	"github.com/aditya/go-comet/internal/config"
 	"github.com/aditya/go-comet/internal/database"
+	"github.com/aditya/go-comet/internal/events"
+	"github.com/aditya/go-comet/internal/geocoding"
 	"github.com/aditya/go-comet/internal/handler"
+	"github.com/aditya/go-comet/internal/insurance"
 	"github.com/aditya/go-comet/internal/middleware"
-	"github.com/aditya/go-comet/internal/repository"
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/moderation"
+	"github.com/aditya/go-comet/internal/psp"
+	"github.com/aditya/go-comet/internal/push"
 	"github.com/aditya/go-comet/internal/service"
+	"github.com/aditya/go-comet/internal/statemachine"
+	"github.com/aditya/go-comet/internal/storage"
+	"github.com/aditya/go-comet/internal/worker"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/cors"
 	"github.com/newrelic/go-agent/v3/newrelic"
 )
 
 func main() {
+	demo := flag.Bool("demo", false, "keep all data in memory instead of Postgres and Redis")
+	flag.Parse()
+
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
@@ -51,6 +67,13 @@ func main() {
 		}
 	}
 
+	// Dependencies may still be starting (e.g. alongside this container), so wait
+	// for them before giving up
+	retry := database.RetryPolicy{
+		Attempts:       cfg.StartupRetryAttempts,
+		InitialBackoff: time.Duration(cfg.StartupRetryBackoffSeconds) * time.Second,
+	}
+
 	// Initialize PostgreSQL
 	db, err := database.NewPostgres(
 		cfg.DatabaseURL,
@@ -58,56 +81,414 @@ func main() {
 		cfg.DBMaxIdleConnections,
 	)
 	if err != nil {
-		log.Fatalf("Failed to connect to PostgreSQL: %v", err)
+		log.Fatalf("Failed to configure PostgreSQL: %v", err)
 	}
 	defer db.Close()
-	log.Println("Connected to PostgreSQL")
 
 	// Initialize Redis
-	redis, err := database.NewRedis(cfg.RedisURL, cfg.RedisPassword)
-	if err != nil {
-		log.Fatalf("Failed to connect to Redis: %v", err)
-	}
+	redis := database.NewRedis(cfg.RedisURL, cfg.RedisPassword)
 	defer redis.Close()
-	log.Println("Connected to Redis")
 
 	// Initialize cache
-	driverCache := cache.NewDriverLocationCache(redis.Client)
+	var driverCache cache.DriverLocationCache
+	if *demo {
+		// Neither connection is opened; Redis-backed middleware runs degraded
+		log.Println("Demo mode: data is kept in memory and lost on restart")
+		driverCache = cache.NewMemoryDriverLocationCache()
+	} else {
+		if err := database.WaitFor(context.Background(), "PostgreSQL", retry, db.Health); err != nil {
+			if !cfg.StartDegraded {
+				log.Fatalf("Failed to connect to PostgreSQL: %v", err)
+			}
+			log.Printf("Warning: PostgreSQL unavailable, starting degraded: %v", err)
+		} else {
+			log.Println("Connected to PostgreSQL")
+		}
+		if err := database.WaitFor(context.Background(), "Redis", retry, redis.Health); err != nil {
+			if !cfg.StartDegraded {
+				log.Fatalf("Failed to connect to Redis: %v", err)
+			}
+			log.Printf("Warning: Redis unavailable, starting degraded: %v", err)
+		} else {
+			log.Println("Connected to Redis")
+		}
+		driverCache = cache.NewDriverLocationCache(redis.Client)
+	}
+
+	// Initialize object storage (optional)
+	objectStore, err := storage.NewS3Store(storage.S3Config{
+		Endpoint:  cfg.StorageEndpoint,
+		Region:    cfg.StorageRegion,
+		Bucket:    cfg.StorageBucket,
+		AccessKey: cfg.StorageAccessKey,
+		SecretKey: cfg.StorageSecretKey,
+		PathStyle: cfg.StoragePathStyle,
+	})
+	if err != nil {
+		log.Printf("Warning: object storage unavailable, uploads disabled: %v", err)
+		objectStore = nil
+	}
+
+	// Count every status change; payments also go to the log since the audit
+	// trigger only covers rides, trips and offers
+	for _, m := range []*statemachine.Machine{models.RideStates, models.TripStates, models.OfferStates, models.PaymentStates} {
+		m.OnTransition(statemachine.CountTransitions)
+	}
+	models.PaymentStates.OnTransition(statemachine.LogTransitions)
+
+	// Ride, trip and payment status changes stream to Kafka for analytics and
+	// fraud detection, or to Redis pub/sub without it
+	var eventStream *events.Stream
+	if cfg.KafkaRESTURL != "" {
+		eventStream = events.NewStream(events.NewKafkaPublisher(cfg.KafkaRESTURL, cfg.KafkaTopicPrefix), cfg.EventsBufferSize)
+	} else if !*demo {
+		eventStream = events.NewStream(events.NewRedisPublisher(redis.Client), cfg.EventsBufferSize)
+	}
+	if eventStream != nil {
+		for _, m := range []*statemachine.Machine{models.RideStates, models.TripStates, models.PaymentStates} {
+			m.OnTransition(eventStream.OnTransition)
+		}
+	}
 
 	// Initialize repositories
-	userRepo := repository.NewUserRepository(db.DB)
-	driverRepo := repository.NewDriverRepository(db.DB)
-	rideRepo := repository.NewRideRepository(db.DB)
-	tripRepo := repository.NewTripRepository(db.DB)
-	paymentRepo := repository.NewPaymentRepository(db.DB)
-	offerRepo := repository.NewRideOfferRepository(db.DB)
+	repos := newSQLRepositories(db.DB)
+	if *demo {
+		repos = newMemoryRepositories()
+	}
 
 	// Initialize services
-	pricingService := service.NewPricingService()
-	rideService := service.NewRideService(rideRepo, userRepo, driverRepo, pricingService, driverCache)
-	driverService := service.NewDriverService(db.DB, driverRepo, rideRepo, tripRepo, offerRepo, userRepo, driverCache)
-	tripService := service.NewTripService(tripRepo, rideRepo, driverRepo, pricingService, driverCache)
-	paymentService := service.NewPaymentService(paymentRepo, tripRepo)
-	matchingService := service.NewMatchingService(driverRepo, rideRepo, offerRepo, driverCache)
+	vehicleTypeService := service.NewVehicleTypeService(repos.vehicleType)
+	pricingService := service.NewPricingService(vehicleTypeService)
+	pricingCalendarService := service.NewPricingCalendarService(repos.pricingEvent)
+	deliveryService := service.NewDeliveryService(repos.delivery, repos.ride, repos.upload)
+	tenantService := service.NewTenantService(repos.tenant)
+	regionService := service.NewRegionService(repos.region, repos.tenant)
+	matchingExclusionService := service.NewMatchingExclusionService(repos.matchingExclusion, repos.driver)
+	var faceMatcher service.FaceMatcher
+	if cfg.FaceMatchURL != "" {
+		faceMatcher = service.NewHTTPFaceMatcher(cfg.FaceMatchURL, cfg.FaceMatchAPIKey)
+	}
+	selfieCheckService := service.NewSelfieCheckService(repos.selfieCheck, repos.upload, objectStore, faceMatcher, cfg.FaceMatchThreshold)
+	var geocoder geocoding.Provider
+	if cfg.GeocoderURL != "" {
+		geocoder = geocoding.NewNominatimProvider(cfg.GeocoderURL, cfg.GeocoderUserAgent)
+	}
+	bidPolicy := models.BidPolicy{
+		MinFareRatio:    cfg.BidMinFareRatio,
+		MaxCounterRatio: cfg.BidMaxCounterRatio,
+		OfferTimeout:    time.Duration(cfg.BidOfferTimeoutSeconds) * time.Second,
+		CounterTTL:      time.Duration(cfg.BidCounterTTLSeconds) * time.Second,
+		Window:          time.Duration(cfg.BidWindowSeconds) * time.Second,
+	}
+	var wordLists map[string][]string
+	if cfg.ModerationWordListsDir != "" {
+		wordLists, err = moderation.LoadWordLists(cfg.ModerationWordListsDir)
+		if err != nil {
+			log.Printf("Warning: failed to load moderation word lists, using defaults: %v", err)
+		}
+	}
+	contentFilter := moderation.NewFilter(wordLists)
+	var gateway psp.Gateway
+	if cfg.PSPURL != "" {
+		// Tenants with their own PSP account are charged through it
+		gateway = service.NewTenantGateway(tenantService, psp.NewHTTPGateway(cfg.PSPURL, cfg.PSPAPIKey))
+	}
+	paymentHoldService := service.NewPaymentHoldService(repos.paymentHold, gateway, cfg.PreauthBufferPercent,
+		time.Duration(cfg.PreauthMaxAgeHours)*time.Hour)
+	walletService := service.NewWalletService(repos.wallet, repos.user)
+	maintenanceService := service.NewMaintenanceService(repos.maintenance, time.Duration(cfg.MaintenanceWarningHours)*time.Hour)
+	riskService := service.NewRiskService(repos.risk, repos.user, models.RiskPolicy{
+		NewAccountAge:       time.Duration(cfg.RiskNewAccountDays) * 24 * time.Hour,
+		HighValueFare:       cfg.RiskHighValueFare,
+		NewAccountFareLimit: cfg.RiskNewAccountFareLimit,
+	})
+	var snapper geocoding.RoadSnapper
+	if cfg.RoadSnapURL != "" {
+		snapper = geocoding.NewOSRMSnapper(cfg.RoadSnapURL)
+	}
+	pickupService := service.NewPickupService(repos.pickupSpot, repos.venue, snapper)
+	router := service.NewRouter(cfg.RouterProvider, cfg.RouterURL, cfg.GoogleMapsAPIKey, pricingService)
+	consentService := service.NewConsentService(repos.consent, repos.user, repos.driver)
+	schedulePolicy := models.SchedulePolicy{
+		MinNotice: time.Duration(cfg.ScheduledRideMinNoticeMinutes) * time.Minute,
+		MaxAhead:  time.Duration(cfg.ScheduledRideMaxDays) * 24 * time.Hour,
+		Lead:      time.Duration(cfg.ScheduledRideLeadMinutes) * time.Minute,
+	}
+	chainService := service.NewTripChainService(repos.transactor, repos.ride, repos.offer, driverCache)
+	promoService := service.NewPromoService(repos.promo, pricingService)
+	rolloutService := service.NewRolloutService(repos.rollout, regionService)
+	surgeService := service.NewSurgeService(driverCache, pricingService, vehicleTypeService,
+		time.Duration(cfg.SurgeDemandWindowSeconds)*time.Second, cfg.SurgeGeohashPrecision)
+	cancellationFeeService := service.NewCancellationFeeService(repos.cancellationFee, repos.offer, regionService,
+		vehicleTypeService, time.Duration(cfg.FreeCancellationWindowSeconds)*time.Second)
+	rideService := service.NewRideService(repos.ride, repos.user, repos.driver, pricingService, pricingCalendarService, regionService,
+		driverCache, geocoder,
+		chainService, bidPolicy, models.DistanceLimits{MinKm: cfg.MinRideDistanceKm, MaxKm: cfg.MaxRideDistanceKm}, contentFilter,
+		paymentHoldService, riskService, pickupService, deliveryService, walletService,
+		maintenanceService, schedulePolicy, cancellationFeeService, promoService, rolloutService, surgeService, router, consentService,
+		vehicleTypeService)
+	repricingService := service.NewRepricingService(repos.fareAdjustment, pricingService, models.RepricingPolicy{
+		Action:             cfg.RepriceSurgeAction,
+		MinETAIncreaseMins: cfg.RepriceMinETAIncreaseMins,
+		SurgeReduction:     cfg.RepriceSurgeReduction,
+	})
+	reconciliationService := service.NewReconciliationService(repos.driver, repos.ride, repos.driverCacheRepair, driverCache,
+		vehicleTypeService)
+	driverService := service.NewDriverService(repos.transactor, repos.driver, repos.ride, repos.trip, repos.offer, repos.user, driverCache,
+		regionService, selfieCheckService, repricingService, reconciliationService, router, consentService,
+		vehicleTypeService, eventStream)
+	var insurer insurance.Insurer
+	if cfg.InsurerURL != "" {
+		insurer = insurance.NewHTTPInsurer(cfg.InsurerName, cfg.InsurerURL, cfg.InsurerAPIKey)
+	}
+	insuranceService := service.NewInsuranceService(repos.insurance, repos.trip, repos.ride, insurer)
+	ratingService := service.NewRatingService(repos.rating, repos.trip, repos.user, repos.driver, contentFilter, cfg.RatingWindow,
+		models.DriverRatingPolicy{
+			MinRatedTrips:    cfg.DriverRatingMinTrips,
+			OutlierThreshold: cfg.DriverRatingOutlierThreshold,
+			OutlierWeight:    cfg.DriverRatingOutlierWeight,
+		}, cfg.RateTripDeepLink)
+	var pusher push.Sender
+	if cfg.PushURL != "" {
+		pusher = push.NewHTTPSender(cfg.PushURL, cfg.PushAPIKey)
+	}
+	commissionService := service.NewCommissionService(repos.commission, repos.driver, cfg.PlatformCommissionPercent)
+	deductionService := service.NewDeductionService(repos.deduction, repos.driver)
+	tripExportService := service.NewTripExportService(repos.tripExport, repos.driver, commissionService, objectStore,
+		time.Duration(cfg.TripExportURLTTLSeconds)*time.Second)
+	// Every ride status change publishes to trackers and webhooks and pushes the rider
+	rideEventService := service.NewRideEventService(repos.ride, repos.trip, repos.driver, driverCache, pusher,
+		cfg.RideEventsWebhookURL, cfg.RateTripDeepLink)
+	models.RideStates.OnTransition(rideEventService.OnTransition)
+	models.RideStates.OnTransition(service.ReleaseDriverReservations(driverCache))
+	earningsService := service.NewEarningsService(repos.driver, repos.trip, repos.payment, repos.deduction, repos.payout,
+		driverCache, pusher, commissionService, cfg.MinPayoutAmount)
+	incentiveService := service.NewIncentiveService(repos.incentive, repos.training, commissionService)
+	cooldownService := service.NewCooldownService(repos.driver, repos.trip, driverCache, models.CooldownPolicy{
+		MaxTrips:   cfg.CooldownMaxTrips,
+		MaxDriving: time.Duration(cfg.CooldownMaxHours * float64(time.Hour)),
+		BreakGap:   time.Duration(cfg.CooldownBreakMinutes) * time.Minute,
+		Cooldown:   time.Duration(cfg.CooldownMinutes) * time.Minute,
+	})
+	tripService := service.NewTripService(repos.trip, repos.ride, repos.driver, repos.upload, repos.segment, pricingService,
+		pricingCalendarService, regionService, driverCache, chainService, earningsService, incentiveService, cooldownService, deliveryService,
+		promoService,
+		models.MileageTolerance{Km: cfg.MileageToleranceKm, Percent: cfg.MileageTolerancePercent}, router, vehicleTypeService)
+	rideEconomicsService := service.NewRideEconomicsService(repos.rideEconomics)
+	paymentService := service.NewPaymentService(repos.payment, repos.trip, commissionService, paymentHoldService,
+		rideEconomicsService, earningsService, walletService, cancellationFeeService, cfg.CarbonOffsetPerKg)
+	receiptService := service.NewReceiptService(repos.trip, repos.ride, repos.payment, repos.insurance, repos.segment, repos.driver, repos.delivery)
+	matchingService := service.NewMatchingService(repos.driver, repos.ride, repos.offer, repos.dispatchRound, repos.favorite, repos.user, repos.training,
+		regionService, matchingExclusionService, driverCache, cfg.FavoriteDriverBoost, cfg.EVRangeReserveKm, bidPolicy, models.ChainPolicy{
+			Window:         time.Duration(cfg.ChainWindowMinutes) * time.Minute,
+			PickupRadiusKm: cfg.ChainPickupRadiusKm,
+		}, models.OfferTimeoutPolicy{
+			Base:              time.Duration(cfg.OfferTimeoutSeconds) * time.Second,
+			Min:               time.Duration(cfg.OfferTimeoutMinSeconds) * time.Second,
+			Max:               time.Duration(cfg.OfferTimeoutMaxSeconds) * time.Second,
+			NightStartHour:    cfg.OfferNightStartHour,
+			NightEndHour:      cfg.OfferNightEndHour,
+			NightExtra:        time.Duration(cfg.OfferNightExtraSeconds) * time.Second,
+			LowDensityDrivers: cfg.OfferLowDensityDrivers,
+			LowDensityExtra:   time.Duration(cfg.OfferLowDensityExtraSeconds) * time.Second,
+			SurgeThreshold:    cfg.OfferSurgeThreshold,
+			SurgeReduction:    time.Duration(cfg.OfferSurgeReductionSeconds) * time.Second,
+		}, models.DispatchPolicy{
+			Strategy:   cfg.DispatchStrategy,
+			RadiiKm:    append([]float64{cfg.MatchingRadiusKM}, cfg.MatchingRadiusStepsKM...),
+			MaxRetries: cfg.MaxMatchingRetries,
+			RetryDelay: time.Duration(cfg.MatchingRetryDelaySeconds) * time.Second,
+		}, cfg.ResponseTimeBoost)
+	bidService := service.NewBidService(repos.transactor, repos.ride, repos.offer, repos.driver, repos.user, driverCache, reconciliationService, bidPolicy)
+	adminService := service.NewAdminService(repos.audit, repos.ride, repos.trip, repos.driver)
+	clientConfigService := service.NewClientConfigService(regionService, maintenanceService, vehicleTypeService, models.ClientDefaults{
+		Features: map[string]bool{
+			models.FeatureBidMode:         true,
+			models.FeatureFavoriteDrivers: true,
+			models.FeatureTripChaining:    cfg.ChainWindowMinutes > 0,
+			models.FeatureAddressPickup:   geocoder != nil,
+			models.FeatureCarbonOffset:    cfg.CarbonOffsetPerKg > 0,
+			models.FeatureTripInsurance:   insurer != nil,
+			models.FeatureSelfieCheck:     false,
+			models.FeatureSafetyGender:    false,
+		},
+		LocationUpdateSeconds:      cfg.ClientLocationUpdateSeconds,
+		OfferPollSeconds:           cfg.ClientOfferPollSeconds,
+		RideStatusPollSeconds:      cfg.ClientRideStatusPollSeconds,
+		MapTileURL:                 cfg.MapTileURL,
+		MapTileKey:                 cfg.MapTileKey,
+		FreeCancellationWindowSecs: cfg.FreeCancellationWindowSeconds,
+	})
+	handoverService := service.NewHandoverService(repos.transactor, repos.trip, repos.ride, repos.driver, repos.segment, pricingService, driverCache)
+	uploadService := service.NewUploadService(repos.upload, repos.user, repos.driver, repos.ride, objectStore,
+		time.Duration(cfg.UploadURLTTLSeconds)*time.Second)
+	favoriteService := service.NewFavoriteService(repos.favorite, repos.user, repos.trip)
+	sessionService := service.NewSessionService(repos.session, repos.user, repos.driver)
+	presenceService := service.NewRiderPresenceService(repos.ride, repos.offer, driverCache,
+		time.Duration(cfg.RiderHeartbeatTimeoutSeconds)*time.Second)
+	heatService := service.NewHeatService(repos.heat, cfg.HeatCellDegrees,
+		time.Duration(cfg.HeatSnapshotIntervalSeconds)*time.Second, time.Duration(cfg.HeatRetentionDays)*24*time.Hour)
+	rideJanitorService := service.NewRideJanitorService(repos.ride, repos.offer,
+		time.Duration(cfg.StuckRideTimeoutSeconds)*time.Second)
+	navigationService := service.NewNavigationService(repos.ride, driverCache, cfg.ArrivalRadiusMeters)
+	productService := service.NewProductService(regionService, pricingService, repos.training, driverCache, cfg.MatchingRadiusKM,
+		rolloutService, surgeService, vehicleTypeService)
+	waitingScreenService := service.NewWaitingScreenService(repos.waitingContent, repos.ride, repos.promo, rideService, regionService)
+	trainingService := service.NewTrainingService(repos.training, repos.driver)
+	fareVarianceService := service.NewFareVarianceService(repos.trip, regionService, models.FareVariancePolicy{
+		Window:           time.Duration(cfg.FareVarianceWindowHours) * time.Hour,
+		ThresholdPercent: cfg.FareVarianceThresholdPercent,
+		MinTrips:         cfg.FareVarianceMinTrips,
+	})
+	var notifier alerting.Notifier
+	if cfg.AlertWebhookURL != "" {
+		notifier = alerting.NewWebhookNotifier(cfg.AlertWebhookURL)
+	}
+	slaService := service.NewSLAService(repos.offer, regionService, notifier, models.SLAPolicy{
+		Defaults: models.SLOTargets{
+			MatchSeconds:           cfg.SLOMatchSeconds,
+			OfferAcceptanceSeconds: cfg.SLOOfferAcceptanceSeconds,
+			PickupSeconds:          cfg.SLOPickupSeconds,
+			CompliancePercent:      cfg.SLOCompliancePercent,
+		},
+		Window:    time.Duration(cfg.SLAWindowMinutes) * time.Minute,
+		MinEvents: cfg.SLAMinEvents,
+	})
+	matchingFunnelService := service.NewMatchingFunnelService(repos.dispatchRound)
+	scheduledRideService := service.NewScheduledRideService(repos.ride, repos.user, paymentHoldService, matchingService,
+		surgeService, schedulePolicy.Lead)
+	backupService := service.NewBackupService(repos.backup, objectStore, time.Duration(cfg.BackupURLTTLSeconds)*time.Second)
+	retentionService := service.NewRetentionService(repos.retention, regionService, cfg.DataRetentionDays,
+		cfg.DataRetentionBatchSize)
+
+	// Background workers
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	runner := worker.NewRunner()
+	runner.UseLeases(repos.workerLease, cfg.InstanceID, time.Duration(cfg.WorkerLeaseGraceSeconds)*time.Second)
+	runner.Register("driver-reconciliation", time.Duration(cfg.ReconcileIntervalSeconds)*time.Second, func(ctx context.Context) error {
+		_, err := reconciliationService.ReconcileDrivers(ctx)
+		return err
+	})
+	runner.Register("rider-presence", time.Duration(cfg.RiderPresenceIntervalSeconds)*time.Second, func(ctx context.Context) error {
+		_, err := presenceService.CancelUnreachableRides(ctx)
+		return err
+	})
+	runner.Register("bid-expiry", time.Duration(cfg.BidExpiryIntervalSeconds)*time.Second, func(ctx context.Context) error {
+		_, err := bidService.ExpireStaleBids(ctx)
+		return err
+	})
+	runner.Register("driver-deductions", time.Duration(cfg.DeductionIntervalSeconds)*time.Second, func(ctx context.Context) error {
+		_, err := deductionService.ChargeDueDeductions(ctx)
+		return err
+	})
+	runner.Register("payment-holds", time.Duration(cfg.PaymentHoldIntervalSeconds)*time.Second, func(ctx context.Context) error {
+		_, err := paymentHoldService.ReleaseStale(ctx)
+		return err
+	})
+	runner.Register("fare-variance", time.Duration(cfg.FareVarianceIntervalSeconds)*time.Second, func(ctx context.Context) error {
+		_, err := fareVarianceService.Check(ctx)
+		return err
+	})
+	runner.Register("sla-monitor", time.Duration(cfg.SLAIntervalSeconds)*time.Second, func(ctx context.Context) error {
+		_, err := slaService.Check(ctx)
+		return err
+	})
+	runner.Register("trip-exports", time.Duration(cfg.TripExportIntervalSeconds)*time.Second, func(ctx context.Context) error {
+		_, err := tripExportService.ProcessPending(ctx)
+		return err
+	})
+	runner.Register("driver-cache-repairs", time.Duration(cfg.CacheRepairIntervalSeconds)*time.Second, func(ctx context.Context) error {
+		_, err := reconciliationService.ProcessRepairs(ctx)
+		return err
+	})
+	runner.Register("stuck-rides", time.Duration(cfg.StuckRideIntervalSeconds)*time.Second, func(ctx context.Context) error {
+		_, err := rideJanitorService.CancelStuckRides(ctx)
+		return err
+	})
+	runner.Register("heat-snapshots", time.Duration(cfg.HeatSnapshotIntervalSeconds)*time.Second, func(ctx context.Context) error {
+		_, err := heatService.Capture(ctx)
+		return err
+	})
+	runner.Register("matching-escalation", time.Duration(cfg.MatchingEscalationSeconds)*time.Second, func(ctx context.Context) error {
+		_, err := matchingService.EscalateUnmatched(ctx)
+		return err
+	})
+	runner.Register("scheduled-rides", time.Duration(cfg.ScheduledRideIntervalSeconds)*time.Second, func(ctx context.Context) error {
+		_, err := scheduledRideService.StartDue(ctx)
+		return err
+	})
+	runner.Register("data-retention", time.Duration(cfg.DataRetentionIntervalSeconds)*time.Second, func(ctx context.Context) error {
+		_, err := retentionService.Run(ctx, cfg.DataRetentionDryRun)
+		return err
+	})
+	runner.Register("insurance-policies", time.Duration(cfg.InsuranceBackfillSeconds)*time.Second, func(ctx context.Context) error {
+		_, err := insuranceService.AttachMissingPolicies(ctx)
+		return err
+	})
+	runner.Register("backups", time.Duration(cfg.BackupIntervalSeconds)*time.Second, func(ctx context.Context) error {
+		_, err := backupService.ProcessPending(ctx)
+		return err
+	})
+	runner.Start(workerCtx)
+
+	// Dispatch rounds and policy issuing started by requests; shutdown waits for them
+	tasks := worker.NewTasks()
+	lc := &lifecycle{
+		runner:      runner,
+		stopWorkers: stopWorkers,
+		tasks:       tasks,
+		delay:       time.Duration(cfg.DrainDelaySeconds) * time.Second,
+		timeout:     time.Duration(cfg.ShutdownTimeoutSeconds) * time.Second,
+	}
 
 	// Initialize handlers
-	userHandler := handler.NewUserHandler(userRepo)
-	rideHandler := handler.NewRideHandler(rideService, matchingService)
-	driverHandler := handler.NewDriverHandler(driverService, matchingService)
-	tripHandler := handler.NewTripHandler(tripService)
+	userHandler := handler.NewUserHandler(repos.user, consentService)
+	rideHandler := handler.NewRideHandler(rideService, matchingService, presenceService, scheduledRideService, tasks)
+	driverHandler := handler.NewDriverHandler(driverService, matchingService, earningsService, tasks)
+	tripHandler := handler.NewTripHandler(tripService, receiptService, insuranceService, ratingService, tasks)
 	paymentHandler := handler.NewPaymentHandler(paymentService)
-	sseHandler := handler.NewSSEHandler(rideRepo, driverCache, redis.Client)
+	driverSocketHandler := handler.NewDriverSocketHandler(driverService, matchingService, redis.Client)
+	sseHandler := handler.NewSSEHandler(repos.ride, rideService, tripService, presenceService, driverCache, redis.Client,
+		time.Duration(cfg.LiveMeterIntervalSeconds)*time.Second)
+	adminHandler := handler.NewAdminHandler(adminService, regionService, handoverService, commissionService, deductionService,
+		incentiveService, riskService, trainingService, pickupService, fareVarianceService, slaService,
+		matchingFunnelService, rideEconomicsService, pricingCalendarService, tenantService, matchingExclusionService,
+		maintenanceService, matchingService, promoService, rolloutService, waitingScreenService, ratingService, consentService,
+		retentionService, backupService, vehicleTypeService, rideService)
+	uploadHandler := handler.NewUploadHandler(uploadService)
+	bidHandler := handler.NewBidHandler(bidService)
+	configHandler := handler.NewConfigHandler(clientConfigService)
+	favoriteHandler := handler.NewFavoriteHandler(favoriteService)
+	sessionHandler := handler.NewSessionHandler(sessionService)
+	navigationHandler := handler.NewNavigationHandler(navigationService)
+	earningsHandler := handler.NewEarningsHandler(earningsService, deductionService, tripExportService)
+	productHandler := handler.NewProductHandler(productService)
+	waitingScreenHandler := handler.NewWaitingScreenHandler(waitingScreenService)
+	consentHandler := handler.NewConsentHandler(consentService)
+	surgeHandler := handler.NewSurgeHandler(surgeService)
+	trainingHandler := handler.NewTrainingHandler(trainingService)
+	pickupHandler := handler.NewPickupHandler(pickupService)
+	heatHandler := handler.NewHeatHandler(heatService)
+	deliveryHandler := handler.NewDeliveryHandler(deliveryService)
+	walletHandler := handler.NewWalletHandler(walletService)
+	promoHandler := handler.NewPromoHandler(promoService)
 
 	// Create router
 	r := chi.NewRouter()
 
 	// Apply middleware
+	trustedProxies, err := middleware.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+
 	r.Use(middleware.Recovery)
+	r.Use(middleware.ResolveClientIP(trustedProxies))
 	r.Use(middleware.Logger)
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"*"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "Idempotency-Key"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "Idempotency-Key", "Prefer", middleware.AdminKeyHeader, middleware.TenantKeyHeader, middleware.UserIDHeader, middleware.DriverIDHeader, middleware.AppPlatformHeader, middleware.AppVersionHeader},
 		ExposedHeaders:   []string{"Link", "X-RateLimit-Limit", "X-RateLimit-Remaining"},
 		AllowCredentials: true,
 		MaxAge:           300,
@@ -118,12 +499,50 @@ func main() {
 		r.Use(middleware.NewRelicMiddleware(nrApp))
 	}
 
-	// Rate limiter (100 requests per minute per IP)
-	rateLimiter := middleware.NewRateLimiter(redis.Client, 100, time.Minute)
+	// Resolve the calling user/driver
+	r.Use(middleware.Authenticate(middleware.SessionPrincipalResolver{Sessions: sessionService}, middleware.HeaderPrincipalResolver{}))
+
+	// Rate limiter (per session principal, otherwise per client IP)
+	rateLimiter := middleware.NewRateLimiter(redis.Client, cfg.RateLimitAnonymous, time.Minute).
+		WithPrincipalBudget(middleware.PrincipalUser, cfg.RateLimitUser).
+		WithPrincipalBudget(middleware.PrincipalDriver, cfg.RateLimitDriver).
+		WithRule(middleware.RateLimitRule{
+			Name:          "driver_location",
+			PrincipalType: middleware.PrincipalDriver,
+			Method:        http.MethodPost,
+			Path:          regexp.MustCompile(`^/v1/drivers/[^/]+/location$`),
+			Requests:      cfg.RateLimitDriverLocation,
+		}).
+		WithRule(middleware.RateLimitRule{
+			Name:          "ride_creation",
+			PrincipalType: middleware.PrincipalUser,
+			Method:        http.MethodPost,
+			Path:          regexp.MustCompile(`^/v1/rides$`),
+			Requests:      cfg.RateLimitRideCreation,
+		})
 	r.Use(rateLimiter.Handler)
 
+	// Reject oversized bodies before anything reads them
+	bodyLimiter := middleware.NewBodyLimiter(int64(cfg.MaxBodyBytes)).
+		WithRule(middleware.BodyLimitRule{
+			Path:     regexp.MustCompile(`^/v1/admin/`),
+			MaxBytes: int64(cfg.MaxAdminBodyBytes),
+		})
+	r.Use(bodyLimiter.Handler)
+
+	// Turn away app releases below the supported minimum. Outdated apps can still
+	// read config and state, which carry the upgrade flag, and take a driver offline.
+	appVersionGate := middleware.NewAppVersionGate(map[string]string{
+		models.PlatformAndroid: cfg.MinAppVersionAndroid,
+		models.PlatformIOS:     cfg.MinAppVersionIOS,
+	}).
+		WithExempt(middleware.AppVersionRule{Method: http.MethodGet, Path: regexp.MustCompile(`^/v1/config/client$`)}).
+		WithExempt(middleware.AppVersionRule{Method: http.MethodGet, Path: regexp.MustCompile(`^/v1/drivers/[^/]+$`)}).
+		WithExempt(middleware.AppVersionRule{Method: http.MethodPost, Path: regexp.MustCompile(`^/v1/drivers/[^/]+/offline$`)})
+	r.Use(appVersionGate.Handler)
+
 	// Idempotency middleware
-	idempotencyMw := middleware.NewIdempotencyMiddleware(redis.Client)
+	idempotencyMw := middleware.NewIdempotencyMiddleware(redis.Client, repos.idempotency)
 	r.Use(idempotencyMw.Handler)
 
 	// Serve frontend
@@ -131,9 +550,15 @@ func main() {
 		http.ServeFile(w, r, "frontend/index.html")
 	})
 
-	// Health check
+	// Health check. Without Redis the API keeps serving in degraded mode (database
+	// matching, per-instance rate limits, idempotency keys in Postgres).
 	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
+		if *demo {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"status":"ok","mode":"demo"}`))
+			return
+		}
 
 		// Check DB health
 		if err := db.Health(ctx); err != nil {
@@ -142,25 +567,90 @@ func main() {
 		}
 
 		// Check Redis health
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
 		if err := redis.Health(ctx); err != nil {
-			http.Error(w, "redis unhealthy", http.StatusServiceUnavailable)
+			w.Write([]byte(`{"status":"degraded","services":{"database":"up","redis":"down"}}`))
 			return
 		}
+		w.Write([]byte(`{"status":"ok","services":{"database":"up","redis":"up"}}`))
+	})
+
+	// Readiness: only take traffic once every dependency is reachable, and not
+	// while draining for shutdown
+	r.Get("/ready", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if lc.Draining() {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			json.NewEncoder(w).Encode(map[string]interface{}{"status": "draining"})
+			return
+		}
+		if *demo {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]interface{}{"status": "ready", "mode": "demo"})
+			return
+		}
+		status := map[string]string{"database": "up", "redis": "up"}
+		ready := true
+		if err := db.Health(ctx); err != nil {
+			status["database"], ready = "down", false
+		}
+		if err := redis.Health(ctx); err != nil {
+			status["redis"], ready = "down", false
+		}
 
 		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(`{"status":"ok","services":{"database":"up","redis":"up"}}`))
+		if !ready {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			json.NewEncoder(w).Encode(map[string]interface{}{"status": "not_ready", "services": status})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"status": "ready", "services": status})
 	})
 
+	// Pre-stop hook for rolling deploys (requires X-Admin-Key)
+	r.With(middleware.AdminAuth(cfg.AdminAPIKey)).Get("/prestop", lc.handlePreStop)
+	r.With(middleware.AdminAuth(cfg.AdminAPIKey)).Post("/prestop", lc.handlePreStop)
+
 	// API v1 routes
 	r.Route("/v1", func(r chi.Router) {
-		// Register all handlers
-		userHandler.RegisterRoutes(r)
-		rideHandler.RegisterRoutes(r)
-		driverHandler.RegisterRoutes(r)
-		tripHandler.RegisterRoutes(r)
-		paymentHandler.RegisterRoutes(r)
-		sseHandler.RegisterRoutes(r)
+		// App routes are scoped to the brand resolved from the API key or host
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.ResolveTenant(tenantService))
+
+			// Register all handlers
+			userHandler.RegisterRoutes(r)
+			rideHandler.RegisterRoutes(r)
+			driverHandler.RegisterRoutes(r)
+			driverSocketHandler.RegisterRoutes(r)
+			tripHandler.RegisterRoutes(r)
+			paymentHandler.RegisterRoutes(r)
+			sseHandler.RegisterRoutes(r)
+			uploadHandler.RegisterRoutes(r)
+			favoriteHandler.RegisterRoutes(r)
+			sessionHandler.RegisterRoutes(r)
+			bidHandler.RegisterRoutes(r)
+			configHandler.RegisterRoutes(r)
+			navigationHandler.RegisterRoutes(r)
+			earningsHandler.RegisterRoutes(r)
+			productHandler.RegisterRoutes(r)
+			waitingScreenHandler.RegisterRoutes(r)
+			consentHandler.RegisterRoutes(r)
+			surgeHandler.RegisterRoutes(r)
+			trainingHandler.RegisterRoutes(r)
+			pickupHandler.RegisterRoutes(r)
+			heatHandler.RegisterRoutes(r)
+			deliveryHandler.RegisterRoutes(r)
+			walletHandler.RegisterRoutes(r)
+			promoHandler.RegisterRoutes(r)
+		})
+
+		// Admin routes (require X-Admin-Key) see every tenant
+		r.Route("/admin", func(r chi.Router) {
+			r.Use(middleware.AdminAuth(cfg.AdminAPIKey))
+			adminHandler.RegisterRoutes(r)
+		})
 	})
 
 	// Create server
@@ -172,19 +662,34 @@ func main() {
 		IdleTimeout:  60 * time.Second,
 	}
 
-	// Graceful shutdown
+	// Streams never go idle, so shutdown ends them for clients to reconnect elsewhere
+	srv.RegisterOnShutdown(sseHandler.Shutdown)
+	srv.RegisterOnShutdown(driverSocketHandler.Shutdown)
+
+	// Graceful shutdown: drain (unless the pre-stop hook already has), then let
+	// open requests finish
+	shutdownDone := make(chan struct{})
 	go func() {
+		defer close(shutdownDone)
 		sigChan := make(chan os.Signal, 1)
 		signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 		<-sigChan
 
 		log.Println("Shutting down server...")
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		lc.Drain()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.ShutdownTimeoutSeconds)*time.Second)
 		defer cancel()
 
 		if err := srv.Shutdown(ctx); err != nil {
 			log.Printf("Server shutdown error: %v", err)
 		}
+		// Requests have finished, so no more events are coming
+		if eventStream != nil {
+			if err := eventStream.Close(ctx); err != nil {
+				log.Printf("Gave up publishing queued events: %v", err)
+			}
+		}
 	}()
 
 	// Start server
@@ -193,12 +698,28 @@ func main() {
 	log.Println("  POST /v1/users          - Create user")
 	log.Println("  POST /v1/drivers        - Create driver")
 	log.Println("  POST /v1/rides          - Create ride")
+	log.Println("  POST /v1/rides/estimate - Fare estimate")
 	log.Println("  GET  /v1/rides/{id}     - Get ride")
 	log.Println("  POST /v1/drivers/{id}/location - Update location")
 	log.Println("  POST /v1/drivers/{id}/accept   - Accept ride")
+	log.Println("  POST /v1/drivers/{id}/cancel   - Give up an assigned ride for reassignment")
+	log.Println("  POST /v1/drivers/{id}/navigation - Report progress to pickup")
+	log.Println("  PUT  /v1/drivers/{id}/earnings-goal - Set daily earnings goal")
+	log.Println("  GET  /v1/drivers/{id}/earnings/statement - Earnings statement")
 	log.Println("  POST /v1/trips/{id}/end        - End trip")
+	log.Println("  POST /v1/trips/{id}/odometer   - Attach odometer photo")
+	log.Println("  GET  /v1/rides/{id}/bids       - Counter-offers on a bid ride")
+	log.Println("  GET  /v1/config/client         - Client app configuration")
+	log.Println("  GET  /v1/products              - Ride products available at a location")
+	log.Println("  GET  /v1/pickup-suggestions    - Recommended pickup points near the rider")
+	log.Println("  GET  /v1/drivers/{id}/training - Training modules completed and pending")
 	log.Println("  POST /v1/payments              - Process payment")
 	log.Println("  GET  /v1/rides/{id}/track      - SSE live tracking")
+	log.Println("  POST /v1/uploads               - Get a signed upload URL")
+	log.Println("  POST /v1/drivers/{id}/selfie   - Selfie check-in")
+	log.Println("  GET  /v1/admin/rides           - Search rides")
+	log.Println("  GET  /v1/admin/rides/{id}/replay - Ride state at a point in time")
+	log.Println("  GET  /v1/admin/metrics         - Runtime metrics")
 	log.Println("")
 	log.Println("Frontend: http://localhost:" + cfg.Port)
 
@@ -206,5 +727,7 @@ func main() {
 		log.Fatalf("Server error: %v", err)
 	}
 
+	// ListenAndServe returns as soon as shutdown starts; wait for it to finish
+	<-shutdownDone
 	log.Println("Server stopped gracefully")
 }
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"github.com/aditya/go-comet/internal/repository"
+	"github.com/aditya/go-comet/internal/repository/memory"
+	"github.com/jmoiron/sqlx"
+)
+
+// repositories is every store the services read and write, backed by Postgres
+// or, in demo mode, by memory
+type repositories struct {
+	transactor        repository.Transactor
+	user              repository.UserRepository
+	driver            repository.DriverRepository
+	ride              repository.RideRepository
+	trip              repository.TripRepository
+	payment           repository.PaymentRepository
+	offer             repository.RideOfferRepository
+	idempotency       repository.IdempotencyRepository
+	audit             repository.AuditRepository
+	region            repository.RegionRepository
+	upload            repository.UploadRepository
+	selfieCheck       repository.SelfieCheckRepository
+	favorite          repository.FavoriteRepository
+	insurance         repository.InsuranceRepository
+	segment           repository.TripSegmentRepository
+	commission        repository.CommissionRepository
+	deduction         repository.DeductionRepository
+	payout            repository.PayoutRepository
+	wallet            repository.WalletRepository
+	maintenance       repository.MaintenanceRepository
+	incentive         repository.IncentiveRepository
+	paymentHold       repository.PaymentHoldRepository
+	risk              repository.RiskRepository
+	matchingExclusion repository.MatchingExclusionRepository
+	fareAdjustment    repository.FareAdjustmentRepository
+	training          repository.TrainingRepository
+	pickupSpot        repository.PickupSpotRepository
+	venue             repository.VenueRepository
+	tenant            repository.TenantRepository
+	tripExport        repository.TripExportRepository
+	driverCacheRepair repository.DriverCacheRepairRepository
+	delivery          repository.DeliveryRepository
+	dispatchRound     repository.DispatchRoundRepository
+	heat              repository.HeatRepository
+	rideEconomics     repository.RideEconomicsRepository
+	pricingEvent      repository.PricingEventRepository
+	session           repository.SessionRepository
+	rating            repository.RatingRepository
+	cancellationFee   repository.CancellationFeeRepository
+	promo             repository.PromoRepository
+	rollout           repository.RolloutRepository
+	waitingContent    repository.WaitingContentRepository
+	consent           repository.ConsentRepository
+	retention         repository.RetentionRepository
+	backup            repository.BackupRepository
+	workerLease       repository.WorkerLeaseRepository
+	vehicleType       repository.VehicleTypeRepository
+}
+
+func newSQLRepositories(db *sqlx.DB) *repositories {
+	return &repositories{
+		transactor:        repository.NewTransactor(db),
+		user:              repository.NewUserRepository(db),
+		driver:            repository.NewDriverRepository(db),
+		ride:              repository.NewRideRepository(db),
+		trip:              repository.NewTripRepository(db),
+		payment:           repository.NewPaymentRepository(db),
+		offer:             repository.NewRideOfferRepository(db),
+		idempotency:       repository.NewIdempotencyRepository(db),
+		audit:             repository.NewAuditRepository(db),
+		region:            repository.NewRegionRepository(db),
+		upload:            repository.NewUploadRepository(db),
+		selfieCheck:       repository.NewSelfieCheckRepository(db),
+		favorite:          repository.NewFavoriteRepository(db),
+		insurance:         repository.NewInsuranceRepository(db),
+		segment:           repository.NewTripSegmentRepository(db),
+		commission:        repository.NewCommissionRepository(db),
+		deduction:         repository.NewDeductionRepository(db),
+		payout:            repository.NewPayoutRepository(db),
+		wallet:            repository.NewWalletRepository(db),
+		maintenance:       repository.NewMaintenanceRepository(db),
+		incentive:         repository.NewIncentiveRepository(db),
+		paymentHold:       repository.NewPaymentHoldRepository(db),
+		risk:              repository.NewRiskRepository(db),
+		matchingExclusion: repository.NewMatchingExclusionRepository(db),
+		fareAdjustment:    repository.NewFareAdjustmentRepository(db),
+		training:          repository.NewTrainingRepository(db),
+		pickupSpot:        repository.NewPickupSpotRepository(db),
+		venue:             repository.NewVenueRepository(db),
+		tenant:            repository.NewTenantRepository(db),
+		tripExport:        repository.NewTripExportRepository(db),
+		driverCacheRepair: repository.NewDriverCacheRepairRepository(db),
+		delivery:          repository.NewDeliveryRepository(db),
+		dispatchRound:     repository.NewDispatchRoundRepository(db),
+		heat:              repository.NewHeatRepository(db),
+		rideEconomics:     repository.NewRideEconomicsRepository(db),
+		pricingEvent:      repository.NewPricingEventRepository(db),
+		session:           repository.NewSessionRepository(db),
+		rating:            repository.NewRatingRepository(db),
+		cancellationFee:   repository.NewCancellationFeeRepository(db),
+		promo:             repository.NewPromoRepository(db),
+		rollout:           repository.NewRolloutRepository(db),
+		waitingContent:    repository.NewWaitingContentRepository(db),
+		consent:           repository.NewConsentRepository(db),
+		retention:         repository.NewRetentionRepository(db),
+		backup:            repository.NewBackupRepository(db),
+		workerLease:       repository.NewWorkerLeaseRepository(db),
+		vehicleType:       repository.NewVehicleTypeRepository(db),
+	}
+}
+
+// newMemoryRepositories starts from the rows the migrations seed
+func newMemoryRepositories() *repositories {
+	store := memory.NewStore()
+	store.Seed()
+	return &repositories{
+		transactor:        memory.NewTransactor(store),
+		user:              memory.NewUserRepository(store),
+		driver:            memory.NewDriverRepository(store),
+		ride:              memory.NewRideRepository(store),
+		trip:              memory.NewTripRepository(store),
+		payment:           memory.NewPaymentRepository(store),
+		offer:             memory.NewRideOfferRepository(store),
+		idempotency:       memory.NewIdempotencyRepository(store),
+		audit:             memory.NewAuditRepository(store),
+		region:            memory.NewRegionRepository(store),
+		upload:            memory.NewUploadRepository(store),
+		selfieCheck:       memory.NewSelfieCheckRepository(store),
+		favorite:          memory.NewFavoriteRepository(store),
+		insurance:         memory.NewInsuranceRepository(store),
+		segment:           memory.NewTripSegmentRepository(store),
+		commission:        memory.NewCommissionRepository(store),
+		deduction:         memory.NewDeductionRepository(store),
+		payout:            memory.NewPayoutRepository(store),
+		wallet:            memory.NewWalletRepository(store),
+		maintenance:       memory.NewMaintenanceRepository(store),
+		incentive:         memory.NewIncentiveRepository(store),
+		paymentHold:       memory.NewPaymentHoldRepository(store),
+		risk:              memory.NewRiskRepository(store),
+		matchingExclusion: memory.NewMatchingExclusionRepository(store),
+		fareAdjustment:    memory.NewFareAdjustmentRepository(store),
+		training:          memory.NewTrainingRepository(store),
+		pickupSpot:        memory.NewPickupSpotRepository(store),
+		venue:             memory.NewVenueRepository(store),
+		tenant:            memory.NewTenantRepository(store),
+		tripExport:        memory.NewTripExportRepository(store),
+		driverCacheRepair: memory.NewDriverCacheRepairRepository(store),
+		delivery:          memory.NewDeliveryRepository(store),
+		dispatchRound:     memory.NewDispatchRoundRepository(store),
+		heat:              memory.NewHeatRepository(store),
+		rideEconomics:     memory.NewRideEconomicsRepository(store),
+		pricingEvent:      memory.NewPricingEventRepository(store),
+		session:           memory.NewSessionRepository(store),
+		rating:            memory.NewRatingRepository(store),
+		cancellationFee:   memory.NewCancellationFeeRepository(store),
+		promo:             memory.NewPromoRepository(store),
+		rollout:           memory.NewRolloutRepository(store),
+		waitingContent:    memory.NewWaitingContentRepository(store),
+		consent:           memory.NewConsentRepository(store),
+		retention:         memory.NewRetentionRepository(store),
+		backup:            memory.NewBackupRepository(store),
+		workerLease:       memory.NewWorkerLeaseRepository(store),
+		vehicleType:       memory.NewVehicleTypeRepository(store),
+	}
+}
@@ -0,0 +1,15 @@
+package alerting
+
+import "context"
+
+// Alert is an operational condition that needs someone's attention
+type Alert struct {
+	Name    string            `json:"name"`
+	Summary string            `json:"summary"`
+	Labels  map[string]string `json:"labels,omitempty"`
+}
+
+// Notifier hands alerts to whatever pages the on-call team
+type Notifier interface {
+	Notify(ctx context.Context, alert *Alert) error
+}
@@ -0,0 +1,49 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aditya/go-comet/internal/httpclient"
+)
+
+// WebhookNotifier posts each alert as JSON to a webhook URL
+type WebhookNotifier struct {
+	url    string
+	client *httpclient.Client
+}
+
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{
+		url:    url,
+		client: httpclient.New(httpclient.Config{Name: "alert-webhook", Timeout: 5 * time.Second}),
+	}
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, alert *Alert) error {
+	payload, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("alerting: webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
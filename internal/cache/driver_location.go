@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -15,9 +16,28 @@ const (
 	driverMetaKeyPrefix     = "driver:meta:"
 	driverActiveRideKey     = "driver:active:"
 	userActiveRideKey       = "user:active:"
+	driverTripDistanceKey   = "driver:trip_km:"
+	tripDistanceTTL         = 12 * time.Hour
+	matchTimesKeyPrefix     = "match:times:"
+	matchTimesSamples       = 50
+	matchTimesTTL           = time.Hour
+	riderSeenKeyPrefix      = "ride:rider_seen:"
+	riderSeenTTL            = time.Hour
+	goalMilestonesKeyPrefix = "driver:goal_milestones:"
+	goalMilestonesTTL       = 48 * time.Hour
+	driverCooldownKeyPrefix = "driver:cooldown:"
+	driverReservedKeyPrefix = "driver:reserved:"
+	rideReservationsPrefix  = "ride:reservations:"
+	demandKeyPrefix         = "demand:"
 	locationTTL             = 5 * time.Minute
 )
 
+// RideStatusChannel carries ride status changes to every API instance
+const RideStatusChannel = "ride:status:updates"
+
+// DriverOfferChannel carries the IDs of drivers who were just sent a ride offer
+const DriverOfferChannel = "driver:offer:updates"
+
 type DriverLocation struct {
 	Lat       float64 `json:"lat"`
 	Lng       float64 `json:"lng"`
@@ -32,14 +52,41 @@ type DriverLocationCache interface {
 	GetDriverLocation(ctx context.Context, driverID string) (*DriverLocation, error)
 	GetNearbyDrivers(ctx context.Context, lat, lng, radiusKm float64, vehicleType string) ([]DriverWithDistance, error)
 	RemoveDriver(ctx context.Context, driverID, vehicleType string) error
+	GetIndexedDrivers(ctx context.Context, vehicleType string) ([]string, error)
 	SetDriverMeta(ctx context.Context, driverID, status, vehicleType string, rating float64) error
+	SetDriverStatus(ctx context.Context, driverID, status string, at time.Time) (bool, error)
 	GetDriverMeta(ctx context.Context, driverID string) (map[string]string, error)
 	SetActiveRide(ctx context.Context, driverID, rideID string) error
 	GetActiveRide(ctx context.Context, driverID string) (string, error)
 	ClearActiveRide(ctx context.Context, driverID string) error
+	// GetActiveRideDrivers returns every driver with an active-ride marker
+	GetActiveRideDrivers(ctx context.Context) ([]string, error)
 	SetUserActiveRide(ctx context.Context, userID, rideID string) error
 	GetUserActiveRide(ctx context.Context, userID string) (string, error)
 	ClearUserActiveRide(ctx context.Context, userID string) error
+	StartTripDistance(ctx context.Context, driverID string) error
+	AddTripDistance(ctx context.Context, driverID string, km float64) error
+	GetTripDistance(ctx context.Context, driverID string) (float64, error)
+	ClearTripDistance(ctx context.Context, driverID string) error
+	SetEVRange(ctx context.Context, driverID string, rangeKm float64, batteryPercent *float64) error
+	RecordMatchTime(ctx context.Context, vehicleType string, d time.Duration) error
+	GetRecentMatchTimes(ctx context.Context, vehicleType string) ([]time.Duration, error)
+	TouchRiderHeartbeat(ctx context.Context, rideID string) error
+	GetRiderHeartbeat(ctx context.Context, rideID string) (time.Time, error)
+	MarkGoalMilestone(ctx context.Context, driverID, day string, percent int) (bool, error)
+	SetCooldown(ctx context.Context, driverID string, until time.Time) error
+	InCooldown(ctx context.Context, driverID string) (bool, error)
+	PublishRideStatus(ctx context.Context, payload []byte) error
+	PublishDriverOffer(ctx context.Context, driverID string) error
+	ReserveDriver(ctx context.Context, driverID, rideID string, ttl time.Duration) (bool, error)
+	GetReservation(ctx context.Context, driverID string) (string, error)
+	ReleaseDriver(ctx context.Context, driverID, rideID string) error
+	ReleaseRideReservations(ctx context.Context, rideID string) error
+	// RecordDemand counts a ride request in a geohash cell, forgetting requests
+	// older than the window
+	RecordDemand(ctx context.Context, vehicleType, cell, rideID string, at time.Time, window time.Duration) error
+	// CountDemand counts requests in the cells since the given time
+	CountDemand(ctx context.Context, vehicleType string, cells []string, since time.Time) (int, error)
 }
 
 type DriverWithDistance struct {
@@ -160,15 +207,68 @@ func (c *driverLocationCache) RemoveDriver(ctx context.Context, driverID, vehicl
 	return c.redis.ZRem(ctx, geoKey, driverID).Err()
 }
 
+// GetIndexedDrivers returns every driver ID currently present in the geo index for a vehicle type
+func (c *driverLocationCache) GetIndexedDrivers(ctx context.Context, vehicleType string) ([]string, error) {
+	geoKey := driverLocationKeyPrefix + vehicleType
+	return c.redis.ZRange(ctx, geoKey, 0, -1).Result()
+}
+
 func (c *driverLocationCache) SetDriverMeta(ctx context.Context, driverID, status, vehicleType string, rating float64) error {
 	metaKey := driverMetaKeyPrefix + driverID
 	return c.redis.HSet(ctx, metaKey, map[string]interface{}{
 		"status":       status,
+		"status_at":    time.Now().UnixNano(),
 		"vehicle_type": vehicleType,
 		"rating":       fmt.Sprintf("%.1f", rating),
 	}).Err()
 }
 
+// setStatusScript writes a driver's status only when it is at least as recent as
+// the cached one, so a delayed write can't undo a later transition
+var setStatusScript = redis.NewScript(`
+local current = tonumber(redis.call('HGET', KEYS[1], 'status_at') or '0')
+if tonumber(ARGV[2]) < current then
+	return 0
+end
+redis.call('HSET', KEYS[1], 'status', ARGV[1], 'status_at', ARGV[2])
+return 1
+`)
+
+// SetDriverStatus mirrors a status change written to Postgres at the given time.
+// It returns false when the cache already holds a later status.
+func (c *driverLocationCache) SetDriverStatus(ctx context.Context, driverID, status string, at time.Time) (bool, error) {
+	n, err := setStatusScript.Run(ctx, c.redis, []string{driverMetaKeyPrefix + driverID}, status, at.UnixNano()).Int()
+	return n == 1, err
+}
+
+// SetEVRange records an EV's remaining range in the driver meta hash so matching
+// can read it alongside status and rating
+func (c *driverLocationCache) SetEVRange(ctx context.Context, driverID string, rangeKm float64, batteryPercent *float64) error {
+	metaKey := driverMetaKeyPrefix + driverID
+	fields := map[string]interface{}{
+		"ev_range_km":   fmt.Sprintf("%.1f", rangeKm),
+		"ev_updated_at": time.Now().Unix(),
+	}
+	if batteryPercent != nil {
+		fields["ev_battery_pct"] = fmt.Sprintf("%.0f", *batteryPercent)
+	}
+	return c.redis.HSet(ctx, metaKey, fields).Err()
+}
+
+// ParseEVRange reads the EV range from driver meta; ok is false when the driver
+// has not reported one within maxAge
+func ParseEVRange(meta map[string]string, maxAge time.Duration) (rangeKm float64, ok bool) {
+	updatedAt, err := strconv.ParseInt(meta["ev_updated_at"], 10, 64)
+	if err != nil || time.Since(time.Unix(updatedAt, 0)) > maxAge {
+		return 0, false
+	}
+	rangeKm, err = strconv.ParseFloat(meta["ev_range_km"], 64)
+	if err != nil {
+		return 0, false
+	}
+	return rangeKm, true
+}
+
 func (c *driverLocationCache) GetDriverMeta(ctx context.Context, driverID string) (map[string]string, error) {
 	metaKey := driverMetaKeyPrefix + driverID
 	return c.redis.HGetAll(ctx, metaKey).Result()
@@ -193,6 +293,15 @@ func (c *driverLocationCache) ClearActiveRide(ctx context.Context, driverID stri
 	return c.redis.Del(ctx, key).Err()
 }
 
+func (c *driverLocationCache) GetActiveRideDrivers(ctx context.Context) ([]string, error) {
+	var ids []string
+	iter := c.redis.Scan(ctx, 0, driverActiveRideKey+"*", 500).Iterator()
+	for iter.Next(ctx) {
+		ids = append(ids, strings.TrimPrefix(iter.Val(), driverActiveRideKey))
+	}
+	return ids, iter.Err()
+}
+
 func (c *driverLocationCache) SetUserActiveRide(ctx context.Context, userID, rideID string) error {
 	key := userActiveRideKey + userID
 	return c.redis.Set(ctx, key, rideID, time.Hour).Err()
@@ -212,6 +321,180 @@ func (c *driverLocationCache) ClearUserActiveRide(ctx context.Context, userID st
 	return c.redis.Del(ctx, key).Err()
 }
 
+// StartTripDistance starts accumulating GPS distance for the driver's current trip
+func (c *driverLocationCache) StartTripDistance(ctx context.Context, driverID string) error {
+	key := driverTripDistanceKey + driverID
+	return c.redis.Set(ctx, key, 0, tripDistanceTTL).Err()
+}
+
+// AddTripDistance adds to the trip distance if a trip is being tracked for the driver
+func (c *driverLocationCache) AddTripDistance(ctx context.Context, driverID string, km float64) error {
+	key := driverTripDistanceKey + driverID
+	exists, err := c.redis.Exists(ctx, key).Result()
+	if err != nil || exists == 0 {
+		return err
+	}
+	return c.redis.IncrByFloat(ctx, key, km).Err()
+}
+
+func (c *driverLocationCache) GetTripDistance(ctx context.Context, driverID string) (float64, error) {
+	key := driverTripDistanceKey + driverID
+	result, err := c.redis.Get(ctx, key).Float64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return result, err
+}
+
+func (c *driverLocationCache) ClearTripDistance(ctx context.Context, driverID string) error {
+	key := driverTripDistanceKey + driverID
+	return c.redis.Del(ctx, key).Err()
+}
+
+// RecordMatchTime keeps a rolling window of how long recent rides of a vehicle type took to match.
+// The key expires when no rides match for a while so stale samples don't linger.
+func (c *driverLocationCache) RecordMatchTime(ctx context.Context, vehicleType string, d time.Duration) error {
+	key := matchTimesKeyPrefix + vehicleType
+	pipe := c.redis.Pipeline()
+	pipe.LPush(ctx, key, int64(d.Seconds()))
+	pipe.LTrim(ctx, key, 0, matchTimesSamples-1)
+	pipe.Expire(ctx, key, matchTimesTTL)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (c *driverLocationCache) GetRecentMatchTimes(ctx context.Context, vehicleType string) ([]time.Duration, error) {
+	key := matchTimesKeyPrefix + vehicleType
+	values, err := c.redis.LRange(ctx, key, 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	times := make([]time.Duration, 0, len(values))
+	for _, v := range values {
+		secs, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			continue
+		}
+		times = append(times, time.Duration(secs)*time.Second)
+	}
+	return times, nil
+}
+
+// TouchRiderHeartbeat records that the rider's app was just seen polling or streaming the ride
+func (c *driverLocationCache) TouchRiderHeartbeat(ctx context.Context, rideID string) error {
+	return c.redis.Set(ctx, riderSeenKeyPrefix+rideID, time.Now().Unix(), riderSeenTTL).Err()
+}
+
+// GetRiderHeartbeat returns when the rider was last seen, or the zero time if never
+func (c *driverLocationCache) GetRiderHeartbeat(ctx context.Context, rideID string) (time.Time, error) {
+	secs, err := c.redis.Get(ctx, riderSeenKeyPrefix+rideID).Int64()
+	if err == redis.Nil {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(secs, 0), nil
+}
+
+// MarkGoalMilestone records that the driver reached an earnings goal milestone on
+// the given day, returning false if it was already recorded
+func (c *driverLocationCache) MarkGoalMilestone(ctx context.Context, driverID, day string, percent int) (bool, error) {
+	key := goalMilestonesKeyPrefix + driverID + ":" + day
+	pipe := c.redis.Pipeline()
+	added := pipe.SAdd(ctx, key, percent)
+	pipe.Expire(ctx, key, goalMilestonesTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, err
+	}
+	return added.Val() > 0, nil
+}
+
+// SetCooldown keeps the driver out of matching until the given time
+func (c *driverLocationCache) SetCooldown(ctx context.Context, driverID string, until time.Time) error {
+	ttl := time.Until(until)
+	if ttl <= 0 {
+		return nil
+	}
+	return c.redis.Set(ctx, driverCooldownKeyPrefix+driverID, until.Unix(), ttl).Err()
+}
+
+func (c *driverLocationCache) InCooldown(ctx context.Context, driverID string) (bool, error) {
+	n, err := c.redis.Exists(ctx, driverCooldownKeyPrefix+driverID).Result()
+	return n > 0, err
+}
+
+func (c *driverLocationCache) PublishRideStatus(ctx context.Context, payload []byte) error {
+	return c.redis.Publish(ctx, RideStatusChannel, payload).Err()
+}
+
+func (c *driverLocationCache) PublishDriverOffer(ctx context.Context, driverID string) error {
+	return c.redis.Publish(ctx, DriverOfferChannel, driverID).Err()
+}
+
+// reserveScript holds a driver for a ride unless another ride already holds them,
+// and tracks the driver under the ride so the ride can release everyone at once
+var reserveScript = redis.NewScript(`
+local holder = redis.call('GET', KEYS[1])
+if holder and holder ~= ARGV[1] then
+	return 0
+end
+redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+redis.call('SADD', KEYS[2], ARGV[3])
+redis.call('PEXPIRE', KEYS[2], ARGV[2])
+return 1
+`)
+
+// releaseScript frees a driver only if the given ride still holds them
+var releaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	redis.call('DEL', KEYS[1])
+end
+redis.call('SREM', KEYS[2], ARGV[2])
+return 1
+`)
+
+// releaseRideScript frees every driver still held for the ride
+var releaseRideScript = redis.NewScript(`
+local drivers = redis.call('SMEMBERS', KEYS[1])
+for _, driverID in ipairs(drivers) do
+	local key = ARGV[2] .. driverID
+	if redis.call('GET', key) == ARGV[1] then
+		redis.call('DEL', key)
+	end
+end
+redis.call('DEL', KEYS[1])
+return #drivers
+`)
+
+// ReserveDriver holds a driver for a ride while its offer is open, so matching
+// doesn't offer them other rides. It returns false when another ride holds them.
+func (c *driverLocationCache) ReserveDriver(ctx context.Context, driverID, rideID string, ttl time.Duration) (bool, error) {
+	keys := []string{driverReservedKeyPrefix + driverID, rideReservationsPrefix + rideID}
+	n, err := reserveScript.Run(ctx, c.redis, keys, rideID, ttl.Milliseconds(), driverID).Int()
+	return n == 1, err
+}
+
+// GetReservation returns the ride holding the driver, or "" when none does
+func (c *driverLocationCache) GetReservation(ctx context.Context, driverID string) (string, error) {
+	rideID, err := c.redis.Get(ctx, driverReservedKeyPrefix+driverID).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return rideID, err
+}
+
+func (c *driverLocationCache) ReleaseDriver(ctx context.Context, driverID, rideID string) error {
+	keys := []string{driverReservedKeyPrefix + driverID, rideReservationsPrefix + rideID}
+	return releaseScript.Run(ctx, c.redis, keys, rideID, driverID).Err()
+}
+
+func (c *driverLocationCache) ReleaseRideReservations(ctx context.Context, rideID string) error {
+	keys := []string{rideReservationsPrefix + rideID}
+	return releaseRideScript.Run(ctx, c.redis, keys, rideID, driverReservedKeyPrefix).Err()
+}
+
 // ParseRating parses rating string to float64
 func ParseRating(ratingStr string) float64 {
 	if ratingStr == "" {
@@ -223,3 +506,35 @@ func ParseRating(ratingStr string) float64 {
 	}
 	return rating
 }
+
+func demandKey(vehicleType, cell string) string {
+	return demandKeyPrefix + vehicleType + ":" + cell
+}
+
+func (c *driverLocationCache) RecordDemand(ctx context.Context, vehicleType, cell, rideID string, at time.Time, window time.Duration) error {
+	key := demandKey(vehicleType, cell)
+	pipe := c.redis.TxPipeline()
+	pipe.ZAdd(ctx, key, redis.Z{Score: float64(at.UnixMilli()), Member: rideID})
+	pipe.ZRemRangeByScore(ctx, key, "-inf", "("+strconv.FormatInt(at.Add(-window).UnixMilli(), 10))
+	pipe.Expire(ctx, key, window)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (c *driverLocationCache) CountDemand(ctx context.Context, vehicleType string, cells []string, since time.Time) (int, error) {
+	min := strconv.FormatInt(since.UnixMilli(), 10)
+	pipe := c.redis.Pipeline()
+	counts := make([]*redis.IntCmd, 0, len(cells))
+	for _, cell := range cells {
+		counts = append(counts, pipe.ZCount(ctx, demandKey(vehicleType, cell), min, "+inf"))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return 0, err
+	}
+
+	total := 0
+	for _, cmd := range counts {
+		total += int(cmd.Val())
+	}
+	return total, nil
+}
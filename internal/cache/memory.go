@@ -0,0 +1,461 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// memoryEntry is a value with the expiry its Redis key would have; a zero
+// expiresAt never expires
+type memoryEntry struct {
+	value     string
+	expiresAt time.Time
+}
+
+func (e memoryEntry) live(now time.Time) bool {
+	return e.expiresAt.IsZero() || now.Before(e.expiresAt)
+}
+
+// memoryDriverLocationCache keeps the driver cache in process for --demo. It
+// serves a single instance only: publishes reach no subscribers.
+type memoryDriverLocationCache struct {
+	mu           sync.Mutex
+	locations    map[string]DriverLocation
+	geo          map[string]map[string]bool // vehicle type -> driver IDs
+	meta         map[string]map[string]string
+	values       map[string]memoryEntry
+	tripKm       map[string]float64
+	matchTimes   map[string][]time.Duration
+	milestones   map[string]bool
+	reservations map[string]map[string]bool      // ride ID -> driver IDs
+	demand       map[string]map[string]time.Time // demand key -> ride ID -> requested at
+}
+
+func NewMemoryDriverLocationCache() DriverLocationCache {
+	return &memoryDriverLocationCache{
+		locations:    make(map[string]DriverLocation),
+		geo:          make(map[string]map[string]bool),
+		meta:         make(map[string]map[string]string),
+		values:       make(map[string]memoryEntry),
+		tripKm:       make(map[string]float64),
+		matchTimes:   make(map[string][]time.Duration),
+		milestones:   make(map[string]bool),
+		reservations: make(map[string]map[string]bool),
+		demand:       make(map[string]map[string]time.Time),
+	}
+}
+
+func (c *memoryDriverLocationCache) UpdateLocation(ctx context.Context, driverID string, lat, lng float64, heading, speed, accuracy *float64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	vehicleType := c.meta[driverID]["vehicle_type"]
+	if vehicleType == "" {
+		vehicleType = "sedan" // default
+	}
+	if c.geo[vehicleType] == nil {
+		c.geo[vehicleType] = make(map[string]bool)
+	}
+	c.geo[vehicleType][driverID] = true
+
+	loc := DriverLocation{
+		Lat:       lat,
+		Lng:       lng,
+		UpdatedAt: time.Now().Unix(),
+	}
+	if heading != nil {
+		loc.Heading = *heading
+	}
+	if speed != nil {
+		loc.Speed = *speed
+	}
+	if accuracy != nil {
+		loc.Accuracy = *accuracy
+	}
+	c.locations[driverID] = loc
+	return nil
+}
+
+func (c *memoryDriverLocationCache) GetDriverLocation(ctx context.Context, driverID string) (*DriverLocation, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	loc, ok := c.locations[driverID]
+	if !ok || time.Since(time.Unix(loc.UpdatedAt, 0)) > locationTTL {
+		return nil, nil
+	}
+	return &loc, nil
+}
+
+func (c *memoryDriverLocationCache) GetNearbyDrivers(ctx context.Context, lat, lng, radiusKm float64, vehicleType string) ([]DriverWithDistance, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// The geo index keeps positions after the detailed location expires, as in Redis
+	var result []DriverWithDistance
+	for driverID := range c.geo[vehicleType] {
+		loc, ok := c.locations[driverID]
+		if !ok || c.meta[driverID]["status"] != "online" {
+			continue
+		}
+		if d := distanceKm(lat, lng, loc.Lat, loc.Lng); d <= radiusKm {
+			result = append(result, DriverWithDistance{DriverID: driverID, Distance: d})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Distance < result[j].Distance })
+	if len(result) > 50 {
+		result = result[:50]
+	}
+	return result, nil
+}
+
+func (c *memoryDriverLocationCache) RemoveDriver(ctx context.Context, driverID, vehicleType string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.geo[vehicleType], driverID)
+	return nil
+}
+
+func (c *memoryDriverLocationCache) GetIndexedDrivers(ctx context.Context, vehicleType string) ([]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ids := make([]string, 0, len(c.geo[vehicleType]))
+	for driverID := range c.geo[vehicleType] {
+		ids = append(ids, driverID)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+func (c *memoryDriverLocationCache) SetDriverMeta(ctx context.Context, driverID, status, vehicleType string, rating float64) error {
+	c.setMeta(driverID, map[string]string{
+		"status":       status,
+		"status_at":    strconv.FormatInt(time.Now().UnixNano(), 10),
+		"vehicle_type": vehicleType,
+		"rating":       fmt.Sprintf("%.1f", rating),
+	})
+	return nil
+}
+
+func (c *memoryDriverLocationCache) SetDriverStatus(ctx context.Context, driverID, status string, at time.Time) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	current, _ := strconv.ParseInt(c.meta[driverID]["status_at"], 10, 64)
+	if at.UnixNano() < current {
+		return false, nil
+	}
+	if c.meta[driverID] == nil {
+		c.meta[driverID] = make(map[string]string)
+	}
+	c.meta[driverID]["status"] = status
+	c.meta[driverID]["status_at"] = strconv.FormatInt(at.UnixNano(), 10)
+	return true, nil
+}
+
+func (c *memoryDriverLocationCache) SetEVRange(ctx context.Context, driverID string, rangeKm float64, batteryPercent *float64) error {
+	fields := map[string]string{
+		"ev_range_km":   fmt.Sprintf("%.1f", rangeKm),
+		"ev_updated_at": strconv.FormatInt(time.Now().Unix(), 10),
+	}
+	if batteryPercent != nil {
+		fields["ev_battery_pct"] = fmt.Sprintf("%.0f", *batteryPercent)
+	}
+	c.setMeta(driverID, fields)
+	return nil
+}
+
+func (c *memoryDriverLocationCache) GetDriverMeta(ctx context.Context, driverID string) (map[string]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	meta := make(map[string]string, len(c.meta[driverID]))
+	for k, v := range c.meta[driverID] {
+		meta[k] = v
+	}
+	return meta, nil
+}
+
+func (c *memoryDriverLocationCache) SetActiveRide(ctx context.Context, driverID, rideID string) error {
+	c.set(driverActiveRideKey+driverID, rideID, time.Hour)
+	return nil
+}
+
+func (c *memoryDriverLocationCache) GetActiveRide(ctx context.Context, driverID string) (string, error) {
+	return c.get(driverActiveRideKey + driverID), nil
+}
+
+func (c *memoryDriverLocationCache) ClearActiveRide(ctx context.Context, driverID string) error {
+	c.del(driverActiveRideKey + driverID)
+	return nil
+}
+
+func (c *memoryDriverLocationCache) GetActiveRideDrivers(ctx context.Context) ([]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	var ids []string
+	for key, entry := range c.values {
+		if driverID, ok := strings.CutPrefix(key, driverActiveRideKey); ok && entry.live(now) {
+			ids = append(ids, driverID)
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+func (c *memoryDriverLocationCache) SetUserActiveRide(ctx context.Context, userID, rideID string) error {
+	c.set(userActiveRideKey+userID, rideID, time.Hour)
+	return nil
+}
+
+func (c *memoryDriverLocationCache) GetUserActiveRide(ctx context.Context, userID string) (string, error) {
+	return c.get(userActiveRideKey + userID), nil
+}
+
+func (c *memoryDriverLocationCache) ClearUserActiveRide(ctx context.Context, userID string) error {
+	c.del(userActiveRideKey + userID)
+	return nil
+}
+
+func (c *memoryDriverLocationCache) StartTripDistance(ctx context.Context, driverID string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.tripKm[driverID] = 0
+	return nil
+}
+
+func (c *memoryDriverLocationCache) AddTripDistance(ctx context.Context, driverID string, km float64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.tripKm[driverID]; ok {
+		c.tripKm[driverID] += km
+	}
+	return nil
+}
+
+func (c *memoryDriverLocationCache) GetTripDistance(ctx context.Context, driverID string) (float64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.tripKm[driverID], nil
+}
+
+func (c *memoryDriverLocationCache) ClearTripDistance(ctx context.Context, driverID string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.tripKm, driverID)
+	return nil
+}
+
+func (c *memoryDriverLocationCache) RecordMatchTime(ctx context.Context, vehicleType string, d time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Newest first, like LPUSH
+	times := append([]time.Duration{d.Truncate(time.Second)}, c.matchTimes[vehicleType]...)
+	if len(times) > matchTimesSamples {
+		times = times[:matchTimesSamples]
+	}
+	c.matchTimes[vehicleType] = times
+	return nil
+}
+
+func (c *memoryDriverLocationCache) GetRecentMatchTimes(ctx context.Context, vehicleType string) ([]time.Duration, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]time.Duration{}, c.matchTimes[vehicleType]...), nil
+}
+
+func (c *memoryDriverLocationCache) TouchRiderHeartbeat(ctx context.Context, rideID string) error {
+	c.set(riderSeenKeyPrefix+rideID, strconv.FormatInt(time.Now().Unix(), 10), riderSeenTTL)
+	return nil
+}
+
+func (c *memoryDriverLocationCache) GetRiderHeartbeat(ctx context.Context, rideID string) (time.Time, error) {
+	secs, err := strconv.ParseInt(c.get(riderSeenKeyPrefix+rideID), 10, 64)
+	if err != nil {
+		return time.Time{}, nil
+	}
+	return time.Unix(secs, 0), nil
+}
+
+func (c *memoryDriverLocationCache) MarkGoalMilestone(ctx context.Context, driverID, day string, percent int) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := fmt.Sprintf("%s:%s:%d", driverID, day, percent)
+	if c.milestones[key] {
+		return false, nil
+	}
+	c.milestones[key] = true
+	return true, nil
+}
+
+func (c *memoryDriverLocationCache) SetCooldown(ctx context.Context, driverID string, until time.Time) error {
+	ttl := time.Until(until)
+	if ttl <= 0 {
+		return nil
+	}
+	c.set(driverCooldownKeyPrefix+driverID, strconv.FormatInt(until.Unix(), 10), ttl)
+	return nil
+}
+
+func (c *memoryDriverLocationCache) InCooldown(ctx context.Context, driverID string) (bool, error) {
+	return c.get(driverCooldownKeyPrefix+driverID) != "", nil
+}
+
+func (c *memoryDriverLocationCache) PublishRideStatus(ctx context.Context, payload []byte) error {
+	return nil
+}
+
+func (c *memoryDriverLocationCache) PublishDriverOffer(ctx context.Context, driverID string) error {
+	return nil
+}
+
+func (c *memoryDriverLocationCache) ReserveDriver(ctx context.Context, driverID, rideID string, ttl time.Duration) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := driverReservedKeyPrefix + driverID
+	now := time.Now()
+	if holder, ok := c.values[key]; ok && holder.live(now) && holder.value != rideID {
+		return false, nil
+	}
+	c.values[key] = memoryEntry{value: rideID, expiresAt: now.Add(ttl)}
+	if c.reservations[rideID] == nil {
+		c.reservations[rideID] = make(map[string]bool)
+	}
+	c.reservations[rideID][driverID] = true
+	return true, nil
+}
+
+func (c *memoryDriverLocationCache) GetReservation(ctx context.Context, driverID string) (string, error) {
+	return c.get(driverReservedKeyPrefix + driverID), nil
+}
+
+func (c *memoryDriverLocationCache) ReleaseDriver(ctx context.Context, driverID, rideID string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.release(driverID, rideID)
+	delete(c.reservations[rideID], driverID)
+	return nil
+}
+
+func (c *memoryDriverLocationCache) ReleaseRideReservations(ctx context.Context, rideID string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for driverID := range c.reservations[rideID] {
+		c.release(driverID, rideID)
+	}
+	delete(c.reservations, rideID)
+	return nil
+}
+
+// release frees the driver if the ride still holds them. The caller holds mu.
+func (c *memoryDriverLocationCache) release(driverID, rideID string) {
+	key := driverReservedKeyPrefix + driverID
+	if c.values[key].value == rideID {
+		delete(c.values, key)
+	}
+}
+
+func (c *memoryDriverLocationCache) setMeta(driverID string, fields map[string]string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.meta[driverID] == nil {
+		c.meta[driverID] = make(map[string]string)
+	}
+	for k, v := range fields {
+		c.meta[driverID][k] = v
+	}
+}
+
+func (c *memoryDriverLocationCache) set(key, value string, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.values[key] = memoryEntry{value: value, expiresAt: time.Now().Add(ttl)}
+}
+
+// get returns the key's value, or "" if it is missing or expired
+func (c *memoryDriverLocationCache) get(key string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.values[key]
+	if !ok || !entry.live(time.Now()) {
+		delete(c.values, key)
+		return ""
+	}
+	return entry.value
+}
+
+func (c *memoryDriverLocationCache) del(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.values, key)
+}
+
+// distanceKm is the haversine distance Redis GEORADIUS measures with
+func distanceKm(lat1, lng1, lat2, lng2 float64) float64 {
+	const earthRadiusKm = 6372.7976 // Redis' geo radius
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dLat := toRad(lat2 - lat1)
+	dLng := toRad(lng2 - lng1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
+
+func (c *memoryDriverLocationCache) RecordDemand(ctx context.Context, vehicleType, cell, rideID string, at time.Time, window time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := demandKey(vehicleType, cell)
+	requests := c.demand[key]
+	if requests == nil {
+		requests = make(map[string]time.Time)
+		c.demand[key] = requests
+	}
+	requests[rideID] = at
+	for id, t := range requests {
+		if t.Before(at.Add(-window)) {
+			delete(requests, id)
+		}
+	}
+	return nil
+}
+
+func (c *memoryDriverLocationCache) CountDemand(ctx context.Context, vehicleType string, cells []string, since time.Time) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	total := 0
+	for _, cell := range cells {
+		for _, t := range c.demand[demandKey(vehicleType, cell)] {
+			if !t.Before(since) {
+				total++
+			}
+		}
+	}
+	return total, nil
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -21,6 +22,12 @@ type Config struct {
 	RedisURL      string
 	RedisPassword string
 
+	// Startup waits for PostgreSQL and Redis, doubling the pause between attempts.
+	// With StartDegraded the server starts anyway and connects on first use.
+	StartupRetryAttempts       int
+	StartupRetryBackoffSeconds int
+	StartDegraded              bool
+
 	// New Relic
 	NewRelicLicenseKey string
 	NewRelicAppName    string
@@ -30,6 +37,259 @@ type Config struct {
 	MatchingRadiusKM    float64
 	OfferTimeoutSeconds int
 	MaxMatchingRetries  int
+	FavoriteDriverBoost float64
+	// Score bonus for drivers who answer offers instantly, scaled down to none
+	// for those who take the whole offer timeout
+	ResponseTimeBoost float64
+	EVRangeReserveKm  float64
+
+	// Radii searched by retries after the first round at MatchingRadiusKM; the last
+	// one is reused for any retries beyond the list
+	MatchingRadiusStepsKM     []float64
+	MatchingRetryDelaySeconds int
+	// "broadcast" offers each round to several drivers at once, "sequential" to
+	// one driver at a time
+	DispatchStrategy string
+
+	// Offer timeout rules: drivers get longer at night and in low-density areas,
+	// less during surge, within the min/max bounds
+	OfferTimeoutMinSeconds      int
+	OfferTimeoutMaxSeconds      int
+	OfferNightStartHour         int
+	OfferNightEndHour           int
+	OfferNightExtraSeconds      int
+	OfferLowDensityDrivers      int
+	OfferLowDensityExtraSeconds int
+	OfferSurgeThreshold         float64
+	OfferSurgeReductionSeconds  int
+
+	// Platform commission taken from each fare (percent)
+	PlatformCommissionPercent float64
+
+	// Smallest amount a driver can pay out of their balance
+	MinPayoutAmount float64
+
+	// Ride distance limits
+	MinRideDistanceKm float64
+	MaxRideDistanceKm float64
+
+	// Back-to-back trip limits: trips less than CooldownBreakMinutes apart form a
+	// streak, and a streak over either limit rests the driver
+	CooldownMaxTrips     int
+	CooldownMaxHours     float64
+	CooldownBreakMinutes int
+	CooldownMinutes      int
+
+	// Fare variance monitoring
+	FareVarianceWindowHours      int
+	FareVarianceThresholdPercent float64
+	FareVarianceMinTrips         int
+
+	// Matching and pickup SLOs; regions can override the targets
+	SLOMatchSeconds           int
+	SLOOfferAcceptanceSeconds int
+	SLOPickupSeconds          int
+	SLOCompliancePercent      float64
+	SLAWindowMinutes          int
+	SLAMinEvents              int
+
+	// Webhook receiving operational alerts such as SLA breaches
+	AlertWebhookURL string
+
+	// Webhook receiving every ride status change
+	RideEventsWebhookURL string
+
+	// Domain event streaming: a Kafka REST proxy, or Redis pub/sub when unset
+	KafkaRESTURL     string
+	KafkaTopicPrefix string
+	// Events queued for the broker before new ones are dropped
+	EventsBufferSize int
+
+	// App deep link to a trip's rating screen; {trip_id} is filled in
+	RateTripDeepLink string
+
+	// Trip chaining
+	ChainWindowMinutes  int
+	ChainPickupRadiusKm float64
+
+	// Drivers can only mark arrival within this many meters of the pickup (0 disables the check)
+	ArrivalRadiusMeters float64
+
+	// Bid mode
+	BidMinFareRatio        float64
+	BidMaxCounterRatio     float64
+	BidOfferTimeoutSeconds int
+	BidCounterTTLSeconds   int
+	BidWindowSeconds       int
+
+	// Rate limiting (requests per minute)
+	RateLimitAnonymous      int
+	RateLimitUser           int
+	RateLimitDriver         int
+	RateLimitDriverLocation int
+	RateLimitRideCreation   int
+	// Proxies (comma-separated CIDRs) whose X-Forwarded-For is believed when
+	// working out the caller's IP
+	TrustedProxies string
+
+	// Request body size limits (bytes); admin endpoints take larger payloads such as
+	// service area and venue polygons
+	MaxBodyBytes      int
+	MaxAdminBodyBytes int
+
+	// Oldest app release still served per platform; older apps get 426
+	// upgrade_required. Empty leaves the platform ungated.
+	MinAppVersionAndroid string
+	MinAppVersionIOS     string
+
+	// Object storage (S3-compatible)
+	StorageEndpoint     string
+	StorageRegion       string
+	StorageBucket       string
+	StorageAccessKey    string
+	StorageSecretKey    string
+	StoragePathStyle    bool
+	UploadURLTTLSeconds int
+	// How long trip export download links stay valid
+	TripExportURLTTLSeconds int
+	// How long backup manifest links stay valid
+	BackupURLTTLSeconds int
+
+	// Geocoding
+	GeocoderURL       string
+	GeocoderUserAgent string
+	// OSRM server used to snap pickup suggestions onto roads
+	RoadSnapURL string
+	// Routing provider for distances, ETAs and route polylines: "osrm" or "google".
+	// Unset estimates from straight-line distance.
+	RouterProvider string
+	// OSRM server, or an alternative Google Directions endpoint
+	RouterURL        string
+	GoogleMapsAPIKey string
+
+	// Price per kg of CO2 for optional carbon offset donations (0 disables)
+	CarbonOffsetPerKg float64
+
+	// Card pre-authorization (holds are skipped when PSPURL is unset)
+	PSPURL               string
+	PSPAPIKey            string
+	PreauthBufferPercent float64
+	PreauthMaxAgeHours   int
+
+	// Surge relief when a driver cancels and the replacement takes longer
+	RepriceSurgeAction        string
+	RepriceMinETAIncreaseMins int
+	RepriceSurgeReduction     float64
+
+	// Payment risk rules for new accounts
+	RiskNewAccountDays      int
+	RiskHighValueFare       float64
+	RiskNewAccountFareLimit float64
+
+	// Trip insurance
+	InsurerName   string
+	InsurerURL    string
+	InsurerAPIKey string
+
+	// Mileage audit
+	MileageToleranceKm      float64
+	MileageTolerancePercent float64
+
+	// Driver identity checks
+	FaceMatchURL       string
+	FaceMatchAPIKey    string
+	FaceMatchThreshold float64
+
+	// Client app configuration
+	MapTileURL                    string
+	MapTileKey                    string
+	ClientLocationUpdateSeconds   int
+	ClientOfferPollSeconds        int
+	ClientRideStatusPollSeconds   int
+	FreeCancellationWindowSeconds int
+
+	// How far ahead of a maintenance window the client config warns of it
+	MaintenanceWarningHours int
+
+	// Ratings are averaged over each account's most recent ones, so old trips age out
+	RatingWindow int
+
+	// A driver's public rating stays at the default until they have this many
+	// rated trips, and a lone 1-star among ratings averaging the threshold or
+	// more counts as a fraction of a rating
+	DriverRatingMinTrips         int
+	DriverRatingOutlierThreshold float64
+	DriverRatingOutlierWeight    float64
+
+	// Rides booked ahead must pick up between the minimum notice and the maximum
+	// days ahead, and start matching the lead time before pickup
+	ScheduledRideMinNoticeMinutes int
+	ScheduledRideMaxDays          int
+	ScheduledRideLeadMinutes      int
+
+	// Directory of per-locale blocked word lists (<locale>.txt) for notes, chat
+	// and reviews; built-in lists are used when unset
+	ModerationWordListsDir string
+
+	// Admin
+	AdminAPIKey string
+
+	// Push notifications
+	PushURL    string
+	PushAPIKey string
+
+	// Matching rides are cancelled after this long without a rider poll or stream
+	RiderHeartbeatTimeoutSeconds int
+
+	// Pending and matching rides are cancelled after this long without a status change
+	StuckRideTimeoutSeconds int
+
+	// Historical heat is kept per grid cell of this many degrees (~1.1km at 0.01)
+	HeatCellDegrees   float64
+	HeatRetentionDays int
+
+	// Finished trips are anonymized after this many days unless their region sets
+	// its own period (0 keeps them); a dry run only logs what would change
+	DataRetentionDays      int
+	DataRetentionDryRun    bool
+	DataRetentionBatchSize int
+
+	// Surge compares ride requests over this window, counted per geohash cell of
+	// this precision and its neighbours, with the drivers near the pickup
+	SurgeDemandWindowSeconds int
+	SurgeGeohashPrecision    int
+
+	// Riders tracking a trip in progress get its running fare this often
+	LiveMeterIntervalSeconds int
+
+	// Background workers
+	ReconcileIntervalSeconds     int
+	BidExpiryIntervalSeconds     int
+	RiderPresenceIntervalSeconds int
+	DeductionIntervalSeconds     int
+	PaymentHoldIntervalSeconds   int
+	FareVarianceIntervalSeconds  int
+	SLAIntervalSeconds           int
+	TripExportIntervalSeconds    int
+	StuckRideIntervalSeconds     int
+	CacheRepairIntervalSeconds   int
+	HeatSnapshotIntervalSeconds  int
+	MatchingEscalationSeconds    int
+	ScheduledRideIntervalSeconds int
+	DataRetentionIntervalSeconds int
+	BackupIntervalSeconds        int
+	InsuranceBackfillSeconds     int
+
+	// With several instances each job runs on one at a time: the holder of its
+	// lease, identified by InstanceID. A lease outlives the job's interval by
+	// WorkerLeaseGraceSeconds, so a dead holder's jobs move after that long.
+	InstanceID              string
+	WorkerLeaseGraceSeconds int
+
+	// Shutdown: readiness fails for DrainDelaySeconds before workers stop, and
+	// jobs, dispatch rounds and open requests get ShutdownTimeoutSeconds to finish
+	DrainDelaySeconds      int
+	ShutdownTimeoutSeconds int
 }
 
 func Load() (*Config, error) {
@@ -50,6 +310,10 @@ func Load() (*Config, error) {
 		RedisURL:      getEnv("REDIS_URL", "localhost:6379"),
 		RedisPassword: getEnv("REDIS_PASSWORD", ""),
 
+		StartupRetryAttempts:       getEnvAsInt("STARTUP_RETRY_ATTEMPTS", 5),
+		StartupRetryBackoffSeconds: getEnvAsInt("STARTUP_RETRY_BACKOFF_SECONDS", 1),
+		StartDegraded:              getEnvAsBool("START_DEGRADED", false),
+
 		// New Relic
 		NewRelicLicenseKey: getEnv("NEW_RELIC_LICENSE_KEY", ""),
 		NewRelicAppName:    getEnv("NEW_RELIC_APP_NAME", "gocomet-ride-hailing"),
@@ -59,9 +323,220 @@ func Load() (*Config, error) {
 		MatchingRadiusKM:    getEnvAsFloat("MATCHING_RADIUS_KM", 5.0),
 		OfferTimeoutSeconds: getEnvAsInt("OFFER_TIMEOUT_SECONDS", 15),
 		MaxMatchingRetries:  getEnvAsInt("MAX_MATCHING_RETRIES", 3),
+		FavoriteDriverBoost: getEnvAsFloat("FAVORITE_DRIVER_BOOST", 30),
+		ResponseTimeBoost:   getEnvAsFloat("RESPONSE_TIME_BOOST", 5),
+		EVRangeReserveKm:    getEnvAsFloat("EV_RANGE_RESERVE_KM", 15),
+
+		MatchingRadiusStepsKM:     getEnvAsFloatList("MATCHING_RADIUS_STEPS_KM", []float64{8, 12}),
+		MatchingRetryDelaySeconds: getEnvAsInt("MATCHING_RETRY_DELAY_SECONDS", 5),
+		DispatchStrategy:          getEnv("DISPATCH_STRATEGY", "broadcast"),
+
+		// Offer timeout rules
+		OfferTimeoutMinSeconds:      getEnvAsInt("OFFER_TIMEOUT_MIN_SECONDS", 8),
+		OfferTimeoutMaxSeconds:      getEnvAsInt("OFFER_TIMEOUT_MAX_SECONDS", 30),
+		OfferNightStartHour:         getEnvAsInt("OFFER_NIGHT_START_HOUR", 22),
+		OfferNightEndHour:           getEnvAsInt("OFFER_NIGHT_END_HOUR", 6),
+		OfferNightExtraSeconds:      getEnvAsInt("OFFER_NIGHT_EXTRA_SECONDS", 10),
+		OfferLowDensityDrivers:      getEnvAsInt("OFFER_LOW_DENSITY_DRIVERS", 3),
+		OfferLowDensityExtraSeconds: getEnvAsInt("OFFER_LOW_DENSITY_EXTRA_SECONDS", 10),
+		OfferSurgeThreshold:         getEnvAsFloat("OFFER_SURGE_THRESHOLD", 1.5),
+		OfferSurgeReductionSeconds:  getEnvAsInt("OFFER_SURGE_REDUCTION_SECONDS", 5),
+
+		// Platform commission
+		PlatformCommissionPercent: getEnvAsFloat("PLATFORM_COMMISSION_PERCENT", 20),
+
+		MinPayoutAmount: getEnvAsFloat("MIN_PAYOUT_AMOUNT", 100),
+
+		// Ride distance limits
+		MinRideDistanceKm: getEnvAsFloat("MIN_RIDE_DISTANCE_KM", 0.5),
+		MaxRideDistanceKm: getEnvAsFloat("MAX_RIDE_DISTANCE_KM", 150),
+
+		// Back-to-back trip limits
+		CooldownMaxTrips:     getEnvAsInt("COOLDOWN_MAX_TRIPS", 12),
+		CooldownMaxHours:     getEnvAsFloat("COOLDOWN_MAX_HOURS", 4),
+		CooldownBreakMinutes: getEnvAsInt("COOLDOWN_BREAK_MINUTES", 20),
+		CooldownMinutes:      getEnvAsInt("COOLDOWN_MINUTES", 30),
+
+		FareVarianceWindowHours:      getEnvAsInt("FARE_VARIANCE_WINDOW_HOURS", 24),
+		FareVarianceThresholdPercent: getEnvAsFloat("FARE_VARIANCE_THRESHOLD_PERCENT", 15),
+		FareVarianceMinTrips:         getEnvAsInt("FARE_VARIANCE_MIN_TRIPS", 20),
+
+		SLOMatchSeconds:           getEnvAsInt("SLO_MATCH_SECONDS", 60),
+		SLOOfferAcceptanceSeconds: getEnvAsInt("SLO_OFFER_ACCEPTANCE_SECONDS", 10),
+		SLOPickupSeconds:          getEnvAsInt("SLO_PICKUP_SECONDS", 600),
+		SLOCompliancePercent:      getEnvAsFloat("SLO_COMPLIANCE_PERCENT", 90),
+		SLAWindowMinutes:          getEnvAsInt("SLA_WINDOW_MINUTES", 60),
+		SLAMinEvents:              getEnvAsInt("SLA_MIN_EVENTS", 10),
+
+		AlertWebhookURL: getEnv("ALERT_WEBHOOK_URL", ""),
+
+		RideEventsWebhookURL: getEnv("RIDE_EVENTS_WEBHOOK_URL", ""),
+
+		KafkaRESTURL:     getEnv("KAFKA_REST_URL", ""),
+		KafkaTopicPrefix: getEnv("KAFKA_TOPIC_PREFIX", "comet"),
+		EventsBufferSize: getEnvAsInt("EVENTS_BUFFER_SIZE", 10000),
+
+		RateTripDeepLink: getEnv("RATE_TRIP_DEEP_LINK", "comet://trips/{trip_id}/rate"),
+
+		// Trip chaining
+		ChainWindowMinutes:  getEnvAsInt("CHAIN_WINDOW_MINUTES", 5),
+		ChainPickupRadiusKm: getEnvAsFloat("CHAIN_PICKUP_RADIUS_KM", 2.0),
+
+		ArrivalRadiusMeters: getEnvAsFloat("ARRIVAL_RADIUS_METERS", 200),
+
+		// Bid mode
+		BidMinFareRatio:        getEnvAsFloat("BID_MIN_FARE_RATIO", 0.7),
+		BidMaxCounterRatio:     getEnvAsFloat("BID_MAX_COUNTER_RATIO", 1.5),
+		BidOfferTimeoutSeconds: getEnvAsInt("BID_OFFER_TIMEOUT_SECONDS", 60),
+		BidCounterTTLSeconds:   getEnvAsInt("BID_COUNTER_TTL_SECONDS", 120),
+		BidWindowSeconds:       getEnvAsInt("BID_WINDOW_SECONDS", 300),
+
+		// Rate limiting
+		RateLimitAnonymous:      getEnvAsInt("RATE_LIMIT_ANONYMOUS", 100),
+		RateLimitUser:           getEnvAsInt("RATE_LIMIT_USER", 120),
+		RateLimitDriver:         getEnvAsInt("RATE_LIMIT_DRIVER", 120),
+		RateLimitDriverLocation: getEnvAsInt("RATE_LIMIT_DRIVER_LOCATION", 600),
+		RateLimitRideCreation:   getEnvAsInt("RATE_LIMIT_RIDE_CREATION", 10),
+		TrustedProxies:          getEnv("TRUSTED_PROXIES", "127.0.0.0/8,::1/128,10.0.0.0/8,172.16.0.0/12,192.168.0.0/16,fc00::/7"),
+
+		// Request body limits
+		MaxBodyBytes:      getEnvAsInt("MAX_BODY_BYTES", 64<<10),
+		MaxAdminBodyBytes: getEnvAsInt("MAX_ADMIN_BODY_BYTES", 1<<20),
+
+		// App version gating
+		MinAppVersionAndroid: getEnv("MIN_APP_VERSION_ANDROID", ""),
+		MinAppVersionIOS:     getEnv("MIN_APP_VERSION_IOS", ""),
+
+		// Object storage
+		StorageEndpoint:         getEnv("STORAGE_ENDPOINT", ""),
+		StorageRegion:           getEnv("STORAGE_REGION", "ap-south-1"),
+		StorageBucket:           getEnv("STORAGE_BUCKET", ""),
+		StorageAccessKey:        getEnv("STORAGE_ACCESS_KEY", ""),
+		StorageSecretKey:        getEnv("STORAGE_SECRET_KEY", ""),
+		StoragePathStyle:        getEnvAsBool("STORAGE_PATH_STYLE", false),
+		UploadURLTTLSeconds:     getEnvAsInt("UPLOAD_URL_TTL_SECONDS", 900),
+		TripExportURLTTLSeconds: getEnvAsInt("TRIP_EXPORT_URL_TTL_SECONDS", 3600),
+		BackupURLTTLSeconds:     getEnvAsInt("BACKUP_URL_TTL_SECONDS", 900),
+
+		// Geocoding
+		GeocoderURL:       getEnv("GEOCODER_URL", ""),
+		GeocoderUserAgent: getEnv("GEOCODER_USER_AGENT", "go-comet/1.0"),
+		RoadSnapURL:       getEnv("ROAD_SNAP_URL", ""),
+		RouterProvider:    getEnv("ROUTER_PROVIDER", ""),
+		RouterURL:         getEnv("ROUTER_URL", ""),
+		GoogleMapsAPIKey:  getEnv("GOOGLE_MAPS_API_KEY", ""),
+
+		CarbonOffsetPerKg: getEnvAsFloat("CARBON_OFFSET_PER_KG", 1.5),
+
+		// Card pre-authorization
+		PSPURL:               getEnv("PSP_URL", ""),
+		PSPAPIKey:            getEnv("PSP_API_KEY", ""),
+		PreauthBufferPercent: getEnvAsFloat("PREAUTH_BUFFER_PERCENT", 20),
+		PreauthMaxAgeHours:   getEnvAsInt("PREAUTH_MAX_AGE_HOURS", 72),
+
+		// Reassignment repricing
+		RepriceSurgeAction:        getEnv("REPRICE_SURGE_ACTION", ""),
+		RepriceMinETAIncreaseMins: getEnvAsInt("REPRICE_MIN_ETA_INCREASE_MINS", 5),
+		RepriceSurgeReduction:     getEnvAsFloat("REPRICE_SURGE_REDUCTION", 0.5),
+
+		// Payment risk rules
+		RiskNewAccountDays:      getEnvAsInt("RISK_NEW_ACCOUNT_DAYS", 7),
+		RiskHighValueFare:       getEnvAsFloat("RISK_HIGH_VALUE_FARE", 1000),
+		RiskNewAccountFareLimit: getEnvAsFloat("RISK_NEW_ACCOUNT_FARE_LIMIT", 2500),
+
+		// Trip insurance
+		InsurerName:   getEnv("INSURER_NAME", "insurer"),
+		InsurerURL:    getEnv("INSURER_URL", ""),
+		InsurerAPIKey: getEnv("INSURER_API_KEY", ""),
+
+		// Mileage audit
+		MileageToleranceKm:      getEnvAsFloat("MILEAGE_TOLERANCE_KM", 1.0),
+		MileageTolerancePercent: getEnvAsFloat("MILEAGE_TOLERANCE_PERCENT", 15),
+
+		// Driver identity checks
+		FaceMatchURL:       getEnv("FACE_MATCH_URL", ""),
+		FaceMatchAPIKey:    getEnv("FACE_MATCH_API_KEY", ""),
+		FaceMatchThreshold: getEnvAsFloat("FACE_MATCH_THRESHOLD", 0.8),
+
+		// Client app configuration
+		MapTileURL:                    getEnv("MAP_TILE_URL", ""),
+		MapTileKey:                    getEnv("MAP_TILE_KEY", ""),
+		ClientLocationUpdateSeconds:   getEnvAsInt("CLIENT_LOCATION_UPDATE_SECONDS", 5),
+		ClientOfferPollSeconds:        getEnvAsInt("CLIENT_OFFER_POLL_SECONDS", 3),
+		ClientRideStatusPollSeconds:   getEnvAsInt("CLIENT_RIDE_STATUS_POLL_SECONDS", 5),
+		FreeCancellationWindowSeconds: getEnvAsInt("FREE_CANCELLATION_WINDOW_SECONDS", 120),
+
+		MaintenanceWarningHours: getEnvAsInt("MAINTENANCE_WARNING_HOURS", 24),
+
+		RatingWindow: getEnvAsInt("RATING_WINDOW", 100),
+
+		DriverRatingMinTrips:         getEnvAsInt("DRIVER_RATING_MIN_TRIPS", 5),
+		DriverRatingOutlierThreshold: getEnvAsFloat("DRIVER_RATING_OUTLIER_THRESHOLD", 4.5),
+		DriverRatingOutlierWeight:    getEnvAsFloat("DRIVER_RATING_OUTLIER_WEIGHT", 0.25),
+
+		ScheduledRideMinNoticeMinutes: getEnvAsInt("SCHEDULED_RIDE_MIN_NOTICE_MINUTES", 30),
+		ScheduledRideMaxDays:          getEnvAsInt("SCHEDULED_RIDE_MAX_DAYS", 7),
+		ScheduledRideLeadMinutes:      getEnvAsInt("SCHEDULED_RIDE_LEAD_MINUTES", 15),
+
+		ModerationWordListsDir: getEnv("MODERATION_WORDLISTS_DIR", ""),
+
+		// Admin
+		AdminAPIKey: getEnv("ADMIN_API_KEY", ""),
+
+		// Push notifications
+		PushURL:    getEnv("PUSH_URL", ""),
+		PushAPIKey: getEnv("PUSH_API_KEY", ""),
+
+		RiderHeartbeatTimeoutSeconds: getEnvAsInt("RIDER_HEARTBEAT_TIMEOUT_SECONDS", 180),
+		StuckRideTimeoutSeconds:      getEnvAsInt("STUCK_RIDE_TIMEOUT_SECONDS", 600),
+
+		HeatCellDegrees:   getEnvAsFloat("HEAT_CELL_DEGREES", 0.01),
+		HeatRetentionDays: getEnvAsInt("HEAT_RETENTION_DAYS", 365),
+
+		DataRetentionDays:      getEnvAsInt("DATA_RETENTION_DAYS", 365),
+		DataRetentionDryRun:    getEnvAsBool("DATA_RETENTION_DRY_RUN", false),
+		DataRetentionBatchSize: getEnvAsInt("DATA_RETENTION_BATCH_SIZE", 500),
+
+		SurgeDemandWindowSeconds: getEnvAsInt("SURGE_DEMAND_WINDOW_SECONDS", 600),
+		SurgeGeohashPrecision:    getEnvAsInt("SURGE_GEOHASH_PRECISION", 6),
+
+		LiveMeterIntervalSeconds: getEnvAsInt("LIVE_METER_INTERVAL_SECONDS", 15),
+
+		// Background workers
+		ReconcileIntervalSeconds:     getEnvAsInt("RECONCILE_INTERVAL_SECONDS", 60),
+		BidExpiryIntervalSeconds:     getEnvAsInt("BID_EXPIRY_INTERVAL_SECONDS", 30),
+		RiderPresenceIntervalSeconds: getEnvAsInt("RIDER_PRESENCE_INTERVAL_SECONDS", 30),
+		DeductionIntervalSeconds:     getEnvAsInt("DEDUCTION_INTERVAL_SECONDS", 3600),
+		PaymentHoldIntervalSeconds:   getEnvAsInt("PAYMENT_HOLD_INTERVAL_SECONDS", 300),
+		FareVarianceIntervalSeconds:  getEnvAsInt("FARE_VARIANCE_INTERVAL_SECONDS", 3600),
+		SLAIntervalSeconds:           getEnvAsInt("SLA_INTERVAL_SECONDS", 300),
+		TripExportIntervalSeconds:    getEnvAsInt("TRIP_EXPORT_INTERVAL_SECONDS", 10),
+		StuckRideIntervalSeconds:     getEnvAsInt("STUCK_RIDE_INTERVAL_SECONDS", 60),
+		CacheRepairIntervalSeconds:   getEnvAsInt("CACHE_REPAIR_INTERVAL_SECONDS", 5),
+		HeatSnapshotIntervalSeconds:  getEnvAsInt("HEAT_SNAPSHOT_INTERVAL_SECONDS", 900),
+		MatchingEscalationSeconds:    getEnvAsInt("MATCHING_ESCALATION_INTERVAL_SECONDS", 5),
+		ScheduledRideIntervalSeconds: getEnvAsInt("SCHEDULED_RIDE_INTERVAL_SECONDS", 30),
+		DataRetentionIntervalSeconds: getEnvAsInt("DATA_RETENTION_INTERVAL_SECONDS", 86400),
+		BackupIntervalSeconds:        getEnvAsInt("BACKUP_INTERVAL_SECONDS", 30),
+		InsuranceBackfillSeconds:     getEnvAsInt("INSURANCE_BACKFILL_INTERVAL_SECONDS", 60),
+
+		InstanceID:              getEnv("INSTANCE_ID", defaultInstanceID()),
+		WorkerLeaseGraceSeconds: getEnvAsInt("WORKER_LEASE_GRACE_SECONDS", 30),
+
+		DrainDelaySeconds:      getEnvAsInt("DRAIN_DELAY_SECONDS", 5),
+		ShutdownTimeoutSeconds: getEnvAsInt("SHUTDOWN_TIMEOUT_SECONDS", 30),
 	}, nil
 }
 
+// defaultInstanceID tells apart instances on different hosts and processes on the same one
+func defaultInstanceID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "localhost"
+	}
+	return host + "-" + strconv.Itoa(os.Getpid())
+}
+
 func getEnv(key, defaultValue string) string {
 	if value, exists := os.LookupEnv(key); exists {
 		return value
@@ -87,6 +562,26 @@ func getEnvAsFloat(key string, defaultValue float64) float64 {
 	return defaultValue
 }
 
+// getEnvAsFloatList reads a comma-separated list such as "8,12"
+func getEnvAsFloatList(key string, defaultValue []float64) []float64 {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+	var list []float64
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		f, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return defaultValue
+		}
+		list = append(list, f)
+	}
+	return list
+}
+
 func getEnvAsBool(key string, defaultValue bool) bool {
 	if value, exists := os.LookupEnv(key); exists {
 		if boolValue, err := strconv.ParseBool(value); err == nil {
@@ -12,9 +12,11 @@ type PostgresDB struct {
 	*sqlx.DB
 }
 
+// NewPostgres configures the connection pool; connections are opened on first
+// use, so pair it with WaitFor to check the database is reachable
 func NewPostgres(databaseURL string, maxConns, maxIdleConns int) (*PostgresDB, error) {
 	// Use nrpq driver for New Relic instrumentation
-	db, err := sqlx.Connect("nrpostgres", databaseURL)
+	db, err := sqlx.Open("nrpostgres", databaseURL)
 	if err != nil {
 		return nil, err
 	}
@@ -23,14 +25,6 @@ func NewPostgres(databaseURL string, maxConns, maxIdleConns int) (*PostgresDB, e
 	db.SetMaxIdleConns(maxIdleConns)
 	db.SetConnMaxLifetime(time.Hour)
 
-	// Test connection
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	if err := db.PingContext(ctx); err != nil {
-		return nil, err
-	}
-
 	return &PostgresDB{DB: db}, nil
 }
 
@@ -2,17 +2,29 @@ package database
 
 import (
 	"context"
+	"errors"
+	"log"
+	"sync/atomic"
 	"time"
 
 	"github.com/newrelic/go-agent/v3/integrations/nrredis-v9"
 	"github.com/redis/go-redis/v9"
 )
 
+// redisRetryInterval is how long commands fail fast after Redis stops answering
+// before the next one is let through to probe it
+const redisRetryInterval = 5 * time.Second
+
+// ErrRedisUnavailable is returned without contacting Redis while it is marked down
+var ErrRedisUnavailable = errors.New("redis unavailable")
+
 type RedisDB struct {
 	*redis.Client
 }
 
-func NewRedis(addr, password string) (*RedisDB, error) {
+// NewRedis creates the client; it connects on first use, so pair it with WaitFor
+// to check Redis is reachable
+func NewRedis(addr, password string) *RedisDB {
 	client := redis.NewClient(&redis.Options{
 		Addr:         addr,
 		Password:     password,
@@ -27,15 +39,11 @@ func NewRedis(addr, password string) (*RedisDB, error) {
 	// Add New Relic instrumentation
 	client.AddHook(nrredis.NewHook(nil))
 
-	// Test connection
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	if err := client.Ping(ctx).Err(); err != nil {
-		return nil, err
-	}
+	// Fail fast while Redis is down so callers can fall back instead of waiting
+	// out a dial timeout on every command
+	client.AddHook(&redisBreaker{})
 
-	return &RedisDB{Client: client}, nil
+	return &RedisDB{Client: client}
 }
 
 func (r *RedisDB) Close() error {
@@ -45,3 +53,68 @@ func (r *RedisDB) Close() error {
 func (r *RedisDB) Health(ctx context.Context) error {
 	return r.Ping(ctx).Err()
 }
+
+// redisBreaker marks Redis down when a command fails for connection reasons and
+// rejects commands until redisRetryInterval has passed
+type redisBreaker struct {
+	down    atomic.Bool
+	retryAt atomic.Int64
+}
+
+func (b *redisBreaker) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (b *redisBreaker) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		// Health checks always reach Redis so they can tell when it's back
+		if !b.allow() && cmd.Name() != "ping" {
+			cmd.SetErr(ErrRedisUnavailable)
+			return ErrRedisUnavailable
+		}
+		err := next(ctx, cmd)
+		b.record(err)
+		return err
+	}
+}
+
+func (b *redisBreaker) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		if !b.allow() {
+			for _, cmd := range cmds {
+				cmd.SetErr(ErrRedisUnavailable)
+			}
+			return ErrRedisUnavailable
+		}
+		err := next(ctx, cmds)
+		b.record(err)
+		return err
+	}
+}
+
+func (b *redisBreaker) allow() bool {
+	return !b.down.Load() || time.Now().UnixNano() >= b.retryAt.Load()
+}
+
+func (b *redisBreaker) record(err error) {
+	if !connectionError(err) {
+		if b.down.CompareAndSwap(true, false) {
+			log.Println("Redis is reachable again, leaving degraded mode")
+		}
+		return
+	}
+	b.retryAt.Store(time.Now().Add(redisRetryInterval).UnixNano())
+	if b.down.CompareAndSwap(false, true) {
+		log.Printf("Redis unreachable, running degraded: %v", err)
+	}
+}
+
+// connectionError reports whether err means Redis could not be reached, as opposed
+// to a missing key, an error reply or the caller giving up
+func connectionError(err error) bool {
+	if err == nil || errors.Is(err, redis.Nil) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var replyErr redis.Error
+	return !errors.As(err, &replyErr)
+}
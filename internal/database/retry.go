@@ -0,0 +1,51 @@
+package database
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// maxStartupBackoff caps the pause between connection attempts
+const maxStartupBackoff = 30 * time.Second
+
+// RetryPolicy bounds how long startup waits for a dependency to come up
+type RetryPolicy struct {
+	Attempts       int
+	InitialBackoff time.Duration
+}
+
+// WaitFor runs check until it succeeds or the attempts run out, doubling the pause
+// between tries. It returns the last error.
+func WaitFor(ctx context.Context, name string, policy RetryPolicy, check func(context.Context) error) error {
+	attempts := policy.Attempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	backoff := policy.InitialBackoff
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		attemptCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		err = check(attemptCtx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+		if attempt == attempts {
+			break
+		}
+
+		log.Printf("%s unavailable (attempt %d/%d), retrying in %s: %v", name, attempt, attempts, backoff, err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > maxStartupBackoff {
+			backoff = maxStartupBackoff
+		}
+	}
+	return err
+}
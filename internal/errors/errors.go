@@ -32,6 +32,8 @@ type APIError struct {
 	Code       string `json:"error"`
 	Message    string `json:"message"`
 	StatusCode int    `json:"-"`
+	// Optional machine-readable context returned alongside the message
+	Details interface{} `json:"details,omitempty"`
 }
 
 func (e *APIError) Error() string {
@@ -64,10 +66,22 @@ func InternalError(message string) *APIError {
 	return NewAPIError("internal_error", message, http.StatusInternalServerError)
 }
 
+func ServiceUnavailable(code, message string) *APIError {
+	return NewAPIError(code, message, http.StatusServiceUnavailable)
+}
+
 func Unauthorized(message string) *APIError {
 	return NewAPIError("unauthorized", message, http.StatusUnauthorized)
 }
 
+func Forbidden(message string) *APIError {
+	return NewAPIError("forbidden", message, http.StatusForbidden)
+}
+
+func RequestTooLarge(limit int64) *APIError {
+	return NewAPIError("request_too_large", fmt.Sprintf("request body exceeds %d bytes", limit), http.StatusRequestEntityTooLarge)
+}
+
 func IdempotencyConflict() *APIError {
 	return NewAPIError("idempotency_conflict", "idempotency key already used with different request", http.StatusConflict)
 }
@@ -95,3 +109,88 @@ func UserHasActiveRide() *APIError {
 func InsufficientFunds() *APIError {
 	return NewAPIError("insufficient_funds", "wallet balance insufficient", http.StatusPaymentRequired)
 }
+
+func PaymentDeclined() *APIError {
+	return NewAPIError("payment_declined", "your card could not be authorized for this ride", http.StatusPaymentRequired)
+}
+
+func PrepaymentRequired() *APIError {
+	return NewAPIError("prepayment_required", "pay for this ride from your wallet, or use a card you've ridden with before", http.StatusPaymentRequired)
+}
+
+func RideLimitExceeded(limit float64) *APIError {
+	return NewAPIError("ride_limit_exceeded", fmt.Sprintf("new accounts can book rides up to %.0f unless paying from the wallet", limit), http.StatusUnprocessableEntity)
+}
+
+func SelfieCheckRequired() *APIError {
+	return NewAPIError("selfie_check_required", "a recent selfie check is required before going online", http.StatusForbidden)
+}
+
+func ReferencePhotoRequired() *APIError {
+	return NewAPIError("reference_photo_required", "upload a profile photo before submitting a selfie check", http.StatusUnprocessableEntity)
+}
+
+// OutsideServiceArea carries details on where the nearest served area is
+func OutsideServiceArea(details interface{}) *APIError {
+	err := NewAPIError("outside_service_area", "this location is outside our service area", http.StatusUnprocessableEntity)
+	err.Details = details
+	return err
+}
+
+// Maintenance carries the maintenance window so apps can say when to come back
+func Maintenance(details interface{}) *APIError {
+	err := NewAPIError("maintenance", "new rides can't be booked during scheduled maintenance", http.StatusServiceUnavailable)
+	err.Details = details
+	return err
+}
+
+// ConsentRequired carries the legal documents that must be accepted first
+func ConsentRequired(details interface{}) *APIError {
+	err := NewAPIError("consent_required", "updated terms must be accepted to continue", http.StatusForbidden)
+	err.Details = details
+	return err
+}
+
+// NotAtPickup carries how far the driver is from the pickup and the radius they
+// must be within to arrive
+func NotAtPickup(details interface{}) *APIError {
+	err := NewAPIError("not_at_pickup", "you must be at the pickup point to mark arrival", http.StatusUnprocessableEntity)
+	err.Details = details
+	return err
+}
+
+func DriverLocationUnknown() *APIError {
+	return NewAPIError("driver_location_unknown", "no recent location for this driver; send a location update first", http.StatusUnprocessableEntity)
+}
+
+func InvalidLocation(message string) *APIError {
+	return NewAPIError("invalid_location", message, http.StatusBadRequest)
+}
+
+func RideTooShort(minKm float64) *APIError {
+	return NewAPIError("ride_too_short", fmt.Sprintf("rides must be at least %.1f km", minKm), http.StatusUnprocessableEntity)
+}
+
+func RideTooLong(maxKm float64) *APIError {
+	return NewAPIError("ride_too_long", fmt.Sprintf("rides can be at most %.0f km", maxKm), http.StatusUnprocessableEntity)
+}
+
+func PickupPointRequired(venue string) *APIError {
+	return NewAPIError("pickup_point_required", fmt.Sprintf("choose one of the pickup points at %s", venue), http.StatusUnprocessableEntity)
+}
+
+func HelmetConfirmationRequired() *APIError {
+	return NewAPIError("helmet_confirmation_required", "confirm that you and the rider wear helmets before starting the trip", http.StatusUnprocessableEntity)
+}
+
+func DeliveryProofRequired(message string) *APIError {
+	return NewAPIError("delivery_proof_required", message, http.StatusUnprocessableEntity)
+}
+
+func InvalidDeliveryCode() *APIError {
+	return NewAPIError("invalid_delivery_code", "the delivery code is incorrect", http.StatusUnprocessableEntity)
+}
+
+func DeliveryCodeLocked() *APIError {
+	return NewAPIError("delivery_code_locked", "too many incorrect delivery codes; contact support to complete this delivery", http.StatusForbidden)
+}
@@ -0,0 +1,68 @@
+// Package events streams domain events (ride, trip and payment status changes,
+// drivers going online and offline) to downstream consumers such as analytics
+// and fraud detection.
+package events
+
+import (
+	"context"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/statemachine"
+	"github.com/google/uuid"
+)
+
+// Entities events are emitted for
+const (
+	EntityRide    = "ride"
+	EntityTrip    = "trip"
+	EntityPayment = "payment"
+	EntityDriver  = "driver"
+)
+
+// entities names the state machines' records as events call them
+var entities = map[string]string{
+	models.AuditEntityRide:    EntityRide,
+	models.AuditEntityTrip:    EntityTrip,
+	models.AuditEntityPayment: EntityPayment,
+}
+
+// Event is one status change of a record. Consumers may see an event more than
+// once and should dedupe on ID.
+type Event struct {
+	ID       string    `json:"id"`
+	Type     string    `json:"type"`
+	Entity   string    `json:"entity"`
+	EntityID string    `json:"entity_id"`
+	From     string    `json:"from,omitempty"`
+	To       string    `json:"to"`
+	At       time.Time `json:"at"`
+}
+
+// Publisher hands events to a broker
+type Publisher interface {
+	Publish(ctx context.Context, event *Event) error
+}
+
+// StatusChanged returns the event for a record of entity moving between statuses
+func StatusChanged(entity, id, from, to string, at time.Time) *Event {
+	return &Event{
+		ID:       uuid.NewString(),
+		Type:     entity + ".status_changed",
+		Entity:   entity,
+		EntityID: id,
+		From:     from,
+		To:       to,
+		At:       at,
+	}
+}
+
+// FromTransition returns the event for a state machine transition, or nil for
+// records that aren't streamed
+func FromTransition(t statemachine.Transition) *Event {
+	entity, ok := entities[t.Entity]
+	if !ok {
+		return nil
+	}
+	return StatusChanged(entity, t.ID, t.From, t.To, t.At)
+}
@@ -0,0 +1,85 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aditya/go-comet/internal/httpclient"
+)
+
+// KafkaPublisher produces events through a Kafka REST proxy (the Confluent v2
+// API): POST {base}/topics/{prefix}.{entity}. Events are keyed by record ID so
+// a record's events land on one partition, in order.
+type KafkaPublisher struct {
+	baseURL     string
+	topicPrefix string
+	client      *httpclient.Client
+}
+
+func NewKafkaPublisher(baseURL, topicPrefix string) *KafkaPublisher {
+	return &KafkaPublisher{
+		baseURL:     strings.TrimRight(baseURL, "/"),
+		topicPrefix: topicPrefix,
+		client:      httpclient.New(httpclient.Config{Name: "kafka", Timeout: 5 * time.Second}),
+	}
+}
+
+type kafkaRecord struct {
+	Key   string `json:"key"`
+	Value *Event `json:"value"`
+}
+
+type kafkaProduceResponse struct {
+	Offsets []struct {
+		ErrorCode *int   `json:"error_code"`
+		Error     string `json:"error"`
+	} `json:"offsets"`
+}
+
+func (p *KafkaPublisher) Publish(ctx context.Context, event *Event) error {
+	payload, err := json.Marshal(map[string][]kafkaRecord{
+		"records": {{Key: event.EntityID, Value: event}},
+	})
+	if err != nil {
+		return err
+	}
+
+	topic := event.Entity
+	if p.topicPrefix != "" {
+		topic = p.topicPrefix + "." + topic
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/topics/"+url.PathEscape(topic), bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/vnd.kafka.json.v2+json")
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("kafka: proxy returned status %d for topic %s", resp.StatusCode, topic)
+	}
+
+	// The proxy answers 200 even when the broker rejected the record
+	var produced kafkaProduceResponse
+	if err := json.NewDecoder(resp.Body).Decode(&produced); err != nil {
+		return fmt.Errorf("kafka: decode produce response: %w", err)
+	}
+	for _, o := range produced.Offsets {
+		if o.ErrorCode != nil {
+			return fmt.Errorf("kafka: produce to %s failed: %s (code %d)", topic, o.Error, *o.ErrorCode)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisChannelPrefix prefixes the pub/sub channel of each entity, e.g. events:ride
+const RedisChannelPrefix = "events:"
+
+// RedisPublisher publishes events on Redis pub/sub when Kafka isn't configured.
+// Only subscribers connected at the time see an event, so this suits
+// dashboards and development rather than consumers that can't miss events.
+type RedisPublisher struct {
+	client *redis.Client
+}
+
+func NewRedisPublisher(client *redis.Client) *RedisPublisher {
+	return &RedisPublisher{client: client}
+}
+
+func (p *RedisPublisher) Publish(ctx context.Context, event *Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return p.client.Publish(ctx, RedisChannelPrefix+event.Entity, payload).Err()
+}
@@ -0,0 +1,95 @@
+package events
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/aditya/go-comet/internal/metrics"
+	"github.com/aditya/go-comet/internal/statemachine"
+)
+
+// publishTimeout bounds each attempt to hand an event to the broker
+const publishTimeout = 10 * time.Second
+
+// Stream publishes events in the background so request paths and state machine
+// hooks never wait on the broker. Events are queued in memory: when the queue
+// is full, or the broker rejects an event, the event is dropped and counted in
+// events_dropped.
+type Stream struct {
+	publisher Publisher
+	queue     chan *Event
+	done      chan struct{}
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewStream starts publishing through publisher, queueing up to buffer events
+func NewStream(publisher Publisher, buffer int) *Stream {
+	if buffer <= 0 {
+		buffer = 1
+	}
+	s := &Stream{
+		publisher: publisher,
+		queue:     make(chan *Event, buffer),
+		done:      make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// Emit queues the event without blocking
+func (s *Stream) Emit(event *Event) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return
+	}
+
+	select {
+	case s.queue <- event:
+	default:
+		metrics.CounterMap("events_dropped").Add(event.Entity, 1)
+		log.Printf("events: queue full, dropped %s for %s %s", event.Type, event.Entity, event.EntityID)
+	}
+}
+
+// OnTransition is a statemachine.Hook emitting the transition's event
+func (s *Stream) OnTransition(ctx context.Context, t statemachine.Transition) {
+	if event := FromTransition(t); event != nil {
+		s.Emit(event)
+	}
+}
+
+// Close stops accepting events and waits for the queued ones to be published
+func (s *Stream) Close(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.queue)
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Stream) run() {
+	defer close(s.done)
+	for event := range s.queue {
+		ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+		if err := s.publisher.Publish(ctx, event); err != nil {
+			metrics.CounterMap("events_dropped").Add(event.Entity, 1)
+			log.Printf("events: failed to publish %s for %s %s: %v", event.Type, event.Entity, event.EntityID, err)
+		} else {
+			metrics.CounterMap("events_published").Add(event.Entity, 1)
+		}
+		cancel()
+	}
+}
@@ -0,0 +1,24 @@
+package geocoding
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	ErrNoResults = errors.New("no geocoding results")
+)
+
+// Place is a geocoded location with a human-readable address
+type Place struct {
+	Lat     float64
+	Lng     float64
+	Address string
+}
+
+// Provider converts between addresses and coordinates. language is a BCP 47 tag
+// (e.g. "hi", "en-IN") used for the returned address; empty means provider default.
+type Provider interface {
+	Geocode(ctx context.Context, address, language string) (*Place, error)
+	ReverseGeocode(ctx context.Context, lat, lng float64, language string) (*Place, error)
+}
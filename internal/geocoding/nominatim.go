@@ -0,0 +1,106 @@
+package geocoding
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aditya/go-comet/internal/httpclient"
+)
+
+// NominatimProvider talks to a Nominatim-compatible geocoding API
+// (OpenStreetMap, or a self-hosted instance).
+type NominatimProvider struct {
+	baseURL   string
+	userAgent string
+	client    *httpclient.Client
+}
+
+func NewNominatimProvider(baseURL, userAgent string) *NominatimProvider {
+	return &NominatimProvider{
+		baseURL:   strings.TrimRight(baseURL, "/"),
+		userAgent: userAgent,
+		client:    httpclient.New(httpclient.Config{Name: "nominatim", Timeout: 5 * time.Second, MaxRetries: 2}),
+	}
+}
+
+type nominatimPlace struct {
+	Lat         string `json:"lat"`
+	Lon         string `json:"lon"`
+	DisplayName string `json:"display_name"`
+	Error       string `json:"error"`
+}
+
+func (p *NominatimProvider) Geocode(ctx context.Context, address, language string) (*Place, error) {
+	params := url.Values{}
+	params.Set("q", address)
+	params.Set("format", "jsonv2")
+	params.Set("limit", "1")
+
+	var results []nominatimPlace
+	if err := p.get(ctx, "/search", params, language, &results); err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, ErrNoResults
+	}
+	return results[0].toPlace()
+}
+
+func (p *NominatimProvider) ReverseGeocode(ctx context.Context, lat, lng float64, language string) (*Place, error) {
+	params := url.Values{}
+	params.Set("lat", strconv.FormatFloat(lat, 'f', 6, 64))
+	params.Set("lon", strconv.FormatFloat(lng, 'f', 6, 64))
+	params.Set("format", "jsonv2")
+
+	var result nominatimPlace
+	if err := p.get(ctx, "/reverse", params, language, &result); err != nil {
+		return nil, err
+	}
+	if result.Error != "" {
+		return nil, ErrNoResults
+	}
+	return result.toPlace()
+}
+
+func (p *NominatimProvider) get(ctx context.Context, path string, params url.Values, language string, out interface{}) error {
+	if language != "" {
+		params.Set("accept-language", language)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+path+"?"+params.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	if p.userAgent != "" {
+		req.Header.Set("User-Agent", p.userAgent)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("geocoding: unexpected status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (r nominatimPlace) toPlace() (*Place, error) {
+	lat, err := strconv.ParseFloat(r.Lat, 64)
+	if err != nil {
+		return nil, fmt.Errorf("geocoding: invalid lat %q", r.Lat)
+	}
+	lng, err := strconv.ParseFloat(r.Lon, 64)
+	if err != nil {
+		return nil, fmt.Errorf("geocoding: invalid lon %q", r.Lon)
+	}
+	return &Place{Lat: lat, Lng: lng, Address: r.DisplayName}, nil
+}
@@ -0,0 +1,90 @@
+package geocoding
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aditya/go-comet/internal/httpclient"
+)
+
+// RoadPoint is a position snapped onto the road network
+type RoadPoint struct {
+	Lat    float64
+	Lng    float64
+	Street string
+	// Distance from the point that was snapped
+	DistanceM float64
+}
+
+// RoadSnapper finds the nearest spots on drivable roads
+type RoadSnapper interface {
+	Nearest(ctx context.Context, lat, lng float64, count int) ([]RoadPoint, error)
+}
+
+// OSRMSnapper uses the nearest service of an OSRM routing server
+type OSRMSnapper struct {
+	baseURL string
+	client  *httpclient.Client
+}
+
+func NewOSRMSnapper(baseURL string) *OSRMSnapper {
+	return &OSRMSnapper{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  httpclient.New(httpclient.Config{Name: "osrm", Timeout: 3 * time.Second, MaxRetries: 1}),
+	}
+}
+
+type osrmNearestResponse struct {
+	Code      string `json:"code"`
+	Waypoints []struct {
+		Name     string     `json:"name"`
+		Location [2]float64 `json:"location"` // lng, lat
+		Distance float64    `json:"distance"`
+	} `json:"waypoints"`
+}
+
+func (s *OSRMSnapper) Nearest(ctx context.Context, lat, lng float64, count int) ([]RoadPoint, error) {
+	params := url.Values{}
+	params.Set("number", strconv.Itoa(count))
+	coords := strconv.FormatFloat(lng, 'f', 6, 64) + "," + strconv.FormatFloat(lat, 'f', 6, 64)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.baseURL+"/nearest/v1/driving/"+coords+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("road snap: unexpected status %d", resp.StatusCode)
+	}
+
+	var result osrmNearestResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	if result.Code != "Ok" || len(result.Waypoints) == 0 {
+		return nil, ErrNoResults
+	}
+
+	points := make([]RoadPoint, 0, len(result.Waypoints))
+	for _, w := range result.Waypoints {
+		points = append(points, RoadPoint{
+			Lat:       w.Location[1],
+			Lng:       w.Location[0],
+			Street:    w.Name,
+			DistanceM: w.Distance,
+		})
+	}
+	return points, nil
+}
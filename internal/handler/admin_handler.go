@@ -0,0 +1,1797 @@
+package handler
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	apperrors "github.com/aditya/go-comet/internal/errors"
+	"github.com/aditya/go-comet/internal/metrics"
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/service"
+	"github.com/aditya/go-comet/pkg/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+)
+
+type AdminHandler struct {
+	adminService        service.AdminService
+	regionService       service.RegionService
+	handoverService     service.HandoverService
+	commissionService   service.CommissionService
+	deductionService    service.DeductionService
+	incentiveService    service.IncentiveService
+	riskService         service.RiskService
+	trainingService     service.TrainingService
+	pickupService       service.PickupService
+	fareVarianceService service.FareVarianceService
+	slaService          service.SLAService
+	funnelService       service.MatchingFunnelService
+	economicsService    service.RideEconomicsService
+	calendarService     service.PricingCalendarService
+	tenantService       service.TenantService
+	exclusionService    service.MatchingExclusionService
+	maintenanceService  service.MaintenanceService
+	matchingService     service.MatchingService
+	promoService        service.PromoService
+	rolloutService      service.RolloutService
+	waitingService      service.WaitingScreenService
+	ratingService       service.RatingService
+	consentService      service.ConsentService
+	retentionService    service.RetentionService
+	backupService       service.BackupService
+	vehicleTypeService  service.VehicleTypeService
+	rideService         service.RideService
+	validate            *validator.Validate
+}
+
+func NewAdminHandler(
+	adminService service.AdminService,
+	regionService service.RegionService,
+	handoverService service.HandoverService,
+	commissionService service.CommissionService,
+	deductionService service.DeductionService,
+	incentiveService service.IncentiveService,
+	riskService service.RiskService,
+	trainingService service.TrainingService,
+	pickupService service.PickupService,
+	fareVarianceService service.FareVarianceService,
+	slaService service.SLAService,
+	funnelService service.MatchingFunnelService,
+	economicsService service.RideEconomicsService,
+	calendarService service.PricingCalendarService,
+	tenantService service.TenantService,
+	exclusionService service.MatchingExclusionService,
+	maintenanceService service.MaintenanceService,
+	matchingService service.MatchingService,
+	promoService service.PromoService,
+	rolloutService service.RolloutService,
+	waitingService service.WaitingScreenService,
+	ratingService service.RatingService,
+	consentService service.ConsentService,
+	retentionService service.RetentionService,
+	backupService service.BackupService,
+	vehicleTypeService service.VehicleTypeService,
+	rideService service.RideService,
+) *AdminHandler {
+	return &AdminHandler{
+		adminService:        adminService,
+		regionService:       regionService,
+		handoverService:     handoverService,
+		commissionService:   commissionService,
+		deductionService:    deductionService,
+		incentiveService:    incentiveService,
+		riskService:         riskService,
+		trainingService:     trainingService,
+		pickupService:       pickupService,
+		fareVarianceService: fareVarianceService,
+		slaService:          slaService,
+		funnelService:       funnelService,
+		economicsService:    economicsService,
+		calendarService:     calendarService,
+		tenantService:       tenantService,
+		exclusionService:    exclusionService,
+		maintenanceService:  maintenanceService,
+		matchingService:     matchingService,
+		promoService:        promoService,
+		rolloutService:      rolloutService,
+		waitingService:      waitingService,
+		ratingService:       ratingService,
+		consentService:      consentService,
+		retentionService:    retentionService,
+		backupService:       backupService,
+		vehicleTypeService:  vehicleTypeService,
+		rideService:         rideService,
+		validate:            validator.New(),
+	}
+}
+
+func (h *AdminHandler) RegisterRoutes(r chi.Router) {
+	r.Get("/rides", h.SearchRides)
+	r.Get("/rides/{id}/replay", h.ReplayRide)
+	r.Post("/rides/{id}/rematch", h.RematchRide)
+	r.Post("/rides/{id}/cancel", h.CancelRide)
+	r.Get("/rides/{id}/economics", h.GetRideEconomics)
+	r.Get("/finance/take-rate", h.GetTakeRate)
+	r.Get("/trips/mileage", h.ListMileageFlags)
+	r.Get("/trips/fare-variance", h.GetFareVariance)
+	r.Get("/sla", h.GetSLACompliance)
+	r.Get("/matching/funnel", h.GetMatchingFunnel)
+	r.Get("/matching/exclusions", h.ListMatchingExclusions)
+	r.Post("/matching/exclusions", h.CreateMatchingExclusion)
+	r.Post("/matching/exclusions/{id}/revoke", h.RevokeMatchingExclusion)
+	r.Post("/trips/{id}/mileage-review", h.ReviewMileage)
+	r.Post("/trips/{id}/handover", h.FreezeTrip)
+	r.Post("/trips/{id}/handover/rescue", h.AssignRescueDriver)
+	r.Post("/drivers:import", h.ImportDrivers)
+	r.Post("/drivers/{id}/verify", h.VerifyDriver)
+	r.Get("/drivers/{id}/commission", h.GetDriverCommission)
+	r.Post("/drivers/{id}/commission", h.CreateCommissionOverride)
+	r.Post("/drivers/{id}/commission/{overrideId}/end", h.EndCommissionOverride)
+	r.Post("/drivers/{id}/deductions", h.CreateDeductionSchedule)
+	r.Post("/drivers/{id}/deductions/{scheduleId}/cancel", h.CancelDeductionSchedule)
+	r.Put("/drivers/{id}/training/{module}", h.RecordDriverTraining)
+	r.Get("/incentives/guarantees", h.ListIncentiveGuarantees)
+	r.Post("/incentives/guarantees", h.CreateIncentiveGuarantee)
+	r.Post("/incentives/guarantees/{id}/activate", h.ActivateIncentiveGuarantee)
+	r.Post("/incentives/guarantees/{id}/deactivate", h.DeactivateIncentiveGuarantee)
+	r.Get("/pricing-events", h.ListPricingEvents)
+	r.Post("/pricing-events", h.CreatePricingEvent)
+	r.Post("/pricing-events/{id}/activate", h.ActivatePricingEvent)
+	r.Post("/pricing-events/{id}/deactivate", h.DeactivatePricingEvent)
+	r.Get("/promos", h.ListPromos)
+	r.Post("/promos", h.CreatePromo)
+	r.Get("/promos/{code}", h.GetPromo)
+	r.Put("/promos/{code}", h.UpdatePromo)
+	r.Post("/pickup-spots", h.CreatePickupSpot)
+	r.Post("/pickup-spots/{id}/activate", h.ActivatePickupSpot)
+	r.Post("/pickup-spots/{id}/deactivate", h.DeactivatePickupSpot)
+	r.Get("/venues", h.ListVenues)
+	r.Post("/venues", h.CreateVenue)
+	r.Post("/venues/{id}/activate", h.ActivateVenue)
+	r.Post("/venues/{id}/deactivate", h.DeactivateVenue)
+	r.Get("/users/{id}/risk", h.GetUserRisk)
+	r.Post("/users/{id}/risk-overrides", h.CreateRiskOverride)
+	r.Post("/users/{id}/risk-overrides/{overrideId}/revoke", h.RevokeRiskOverride)
+	r.Get("/maintenance", h.ListMaintenanceWindows)
+	r.Post("/maintenance", h.CreateMaintenanceWindow)
+	r.Post("/maintenance/{id}/activate", h.ActivateMaintenanceWindow)
+	r.Post("/maintenance/{id}/deactivate", h.DeactivateMaintenanceWindow)
+	r.Get("/waiting-content", h.ListWaitingContent)
+	r.Post("/waiting-content", h.CreateWaitingContent)
+	r.Post("/waiting-content/{id}/activate", h.ActivateWaitingContent)
+	r.Post("/waiting-content/{id}/deactivate", h.DeactivateWaitingContent)
+	r.Get("/reviews", h.ListReviewQueue)
+	r.Post("/reviews/{id}/publish", h.PublishReview)
+	r.Post("/reviews/{id}/remove", h.RemoveReview)
+	r.Get("/legal-documents", h.ListLegalDocuments)
+	r.Post("/legal-documents", h.PublishLegalDocument)
+	r.Get("/retention/report", h.RetentionReport)
+	r.Post("/retention/run", h.RunRetention)
+	r.Get("/backups", h.ListBackups)
+	r.Post("/backups", h.RequestBackup)
+	r.Get("/backups/{id}", h.GetBackup)
+	r.Get("/regions", h.ListRegions)
+	r.Put("/regions/{code}/settings", h.UpdateRegionSettings)
+	r.Put("/regions/{code}/service-area", h.UpdateServiceArea)
+	r.Put("/regions/{code}/tenant", h.AssignRegionTenant)
+	r.Get("/regions/{code}/rollouts", h.GetRolloutSummary)
+	r.Get("/tenants", h.ListTenants)
+	r.Post("/tenants", h.CreateTenant)
+	r.Put("/tenants/{code}", h.UpdateTenant)
+	r.Post("/tenants/{code}/api-key", h.RotateTenantAPIKey)
+	r.Get("/vehicle-types", h.ListVehicleTypes)
+	r.Post("/vehicle-types", h.CreateVehicleType)
+	r.Put("/vehicle-types/{code}", h.UpdateVehicleType)
+	r.Handle("/metrics", metrics.Handler())
+}
+
+// GET /v1/admin/rides/{id}/replay?at=2024-01-01T10:00:00Z
+func (h *AdminHandler) ReplayRide(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		utils.BadRequest(w, "ride id is required")
+		return
+	}
+
+	at := time.Now()
+	if raw := r.URL.Query().Get("at"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			utils.BadRequest(w, "at must be an RFC3339 timestamp")
+			return
+		}
+		at = parsed
+	}
+
+	replay, err := h.adminService.ReplayRide(r.Context(), id, at)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, replay)
+}
+
+// POST /v1/admin/rides/{id}/rematch
+func (h *AdminHandler) RematchRide(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		utils.BadRequest(w, "ride id is required")
+		return
+	}
+
+	ride, err := h.matchingService.RematchRide(r.Context(), id)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, ride.ToResponse())
+}
+
+// POST /v1/admin/rides/{id}/cancel cancels a ride as system; the rider pays no fee
+func (h *AdminHandler) CancelRide(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		utils.BadRequest(w, "ride id is required")
+		return
+	}
+
+	var req models.CancelRideRequest
+	if !utils.DecodeJSON(w, r, &req) {
+		return
+	}
+	req.CancelledBy = models.CancelledBySystem
+
+	if _, err := h.rideService.CancelRide(r.Context(), id, &req); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, map[string]interface{}{
+		"status":  "cancelled",
+		"message": "ride cancelled successfully",
+	})
+}
+
+// GET /v1/admin/rides?status=&region=&payment_method=&from=&to=&min_surge=&q=&limit=&offset=
+func (h *AdminHandler) SearchRides(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := &models.RideSearchFilter{
+		Status:        q.Get("status"),
+		RegionCode:    q.Get("region"),
+		TenantCode:    q.Get("tenant"),
+		PaymentMethod: q.Get("payment_method"),
+		Query:         q.Get("q"),
+	}
+
+	for param, dst := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		if raw := q.Get(param); raw != "" {
+			parsed, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				utils.BadRequest(w, param+" must be an RFC3339 timestamp")
+				return
+			}
+			*dst = &parsed
+		}
+	}
+
+	if raw := q.Get("min_surge"); raw != "" {
+		minSurge, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			utils.BadRequest(w, "min_surge must be a number")
+			return
+		}
+		filter.MinSurge = &minSurge
+	}
+
+	for param, dst := range map[string]*int{"limit": &filter.Limit, "offset": &filter.Offset} {
+		if raw := q.Get(param); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil {
+				utils.BadRequest(w, param+" must be an integer")
+				return
+			}
+			*dst = n
+		}
+	}
+
+	rides, err := h.adminService.SearchRides(r.Context(), filter)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, map[string]interface{}{
+		"rides":  rides,
+		"limit":  filter.Limit,
+		"offset": filter.Offset,
+	})
+}
+
+// GET /v1/admin/regions
+func (h *AdminHandler) ListRegions(w http.ResponseWriter, r *http.Request) {
+	regions, err := h.regionService.ListAllRegions(r.Context())
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, map[string]interface{}{
+		"regions": regions,
+	})
+}
+
+// PUT /v1/admin/regions/{code}/settings
+func (h *AdminHandler) UpdateRegionSettings(w http.ResponseWriter, r *http.Request) {
+	code := chi.URLParam(r, "code")
+	if code == "" {
+		utils.BadRequest(w, "region code is required")
+		return
+	}
+
+	var settings models.RegionSettings
+	if !utils.DecodeJSON(w, r, &settings) {
+		return
+	}
+
+	if err := h.validate.Struct(settings); err != nil {
+		utils.BadRequest(w, err.Error())
+		return
+	}
+	if !h.checkVehicleTypes(w, r, settings.VehicleTypes...) {
+		return
+	}
+
+	region, err := h.regionService.UpdateSettings(r.Context(), code, settings)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, region)
+}
+
+// PUT /v1/admin/regions/{code}/service-area
+func (h *AdminHandler) UpdateServiceArea(w http.ResponseWriter, r *http.Request) {
+	code := chi.URLParam(r, "code")
+	if code == "" {
+		utils.BadRequest(w, "region code is required")
+		return
+	}
+
+	var req models.UpdateServiceAreaRequest
+	if !utils.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.BadRequest(w, err.Error())
+		return
+	}
+
+	region, err := h.regionService.UpdateServiceArea(r.Context(), code, req.Polygon)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, region)
+}
+
+// PUT /v1/admin/regions/{code}/tenant
+func (h *AdminHandler) AssignRegionTenant(w http.ResponseWriter, r *http.Request) {
+	code := chi.URLParam(r, "code")
+	if code == "" {
+		utils.BadRequest(w, "region code is required")
+		return
+	}
+
+	var req models.AssignRegionTenantRequest
+	if !utils.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.BadRequest(w, err.Error())
+		return
+	}
+
+	region, err := h.regionService.AssignTenant(r.Context(), code, req.TenantCode)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, region)
+}
+
+// GET /v1/admin/regions/{code}/rollouts
+func (h *AdminHandler) GetRolloutSummary(w http.ResponseWriter, r *http.Request) {
+	summaries, err := h.rolloutService.GetSummary(r.Context(), chi.URLParam(r, "code"))
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, map[string]interface{}{
+		"rollouts": summaries,
+	})
+}
+
+// GET /v1/admin/tenants
+func (h *AdminHandler) ListTenants(w http.ResponseWriter, r *http.Request) {
+	tenants, err := h.tenantService.ListTenants(r.Context())
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, tenants)
+}
+
+// POST /v1/admin/tenants
+// The response carries the tenant's API key, which isn't shown again
+func (h *AdminHandler) CreateTenant(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateTenantRequest
+	if !utils.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.BadRequest(w, err.Error())
+		return
+	}
+	if !h.checkVehicleTypes(w, r, req.FareConfigs.VehicleTypes()...) {
+		return
+	}
+
+	creds, err := h.tenantService.CreateTenant(r.Context(), &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Created(w, creds)
+}
+
+// PUT /v1/admin/tenants/{code}
+func (h *AdminHandler) UpdateTenant(w http.ResponseWriter, r *http.Request) {
+	code := chi.URLParam(r, "code")
+	if code == "" {
+		utils.BadRequest(w, "tenant code is required")
+		return
+	}
+
+	var req models.UpdateTenantRequest
+	if !utils.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.BadRequest(w, err.Error())
+		return
+	}
+	if !h.checkVehicleTypes(w, r, req.FareConfigs.VehicleTypes()...) {
+		return
+	}
+
+	tenant, err := h.tenantService.UpdateTenant(r.Context(), code, &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, tenant)
+}
+
+// POST /v1/admin/tenants/{code}/api-key
+func (h *AdminHandler) RotateTenantAPIKey(w http.ResponseWriter, r *http.Request) {
+	code := chi.URLParam(r, "code")
+	if code == "" {
+		utils.BadRequest(w, "tenant code is required")
+		return
+	}
+
+	creds, err := h.tenantService.RotateAPIKey(r.Context(), code)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, creds)
+}
+
+// GET /v1/admin/vehicle-types
+func (h *AdminHandler) ListVehicleTypes(w http.ResponseWriter, r *http.Request) {
+	types, err := h.vehicleTypeService.ListVehicleTypes(r.Context())
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, types)
+}
+
+// POST /v1/admin/vehicle-types
+func (h *AdminHandler) CreateVehicleType(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateVehicleTypeRequest
+	if !utils.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.BadRequest(w, err.Error())
+		return
+	}
+
+	vehicleType, err := h.vehicleTypeService.CreateVehicleType(r.Context(), &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Created(w, vehicleType)
+}
+
+// PUT /v1/admin/vehicle-types/{code}
+func (h *AdminHandler) UpdateVehicleType(w http.ResponseWriter, r *http.Request) {
+	code := chi.URLParam(r, "code")
+	if code == "" {
+		utils.BadRequest(w, "vehicle type code is required")
+		return
+	}
+
+	var req models.UpdateVehicleTypeRequest
+	if !utils.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.BadRequest(w, err.Error())
+		return
+	}
+
+	vehicleType, err := h.vehicleTypeService.UpdateVehicleType(r.Context(), code, &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, vehicleType)
+}
+
+// checkVehicleTypes writes an error and returns false unless every non-empty
+// code is in the vehicle type registry
+func (h *AdminHandler) checkVehicleTypes(w http.ResponseWriter, r *http.Request, codes ...string) bool {
+	var check []string
+	for _, code := range codes {
+		if code != "" {
+			check = append(check, code)
+		}
+	}
+	if err := h.vehicleTypeService.CheckRegistered(r.Context(), check...); err != nil {
+		handleError(w, err)
+		return false
+	}
+	return true
+}
+
+// GET /v1/admin/trips/mileage?status=flagged&limit=
+func (h *AdminHandler) ListMileageFlags(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			utils.BadRequest(w, "limit must be an integer")
+			return
+		}
+		limit = n
+	}
+
+	trips, err := h.adminService.ListMileageFlags(r.Context(), r.URL.Query().Get("status"), limit)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, map[string]interface{}{
+		"trips": trips,
+	})
+}
+
+// POST /v1/admin/trips/{id}/mileage-review
+func (h *AdminHandler) ReviewMileage(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		utils.BadRequest(w, "trip id is required")
+		return
+	}
+
+	var req models.ReviewMileageRequest
+	if !utils.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.BadRequest(w, err.Error())
+		return
+	}
+
+	trip, err := h.adminService.ReviewMileage(r.Context(), id, &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, trip)
+}
+
+// POST /v1/admin/trips/{id}/handover
+func (h *AdminHandler) FreezeTrip(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		utils.BadRequest(w, "trip id is required")
+		return
+	}
+
+	var req models.HandoverRequest
+	if !utils.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.BadRequest(w, err.Error())
+		return
+	}
+
+	handover, err := h.handoverService.FreezeTrip(r.Context(), id, &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, handover)
+}
+
+// POST /v1/admin/trips/{id}/handover/rescue
+func (h *AdminHandler) AssignRescueDriver(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		utils.BadRequest(w, "trip id is required")
+		return
+	}
+
+	// An empty body dispatches the nearest available driver
+	var req models.AssignRescueDriverRequest
+	if r.ContentLength != 0 {
+		if !utils.DecodeJSON(w, r, &req) {
+			return
+		}
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.BadRequest(w, err.Error())
+		return
+	}
+
+	handover, err := h.handoverService.AssignRescueDriver(r.Context(), id, &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, handover)
+}
+
+// POST /v1/admin/drivers/{id}/verify
+func (h *AdminHandler) VerifyDriver(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		utils.BadRequest(w, "driver id is required")
+		return
+	}
+
+	driver, err := h.adminService.VerifyDriver(r.Context(), id)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, driver)
+}
+
+// GET /v1/admin/drivers/{id}/commission
+func (h *AdminHandler) GetDriverCommission(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		utils.BadRequest(w, "driver id is required")
+		return
+	}
+
+	commission, err := h.commissionService.GetDriverCommission(r.Context(), id)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, commission)
+}
+
+// POST /v1/admin/drivers/{id}/commission
+func (h *AdminHandler) CreateCommissionOverride(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		utils.BadRequest(w, "driver id is required")
+		return
+	}
+
+	var req models.CreateCommissionOverrideRequest
+	if !utils.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.BadRequest(w, err.Error())
+		return
+	}
+
+	override, err := h.commissionService.CreateOverride(r.Context(), id, &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Created(w, override)
+}
+
+// POST /v1/admin/drivers/{id}/commission/{overrideId}/end
+func (h *AdminHandler) EndCommissionOverride(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	overrideID := chi.URLParam(r, "overrideId")
+	if id == "" || overrideID == "" {
+		utils.BadRequest(w, "driver id and override id are required")
+		return
+	}
+
+	override, err := h.commissionService.EndOverride(r.Context(), id, overrideID)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, override)
+}
+
+// POST /v1/admin/drivers/{id}/deductions
+func (h *AdminHandler) CreateDeductionSchedule(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		utils.BadRequest(w, "driver id is required")
+		return
+	}
+
+	var req models.CreateDeductionScheduleRequest
+	if !utils.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.BadRequest(w, err.Error())
+		return
+	}
+
+	schedule, err := h.deductionService.CreateSchedule(r.Context(), id, &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Created(w, schedule)
+}
+
+// POST /v1/admin/drivers/{id}/deductions/{scheduleId}/cancel
+func (h *AdminHandler) CancelDeductionSchedule(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	scheduleID := chi.URLParam(r, "scheduleId")
+	if id == "" || scheduleID == "" {
+		utils.BadRequest(w, "driver id and schedule id are required")
+		return
+	}
+
+	schedule, err := h.deductionService.CancelSchedule(r.Context(), id, scheduleID)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, schedule)
+}
+
+// GET /v1/admin/incentives/guarantees
+func (h *AdminHandler) ListIncentiveGuarantees(w http.ResponseWriter, r *http.Request) {
+	guarantees, err := h.incentiveService.ListGuarantees(r.Context())
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, map[string]interface{}{
+		"guarantees": guarantees,
+	})
+}
+
+// POST /v1/admin/incentives/guarantees
+func (h *AdminHandler) CreateIncentiveGuarantee(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateIncentiveGuaranteeRequest
+	if !utils.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.BadRequest(w, err.Error())
+		return
+	}
+	if !h.checkVehicleTypes(w, r, req.VehicleType) {
+		return
+	}
+
+	guarantee, err := h.incentiveService.CreateGuarantee(r.Context(), &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Created(w, guarantee)
+}
+
+// POST /v1/admin/incentives/guarantees/{id}/activate
+func (h *AdminHandler) ActivateIncentiveGuarantee(w http.ResponseWriter, r *http.Request) {
+	h.setIncentiveGuaranteeActive(w, r, true)
+}
+
+// POST /v1/admin/incentives/guarantees/{id}/deactivate
+func (h *AdminHandler) DeactivateIncentiveGuarantee(w http.ResponseWriter, r *http.Request) {
+	h.setIncentiveGuaranteeActive(w, r, false)
+}
+
+func (h *AdminHandler) setIncentiveGuaranteeActive(w http.ResponseWriter, r *http.Request, active bool) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		utils.BadRequest(w, "guarantee id is required")
+		return
+	}
+
+	guarantee, err := h.incentiveService.SetGuaranteeActive(r.Context(), id, active)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, guarantee)
+}
+
+// GET /v1/admin/pricing-events
+func (h *AdminHandler) ListPricingEvents(w http.ResponseWriter, r *http.Request) {
+	events, err := h.calendarService.ListEvents(r.Context())
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, map[string]interface{}{
+		"events": events,
+	})
+}
+
+// POST /v1/admin/pricing-events
+func (h *AdminHandler) CreatePricingEvent(w http.ResponseWriter, r *http.Request) {
+	var req models.CreatePricingEventRequest
+	if !utils.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.BadRequest(w, err.Error())
+		return
+	}
+	if !h.checkVehicleTypes(w, r, req.VehicleType) {
+		return
+	}
+
+	event, err := h.calendarService.CreateEvent(r.Context(), &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Created(w, event)
+}
+
+// POST /v1/admin/pricing-events/{id}/activate
+func (h *AdminHandler) ActivatePricingEvent(w http.ResponseWriter, r *http.Request) {
+	h.setPricingEventActive(w, r, true)
+}
+
+// POST /v1/admin/pricing-events/{id}/deactivate
+func (h *AdminHandler) DeactivatePricingEvent(w http.ResponseWriter, r *http.Request) {
+	h.setPricingEventActive(w, r, false)
+}
+
+func (h *AdminHandler) setPricingEventActive(w http.ResponseWriter, r *http.Request, active bool) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		utils.BadRequest(w, "pricing event id is required")
+		return
+	}
+
+	event, err := h.calendarService.SetEventActive(r.Context(), id, active)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, event)
+}
+
+// GET /v1/admin/promos
+func (h *AdminHandler) ListPromos(w http.ResponseWriter, r *http.Request) {
+	promos, err := h.promoService.ListPromos(r.Context())
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, map[string]interface{}{
+		"promos": promos,
+	})
+}
+
+// POST /v1/admin/promos
+func (h *AdminHandler) CreatePromo(w http.ResponseWriter, r *http.Request) {
+	var req models.CreatePromoCodeRequest
+	if !utils.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.BadRequest(w, err.Error())
+		return
+	}
+
+	promo, err := h.promoService.CreatePromo(r.Context(), &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Created(w, promo)
+}
+
+// GET /v1/admin/promos/{code}
+func (h *AdminHandler) GetPromo(w http.ResponseWriter, r *http.Request) {
+	promo, err := h.promoService.GetPromo(r.Context(), chi.URLParam(r, "code"))
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, promo)
+}
+
+// PUT /v1/admin/promos/{code}
+func (h *AdminHandler) UpdatePromo(w http.ResponseWriter, r *http.Request) {
+	var req models.UpdatePromoCodeRequest
+	if !utils.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.BadRequest(w, err.Error())
+		return
+	}
+
+	promo, err := h.promoService.UpdatePromo(r.Context(), chi.URLParam(r, "code"), &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, promo)
+}
+
+// POST /v1/admin/pickup-spots
+func (h *AdminHandler) CreatePickupSpot(w http.ResponseWriter, r *http.Request) {
+	var req models.CreatePickupSpotRequest
+	if !utils.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.BadRequest(w, err.Error())
+		return
+	}
+
+	spot, err := h.pickupService.CreateSpot(r.Context(), &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Created(w, spot)
+}
+
+// POST /v1/admin/pickup-spots/{id}/activate
+func (h *AdminHandler) ActivatePickupSpot(w http.ResponseWriter, r *http.Request) {
+	h.setPickupSpotActive(w, r, true)
+}
+
+// POST /v1/admin/pickup-spots/{id}/deactivate
+func (h *AdminHandler) DeactivatePickupSpot(w http.ResponseWriter, r *http.Request) {
+	h.setPickupSpotActive(w, r, false)
+}
+
+func (h *AdminHandler) setPickupSpotActive(w http.ResponseWriter, r *http.Request, active bool) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		utils.BadRequest(w, "pickup spot id is required")
+		return
+	}
+
+	spot, err := h.pickupService.SetSpotActive(r.Context(), id, active)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, spot)
+}
+
+// GET /v1/admin/venues
+func (h *AdminHandler) ListVenues(w http.ResponseWriter, r *http.Request) {
+	venues, err := h.pickupService.ListVenues(r.Context())
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, map[string]interface{}{
+		"venues": venues,
+	})
+}
+
+// POST /v1/admin/venues
+func (h *AdminHandler) CreateVenue(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateVenueRequest
+	if !utils.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.BadRequest(w, err.Error())
+		return
+	}
+
+	venue, err := h.pickupService.CreateVenue(r.Context(), &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Created(w, venue)
+}
+
+// POST /v1/admin/venues/{id}/activate
+func (h *AdminHandler) ActivateVenue(w http.ResponseWriter, r *http.Request) {
+	h.setVenueActive(w, r, true)
+}
+
+// POST /v1/admin/venues/{id}/deactivate
+func (h *AdminHandler) DeactivateVenue(w http.ResponseWriter, r *http.Request) {
+	h.setVenueActive(w, r, false)
+}
+
+func (h *AdminHandler) setVenueActive(w http.ResponseWriter, r *http.Request, active bool) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		utils.BadRequest(w, "venue id is required")
+		return
+	}
+
+	venue, err := h.pickupService.SetVenueActive(r.Context(), id, active)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, venue)
+}
+
+// GET /v1/admin/trips/fare-variance
+// Estimated vs actual fares per region and vehicle type over the monitoring window
+func (h *AdminHandler) GetFareVariance(w http.ResponseWriter, r *http.Request) {
+	report, err := h.fareVarianceService.Report(r.Context())
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, report)
+}
+
+// GET /v1/admin/sla
+// Rolling time-to-match, offer acceptance and time-to-pickup compliance per region
+func (h *AdminHandler) GetSLACompliance(w http.ResponseWriter, r *http.Request) {
+	report, err := h.slaService.Report(r.Context())
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, report)
+}
+
+// GET /v1/admin/matching/funnel?hours=24&region=
+// Drivers found, filtered, offered, viewed and accepting per region and vehicle type
+func (h *AdminHandler) GetMatchingFunnel(w http.ResponseWriter, r *http.Request) {
+	hours := 24
+	if raw := r.URL.Query().Get("hours"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			utils.BadRequest(w, "hours must be a positive integer")
+			return
+		}
+		hours = n
+	}
+
+	report, err := h.funnelService.Report(r.Context(), time.Duration(hours)*time.Hour, r.URL.Query().Get("region"))
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, report)
+}
+
+// GET /v1/admin/matching/exclusions?active=true
+// Every driver and area exclusion with who applied and revoked it; active=true
+// lists only those in effect now
+func (h *AdminHandler) ListMatchingExclusions(w http.ResponseWriter, r *http.Request) {
+	exclusions, err := h.exclusionService.ListExclusions(r.Context(), r.URL.Query().Get("active") == "true")
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, map[string]interface{}{
+		"exclusions": exclusions,
+	})
+}
+
+// POST /v1/admin/matching/exclusions
+// Takes a driver or an area out of matching until expires_at
+func (h *AdminHandler) CreateMatchingExclusion(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateMatchingExclusionRequest
+	if !utils.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.BadRequest(w, err.Error())
+		return
+	}
+
+	exclusion, err := h.exclusionService.CreateExclusion(r.Context(), &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Created(w, exclusion)
+}
+
+// POST /v1/admin/matching/exclusions/{id}/revoke
+func (h *AdminHandler) RevokeMatchingExclusion(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		utils.BadRequest(w, "exclusion id is required")
+		return
+	}
+
+	var req models.RevokeMatchingExclusionRequest
+	if !utils.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.BadRequest(w, err.Error())
+		return
+	}
+
+	exclusion, err := h.exclusionService.RevokeExclusion(r.Context(), id, &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, exclusion)
+}
+
+// GET /v1/admin/rides/{id}/economics
+// What the rider paid, discounts, commission and incentive top-ups on a paid ride
+func (h *AdminHandler) GetRideEconomics(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		utils.BadRequest(w, "ride id is required")
+		return
+	}
+
+	economics, err := h.economicsService.GetRideEconomics(r.Context(), id)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, economics)
+}
+
+// GET /v1/admin/finance/take-rate?from=&to=
+// Net revenue as a share of gross fares per region, defaulting to the last 30 days
+func (h *AdminHandler) GetTakeRate(w http.ResponseWriter, r *http.Request) {
+	to := time.Now()
+	from := to.AddDate(0, 0, -30)
+	q := r.URL.Query()
+	for param, dst := range map[string]*time.Time{"from": &from, "to": &to} {
+		if raw := q.Get(param); raw != "" {
+			parsed, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				utils.BadRequest(w, param+" must be an RFC3339 timestamp")
+				return
+			}
+			*dst = parsed
+		}
+	}
+
+	report, err := h.economicsService.TakeRateReport(r.Context(), from, to)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, report)
+}
+
+// PUT /v1/admin/drivers/{id}/training/{module}
+// Called by the training module when a driver completes (or must retake) a module
+func (h *AdminHandler) RecordDriverTraining(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	module := chi.URLParam(r, "module")
+	if id == "" || module == "" {
+		utils.BadRequest(w, "driver id and module are required")
+		return
+	}
+
+	var req models.RecordTrainingRequest
+	if !utils.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.BadRequest(w, err.Error())
+		return
+	}
+
+	status, err := h.trainingService.RecordTraining(r.Context(), id, module, &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, status)
+}
+
+// GET /v1/admin/users/{id}/risk
+func (h *AdminHandler) GetUserRisk(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		utils.BadRequest(w, "user id is required")
+		return
+	}
+
+	risk, err := h.riskService.GetUserRisk(r.Context(), id)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, risk)
+}
+
+// POST /v1/admin/users/{id}/risk-overrides
+func (h *AdminHandler) CreateRiskOverride(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		utils.BadRequest(w, "user id is required")
+		return
+	}
+
+	var req models.CreateRiskOverrideRequest
+	if !utils.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.BadRequest(w, err.Error())
+		return
+	}
+
+	override, err := h.riskService.CreateOverride(r.Context(), id, &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Created(w, override)
+}
+
+// POST /v1/admin/users/{id}/risk-overrides/{overrideId}/revoke
+func (h *AdminHandler) RevokeRiskOverride(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	overrideID := chi.URLParam(r, "overrideId")
+	if id == "" || overrideID == "" {
+		utils.BadRequest(w, "user id and override id are required")
+		return
+	}
+
+	var req models.RevokeRiskOverrideRequest
+	if !utils.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.BadRequest(w, err.Error())
+		return
+	}
+
+	override, err := h.riskService.RevokeOverride(r.Context(), id, overrideID, &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, override)
+}
+
+// GET /v1/admin/maintenance
+func (h *AdminHandler) ListMaintenanceWindows(w http.ResponseWriter, r *http.Request) {
+	windows, err := h.maintenanceService.ListWindows(r.Context())
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, map[string]interface{}{
+		"windows": windows,
+	})
+}
+
+// POST /v1/admin/maintenance
+// Schedules a window during which new rides can't be booked
+func (h *AdminHandler) CreateMaintenanceWindow(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateMaintenanceWindowRequest
+	if !utils.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.BadRequest(w, err.Error())
+		return
+	}
+
+	window, err := h.maintenanceService.CreateWindow(r.Context(), &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Created(w, window)
+}
+
+// POST /v1/admin/maintenance/{id}/activate
+func (h *AdminHandler) ActivateMaintenanceWindow(w http.ResponseWriter, r *http.Request) {
+	h.setMaintenanceWindowActive(w, r, true)
+}
+
+// POST /v1/admin/maintenance/{id}/deactivate
+func (h *AdminHandler) DeactivateMaintenanceWindow(w http.ResponseWriter, r *http.Request) {
+	h.setMaintenanceWindowActive(w, r, false)
+}
+
+func (h *AdminHandler) setMaintenanceWindowActive(w http.ResponseWriter, r *http.Request, active bool) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		utils.BadRequest(w, "maintenance window id is required")
+		return
+	}
+
+	window, err := h.maintenanceService.SetWindowActive(r.Context(), id, active)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, window)
+}
+
+// GET /v1/admin/waiting-content
+func (h *AdminHandler) ListWaitingContent(w http.ResponseWriter, r *http.Request) {
+	contents, err := h.waitingService.ListContent(r.Context())
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, map[string]interface{}{
+		"content": contents,
+	})
+}
+
+// POST /v1/admin/waiting-content
+// Adds a safety tip or promo banner to riders' waiting screen
+func (h *AdminHandler) CreateWaitingContent(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateWaitingContentRequest
+	if !utils.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.BadRequest(w, err.Error())
+		return
+	}
+
+	content, err := h.waitingService.CreateContent(r.Context(), &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Created(w, content)
+}
+
+// POST /v1/admin/waiting-content/{id}/activate
+func (h *AdminHandler) ActivateWaitingContent(w http.ResponseWriter, r *http.Request) {
+	h.setWaitingContentActive(w, r, true)
+}
+
+// POST /v1/admin/waiting-content/{id}/deactivate
+func (h *AdminHandler) DeactivateWaitingContent(w http.ResponseWriter, r *http.Request) {
+	h.setWaitingContentActive(w, r, false)
+}
+
+func (h *AdminHandler) setWaitingContentActive(w http.ResponseWriter, r *http.Request, active bool) {
+	content, err := h.waitingService.SetContentActive(r.Context(), chi.URLParam(r, "id"), active)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, content)
+}
+
+// GET /v1/admin/reviews?status=&limit=
+// Rating feedback waiting on moderation (flagged by the filter or appealed) by default
+func (h *AdminHandler) ListReviewQueue(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			utils.BadRequest(w, "limit must be an integer")
+			return
+		}
+		limit = n
+	}
+
+	reviews, err := h.ratingService.FeedbackQueue(r.Context(), r.URL.Query().Get("status"), limit)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, map[string]interface{}{
+		"reviews": reviews,
+	})
+}
+
+// POST /v1/admin/reviews/{id}/publish
+func (h *AdminHandler) PublishReview(w http.ResponseWriter, r *http.Request) {
+	h.moderateReview(w, r, models.FeedbackPublished)
+}
+
+// POST /v1/admin/reviews/{id}/remove
+func (h *AdminHandler) RemoveReview(w http.ResponseWriter, r *http.Request) {
+	h.moderateReview(w, r, models.FeedbackRemoved)
+}
+
+func (h *AdminHandler) moderateReview(w http.ResponseWriter, r *http.Request, status string) {
+	rating, err := h.ratingService.ModerateFeedback(r.Context(), chi.URLParam(r, "id"), status)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, rating)
+}
+
+// GET /v1/admin/legal-documents
+func (h *AdminHandler) ListLegalDocuments(w http.ResponseWriter, r *http.Request) {
+	docs, err := h.consentService.ListDocuments(r.Context())
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, map[string]interface{}{
+		"documents": docs,
+	})
+}
+
+// POST /v1/admin/legal-documents
+// Publishes a new version of the terms, privacy policy or a regional addendum
+func (h *AdminHandler) PublishLegalDocument(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateLegalDocumentRequest
+	if !utils.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.BadRequest(w, err.Error())
+		return
+	}
+
+	doc, err := h.consentService.PublishDocument(r.Context(), &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Created(w, doc)
+}
+
+// GET /v1/admin/retention/report
+// Dry run: how many trips per region the retention job would anonymize now
+func (h *AdminHandler) RetentionReport(w http.ResponseWriter, r *http.Request) {
+	report, err := h.retentionService.Run(r.Context(), true)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, report)
+}
+
+// POST /v1/admin/retention/run
+// Anonymizes trips past their retention period without waiting for the job
+func (h *AdminHandler) RunRetention(w http.ResponseWriter, r *http.Request) {
+	report, err := h.retentionService.Run(r.Context(), false)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, report)
+}
+
+// GET /v1/admin/backups?limit=
+func (h *AdminHandler) ListBackups(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			utils.BadRequest(w, "limit must be an integer")
+			return
+		}
+		limit = n
+	}
+
+	backups, err := h.backupService.ListBackups(r.Context(), limit)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, map[string]interface{}{
+		"backups": backups,
+	})
+}
+
+// POST /v1/admin/backups
+// Queues a logical backup of the core tables; poll GET /backups/{id} until ready
+func (h *AdminHandler) RequestBackup(w http.ResponseWriter, r *http.Request) {
+	backup, err := h.backupService.RequestBackup(r.Context())
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusAccepted, backup)
+}
+
+// GET /v1/admin/backups/{id}
+func (h *AdminHandler) GetBackup(w http.ResponseWriter, r *http.Request) {
+	backup, err := h.backupService.GetBackup(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, backup)
+}
+
+// maxDriverImportRows caps one bulk import; bigger fleets are split across requests
+const maxDriverImportRows = 5000
+
+// POST /v1/admin/drivers:import
+//
+// Takes {"drivers": [...]} with the fields of a driver sign-up, or text/csv with
+// a header row naming the same fields. Rows are checked one by one and the
+// response reports each row that wasn't created.
+func (h *AdminHandler) ImportDrivers(w http.ResponseWriter, r *http.Request) {
+	var rows []*models.DriverImportRow
+	var invalid []*models.DriverImportRowError
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "text/csv" {
+		var err error
+		rows, invalid, err = parseDriverCSV(r.Body)
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			utils.Error(w, apperrors.RequestTooLarge(tooLarge.Limit))
+			return
+		}
+		if err != nil {
+			utils.BadRequest(w, err.Error())
+			return
+		}
+	} else {
+		var req struct {
+			Drivers []models.CreateDriverRequest `json:"drivers"`
+		}
+		if !utils.DecodeJSON(w, r, &req) {
+			return
+		}
+		for i := range req.Drivers {
+			rows = append(rows, &models.DriverImportRow{Row: i + 1, Driver: req.Drivers[i]})
+		}
+	}
+
+	total := len(rows) + len(invalid)
+	if total == 0 {
+		utils.BadRequest(w, "no drivers to import")
+		return
+	}
+	if total > maxDriverImportRows {
+		utils.BadRequest(w, fmt.Sprintf("at most %d drivers can be imported at once", maxDriverImportRows))
+		return
+	}
+
+	var valid []*models.DriverImportRow
+	for _, row := range rows {
+		if err := h.validate.Struct(row.Driver); err != nil {
+			invalid = append(invalid, &models.DriverImportRowError{Row: row.Row, Phone: row.Driver.Phone, Error: err.Error()})
+			continue
+		}
+		if err := h.vehicleTypeService.CheckActive(r.Context(), row.Driver.VehicleType, ""); err != nil {
+			apiErr, ok := err.(*apperrors.APIError)
+			if !ok {
+				handleError(w, err)
+				return
+			}
+			invalid = append(invalid, &models.DriverImportRowError{Row: row.Row, Phone: row.Driver.Phone, Error: apiErr.Message})
+			continue
+		}
+		valid = append(valid, row)
+	}
+
+	result, err := h.adminService.ImportDrivers(r.Context(), valid)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+	result.Total = total
+	result.Errors = append(result.Errors, invalid...)
+	sort.Slice(result.Errors, func(i, j int) bool { return result.Errors[i].Row < result.Errors[j].Row })
+
+	utils.Success(w, http.StatusOK, result)
+}
+
+// driverCSVColumns maps the columns a driver import understands onto the sign-up request
+var driverCSVColumns = map[string]func(req *models.CreateDriverRequest, value string) error{
+	"phone":          func(req *models.CreateDriverRequest, v string) error { req.Phone = v; return nil },
+	"name":           func(req *models.CreateDriverRequest, v string) error { req.Name = v; return nil },
+	"email":          func(req *models.CreateDriverRequest, v string) error { req.Email = v; return nil },
+	"license_number": func(req *models.CreateDriverRequest, v string) error { req.LicenseNumber = v; return nil },
+	"vehicle_type":   func(req *models.CreateDriverRequest, v string) error { req.VehicleType = v; return nil },
+	"vehicle_number": func(req *models.CreateDriverRequest, v string) error { req.VehicleNumber = v; return nil },
+	"gender":         func(req *models.CreateDriverRequest, v string) error { req.Gender = v; return nil },
+	"is_ev": func(req *models.CreateDriverRequest, v string) error {
+		if v == "" {
+			return nil
+		}
+		isEV, err := strconv.ParseBool(v)
+		if err != nil {
+			return errors.New("is_ev must be true or false")
+		}
+		req.IsEV = isEV
+		return nil
+	},
+}
+
+// parseDriverCSV reads a driver import. A bad header fails the whole import;
+// malformed rows are returned as row errors so the rest can still be imported.
+func parseDriverCSV(body io.Reader) ([]*models.DriverImportRow, []*models.DriverImportRowError, error) {
+	reader := csv.NewReader(body)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid CSV header: %w", err)
+	}
+	setters := make([]func(*models.CreateDriverRequest, string) error, len(header))
+	columns := make(map[string]bool)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		set, ok := driverCSVColumns[name]
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown CSV column %q", name)
+		}
+		setters[i] = set
+		columns[name] = true
+	}
+	for _, name := range []string{"phone", "name", "license_number", "vehicle_type", "vehicle_number"} {
+		if !columns[name] {
+			return nil, nil, fmt.Errorf("CSV is missing the %s column", name)
+		}
+	}
+
+	var rows []*models.DriverImportRow
+	var invalid []*models.DriverImportRowError
+	for n := 1; ; n++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			invalid = append(invalid, &models.DriverImportRowError{Row: n, Error: parseErr.Err.Error()})
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		row := &models.DriverImportRow{Row: n}
+		var rowErr error
+		for i, value := range record {
+			if rowErr = setters[i](&row.Driver, strings.TrimSpace(value)); rowErr != nil {
+				break
+			}
+		}
+		if rowErr != nil {
+			invalid = append(invalid, &models.DriverImportRowError{Row: n, Phone: row.Driver.Phone, Error: rowErr.Error()})
+			continue
+		}
+		rows = append(rows, row)
+	}
+	return rows, invalid, nil
+}
@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/service"
+	"github.com/aditya/go-comet/pkg/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+)
+
+type BidHandler struct {
+	bidService service.BidService
+	validate   *validator.Validate
+}
+
+func NewBidHandler(bidService service.BidService) *BidHandler {
+	return &BidHandler{
+		bidService: bidService,
+		validate:   validator.New(),
+	}
+}
+
+func (h *BidHandler) RegisterRoutes(r chi.Router) {
+	r.Post("/drivers/{id}/offers/{offerId}/counter", h.CounterOffer)
+	r.Get("/rides/{id}/bids", h.ListBids)
+	r.Post("/rides/{id}/bids/{offerId}/accept", h.AcceptBid)
+}
+
+// POST /v1/drivers/{id}/offers/{offerId}/counter
+func (h *BidHandler) CounterOffer(w http.ResponseWriter, r *http.Request) {
+	driverID := chi.URLParam(r, "id")
+	offerID := chi.URLParam(r, "offerId")
+	if driverID == "" || offerID == "" {
+		utils.BadRequest(w, "driver id and offer id are required")
+		return
+	}
+
+	var req models.CounterOfferRequest
+	if !utils.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.BadRequest(w, err.Error())
+		return
+	}
+
+	offer, err := h.bidService.CounterOffer(r.Context(), driverID, offerID, &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, offer)
+}
+
+// GET /v1/rides/{id}/bids
+func (h *BidHandler) ListBids(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		utils.BadRequest(w, "ride id is required")
+		return
+	}
+
+	bids, err := h.bidService.ListBids(r.Context(), id)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, map[string]interface{}{
+		"bids": bids,
+	})
+}
+
+// POST /v1/rides/{id}/bids/{offerId}/accept
+func (h *BidHandler) AcceptBid(w http.ResponseWriter, r *http.Request) {
+	rideID := chi.URLParam(r, "id")
+	offerID := chi.URLParam(r, "offerId")
+	if rideID == "" || offerID == "" {
+		utils.BadRequest(w, "ride id and offer id are required")
+		return
+	}
+
+	var req models.AcceptBidRequest
+	if !utils.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.BadRequest(w, err.Error())
+		return
+	}
+
+	ride, err := h.bidService.AcceptBid(r.Context(), rideID, offerID, &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, ride)
+}
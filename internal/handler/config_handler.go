@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/aditya/go-comet/internal/middleware"
+	"github.com/aditya/go-comet/internal/service"
+	"github.com/aditya/go-comet/pkg/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+// clientConfigMaxAge lets clients and CDNs cache the config briefly
+const clientConfigMaxAge = "public, max-age=300"
+
+type ConfigHandler struct {
+	clientConfigService service.ClientConfigService
+}
+
+func NewConfigHandler(clientConfigService service.ClientConfigService) *ConfigHandler {
+	return &ConfigHandler{
+		clientConfigService: clientConfigService,
+	}
+}
+
+func (h *ConfigHandler) RegisterRoutes(r chi.Router) {
+	r.Get("/config/client", h.GetClientConfig)
+}
+
+// GET /v1/config/client?region=&lat=&lng=
+func (h *ConfigHandler) GetClientConfig(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	var lat, lng *float64
+	if q.Get("lat") != "" || q.Get("lng") != "" {
+		parsedLat, latErr := strconv.ParseFloat(q.Get("lat"), 64)
+		parsedLng, lngErr := strconv.ParseFloat(q.Get("lng"), 64)
+		if latErr != nil || lngErr != nil {
+			utils.BadRequest(w, "lat and lng must both be numbers")
+			return
+		}
+		lat, lng = &parsedLat, &parsedLng
+	}
+
+	cfg, err := h.clientConfigService.GetClientConfig(r.Context(), q.Get("region"), lat, lng)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	// The version status is per app, so such responses can't be shared
+	if status := middleware.AppVersionFromContext(r.Context()); status != nil {
+		cfg.AppVersion = status
+		w.Header().Set("Cache-Control", "private, max-age=300")
+	} else {
+		w.Header().Set("Cache-Control", clientConfigMaxAge)
+	}
+	utils.Success(w, http.StatusOK, cfg)
+}
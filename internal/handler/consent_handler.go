@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/aditya/go-comet/internal/middleware"
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/service"
+	"github.com/aditya/go-comet/pkg/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+)
+
+type ConsentHandler struct {
+	consentService service.ConsentService
+	validate       *validator.Validate
+}
+
+func NewConsentHandler(consentService service.ConsentService) *ConsentHandler {
+	return &ConsentHandler{
+		consentService: consentService,
+		validate:       validator.New(),
+	}
+}
+
+func (h *ConsentHandler) RegisterRoutes(r chi.Router) {
+	r.Get("/users/{id}/consents", h.GetUserConsents)
+	r.Post("/users/{id}/consents", h.AcceptUserConsents)
+	r.Get("/drivers/{id}/consents", h.GetDriverConsents)
+	r.Post("/drivers/{id}/consents", h.AcceptDriverConsents)
+}
+
+// GET /v1/users/{id}/consents
+func (h *ConsentHandler) GetUserConsents(w http.ResponseWriter, r *http.Request) {
+	h.getConsents(w, r, models.RaterUser)
+}
+
+// GET /v1/drivers/{id}/consents
+func (h *ConsentHandler) GetDriverConsents(w http.ResponseWriter, r *http.Request) {
+	h.getConsents(w, r, models.RaterDriver)
+}
+
+func (h *ConsentHandler) getConsents(w http.ResponseWriter, r *http.Request, partyType string) {
+	records, err := h.consentService.History(r.Context(), partyType, chi.URLParam(r, "id"))
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, map[string]interface{}{
+		"consents": records,
+	})
+}
+
+// POST /v1/users/{id}/consents
+func (h *ConsentHandler) AcceptUserConsents(w http.ResponseWriter, r *http.Request) {
+	h.acceptConsents(w, r, models.RaterUser)
+}
+
+// POST /v1/drivers/{id}/consents
+func (h *ConsentHandler) AcceptDriverConsents(w http.ResponseWriter, r *http.Request) {
+	h.acceptConsents(w, r, models.RaterDriver)
+}
+
+func (h *ConsentHandler) acceptConsents(w http.ResponseWriter, r *http.Request, partyType string) {
+	var req models.AcceptConsentRequest
+	if !utils.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.BadRequest(w, err.Error())
+		return
+	}
+
+	// Consent records keep the address without the connection's port
+	ip := middleware.ClientIP(r)
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+
+	records, err := h.consentService.Accept(r.Context(), partyType, chi.URLParam(r, "id"), &req, ip)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Created(w, map[string]interface{}{
+		"consents": records,
+	})
+}
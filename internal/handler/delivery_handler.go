@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/aditya/go-comet/internal/middleware"
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/service"
+	"github.com/aditya/go-comet/pkg/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+)
+
+type DeliveryHandler struct {
+	deliveryService service.DeliveryService
+	validate        *validator.Validate
+}
+
+func NewDeliveryHandler(deliveryService service.DeliveryService) *DeliveryHandler {
+	return &DeliveryHandler{
+		deliveryService: deliveryService,
+		validate:        validator.New(),
+	}
+}
+
+func (h *DeliveryHandler) RegisterRoutes(r chi.Router) {
+	r.Get("/rides/{id}/delivery", h.GetDelivery)
+	r.Post("/rides/{id}/delivery/pickup", h.ConfirmPickup)
+	r.Post("/rides/{id}/delivery/dropoff", h.ConfirmDropoff)
+}
+
+// GET /v1/rides/{id}/delivery
+func (h *DeliveryHandler) GetDelivery(w http.ResponseWriter, r *http.Request) {
+	rideID := chi.URLParam(r, "id")
+	if rideID == "" {
+		utils.BadRequest(w, "ride id is required")
+		return
+	}
+
+	delivery, err := h.deliveryService.GetDelivery(r.Context(), rideID)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	// The code is the recipient's to give; drivers must not see it
+	if principal := middleware.PrincipalFromContext(r.Context()); principal != nil && principal.Type == middleware.PrincipalDriver {
+		delivery.OTP = ""
+	}
+
+	utils.Success(w, http.StatusOK, delivery)
+}
+
+// POST /v1/rides/{id}/delivery/pickup
+func (h *DeliveryHandler) ConfirmPickup(w http.ResponseWriter, r *http.Request) {
+	rideID := chi.URLParam(r, "id")
+	if rideID == "" {
+		utils.BadRequest(w, "ride id is required")
+		return
+	}
+
+	var req models.ConfirmPickupRequest
+	if !utils.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.BadRequest(w, err.Error())
+		return
+	}
+
+	delivery, err := h.deliveryService.ConfirmPickup(r.Context(), rideID, &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, delivery)
+}
+
+// POST /v1/rides/{id}/delivery/dropoff
+func (h *DeliveryHandler) ConfirmDropoff(w http.ResponseWriter, r *http.Request) {
+	rideID := chi.URLParam(r, "id")
+	if rideID == "" {
+		utils.BadRequest(w, "ride id is required")
+		return
+	}
+
+	var req models.ConfirmDropoffRequest
+	if !utils.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.BadRequest(w, err.Error())
+		return
+	}
+
+	delivery, err := h.deliveryService.ConfirmDropoff(r.Context(), rideID, &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, delivery)
+}
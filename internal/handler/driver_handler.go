@@ -1,11 +1,14 @@
 package handler
 
 import (
-	"encoding/json"
+	"context"
+	"log"
 	"net/http"
 
+	"github.com/aditya/go-comet/internal/middleware"
 	"github.com/aditya/go-comet/internal/models"
 	"github.com/aditya/go-comet/internal/service"
+	"github.com/aditya/go-comet/internal/worker"
 	"github.com/aditya/go-comet/pkg/utils"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator/v10"
@@ -14,13 +17,17 @@ import (
 type DriverHandler struct {
 	driverService   service.DriverService
 	matchingService service.MatchingService
+	earningsService service.EarningsService
+	tasks           *worker.Tasks
 	validate        *validator.Validate
 }
 
-func NewDriverHandler(driverService service.DriverService, matchingService service.MatchingService) *DriverHandler {
+func NewDriverHandler(driverService service.DriverService, matchingService service.MatchingService, earningsService service.EarningsService, tasks *worker.Tasks) *DriverHandler {
 	return &DriverHandler{
 		driverService:   driverService,
 		matchingService: matchingService,
+		earningsService: earningsService,
+		tasks:           tasks,
 		validate:        validator.New(),
 	}
 }
@@ -31,16 +38,18 @@ func (h *DriverHandler) RegisterRoutes(r chi.Router) {
 	r.Post("/drivers/{id}/location", h.UpdateLocation)
 	r.Post("/drivers/{id}/accept", h.AcceptRide)
 	r.Post("/drivers/{id}/decline", h.DeclineRide)
+	r.Post("/drivers/{id}/cancel", h.CancelRide)
 	r.Post("/drivers/{id}/online", h.GoOnline)
 	r.Post("/drivers/{id}/offline", h.GoOffline)
 	r.Get("/drivers/{id}/offers", h.GetPendingOffers)
+	r.Post("/drivers/{id}/selfie", h.SubmitSelfie)
+	r.Put("/drivers/{id}/payment-methods", h.SetPaymentMethods)
 }
 
 // POST /v1/drivers
 func (h *DriverHandler) CreateDriver(w http.ResponseWriter, r *http.Request) {
 	var req models.CreateDriverRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.BadRequest(w, "invalid request body")
+	if !utils.DecodeJSON(w, r, &req) {
 		return
 	}
 
@@ -72,7 +81,22 @@ func (h *DriverHandler) GetDriver(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	utils.Success(w, http.StatusOK, driver.ToResponse())
+	response := driver.ToResponse()
+	if driver.DailyEarningsGoal != nil {
+		progress, err := h.earningsService.GetGoalProgress(r.Context(), id)
+		if err != nil {
+			log.Printf("failed to load earnings goal for driver %s: %v", id, err)
+		}
+		response.EarningsGoal = progress
+	}
+	response.AppVersion = middleware.AppVersionFromContext(r.Context())
+	pending, err := h.driverService.PendingConsents(r.Context(), driver)
+	if err != nil {
+		log.Printf("failed to load pending consents for driver %s: %v", id, err)
+	}
+	response.PendingConsents = pending
+
+	utils.Success(w, http.StatusOK, response)
 }
 
 // POST /v1/drivers/{id}/location
@@ -84,8 +108,7 @@ func (h *DriverHandler) UpdateLocation(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req models.UpdateDriverLocationRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.BadRequest(w, "invalid request body")
+	if !utils.DecodeJSON(w, r, &req) {
 		return
 	}
 
@@ -114,8 +137,7 @@ func (h *DriverHandler) AcceptRide(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req models.AcceptRideRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.BadRequest(w, "invalid request body")
+	if !utils.DecodeJSON(w, r, &req) {
 		return
 	}
 
@@ -136,6 +158,43 @@ func (h *DriverHandler) AcceptRide(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// POST /v1/drivers/{id}/cancel
+func (h *DriverHandler) CancelRide(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		utils.BadRequest(w, "driver id is required")
+		return
+	}
+
+	var req models.DriverCancelRideRequest
+	if !utils.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.BadRequest(w, err.Error())
+		return
+	}
+
+	ride, err := h.driverService.CancelAssignedRide(r.Context(), id, &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	// Find the rider a replacement; while draining, escalation elsewhere does
+	h.tasks.Go(r.Context(), func(ctx context.Context) {
+		if err := h.matchingService.FindAndOfferDrivers(ctx, ride); err != nil {
+			log.Printf("failed to rematch ride %s: %v", ride.ID, err)
+		}
+	})
+
+	utils.Success(w, http.StatusOK, map[string]interface{}{
+		"status": "reassigning",
+		"ride":   ride.ToResponse(),
+	})
+}
+
 // POST /v1/drivers/{id}/decline
 func (h *DriverHandler) DeclineRide(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
@@ -147,8 +206,7 @@ func (h *DriverHandler) DeclineRide(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		OfferID string `json:"offer_id" validate:"required,uuid"`
 	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.BadRequest(w, "invalid request body")
+	if !utils.DecodeJSON(w, r, &req) {
 		return
 	}
 
@@ -216,3 +274,57 @@ func (h *DriverHandler) GetPendingOffers(w http.ResponseWriter, r *http.Request)
 		"offers": offers,
 	})
 }
+
+// POST /v1/drivers/{id}/selfie
+func (h *DriverHandler) SubmitSelfie(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		utils.BadRequest(w, "driver id is required")
+		return
+	}
+
+	var req models.SubmitSelfieRequest
+	if !utils.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.BadRequest(w, err.Error())
+		return
+	}
+
+	check, err := h.driverService.SubmitSelfie(r.Context(), id, &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Created(w, check)
+}
+
+// PUT /v1/drivers/{id}/payment-methods
+func (h *DriverHandler) SetPaymentMethods(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		utils.BadRequest(w, "driver id is required")
+		return
+	}
+
+	var req models.SetPaymentMethodsRequest
+	if !utils.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.BadRequest(w, err.Error())
+		return
+	}
+
+	driver, err := h.driverService.SetPaymentMethods(r.Context(), id, &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, driver.ToResponse())
+}
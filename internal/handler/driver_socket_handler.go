@@ -0,0 +1,301 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aditya/go-comet/internal/cache"
+	apperrors "github.com/aditya/go-comet/internal/errors"
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/service"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	// The server pings this often; a connection that sends nothing for
+	// driverSocketReadTimeout (a pong, a location, anything) is dropped
+	driverSocketPingInterval = 20 * time.Second
+	driverSocketReadTimeout  = 60 * time.Second
+	driverSocketWriteTimeout = 10 * time.Second
+	// Pending offers are re-checked this often in case a pub/sub message was missed
+	driverSocketSyncInterval = 10 * time.Second
+)
+
+// driverSocketMessage is a frame sent by the driver app
+type driverSocketMessage struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// driverSocketEvent is a frame sent to the driver app
+type driverSocketEvent struct {
+	Type      string      `json:"type"`
+	Data      interface{} `json:"data,omitempty"`
+	Timestamp string      `json:"timestamp"`
+}
+
+// DriverSocketHandler serves the driver app's WebSocket, which takes location
+// updates and pushes ride offers as they are created
+type DriverSocketHandler struct {
+	driverService   service.DriverService
+	matchingService service.MatchingService
+	redis           *redis.Client
+	validate        *validator.Validate
+	clients         map[string]map[chan struct{}]bool // driverID -> connections
+	mu              sync.RWMutex
+	// closed on shutdown so apps reconnect to another instance
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func NewDriverSocketHandler(driverService service.DriverService, matchingService service.MatchingService, redisClient *redis.Client) *DriverSocketHandler {
+	handler := &DriverSocketHandler{
+		driverService:   driverService,
+		matchingService: matchingService,
+		redis:           redisClient,
+		validate:        validator.New(),
+		clients:         make(map[string]map[chan struct{}]bool),
+		done:            make(chan struct{}),
+	}
+
+	go handler.startPubSubListener()
+
+	return handler
+}
+
+func (h *DriverSocketHandler) RegisterRoutes(r chi.Router) {
+	r.Get("/drivers/{id}/ws", h.Connect)
+}
+
+// Shutdown sends every connected app a reconnect event and closes its socket.
+// WebSockets are hijacked from the HTTP server, so its shutdown doesn't wait
+// for them.
+func (h *DriverSocketHandler) Shutdown() {
+	h.closeOnce.Do(func() { close(h.done) })
+}
+
+// GET /v1/drivers/{id}/ws
+// Upgrades to a WebSocket. On connect, and again on every reconnect, the server
+// sends every offer still pending so nothing offered while the app was away is
+// lost; ?seen=id1,id2 skips offers the app is already showing.
+func (h *DriverSocketHandler) Connect(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		http.Error(w, "driver id required", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.driverService.GetDriver(r.Context(), id); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	seen := make(map[string]bool)
+	if raw := r.URL.Query().Get("seen"); raw != "" {
+		for _, offerID := range strings.Split(raw, ",") {
+			if offerID = strings.TrimSpace(offerID); offerID != "" {
+				seen[offerID] = true
+			}
+		}
+	}
+
+	server := websocket.Server{
+		// Driver apps are native clients and send no Origin header
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(ws *websocket.Conn) {
+			h.serve(r.Context(), ws, id, seen)
+		},
+	}
+	server.ServeHTTP(w, r)
+}
+
+func (h *DriverSocketHandler) serve(ctx context.Context, ws *websocket.Conn, driverID string, sent map[string]bool) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	notify := make(chan struct{}, 1)
+	h.registerClient(driverID, notify)
+	defer h.unregisterClient(driverID, notify)
+
+	inbound := make(chan driverSocketMessage)
+	closed := make(chan struct{})
+	go h.readLoop(ctx, ws, inbound, closed)
+
+	err := h.send(ws, "hello", map[string]interface{}{
+		"driver_id":             driverID,
+		"ping_interval_seconds": int(driverSocketPingInterval / time.Second),
+	})
+	if err == nil {
+		err = h.syncOffers(ctx, ws, driverID, sent)
+	}
+
+	ping := time.NewTicker(driverSocketPingInterval)
+	defer ping.Stop()
+	resync := time.NewTicker(driverSocketSyncInterval)
+	defer resync.Stop()
+
+	for err == nil {
+		select {
+		case <-closed:
+			return
+		case <-h.done:
+			h.send(ws, "reconnect", nil)
+			return
+		case <-notify:
+			err = h.syncOffers(ctx, ws, driverID, sent)
+		case <-resync.C:
+			err = h.syncOffers(ctx, ws, driverID, sent)
+		case <-ping.C:
+			err = h.send(ws, "ping", nil)
+		case msg := <-inbound:
+			err = h.handleMessage(ctx, ws, driverID, msg)
+		}
+	}
+}
+
+// readLoop forwards the app's frames until the connection drops or goes quiet
+func (h *DriverSocketHandler) readLoop(ctx context.Context, ws *websocket.Conn, inbound chan<- driverSocketMessage, closed chan<- struct{}) {
+	defer close(closed)
+
+	for {
+		ws.SetReadDeadline(time.Now().Add(driverSocketReadTimeout))
+		var frame []byte
+		if err := websocket.Message.Receive(ws, &frame); err != nil {
+			return
+		}
+
+		var msg driverSocketMessage
+		if err := json.Unmarshal(frame, &msg); err != nil {
+			msg = driverSocketMessage{Type: "invalid"}
+		}
+		select {
+		case inbound <- msg:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (h *DriverSocketHandler) handleMessage(ctx context.Context, ws *websocket.Conn, driverID string, msg driverSocketMessage) error {
+	switch msg.Type {
+	case "ping":
+		return h.send(ws, "pong", nil)
+	case "pong":
+		// Receiving it already pushed the read deadline back
+		return nil
+	case "location":
+		var req models.UpdateDriverLocationRequest
+		if err := json.Unmarshal(msg.Data, &req); err != nil {
+			return h.sendError(ws, apperrors.BadRequest("invalid location payload"))
+		}
+		if err := h.validate.Struct(req); err != nil {
+			return h.sendError(ws, apperrors.BadRequest(err.Error()))
+		}
+		if err := h.driverService.UpdateLocation(ctx, driverID, &req); err != nil {
+			return h.sendError(ws, err)
+		}
+		return nil
+	default:
+		return h.sendError(ws, apperrors.BadRequest("unknown message type"))
+	}
+}
+
+// syncOffers sends offers the app hasn't been sent yet on this connection and
+// tells it about sent offers that are no longer pending (taken, expired, declined)
+func (h *DriverSocketHandler) syncOffers(ctx context.Context, ws *websocket.Conn, driverID string, sent map[string]bool) error {
+	offers, err := h.matchingService.GetPendingOffers(ctx, driverID)
+	if err != nil {
+		log.Printf("driver socket: failed to load offers for driver %s: %v", driverID, err)
+		return nil
+	}
+
+	pending := make(map[string]bool, len(offers))
+	for _, offer := range offers {
+		pending[offer.ID] = true
+		if sent[offer.ID] {
+			continue
+		}
+		if err := h.send(ws, "offer", offer); err != nil {
+			return err
+		}
+		sent[offer.ID] = true
+	}
+
+	for offerID := range sent {
+		if pending[offerID] {
+			continue
+		}
+		if err := h.send(ws, "offer_closed", map[string]string{"offer_id": offerID}); err != nil {
+			return err
+		}
+		delete(sent, offerID)
+	}
+	return nil
+}
+
+func (h *DriverSocketHandler) send(ws *websocket.Conn, eventType string, data interface{}) error {
+	ws.SetWriteDeadline(time.Now().Add(driverSocketWriteTimeout))
+	return websocket.JSON.Send(ws, driverSocketEvent{
+		Type:      eventType,
+		Data:      data,
+		Timestamp: time.Now().Format(time.RFC3339),
+	})
+}
+
+// sendError reports a rejected message; the connection stays open
+func (h *DriverSocketHandler) sendError(ws *websocket.Conn, err error) error {
+	apiErr, ok := err.(*apperrors.APIError)
+	if !ok {
+		apiErr = apperrors.InternalError("internal server error")
+	}
+	return h.send(ws, "error", apiErr)
+}
+
+func (h *DriverSocketHandler) registerClient(driverID string, ch chan struct{}) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.clients[driverID] == nil {
+		h.clients[driverID] = make(map[chan struct{}]bool)
+	}
+	h.clients[driverID][ch] = true
+}
+
+func (h *DriverSocketHandler) unregisterClient(driverID string, ch chan struct{}) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if clients, ok := h.clients[driverID]; ok {
+		delete(clients, ch)
+		if len(clients) == 0 {
+			delete(h.clients, driverID)
+		}
+	}
+}
+
+// startPubSubListener wakes a driver's connections when any instance creates an offer for them
+func (h *DriverSocketHandler) startPubSubListener() {
+	ctx := context.Background()
+	pubsub := h.redis.Subscribe(ctx, cache.DriverOfferChannel)
+	defer pubsub.Close()
+
+	for msg := range pubsub.Channel() {
+		h.mu.RLock()
+		for ch := range h.clients[msg.Payload] {
+			select {
+			case ch <- struct{}{}:
+			default:
+				// A sync is already queued
+			}
+		}
+		h.mu.RUnlock()
+	}
+}
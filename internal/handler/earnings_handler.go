@@ -0,0 +1,246 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/service"
+	"github.com/aditya/go-comet/pkg/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+)
+
+type EarningsHandler struct {
+	earningsService  service.EarningsService
+	deductionService service.DeductionService
+	exportService    service.TripExportService
+	validate         *validator.Validate
+}
+
+func NewEarningsHandler(earningsService service.EarningsService, deductionService service.DeductionService, exportService service.TripExportService) *EarningsHandler {
+	return &EarningsHandler{
+		earningsService:  earningsService,
+		deductionService: deductionService,
+		exportService:    exportService,
+		validate:         validator.New(),
+	}
+}
+
+func (h *EarningsHandler) RegisterRoutes(r chi.Router) {
+	r.Get("/drivers/{id}/earnings-goal", h.GetGoal)
+	r.Put("/drivers/{id}/earnings-goal", h.SetGoal)
+	r.Get("/drivers/{id}/earnings", h.GetEarnings)
+	r.Get("/drivers/{id}/earnings/statement", h.GetStatement)
+	r.Get("/drivers/{id}/payouts", h.GetPayouts)
+	r.Post("/drivers/{id}/payouts", h.RequestPayout)
+	r.Get("/drivers/{id}/deductions", h.GetDeductions)
+	r.Get("/drivers/{id}/trips/export", h.ExportTrips)
+}
+
+// GET /v1/drivers/{id}/earnings-goal
+func (h *EarningsHandler) GetGoal(w http.ResponseWriter, r *http.Request) {
+	driverID := chi.URLParam(r, "id")
+	if driverID == "" {
+		utils.BadRequest(w, "driver id is required")
+		return
+	}
+
+	progress, err := h.earningsService.GetGoalProgress(r.Context(), driverID)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, map[string]interface{}{
+		"earnings_goal": progress,
+	})
+}
+
+// PUT /v1/drivers/{id}/earnings-goal
+func (h *EarningsHandler) SetGoal(w http.ResponseWriter, r *http.Request) {
+	driverID := chi.URLParam(r, "id")
+	if driverID == "" {
+		utils.BadRequest(w, "driver id is required")
+		return
+	}
+
+	var req models.SetEarningsGoalRequest
+	if !utils.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.BadRequest(w, err.Error())
+		return
+	}
+
+	progress, err := h.earningsService.SetDailyGoal(r.Context(), driverID, &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, map[string]interface{}{
+		"earnings_goal": progress,
+	})
+}
+
+// GET /v1/drivers/{id}/earnings/statement?from=&to=
+func (h *EarningsHandler) GetStatement(w http.ResponseWriter, r *http.Request) {
+	driverID := chi.URLParam(r, "id")
+	if driverID == "" {
+		utils.BadRequest(w, "driver id is required")
+		return
+	}
+
+	from, to, ok := parseEarningsPeriod(w, r)
+	if !ok {
+		return
+	}
+
+	statement, err := h.earningsService.GetStatement(r.Context(), driverID, from, to)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, statement)
+}
+
+// GET /v1/drivers/{id}/earnings?from=&to=
+// Paid trips with the commission taken from each and the driver's balance
+func (h *EarningsHandler) GetEarnings(w http.ResponseWriter, r *http.Request) {
+	driverID := chi.URLParam(r, "id")
+	if driverID == "" {
+		utils.BadRequest(w, "driver id is required")
+		return
+	}
+
+	from, to, ok := parseEarningsPeriod(w, r)
+	if !ok {
+		return
+	}
+
+	earnings, err := h.earningsService.GetEarnings(r.Context(), driverID, from, to)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, earnings)
+}
+
+// GET /v1/drivers/{id}/payouts
+func (h *EarningsHandler) GetPayouts(w http.ResponseWriter, r *http.Request) {
+	driverID := chi.URLParam(r, "id")
+	if driverID == "" {
+		utils.BadRequest(w, "driver id is required")
+		return
+	}
+
+	payouts, err := h.earningsService.GetPayouts(r.Context(), driverID)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, payouts)
+}
+
+// POST /v1/drivers/{id}/payouts
+// Pays out the requested amount, or the whole balance, to the driver's bank account
+func (h *EarningsHandler) RequestPayout(w http.ResponseWriter, r *http.Request) {
+	driverID := chi.URLParam(r, "id")
+	if driverID == "" {
+		utils.BadRequest(w, "driver id is required")
+		return
+	}
+
+	var req models.RequestPayoutRequest
+	if !utils.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.BadRequest(w, err.Error())
+		return
+	}
+
+	payout, err := h.earningsService.RequestPayout(r.Context(), driverID, &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Created(w, payout)
+}
+
+// GET /v1/drivers/{id}/deductions
+func (h *EarningsHandler) GetDeductions(w http.ResponseWriter, r *http.Request) {
+	driverID := chi.URLParam(r, "id")
+	if driverID == "" {
+		utils.BadRequest(w, "driver id is required")
+		return
+	}
+
+	deductions, err := h.deductionService.GetDriverDeductions(r.Context(), driverID)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, deductions)
+}
+
+// GET /v1/drivers/{id}/trips/export?year=
+// Returns 202 while the CSV is being generated; poll until it is ready with a download link
+func (h *EarningsHandler) ExportTrips(w http.ResponseWriter, r *http.Request) {
+	driverID := chi.URLParam(r, "id")
+	if driverID == "" {
+		utils.BadRequest(w, "driver id is required")
+		return
+	}
+
+	// Defaults to last year, the one being filed
+	year := time.Now().Year() - 1
+	if raw := r.URL.Query().Get("year"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			utils.BadRequest(w, "year must be a number")
+			return
+		}
+		year = parsed
+	}
+
+	export, err := h.exportService.RequestExport(r.Context(), driverID, year)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	status := http.StatusAccepted
+	if export.Status == models.TripExportStatusReady {
+		status = http.StatusOK
+	}
+	utils.Success(w, status, export)
+}
+
+// parseEarningsPeriod reads the from and to query params, defaulting to the last 7 days
+func parseEarningsPeriod(w http.ResponseWriter, r *http.Request) (time.Time, time.Time, bool) {
+	to := time.Now()
+	from := to.AddDate(0, 0, -7)
+	q := r.URL.Query()
+	for param, dst := range map[string]*time.Time{"from": &from, "to": &to} {
+		if raw := q.Get(param); raw != "" {
+			parsed, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				utils.BadRequest(w, param+" must be an RFC3339 timestamp")
+				return time.Time{}, time.Time{}, false
+			}
+			*dst = parsed
+		}
+	}
+	return from, to, true
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/service"
+	"github.com/aditya/go-comet/pkg/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+)
+
+type FavoriteHandler struct {
+	favoriteService service.FavoriteService
+	validate        *validator.Validate
+}
+
+func NewFavoriteHandler(favoriteService service.FavoriteService) *FavoriteHandler {
+	return &FavoriteHandler{
+		favoriteService: favoriteService,
+		validate:        validator.New(),
+	}
+}
+
+func (h *FavoriteHandler) RegisterRoutes(r chi.Router) {
+	r.Get("/users/{id}/favorite-drivers", h.ListFavorites)
+	r.Post("/users/{id}/favorite-drivers", h.AddFavorite)
+	r.Delete("/users/{id}/favorite-drivers/{driverId}", h.RemoveFavorite)
+}
+
+// GET /v1/users/{id}/favorite-drivers
+func (h *FavoriteHandler) ListFavorites(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		utils.BadRequest(w, "user id is required")
+		return
+	}
+
+	favorites, err := h.favoriteService.ListFavorites(r.Context(), id)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, map[string]interface{}{
+		"favorite_drivers": favorites,
+	})
+}
+
+// POST /v1/users/{id}/favorite-drivers
+func (h *FavoriteHandler) AddFavorite(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		utils.BadRequest(w, "user id is required")
+		return
+	}
+
+	var req models.AddFavoriteDriverRequest
+	if !utils.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.BadRequest(w, err.Error())
+		return
+	}
+
+	fav, err := h.favoriteService.AddFavorite(r.Context(), id, req.DriverID)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Created(w, fav)
+}
+
+// DELETE /v1/users/{id}/favorite-drivers/{driverId}
+func (h *FavoriteHandler) RemoveFavorite(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	driverID := chi.URLParam(r, "driverId")
+	if id == "" || driverID == "" {
+		utils.BadRequest(w, "user id and driver id are required")
+		return
+	}
+
+	if err := h.favoriteService.RemoveFavorite(r.Context(), id, driverID); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, map[string]string{
+		"message": "favorite removed",
+	})
+}
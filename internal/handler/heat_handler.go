@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/service"
+	"github.com/aditya/go-comet/pkg/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+type HeatHandler struct {
+	heatService service.HeatService
+}
+
+func NewHeatHandler(heatService service.HeatService) *HeatHandler {
+	return &HeatHandler{
+		heatService: heatService,
+	}
+}
+
+func (h *HeatHandler) RegisterRoutes(r chi.Router) {
+	r.Get("/heat/history", h.GetHistory)
+}
+
+// GET /v1/heat/history?min_lat=&min_lng=&max_lat=&max_lng=&vehicle_type=&hour_of_week=&weeks=4
+func (h *HeatHandler) GetHistory(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	minLat, err1 := strconv.ParseFloat(q.Get("min_lat"), 64)
+	minLng, err2 := strconv.ParseFloat(q.Get("min_lng"), 64)
+	maxLat, err3 := strconv.ParseFloat(q.Get("max_lat"), 64)
+	maxLng, err4 := strconv.ParseFloat(q.Get("max_lng"), 64)
+	if err1 != nil || err2 != nil || err3 != nil || err4 != nil {
+		utils.BadRequest(w, "min_lat, min_lng, max_lat and max_lng are required numbers")
+		return
+	}
+	if minLat < -90 || maxLat > 90 || minLng < -180 || maxLng > 180 {
+		utils.BadRequest(w, "bounds must be valid coordinates")
+		return
+	}
+
+	query := &models.HeatQuery{
+		MinLat:      minLat,
+		MinLng:      minLng,
+		MaxLat:      maxLat,
+		MaxLng:      maxLng,
+		VehicleType: q.Get("vehicle_type"),
+		HourOfWeek:  -1,
+	}
+	if raw := q.Get("hour_of_week"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 || n > 167 {
+			utils.BadRequest(w, "hour_of_week must be between 0 and 167")
+			return
+		}
+		query.HourOfWeek = n
+	}
+
+	weeks := 4
+	if raw := q.Get("weeks"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			utils.BadRequest(w, "weeks must be an integer")
+			return
+		}
+		weeks = n
+	}
+
+	history, err := h.heatService.History(r.Context(), query, weeks)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, history)
+}
@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"net/http"
+
+	apperrors "github.com/aditya/go-comet/internal/errors"
+	"github.com/aditya/go-comet/internal/middleware"
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/service"
+	"github.com/aditya/go-comet/pkg/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+)
+
+type NavigationHandler struct {
+	navigationService service.NavigationService
+	validate          *validator.Validate
+}
+
+func NewNavigationHandler(navigationService service.NavigationService) *NavigationHandler {
+	return &NavigationHandler{
+		navigationService: navigationService,
+		validate:          validator.New(),
+	}
+}
+
+func (h *NavigationHandler) RegisterRoutes(r chi.Router) {
+	r.Post("/drivers/{id}/navigation", h.UpdateStatus)
+	r.Post("/rides/{id}/arrived", h.Arrived)
+}
+
+// POST /v1/drivers/{id}/navigation
+func (h *NavigationHandler) UpdateStatus(w http.ResponseWriter, r *http.Request) {
+	driverID := chi.URLParam(r, "id")
+	if driverID == "" {
+		utils.BadRequest(w, "driver id is required")
+		return
+	}
+
+	var req models.UpdateNavigationRequest
+	if !utils.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.BadRequest(w, err.Error())
+		return
+	}
+
+	ride, err := h.navigationService.UpdateStatus(r.Context(), driverID, &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, ride)
+}
+
+// POST /v1/rides/{id}/arrived
+// Called by the assigned driver; their last reported location must be at the pickup
+func (h *NavigationHandler) Arrived(w http.ResponseWriter, r *http.Request) {
+	rideID := chi.URLParam(r, "id")
+	if !utils.IsValidUUID(rideID) {
+		utils.BadRequest(w, "valid ride id is required")
+		return
+	}
+
+	principal := middleware.PrincipalFromContext(r.Context())
+	if principal == nil {
+		handleError(w, apperrors.Unauthorized("sign in required"))
+		return
+	}
+	if principal.Type != middleware.PrincipalDriver {
+		handleError(w, apperrors.Forbidden("only the assigned driver can mark arrival"))
+		return
+	}
+
+	ride, err := h.navigationService.Arrive(r.Context(), principal.ID, rideID)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, ride)
+}
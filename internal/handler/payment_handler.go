@@ -1,7 +1,6 @@
 package handler
 
 import (
-	"encoding/json"
 	"net/http"
 
 	"github.com/aditya/go-comet/internal/models"
@@ -32,8 +31,7 @@ func (h *PaymentHandler) RegisterRoutes(r chi.Router) {
 // POST /v1/payments
 func (h *PaymentHandler) ProcessPayment(w http.ResponseWriter, r *http.Request) {
 	var req models.CreatePaymentRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.BadRequest(w, "invalid request body")
+	if !utils.DecodeJSON(w, r, &req) {
 		return
 	}
 
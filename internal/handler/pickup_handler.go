@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/aditya/go-comet/internal/service"
+	"github.com/aditya/go-comet/pkg/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+type PickupHandler struct {
+	pickupService service.PickupService
+}
+
+func NewPickupHandler(pickupService service.PickupService) *PickupHandler {
+	return &PickupHandler{
+		pickupService: pickupService,
+	}
+}
+
+func (h *PickupHandler) RegisterRoutes(r chi.Router) {
+	r.Get("/pickup-suggestions", h.GetSuggestions)
+}
+
+// GET /v1/pickup-suggestions?lat=&lng=
+func (h *PickupHandler) GetSuggestions(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	lat, latErr := strconv.ParseFloat(q.Get("lat"), 64)
+	lng, lngErr := strconv.ParseFloat(q.Get("lng"), 64)
+	if latErr != nil || lngErr != nil {
+		utils.BadRequest(w, "lat and lng are required numbers")
+		return
+	}
+	if lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		utils.BadRequest(w, "lat and lng must be valid coordinates")
+		return
+	}
+
+	suggestions, err := h.pickupService.Suggest(r.Context(), lat, lng)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, suggestions)
+}
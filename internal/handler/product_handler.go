@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/aditya/go-comet/internal/service"
+	"github.com/aditya/go-comet/pkg/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+type ProductHandler struct {
+	productService service.ProductService
+}
+
+func NewProductHandler(productService service.ProductService) *ProductHandler {
+	return &ProductHandler{
+		productService: productService,
+	}
+}
+
+func (h *ProductHandler) RegisterRoutes(r chi.Router) {
+	r.Get("/products", h.GetProducts)
+}
+
+// GET /v1/products?lat=&lng=&user_id=
+func (h *ProductHandler) GetProducts(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	lat, latErr := strconv.ParseFloat(q.Get("lat"), 64)
+	lng, lngErr := strconv.ParseFloat(q.Get("lng"), 64)
+	if latErr != nil || lngErr != nil {
+		utils.BadRequest(w, "lat and lng are required numbers")
+		return
+	}
+	if lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		utils.BadRequest(w, "lat and lng must be valid coordinates")
+		return
+	}
+
+	catalog, err := h.productService.GetCatalog(r.Context(), lat, lng, q.Get("user_id"))
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, catalog)
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/service"
+	"github.com/aditya/go-comet/pkg/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+)
+
+type PromoHandler struct {
+	promoService service.PromoService
+	validate     *validator.Validate
+}
+
+func NewPromoHandler(promoService service.PromoService) *PromoHandler {
+	return &PromoHandler{
+		promoService: promoService,
+		validate:     validator.New(),
+	}
+}
+
+func (h *PromoHandler) RegisterRoutes(r chi.Router) {
+	r.Post("/promos/validate", h.ValidatePromo)
+}
+
+// POST /v1/promos/validate
+func (h *PromoHandler) ValidatePromo(w http.ResponseWriter, r *http.Request) {
+	var req models.ValidatePromoRequest
+	if !utils.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.BadRequest(w, err.Error())
+		return
+	}
+
+	result, err := h.promoService.Validate(r.Context(), &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, result)
+}
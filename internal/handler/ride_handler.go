@@ -1,43 +1,68 @@
 package handler
 
 import (
-	"encoding/json"
+	"context"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	apperrors "github.com/aditya/go-comet/internal/errors"
 	"github.com/aditya/go-comet/internal/middleware"
 	"github.com/aditya/go-comet/internal/models"
 	"github.com/aditya/go-comet/internal/service"
+	"github.com/aditya/go-comet/internal/worker"
 	"github.com/aditya/go-comet/pkg/utils"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator/v10"
 )
 
+// maxMatchWait caps how long ride creation may be held open waiting for a driver,
+// keeping it well inside the server's write timeout
+const maxMatchWait = 10 * time.Second
+
 type RideHandler struct {
 	rideService     service.RideService
 	matchingService service.MatchingService
+	presenceService service.RiderPresenceService
+	scheduleService service.ScheduledRideService
+	tasks           *worker.Tasks
 	validate        *validator.Validate
 }
 
-func NewRideHandler(rideService service.RideService, matchingService service.MatchingService) *RideHandler {
+func NewRideHandler(
+	rideService service.RideService,
+	matchingService service.MatchingService,
+	presenceService service.RiderPresenceService,
+	scheduleService service.ScheduledRideService,
+	tasks *worker.Tasks,
+) *RideHandler {
 	return &RideHandler{
 		rideService:     rideService,
 		matchingService: matchingService,
+		presenceService: presenceService,
+		scheduleService: scheduleService,
+		tasks:           tasks,
 		validate:        validator.New(),
 	}
 }
 
 func (h *RideHandler) RegisterRoutes(r chi.Router) {
 	r.Post("/rides", h.CreateRide)
+	r.Post("/rides/estimate", h.EstimateFare)
+	r.Get("/fares/estimate", h.EstimateFares)
+	r.Post("/fares/estimate", h.EstimateFares)
 	r.Get("/rides/{id}", h.GetRide)
 	r.Post("/rides/{id}/cancel", h.CancelRide)
+	r.Get("/users/{id}/rides", h.GetUserRides)
+	r.Get("/drivers/{id}/rides", h.GetDriverRides)
+	r.Get("/users/{id}/scheduled-rides", h.GetScheduledRides)
 }
 
-// POST /v1/rides
+// POST /v1/rides?wait_for_match_ms=
 func (h *RideHandler) CreateRide(w http.ResponseWriter, r *http.Request) {
 	var req models.CreateRideRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.BadRequest(w, "invalid request body")
+	if !utils.DecodeJSON(w, r, &req) {
 		return
 	}
 
@@ -46,22 +71,116 @@ func (h *RideHandler) CreateRide(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if req.Language == "" {
+		req.Language = preferredLanguage(r)
+	}
+
 	idempotencyKey := r.Header.Get(middleware.IdempotencyHeader)
 
-	ride, err := h.rideService.CreateRide(r.Context(), &req, idempotencyKey)
+	ride, created, err := h.rideService.CreateRide(r.Context(), &req, idempotencyKey)
 	if err != nil {
 		handleError(w, err)
 		return
 	}
 
-	// Trigger matching asynchronously
-	go func() {
-		if err := h.matchingService.FindAndOfferDrivers(r.Context(), ride); err != nil {
+	// A repeated request is already being matched
+	if !created {
+		utils.Success(w, http.StatusOK, ride)
+		return
+	}
+
+	// Rides booked ahead are matched by the scheduler shortly before pickup
+	if ride.Status == models.RideStatusScheduled {
+		utils.Created(w, ride)
+		return
+	}
+
+	// Trigger matching asynchronously. While the instance is draining the first
+	// round is left to matching escalation on another instance.
+	h.tasks.Go(r.Context(), func(ctx context.Context) {
+		if err := h.matchingService.FindAndOfferDrivers(ctx, ride); err != nil {
 			// Log error, don't fail the request
 		}
-	}()
+	})
 
-	utils.Created(w, ride)
+	// Simple clients can ask to hold the request open until a driver accepts
+	wait := matchWait(r)
+	if wait <= 0 || ride.PricingMode == models.PricingModeBid {
+		utils.Created(w, ride)
+		return
+	}
+
+	response, err := h.rideService.WaitForMatch(r.Context(), ride.ID, wait)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+	utils.Created(w, response)
+}
+
+// POST /v1/rides/estimate
+func (h *RideHandler) EstimateFare(w http.ResponseWriter, r *http.Request) {
+	var req models.FareEstimateRequest
+	if !utils.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.BadRequest(w, err.Error())
+		return
+	}
+
+	if req.Language == "" {
+		req.Language = preferredLanguage(r)
+	}
+
+	estimate, err := h.rideService.EstimateFare(r.Context(), &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, estimate)
+}
+
+// GET /v1/fares/estimate?pickup_lat=&pickup_lng=&dropoff_lat=&dropoff_lng=
+// POST /v1/fares/estimate
+func (h *RideHandler) EstimateFares(w http.ResponseWriter, r *http.Request) {
+	var req models.FaresEstimateRequest
+	if r.Method == http.MethodGet {
+		q := r.URL.Query()
+		coords := make([]float64, 4)
+		for i, name := range []string{"pickup_lat", "pickup_lng", "dropoff_lat", "dropoff_lng"} {
+			v, err := strconv.ParseFloat(q.Get(name), 64)
+			if err != nil {
+				utils.BadRequest(w, "pickup_lat, pickup_lng, dropoff_lat and dropoff_lng are required numbers")
+				return
+			}
+			coords[i] = v
+		}
+		req.Pickup = models.Location{Lat: coords[0], Lng: coords[1]}
+		req.Dropoff = models.Location{Lat: coords[2], Lng: coords[3]}
+		req.Language = q.Get("language")
+	} else if !utils.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.BadRequest(w, err.Error())
+		return
+	}
+
+	if req.Language == "" {
+		req.Language = preferredLanguage(r)
+	}
+
+	estimates, err := h.rideService.EstimateFares(r.Context(), &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, estimates)
 }
 
 // GET /v1/rides/{id}
@@ -78,6 +197,12 @@ func (h *RideHandler) GetRide(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The rider's app polling a matching ride shows they're still waiting for it
+	principal := middleware.PrincipalFromContext(r.Context())
+	if ride.Status == models.RideStatusMatching && (principal == nil || principal.Type != middleware.PrincipalDriver) {
+		h.presenceService.Heartbeat(r.Context(), ride.ID)
+	}
+
 	utils.Success(w, http.StatusOK, ride)
 }
 
@@ -90,8 +215,7 @@ func (h *RideHandler) CancelRide(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req models.CancelRideRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.BadRequest(w, "invalid request body")
+	if !utils.DecodeJSON(w, r, &req) {
 		return
 	}
 
@@ -100,15 +224,30 @@ func (h *RideHandler) CancelRide(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.rideService.CancelRide(r.Context(), id, &req); err != nil {
+	// Who cancelled decides the fee, so it comes from the caller's identity
+	req.CancelledBy = models.CancelledByUser
+	if principal := middleware.PrincipalFromContext(r.Context()); principal != nil {
+		if principal.Type == middleware.PrincipalDriver {
+			req.CancelledBy = models.CancelledByDriver
+		}
+		req.CancelledByID = principal.ID
+		req.Verified = principal.SessionID != ""
+	}
+
+	fee, err := h.rideService.CancelRide(r.Context(), id, &req)
+	if err != nil {
 		handleError(w, err)
 		return
 	}
 
-	utils.Success(w, http.StatusOK, map[string]string{
+	resp := map[string]interface{}{
 		"status":  "cancelled",
 		"message": "ride cancelled successfully",
-	})
+	}
+	if fee != nil {
+		resp["cancellation_fee"] = fee
+	}
+	utils.Success(w, http.StatusOK, resp)
 }
 
 func handleError(w http.ResponseWriter, err error) {
@@ -131,3 +270,116 @@ func handleError(w http.ResponseWriter, err error) {
 		utils.InternalError(w, "internal server error")
 	}
 }
+
+// matchWait reads the requested wait from wait_for_match_ms or a "Prefer: wait=N"
+// header (seconds, RFC 7240), capped at maxMatchWait
+func matchWait(r *http.Request) time.Duration {
+	var wait time.Duration
+	if ms, err := strconv.Atoi(r.URL.Query().Get("wait_for_match_ms")); err == nil {
+		wait = time.Duration(ms) * time.Millisecond
+	} else {
+		for _, pref := range strings.Split(r.Header.Get("Prefer"), ",") {
+			name, value, _ := strings.Cut(strings.TrimSpace(pref), "=")
+			if !strings.EqualFold(name, "wait") {
+				continue
+			}
+			if secs, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
+				wait = time.Duration(secs) * time.Second
+			}
+		}
+	}
+	return min(wait, maxMatchWait)
+}
+
+// preferredLanguage returns the first language tag from Accept-Language, if any
+func preferredLanguage(r *http.Request) string {
+	header := r.Header.Get("Accept-Language")
+	if header == "" {
+		return ""
+	}
+	tag := strings.TrimSpace(strings.Split(strings.Split(header, ",")[0], ";")[0])
+	if tag == "*" {
+		return ""
+	}
+	return tag
+}
+
+// GET /v1/users/{id}/scheduled-rides
+func (h *RideHandler) GetScheduledRides(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		utils.BadRequest(w, "user id is required")
+		return
+	}
+
+	rides, err := h.scheduleService.ListScheduled(r.Context(), id)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, map[string]interface{}{
+		"rides": rides,
+	})
+}
+
+// GET /v1/users/{id}/rides?status=&from=&to=&cursor=&limit=
+func (h *RideHandler) GetUserRides(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		utils.BadRequest(w, "user id is required")
+		return
+	}
+	h.getRideHistory(w, r, &models.RideHistoryFilter{UserID: id})
+}
+
+// GET /v1/drivers/{id}/rides?status=&from=&to=&cursor=&limit=
+func (h *RideHandler) GetDriverRides(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		utils.BadRequest(w, "driver id is required")
+		return
+	}
+	h.getRideHistory(w, r, &models.RideHistoryFilter{DriverID: id})
+}
+
+// getRideHistory reads the history filters shared by riders and drivers; status
+// takes a comma-separated list
+func (h *RideHandler) getRideHistory(w http.ResponseWriter, r *http.Request, filter *models.RideHistoryFilter) {
+	q := r.URL.Query()
+	if raw := q.Get("status"); raw != "" {
+		for _, status := range strings.Split(raw, ",") {
+			if status = strings.TrimSpace(status); status != "" {
+				filter.Statuses = append(filter.Statuses, status)
+			}
+		}
+	}
+
+	for param, dst := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		if raw := q.Get(param); raw != "" {
+			parsed, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				utils.BadRequest(w, param+" must be an RFC3339 timestamp")
+				return
+			}
+			*dst = &parsed
+		}
+	}
+
+	if raw := q.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			utils.BadRequest(w, "limit must be an integer")
+			return
+		}
+		filter.Limit = n
+	}
+
+	page, err := h.rideService.GetRideHistory(r.Context(), filter, q.Get("cursor"))
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, page)
+}
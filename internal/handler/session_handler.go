@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"net/http"
+
+	apperrors "github.com/aditya/go-comet/internal/errors"
+	"github.com/aditya/go-comet/internal/middleware"
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/service"
+	"github.com/aditya/go-comet/pkg/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+)
+
+// SessionHandler serves the signed-in devices of user and driver accounts. Every
+// route is only open to the account itself.
+type SessionHandler struct {
+	sessionService service.SessionService
+	validate       *validator.Validate
+}
+
+func NewSessionHandler(sessionService service.SessionService) *SessionHandler {
+	return &SessionHandler{
+		sessionService: sessionService,
+		validate:       validator.New(),
+	}
+}
+
+func (h *SessionHandler) RegisterRoutes(r chi.Router) {
+	for prefix, principalType := range map[string]string{
+		"/users/{id}":   models.SessionPrincipalUser,
+		"/drivers/{id}": models.SessionPrincipalDriver,
+	} {
+		r.Post(prefix+"/sessions", h.account(principalType, h.CreateSession))
+		r.Get(prefix+"/sessions", h.account(principalType, h.ListSessions))
+		r.Delete(prefix+"/sessions/{sessionId}", h.account(principalType, h.RevokeSession))
+		r.Post(prefix+"/sessions/revoke-others", h.account(principalType, h.RevokeOtherSessions))
+		r.Put(prefix+"/phone", h.account(principalType, h.ChangePhone))
+	}
+}
+
+type accountHandlerFunc func(w http.ResponseWriter, r *http.Request, principalType, id string)
+
+// account resolves the account from the path and checks the caller is its holder
+func (h *SessionHandler) account(principalType string, next accountHandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "id")
+		if !utils.IsValidUUID(id) {
+			utils.BadRequest(w, "valid account id is required")
+			return
+		}
+
+		principal := middleware.PrincipalFromContext(r.Context())
+		if principal == nil {
+			handleError(w, apperrors.Unauthorized("sign in required"))
+			return
+		}
+		if principal.Type != principalType || principal.ID != id {
+			handleError(w, apperrors.Forbidden("sessions can only be managed by the account holder"))
+			return
+		}
+
+		next(w, r, principalType, id)
+	}
+}
+
+// POST /v1/{users|drivers}/{id}/sessions
+func (h *SessionHandler) CreateSession(w http.ResponseWriter, r *http.Request, principalType, id string) {
+	var req models.CreateSessionRequest
+	if !utils.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.BadRequest(w, err.Error())
+		return
+	}
+
+	created, err := h.sessionService.CreateSession(r.Context(), principalType, id, &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Created(w, created)
+}
+
+// GET /v1/{users|drivers}/{id}/sessions
+func (h *SessionHandler) ListSessions(w http.ResponseWriter, r *http.Request, principalType, id string) {
+	sessions, err := h.sessionService.ListSessions(r.Context(), principalType, id)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	current := middleware.PrincipalFromContext(r.Context()).SessionID
+	for _, s := range sessions {
+		s.Current = current != "" && s.ID == current
+	}
+
+	utils.Success(w, http.StatusOK, map[string]interface{}{
+		"sessions": sessions,
+	})
+}
+
+// DELETE /v1/{users|drivers}/{id}/sessions/{sessionId}
+func (h *SessionHandler) RevokeSession(w http.ResponseWriter, r *http.Request, principalType, id string) {
+	sessionID := chi.URLParam(r, "sessionId")
+	if !utils.IsValidUUID(sessionID) {
+		utils.BadRequest(w, "valid session id is required")
+		return
+	}
+
+	if err := h.sessionService.RevokeSession(r.Context(), principalType, id, sessionID); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, map[string]string{
+		"status": "revoked",
+	})
+}
+
+// POST /v1/{users|drivers}/{id}/sessions/revoke-others
+// Signs out every device except the one making the request
+func (h *SessionHandler) RevokeOtherSessions(w http.ResponseWriter, r *http.Request, principalType, id string) {
+	current := middleware.PrincipalFromContext(r.Context()).SessionID
+	revoked, err := h.sessionService.RevokeOtherSessions(r.Context(), principalType, id, current)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, map[string]interface{}{
+		"revoked": revoked,
+	})
+}
+
+// PUT /v1/{users|drivers}/{id}/phone
+// Changes the sign-in phone number and signs out every device
+func (h *SessionHandler) ChangePhone(w http.ResponseWriter, r *http.Request, principalType, id string) {
+	var req models.ChangePhoneRequest
+	if !utils.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.BadRequest(w, err.Error())
+		return
+	}
+
+	if err := h.sessionService.ChangePhone(r.Context(), principalType, id, req.Phone); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, map[string]string{
+		"status": "updated",
+		"phone":  req.Phone,
+	})
+}
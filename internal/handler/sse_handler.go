@@ -10,25 +10,69 @@ import (
 	"time"
 
 	"github.com/aditya/go-comet/internal/cache"
+	"github.com/aditya/go-comet/internal/models"
 	"github.com/aditya/go-comet/internal/repository"
+	"github.com/aditya/go-comet/internal/service"
 	"github.com/go-chi/chi/v5"
 	"github.com/redis/go-redis/v9"
 )
 
 type SSEHandler struct {
 	rideRepo    repository.RideRepository
+	rideService service.RideService
+	tripService service.TripService
+	presence    service.RiderPresenceService
 	driverCache cache.DriverLocationCache
 	redis       *redis.Client
-	clients     map[string]map[chan []byte]bool // rideID -> clients
-	mu          sync.RWMutex
+	// how often fare_tick events are sent while the trip is in progress
+	meterInterval time.Duration
+	clients       map[string]map[chan sseEvent]bool // rideID -> clients
+	mu            sync.RWMutex
+	// closed on shutdown so streams end and clients reconnect to another instance
+	done      chan struct{}
+	closeOnce sync.Once
 }
 
-func NewSSEHandler(rideRepo repository.RideRepository, driverCache cache.DriverLocationCache, redisClient *redis.Client) *SSEHandler {
+// rideStatusEvents names the event sent when a ride reaches a lifecycle status so
+// the rider app can react to each without polling; other statuses are sent as
+// ride_status
+var rideStatusEvents = map[string]string{
+	models.RideStatusDriverAssigned: "driver_assigned",
+	models.RideStatusDriverArrived:  "driver_arrived",
+	models.RideStatusInProgress:     "trip_started",
+	models.RideStatusCompleted:      "trip_completed",
+	models.RideStatusCancelled:      "cancelled",
+}
+
+func rideStatusEventName(status string) string {
+	if name, ok := rideStatusEvents[status]; ok {
+		return name
+	}
+	return "ride_status"
+}
+
+// sseEvent is one named event queued for a tracking client
+type sseEvent struct {
+	name string
+	data []byte
+	// final events end the stream, such as the ride completing
+	final bool
+}
+
+func NewSSEHandler(rideRepo repository.RideRepository, rideService service.RideService, tripService service.TripService, presence service.RiderPresenceService, driverCache cache.DriverLocationCache, redisClient *redis.Client, meterInterval time.Duration) *SSEHandler {
+	if meterInterval <= 0 {
+		meterInterval = 15 * time.Second
+	}
 	handler := &SSEHandler{
-		rideRepo:    rideRepo,
-		driverCache: driverCache,
-		redis:       redisClient,
-		clients:     make(map[string]map[chan []byte]bool),
+		rideRepo:      rideRepo,
+		rideService:   rideService,
+		tripService:   tripService,
+		presence:      presence,
+		driverCache:   driverCache,
+		redis:         redisClient,
+		meterInterval: meterInterval,
+		clients:       make(map[string]map[chan sseEvent]bool),
+		done:          make(chan struct{}),
 	}
 
 	// Start Redis pub/sub listener
@@ -41,6 +85,12 @@ func (h *SSEHandler) RegisterRoutes(r chi.Router) {
 	r.Get("/rides/{id}/track", h.TrackRide)
 }
 
+// Shutdown ends every tracking stream. Browsers' EventSource reconnects on its
+// own, reaching an instance that is still taking traffic.
+func (h *SSEHandler) Shutdown() {
+	h.closeOnce.Do(func() { close(h.done) })
+}
+
 // TrackRide handles SSE connections for real-time ride tracking
 func (h *SSEHandler) TrackRide(w http.ResponseWriter, r *http.Request) {
 	rideID := chi.URLParam(r, "id")
@@ -56,7 +106,7 @@ func (h *SSEHandler) TrackRide(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if ride.DriverID == nil {
+	if ride.DriverID == nil && ride.Status != models.RideStatusMatching {
 		http.Error(w, "no driver assigned yet", http.StatusBadRequest)
 		return
 	}
@@ -68,7 +118,7 @@ func (h *SSEHandler) TrackRide(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 
 	// Create client channel
-	clientChan := make(chan []byte, 10)
+	clientChan := make(chan sseEvent, 10)
 
 	// Register client
 	h.registerClient(rideID, clientChan)
@@ -81,6 +131,14 @@ func (h *SSEHandler) TrackRide(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// While matching, stream time-to-match estimates until a driver accepts
+	if ride.DriverID == nil {
+		ride = h.streamMatchEstimates(r.Context(), w, flusher, ride, clientChan)
+		if ride == nil {
+			return
+		}
+	}
+
 	// Send initial location
 	if loc, err := h.driverCache.GetDriverLocation(r.Context(), *ride.DriverID); err == nil && loc != nil {
 		event := map[string]interface{}{
@@ -98,19 +156,28 @@ func (h *SSEHandler) TrackRide(w http.ResponseWriter, r *http.Request) {
 		fmt.Fprintf(w, "event: location\ndata: %s\n\n", data)
 		flusher.Flush()
 	}
+	h.sendPickupETA(r.Context(), w, flusher, ride.ID)
+	h.sendFareTick(r.Context(), w, flusher, ride.ID)
 
 	// Keep connection open and send updates
 	ctx := r.Context()
 	ticker := time.NewTicker(5 * time.Second)
 	defer ticker.Stop()
+	meter := time.NewTicker(h.meterInterval)
+	defer meter.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case msg := <-clientChan:
-			fmt.Fprintf(w, "event: location\ndata: %s\n\n", msg)
+		case <-h.done:
+			return
+		case event := <-clientChan:
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.name, event.data)
 			flusher.Flush()
+			if event.final {
+				return
+			}
 		case <-ticker.C:
 			// Send heartbeat
 			fmt.Fprintf(w, "event: heartbeat\ndata: {\"time\": \"%s\"}\n\n", time.Now().Format(time.RFC3339))
@@ -130,21 +197,120 @@ func (h *SSEHandler) TrackRide(w http.ResponseWriter, r *http.Request) {
 				fmt.Fprintf(w, "event: location\ndata: %s\n\n", data)
 				flusher.Flush()
 			}
+			h.sendPickupETA(ctx, w, flusher, ride.ID)
+		case <-meter.C:
+			h.sendFareTick(ctx, w, flusher, ride.ID)
 		}
 	}
 }
 
-func (h *SSEHandler) registerClient(rideID string, ch chan []byte) {
+// sendFareTick sends the running fare while the trip is in progress, so the
+// rider sees the bill build up rather than meeting it at drop-off
+func (h *SSEHandler) sendFareTick(ctx context.Context, w http.ResponseWriter, flusher http.Flusher, rideID string) {
+	ride, err := h.rideRepo.GetByID(ctx, rideID)
+	if err != nil || ride == nil || ride.Status != models.RideStatusInProgress {
+		return
+	}
+	tick, err := h.tripService.LiveFare(ctx, rideID)
+	if err != nil {
+		log.Printf("failed to compute live fare for ride %s: %v", rideID, err)
+		return
+	}
+	data, _ := json.Marshal(tick)
+	fmt.Fprintf(w, "event: fare_tick\ndata: %s\n\n", data)
+	flusher.Flush()
+}
+
+// sendPickupETA sends an eta event while the driver is on the way to pickup
+func (h *SSEHandler) sendPickupETA(ctx context.Context, w http.ResponseWriter, flusher http.Flusher, rideID string) {
+	ride, err := h.rideRepo.GetByID(ctx, rideID)
+	if err != nil || ride == nil {
+		return
+	}
+	eta, err := h.rideService.PickupETA(ctx, ride)
+	if err != nil || eta == nil {
+		return
+	}
+	data, _ := json.Marshal(map[string]interface{}{
+		"driver_id":          *ride.DriverID,
+		"eta_to_pickup_mins": *eta,
+		"timestamp":          time.Now().Format(time.RFC3339),
+	})
+	fmt.Fprintf(w, "event: eta\ndata: %s\n\n", data)
+	flusher.Flush()
+}
+
+// streamMatchEstimates sends match_estimate events for a ride in matching, along
+// with its status events, and returns the ride once a driver is assigned, or nil
+// if the ride ended or the client left
+func (h *SSEHandler) streamMatchEstimates(ctx context.Context, w http.ResponseWriter, flusher http.Flusher, ride *models.Ride, events <-chan sseEvent) *models.Ride {
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+	// Whether a status event arrived from pub/sub; polling only stands in for one
+	// that didn't, so the client isn't told twice
+	announced := false
+	for {
+		// An open stream counts as the rider still waiting
+		h.presence.Heartbeat(ctx, ride.ID)
+
+		if estimate, err := h.rideService.EstimateMatch(ctx, ride); err == nil {
+			data, _ := json.Marshal(estimate)
+			fmt.Fprintf(w, "event: match_estimate\ndata: %s\n\n", data)
+			flusher.Flush()
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-h.done:
+			return nil
+		case event := <-events:
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.name, event.data)
+			flusher.Flush()
+			if event.final {
+				return nil
+			}
+			announced = true
+		case <-ticker.C:
+		}
+
+		latest, err := h.rideRepo.GetByID(ctx, ride.ID)
+		if err != nil || latest == nil {
+			continue
+		}
+		ride = latest
+
+		if ride.DriverID == nil && ride.Status == models.RideStatusMatching {
+			continue
+		}
+		if !announced {
+			status := map[string]string{"status": ride.Status}
+			if ride.DriverID != nil {
+				status["driver_id"] = *ride.DriverID
+			}
+			data, _ := json.Marshal(status)
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", rideStatusEventName(ride.Status), data)
+			flusher.Flush()
+		}
+		if ride.DriverID == nil {
+			return nil
+		}
+		return ride
+	}
+}
+
+func (h *SSEHandler) registerClient(rideID string, ch chan sseEvent) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
 	if h.clients[rideID] == nil {
-		h.clients[rideID] = make(map[chan []byte]bool)
+		h.clients[rideID] = make(map[chan sseEvent]bool)
 	}
 	h.clients[rideID][ch] = true
 }
 
-func (h *SSEHandler) unregisterClient(rideID string, ch chan []byte) {
+func (h *SSEHandler) unregisterClient(rideID string, ch chan sseEvent) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
@@ -158,13 +324,17 @@ func (h *SSEHandler) unregisterClient(rideID string, ch chan []byte) {
 }
 
 func (h *SSEHandler) BroadcastLocation(rideID string, data []byte) {
+	h.broadcast(rideID, sseEvent{name: "location", data: data})
+}
+
+func (h *SSEHandler) broadcast(rideID string, event sseEvent) {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 
 	if clients, ok := h.clients[rideID]; ok {
 		for ch := range clients {
 			select {
-			case ch <- data:
+			case ch <- event:
 			default:
 				// Client too slow, skip
 			}
@@ -172,13 +342,18 @@ func (h *SSEHandler) BroadcastLocation(rideID string, data []byte) {
 	}
 }
 
-// startPubSubListener listens for location updates via Redis pub/sub
+// startPubSubListener listens for location and ride status updates via Redis pub/sub
 func (h *SSEHandler) startPubSubListener() {
 	ctx := context.Background()
-	pubsub := h.redis.Subscribe(ctx, "driver:location:updates")
+	pubsub := h.redis.Subscribe(ctx, "driver:location:updates", cache.RideStatusChannel)
 	defer pubsub.Close()
 
 	for msg := range pubsub.Channel() {
+		if msg.Channel == cache.RideStatusChannel {
+			h.broadcastStatus([]byte(msg.Payload))
+			continue
+		}
+
 		var update struct {
 			RideID   string  `json:"ride_id"`
 			DriverID string  `json:"driver_id"`
@@ -202,6 +377,30 @@ func (h *SSEHandler) startPubSubListener() {
 	}
 }
 
+// broadcastStatus forwards a ride status event to the ride's trackers as its
+// lifecycle event, ending their streams once the ride is over
+func (h *SSEHandler) broadcastStatus(payload []byte) {
+	var event models.RideStatusEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return
+	}
+	// Trackers only need the status and driver, not who the rider is
+	status := map[string]interface{}{
+		"status":          event.Status,
+		"previous_status": event.PreviousStatus,
+		"timestamp":       event.At.Format(time.RFC3339),
+	}
+	if event.DriverID != nil {
+		status["driver_id"] = *event.DriverID
+	}
+	data, _ := json.Marshal(status)
+	h.broadcast(event.RideID, sseEvent{
+		name:  rideStatusEventName(event.Status),
+		data:  data,
+		final: models.RideStates.IsTerminal(event.Status),
+	})
+}
+
 // PublishLocationUpdate publishes a location update to Redis
 func PublishLocationUpdate(ctx context.Context, redis *redis.Client, rideID, driverID string, lat, lng float64) error {
 	update := map[string]interface{}{
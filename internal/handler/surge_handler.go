@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/aditya/go-comet/internal/service"
+	"github.com/aditya/go-comet/pkg/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+type SurgeHandler struct {
+	surgeService service.SurgeService
+}
+
+func NewSurgeHandler(surgeService service.SurgeService) *SurgeHandler {
+	return &SurgeHandler{
+		surgeService: surgeService,
+	}
+}
+
+func (h *SurgeHandler) RegisterRoutes(r chi.Router) {
+	r.Get("/surge", h.GetSurge)
+}
+
+// GET /v1/surge?lat=&lng=
+func (h *SurgeHandler) GetSurge(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	lat, latErr := strconv.ParseFloat(q.Get("lat"), 64)
+	lng, lngErr := strconv.ParseFloat(q.Get("lng"), 64)
+	if latErr != nil || lngErr != nil {
+		utils.BadRequest(w, "lat and lng are required numbers")
+		return
+	}
+	if lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		utils.BadRequest(w, "lat and lng must be valid coordinates")
+		return
+	}
+
+	info, err := h.surgeService.GetSurge(r.Context(), lat, lng)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, info)
+}
@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/aditya/go-comet/internal/service"
+	"github.com/aditya/go-comet/pkg/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+type TrainingHandler struct {
+	trainingService service.TrainingService
+}
+
+func NewTrainingHandler(trainingService service.TrainingService) *TrainingHandler {
+	return &TrainingHandler{trainingService: trainingService}
+}
+
+func (h *TrainingHandler) RegisterRoutes(r chi.Router) {
+	r.Get("/drivers/{id}/training", h.GetTraining)
+}
+
+// GET /v1/drivers/{id}/training
+func (h *TrainingHandler) GetTraining(w http.ResponseWriter, r *http.Request) {
+	driverID := chi.URLParam(r, "id")
+	if driverID == "" {
+		utils.BadRequest(w, "driver id is required")
+		return
+	}
+
+	status, err := h.trainingService.GetDriverTraining(r.Context(), driverID)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, status)
+}
@@ -1,25 +1,40 @@
 package handler
 
 import (
-	"encoding/json"
+	"context"
 	"net/http"
 
 	"github.com/aditya/go-comet/internal/models"
 	"github.com/aditya/go-comet/internal/service"
+	"github.com/aditya/go-comet/internal/worker"
 	"github.com/aditya/go-comet/pkg/utils"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator/v10"
 )
 
 type TripHandler struct {
-	tripService service.TripService
-	validate    *validator.Validate
+	tripService      service.TripService
+	receiptService   service.ReceiptService
+	insuranceService service.InsuranceService
+	ratingService    service.RatingService
+	tasks            *worker.Tasks
+	validate         *validator.Validate
 }
 
-func NewTripHandler(tripService service.TripService) *TripHandler {
+func NewTripHandler(
+	tripService service.TripService,
+	receiptService service.ReceiptService,
+	insuranceService service.InsuranceService,
+	ratingService service.RatingService,
+	tasks *worker.Tasks,
+) *TripHandler {
 	return &TripHandler{
-		tripService: tripService,
-		validate:    validator.New(),
+		tripService:      tripService,
+		receiptService:   receiptService,
+		insuranceService: insuranceService,
+		ratingService:    ratingService,
+		tasks:            tasks,
+		validate:         validator.New(),
 	}
 }
 
@@ -29,15 +44,22 @@ func (h *TripHandler) RegisterRoutes(r chi.Router) {
 	r.Post("/trips/{id}/end", h.EndTrip)
 	r.Post("/trips/{id}/pause", h.PauseTrip)
 	r.Post("/trips/{id}/resume", h.ResumeTrip)
+	r.Post("/trips/{id}/odometer", h.RecordOdometer)
+	r.Get("/trips/{id}/receipt", h.GetReceipt)
+	r.Post("/trips/{id}/claims", h.FileClaim)
+	r.Post("/trips/{id}/rate", h.RateTrip)
+	r.Get("/trips/{id}/ratings", h.GetTripRatings)
+	r.Post("/ratings/{id}/appeal", h.AppealFeedback)
+	r.Get("/users/{id}/unrated-trips", h.GetUnratedTrips)
+	r.Get("/claims/{id}", h.GetClaim)
+	r.Get("/users/{id}/carbon", h.GetUserCarbonStats)
+	r.Get("/drivers/{id}/carbon", h.GetDriverCarbonStats)
 }
 
 // POST /v1/trips/start
 func (h *TripHandler) StartTrip(w http.ResponseWriter, r *http.Request) {
-	var req struct {
-		RideID string `json:"ride_id" validate:"required,uuid"`
-	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.BadRequest(w, "invalid request body")
+	var req models.StartTripRequest
+	if !utils.DecodeJSON(w, r, &req) {
 		return
 	}
 
@@ -46,12 +68,18 @@ func (h *TripHandler) StartTrip(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	trip, err := h.tripService.StartTrip(r.Context(), req.RideID)
+	trip, err := h.tripService.StartTrip(r.Context(), &req)
 	if err != nil {
 		handleError(w, err)
 		return
 	}
 
+	// The driver doesn't wait on the insurer; policies not issued here, e.g.
+	// while draining, are backfilled by the insurance-policies job
+	h.tasks.Go(r.Context(), func(ctx context.Context) {
+		h.insuranceService.AttachPolicy(ctx, trip)
+	})
+
 	utils.Created(w, trip.ToResponse())
 }
 
@@ -81,8 +109,7 @@ func (h *TripHandler) EndTrip(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req models.EndTripRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.BadRequest(w, "invalid request body")
+	if !utils.DecodeJSON(w, r, &req) {
 		return
 	}
 
@@ -139,3 +166,192 @@ func (h *TripHandler) ResumeTrip(w http.ResponseWriter, r *http.Request) {
 		"status": "resumed",
 	})
 }
+
+// POST /v1/trips/{id}/odometer
+func (h *TripHandler) RecordOdometer(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		utils.BadRequest(w, "trip id is required")
+		return
+	}
+
+	var req models.RecordOdometerRequest
+	if !utils.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.BadRequest(w, err.Error())
+		return
+	}
+
+	trip, err := h.tripService.RecordOdometer(r.Context(), id, &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, trip.ToResponse())
+}
+
+// GET /v1/trips/{id}/receipt
+func (h *TripHandler) GetReceipt(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		utils.BadRequest(w, "trip id is required")
+		return
+	}
+
+	receipt, err := h.receiptService.GetReceipt(r.Context(), id)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, receipt)
+}
+
+// POST /v1/trips/{id}/claims
+func (h *TripHandler) FileClaim(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		utils.BadRequest(w, "trip id is required")
+		return
+	}
+
+	var req models.CreateClaimRequest
+	if !utils.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.BadRequest(w, err.Error())
+		return
+	}
+
+	claim, err := h.insuranceService.FileClaim(r.Context(), id, &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Created(w, claim)
+}
+
+// POST /v1/trips/{id}/rate
+func (h *TripHandler) RateTrip(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		utils.BadRequest(w, "trip id is required")
+		return
+	}
+
+	var req models.RateTripRequest
+	if !utils.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.BadRequest(w, err.Error())
+		return
+	}
+
+	rating, err := h.ratingService.RateTrip(r.Context(), id, &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Created(w, rating)
+}
+
+// GET /v1/trips/{id}/ratings
+// Feedback appears once moderation has published it
+func (h *TripHandler) GetTripRatings(w http.ResponseWriter, r *http.Request) {
+	ratings, err := h.ratingService.TripRatings(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, map[string]interface{}{
+		"ratings": ratings,
+	})
+}
+
+// POST /v1/ratings/{id}/appeal
+func (h *TripHandler) AppealFeedback(w http.ResponseWriter, r *http.Request) {
+	var req models.AppealFeedbackRequest
+	if !utils.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.BadRequest(w, err.Error())
+		return
+	}
+
+	rating, err := h.ratingService.AppealFeedback(r.Context(), chi.URLParam(r, "id"), &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, rating)
+}
+
+// GET /v1/claims/{id}
+func (h *TripHandler) GetClaim(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		utils.BadRequest(w, "claim id is required")
+		return
+	}
+
+	claim, err := h.insuranceService.GetClaim(r.Context(), id)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, claim)
+}
+
+// GET /v1/users/{id}/carbon
+func (h *TripHandler) GetUserCarbonStats(w http.ResponseWriter, r *http.Request) {
+	h.getCarbonStats(w, r, models.CarbonOwnerUser)
+}
+
+// GET /v1/drivers/{id}/carbon
+func (h *TripHandler) GetDriverCarbonStats(w http.ResponseWriter, r *http.Request) {
+	h.getCarbonStats(w, r, models.CarbonOwnerDriver)
+}
+
+func (h *TripHandler) getCarbonStats(w http.ResponseWriter, r *http.Request, ownerType string) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		utils.BadRequest(w, ownerType+" id is required")
+		return
+	}
+
+	stats, err := h.tripService.GetCarbonStats(r.Context(), ownerType, id)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, stats)
+}
+
+// GET /v1/users/{id}/unrated-trips
+func (h *TripHandler) GetUnratedTrips(w http.ResponseWriter, r *http.Request) {
+	trips, err := h.ratingService.UnratedTrips(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, map[string]interface{}{
+		"trips": trips,
+	})
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/service"
+	"github.com/aditya/go-comet/pkg/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+)
+
+type UploadHandler struct {
+	uploadService service.UploadService
+	validate      *validator.Validate
+}
+
+func NewUploadHandler(uploadService service.UploadService) *UploadHandler {
+	return &UploadHandler{
+		uploadService: uploadService,
+		validate:      validator.New(),
+	}
+}
+
+func (h *UploadHandler) RegisterRoutes(r chi.Router) {
+	r.Post("/uploads", h.CreateUpload)
+	r.Get("/uploads/{id}", h.GetUpload)
+	r.Post("/uploads/{id}/complete", h.CompleteUpload)
+}
+
+// POST /v1/uploads
+func (h *UploadHandler) CreateUpload(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateUploadRequest
+	if !utils.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.BadRequest(w, err.Error())
+		return
+	}
+
+	ticket, err := h.uploadService.CreateUpload(r.Context(), &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Created(w, ticket)
+}
+
+// GET /v1/uploads/{id}
+func (h *UploadHandler) GetUpload(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		utils.BadRequest(w, "upload id is required")
+		return
+	}
+
+	upload, err := h.uploadService.GetUpload(r.Context(), id)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, upload)
+}
+
+// POST /v1/uploads/{id}/complete
+func (h *UploadHandler) CompleteUpload(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		utils.BadRequest(w, "upload id is required")
+		return
+	}
+
+	upload, err := h.uploadService.CompleteUpload(r.Context(), id)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, upload)
+}
@@ -1,38 +1,41 @@
 package handler
 
 import (
-	"encoding/json"
+	"log"
 	"net/http"
 
 	"github.com/aditya/go-comet/internal/models"
 	"github.com/aditya/go-comet/internal/repository"
+	"github.com/aditya/go-comet/internal/service"
 	"github.com/aditya/go-comet/pkg/utils"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator/v10"
 )
 
 type UserHandler struct {
-	userRepo repository.UserRepository
-	validate *validator.Validate
+	userRepo       repository.UserRepository
+	consentService service.ConsentService
+	validate       *validator.Validate
 }
 
-func NewUserHandler(userRepo repository.UserRepository) *UserHandler {
+func NewUserHandler(userRepo repository.UserRepository, consentService service.ConsentService) *UserHandler {
 	return &UserHandler{
-		userRepo: userRepo,
-		validate: validator.New(),
+		userRepo:       userRepo,
+		consentService: consentService,
+		validate:       validator.New(),
 	}
 }
 
 func (h *UserHandler) RegisterRoutes(r chi.Router) {
 	r.Post("/users", h.CreateUser)
 	r.Get("/users/{id}", h.GetUser)
+	r.Put("/users/{id}/safety-preferences", h.UpdateSafetyPreferences)
 }
 
 // POST /v1/users
 func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	var req models.CreateUserRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.BadRequest(w, "invalid request body")
+	if !utils.DecodeJSON(w, r, &req) {
 		return
 	}
 
@@ -63,6 +66,9 @@ func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	if req.Email != "" {
 		user.Email = &req.Email
 	}
+	if req.Gender != "" {
+		user.Gender = &req.Gender
+	}
 
 	if err := h.userRepo.Create(r.Context(), user); err != nil {
 		utils.InternalError(w, "failed to create user")
@@ -90,5 +96,54 @@ func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	response := user.ToResponse()
+	pending, err := h.consentService.Pending(r.Context(), models.RaterUser, user.ID, "")
+	if err != nil {
+		log.Printf("failed to load pending consents for user %s: %v", user.ID, err)
+	}
+	response.PendingConsents = pending
+
+	utils.Success(w, http.StatusOK, response)
+}
+
+// PUT /v1/users/{id}/safety-preferences
+func (h *UserHandler) UpdateSafetyPreferences(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		utils.BadRequest(w, "user id is required")
+		return
+	}
+
+	var req models.UpdateSafetyPreferencesRequest
+	if !utils.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.BadRequest(w, err.Error())
+		return
+	}
+
+	user, err := h.userRepo.GetByID(r.Context(), id)
+	if err != nil {
+		utils.InternalError(w, "failed to get user")
+		return
+	}
+	if user == nil {
+		utils.NotFound(w, "user")
+		return
+	}
+
+	user.SafetyMode = req.SafetyMode
+	user.PreferredDriverGender = nil
+	if req.PreferredDriverGender != "" {
+		user.PreferredDriverGender = &req.PreferredDriverGender
+	}
+
+	if err := h.userRepo.UpdateSafetyPreferences(r.Context(), user); err != nil {
+		utils.InternalError(w, "failed to update safety preferences")
+		return
+	}
+
 	utils.Success(w, http.StatusOK, user.ToResponse())
 }
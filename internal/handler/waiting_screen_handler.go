@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/aditya/go-comet/internal/service"
+	"github.com/aditya/go-comet/pkg/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+type WaitingScreenHandler struct {
+	waitingService service.WaitingScreenService
+}
+
+func NewWaitingScreenHandler(waitingService service.WaitingScreenService) *WaitingScreenHandler {
+	return &WaitingScreenHandler{
+		waitingService: waitingService,
+	}
+}
+
+func (h *WaitingScreenHandler) RegisterRoutes(r chi.Router) {
+	r.Get("/rides/{id}/waiting-screen", h.GetWaitingScreen)
+}
+
+// GET /v1/rides/{id}/waiting-screen
+func (h *WaitingScreenHandler) GetWaitingScreen(w http.ResponseWriter, r *http.Request) {
+	screen, err := h.waitingService.GetWaitingScreen(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, screen)
+}
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/aditya/go-comet/internal/middleware"
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/service"
+	"github.com/aditya/go-comet/pkg/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+)
+
+type WalletHandler struct {
+	walletService service.WalletService
+	validate      *validator.Validate
+}
+
+func NewWalletHandler(walletService service.WalletService) *WalletHandler {
+	return &WalletHandler{
+		walletService: walletService,
+		validate:      validator.New(),
+	}
+}
+
+func (h *WalletHandler) RegisterRoutes(r chi.Router) {
+	r.Get("/users/{id}/wallet", h.GetWallet)
+	r.Post("/users/{id}/wallet/topup", h.TopUp)
+	r.Get("/users/{id}/wallet/transactions", h.GetTransactions)
+}
+
+// GET /v1/users/{id}/wallet
+// Balance with the latest transactions
+func (h *WalletHandler) GetWallet(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "id")
+	if userID == "" {
+		utils.BadRequest(w, "user id is required")
+		return
+	}
+
+	wallet, err := h.walletService.GetWallet(r.Context(), userID)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, wallet)
+}
+
+// POST /v1/users/{id}/wallet/topup
+func (h *WalletHandler) TopUp(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "id")
+	if userID == "" {
+		utils.BadRequest(w, "user id is required")
+		return
+	}
+
+	var req models.TopUpWalletRequest
+	if !utils.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.BadRequest(w, err.Error())
+		return
+	}
+
+	txn, err := h.walletService.TopUp(r.Context(), userID, &req, r.Header.Get(middleware.IdempotencyHeader))
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Created(w, txn)
+}
+
+// GET /v1/users/{id}/wallet/transactions?limit=
+func (h *WalletHandler) GetTransactions(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "id")
+	if userID == "" {
+		utils.BadRequest(w, "user id is required")
+		return
+	}
+
+	limit := 0
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			utils.BadRequest(w, "limit must be an integer")
+			return
+		}
+		limit = n
+	}
+
+	txns, err := h.walletService.GetTransactions(r.Context(), userID, limit)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	utils.Success(w, http.StatusOK, map[string]interface{}{
+		"transactions": txns,
+	})
+}
@@ -0,0 +1,190 @@
+// Package httpclient is the shared client for calls to external services. Each
+// integration gets its own timeout, retry budget and circuit breaker, and every
+// call is counted in metrics under the integration's name.
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aditya/go-comet/internal/metrics"
+)
+
+// ErrCircuitOpen is returned without calling the service while its breaker is open
+var ErrCircuitOpen = errors.New("httpclient: circuit open")
+
+// maxRetryTokens caps how many retries can be saved up while a service is healthy
+const maxRetryTokens = 10
+
+// Config tunes the client for one integration. Zero values take the defaults.
+type Config struct {
+	// Name labels metrics and logs, e.g. "psp"
+	Name    string
+	Timeout time.Duration
+	// Retries after a network error, 429 or 5xx. Only requests that are safe to
+	// repeat are retried: GET and HEAD, or any carrying an Idempotency-Key.
+	MaxRetries   int
+	RetryBackoff time.Duration
+	// Retries earned per request sent, so a struggling service isn't flooded with them
+	RetryBudgetRatio float64
+	// Consecutive failures that open the breaker, and how long it then stays open
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+}
+
+func (c Config) withDefaults() Config {
+	if c.Timeout <= 0 {
+		c.Timeout = 5 * time.Second
+	}
+	if c.RetryBackoff <= 0 {
+		c.RetryBackoff = 200 * time.Millisecond
+	}
+	if c.RetryBudgetRatio <= 0 {
+		c.RetryBudgetRatio = 0.2
+	}
+	if c.BreakerThreshold <= 0 {
+		c.BreakerThreshold = 5
+	}
+	if c.BreakerCooldown <= 0 {
+		c.BreakerCooldown = 30 * time.Second
+	}
+	return c
+}
+
+// Client sends requests to one external service
+type Client struct {
+	cfg    Config
+	client *http.Client
+
+	mu          sync.Mutex
+	retryTokens float64
+	failures    int
+	openUntil   time.Time
+}
+
+func New(cfg Config) *Client {
+	cfg = cfg.withDefaults()
+	return &Client{
+		cfg:         cfg,
+		client:      &http.Client{Timeout: cfg.Timeout},
+		retryTokens: maxRetryTokens,
+	}
+}
+
+// Do sends the request, retrying when allowed. Like http.Client.Do, a response
+// with an error status is returned without an error.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	if !c.allow() {
+		c.count("circuit_open")
+		return nil, fmt.Errorf("%s: %w", c.cfg.Name, ErrCircuitOpen)
+	}
+	c.deposit()
+
+	backoff := c.cfg.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		start := time.Now()
+		resp, err := c.client.Do(req)
+		metrics.CounterMap("outbound_latency_ms_total").Add(c.cfg.Name, time.Since(start).Milliseconds())
+
+		failed := err != nil || resp.StatusCode >= 500
+		c.record(failed)
+		if err != nil {
+			c.count("error")
+		} else {
+			c.count(strconv.Itoa(resp.StatusCode/100) + "xx")
+		}
+
+		retryable := err != nil || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		if !retryable || attempt >= c.cfg.MaxRetries || !c.canRepeat(req) || !c.allow() || !c.withdraw() {
+			return resp, err
+		}
+
+		next, rerr := c.rewind(req)
+		if rerr != nil {
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		req = next
+		metrics.CounterMap("outbound_retries").Add(c.cfg.Name, 1)
+	}
+}
+
+// canRepeat reports whether sending the request twice can't have a double effect
+func (c *Client) canRepeat(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	return req.Method == http.MethodGet || req.Method == http.MethodHead || req.Header.Get("Idempotency-Key") != ""
+}
+
+// rewind copies the request with a fresh body for another attempt
+func (c *Client) rewind(req *http.Request) (*http.Request, error) {
+	next := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		next.Body = body
+	}
+	return next, nil
+}
+
+func (c *Client) count(outcome string) {
+	metrics.CounterMap("outbound_requests").Add(c.cfg.Name+"/"+outcome, 1)
+}
+
+func (c *Client) allow() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return !time.Now().Before(c.openUntil)
+}
+
+// record tracks consecutive failures, opening the breaker at the threshold
+func (c *Client) record(failed bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !failed {
+		c.failures = 0
+		return
+	}
+	c.failures++
+	if c.failures >= c.cfg.BreakerThreshold {
+		c.failures = 0
+		c.openUntil = time.Now().Add(c.cfg.BreakerCooldown)
+		log.Printf("%s: %d consecutive failures, pausing calls for %s", c.cfg.Name, c.cfg.BreakerThreshold, c.cfg.BreakerCooldown)
+	}
+}
+
+func (c *Client) deposit() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.retryTokens = min(c.retryTokens+c.cfg.RetryBudgetRatio, maxRetryTokens)
+}
+
+func (c *Client) withdraw() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.retryTokens < 1 {
+		return false
+	}
+	c.retryTokens--
+	return true
+}
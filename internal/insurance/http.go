@@ -0,0 +1,80 @@
+package insurance
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aditya/go-comet/internal/httpclient"
+)
+
+// HTTPInsurer calls an insurer's REST API: POST {base}/policies and POST {base}/claims
+type HTTPInsurer struct {
+	name    string
+	baseURL string
+	apiKey  string
+	client  *httpclient.Client
+}
+
+func NewHTTPInsurer(name, baseURL, apiKey string) *HTTPInsurer {
+	return &HTTPInsurer{
+		name:    name,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		client:  httpclient.New(httpclient.Config{Name: "insurance", Timeout: 10 * time.Second}),
+	}
+}
+
+func (i *HTTPInsurer) Name() string {
+	return i.name
+}
+
+func (i *HTTPInsurer) IssuePolicy(ctx context.Context, req *PolicyRequest) (*Policy, error) {
+	var policy Policy
+	if err := i.post(ctx, "/policies", req, &policy); err != nil {
+		return nil, err
+	}
+	if policy.PolicyNumber == "" {
+		return nil, fmt.Errorf("insurance: response missing policy number")
+	}
+	return &policy, nil
+}
+
+func (i *HTTPInsurer) FileClaim(ctx context.Context, req *ClaimRequest) (*ClaimAck, error) {
+	var ack ClaimAck
+	if err := i.post(ctx, "/claims", req, &ack); err != nil {
+		return nil, err
+	}
+	return &ack, nil
+}
+
+func (i *HTTPInsurer) post(ctx context.Context, path string, body, out interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, i.baseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if i.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+i.apiKey)
+	}
+
+	resp, err := i.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("insurance: %s returned status %d", path, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
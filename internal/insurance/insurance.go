@@ -0,0 +1,47 @@
+package insurance
+
+import (
+	"context"
+	"time"
+)
+
+// Coverage describes what a trip policy covers. Limits maps a coverage type
+// (e.g. "accidental_death", "medical", "baggage") to its maximum payout.
+type Coverage struct {
+	Currency string             `json:"currency"`
+	Limits   map[string]float64 `json:"limits"`
+}
+
+type PolicyRequest struct {
+	TripID      string    `json:"trip_id"`
+	RideID      string    `json:"ride_id"`
+	DriverID    string    `json:"driver_id"`
+	UserID      string    `json:"user_id"`
+	VehicleType string    `json:"vehicle_type"`
+	StartedAt   time.Time `json:"started_at"`
+}
+
+type Policy struct {
+	PolicyNumber string   `json:"policy_number"`
+	Coverage     Coverage `json:"coverage"`
+}
+
+type ClaimRequest struct {
+	ClaimID      string    `json:"claim_id"`
+	PolicyNumber string    `json:"policy_number"`
+	TripID       string    `json:"trip_id"`
+	IncidentType string    `json:"incident_type"`
+	IncidentAt   time.Time `json:"incident_at"`
+	Description  string    `json:"description"`
+}
+
+type ClaimAck struct {
+	Reference string `json:"claim_reference"`
+}
+
+// Insurer issues per-trip policies and accepts claims against them
+type Insurer interface {
+	Name() string
+	IssuePolicy(ctx context.Context, req *PolicyRequest) (*Policy, error)
+	FileClaim(ctx context.Context, req *ClaimRequest) (*ClaimAck, error)
+}
@@ -0,0 +1,46 @@
+package metrics
+
+import (
+	"expvar"
+	"net/http"
+	"sync"
+)
+
+// Metrics are exported through expvar so they can be scraped from the admin
+// metrics endpoint without pulling in an extra client library.
+
+var mu sync.Mutex
+
+// Counter returns the named counter, registering it on first use
+func Counter(name string) *expvar.Int {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if v, ok := expvar.Get(name).(*expvar.Int); ok {
+		return v
+	}
+	return expvar.NewInt(name)
+}
+
+// CounterMap returns the named labelled counter set, registering it on first use
+func CounterMap(name string) *expvar.Map {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if v, ok := expvar.Get(name).(*expvar.Map); ok {
+		return v
+	}
+	return expvar.NewMap(name)
+}
+
+// SetGauge sets a labelled value in the named gauge set, registering it on first use
+func SetGauge(name, label string, value float64) {
+	gauge := new(expvar.Float)
+	gauge.Set(value)
+	CounterMap(name).Set(label, gauge)
+}
+
+// Handler serves all registered metrics as JSON
+func Handler() http.Handler {
+	return expvar.Handler()
+}
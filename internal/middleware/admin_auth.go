@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+)
+
+const AdminKeyHeader = "X-Admin-Key"
+
+// AdminAuth guards admin routes with a shared API key. An empty key disables
+// admin access entirely rather than leaving the routes open.
+func AdminAuth(apiKey string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			provided := r.Header.Get(AdminKeyHeader)
+			if apiKey == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(apiKey)) != 1 {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusUnauthorized)
+				json.NewEncoder(w).Encode(map[string]string{
+					"error":   "unauthorized",
+					"message": "valid admin key required",
+				})
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
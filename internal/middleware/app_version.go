@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/aditya/go-comet/internal/metrics"
+	"github.com/aditya/go-comet/internal/models"
+)
+
+// Headers the mobile apps send with their platform and release version
+const (
+	AppPlatformHeader = "X-App-Platform"
+	AppVersionHeader  = "X-App-Version"
+)
+
+// AppVersionRule exempts matching endpoints from the upgrade block so an outdated
+// app can still load its config and state and take the driver offline
+type AppVersionRule struct {
+	Method string
+	Path   *regexp.Regexp
+}
+
+// AppVersionGate rejects requests from app versions below the platform's minimum
+// with 426 upgrade_required. Requests without the headers (web, partners, apps
+// predating them) or from other platforms pass through.
+type AppVersionGate struct {
+	minVersions map[string]string // platform -> minimum version
+	exempt      []AppVersionRule
+}
+
+// NewAppVersionGate creates a gate; platforms without a minimum are never blocked
+func NewAppVersionGate(minVersions map[string]string) *AppVersionGate {
+	return &AppVersionGate{minVersions: minVersions}
+}
+
+// WithExempt lets outdated apps reach matching endpoints; they see the version
+// status in the request context instead
+func (g *AppVersionGate) WithExempt(rule AppVersionRule) *AppVersionGate {
+	g.exempt = append(g.exempt, rule)
+	return g
+}
+
+func (g *AppVersionGate) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		platform := strings.ToLower(r.Header.Get(AppPlatformHeader))
+		version := r.Header.Get(AppVersionHeader)
+		if (platform != models.PlatformAndroid && platform != models.PlatformIOS) || !models.ValidVersion(version) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		metrics.CounterMap("app_versions").Add(platform+"/"+version, 1)
+
+		status := &models.AppVersionStatus{Platform: platform, Version: version}
+		if min := g.minVersions[platform]; min != "" {
+			status.MinVersion = min
+			status.UpgradeRequired = models.CompareVersions(version, min) < 0
+		}
+
+		if status.UpgradeRequired && !g.isExempt(r) {
+			metrics.CounterMap("upgrade_required").Add(platform, 1)
+			writeUpgradeRequired(w, status)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(WithAppVersion(r.Context(), status)))
+	})
+}
+
+func (g *AppVersionGate) isExempt(r *http.Request) bool {
+	for _, rule := range g.exempt {
+		if rule.Method != "" && rule.Method != r.Method {
+			continue
+		}
+		if rule.Path != nil && !rule.Path.MatchString(r.URL.Path) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+type appVersionContextKey struct{}
+
+// WithAppVersion returns a copy of ctx carrying the caller's app version status
+func WithAppVersion(ctx context.Context, status *models.AppVersionStatus) context.Context {
+	return context.WithValue(ctx, appVersionContextKey{}, status)
+}
+
+// AppVersionFromContext returns the caller's app version status, or nil when the
+// request didn't identify its app
+func AppVersionFromContext(ctx context.Context) *models.AppVersionStatus {
+	status, _ := ctx.Value(appVersionContextKey{}).(*models.AppVersionStatus)
+	return status
+}
+
+func writeUpgradeRequired(w http.ResponseWriter, status *models.AppVersionStatus) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUpgradeRequired)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error":   "upgrade_required",
+		"message": fmt.Sprintf("%s app %s is no longer supported; update to %s or later", status.Platform, status.Version, status.MinVersion),
+		"details": status,
+	})
+}
@@ -0,0 +1,139 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/pkg/utils"
+)
+
+// Principal types
+const (
+	PrincipalUser   = "user"
+	PrincipalDriver = "driver"
+)
+
+// Identity headers injected by the API gateway after it authenticates the caller.
+// The gateway must strip these from inbound client requests.
+const (
+	UserIDHeader   = "X-User-ID"
+	DriverIDHeader = "X-Driver-ID"
+)
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	Type string
+	ID   string
+	// Set when the caller authenticated with a session token
+	SessionID string
+}
+
+// Key identifies the principal across principal types
+func (p *Principal) Key() string {
+	return p.Type + ":" + p.ID
+}
+
+type principalContextKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying the principal
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalContextKey{}, p)
+}
+
+// PrincipalFromContext returns the authenticated principal, or nil for anonymous requests
+func PrincipalFromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalContextKey{}).(*Principal)
+	return p
+}
+
+// PrincipalResolver extracts the caller identity from a request.
+// It returns (nil, nil) when the request carries no credentials it understands.
+type PrincipalResolver interface {
+	Resolve(r *http.Request) (*Principal, error)
+}
+
+// HeaderPrincipalResolver trusts the gateway identity headers
+type HeaderPrincipalResolver struct{}
+
+func (HeaderPrincipalResolver) Resolve(r *http.Request) (*Principal, error) {
+	if id := r.Header.Get(DriverIDHeader); id != "" {
+		if !utils.IsValidUUID(id) {
+			return nil, errInvalidPrincipal
+		}
+		return &Principal{Type: PrincipalDriver, ID: id}, nil
+	}
+	if id := r.Header.Get(UserIDHeader); id != "" {
+		if !utils.IsValidUUID(id) {
+			return nil, errInvalidPrincipal
+		}
+		return &Principal{Type: PrincipalUser, ID: id}, nil
+	}
+	return nil, nil
+}
+
+// SessionAuthenticator looks up the session a bearer token belongs to
+type SessionAuthenticator interface {
+	Authenticate(ctx context.Context, token string) (*models.Session, error)
+}
+
+// SessionPrincipalResolver accepts "Authorization: Bearer <token>" for sessions
+// issued by POST /v1/users/{id}/sessions and /v1/drivers/{id}/sessions. Revoked
+// tokens are rejected rather than falling through to other resolvers.
+type SessionPrincipalResolver struct {
+	Sessions SessionAuthenticator
+}
+
+func (s SessionPrincipalResolver) Resolve(r *http.Request) (*Principal, error) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return nil, nil
+	}
+	session, err := s.Sessions.Authenticate(r.Context(), token)
+	if err != nil {
+		return nil, errSessionLookup
+	}
+	if session == nil {
+		return nil, errInvalidSession
+	}
+	return &Principal{Type: session.PrincipalType, ID: session.PrincipalID, SessionID: session.ID}, nil
+}
+
+type authError string
+
+func (e authError) Error() string { return string(e) }
+
+const (
+	errInvalidPrincipal = authError("invalid principal identifier")
+	errInvalidSession   = authError("session expired or revoked")
+	errSessionLookup    = authError("session could not be verified")
+)
+
+// Authenticate attaches the first principal resolved by the given resolvers to the
+// request context. Requests without credentials continue anonymously.
+func Authenticate(resolvers ...PrincipalResolver) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, resolver := range resolvers {
+				principal, err := resolver.Resolve(r)
+				if err != nil {
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusUnauthorized)
+					json.NewEncoder(w).Encode(map[string]string{
+						"error":   "unauthorized",
+						"message": err.Error(),
+					})
+					return
+				}
+				if principal != nil {
+					r = r.WithContext(WithPrincipal(r.Context(), principal))
+					break
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// TrustedProxies are the load balancers and gateways allowed to report the
+// caller's address in X-Forwarded-For. Anyone else can put anything there.
+type TrustedProxies []*net.IPNet
+
+// ParseTrustedProxies reads a comma-separated list of CIDRs or single addresses
+func ParseTrustedProxies(list string) (TrustedProxies, error) {
+	var proxies TrustedProxies
+	for _, part := range strings.Split(list, ",") {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		if !strings.Contains(part, "/") {
+			ip := net.ParseIP(part)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", part)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				bits = 8 * net.IPv4len
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", part, err)
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies, nil
+}
+
+func (p TrustedProxies) trusts(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, network := range p {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP walks X-Forwarded-For back from the connection's peer for as long as
+// each hop is a trusted proxy; the first address not a proxy is the caller
+func (p TrustedProxies) clientIP(r *http.Request) string {
+	ip := remoteHost(r.RemoteAddr)
+	if !p.trusts(ip) {
+		return ip
+	}
+	hops := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if net.ParseIP(hop) == nil {
+			break
+		}
+		ip = hop
+		if !p.trusts(hop) {
+			break
+		}
+	}
+	return ip
+}
+
+type clientIPContextKey struct{}
+
+// ResolveClientIP records the caller's address for rate limiting and audit,
+// taking X-Forwarded-For only from trusted proxies
+func ResolveClientIP(proxies TrustedProxies) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), clientIPContextKey{}, proxies.clientIP(r))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// ClientIP is the caller's address as resolved by ResolveClientIP, or the
+// connection's remote address
+func ClientIP(r *http.Request) string {
+	if ip, ok := r.Context().Value(clientIPContextKey{}).(string); ok {
+		return ip
+	}
+	return remoteHost(r.RemoteAddr)
+}
+
+func remoteHost(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
@@ -6,10 +6,13 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"io"
+	"log"
 	"net/http"
 	"time"
 
+	"github.com/aditya/go-comet/internal/repository"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -17,10 +20,13 @@ const (
 	IdempotencyHeader  = "Idempotency-Key"
 	idempotencyTTL     = 24 * time.Hour
 	idempotencyPrefix  = "idempotency:"
+	idempotencyLockTTL = 30 * time.Second
 )
 
 type IdempotencyMiddleware struct {
 	redis *redis.Client
+	// Used instead of Redis while it is unreachable
+	store repository.IdempotencyRepository
 }
 
 type cachedResponse struct {
@@ -30,8 +36,8 @@ type cachedResponse struct {
 	BodyHash   string            `json:"body_hash"`
 }
 
-func NewIdempotencyMiddleware(redisClient *redis.Client) *IdempotencyMiddleware {
-	return &IdempotencyMiddleware{redis: redisClient}
+func NewIdempotencyMiddleware(redisClient *redis.Client, store repository.IdempotencyRepository) *IdempotencyMiddleware {
+	return &IdempotencyMiddleware{redis: redisClient, store: store}
 }
 
 // responseWriter captures the response for caching
@@ -69,6 +75,11 @@ func (m *IdempotencyMiddleware) Handler(next http.Handler) http.Handler {
 		// Read and hash the request body
 		bodyBytes, err := io.ReadAll(r.Body)
 		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				writeTooLarge(w, tooLarge.Limit)
+				return
+			}
 			http.Error(w, "failed to read request body", http.StatusBadRequest)
 			return
 		}
@@ -81,38 +92,24 @@ func (m *IdempotencyMiddleware) Handler(next http.Handler) http.Handler {
 
 		// Check if we have a cached response
 		cached, err := m.getCachedResponse(ctx, cacheKey)
-		if err == nil {
-			// Verify the body hash matches
-			if cached.BodyHash != bodyHash {
-				w.Header().Set("Content-Type", "application/json")
-				w.WriteHeader(http.StatusConflict)
-				json.NewEncoder(w).Encode(map[string]string{
-					"error":   "idempotency_conflict",
-					"message": "idempotency key already used with different request",
-				})
-				return
-			}
-
-			// Return cached response
-			for k, v := range cached.Headers {
-				w.Header().Set(k, v)
-			}
-			w.WriteHeader(cached.StatusCode)
-			w.Write(cached.Body)
+		if err != nil && err != redis.Nil {
+			m.handleWithStore(w, r, next, cacheKey, bodyHash)
+			return
+		}
+		if cached != nil {
+			m.replay(w, cached, bodyHash)
 			return
 		}
 
 		// Try to acquire lock for this idempotency key
 		lockKey := cacheKey + ":lock"
-		locked, err := m.redis.SetNX(ctx, lockKey, "1", 30*time.Second).Result()
-		if err != nil || !locked {
-			// Another request is processing
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusConflict)
-			json.NewEncoder(w).Encode(map[string]string{
-				"error":   "request_in_progress",
-				"message": "a request with this idempotency key is already being processed",
-			})
+		locked, err := m.redis.SetNX(ctx, lockKey, "1", idempotencyLockTTL).Result()
+		if err != nil {
+			m.handleWithStore(w, r, next, cacheKey, bodyHash)
+			return
+		}
+		if !locked {
+			writeInProgress(w)
 			return
 		}
 		defer m.redis.Del(ctx, lockKey)
@@ -139,6 +136,86 @@ func (m *IdempotencyMiddleware) Handler(next http.Handler) http.Handler {
 	})
 }
 
+// handleWithStore processes the request with its key held in Postgres, for when
+// Redis can't be reached
+func (m *IdempotencyMiddleware) handleWithStore(w http.ResponseWriter, r *http.Request, next http.Handler, key, bodyHash string) {
+	ctx := r.Context()
+	if m.store == nil {
+		// Without a store the key can't be honoured; processing beats rejecting every retry
+		next.ServeHTTP(w, r)
+		return
+	}
+
+	stored, err := m.store.Get(ctx, key)
+	if err != nil {
+		http.Error(w, "failed to check idempotency key", http.StatusServiceUnavailable)
+		return
+	}
+	if stored != nil && stored.StatusCode != nil {
+		resp := &cachedResponse{
+			StatusCode: *stored.StatusCode,
+			Headers:    map[string]string{},
+			Body:       stored.Body,
+			BodyHash:   stored.BodyHash,
+		}
+		if stored.ContentType != nil {
+			resp.Headers["Content-Type"] = *stored.ContentType
+		}
+		m.replay(w, resp, bodyHash)
+		return
+	}
+
+	locked, err := m.store.Lock(ctx, key, bodyHash, idempotencyLockTTL, idempotencyTTL)
+	if err != nil {
+		http.Error(w, "failed to check idempotency key", http.StatusServiceUnavailable)
+		return
+	}
+	if !locked {
+		writeInProgress(w)
+		return
+	}
+
+	rw := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+	next.ServeHTTP(rw, r)
+
+	if rw.statusCode >= 200 && rw.statusCode < 300 {
+		err = m.store.Save(ctx, key, rw.statusCode, rw.Header().Get("Content-Type"), rw.body.Bytes())
+	} else {
+		err = m.store.Release(ctx, key)
+	}
+	if err != nil {
+		log.Printf("failed to store idempotent response for %s: %v", key, err)
+	}
+}
+
+// replay writes a stored response, refusing it when the key was reused for a different body
+func (m *IdempotencyMiddleware) replay(w http.ResponseWriter, cached *cachedResponse, bodyHash string) {
+	if cached.BodyHash != bodyHash {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error":   "idempotency_conflict",
+			"message": "idempotency key already used with different request",
+		})
+		return
+	}
+
+	for k, v := range cached.Headers {
+		w.Header().Set(k, v)
+	}
+	w.WriteHeader(cached.StatusCode)
+	w.Write(cached.Body)
+}
+
+func writeInProgress(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusConflict)
+	json.NewEncoder(w).Encode(map[string]string{
+		"error":   "request_in_progress",
+		"message": "a request with this idempotency key is already being processed",
+	})
+}
+
 func (m *IdempotencyMiddleware) getCachedResponse(ctx context.Context, key string) (*cachedResponse, error) {
 	data, err := m.redis.Get(ctx, key).Bytes()
 	if err != nil {
@@ -5,44 +5,82 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"regexp"
+	"sync"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 )
 
+// RateLimitRule gives a dedicated budget to one endpoint for one kind of caller.
+// An empty PrincipalType matches every caller, including anonymous ones.
+type RateLimitRule struct {
+	Name          string
+	PrincipalType string
+	Method        string
+	Path          *regexp.Regexp
+	Requests      int
+}
+
 type RateLimiter struct {
-	redis    *redis.Client
-	requests int
-	window   time.Duration
+	redis            *redis.Client
+	requests         int
+	window           time.Duration
+	principalBudgets map[string]int
+	rules            []RateLimitRule
+	// Counts requests on this instance while Redis is unreachable
+	local *localLimiter
 }
 
+// NewRateLimiter creates a limiter whose default budget applies to anonymous callers
 func NewRateLimiter(redisClient *redis.Client, requests int, window time.Duration) *RateLimiter {
 	return &RateLimiter{
-		redis:    redisClient,
-		requests: requests,
-		window:   window,
+		redis:            redisClient,
+		requests:         requests,
+		window:           window,
+		principalBudgets: make(map[string]int),
+		local:            newLocalLimiter(window),
 	}
 }
 
+// WithPrincipalBudget sets the default per-endpoint budget for authenticated callers of a type
+func (rl *RateLimiter) WithPrincipalBudget(principalType string, requests int) *RateLimiter {
+	rl.principalBudgets[principalType] = requests
+	return rl
+}
+
+// WithRule adds an endpoint-specific budget. Rules are matched in registration order.
+func (rl *RateLimiter) WithRule(rule RateLimitRule) *RateLimiter {
+	rl.rules = append(rl.rules, rule)
+	return rl
+}
+
 func (rl *RateLimiter) Handler(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Get client IP
-		clientIP := r.RemoteAddr
-		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
-			clientIP = forwarded
+		// Callers with a session are limited per principal. Everyone else is
+		// limited per IP, including callers naming themselves in the identity
+		// headers: a fresh made-up ID per request would otherwise get a fresh budget.
+		// Budgets follow the same rule, so naming yourself a driver doesn't buy a
+		// driver's allowance.
+		subject := "ip:" + ClientIP(r)
+		principal := PrincipalFromContext(r.Context())
+		if principal != nil && principal.SessionID != "" {
+			subject = principal.Key()
+		} else {
+			principal = nil
 		}
 
-		key := fmt.Sprintf("ratelimit:%s:%s", clientIP, r.URL.Path)
+		bucket, limit := rl.budgetFor(r, principal)
+		key := fmt.Sprintf("ratelimit:%s:%s", subject, bucket)
 		ctx := r.Context()
 
-		allowed, remaining, err := rl.isAllowed(ctx, key)
+		allowed, remaining, err := rl.isAllowed(ctx, key, limit)
 		if err != nil {
-			// On error, allow the request
-			next.ServeHTTP(w, r)
-			return
+			// Redis is unreachable; budgets are enforced per instance until it's back
+			allowed, remaining = rl.local.allow(key, limit)
 		}
 
-		w.Header().Set("X-RateLimit-Limit", fmt.Sprintf("%d", rl.requests))
+		w.Header().Set("X-RateLimit-Limit", fmt.Sprintf("%d", limit))
 		w.Header().Set("X-RateLimit-Remaining", fmt.Sprintf("%d", remaining))
 
 		if !allowed {
@@ -59,7 +97,34 @@ func (rl *RateLimiter) Handler(next http.Handler) http.Handler {
 	})
 }
 
-func (rl *RateLimiter) isAllowed(ctx context.Context, key string) (bool, int, error) {
+// budgetFor returns the counter bucket and request allowance for this request.
+// principal is nil for callers without a verified session.
+func (rl *RateLimiter) budgetFor(r *http.Request, principal *Principal) (string, int) {
+	principalType := ""
+	if principal != nil {
+		principalType = principal.Type
+	}
+
+	for _, rule := range rl.rules {
+		if rule.PrincipalType != "" && rule.PrincipalType != principalType {
+			continue
+		}
+		if rule.Method != "" && rule.Method != r.Method {
+			continue
+		}
+		if rule.Path != nil && !rule.Path.MatchString(r.URL.Path) {
+			continue
+		}
+		return rule.Name, rule.Requests
+	}
+
+	if budget, ok := rl.principalBudgets[principalType]; ok {
+		return r.URL.Path, budget
+	}
+	return r.URL.Path, rl.requests
+}
+
+func (rl *RateLimiter) isAllowed(ctx context.Context, key string, limit int) (bool, int, error) {
 	pipe := rl.redis.Pipeline()
 
 	incr := pipe.Incr(ctx, key)
@@ -67,14 +132,46 @@ func (rl *RateLimiter) isAllowed(ctx context.Context, key string) (bool, int, er
 
 	_, err := pipe.Exec(ctx)
 	if err != nil {
-		return true, rl.requests, err
+		return true, limit, err
 	}
 
 	count := int(incr.Val())
-	remaining := rl.requests - count
+	remaining := limit - count
 	if remaining < 0 {
 		remaining = 0
 	}
 
-	return count <= rl.requests, remaining, nil
+	return count <= limit, remaining, nil
+}
+
+// localLimiter is a fixed-window counter kept in memory
+type localLimiter struct {
+	mu          sync.Mutex
+	window      time.Duration
+	windowStart time.Time
+	counts      map[string]int
+}
+
+func newLocalLimiter(window time.Duration) *localLimiter {
+	return &localLimiter{window: window, counts: make(map[string]int)}
+}
+
+func (l *localLimiter) allow(key string, limit int) (bool, int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Every counter resets together, so keys seen once don't accumulate
+	now := time.Now()
+	if now.Sub(l.windowStart) >= l.window {
+		l.windowStart = now
+		l.counts = make(map[string]int)
+	}
+
+	l.counts[key]++
+	count := l.counts[key]
+	remaining := limit - count
+	if remaining < 0 {
+		remaining = 0
+	}
+	return count <= limit, remaining
 }
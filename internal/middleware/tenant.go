@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net"
+	"net/http"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/tenant"
+)
+
+// TenantKeyHeader carries the API key a white-label app was issued
+const TenantKeyHeader = "X-Tenant-Key"
+
+// TenantResolver looks up tenants by API key, host and code
+type TenantResolver interface {
+	ResolveAPIKey(ctx context.Context, apiKey string) (*models.Tenant, error)
+	ResolveHost(ctx context.Context, host string) (*models.Tenant, error)
+	GetTenant(ctx context.Context, code string) (*models.Tenant, error)
+}
+
+// ResolveTenant scopes the request to the tenant identified by its API key or,
+// failing that, the host it was sent to. Other requests belong to the default tenant.
+func ResolveTenant(resolver TenantResolver) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t, err := resolveTenant(r, resolver)
+			if err != nil {
+				log.Printf("failed to resolve tenant: %v", err)
+				writeTenantError(w, http.StatusServiceUnavailable, "service_unavailable", "tenant lookup failed")
+				return
+			}
+			if t == nil {
+				writeTenantError(w, http.StatusUnauthorized, "unauthorized", "invalid tenant key")
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(tenant.With(r.Context(), t)))
+		})
+	}
+}
+
+// resolveTenant returns nil without an error when the request's API key is unknown
+func resolveTenant(r *http.Request, resolver TenantResolver) (*models.Tenant, error) {
+	ctx := r.Context()
+	if key := r.Header.Get(TenantKeyHeader); key != "" {
+		return resolver.ResolveAPIKey(ctx, key)
+	}
+
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	t, err := resolver.ResolveHost(ctx, host)
+	if err != nil || t != nil {
+		return t, err
+	}
+
+	t, err = resolver.GetTenant(ctx, models.DefaultTenant)
+	if err != nil || t != nil {
+		return t, err
+	}
+	// The default tenant row is missing or inactive; still serve under its code
+	return &models.Tenant{Code: models.DefaultTenant, Active: true}, nil
+}
+
+func writeTenantError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{
+		"error":   code,
+		"message": message,
+	})
+}
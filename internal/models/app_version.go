@@ -0,0 +1,73 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+)
+
+// App platforms that can be held to a minimum version
+const (
+	PlatformAndroid = "android"
+	PlatformIOS     = "ios"
+)
+
+// AppVersionStatus tells a client whether its app version is still supported
+type AppVersionStatus struct {
+	Platform        string `json:"platform"`
+	Version         string `json:"version"`
+	MinVersion      string `json:"min_version"`
+	UpgradeRequired bool   `json:"upgrade_required"`
+}
+
+// CompareVersions compares dotted numeric versions such as "4.12.0", returning
+// -1, 0 or 1. Missing parts count as zero and anything after a "-" or "+" (build
+// or pre-release tags) is ignored.
+func CompareVersions(a, b string) int {
+	pa, pb := versionParts(a), versionParts(b)
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+	return 0
+}
+
+// ValidVersion reports whether v is a dotted numeric version
+func ValidVersion(v string) bool {
+	v = strings.TrimPrefix(v, "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	if v == "" {
+		return false
+	}
+	for _, part := range strings.Split(v, ".") {
+		if _, err := strconv.Atoi(part); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func versionParts(v string) []int {
+	v = strings.TrimPrefix(v, "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	var parts []int
+	for _, s := range strings.Split(v, ".") {
+		n, _ := strconv.Atoi(s)
+		parts = append(parts, n)
+	}
+	return parts
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Audited entity types (match the source table names written by the audit trigger)
+const (
+	AuditEntityRide  = "rides"
+	AuditEntityTrip  = "trips"
+	AuditEntityOffer = "ride_offers"
+	// Payments aren't captured by the audit trigger but share its naming
+	AuditEntityPayment = "payments"
+)
+
+type AuditEntry struct {
+	ID         int64           `db:"id" json:"id"`
+	EntityType string          `db:"entity_type" json:"entity_type"`
+	EntityID   string          `db:"entity_id" json:"entity_id"`
+	RideID     string          `db:"ride_id" json:"ride_id"`
+	Operation  string          `db:"operation" json:"operation"`
+	Snapshot   json.RawMessage `db:"snapshot" json:"snapshot"`
+	RecordedAt time.Time       `db:"recorded_at" json:"recorded_at"`
+}
+
+// DecodeSnapshot decodes the snapshot into dst, a pointer to a model struct. The
+// trigger records row_to_json, keyed by column name, so keys are matched to the
+// fields' db tags rather than their JSON names, which differ for some columns.
+func (e *AuditEntry) DecodeSnapshot(dst interface{}) error {
+	var columns map[string]json.RawMessage
+	if err := json.Unmarshal(e.Snapshot, &columns); err != nil {
+		return err
+	}
+
+	v := reflect.ValueOf(dst).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		column, _, _ := strings.Cut(t.Field(i).Tag.Get("db"), ",")
+		raw, ok := columns[column]
+		if column == "" || column == "-" || !ok {
+			continue
+		}
+		if err := json.Unmarshal(raw, v.Field(i).Addr().Interface()); err != nil {
+			return fmt.Errorf("decode %s.%s snapshot: %w", e.EntityType, column, err)
+		}
+	}
+	return nil
+}
+
+// RideReplay is the reconstructed state of a ride and its related rows at a point in time
+type RideReplay struct {
+	At       time.Time     `json:"at"`
+	Ride     *Ride         `json:"ride"`
+	Trip     *Trip         `json:"trip,omitempty"`
+	Offers   []*RideOffer  `json:"offers"`
+	Timeline []*AuditEntry `json:"timeline"`
+}
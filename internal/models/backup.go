@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+// Backup statuses
+const (
+	BackupStatusPending    = "pending"
+	BackupStatusProcessing = "processing"
+	BackupStatusReady      = "ready"
+	BackupStatusFailed     = "failed"
+)
+
+// BackupTables are the tables a logical backup exports, in the order they can be
+// restored without breaking foreign keys
+var BackupTables = []string{"users", "drivers", "rides", "trips", "payments"}
+
+// BackupFormat describes the table files of a backup: one JSON object per row,
+// keyed by column name, gzipped
+const BackupFormat = "jsonl+gzip"
+
+// Backup is a logical export of the core tables taken from one consistent
+// snapshot of the database
+type Backup struct {
+	ID          string     `db:"id" json:"id"`
+	Status      string     `db:"status" json:"status"`
+	ManifestKey *string    `db:"manifest_key" json:"manifest_key,omitempty"`
+	RowCount    *int       `db:"row_count" json:"row_count,omitempty"`
+	SnapshotAt  *time.Time `db:"snapshot_at" json:"snapshot_at,omitempty"`
+	Error       *string    `db:"error" json:"error,omitempty"`
+	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
+	StartedAt   *time.Time `db:"started_at" json:"started_at,omitempty"`
+	CompletedAt *time.Time `db:"completed_at" json:"completed_at,omitempty"`
+
+	// Set on ready backups; the manifest link stops working at DownloadExpiresAt
+	DownloadURL       *string    `db:"-" json:"manifest_url,omitempty"`
+	DownloadExpiresAt *time.Time `db:"-" json:"manifest_url_expires_at,omitempty"`
+}
+
+// BackupManifest is stored next to a backup's table files. Restores should
+// check each file's size and SHA-256 before loading it.
+type BackupManifest struct {
+	BackupID   string                 `json:"backup_id"`
+	Format     string                 `json:"format"`
+	SnapshotAt time.Time              `json:"snapshot_at"`
+	CreatedAt  time.Time              `json:"created_at"`
+	Tables     []*BackupManifestTable `json:"tables"`
+}
+
+// BackupManifestTable describes one table file of a backup
+type BackupManifestTable struct {
+	Name      string `json:"name"`
+	ObjectKey string `json:"object_key"`
+	Rows      int    `json:"rows"`
+	Bytes     int    `json:"bytes"`
+	SHA256    string `json:"sha256"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+)
+
+// Ride pricing modes
+const (
+	PricingModeStandard = "standard"
+	PricingModeBid      = "bid" // rider proposes a fare and picks among driver counter-offers
+)
+
+// BidPolicy bounds fare negotiation in bid mode
+type BidPolicy struct {
+	// Lowest fare a rider may propose, as a fraction of the estimated fare
+	MinFareRatio float64
+	// Highest counter-offer a driver may make, as a multiple of the proposed fare
+	MaxCounterRatio float64
+	// How long drivers have to accept or counter a bid ride offer
+	OfferTimeout time.Duration
+	// How long a counter-offer stays open for the rider to accept
+	CounterTTL time.Duration
+	// Bid rides still unassigned after this long are cancelled
+	Window time.Duration
+}
+
+type CounterOfferRequest struct {
+	Fare float64 `json:"fare" validate:"required,gt=0"`
+}
+
+type AcceptBidRequest struct {
+	UserID string `json:"user_id" validate:"required,uuid"`
+}
+
+// Bid is a driver's counter-offer as shown to the rider
+type Bid struct {
+	OfferID     string          `json:"offer_id"`
+	DriverID    string          `json:"driver_id"`
+	Driver      *DriverResponse `json:"driver,omitempty"`
+	Fare        float64         `json:"fare"`
+	CounteredAt time.Time       `json:"countered_at"`
+	ExpiresAt   time.Time       `json:"expires_at"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Cancellation fee statuses
+const (
+	CancellationFeeCharged     = "charged"     // debited from the rider's wallet
+	CancellationFeeOutstanding = "outstanding" // added to the rider's next trip payment
+	CancellationFeeSettled     = "settled"     // paid with a later trip
+)
+
+// CancellationFee is what a rider owes for cancelling late or after the driver arrived
+type CancellationFee struct {
+	ID          string    `db:"id" json:"id"`
+	RideID      string    `db:"ride_id" json:"ride_id"`
+	UserID      string    `db:"user_id" json:"user_id"`
+	DriverID    string    `db:"driver_id" json:"driver_id"`
+	Amount      float64   `db:"amount" json:"amount"`
+	Currency    string    `db:"currency" json:"currency"`
+	Status      string    `db:"status" json:"status"`
+	WalletTxnID *string   `db:"wallet_txn_id" json:"wallet_txn_id,omitempty"`
+	PaymentID   *string   `db:"payment_id" json:"payment_id,omitempty"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"math"
+)
+
+// Carbon stats owner types
+const (
+	CarbonOwnerUser   = "user"
+	CarbonOwnerDriver = "driver"
+)
+
+// EmissionFactors are average tailpipe CO2 emissions per vehicle type in grams per km
+var EmissionFactors = map[string]float64{
+	VehicleTypeBike:  40,
+	VehicleTypeAuto:  70,
+	VehicleTypeMini:  120,
+	VehicleTypeSedan: 150,
+	VehicleTypeSUV:   210,
+}
+
+const (
+	defaultEmissionFactor = 150
+	// evEmissionFactor approximates grid emissions for charging an EV, per km
+	evEmissionFactor = 45
+)
+
+// EstimateCO2Grams estimates the CO2 emitted driving distanceKm in the given vehicle type
+func EstimateCO2Grams(vehicleType string, isEV bool, distanceKm float64) float64 {
+	factor, ok := EmissionFactors[vehicleType]
+	if !ok {
+		factor = defaultEmissionFactor
+	}
+	if isEV {
+		factor = evEmissionFactor
+	}
+	return math.Round(factor*distanceKm*10) / 10
+}
+
+// CarbonOffsetAmount prices offsetting co2Grams at pricePerKg, rounded up to
+// the currency's minor unit
+func CarbonOffsetAmount(co2Grams, pricePerKg float64) float64 {
+	return math.Ceil(co2Grams/1000*pricePerKg*100) / 100
+}
+
+// CarbonStats are cumulative trip emissions for a rider or driver
+type CarbonStats struct {
+	OwnerType     string  `db:"-" json:"owner_type"`
+	OwnerID       string  `db:"-" json:"owner_id"`
+	Trips         int     `db:"trips" json:"trips"`
+	DistanceKm    float64 `db:"distance_km" json:"distance_km"`
+	CO2Grams      float64 `db:"co2_grams" json:"co2_grams"`
+	OffsetGrams   float64 `db:"offset_grams" json:"offset_grams"`
+	OffsetDonated float64 `db:"offset_donated" json:"offset_donated"`
+}
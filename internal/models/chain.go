@@ -0,0 +1,13 @@
+package models
+
+import (
+	"time"
+)
+
+// ChainPolicy controls when a driver still on a trip can be offered their next ride
+type ChainPolicy struct {
+	// Drivers this close to finishing their current trip are eligible (0 disables chaining)
+	Window time.Duration
+	// The next pickup must be within this distance of the current dropoff
+	PickupRadiusKm float64
+}
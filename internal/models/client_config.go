@@ -0,0 +1,66 @@
+package models
+
+// Client feature flag names
+const (
+	FeatureBidMode         = "bid_mode"
+	FeatureTripChaining    = "trip_chaining"
+	FeatureAddressPickup   = "address_pickup"
+	FeatureCarbonOffset    = "carbon_offset"
+	FeatureTripInsurance   = "trip_insurance"
+	FeatureSelfieCheck     = "selfie_check"
+	FeatureSafetyGender    = "safety_gender_filter"
+	FeatureFavoriteDrivers = "favorite_drivers"
+)
+
+// ClientDefaults is the deployment-wide client configuration that regions may override
+type ClientDefaults struct {
+	Features                   map[string]bool
+	LocationUpdateSeconds      int
+	OfferPollSeconds           int
+	RideStatusPollSeconds      int
+	MapTileURL                 string
+	MapTileKey                 string
+	FreeCancellationWindowSecs int
+}
+
+// ClientConfig is served to mobile apps so they don't hardcode server-side policy
+type ClientConfig struct {
+	Tenant       string              `json:"tenant,omitempty"`
+	Branding     *TenantBranding     `json:"branding,omitempty"`
+	Region       *string             `json:"region,omitempty"`
+	Features     map[string]bool     `json:"features"`
+	Polling      ClientPollingConfig `json:"polling"`
+	Map          ClientMapConfig     `json:"map"`
+	Cancellation ClientCancelConfig  `json:"cancellation"`
+	VehicleTypes []string            `json:"vehicle_types"`
+	// Display details for each of VehicleTypes, in the same order
+	VehicleTypeDetails []*ClientVehicleType `json:"vehicle_type_details"`
+	// Set when the request carried app version headers
+	AppVersion *AppVersionStatus `json:"app_version,omitempty"`
+	// Set while maintenance is in effect or coming up, for the app to show a banner
+	Maintenance *MaintenanceNotice `json:"maintenance,omitempty"`
+}
+
+// ClientVehicleType is how apps show a bookable vehicle type
+type ClientVehicleType struct {
+	Code     string `json:"code"`
+	Label    string `json:"label"`
+	Capacity int    `json:"capacity"`
+	Icon     string `json:"icon"`
+}
+
+type ClientPollingConfig struct {
+	LocationUpdateSeconds int `json:"location_update_seconds"`
+	OfferPollSeconds      int `json:"offer_poll_seconds"`
+	RideStatusPollSeconds int `json:"ride_status_poll_seconds"`
+}
+
+type ClientMapConfig struct {
+	TileURL string `json:"tile_url,omitempty"`
+	TileKey string `json:"tile_key,omitempty"`
+}
+
+type ClientCancelConfig struct {
+	// Riders may cancel without penalty within this long of a driver accepting
+	FreeWindowSeconds int `json:"free_window_seconds"`
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// Commission override kinds
+const (
+	CommissionKindNewDriver    = "new_driver"
+	CommissionKindFleetPartner = "fleet_partner"
+	CommissionKindCustom       = "custom"
+)
+
+// CommissionOverride replaces the platform commission for one driver while it is in effect
+type CommissionOverride struct {
+	ID                string     `db:"id" json:"id"`
+	DriverID          string     `db:"driver_id" json:"driver_id"`
+	Kind              string     `db:"kind" json:"kind"`
+	CommissionPercent float64    `db:"commission_percent" json:"commission_percent"`
+	EffectiveFrom     time.Time  `db:"effective_from" json:"effective_from"`
+	EffectiveTo       *time.Time `db:"effective_to" json:"effective_to,omitempty"`
+	Note              *string    `db:"note" json:"note,omitempty"`
+	CreatedAt         time.Time  `db:"created_at" json:"created_at"`
+}
+
+// ActiveAt reports whether the override applies at the given moment
+func (o *CommissionOverride) ActiveAt(at time.Time) bool {
+	return !at.Before(o.EffectiveFrom) && (o.EffectiveTo == nil || at.Before(*o.EffectiveTo))
+}
+
+type CreateCommissionOverrideRequest struct {
+	Kind              string  `json:"kind" validate:"required,oneof=new_driver fleet_partner custom"`
+	CommissionPercent float64 `json:"commission_percent" validate:"gte=0,lte=100"`
+	// Defaults to now
+	EffectiveFrom *time.Time `json:"effective_from,omitempty"`
+	// Open-ended when omitted
+	EffectiveTo *time.Time `json:"effective_to,omitempty"`
+	Note        string     `json:"note,omitempty" validate:"max=500"`
+}
+
+// DriverCommission is a driver's current rate alongside their override history
+type DriverCommission struct {
+	DriverID          string                `json:"driver_id"`
+	CommissionPercent float64               `json:"commission_percent"`
+	DefaultPercent    float64               `json:"default_percent"`
+	Override          *CommissionOverride   `json:"override,omitempty"`
+	History           []*CommissionOverride `json:"history"`
+}
+
+// CommissionSplit divides a fare between the platform and the driver
+type CommissionSplit struct {
+	Percent        float64
+	Commission     float64
+	DriverEarnings float64
+}
+
+func NewCommissionSplit(fare, percent float64) CommissionSplit {
+	commission := math.Round(fare*percent) / 100
+	return CommissionSplit{
+		Percent:        percent,
+		Commission:     commission,
+		DriverEarnings: roundMoney(fare - commission),
+	}
+}
@@ -0,0 +1,71 @@
+package models
+
+import "time"
+
+// Legal document types. Addenda add region-specific terms on top of the general
+// terms and privacy policy.
+const (
+	LegalDocTerms    = "terms"
+	LegalDocPrivacy  = "privacy"
+	LegalDocAddendum = "addendum"
+)
+
+// Who a legal document applies to; parties are the same as rater types
+const (
+	ConsentAudienceAll    = "all"
+	ConsentAudienceUser   = "user"
+	ConsentAudienceDriver = "driver"
+)
+
+// LegalDocument is one published version of a legal document. The newest
+// version of each document (type, region and audience) is the current one.
+// Documents without a region apply everywhere.
+type LegalDocument struct {
+	ID         string  `db:"id" json:"id"`
+	DocType    string  `db:"doc_type" json:"doc_type"`
+	RegionCode *string `db:"region_code" json:"region_code,omitempty"`
+	Audience   string  `db:"audience" json:"audience"`
+	Version    string  `db:"version" json:"version"`
+	Title      string  `db:"title" json:"title"`
+	URL        string  `db:"url" json:"url"`
+	// A required version must be accepted before booking or going online; other
+	// versions are only recorded when accepted
+	Required  bool      `db:"required" json:"required"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+// AppliesTo reports whether the document covers the party in the region; a
+// party in no known region is only covered by documents without one
+func (d *LegalDocument) AppliesTo(partyType, regionCode string) bool {
+	if d.Audience != ConsentAudienceAll && d.Audience != partyType {
+		return false
+	}
+	return d.RegionCode == nil || *d.RegionCode == regionCode
+}
+
+// ConsentRecord is a rider's or driver's acceptance of a document version
+type ConsentRecord struct {
+	ID         string    `db:"id" json:"id"`
+	PartyType  string    `db:"party_type" json:"party_type"`
+	PartyID    string    `db:"party_id" json:"party_id"`
+	DocumentID string    `db:"document_id" json:"document_id"`
+	DocType    string    `db:"doc_type" json:"doc_type"`
+	RegionCode *string   `db:"region_code" json:"region_code,omitempty"`
+	Version    string    `db:"version" json:"version"`
+	IPAddress  string    `db:"ip_address" json:"ip_address"`
+	AcceptedAt time.Time `db:"accepted_at" json:"accepted_at"`
+}
+
+type CreateLegalDocumentRequest struct {
+	DocType    string  `json:"doc_type" validate:"required,oneof=terms privacy addendum"`
+	RegionCode *string `json:"region_code,omitempty"`
+	Audience   string  `json:"audience,omitempty" validate:"omitempty,oneof=all user driver"`
+	Version    string  `json:"version" validate:"required,max=20"`
+	Title      string  `json:"title" validate:"required,max=100"`
+	URL        string  `json:"url" validate:"required,url"`
+	Required   bool    `json:"required"`
+}
+
+type AcceptConsentRequest struct {
+	DocumentIDs []string `json:"document_ids" validate:"required,min=1,dive,uuid"`
+}
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"time"
+)
+
+// Reasons a driver was put on a cooldown
+const (
+	CooldownConsecutiveTrips = "consecutive_trips"
+	CooldownDrivingHours     = "driving_hours"
+)
+
+// CooldownPolicy limits back-to-back driving. Trips separated by less than
+// BreakGap form a streak; a streak of MaxTrips trips or lasting MaxDriving earns
+// the driver a Cooldown with no new offers.
+type CooldownPolicy struct {
+	// 0 disables the rule
+	MaxTrips   int
+	MaxDriving time.Duration
+	BreakGap   time.Duration
+	Cooldown   time.Duration
+}
+
+// Enabled reports whether any limit is set
+func (p CooldownPolicy) Enabled() bool {
+	return p.Cooldown > 0 && (p.MaxTrips > 0 || p.MaxDriving > 0)
+}
+
+// Streak is the run of back-to-back trips ending with the driver's latest trip
+type Streak struct {
+	Trips   int
+	Driving time.Duration
+}
+
+// CurrentStreak walks back from the most recent completed trip (trips are newest
+// first) until a break of at least BreakGap. Trips started before since, e.g. the
+// end of a previous cooldown, don't count.
+func (p CooldownPolicy) CurrentStreak(trips []*Trip, since time.Time) Streak {
+	var streak Streak
+	var earliest, latest time.Time
+	for i, trip := range trips {
+		if trip.StartTime == nil || trip.EndTime == nil || trip.StartTime.Before(since) {
+			break
+		}
+		if i > 0 && earliest.Sub(*trip.EndTime) >= p.BreakGap {
+			break
+		}
+		if i == 0 {
+			latest = *trip.EndTime
+		}
+		earliest = *trip.StartTime
+		streak.Trips++
+	}
+	if streak.Trips > 0 {
+		streak.Driving = latest.Sub(earliest)
+	}
+	return streak
+}
+
+// Exceeded returns why the streak requires a cooldown, or "" if it doesn't
+func (p CooldownPolicy) Exceeded(streak Streak) string {
+	if p.MaxTrips > 0 && streak.Trips >= p.MaxTrips {
+		return CooldownConsecutiveTrips
+	}
+	if p.MaxDriving > 0 && streak.Driving >= p.MaxDriving {
+		return CooldownDrivingHours
+	}
+	return ""
+}
+
+// DriverCooldown is shown on the driver's state while they are resting
+type DriverCooldown struct {
+	Reason           string    `json:"reason"`
+	Until            time.Time `json:"until"`
+	RemainingSeconds int       `json:"remaining_seconds"`
+}
+
+// ActiveCooldown returns the driver's cooldown if it hasn't passed yet
+func (d *Driver) ActiveCooldown(now time.Time) *DriverCooldown {
+	if d.CooldownUntil == nil || !d.CooldownUntil.After(now) {
+		return nil
+	}
+	cooldown := &DriverCooldown{
+		Until:            *d.CooldownUntil,
+		RemainingSeconds: int(d.CooldownUntil.Sub(now).Seconds()),
+	}
+	if d.CooldownReason != nil {
+		cooldown.Reason = *d.CooldownReason
+	}
+	return cooldown
+}
@@ -0,0 +1,115 @@
+package models
+
+import (
+	"time"
+)
+
+// Deduction kinds
+const (
+	DeductionKindVehicleRent = "vehicle_rent"
+	DeductionKindOther       = "other"
+)
+
+// Deduction frequencies
+const (
+	DeductionFrequencyDaily  = "daily"
+	DeductionFrequencyWeekly = "weekly"
+)
+
+// Deduction schedule statuses
+const (
+	DeductionStatusActive    = "active"
+	DeductionStatusCancelled = "cancelled"
+	DeductionStatusEnded     = "ended"
+)
+
+// Ledger entry types
+const (
+	LedgerEntryDeduction = "deduction"
+	LedgerEntryIncentive = "incentive"
+	// The driver's share of a paid fare, or the commission owed on a cash fare
+	LedgerEntryEarning         = "earning"
+	LedgerEntryEarningReversal = "earning_reversal"
+	LedgerEntryPayout          = "payout"
+)
+
+// LedgerAdjustmentTypes are the entries that adjust a driver's pay, as opposed to
+// posting fares or settling the balance
+var LedgerAdjustmentTypes = []string{LedgerEntryDeduction, LedgerEntryIncentive}
+
+// DeductionSchedule charges a fixed amount against a driver's balance every period
+type DeductionSchedule struct {
+	ID            string     `db:"id" json:"id"`
+	DriverID      string     `db:"driver_id" json:"driver_id"`
+	Kind          string     `db:"kind" json:"kind"`
+	Amount        float64    `db:"amount" json:"amount"`
+	Frequency     string     `db:"frequency" json:"frequency"`
+	StartDate     time.Time  `db:"start_date" json:"start_date"`
+	EndDate       *time.Time `db:"end_date" json:"end_date,omitempty"`
+	Description   *string    `db:"description" json:"description,omitempty"`
+	Status        string     `db:"status" json:"status"`
+	LastChargedOn *time.Time `db:"last_charged_on" json:"last_charged_on,omitempty"`
+	CreatedAt     time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt     time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// DueDates lists the period dates not yet charged up to and including today
+func (s *DeductionSchedule) DueDates(today time.Time) []time.Time {
+	last := today
+	if s.EndDate != nil && s.EndDate.Before(last) {
+		last = *s.EndDate
+	}
+
+	var due []time.Time
+	for d := s.StartDate; !d.After(last); d = d.AddDate(0, 0, s.step()) {
+		if s.LastChargedOn != nil && !d.After(*s.LastChargedOn) {
+			continue
+		}
+		due = append(due, d)
+	}
+	return due
+}
+
+// Finished reports whether every period up to the end date has been charged
+func (s *DeductionSchedule) Finished() bool {
+	return s.EndDate != nil && s.LastChargedOn != nil && s.LastChargedOn.AddDate(0, 0, s.step()).After(*s.EndDate)
+}
+
+func (s *DeductionSchedule) step() int {
+	if s.Frequency == DeductionFrequencyWeekly {
+		return 7
+	}
+	return 1
+}
+
+type CreateDeductionScheduleRequest struct {
+	Kind        string  `json:"kind" validate:"required,oneof=vehicle_rent other"`
+	Amount      float64 `json:"amount" validate:"required,gt=0"`
+	Frequency   string  `json:"frequency" validate:"required,oneof=daily weekly"`
+	Description string  `json:"description,omitempty" validate:"max=500"`
+	// YYYY-MM-DD; start defaults to today, end is open-ended when omitted
+	StartDate string `json:"start_date,omitempty" validate:"omitempty,datetime=2006-01-02"`
+	EndDate   string `json:"end_date,omitempty" validate:"omitempty,datetime=2006-01-02"`
+}
+
+// LedgerEntry is a signed movement on a driver's balance
+type LedgerEntry struct {
+	ID          string     `db:"id" json:"id"`
+	DriverID    string     `db:"driver_id" json:"driver_id"`
+	EntryType   string     `db:"entry_type" json:"entry_type"`
+	Amount      float64    `db:"amount" json:"amount"`
+	Description *string    `db:"description" json:"description,omitempty"`
+	ScheduleID  *string    `db:"schedule_id" json:"schedule_id,omitempty"`
+	PeriodDate  *time.Time `db:"period_date" json:"period_date,omitempty"`
+	TripID      *string    `db:"trip_id" json:"trip_id,omitempty"`
+	IncentiveID *string    `db:"incentive_id" json:"incentive_id,omitempty"`
+	PaymentID   *string    `db:"payment_id" json:"payment_id,omitempty"`
+	PayoutID    *string    `db:"payout_id" json:"payout_id,omitempty"`
+	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
+}
+
+// DriverDeductions is a driver's deduction schedules with recent charges
+type DriverDeductions struct {
+	Schedules []*DeductionSchedule `json:"schedules"`
+	Recent    []*LedgerEntry       `json:"recent_entries"`
+}
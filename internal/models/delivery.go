@@ -0,0 +1,100 @@
+package models
+
+import (
+	"time"
+)
+
+// Delivery status constants
+const (
+	DeliveryStatusAwaitingPickup = "awaiting_pickup"
+	DeliveryStatusPickedUp       = "picked_up"
+	DeliveryStatusDelivered      = "delivered"
+)
+
+// Package size classes
+const (
+	PackageSizeSmall  = "small"
+	PackageSizeMedium = "medium"
+	PackageSizeLarge  = "large"
+)
+
+// PackageClass is the heaviest parcel a size class carries and the flat
+// surcharge added to the fare for it
+type PackageClass struct {
+	MaxWeightKg float64
+	Surcharge   float64
+}
+
+// PackageClasses maps each package size to its weight limit and surcharge
+var PackageClasses = map[string]PackageClass{
+	PackageSizeSmall:  {MaxWeightKg: 5, Surcharge: 0},
+	PackageSizeMedium: {MaxWeightKg: 15, Surcharge: 30},
+	PackageSizeLarge:  {MaxWeightKg: 30, Surcharge: 60},
+}
+
+// DeclaredValueSurchargePercent of a parcel's declared value is charged to cover
+// it against loss or damage in transit
+const DeclaredValueSurchargePercent = 1.0
+
+// DeliveryMaxCodeAttempts is how many wrong codes a driver may enter before the
+// delivery can only be completed by support
+const DeliveryMaxCodeAttempts = 5
+
+// Delivery is the parcel carried on a delivery ride. The sender shares the OTP
+// with the recipient, who reads it out to the driver at handover.
+type Delivery struct {
+	RideID               string     `db:"ride_id" json:"ride_id"`
+	Status               string     `db:"status" json:"status"`
+	RecipientName        string     `db:"recipient_name" json:"recipient_name"`
+	RecipientPhone       string     `db:"recipient_phone" json:"recipient_phone"`
+	PackageDescription   *string    `db:"package_description" json:"package_description,omitempty"`
+	PackageSize          string     `db:"package_size" json:"package_size"`
+	WeightKg             *float64   `db:"weight_kg" json:"weight_kg,omitempty"`
+	DeclaredValue        *float64   `db:"declared_value" json:"declared_value,omitempty"`
+	OTP                  string     `db:"otp" json:"otp,omitempty"`
+	OTPAttempts          int        `db:"otp_attempts" json:"-"`
+	PickupPhotoUploadID  *string    `db:"pickup_photo_upload_id" json:"pickup_photo_upload_id,omitempty"`
+	PickedUpAt           *time.Time `db:"picked_up_at" json:"picked_up_at,omitempty"`
+	DropoffPhotoUploadID *string    `db:"dropoff_photo_upload_id" json:"dropoff_photo_upload_id,omitempty"`
+	ReceivedBy           *string    `db:"received_by" json:"received_by,omitempty"`
+	DeliveredAt          *time.Time `db:"delivered_at" json:"delivered_at,omitempty"`
+	CreatedAt            time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt            time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// DeliveryDetails describes the parcel when booking a delivery ride
+type DeliveryDetails struct {
+	RecipientName      string  `json:"recipient_name" validate:"required,max=100"`
+	RecipientPhone     string  `json:"recipient_phone" validate:"required,min=10,max=15"`
+	PackageDescription string  `json:"package_description,omitempty" validate:"max=200"`
+	PackageSize        string  `json:"package_size" validate:"required,oneof=small medium large"`
+	WeightKg           float64 `json:"weight_kg" validate:"gt=0,lte=30"`
+	// Value the parcel is covered for, charged at DeclaredValueSurchargePercent
+	DeclaredValue *float64 `json:"declared_value,omitempty" validate:"omitempty,gt=0,lte=50000"`
+}
+
+// ConfirmPickupRequest is the driver's photo of the parcel as collected
+type ConfirmPickupRequest struct {
+	UploadID string `json:"upload_id" validate:"required,uuid"`
+}
+
+// ConfirmDropoffRequest is the driver's proof of handover to the recipient
+type ConfirmDropoffRequest struct {
+	UploadID   string `json:"upload_id" validate:"required,uuid"`
+	ReceivedBy string `json:"received_by" validate:"required,max=100"`
+	OTP        string `json:"otp" validate:"required,numeric,len=4"`
+}
+
+// ReceiptDelivery is the proof of delivery shown on a delivery receipt
+type ReceiptDelivery struct {
+	RecipientName        string     `json:"recipient_name"`
+	ReceivedBy           *string    `json:"received_by,omitempty"`
+	PackageDescription   *string    `json:"package_description,omitempty"`
+	PackageSize          string     `json:"package_size"`
+	WeightKg             *float64   `json:"weight_kg,omitempty"`
+	DeclaredValue        *float64   `json:"declared_value,omitempty"`
+	PickedUpAt           *time.Time `json:"picked_up_at,omitempty"`
+	DeliveredAt          *time.Time `json:"delivered_at,omitempty"`
+	PickupPhotoUploadID  *string    `json:"pickup_photo_upload_id,omitempty"`
+	DropoffPhotoUploadID *string    `json:"dropoff_photo_upload_id,omitempty"`
+}
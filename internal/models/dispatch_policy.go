@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// How a dispatch round offers a ride
+const (
+	// DispatchBroadcast offers the ride to several drivers at once; the first to accept gets it
+	DispatchBroadcast = "broadcast"
+	// DispatchSequential offers the ride to one driver at a time, moving to the
+	// next in line when they decline or their offer times out
+	DispatchSequential = "sequential"
+)
+
+// DispatchPolicy decides how rides are offered and re-offered when nobody
+// accepts. Each round searches the next radius in RadiiKm (staying at the last
+// one) until the ride has been through MaxRetries rounds after the first. In
+// sequential mode a round only widens once every driver in its radius has been
+// offered the ride.
+type DispatchPolicy struct {
+	Strategy   string
+	RadiiKm    []float64
+	MaxRetries int
+	// How long after a round with no offers left pending the next one starts
+	RetryDelay time.Duration
+}
+
+// RadiusFor returns the search radius of a round, counting the first as 0
+func (p DispatchPolicy) RadiusFor(attempt int) float64 {
+	if len(p.RadiiKm) == 0 {
+		return 0
+	}
+	if attempt >= len(p.RadiiKm) {
+		attempt = len(p.RadiiKm) - 1
+	}
+	return p.RadiiKm[attempt]
+}
+
+// Exhausted reports whether a ride that has been through attempts rounds gets no more
+func (p DispatchPolicy) Exhausted(attempts int) bool {
+	return attempts > p.MaxRetries
+}
+
+func (p DispatchPolicy) Sequential() bool {
+	return p.Strategy == DispatchSequential
+}
@@ -1,7 +1,10 @@
 package models
 
 import (
+	"math"
 	"time"
+
+	"github.com/lib/pq"
 )
 
 // Driver status constants
@@ -11,8 +14,9 @@ const (
 	DriverStatusBusy    = "busy"
 )
 
-// Vehicle types
+// Built-in vehicle types; more can be added to the registry at runtime
 const (
+	VehicleTypeBike  = "bike"
 	VehicleTypeAuto  = "auto"
 	VehicleTypeMini  = "mini"
 	VehicleTypeSedan = "sedan"
@@ -34,6 +38,46 @@ type Driver struct {
 	CurrentLng    *float64  `db:"current_lng" json:"current_lng,omitempty"`
 	CreatedAt     time.Time `db:"created_at" json:"created_at"`
 	UpdatedAt     time.Time `db:"updated_at" json:"updated_at"`
+
+	Gender     *string    `db:"gender" json:"gender,omitempty"`
+	VerifiedAt *time.Time `db:"verified_at" json:"verified_at,omitempty"`
+	IsEV       bool       `db:"is_ev" json:"is_ev"`
+
+	DailyEarningsGoal *float64 `db:"daily_earnings_goal" json:"daily_earnings_goal,omitempty"`
+
+	CooldownUntil  *time.Time `db:"cooldown_until" json:"cooldown_until,omitempty"`
+	CooldownReason *string    `db:"cooldown_reason" json:"cooldown_reason,omitempty"`
+
+	TenantCode string `db:"tenant_code" json:"tenant_code"`
+
+	// Typical time to answer an offer, weighted towards recent offers, with
+	// offers left to time out counted at the timeout; nil until the first one
+	OfferResponseMs *int `db:"offer_response_ms" json:"offer_response_ms,omitempty"`
+
+	// Payment methods the driver takes trips for; empty takes every method
+	AcceptedPaymentMethods pq.StringArray `db:"accepted_payment_methods" json:"accepted_payment_methods,omitempty"`
+}
+
+// AcceptsPayment reports whether the driver takes trips paid by method
+func (d *Driver) AcceptsPayment(method string) bool {
+	if len(d.AcceptedPaymentMethods) == 0 {
+		return true
+	}
+	for _, m := range d.AcceptedPaymentMethods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// OfferResponseSecs is the driver's typical offer response time in seconds
+func (d *Driver) OfferResponseSecs() *float64 {
+	if d.OfferResponseMs == nil {
+		return nil
+	}
+	secs := math.Round(float64(*d.OfferResponseMs)/100) / 10
+	return &secs
 }
 
 type CreateDriverRequest struct {
@@ -41,8 +85,15 @@ type CreateDriverRequest struct {
 	Name          string `json:"name" validate:"required,min=2,max=100"`
 	Email         string `json:"email,omitempty" validate:"omitempty,email"`
 	LicenseNumber string `json:"license_number" validate:"required"`
-	VehicleType   string `json:"vehicle_type" validate:"required,oneof=auto mini sedan suv"`
+	VehicleType   string `json:"vehicle_type" validate:"required,max=30"`
 	VehicleNumber string `json:"vehicle_number" validate:"required"`
+	Gender        string `json:"gender,omitempty" validate:"omitempty,oneof=female male other"`
+	IsEV          bool   `json:"is_ev,omitempty"`
+}
+
+type SetPaymentMethodsRequest struct {
+	// Empty takes every method again
+	Methods []string `json:"methods" validate:"omitempty,unique,dive,oneof=cash wallet card upi"`
 }
 
 type UpdateDriverLocationRequest struct {
@@ -51,6 +102,10 @@ type UpdateDriverLocationRequest struct {
 	Heading  *float64 `json:"heading,omitempty"`
 	Speed    *float64 `json:"speed,omitempty"`
 	Accuracy *float64 `json:"accuracy,omitempty"`
+
+	// EV hints reported by the driver app
+	BatteryPercent *float64 `json:"battery_percent,omitempty" validate:"omitempty,gte=0,lte=100"`
+	RangeKm        *float64 `json:"range_km,omitempty" validate:"omitempty,gte=0"`
 }
 
 type DriverResponse struct {
@@ -63,6 +118,19 @@ type DriverResponse struct {
 	Status        string   `json:"status"`
 	CurrentLat    *float64 `json:"current_lat,omitempty"`
 	CurrentLng    *float64 `json:"current_lng,omitempty"`
+
+	EarningsGoal *EarningsGoalProgress `json:"earnings_goal,omitempty"`
+	// Set while the driver must rest before receiving offers
+	Cooldown *DriverCooldown `json:"cooldown,omitempty"`
+	// Set when the request carried app version headers; the app should block on
+	// an upgrade screen while upgrade_required is true
+	AppVersion *AppVersionStatus `json:"app_version,omitempty"`
+	// Required legal documents to accept before going online
+	PendingConsents []*LegalDocument `json:"pending_consents,omitempty"`
+	// Typical time the driver takes to accept or decline an offer
+	OfferResponseSecs *float64 `json:"offer_response_secs,omitempty"`
+	// Set when the driver only takes some payment methods
+	AcceptedPaymentMethods []string `json:"accepted_payment_methods,omitempty"`
 }
 
 type DriverWithDistance struct {
@@ -81,11 +149,11 @@ func (d *Driver) ToResponse() *DriverResponse {
 		Status:        d.Status,
 		CurrentLat:    d.CurrentLat,
 		CurrentLng:    d.CurrentLng,
-	}
-}
+		Cooldown:      d.ActiveCooldown(time.Now()),
 
-func IsValidVehicleType(vt string) bool {
-	return vt == VehicleTypeAuto || vt == VehicleTypeMini || vt == VehicleTypeSedan || vt == VehicleTypeSUV
+		OfferResponseSecs:      d.OfferResponseSecs(),
+		AcceptedPaymentMethods: d.AcceptedPaymentMethods,
+	}
 }
 
 func IsValidDriverStatus(status string) bool {
@@ -0,0 +1,28 @@
+package models
+
+// DriverImportRow is one driver in a bulk import. Rows are numbered from 1 in
+// file order, not counting a CSV header.
+type DriverImportRow struct {
+	Row    int
+	Driver CreateDriverRequest
+}
+
+// DriverImportResult reports a bulk import row by row; rows not created are
+// listed in Errors and the rest of the import still goes ahead
+type DriverImportResult struct {
+	Total   int                     `json:"total"`
+	Created []*DriverImportCreated  `json:"created"`
+	Errors  []*DriverImportRowError `json:"errors"`
+}
+
+type DriverImportCreated struct {
+	Row   int    `json:"row"`
+	ID    string `json:"id"`
+	Phone string `json:"phone"`
+}
+
+type DriverImportRowError struct {
+	Row   int    `json:"row"`
+	Phone string `json:"phone,omitempty"`
+	Error string `json:"error"`
+}
@@ -0,0 +1,171 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// EarningsGoalMilestones are the shares of the daily goal (percent) drivers are pushed about
+var EarningsGoalMilestones = []int{25, 50, 75, 100}
+
+// minPaceWindow keeps a single early trip from projecting an unrealistic pace
+const minPaceWindow = 15 * time.Minute
+
+type SetEarningsGoalRequest struct {
+	// Zero clears the goal
+	DailyGoal float64 `json:"daily_goal" validate:"gte=0"`
+}
+
+// EarningsSummary totals a driver's completed trips over a period
+type EarningsSummary struct {
+	Gross       float64    `db:"gross"`
+	Trips       int        `db:"trips"`
+	FirstTripAt *time.Time `db:"first_trip_at"`
+}
+
+type EarningsGoalProgress struct {
+	DailyGoal        float64    `json:"daily_goal"`
+	NetEarnings      float64    `json:"net_earnings"`
+	Remaining        float64    `json:"remaining"`
+	PercentComplete  float64    `json:"percent_complete"`
+	TripsCompleted   int        `json:"trips_completed"`
+	HourlyPace       float64    `json:"hourly_pace"`
+	ProjectedReachAt *time.Time `json:"projected_reach_at,omitempty"`
+}
+
+// NewEarningsGoalProgress measures today's net earnings against the goal and
+// projects when it will be reached at the pace since the first trip of the day
+func NewEarningsGoalProgress(goal, net float64, trips int, firstTripAt *time.Time, now time.Time) *EarningsGoalProgress {
+	p := &EarningsGoalProgress{
+		DailyGoal:      goal,
+		NetEarnings:    roundMoney(net),
+		Remaining:      roundMoney(math.Max(goal-net, 0)),
+		TripsCompleted: trips,
+	}
+	if goal > 0 {
+		p.PercentComplete = math.Min(math.Round(net/goal*1000)/10, 100)
+	}
+
+	if firstTripAt != nil && net > 0 {
+		elapsed := now.Sub(*firstTripAt)
+		if elapsed < minPaceWindow {
+			elapsed = minPaceWindow
+		}
+		p.HourlyPace = roundMoney(net / elapsed.Hours())
+		if p.Remaining > 0 {
+			at := now.Add(time.Duration(p.Remaining / p.HourlyPace * float64(time.Hour)))
+			p.ProjectedReachAt = &at
+		}
+	}
+	return p
+}
+
+func roundMoney(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// EarningsStatement itemises a driver's paid trips over a period with the
+// commission taken from each, plus incentive top-ups and less deductions such
+// as vehicle rent
+type EarningsStatement struct {
+	DriverID   string                   `json:"driver_id"`
+	From       time.Time                `json:"from"`
+	To         time.Time                `json:"to"`
+	Trips      int                      `json:"trips"`
+	Gross      float64                  `json:"gross"`
+	Commission float64                  `json:"commission"`
+	Net        float64                  `json:"net"`
+	Incentives float64                  `json:"incentives"`
+	Deductions float64                  `json:"deductions"`
+	Payable    float64                  `json:"payable"`
+	Lines      []*EarningsStatementLine `json:"lines"`
+	Ledger     []*LedgerEntry           `json:"ledger"`
+}
+
+type EarningsStatementLine struct {
+	TripID            string    `json:"trip_id"`
+	PaymentID         string    `json:"payment_id"`
+	PaidAt            time.Time `json:"paid_at"`
+	Fare              float64   `json:"fare"`
+	CommissionPercent float64   `json:"commission_percent"`
+	Commission        float64   `json:"commission"`
+	Net               float64   `json:"net"`
+}
+
+// AddPayment appends a completed payment to the statement
+func (s *EarningsStatement) AddPayment(p *Payment, split CommissionSplit) {
+	fare := p.Amount - p.CarbonOffsetAmount
+	s.Lines = append(s.Lines, &EarningsStatementLine{
+		TripID:            p.TripID,
+		PaymentID:         p.ID,
+		PaidAt:            p.CreatedAt,
+		Fare:              roundMoney(fare),
+		CommissionPercent: split.Percent,
+		Commission:        split.Commission,
+		Net:               split.DriverEarnings,
+	})
+	s.Trips++
+	s.Gross = roundMoney(s.Gross + fare)
+	s.Commission = roundMoney(s.Commission + split.Commission)
+	s.Net = roundMoney(s.Net + split.DriverEarnings)
+	s.updatePayable()
+}
+
+// AddLedgerEntry appends a balance movement: credits count as incentives,
+// debits as deductions
+func (s *EarningsStatement) AddLedgerEntry(e *LedgerEntry) {
+	s.Ledger = append(s.Ledger, e)
+	if e.Amount < 0 {
+		s.Deductions = roundMoney(s.Deductions - e.Amount)
+	} else {
+		s.Incentives = roundMoney(s.Incentives + e.Amount)
+	}
+	s.updatePayable()
+}
+
+func (s *EarningsStatement) updatePayable() {
+	s.Payable = roundMoney(s.Net + s.Incentives - s.Deductions)
+}
+
+// TripEarning is what one paid trip earned the driver after commission
+type TripEarning struct {
+	RideID         string     `db:"ride_id" json:"ride_id"`
+	TripID         string     `db:"trip_id" json:"trip_id"`
+	PaymentID      string     `db:"payment_id" json:"payment_id"`
+	Method         string     `db:"method" json:"method"`
+	Currency       string     `db:"currency" json:"currency"`
+	Fare           float64    `db:"fare" json:"fare"`
+	Commission     float64    `db:"commission" json:"commission"`
+	Earnings       float64    `db:"earnings" json:"earnings"`
+	IncentiveTopUp float64    `db:"incentive_top_up" json:"incentive_top_up"`
+	RefundedAt     *time.Time `db:"refunded_at" json:"refunded_at,omitempty"`
+	PaidAt         time.Time  `db:"paid_at" json:"paid_at"`
+}
+
+// DriverEarnings lists a driver's paid trips over a period with totals for the
+// unrefunded ones, and their current balance
+type DriverEarnings struct {
+	DriverID        string         `json:"driver_id"`
+	From            time.Time      `json:"from"`
+	To              time.Time      `json:"to"`
+	Trips           int            `json:"trips"`
+	Fares           float64        `json:"fares"`
+	Commission      float64        `json:"commission"`
+	Earnings        float64        `json:"earnings"`
+	IncentiveTopUps float64        `json:"incentive_top_ups"`
+	Balance         float64        `json:"balance"`
+	Records         []*TripEarning `json:"records"`
+}
+
+// Add appends a trip; refunded trips are listed but not totalled
+func (e *DriverEarnings) Add(t *TripEarning) {
+	e.Records = append(e.Records, t)
+	if t.RefundedAt != nil {
+		return
+	}
+	e.Trips++
+	e.Fares = roundMoney(e.Fares + t.Fare)
+	e.Commission = roundMoney(e.Commission + t.Commission)
+	e.Earnings = roundMoney(e.Earnings + t.Earnings)
+	e.IncentiveTopUps = roundMoney(e.IncentiveTopUps + t.IncentiveTopUp)
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// Fare adjustment reasons
+const (
+	FareAdjustmentReassignmentDelay = "reassignment_delay"
+)
+
+// Actions taken on surge when a reassignment makes the rider wait longer
+const (
+	RepricingActionWaive  = "waive"
+	RepricingActionReduce = "reduce"
+)
+
+// RepricingPolicy decides how surge is relieved when a replacement driver's
+// pickup ETA is well beyond the one first promised
+type RepricingPolicy struct {
+	// "waive", "reduce" or "" to leave fares alone
+	Action string
+	// How many minutes longer the replacement must take before acting
+	MinETAIncreaseMins int
+	// Share of the surge premium removed by "reduce", 0-1
+	SurgeReduction float64
+}
+
+// AdjustedSurge returns the multiplier to charge after a delayed reassignment
+func (p RepricingPolicy) AdjustedSurge(surge float64) float64 {
+	if surge <= 1 {
+		return surge
+	}
+	switch p.Action {
+	case RepricingActionWaive:
+		return 1
+	case RepricingActionReduce:
+		reduced := 1 + (surge-1)*(1-p.SurgeReduction)
+		return math.Round(reduced*100) / 100
+	}
+	return surge
+}
+
+// FareAdjustment records a change to a ride's pricing made after booking
+type FareAdjustment struct {
+	ID                string    `db:"id" json:"id"`
+	RideID            string    `db:"ride_id" json:"ride_id"`
+	Reason            string    `db:"reason" json:"reason"`
+	OriginalSurge     float64   `db:"original_surge" json:"original_surge"`
+	AdjustedSurge     float64   `db:"adjusted_surge" json:"adjusted_surge"`
+	OriginalFare      *float64  `db:"original_fare" json:"original_fare,omitempty"`
+	AdjustedFare      *float64  `db:"adjusted_fare" json:"adjusted_fare,omitempty"`
+	OriginalETAMin    *int      `db:"original_eta_mins" json:"original_eta_mins,omitempty"`
+	ReplacementETAMin *int      `db:"replacement_eta_mins" json:"replacement_eta_mins,omitempty"`
+	CreatedAt         time.Time `db:"created_at" json:"created_at"`
+}
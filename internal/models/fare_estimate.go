@@ -0,0 +1,62 @@
+package models
+
+// DistanceLimits bounds the trip distance a ride may be booked for, in km. A zero
+// bound is not enforced.
+type DistanceLimits struct {
+	MinKm float64
+	MaxKm float64
+}
+
+// ForRegion applies the region's overrides on top of the deployment defaults
+func (l DistanceLimits) ForRegion(region *Region) DistanceLimits {
+	if region == nil {
+		return l
+	}
+	if region.Settings.MinRideDistanceKm > 0 {
+		l.MinKm = region.Settings.MinRideDistanceKm
+	}
+	if region.Settings.MaxRideDistanceKm > 0 {
+		l.MaxKm = region.Settings.MaxRideDistanceKm
+	}
+	return l
+}
+
+// Why a quoted ride can't be booked right now
+const EstimateUnavailableNoDrivers = "no_drivers_nearby"
+
+type FareEstimateRequest struct {
+	Pickup      Location `json:"pickup" validate:"required"`
+	Dropoff     Location `json:"dropoff" validate:"required"`
+	VehicleType string   `json:"vehicle_type" validate:"required,max=30"`
+	Language    string   `json:"language,omitempty" validate:"omitempty,bcp47_language_tag"`
+}
+
+type FareEstimate struct {
+	Pickup               Location       `json:"pickup"`
+	Dropoff              Location       `json:"dropoff"`
+	VehicleType          string         `json:"vehicle_type"`
+	RegionCode           string         `json:"region_code,omitempty"`
+	EstimatedDistanceKm  float64        `json:"estimated_distance_km"`
+	EstimatedDurationMin int            `json:"estimated_duration_min"`
+	SurgeMultiplier      float64        `json:"surge_multiplier"`
+	Fare                 *FareBreakdown `json:"fare"`
+	// False when no driver could take the ride now; the fare is indicative only
+	Available         bool   `json:"available"`
+	UnavailableReason string `json:"unavailable_reason,omitempty"`
+}
+
+type FaresEstimateRequest struct {
+	Pickup   Location `json:"pickup" validate:"required"`
+	Dropoff  Location `json:"dropoff" validate:"required"`
+	Language string   `json:"language,omitempty" validate:"omitempty,bcp47_language_tag"`
+}
+
+// FaresEstimate quotes one trip for each vehicle type offered at the pickup
+type FaresEstimate struct {
+	Pickup               Location        `json:"pickup"`
+	Dropoff              Location        `json:"dropoff"`
+	RegionCode           string          `json:"region_code,omitempty"`
+	EstimatedDistanceKm  float64         `json:"estimated_distance_km"`
+	EstimatedDurationMin int             `json:"estimated_duration_min"`
+	Estimates            []*FareEstimate `json:"estimates"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+)
+
+// FareVariancePolicy decides when estimated fares have drifted too far from what
+// trips actually cost, which usually means distance estimation or routing broke
+type FareVariancePolicy struct {
+	// Completed trips considered
+	Window time.Duration
+	// Alert when the median variance for a region and vehicle type exceeds this (percent)
+	ThresholdPercent float64
+	// Groups with fewer trips are reported but never alert
+	MinTrips int
+}
+
+// FareVarianceGroup is the variance of actual over estimated fare, as a percent of
+// the estimate, for one region and vehicle type
+type FareVarianceGroup struct {
+	RegionCode            *string `db:"region_code" json:"region_code,omitempty"`
+	VehicleType           string  `db:"vehicle_type" json:"vehicle_type"`
+	Trips                 int     `db:"trips" json:"trips"`
+	MedianVariancePercent float64 `db:"median_variance_pct" json:"median_variance_percent"`
+	P90AbsVariancePercent float64 `db:"p90_abs_variance_pct" json:"p90_abs_variance_percent"`
+	ThresholdPercent      float64 `db:"-" json:"threshold_percent"`
+	Alerting              bool    `db:"-" json:"alerting"`
+}
+
+// Label identifies the group in metrics and logs
+func (g *FareVarianceGroup) Label() string {
+	region := "none"
+	if g.RegionCode != nil {
+		region = *g.RegionCode
+	}
+	return region + "/" + g.VehicleType
+}
+
+type FareVarianceReport struct {
+	From   time.Time            `json:"from"`
+	To     time.Time            `json:"to"`
+	Groups []*FareVarianceGroup `json:"groups"`
+}
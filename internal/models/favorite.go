@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+)
+
+// MaxFavoriteDrivers caps how many drivers a rider may favorite
+const MaxFavoriteDrivers = 20
+
+type FavoriteDriver struct {
+	UserID    string    `db:"user_id" json:"user_id"`
+	DriverID  string    `db:"driver_id" json:"driver_id"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+type AddFavoriteDriverRequest struct {
+	DriverID string `json:"driver_id" validate:"required,uuid"`
+}
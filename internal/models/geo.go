@@ -0,0 +1,87 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// GeoPoint is a polygon vertex
+type GeoPoint struct {
+	Lat float64 `json:"lat" validate:"latitude"`
+	Lng float64 `json:"lng" validate:"longitude"`
+}
+
+// ServiceArea is a closed polygon; the last vertex joins back to the first
+type ServiceArea []GeoPoint
+
+// UpdateServiceAreaRequest replaces a region's service area; an empty polygon
+// falls back to the region's bounding box
+type UpdateServiceAreaRequest struct {
+	Polygon ServiceArea `json:"polygon" validate:"omitempty,min=3,dive"`
+}
+
+// IsNullIsland reports whether a coordinate is the (0,0) placeholder sent by
+// clients without a location fix
+func IsNullIsland(lat, lng float64) bool {
+	return lat == 0 && lng == 0
+}
+
+// Contains reports whether the point falls inside the polygon, by ray casting
+func (a ServiceArea) Contains(lat, lng float64) bool {
+	inside := false
+	for i, j := 0, len(a)-1; i < len(a); j, i = i, i+1 {
+		pi, pj := a[i], a[j]
+		if (pi.Lat > lat) != (pj.Lat > lat) &&
+			lng < (pj.Lng-pi.Lng)*(lat-pi.Lat)/(pj.Lat-pi.Lat)+pi.Lng {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// NearestPoint returns the point on the polygon's boundary closest to the given
+// point. Edges are projected flat around the point, which is accurate enough at
+// city scale.
+func (a ServiceArea) NearestPoint(lat, lng float64) GeoPoint {
+	if len(a) == 0 {
+		return GeoPoint{Lat: lat, Lng: lng}
+	}
+	scale := math.Cos(lat * math.Pi / 180)
+	best, bestDist := a[0], math.Inf(1)
+	for i, j := 0, len(a)-1; i < len(a); j, i = i, i+1 {
+		p1, p2 := a[j], a[i]
+		dx, dy := (p2.Lng-p1.Lng)*scale, p2.Lat-p1.Lat
+		t := 0.0
+		if length := dx*dx + dy*dy; length > 0 {
+			t = math.Max(0, math.Min(1, ((lng-p1.Lng)*scale*dx+(lat-p1.Lat)*dy)/length))
+		}
+		p := GeoPoint{Lat: p1.Lat + t*(p2.Lat-p1.Lat), Lng: p1.Lng + t*(p2.Lng-p1.Lng)}
+		if d := math.Hypot((p.Lng-lng)*scale, p.Lat-lat); d < bestDist {
+			best, bestDist = p, d
+		}
+	}
+	return best
+}
+
+func (a ServiceArea) Value() (driver.Value, error) {
+	if len(a) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(a)
+}
+
+func (a *ServiceArea) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, a)
+	case string:
+		return json.Unmarshal([]byte(v), a)
+	case nil:
+		*a = nil
+		return nil
+	default:
+		return fmt.Errorf("unsupported service area type %T", src)
+	}
+}
@@ -0,0 +1,69 @@
+package models
+
+import (
+	"math"
+	"strings"
+)
+
+const geohashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// Geohash encodes a point as a geohash of the given length. Each extra
+// character narrows the cell, from ~5km square at 5 to ~1.2km by 0.6km at 6.
+func Geohash(lat, lng float64, precision int) string {
+	latRange := [2]float64{-90, 90}
+	lngRange := [2]float64{-180, 180}
+
+	var b strings.Builder
+	bits, ch := 0, 0
+	// Bits alternate between longitude and latitude, starting with longitude
+	even := true
+	for b.Len() < precision {
+		r, v := &latRange, lat
+		if even {
+			r, v = &lngRange, lng
+		}
+		mid := (r[0] + r[1]) / 2
+		ch <<= 1
+		if v >= mid {
+			ch |= 1
+			r[0] = mid
+		} else {
+			r[1] = mid
+		}
+		even = !even
+
+		bits++
+		if bits == 5 {
+			b.WriteByte(geohashAlphabet[ch])
+			bits, ch = 0, 0
+		}
+	}
+	return b.String()
+}
+
+// GeohashCellSize returns the height and width in degrees of a geohash cell
+func GeohashCellSize(precision int) (latDeg, lngDeg float64) {
+	bits := 5 * precision
+	lngBits := (bits + 1) / 2
+	latBits := bits / 2
+	return 180 / math.Pow(2, float64(latBits)), 360 / math.Pow(2, float64(lngBits))
+}
+
+// GeohashZone returns the cell containing the point followed by its eight
+// neighbours, so a point near a cell edge still sees activity just across it
+func GeohashZone(lat, lng float64, precision int) []string {
+	latDeg, lngDeg := GeohashCellSize(precision)
+	cells := make([]string, 0, 9)
+	seen := make(map[string]bool, 9)
+	for _, dLat := range []float64{0, -1, 1} {
+		for _, dLng := range []float64{0, -1, 1} {
+			cellLat := math.Max(-90, math.Min(90, lat+dLat*latDeg))
+			cell := Geohash(cellLat, lng+dLng*lngDeg, precision)
+			if !seen[cell] {
+				seen[cell] = true
+				cells = append(cells, cell)
+			}
+		}
+	}
+	return cells
+}
@@ -0,0 +1,105 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// TripSegment is one driver's leg of a trip that was handed over mid-way
+type TripSegment struct {
+	ID           string     `db:"id" json:"id"`
+	TripID       string     `db:"trip_id" json:"trip_id"`
+	DriverID     string     `db:"driver_id" json:"driver_id"`
+	Sequence     int        `db:"sequence" json:"sequence"`
+	StartTime    time.Time  `db:"start_time" json:"start_time"`
+	EndTime      *time.Time `db:"end_time" json:"end_time,omitempty"`
+	StartLat     float64    `db:"start_lat" json:"start_lat"`
+	StartLng     float64    `db:"start_lng" json:"start_lng"`
+	EndLat       *float64   `db:"end_lat" json:"end_lat,omitempty"`
+	EndLng       *float64   `db:"end_lng" json:"end_lng,omitempty"`
+	DistanceKm   *float64   `db:"distance_km" json:"distance_km,omitempty"`
+	DurationMins *int       `db:"duration_mins" json:"duration_mins,omitempty"`
+	FareShare    *float64   `db:"fare_share" json:"fare_share,omitempty"`
+	EndReason    *string    `db:"end_reason" json:"end_reason,omitempty"`
+	CreatedAt    time.Time  `db:"created_at" json:"created_at"`
+}
+
+// Close ends the segment at the given point and time
+func (s *TripSegment) Close(lat, lng, distanceKm float64, at time.Time, reason string) {
+	mins := int(math.Ceil(at.Sub(s.StartTime).Minutes()))
+	if mins < 1 {
+		mins = 1
+	}
+	s.EndTime = &at
+	s.EndLat = &lat
+	s.EndLng = &lng
+	s.DistanceKm = &distanceKm
+	s.DurationMins = &mins
+	if reason != "" {
+		s.EndReason = &reason
+	}
+}
+
+// SplitFare divides a trip's fare across its segments by distance driven, or by
+// time when no distance was recorded. Rounding leftovers go to the last segment.
+func SplitFare(total float64, segments []*TripSegment) {
+	if len(segments) == 0 {
+		return
+	}
+
+	weights := make([]float64, len(segments))
+	sum := 0.0
+	for i, s := range segments {
+		if s.DistanceKm != nil {
+			weights[i] = *s.DistanceKm
+		}
+		sum += weights[i]
+	}
+	if sum == 0 {
+		for i, s := range segments {
+			if s.DurationMins != nil {
+				weights[i] = float64(*s.DurationMins)
+			}
+			sum += weights[i]
+		}
+	}
+
+	allocated := 0.0
+	for i, s := range segments {
+		share := 0.0
+		switch {
+		case i == len(segments)-1:
+			share = math.Round((total-allocated)*100) / 100
+		case sum > 0:
+			share = math.Round(total*weights[i]/sum*100) / 100
+		}
+		allocated += share
+		s.FareShare = &share
+	}
+}
+
+type HandoverRequest struct {
+	Lat    float64 `json:"lat" validate:"required,latitude"`
+	Lng    float64 `json:"lng" validate:"required,longitude"`
+	Reason string  `json:"reason" validate:"required,max=500"`
+}
+
+type AssignRescueDriverRequest struct {
+	// Leave empty to dispatch the nearest available driver
+	DriverID string `json:"driver_id,omitempty" validate:"omitempty,uuid"`
+}
+
+type HandoverResponse struct {
+	Trip     *TripResponse  `json:"trip"`
+	Segments []*TripSegment `json:"segments"`
+}
+
+// ReceiptSegment is a rider-facing leg of a trip completed by more than one driver
+type ReceiptSegment struct {
+	Sequence     int        `json:"sequence"`
+	DriverName   string     `json:"driver_name,omitempty"`
+	StartTime    time.Time  `json:"start_time"`
+	EndTime      *time.Time `json:"end_time,omitempty"`
+	DistanceKm   *float64   `json:"distance_km,omitempty"`
+	DurationMins *int       `json:"duration_mins,omitempty"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// HourOfWeek numbers the hours of the week from Sunday 00:00 UTC, matching
+// Postgres' day-of-week numbering
+func HourOfWeek(t time.Time) int {
+	t = t.UTC()
+	return int(t.Weekday())*24 + t.Hour()
+}
+
+// HeatQuery selects historical heat for the cells inside a bounding box
+type HeatQuery struct {
+	MinLat      float64
+	MinLng      float64
+	MaxLat      float64
+	MaxLng      float64
+	VehicleType string
+	// -1 returns every hour of the week
+	HourOfWeek int
+	Since      time.Time
+}
+
+// HeatCell averages a cell's snapshots for one hour of the week
+type HeatCell struct {
+	CellLat          float64  `db:"cell_lat" json:"cell_lat"`
+	CellLng          float64  `db:"cell_lng" json:"cell_lng"`
+	VehicleType      string   `db:"vehicle_type" json:"vehicle_type"`
+	HourOfWeek       int      `db:"hour_of_week" json:"hour_of_week"`
+	Samples          int      `db:"samples" json:"samples"`
+	AvgOnlineDrivers float64  `db:"avg_online_drivers" json:"avg_online_drivers"`
+	AvgBusyDrivers   float64  `db:"avg_busy_drivers" json:"avg_busy_drivers"`
+	AvgRideRequests  float64  `db:"avg_ride_requests" json:"avg_ride_requests"`
+	AvgSurge         *float64 `db:"avg_surge" json:"avg_surge,omitempty"`
+}
+
+type HeatHistoryResponse struct {
+	CellDegrees float64     `json:"cell_degrees"`
+	From        time.Time   `json:"from"`
+	To          time.Time   `json:"to"`
+	Cells       []*HeatCell `json:"cells"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+)
+
+// IdempotentResponse is the stored outcome of a request made with an
+// Idempotency-Key, replayed to retries of the same request
+type IdempotentResponse struct {
+	Key      string `db:"key"`
+	BodyHash string `db:"body_hash"`
+	// Unset while the first request is still being processed
+	StatusCode  *int      `db:"status_code"`
+	ContentType *string   `db:"content_type"`
+	Body        []byte    `db:"body"`
+	LockedUntil time.Time `db:"locked_until"`
+	ExpiresAt   time.Time `db:"expires_at"`
+	CreatedAt   time.Time `db:"created_at"`
+}
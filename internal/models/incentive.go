@@ -0,0 +1,65 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// IncentiveGuarantee promises drivers a minimum net payout for trips picked up
+// in a zone during a daily time window
+type IncentiveGuarantee struct {
+	ID          string   `db:"id" json:"id"`
+	Name        string   `db:"name" json:"name"`
+	RegionCode  *string  `db:"region_code" json:"region_code,omitempty"`
+	VehicleType *string  `db:"vehicle_type" json:"vehicle_type,omitempty"`
+	CenterLat   *float64 `db:"center_lat" json:"center_lat,omitempty"`
+	CenterLng   *float64 `db:"center_lng" json:"center_lng,omitempty"`
+	RadiusKm    *float64 `db:"radius_km" json:"radius_km,omitempty"`
+	// Minutes after local midnight; a window ending before it starts spans midnight
+	StartMinute int     `db:"start_minute" json:"start_minute"`
+	EndMinute   int     `db:"end_minute" json:"end_minute"`
+	MinEarnings float64 `db:"min_earnings" json:"min_earnings"`
+	// Only drivers who completed this training module qualify
+	RequiredTraining *string   `db:"required_training" json:"required_training,omitempty"`
+	Active           bool      `db:"active" json:"active"`
+	CreatedAt        time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt        time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// InWindow reports whether the time of day falls in the guarantee's window
+func (g *IncentiveGuarantee) InWindow(at time.Time) bool {
+	minute := at.Hour()*60 + at.Minute()
+	if g.StartMinute <= g.EndMinute {
+		return minute >= g.StartMinute && minute < g.EndMinute
+	}
+	return minute >= g.StartMinute || minute < g.EndMinute
+}
+
+// HasZone reports whether the guarantee is limited to a pickup radius
+func (g *IncentiveGuarantee) HasZone() bool {
+	return g.CenterLat != nil && g.CenterLng != nil && g.RadiusKm != nil
+}
+
+type CreateIncentiveGuaranteeRequest struct {
+	Name        string   `json:"name" validate:"required,max=100"`
+	RegionCode  string   `json:"region_code,omitempty" validate:"max=20"`
+	VehicleType string   `json:"vehicle_type,omitempty" validate:"max=30"`
+	CenterLat   *float64 `json:"center_lat,omitempty" validate:"required_with=RadiusKm,omitempty,latitude"`
+	CenterLng   *float64 `json:"center_lng,omitempty" validate:"required_with=RadiusKm,omitempty,longitude"`
+	RadiusKm    *float64 `json:"radius_km,omitempty" validate:"required_with=CenterLat CenterLng,omitempty,gt=0"`
+	// Local time window, HH:MM
+	StartTime   string  `json:"start_time" validate:"required,datetime=15:04"`
+	EndTime     string  `json:"end_time" validate:"required,datetime=15:04"`
+	MinEarnings float64 `json:"min_earnings" validate:"required,gt=0"`
+	// Training module drivers must have completed, e.g. ev_incentives
+	RequiredTraining string `json:"required_training,omitempty" validate:"omitempty,oneof=pool intercity ev_incentives"`
+}
+
+// ParseMinuteOfDay converts HH:MM to minutes after midnight
+func ParseMinuteOfDay(hhmm string) (int, error) {
+	t, err := time.Parse("15:04", hhmm)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q", hhmm)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
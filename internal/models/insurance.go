@@ -0,0 +1,76 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Claim status constants
+const (
+	ClaimStatusReceived  = "received"  // stored, not yet accepted by the insurer
+	ClaimStatusSubmitted = "submitted" // forwarded to the insurer
+)
+
+// Claimant types
+const (
+	ClaimantUser   = "user"
+	ClaimantDriver = "driver"
+)
+
+// InsuranceCoverage is the coverage attached to a trip policy, stored as JSONB.
+// Limits maps a coverage type (e.g. "medical") to its maximum payout.
+type InsuranceCoverage struct {
+	Currency string             `json:"currency"`
+	Limits   map[string]float64 `json:"limits"`
+}
+
+func (c InsuranceCoverage) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+func (c *InsuranceCoverage) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, c)
+	case string:
+		return json.Unmarshal([]byte(v), c)
+	case nil:
+		*c = InsuranceCoverage{}
+		return nil
+	default:
+		return fmt.Errorf("unsupported insurance coverage type %T", src)
+	}
+}
+
+type TripInsurance struct {
+	ID           string            `db:"id" json:"id"`
+	TripID       string            `db:"trip_id" json:"trip_id"`
+	Provider     string            `db:"provider" json:"provider"`
+	PolicyNumber string            `db:"policy_number" json:"policy_number"`
+	Coverage     InsuranceCoverage `db:"coverage" json:"coverage"`
+	IssuedAt     time.Time         `db:"issued_at" json:"issued_at"`
+}
+
+type InsuranceClaim struct {
+	ID                string    `db:"id" json:"id"`
+	TripID            string    `db:"trip_id" json:"trip_id"`
+	ClaimantType      string    `db:"claimant_type" json:"claimant_type"`
+	ClaimantID        string    `db:"claimant_id" json:"claimant_id"`
+	IncidentType      string    `db:"incident_type" json:"incident_type"`
+	IncidentAt        time.Time `db:"incident_at" json:"incident_at"`
+	Description       string    `db:"description" json:"description"`
+	Status            string    `db:"status" json:"status"`
+	ProviderReference *string   `db:"provider_reference" json:"provider_reference,omitempty"`
+	CreatedAt         time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt         time.Time `db:"updated_at" json:"updated_at"`
+}
+
+type CreateClaimRequest struct {
+	ClaimantType string    `json:"claimant_type" validate:"required,oneof=user driver"`
+	ClaimantID   string    `json:"claimant_id" validate:"required,uuid"`
+	IncidentType string    `json:"incident_type" validate:"required,oneof=accident injury property_damage theft other"`
+	IncidentAt   time.Time `json:"incident_at" validate:"required"`
+	Description  string    `json:"description" validate:"required,min=10,max=5000"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// MaintenanceWindow blocks new ride bookings while it is in effect
+type MaintenanceWindow struct {
+	ID        string    `db:"id" json:"id"`
+	Message   string    `db:"message" json:"message"`
+	StartsAt  time.Time `db:"starts_at" json:"starts_at"`
+	EndsAt    time.Time `db:"ends_at" json:"ends_at"`
+	Active    bool      `db:"active" json:"active"`
+	CreatedBy string    `db:"created_by" json:"created_by"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// InEffectAt reports whether bookings are blocked at the given moment
+func (m *MaintenanceWindow) InEffectAt(at time.Time) bool {
+	return m.Active && !at.Before(m.StartsAt) && at.Before(m.EndsAt)
+}
+
+// Notice is what apps are shown about the window
+func (m *MaintenanceWindow) Notice(at time.Time) *MaintenanceNotice {
+	return &MaintenanceNotice{
+		Message:  m.Message,
+		StartsAt: m.StartsAt,
+		EndsAt:   m.EndsAt,
+		InEffect: m.InEffectAt(at),
+	}
+}
+
+type CreateMaintenanceWindowRequest struct {
+	// Shown to riders in the app banner and booking error
+	Message string `json:"message" validate:"required,max=300"`
+	// Starts immediately when omitted
+	StartsAt  *time.Time `json:"starts_at,omitempty"`
+	EndsAt    time.Time  `json:"ends_at" validate:"required"`
+	CreatedBy string     `json:"created_by" validate:"required,max=100"`
+}
+
+// MaintenanceNotice warns apps of maintenance in effect or coming up, and is
+// the details of the error bookings get during it
+type MaintenanceNotice struct {
+	Message  string    `json:"message"`
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+	InEffect bool      `json:"in_effect"`
+}
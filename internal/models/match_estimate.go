@@ -0,0 +1,43 @@
+package models
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	// Assumed time-to-match before any recent matches have been observed
+	DefaultMatchWait = 60 * time.Second
+	// Never promise a match sooner than this while still searching
+	MinMatchWait = 5 * time.Second
+)
+
+// MatchEstimate tells a rider in matching roughly how long until a driver accepts
+type MatchEstimate struct {
+	QueuePosition    int       `json:"queue_position"`
+	NearbyDrivers    int       `json:"nearby_drivers"`
+	EstimatedSeconds int       `json:"estimated_seconds"`
+	SampleSize       int       `json:"sample_size"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// EstimateMatchWait derives the remaining wait from the median of recent match
+// times, scaled up when more riders are queued ahead than there are nearby drivers
+func EstimateMatchWait(recent []time.Duration, queuePosition, nearbyDrivers int, elapsed time.Duration) time.Duration {
+	base := DefaultMatchWait
+	if len(recent) > 0 {
+		sorted := append([]time.Duration(nil), recent...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		base = sorted[len(sorted)/2]
+	}
+
+	// Each driver nearby can absorb one rider; beyond that riders wait for another round
+	demandRatio := float64(queuePosition) / math.Max(float64(nearbyDrivers), 1)
+	wait := time.Duration(float64(base)*math.Max(demandRatio, 1)) - elapsed
+
+	if wait < MinMatchWait {
+		wait = MinMatchWait
+	}
+	return wait
+}
@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// Matching exclusion kinds
+const (
+	// A driver is offered no rides, e.g. while a complaint is investigated
+	MatchingExclusionDriver = "driver"
+	// Rides picking up inside a geofence aren't matched, e.g. during road works
+	MatchingExclusionArea = "area"
+)
+
+// MatchingExclusion takes a driver or an area out of matching for a window. It
+// ends on its own at ExpiresAt unless revoked earlier.
+type MatchingExclusion struct {
+	ID        string     `db:"id" json:"id"`
+	Kind      string     `db:"kind" json:"kind"`
+	DriverID  *string    `db:"driver_id" json:"driver_id,omitempty"`
+	CenterLat *float64   `db:"center_lat" json:"center_lat,omitempty"`
+	CenterLng *float64   `db:"center_lng" json:"center_lng,omitempty"`
+	RadiusKm  *float64   `db:"radius_km" json:"radius_km,omitempty"`
+	Reason    string     `db:"reason" json:"reason"`
+	CreatedBy string     `db:"created_by" json:"created_by"`
+	StartsAt  time.Time  `db:"starts_at" json:"starts_at"`
+	ExpiresAt time.Time  `db:"expires_at" json:"expires_at"`
+	RevokedAt *time.Time `db:"revoked_at" json:"revoked_at,omitempty"`
+	RevokedBy *string    `db:"revoked_by" json:"revoked_by,omitempty"`
+	CreatedAt time.Time  `db:"created_at" json:"created_at"`
+}
+
+// ActiveAt reports whether the exclusion applies at the given moment
+func (e *MatchingExclusion) ActiveAt(at time.Time) bool {
+	return e.RevokedAt == nil && !at.Before(e.StartsAt) && at.Before(e.ExpiresAt)
+}
+
+type CreateMatchingExclusionRequest struct {
+	Kind      string   `json:"kind" validate:"required,oneof=driver area"`
+	DriverID  string   `json:"driver_id,omitempty" validate:"required_if=Kind driver,omitempty,uuid"`
+	CenterLat *float64 `json:"center_lat,omitempty" validate:"required_if=Kind area,omitempty,latitude"`
+	CenterLng *float64 `json:"center_lng,omitempty" validate:"required_if=Kind area,omitempty,longitude"`
+	RadiusKm  *float64 `json:"radius_km,omitempty" validate:"required_if=Kind area,omitempty,gt=0,lte=50"`
+	Reason    string   `json:"reason" validate:"required,max=500"`
+	CreatedBy string   `json:"created_by" validate:"required,max=100"`
+	// Starts immediately when omitted
+	StartsAt  *time.Time `json:"starts_at,omitempty"`
+	ExpiresAt time.Time  `json:"expires_at" validate:"required"`
+}
+
+type RevokeMatchingExclusionRequest struct {
+	RevokedBy string `json:"revoked_by" validate:"required,max=100"`
+}
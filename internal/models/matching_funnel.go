@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// Matching funnel stages, in order
+const (
+	FunnelStageCandidates    = "candidates"
+	FunnelStagePassedFilters = "passed_filters"
+	FunnelStageOffered       = "offered"
+	FunnelStageViewed        = "viewed"
+	FunnelStageAccepted      = "accepted"
+)
+
+// DispatchRound is one attempt by matching to offer a ride to nearby drivers
+type DispatchRound struct {
+	ID            string    `db:"id" json:"id"`
+	RideID        string    `db:"ride_id" json:"ride_id"`
+	RegionCode    *string   `db:"region_code" json:"region_code,omitempty"`
+	VehicleType   string    `db:"vehicle_type" json:"vehicle_type"`
+	Candidates    int       `db:"candidates" json:"candidates"`
+	PassedFilters int       `db:"passed_filters" json:"passed_filters"`
+	CreatedAt     time.Time `db:"created_at" json:"created_at"`
+}
+
+// MatchingFunnel sums dispatch rounds for one region and vehicle type. Driver
+// counts add up across rounds, so a driver found in two rounds counts twice.
+type MatchingFunnel struct {
+	RegionCode  *string `db:"region_code" json:"region_code,omitempty"`
+	VehicleType string  `db:"vehicle_type" json:"vehicle_type"`
+	Rides       int     `db:"rides" json:"rides"`
+	Rounds      int     `db:"rounds" json:"rounds"`
+	// Rounds that found no driver near the pickup at all
+	EmptyRounds   int `db:"empty_rounds" json:"empty_rounds"`
+	Candidates    int `db:"candidates" json:"candidates"`
+	PassedFilters int `db:"passed_filters" json:"passed_filters"`
+	Offered       int `db:"offered" json:"offered"`
+	Viewed        int `db:"viewed" json:"viewed"`
+	Accepted      int `db:"accepted" json:"accepted"`
+	// Offers drivers accepted, declined or countered, and how many seconds they took
+	Responded          int     `db:"responded" json:"responded"`
+	AvgResponseSecs    float64 `db:"avg_response_secs" json:"avg_response_secs"`
+	MedianResponseSecs float64 `db:"median_response_secs" json:"median_response_secs"`
+	P90ResponseSecs    float64 `db:"p90_response_secs" json:"p90_response_secs"`
+	// Share of the previous stage that reached each stage, in percent
+	Conversion map[string]float64 `db:"-" json:"conversion"`
+}
+
+type MatchingFunnelReport struct {
+	From    time.Time         `json:"from"`
+	To      time.Time         `json:"to"`
+	Funnels []*MatchingFunnel `json:"funnels"`
+}
@@ -0,0 +1,12 @@
+package models
+
+// Driver sub-states reported while a ride is assigned
+const (
+	NavigationEnRouteToPickup = "en_route_to_pickup"
+	NavigationWaitingAtPickup = "waiting_at_pickup"
+)
+
+type UpdateNavigationRequest struct {
+	RideID string `json:"ride_id" validate:"required,uuid"`
+	Status string `json:"status" validate:"required,oneof=en_route_to_pickup waiting_at_pickup"`
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"time"
+)
+
+// Rules that lengthened or shortened an offer's timeout
+const (
+	OfferTimeoutNight      = "night"
+	OfferTimeoutLowDensity = "low_density"
+	OfferTimeoutSurge      = "surge"
+)
+
+// OfferTimeoutPolicy decides how long a driver has to respond to an offer.
+// Drivers get longer at night and where few drivers are around, and less during
+// surge when riders are waiting on a busy market.
+type OfferTimeoutPolicy struct {
+	Base time.Duration
+	// Bounds on the adjusted timeout
+	Min time.Duration
+	Max time.Duration
+	// Local hours the night window runs from and to; it may span midnight
+	NightStartHour int
+	NightEndHour   int
+	NightExtra     time.Duration
+	// Fewer nearby drivers than this is a low-density area (0 disables)
+	LowDensityDrivers int
+	LowDensityExtra   time.Duration
+	// Rides at or above this surge multiplier get SurgeReduction taken off (0 disables)
+	SurgeThreshold float64
+	SurgeReduction time.Duration
+}
+
+// OfferTimeout is the evaluated timeout with the rules that applied
+type OfferTimeout struct {
+	Duration time.Duration
+	Reasons  []string
+}
+
+// Evaluate applies the rules for an offer made at the given local time to a ride
+// with nearbyDrivers candidates around its pickup
+func (p OfferTimeoutPolicy) Evaluate(at time.Time, nearbyDrivers int, surge float64) OfferTimeout {
+	timeout := OfferTimeout{Duration: p.Base}
+	if p.isNight(at) && p.NightExtra > 0 {
+		timeout.Duration += p.NightExtra
+		timeout.Reasons = append(timeout.Reasons, OfferTimeoutNight)
+	}
+	if p.LowDensityDrivers > 0 && nearbyDrivers < p.LowDensityDrivers && p.LowDensityExtra > 0 {
+		timeout.Duration += p.LowDensityExtra
+		timeout.Reasons = append(timeout.Reasons, OfferTimeoutLowDensity)
+	}
+	if p.SurgeThreshold > 0 && surge >= p.SurgeThreshold && p.SurgeReduction > 0 {
+		timeout.Duration -= p.SurgeReduction
+		timeout.Reasons = append(timeout.Reasons, OfferTimeoutSurge)
+	}
+
+	if p.Min > 0 && timeout.Duration < p.Min {
+		timeout.Duration = p.Min
+	}
+	if p.Max > 0 && timeout.Duration > p.Max {
+		timeout.Duration = p.Max
+	}
+	return timeout
+}
+
+func (p OfferTimeoutPolicy) isNight(at time.Time) bool {
+	if p.NightStartHour == p.NightEndHour {
+		return false
+	}
+	hour := at.Hour()
+	if p.NightStartHour < p.NightEndHour {
+		return hour >= p.NightStartHour && hour < p.NightEndHour
+	}
+	return hour >= p.NightStartHour || hour < p.NightEndHour
+}
@@ -3,6 +3,8 @@ package models
 import (
 	"encoding/json"
 	"time"
+
+	"github.com/aditya/go-comet/internal/statemachine"
 )
 
 // Payment status constants
@@ -14,6 +16,15 @@ const (
 	PaymentStatusRefunded   = "refunded"
 )
 
+// PaymentStates holds the valid payment status transitions
+var PaymentStates = statemachine.New(AuditEntityPayment, map[string][]string{
+	PaymentStatusPending:    {PaymentStatusProcessing, PaymentStatusCompleted, PaymentStatusFailed},
+	PaymentStatusProcessing: {PaymentStatusCompleted, PaymentStatusFailed},
+	PaymentStatusCompleted:  {PaymentStatusRefunded},
+	PaymentStatusFailed:     {},
+	PaymentStatusRefunded:   {},
+})
+
 type Payment struct {
 	ID               string          `db:"id" json:"id"`
 	TripID           string          `db:"trip_id" json:"trip_id"`
@@ -28,12 +39,25 @@ type Payment struct {
 	IdempotencyKey   *string         `db:"idempotency_key" json:"idempotency_key,omitempty"`
 	CreatedAt        time.Time       `db:"created_at" json:"created_at"`
 	UpdatedAt        time.Time       `db:"updated_at" json:"updated_at"`
+
+	CarbonOffsetAmount float64 `db:"carbon_offset_amount" json:"carbon_offset_amount"`
+	// Earlier cancellation fees the rider hadn't paid, charged on top of the fare
+	CancellationFeeAmount float64 `db:"cancellation_fee_amount" json:"cancellation_fee_amount"`
+	// Promo discount already taken off the trip fare
+	PromoDiscount float64 `db:"promo_discount" json:"promo_discount"`
+
+	// Fare split at the driver's commission rate when the payment was taken
+	CommissionPercent *float64 `db:"commission_percent" json:"commission_percent,omitempty"`
+	CommissionAmount  *float64 `db:"commission_amount" json:"commission_amount,omitempty"`
+	DriverEarnings    *float64 `db:"driver_earnings" json:"driver_earnings,omitempty"`
 }
 
 type CreatePaymentRequest struct {
 	TripID         string `json:"trip_id" validate:"required,uuid"`
 	Method         string `json:"method" validate:"required,oneof=cash wallet card upi"`
 	IdempotencyKey string `json:"idempotency_key,omitempty"`
+	// Add a donation offsetting the trip's estimated CO2 to the charge
+	CarbonOffset bool `json:"carbon_offset,omitempty"`
 }
 
 type PaymentResponse struct {
@@ -44,16 +68,48 @@ type PaymentResponse struct {
 	Method        string  `json:"method"`
 	Status        string  `json:"status"`
 	TransactionID *string `json:"transaction_id,omitempty"`
+	CarbonOffset  float64 `json:"carbon_offset_amount,omitempty"`
+	// Earlier cancellation fees included in the amount
+	CancellationFees float64 `json:"cancellation_fee_amount,omitempty"`
+	PromoDiscount    float64 `json:"promo_discount,omitempty"`
 }
 
 func (p *Payment) ToResponse() *PaymentResponse {
 	return &PaymentResponse{
-		ID:            p.ID,
-		TripID:        p.TripID,
-		Amount:        p.Amount,
-		Currency:      p.Currency,
-		Method:        p.Method,
-		Status:        p.Status,
-		TransactionID: p.PSPTransactionID,
+		ID:               p.ID,
+		TripID:           p.TripID,
+		Amount:           p.Amount,
+		Currency:         p.Currency,
+		Method:           p.Method,
+		Status:           p.Status,
+		TransactionID:    p.PSPTransactionID,
+		CarbonOffset:     p.CarbonOffsetAmount,
+		CancellationFees: p.CancellationFeeAmount,
+		PromoDiscount:    p.PromoDiscount,
 	}
 }
+
+// Payment hold status constants
+const (
+	HoldStatusAuthorized = "authorized"
+	HoldStatusCaptured   = "captured"
+	HoldStatusVoided     = "voided"
+	HoldStatusFailed     = "failed"
+)
+
+// PaymentHold is a card pre-authorization for a ride's estimated fare plus a
+// buffer, captured for the actual fare when the rider pays
+type PaymentHold struct {
+	ID                 string    `db:"id" json:"id"`
+	RideID             string    `db:"ride_id" json:"ride_id"`
+	UserID             string    `db:"user_id" json:"user_id"`
+	Amount             float64   `db:"amount" json:"amount"`
+	Currency           string    `db:"currency" json:"currency"`
+	Status             string    `db:"status" json:"status"`
+	PSPAuthorizationID *string   `db:"psp_authorization_id" json:"psp_authorization_id,omitempty"`
+	CapturedAmount     *float64  `db:"captured_amount" json:"captured_amount,omitempty"`
+	PaymentID          *string   `db:"payment_id" json:"payment_id,omitempty"`
+	TenantCode         string    `db:"tenant_code" json:"tenant_code"`
+	CreatedAt          time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt          time.Time `db:"updated_at" json:"updated_at"`
+}
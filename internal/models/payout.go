@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// Payout statuses
+const (
+	PayoutStatusRequested = "requested"
+)
+
+// Payout moves money from a driver's balance to their bank account. The amount
+// is debited from the balance as soon as it is requested.
+type Payout struct {
+	ID        string    `db:"id" json:"id"`
+	DriverID  string    `db:"driver_id" json:"driver_id"`
+	Amount    float64   `db:"amount" json:"amount"`
+	Status    string    `db:"status" json:"status"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+type RequestPayoutRequest struct {
+	// The whole balance when omitted
+	Amount float64 `json:"amount,omitempty" validate:"gte=0"`
+}
+
+// DriverPayouts is a driver's balance with their recent payouts
+type DriverPayouts struct {
+	Balance float64   `json:"balance"`
+	Payouts []*Payout `json:"payouts"`
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+)
+
+// Where a pickup suggestion came from; curated spots use their kind
+const (
+	PickupSourceRoad     = "road"
+	PickupSourceEntrance = "entrance"
+	PickupSourceLandmark = "landmark"
+	PickupSourceBay      = "bay"
+)
+
+// PickupSpot is a curated place riders are picked up from, such as a venue
+// entrance or a pickup bay
+type PickupSpot struct {
+	ID        string    `db:"id" json:"id"`
+	Name      string    `db:"name" json:"name"`
+	Kind      string    `db:"kind" json:"kind"`
+	Lat       float64   `db:"lat" json:"lat"`
+	Lng       float64   `db:"lng" json:"lng"`
+	Active    bool      `db:"active" json:"active"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+	// Set for a venue's named pickup points
+	VenueID *string `db:"venue_id" json:"venue_id,omitempty"`
+}
+
+type CreatePickupSpotRequest struct {
+	Name string  `json:"name" validate:"required,max=100"`
+	Kind string  `json:"kind" validate:"required,oneof=entrance landmark bay"`
+	Lat  float64 `json:"lat" validate:"required,latitude"`
+	Lng  float64 `json:"lng" validate:"required,longitude"`
+}
+
+// PickupSuggestion is a recommended pickup point near the rider
+type PickupSuggestion struct {
+	SpotID    *string `json:"spot_id,omitempty"`
+	Name      string  `json:"name"`
+	Lat       float64 `json:"lat"`
+	Lng       float64 `json:"lng"`
+	Source    string  `json:"source"`
+	DistanceM int     `json:"distance_m"`
+}
+
+// PickupSuggestions are the pickup points offered for a location. Inside a venue
+// only its named points are offered and one of them must be chosen.
+type PickupSuggestions struct {
+	Venue       *VenueInfo          `json:"venue,omitempty"`
+	Suggestions []*PickupSuggestion `json:"suggestions"`
+}
+
+// PickupSpotChoice is the suggestion the rider picked when booking; a curated
+// spot is looked up by ID, a road point is taken from the ride's pickup
+type PickupSpotChoice struct {
+	SpotID string `json:"spot_id,omitempty" validate:"omitempty,uuid"`
+	Name   string `json:"name,omitempty" validate:"required_without=SpotID,max=100"`
+	Source string `json:"source" validate:"required,oneof=road entrance landmark bay"`
+}
+
+// PickupSpotInfo tells the driver where exactly to meet the rider
+type PickupSpotInfo struct {
+	ID     *string `json:"id,omitempty"`
+	Name   string  `json:"name"`
+	Source string  `json:"source"`
+	Venue  *string `json:"venue,omitempty"`
+}
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Pricing event kinds
+const (
+	PricingEventMultiplier = "multiplier"
+	PricingEventSurcharge  = "surcharge"
+)
+
+// PricingEvent is a calendar entry that raises fares for rides requested during
+// it, e.g. New Year's Eve or a stadium concert
+type PricingEvent struct {
+	ID          string   `db:"id" json:"id"`
+	Name        string   `db:"name" json:"name"`
+	Kind        string   `db:"kind" json:"kind"`
+	Multiplier  *float64 `db:"multiplier" json:"multiplier,omitempty"`
+	Surcharge   *float64 `db:"surcharge" json:"surcharge,omitempty"`
+	RegionCode  *string  `db:"region_code" json:"region_code,omitempty"`
+	VehicleType *string  `db:"vehicle_type" json:"vehicle_type,omitempty"`
+	// Geofence around a venue; rides starting or ending inside it are charged
+	CenterLat *float64  `db:"center_lat" json:"center_lat,omitempty"`
+	CenterLng *float64  `db:"center_lng" json:"center_lng,omitempty"`
+	RadiusKm  *float64  `db:"radius_km" json:"radius_km,omitempty"`
+	StartsAt  time.Time `db:"starts_at" json:"starts_at"`
+	EndsAt    time.Time `db:"ends_at" json:"ends_at"`
+	Active    bool      `db:"active" json:"active"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// HasGeofence reports whether the event is limited to rides near a venue
+func (e *PricingEvent) HasGeofence() bool {
+	return e.CenterLat != nil && e.CenterLng != nil && e.RadiusKm != nil
+}
+
+type CreatePricingEventRequest struct {
+	Name string `json:"name" validate:"required,max=100"`
+	Kind string `json:"kind" validate:"required,oneof=multiplier surcharge"`
+	// Applied to the fare before discounts and fees; required for multiplier events
+	Multiplier *float64 `json:"multiplier,omitempty" validate:"omitempty,gt=1,lte=5"`
+	// Flat amount added to the fare; required for surcharge events
+	Surcharge   *float64  `json:"surcharge,omitempty" validate:"omitempty,gt=0"`
+	RegionCode  string    `json:"region_code,omitempty" validate:"max=30"`
+	VehicleType string    `json:"vehicle_type,omitempty" validate:"max=30"`
+	CenterLat   *float64  `json:"center_lat,omitempty" validate:"required_with=RadiusKm,omitempty,latitude"`
+	CenterLng   *float64  `json:"center_lng,omitempty" validate:"required_with=RadiusKm,omitempty,longitude"`
+	RadiusKm    *float64  `json:"radius_km,omitempty" validate:"required_with=CenterLat CenterLng,omitempty,gt=0"`
+	StartsAt    time.Time `json:"starts_at" validate:"required"`
+	EndsAt      time.Time `json:"ends_at" validate:"required"`
+}
+
+// FareEvent is one calendar charge on a fare
+type FareEvent struct {
+	ID     string  `json:"id"`
+	Name   string  `json:"name"`
+	Kind   string  `json:"kind"`
+	Amount float64 `json:"amount"`
+}
+
+// FareEvents are stored on the trip as JSON
+type FareEvents []*FareEvent
+
+func (e FareEvents) Value() (driver.Value, error) {
+	if len(e) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(e)
+}
+
+func (e *FareEvents) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, e)
+	case string:
+		return json.Unmarshal([]byte(v), e)
+	case nil:
+		*e = nil
+		return nil
+	default:
+		return fmt.Errorf("unsupported fare events type %T", src)
+	}
+}
@@ -0,0 +1,101 @@
+package models
+
+// Ride product codes
+const (
+	ProductBike      = "bike"
+	ProductAuto      = "auto"
+	ProductMini      = "mini"
+	ProductSedan     = "sedan"
+	ProductSUV       = "suv"
+	ProductPool      = "pool"
+	ProductRental    = "rental"
+	ProductIntercity = "intercity"
+	ProductDelivery  = "delivery"
+)
+
+// ProductDefinition describes a ride product. Every product is served by the
+// drivers of one vehicle type; products that aren't a vehicle type themselves
+// are listed so clients can show them, but can't be booked through /rides yet.
+type ProductDefinition struct {
+	Code        string
+	Name        string
+	Description string
+	VehicleType string
+	Bookable    bool
+	// The fare range is quoted for trips between these lengths
+	MinKm float64
+	MaxKm float64
+	// Fixed durations for time-based products such as rentals; otherwise the
+	// duration is estimated from the distance
+	MinMins int
+	MaxMins int
+	// Share of the vehicle fare charged, e.g. for seats on a shared ride
+	FareShare float64
+	// Training module drivers must complete before they are matched to the product
+	RequiredTraining string
+}
+
+// Products is the catalog in display order
+var Products = []ProductDefinition{
+	{Code: ProductBike, Name: "Bike", Description: "Beat the traffic on a bike taxi, helmet included", VehicleType: VehicleTypeBike, Bookable: true, MinKm: 1, MaxKm: 12, FareShare: 1},
+	{Code: ProductAuto, Name: "Auto", Description: "Affordable auto rickshaw rides", VehicleType: VehicleTypeAuto, Bookable: true, MinKm: 2, MaxKm: 10, FareShare: 1},
+	{Code: ProductMini, Name: "Mini", Description: "Compact hatchbacks for everyday trips", VehicleType: VehicleTypeMini, Bookable: true, MinKm: 3, MaxKm: 15, FareShare: 1},
+	{Code: ProductSedan, Name: "Sedan", Description: "Comfortable sedans with extra legroom", VehicleType: VehicleTypeSedan, Bookable: true, MinKm: 3, MaxKm: 15, FareShare: 1},
+	{Code: ProductSUV, Name: "SUV", Description: "Spacious rides for up to six", VehicleType: VehicleTypeSUV, Bookable: true, MinKm: 3, MaxKm: 15, FareShare: 1},
+	{Code: ProductPool, Name: "Pool", Description: "Share the ride and the fare with riders going your way", VehicleType: VehicleTypeMini, MinKm: 3, MaxKm: 15, FareShare: 0.7, RequiredTraining: TrainingPool},
+	{Code: ProductRental, Name: "Rental", Description: "Keep a car and driver by the hour", VehicleType: VehicleTypeSedan, MinKm: 10, MaxKm: 40, MinMins: 60, MaxMins: 240, FareShare: 1},
+	{Code: ProductIntercity, Name: "Intercity", Description: "One-way trips to nearby cities", VehicleType: VehicleTypeSedan, MinKm: 50, MaxKm: 300, FareShare: 1, RequiredTraining: TrainingIntercity},
+	{Code: ProductDelivery, Name: "Parcel", Description: "Send packages across town with photo proof of delivery", VehicleType: VehicleTypeAuto, Bookable: true, MinKm: 2, MaxKm: 15, FareShare: 1},
+}
+
+// FindProduct returns the catalog entry for a product code
+func FindProduct(code string) (ProductDefinition, bool) {
+	for _, p := range Products {
+		if p.Code == code {
+			return p, true
+		}
+	}
+	return ProductDefinition{}, false
+}
+
+// CarsOnly reports whether the product can't run on bikes: single-seat bikes
+// are never offered as shared or hourly rides
+func (p ProductDefinition) CarsOnly() bool {
+	return p.Code == ProductPool || p.Code == ProductRental
+}
+
+// ServedBy reports whether drivers of the vehicle type can take the product.
+// Checked against the registry, so an edit to the catalog or the vehicle type
+// can't put a cars-only product on bikes.
+func (p ProductDefinition) ServedBy(vt *VehicleTypeDefinition) bool {
+	return !p.CarsOnly() || !vt.IsBikeClass()
+}
+
+// FareRange is what a typical trip on a product costs
+type FareRange struct {
+	Min      float64 `json:"min"`
+	Max      float64 `json:"max"`
+	Currency string  `json:"currency"`
+}
+
+// Product is a catalog entry with live availability at a location
+type Product struct {
+	Code        string `json:"code"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	VehicleType string `json:"vehicle_type"`
+	Bookable    bool   `json:"bookable"`
+	// Training module a driver needs to be matched to the product
+	RequiredTraining string `json:"required_training,omitempty"`
+	// Offered at the location and drivers are nearby
+	Available       bool      `json:"available"`
+	NearbyDrivers   int       `json:"nearby_drivers"`
+	ETAMinutes      *int      `json:"eta_mins,omitempty"`
+	SurgeMultiplier float64   `json:"surge_multiplier"`
+	FareRange       FareRange `json:"fare_range"`
+}
+
+type ProductCatalog struct {
+	Region   *string    `json:"region,omitempty"`
+	Products []*Product `json:"products"`
+}
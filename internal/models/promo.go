@@ -0,0 +1,76 @@
+package models
+
+import "time"
+
+// Promo discount types
+const (
+	PromoTypePercent = "percent"
+	PromoTypeFixed   = "fixed"
+)
+
+// PromoCode is a discount riders enter when booking. Each completed trip using
+// it counts as one use.
+type PromoCode struct {
+	ID           string `db:"id" json:"id"`
+	Code         string `db:"code" json:"code"`
+	DiscountType string `db:"discount_type" json:"discount_type"`
+	// Percent off the fare, or a flat amount for fixed promos
+	Value float64 `db:"value" json:"value"`
+	// Caps a percent discount
+	MaxDiscount *float64   `db:"max_discount" json:"max_discount,omitempty"`
+	MinFare     float64    `db:"min_fare" json:"min_fare"`
+	MaxUses     *int       `db:"max_uses" json:"max_uses,omitempty"`
+	Uses        int        `db:"uses" json:"uses"`
+	ExpiresAt   *time.Time `db:"expires_at" json:"expires_at,omitempty"`
+	Active      bool       `db:"active" json:"active"`
+	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// Exhausted reports whether the promo has no uses left
+func (p *PromoCode) Exhausted() bool {
+	return p.MaxUses != nil && p.Uses >= *p.MaxUses
+}
+
+// PromoRedemption records the discount a promo gave on one ride
+type PromoRedemption struct {
+	ID        string    `db:"id" json:"id"`
+	PromoID   string    `db:"promo_id" json:"promo_id"`
+	RideID    string    `db:"ride_id" json:"ride_id"`
+	UserID    string    `db:"user_id" json:"user_id"`
+	Discount  float64   `db:"discount" json:"discount"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+type CreatePromoCodeRequest struct {
+	Code         string     `json:"code" validate:"required,alphanum,min=3,max=20"`
+	DiscountType string     `json:"discount_type" validate:"required,oneof=percent fixed"`
+	Value        float64    `json:"value" validate:"required,gt=0"`
+	MaxDiscount  *float64   `json:"max_discount,omitempty" validate:"omitempty,gt=0"`
+	MinFare      float64    `json:"min_fare,omitempty" validate:"gte=0"`
+	MaxUses      *int       `json:"max_uses,omitempty" validate:"omitempty,gt=0"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+}
+
+// UpdatePromoCodeRequest replaces the promo's limits; its code and discount stay fixed
+type UpdatePromoCodeRequest struct {
+	MaxDiscount *float64   `json:"max_discount,omitempty" validate:"omitempty,gt=0"`
+	MinFare     float64    `json:"min_fare,omitempty" validate:"gte=0"`
+	MaxUses     *int       `json:"max_uses,omitempty" validate:"omitempty,gt=0"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	Active      bool       `json:"active"`
+}
+
+type ValidatePromoRequest struct {
+	Code string  `json:"code" validate:"required"`
+	Fare float64 `json:"fare" validate:"required,gt=0"`
+}
+
+// PromoValidation says whether a code can be used on a fare and what it takes off
+type PromoValidation struct {
+	Code           string  `json:"code"`
+	Valid          bool    `json:"valid"`
+	Reason         string  `json:"reason,omitempty"`
+	Discount       float64 `json:"discount"`
+	DiscountedFare float64 `json:"discounted_fare"`
+}
@@ -0,0 +1,145 @@
+package models
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+// Rater types: who left the rating. Riders rate the driver and drivers the rider.
+const (
+	RaterUser   = "user"
+	RaterDriver = "driver"
+)
+
+// Feedback moderation statuses. Written feedback is shown to the other side only
+// once published; the star rating counts either way.
+const (
+	FeedbackPublished = "published"
+	// Held by the content filter for an admin to review
+	FeedbackFlagged = "flagged"
+	FeedbackRemoved = "removed"
+	// Removed feedback the author asked an admin to look at again
+	FeedbackAppealed = "appealed"
+)
+
+// FeedbackQueueStatuses are the statuses waiting on an admin
+var FeedbackQueueStatuses = []string{FeedbackFlagged, FeedbackAppealed}
+
+// Why the content filter flagged feedback
+const FlagReasonBlockedLanguage = "blocked_language"
+
+// DefaultDriverRating is shown until a driver has enough rated trips
+const DefaultDriverRating = 5.0
+
+// DriverRatingPolicy keeps a driver's public rating from swinging on a few trips
+// or a single bad one
+type DriverRatingPolicy struct {
+	// Rated trips needed before the rating moves off the default
+	MinRatedTrips int
+	// A lone 1-star among ratings averaging at least OutlierThreshold counts as
+	// OutlierWeight of a rating
+	OutlierThreshold float64
+	OutlierWeight    float64
+}
+
+// Rating averages the driver's ratings under the policy
+func (p DriverRatingPolicy) Rating(ratings []int) float64 {
+	if len(ratings) == 0 || len(ratings) < p.MinRatedTrips {
+		return DefaultDriverRating
+	}
+
+	total, ones := 0, 0
+	for _, r := range ratings {
+		total += r
+		if r == 1 {
+			ones++
+		}
+	}
+	avg := float64(total) / float64(len(ratings))
+
+	if ones == 1 && len(ratings) > 1 {
+		others := len(ratings) - 1
+		if float64(total-1)/float64(others) >= p.OutlierThreshold {
+			avg = (float64(total-1) + p.OutlierWeight) / (float64(others) + p.OutlierWeight)
+		}
+	}
+	return math.Round(avg*10) / 10
+}
+
+// TripRating is one side's rating of the other after a trip
+type TripRating struct {
+	ID        string  `db:"id" json:"id"`
+	TripID    string  `db:"trip_id" json:"trip_id"`
+	RaterType string  `db:"rater_type" json:"rater_type"`
+	RaterID   string  `db:"rater_id" json:"rater_id"`
+	RateeID   string  `db:"ratee_id" json:"ratee_id"`
+	Rating    int     `db:"rating" json:"rating"`
+	Feedback  *string `db:"feedback" json:"feedback,omitempty"`
+	// Moderation of the feedback; unset when there is none
+	FeedbackStatus *string    `db:"feedback_status" json:"feedback_status,omitempty"`
+	FlagReason     *string    `db:"flag_reason" json:"flag_reason,omitempty"`
+	AppealReason   *string    `db:"appeal_reason" json:"appeal_reason,omitempty"`
+	ModeratedAt    *time.Time `db:"moderated_at" json:"moderated_at,omitempty"`
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
+}
+
+// FeedbackIs reports whether the rating has feedback in one of the statuses
+func (r *TripRating) FeedbackIs(statuses ...string) bool {
+	if r.FeedbackStatus == nil {
+		return false
+	}
+	for _, status := range statuses {
+		if *r.FeedbackStatus == status {
+			return true
+		}
+	}
+	return false
+}
+
+// ForRatee is the rating as the rated side sees it: feedback that isn't
+// published yet, or was removed, is left out along with moderation details
+func (r *TripRating) ForRatee() *TripRating {
+	c := *r
+	if !r.FeedbackIs(FeedbackPublished) {
+		c.Feedback = nil
+		c.FeedbackStatus = nil
+	}
+	c.FlagReason = nil
+	c.AppealReason = nil
+	c.ModeratedAt = nil
+	return &c
+}
+
+// AppealFeedbackRequest asks for removed feedback to be reviewed again
+type AppealFeedbackRequest struct {
+	RaterID string `json:"rater_id" validate:"required,uuid"`
+	Reason  string `json:"reason" validate:"required,max=500"`
+}
+
+type RateTripRequest struct {
+	RaterType string `json:"rater_type" validate:"required,oneof=user driver"`
+	RaterID   string `json:"rater_id" validate:"required,uuid"`
+	Rating    int    `json:"rating" validate:"required,min=1,max=5"`
+	Feedback  string `json:"feedback,omitempty" validate:"max=1000"`
+	// Language of the feedback, for moderation
+	Language string `json:"language,omitempty" validate:"omitempty,bcp47_language_tag"`
+}
+
+// TripSummary recaps a completed trip for the rider, who is asked to rate it
+type TripSummary struct {
+	TripID       string     `db:"trip_id" json:"trip_id"`
+	RideID       string     `db:"ride_id" json:"ride_id"`
+	DriverID     string     `db:"driver_id" json:"driver_id"`
+	DriverName   string     `db:"driver_name" json:"driver_name"`
+	TotalFare    *float64   `db:"total_fare" json:"total_fare,omitempty"`
+	DurationMins *int       `db:"duration_mins" json:"duration_mins,omitempty"`
+	EndedAt      *time.Time `db:"ended_at" json:"ended_at,omitempty"`
+	// Opens the rating screen in the app
+	RateLink string `db:"-" json:"rate_link"`
+}
+
+// RateTripLink fills the trip into a deep link template containing {trip_id}
+func RateTripLink(template, tripID string) string {
+	return strings.ReplaceAll(template, "{trip_id}", tripID)
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+)
+
+// Receipt types
+const (
+	ReceiptTypeRide     = "ride"
+	ReceiptTypeDelivery = "delivery"
+)
+
+// Receipt is the rider-facing summary of a completed trip
+type Receipt struct {
+	Type         string            `json:"type"`
+	TripID       string            `json:"trip_id"`
+	RideID       string            `json:"ride_id"`
+	Pickup       Location          `json:"pickup"`
+	Dropoff      Location          `json:"dropoff"`
+	VehicleType  string            `json:"vehicle_type"`
+	StartTime    *time.Time        `json:"start_time,omitempty"`
+	EndTime      *time.Time        `json:"end_time,omitempty"`
+	DistanceKm   *float64          `json:"distance_km,omitempty"`
+	DurationMins *int              `json:"duration_mins,omitempty"`
+	Fare         *FareBreakdown    `json:"fare,omitempty"`
+	CO2Grams     *float64          `json:"co2_grams,omitempty"`
+	Payment      *PaymentResponse  `json:"payment,omitempty"`
+	Insurance    *ReceiptInsurance `json:"insurance,omitempty"`
+	Segments     []*ReceiptSegment `json:"segments,omitempty"`
+	// Proof of delivery on parcel receipts
+	Delivery *ReceiptDelivery `json:"delivery,omitempty"`
+}
+
+// ReceiptInsurance is the coverage summary shown on a receipt
+type ReceiptInsurance struct {
+	Provider     string             `json:"provider"`
+	PolicyNumber string             `json:"policy_number"`
+	Currency     string             `json:"currency,omitempty"`
+	Limits       map[string]float64 `json:"limits,omitempty"`
+}
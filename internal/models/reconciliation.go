@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// Why a driver's cache entries were queued for repair
+const (
+	CacheRepairAccept    = "accept"
+	CacheRepairBidAccept = "bid_accept"
+)
+
+// DriverCacheRepair is a driver whose Redis state must be rewritten from Postgres
+// because a cache write failed after the change committed
+type DriverCacheRepair struct {
+	DriverID      string    `db:"driver_id" json:"driver_id"`
+	Reason        string    `db:"reason" json:"reason"`
+	Attempts      int       `db:"attempts" json:"attempts"`
+	LastError     *string   `db:"last_error" json:"last_error,omitempty"`
+	CreatedAt     time.Time `db:"created_at" json:"created_at"`
+	NextAttemptAt time.Time `db:"next_attempt_at" json:"next_attempt_at"`
+}
+
+// Kinds of cache/DB drift detected by driver reconciliation
+const (
+	DriftStatusMismatch    = "status_mismatch"
+	DriftStaleActiveRide   = "stale_active_ride"
+	DriftMissingActiveRide = "missing_active_ride"
+	DriftStaleGeoEntry     = "stale_geo_entry"
+)
+
+// ReconciliationReport summarises a single cache-vs-DB reconciliation pass
+type ReconciliationReport struct {
+	StartedAt      time.Time      `json:"started_at"`
+	DriversChecked int            `json:"drivers_checked"`
+	Repaired       map[string]int `json:"repaired"`
+}
+
+func (r *ReconciliationReport) Total() int {
+	total := 0
+	for _, n := range r.Repaired {
+		total += n
+	}
+	return total
+}
@@ -0,0 +1,138 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+type Region struct {
+	Code        string         `db:"code" json:"code"`
+	Name        string         `db:"name" json:"name"`
+	MinLat      float64        `db:"min_lat" json:"min_lat"`
+	MinLng      float64        `db:"min_lng" json:"min_lng"`
+	MaxLat      float64        `db:"max_lat" json:"max_lat"`
+	MaxLng      float64        `db:"max_lng" json:"max_lng"`
+	ServiceArea ServiceArea    `db:"service_area" json:"service_area,omitempty"`
+	Active      bool           `db:"active" json:"active"`
+	Settings    RegionSettings `db:"settings" json:"settings"`
+	TenantCode  string         `db:"tenant_code" json:"tenant_code"`
+	CreatedAt   time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time      `db:"updated_at" json:"updated_at"`
+}
+
+// RegionSettings holds per-region feature configuration, stored as JSONB
+type RegionSettings struct {
+	// Drivers must pass a selfie check before going online
+	SelfieCheckRequired bool `json:"selfie_check_required"`
+	// How long a passed selfie check remains valid (minutes)
+	SelfieCheckValidityMins int `json:"selfie_check_validity_mins,omitempty"`
+
+	// Safety mode driver criteria; zero values fall back to the defaults
+	SafetyMinDriverRating float64 `json:"safety_min_driver_rating,omitempty"`
+	SafetyMinTenureDays   int     `json:"safety_min_tenure_days,omitempty"`
+	// Riders may restrict matching by driver gender (only where legally permitted)
+	SafetyGenderFilterAllowed bool `json:"safety_gender_filter_allowed,omitempty"`
+
+	// Fare discount for trips served by electric vehicles (percent)
+	EVDiscountPercent float64 `json:"ev_discount_percent,omitempty"`
+
+	// Bookable trip distance (km); zero values fall back to the defaults
+	MinRideDistanceKm float64 `json:"min_ride_distance_km,omitempty" validate:"gte=0"`
+	MaxRideDistanceKm float64 `json:"max_ride_distance_km,omitempty" validate:"omitempty,gtfield=MinRideDistanceKm"`
+
+	// Vehicle types offered in the region; empty means all
+	VehicleTypes []string `json:"vehicle_types,omitempty" validate:"omitempty,dive,max=30"`
+	// Overrides the default free cancellation window for clients (seconds)
+	FreeCancellationWindowSecs int `json:"free_cancellation_window_secs,omitempty"`
+	// Client feature flags that differ from the deployment defaults
+	FeatureFlags map[string]bool `json:"feature_flags,omitempty"`
+	// Soft launches: the percent of riders a product is open to in the region,
+	// overriding whether the catalog makes it bookable
+	ProductRollouts map[string]float64 `json:"product_rollouts,omitempty" validate:"omitempty,dive,keys,oneof=bike auto mini sedan suv pool rental intercity delivery,endkeys,gte=0,lte=100"`
+	// Median gap between estimated and actual fares that raises an alert (percent);
+	// zero falls back to the default
+	FareVarianceThresholdPercent float64 `json:"fare_variance_threshold_percent,omitempty" validate:"gte=0"`
+	// Matching and pickup time targets; unset targets fall back to the defaults
+	SLO *SLOTargets `json:"slo,omitempty"`
+	// Days before a finished trip's GPS detail and personal data are anonymized;
+	// zero falls back to the default
+	DataRetentionDays int `json:"data_retention_days,omitempty" validate:"gte=0"`
+}
+
+// AssignRegionTenantRequest moves a region's service area to another brand
+type AssignRegionTenantRequest struct {
+	TenantCode string `json:"tenant_code" validate:"required,max=50"`
+}
+
+// OffersVehicleType reports whether riders in the region can book the vehicle type
+func (s RegionSettings) OffersVehicleType(vehicleType string) bool {
+	if len(s.VehicleTypes) == 0 {
+		return true
+	}
+	for _, vt := range s.VehicleTypes {
+		if vt == vehicleType {
+			return true
+		}
+	}
+	return false
+}
+
+func (s RegionSettings) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+func (s *RegionSettings) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, s)
+	case string:
+		return json.Unmarshal([]byte(v), s)
+	case nil:
+		*s = RegionSettings{}
+		return nil
+	default:
+		return fmt.Errorf("unsupported region settings type %T", src)
+	}
+}
+
+// Contains reports whether a point falls inside the region's bounding box and,
+// when one is configured, its service area polygon
+func (r *Region) Contains(lat, lng float64) bool {
+	if lat < r.MinLat || lat > r.MaxLat || lng < r.MinLng || lng > r.MaxLng {
+		return false
+	}
+	return len(r.ServiceArea) == 0 || r.ServiceArea.Contains(lat, lng)
+}
+
+// Boundary is the region's service area polygon, or its bounding box when none
+// is configured
+func (r *Region) Boundary() ServiceArea {
+	if len(r.ServiceArea) > 0 {
+		return r.ServiceArea
+	}
+	return ServiceArea{
+		{Lat: r.MinLat, Lng: r.MinLng},
+		{Lat: r.MinLat, Lng: r.MaxLng},
+		{Lat: r.MaxLat, Lng: r.MaxLng},
+		{Lat: r.MaxLat, Lng: r.MinLng},
+	}
+}
+
+// ServiceAreaMiss explains which end of a ride fell outside every region and
+// where the closest served area is
+type ServiceAreaMiss struct {
+	// pickup or dropoff
+	Location string              `json:"location"`
+	Nearest  *NearestServiceArea `json:"nearest_service_area,omitempty"`
+}
+
+type NearestServiceArea struct {
+	RegionCode string  `json:"region_code"`
+	Name       string  `json:"name"`
+	DistanceKm float64 `json:"distance_km"`
+	// Closest point on the area's boundary
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
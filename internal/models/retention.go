@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// AnonymizedCoordDecimals is the precision coordinates keep once a trip is
+// anonymized: two decimals is roughly 1 km, enough for zone-level reporting
+const AnonymizedCoordDecimals = 2
+
+// Audit log snapshots of an anonymized trip's ride, trip and offers keep their
+// timeline but have these columns rounded or cleared like the rows themselves
+var (
+	AnonymizedSnapshotCoords = []string{"pickup_lat", "pickup_lng", "dropoff_lat", "dropoff_lng"}
+	AnonymizedSnapshotFields = []string{"pickup_address", "dropoff_address", "rider_note",
+		"pickup_spot_name", "card_fingerprint", "route_polyline"}
+)
+
+// RetentionReport is the outcome of one data retention run. In a dry run the
+// counts are the trips that would be anonymized and nothing is changed.
+type RetentionReport struct {
+	DryRun  bool                     `json:"dry_run"`
+	RunAt   time.Time                `json:"run_at"`
+	Regions []*RegionRetentionReport `json:"regions"`
+	Trips   int                      `json:"trips"`
+}
+
+// RegionRetentionReport covers the trips of one region; trips booked outside
+// every region are reported without a region code
+type RegionRetentionReport struct {
+	RegionCode    *string   `json:"region_code,omitempty"`
+	RetentionDays int       `json:"retention_days"`
+	Cutoff        time.Time `json:"cutoff"`
+	Trips         int       `json:"trips"`
+}
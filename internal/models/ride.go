@@ -2,12 +2,16 @@ package models
 
 import (
 	"time"
+
+	"github.com/aditya/go-comet/internal/statemachine"
 )
 
 // Ride status constants
 const (
 	RideStatusPending        = "pending"
+	RideStatusScheduled      = "scheduled" // booked ahead, waiting for matching to start
 	RideStatusMatching       = "matching"
+	RideStatusQueued         = "queued" // accepted by a driver who is finishing another trip
 	RideStatusDriverAssigned = "driver_assigned"
 	RideStatusDriverArrived  = "driver_arrived"
 	RideStatusInProgress     = "in_progress"
@@ -15,16 +19,29 @@ const (
 	RideStatusCancelled      = "cancelled"
 )
 
-// Valid ride state transitions
-var ValidRideTransitions = map[string][]string{
-	RideStatusPending:        {RideStatusMatching, RideStatusCancelled},
-	RideStatusMatching:       {RideStatusDriverAssigned, RideStatusCancelled},
-	RideStatusDriverAssigned: {RideStatusDriverArrived, RideStatusCancelled},
-	RideStatusDriverArrived:  {RideStatusInProgress, RideStatusCancelled},
+// CancelReasonRiderUnreachable is recorded when the rider's app stops checking in during matching
+const CancelReasonRiderUnreachable = "rider_unreachable"
+
+// CancelReasonMatchingTimedOut is recorded when a ride sat in pending or matching
+// past the stuck-ride timeout, e.g. after matching crashed
+const CancelReasonMatchingTimedOut = "matching_timed_out"
+
+// CancelReasonNoDrivers is recorded when every dispatch round, out to the widest
+// search radius, ended without a driver accepting
+const CancelReasonNoDrivers = "no_drivers_available"
+
+// RideStates holds the valid ride status transitions
+var RideStates = statemachine.New(AuditEntityRide, map[string][]string{
+	RideStatusPending:        {RideStatusMatching, RideStatusScheduled, RideStatusCancelled},
+	RideStatusScheduled:      {RideStatusMatching, RideStatusCancelled},
+	RideStatusMatching:       {RideStatusDriverAssigned, RideStatusQueued, RideStatusCancelled},
+	RideStatusQueued:         {RideStatusDriverAssigned, RideStatusCancelled},
+	RideStatusDriverAssigned: {RideStatusDriverArrived, RideStatusMatching, RideStatusCancelled},
+	RideStatusDriverArrived:  {RideStatusInProgress, RideStatusMatching, RideStatusCancelled},
 	RideStatusInProgress:     {RideStatusCompleted, RideStatusCancelled},
 	RideStatusCompleted:      {},
 	RideStatusCancelled:      {},
-}
+})
 
 // Payment methods
 const (
@@ -34,42 +51,103 @@ const (
 	PaymentMethodUPI    = "upi"
 )
 
+// Location is a point with an optional address. In requests either the
+// coordinates or the address may be omitted; the other is geocoded.
 type Location struct {
-	Lat     float64 `json:"lat" validate:"required,latitude"`
-	Lng     float64 `json:"lng" validate:"required,longitude"`
+	Lat     float64 `json:"lat" validate:"required_without=Address,omitempty,latitude"`
+	Lng     float64 `json:"lng" validate:"required_without=Address,omitempty,longitude"`
 	Address string  `json:"address,omitempty"`
 }
 
+// HasCoordinates reports whether the location carries a lat/lng pair
+func (l *Location) HasCoordinates() bool {
+	return l.Lat != 0 || l.Lng != 0
+}
+
 type Ride struct {
-	ID                   string    `db:"id" json:"id"`
-	UserID               string    `db:"user_id" json:"user_id"`
-	DriverID             *string   `db:"driver_id" json:"driver_id,omitempty"`
-	PickupLat            float64   `db:"pickup_lat" json:"pickup_lat"`
-	PickupLng            float64   `db:"pickup_lng" json:"pickup_lng"`
-	PickupAddress        *string   `db:"pickup_address" json:"pickup_address,omitempty"`
-	DropoffLat           float64   `db:"dropoff_lat" json:"dropoff_lat"`
-	DropoffLng           float64   `db:"dropoff_lng" json:"dropoff_lng"`
-	DropoffAddress       *string   `db:"dropoff_address" json:"dropoff_address,omitempty"`
-	VehicleType          string    `db:"vehicle_type" json:"vehicle_type"`
-	Status               string    `db:"status" json:"status"`
-	EstimatedFare        *float64  `db:"estimated_fare" json:"estimated_fare,omitempty"`
-	SurgeMultiplier      float64   `db:"surge_multiplier" json:"surge_multiplier"`
-	EstimatedDistanceKm  *float64  `db:"estimated_distance_km" json:"estimated_distance_km,omitempty"`
-	EstimatedDurationMin *int      `db:"estimated_duration_mins" json:"estimated_duration_mins,omitempty"`
-	PaymentMethod        string    `db:"payment_method" json:"payment_method"`
-	IdempotencyKey       *string   `db:"idempotency_key" json:"idempotency_key,omitempty"`
-	CancelledBy          *string   `db:"cancelled_by" json:"cancelled_by,omitempty"`
-	CancellationReason   *string   `db:"cancellation_reason" json:"cancellation_reason,omitempty"`
-	CreatedAt            time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt            time.Time `db:"updated_at" json:"updated_at"`
+	ID                   string     `db:"id" json:"id"`
+	UserID               string     `db:"user_id" json:"user_id"`
+	DriverID             *string    `db:"driver_id" json:"driver_id,omitempty"`
+	PickupLat            float64    `db:"pickup_lat" json:"pickup_lat"`
+	PickupLng            float64    `db:"pickup_lng" json:"pickup_lng"`
+	PickupAddress        *string    `db:"pickup_address" json:"pickup_address,omitempty"`
+	DropoffLat           float64    `db:"dropoff_lat" json:"dropoff_lat"`
+	DropoffLng           float64    `db:"dropoff_lng" json:"dropoff_lng"`
+	DropoffAddress       *string    `db:"dropoff_address" json:"dropoff_address,omitempty"`
+	VehicleType          string     `db:"vehicle_type" json:"vehicle_type"`
+	Status               string     `db:"status" json:"status"`
+	EstimatedFare        *float64   `db:"estimated_fare" json:"estimated_fare,omitempty"`
+	SurgeMultiplier      float64    `db:"surge_multiplier" json:"surge_multiplier"`
+	EstimatedDistanceKm  *float64   `db:"estimated_distance_km" json:"estimated_distance_km,omitempty"`
+	EstimatedDurationMin *int       `db:"estimated_duration_mins" json:"estimated_duration_mins,omitempty"`
+	PaymentMethod        string     `db:"payment_method" json:"payment_method"`
+	RegionCode           *string    `db:"region_code" json:"region_code,omitempty"`
+	IdempotencyKey       *string    `db:"idempotency_key" json:"idempotency_key,omitempty"`
+	CancelledBy          *string    `db:"cancelled_by" json:"cancelled_by,omitempty"`
+	CancellationReason   *string    `db:"cancellation_reason" json:"cancellation_reason,omitempty"`
+	CreatedAt            time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt            time.Time  `db:"updated_at" json:"updated_at"`
+	PricingMode          string     `db:"pricing_mode" json:"pricing_mode"`
+	ProposedFare         *float64   `db:"proposed_fare" json:"proposed_fare,omitempty"`
+	AgreedFare           *float64   `db:"agreed_fare" json:"agreed_fare,omitempty"`
+	NavigationStatus     *string    `db:"navigation_status" json:"navigation_status,omitempty"`
+	EnRouteAt            *time.Time `db:"en_route_at" json:"en_route_at,omitempty"`
+	ArrivedAt            *time.Time `db:"arrived_at" json:"arrived_at,omitempty"`
+	RiderNote            *string    `db:"rider_note" json:"note,omitempty"`
+	CardFingerprint      *string    `db:"card_fingerprint" json:"-"`
+	PickupETAMin         *int       `db:"pickup_eta_mins" json:"pickup_eta_mins,omitempty"`
+	// Set when the assigned driver cancelled and the ride went back to matching
+	OriginalPickupETAMin *int       `db:"original_pickup_eta_mins" json:"original_pickup_eta_mins,omitempty"`
+	ReassignedFrom       *string    `db:"reassigned_from_driver_id" json:"reassigned_from_driver_id,omitempty"`
+	ReassignedAt         *time.Time `db:"reassigned_at" json:"reassigned_at,omitempty"`
+	// Catalog product when the ride was booked as more than its vehicle type, e.g. pool
+	Product              *string    `db:"product" json:"product,omitempty"`
+	PickupSpotID         *string    `db:"pickup_spot_id" json:"pickup_spot_id,omitempty"`
+	PickupSpotName       *string    `db:"pickup_spot_name" json:"pickup_spot_name,omitempty"`
+	PickupSpotSource     *string    `db:"pickup_spot_source" json:"pickup_spot_source,omitempty"`
+	// Brand the ride was booked under
+	TenantCode           string     `db:"tenant_code" json:"tenant_code"`
+	// Dispatch rounds so far; each unanswered round searches further out
+	MatchingAttempts     int        `db:"matching_attempts" json:"matching_attempts"`
+	MatchRadiusKm        *float64   `db:"match_radius_km" json:"match_radius_km,omitempty"`
+	LastDispatchedAt     *time.Time `db:"last_dispatched_at" json:"-"`
+	// Pickup time of a ride booked ahead; it waits in scheduled until matching starts
+	ScheduledAt          *time.Time `db:"scheduled_at" json:"scheduled_at,omitempty"`
+	// Promo the rider booked with; it's redeemed when the trip ends
+	PromoCode            *string    `db:"promo_code" json:"promo_code,omitempty"`
+	// Whether the rider had safety mode on when booking; matching only loads
+	// their safety preferences for these rides
+	SafetyMode           bool       `db:"safety_mode" json:"-"`
+
+	// Set on a newly booked delivery so the sender gets the recipient's code
+	Delivery *Delivery `db:"-" json:"delivery,omitempty"`
 }
 
 type CreateRideRequest struct {
 	UserID        string   `json:"user_id" validate:"required,uuid"`
 	Pickup        Location `json:"pickup" validate:"required"`
 	Dropoff       Location `json:"dropoff" validate:"required"`
-	VehicleType   string   `json:"vehicle_type" validate:"required,oneof=auto mini sedan suv"`
+	VehicleType   string   `json:"vehicle_type" validate:"required,max=30"`
 	PaymentMethod string   `json:"payment_method" validate:"required,oneof=cash wallet card upi"`
+	Language      string   `json:"language,omitempty" validate:"omitempty,bcp47_language_tag"`
+	PricingMode   string   `json:"pricing_mode,omitempty" validate:"omitempty,oneof=standard bid"`
+	ProposedFare  *float64 `json:"proposed_fare,omitempty" validate:"required_if=PricingMode bid,omitempty,gt=0"`
+	// Special instructions shown to the driver, e.g. "gate code 1234, call on arrival"
+	Note string `json:"note,omitempty" validate:"max=300"`
+	// PSP fingerprint of the card paying for a card ride; an unknown card is treated as new
+	CardFingerprint string `json:"card_fingerprint,omitempty" validate:"max=64"`
+	// Catalog product to book; must run on the requested vehicle type
+	Product string `json:"product,omitempty" validate:"omitempty,oneof=bike auto mini sedan suv pool rental intercity delivery"`
+	// Parcel and recipient, required when booking a delivery
+	Delivery *DeliveryDetails `json:"delivery,omitempty" validate:"required_if=Product delivery,omitempty"`
+	// Suggested pickup point the rider chose (GET /v1/pickup-suggestions)
+	PickupSpot *PickupSpotChoice `json:"pickup_spot,omitempty"`
+	// Books the ride ahead for this pickup time instead of matching it now
+	ScheduledAt *time.Time `json:"scheduled_at,omitempty"`
+	// Discount code applied to the estimate and redeemed at the end of the trip
+	PromoCode string `json:"promo_code,omitempty" validate:"omitempty,max=20"`
+	// Riders travelling together; can't exceed the vehicle type's capacity
+	Passengers int `json:"passengers,omitempty" validate:"omitempty,gte=1,lte=50"`
 }
 
 type RideResponse struct {
@@ -77,6 +155,7 @@ type RideResponse struct {
 	Status               string           `json:"status"`
 	User                 *UserResponse    `json:"user,omitempty"`
 	Driver               *DriverResponse  `json:"driver,omitempty"`
+	MatchEstimate        *MatchEstimate   `json:"match_estimate,omitempty"`
 	Pickup               Location         `json:"pickup"`
 	Dropoff              Location         `json:"dropoff"`
 	VehicleType          string           `json:"vehicle_type"`
@@ -85,13 +164,68 @@ type RideResponse struct {
 	EstimatedDistanceKm  *float64         `json:"estimated_distance_km,omitempty"`
 	EstimatedDurationMin *int             `json:"estimated_duration_mins,omitempty"`
 	PaymentMethod        string           `json:"payment_method"`
+	PricingMode          string           `json:"pricing_mode"`
+	ProposedFare         *float64         `json:"proposed_fare,omitempty"`
+	AgreedFare           *float64         `json:"agreed_fare,omitempty"`
+	NavigationStatus     *string          `json:"navigation_status,omitempty"`
+	EnRouteAt            *time.Time       `json:"en_route_at,omitempty"`
+	ArrivedAt            *time.Time       `json:"arrived_at,omitempty"`
+	Note                 *string          `json:"note,omitempty"`
+	PickupETAMin         *int             `json:"pickup_eta_mins,omitempty"`
+	// Live estimate from the driver's current location while they head to pickup
+	ETAToPickupMin       *int             `json:"eta_to_pickup_mins,omitempty"`
+	Reassigned           bool             `json:"reassigned,omitempty"`
+	PickupSpot           *PickupSpotInfo  `json:"pickup_spot,omitempty"`
+	Product              *string          `json:"product,omitempty"`
+	ScheduledAt          *time.Time       `json:"scheduled_at,omitempty"`
+	PromoCode            *string          `json:"promo_code,omitempty"`
 	CreatedAt            time.Time        `json:"created_at"`
 	UpdatedAt            time.Time        `json:"updated_at"`
 }
 
+// RideSearchFilter holds admin ride search criteria; zero values are ignored
+type RideSearchFilter struct {
+	Status        string
+	RegionCode    string
+	TenantCode    string
+	PaymentMethod string
+	From          *time.Time
+	To            *time.Time
+	MinSurge      *float64
+	Query         string
+	Limit         int
+	Offset        int
+}
+
+// DriverCancelRideRequest is a driver giving up an assigned ride before pickup;
+// the ride is offered to other drivers rather than cancelled
+type DriverCancelRideRequest struct {
+	RideID string `json:"ride_id" validate:"required,uuid"`
+	Reason string `json:"reason,omitempty" validate:"max=200"`
+}
+
+// Who cancels a ride
+const (
+	CancelledByUser   = "user"
+	CancelledByDriver = "driver"
+	CancelledBySystem = "system"
+)
+
 type CancelRideRequest struct {
-	Reason      string `json:"reason,omitempty"`
-	CancelledBy string `json:"cancelled_by" validate:"required,oneof=user driver system"`
+	Reason string `json:"reason,omitempty"`
+	// Set from the authenticated caller, never the client: whatever a client sends
+	// is ignored. Ops cancel as system through the admin API.
+	CancelledBy string `json:"cancelled_by,omitempty"`
+	// The rider or driver cancelling; empty for system and anonymous callers
+	CancelledByID string `json:"-"`
+	// Set when the caller authenticated with a session; only verified riders are
+	// charged a cancellation fee
+	Verified bool `json:"-"`
+}
+
+// IsDelivery reports whether the ride carries a parcel rather than a rider
+func (r *Ride) IsDelivery() bool {
+	return r.Product != nil && *r.Product == ProductDelivery
 }
 
 func (r *Ride) ToResponse() *RideResponse {
@@ -112,6 +246,18 @@ func (r *Ride) ToResponse() *RideResponse {
 		EstimatedDistanceKm:  r.EstimatedDistanceKm,
 		EstimatedDurationMin: r.EstimatedDurationMin,
 		PaymentMethod:        r.PaymentMethod,
+		PricingMode:          r.PricingMode,
+		ProposedFare:         r.ProposedFare,
+		AgreedFare:           r.AgreedFare,
+		NavigationStatus:     r.NavigationStatus,
+		EnRouteAt:            r.EnRouteAt,
+		ArrivedAt:            r.ArrivedAt,
+		Note:                 r.RiderNote,
+		PickupETAMin:         r.PickupETAMin,
+		Reassigned:           r.ReassignedFrom != nil,
+		Product:              r.Product,
+		ScheduledAt:          r.ScheduledAt,
+		PromoCode:            r.PromoCode,
 		CreatedAt:            r.CreatedAt,
 		UpdatedAt:            r.UpdatedAt,
 	}
@@ -122,26 +268,33 @@ func (r *Ride) ToResponse() *RideResponse {
 	if r.DropoffAddress != nil {
 		resp.Dropoff.Address = *r.DropoffAddress
 	}
+	if r.PickupSpotName != nil {
+		resp.PickupSpot = &PickupSpotInfo{ID: r.PickupSpotID, Name: *r.PickupSpotName}
+		if r.PickupSpotSource != nil {
+			resp.PickupSpot.Source = *r.PickupSpotSource
+		}
+	}
 
 	return resp
 }
 
+// RideStatusEvent is published to trackers and webhooks whenever a ride changes status
+type RideStatusEvent struct {
+	RideID         string    `json:"ride_id"`
+	TenantCode     string    `json:"tenant_code"`
+	UserID         string    `json:"user_id"`
+	DriverID       *string   `json:"driver_id,omitempty"`
+	PreviousStatus string    `json:"previous_status"`
+	Status         string    `json:"status"`
+	At             time.Time `json:"at"`
+}
+
 // CanTransitionTo checks if a ride can transition to a new status
 func (r *Ride) CanTransitionTo(newStatus string) bool {
-	validNextStates, exists := ValidRideTransitions[r.Status]
-	if !exists {
-		return false
-	}
-
-	for _, state := range validNextStates {
-		if state == newStatus {
-			return true
-		}
-	}
-	return false
+	return RideStates.Can(r.Status, newStatus)
 }
 
 // IsActive returns true if the ride is not in a terminal state
 func (r *Ride) IsActive() bool {
-	return r.Status != RideStatusCompleted && r.Status != RideStatusCancelled
+	return !RideStates.IsTerminal(r.Status)
 }
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// RideEconomics is what a paid ride earned and cost the platform. The gross fare
+// is what the ride would have cost before discounts; commission is charged on
+// the discounted fare the rider paid, and incentive top-ups come out of it.
+type RideEconomics struct {
+	RideID      string  `db:"ride_id" json:"ride_id"`
+	TripID      string  `db:"trip_id" json:"trip_id"`
+	PaymentID   string  `db:"payment_id" json:"payment_id"`
+	DriverID    string  `db:"driver_id" json:"driver_id"`
+	RegionCode  *string `db:"region_code" json:"region_code,omitempty"`
+	VehicleType string  `db:"vehicle_type" json:"vehicle_type"`
+	Currency    string  `db:"currency" json:"currency"`
+	GrossFare   float64 `db:"gross_fare" json:"gross_fare"`
+	Discounts   float64 `db:"discounts" json:"discounts"`
+	// The fare charged, excluding any carbon offset donation
+	RiderPaid      float64 `db:"rider_paid" json:"rider_paid"`
+	Commission     float64 `db:"commission" json:"commission"`
+	DriverEarnings float64 `db:"driver_earnings" json:"driver_earnings"`
+	IncentiveTopUp float64 `db:"incentive_top_up" json:"incentive_top_up"`
+	// Commission less incentive top-ups
+	NetRevenue      float64    `db:"net_revenue" json:"net_revenue"`
+	TakeRatePercent float64    `db:"-" json:"take_rate_percent"`
+	RefundedAt      *time.Time `db:"refunded_at" json:"refunded_at,omitempty"`
+	CreatedAt       time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt       time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// RegionTakeRate sums the economics of unrefunded rides in one region
+type RegionTakeRate struct {
+	RegionCode      *string `db:"region_code" json:"region_code,omitempty"`
+	Rides           int     `db:"rides" json:"rides"`
+	GrossFare       float64 `db:"gross_fare" json:"gross_fare"`
+	Discounts       float64 `db:"discounts" json:"discounts"`
+	RiderPaid       float64 `db:"rider_paid" json:"rider_paid"`
+	Commission      float64 `db:"commission" json:"commission"`
+	DriverEarnings  float64 `db:"driver_earnings" json:"driver_earnings"`
+	IncentiveTopUp  float64 `db:"incentive_top_up" json:"incentive_top_up"`
+	NetRevenue      float64 `db:"net_revenue" json:"net_revenue"`
+	TakeRatePercent float64 `db:"-" json:"take_rate_percent"`
+}
+
+type TakeRateReport struct {
+	From    time.Time         `json:"from"`
+	To      time.Time         `json:"to"`
+	Regions []*RegionTakeRate `json:"regions"`
+}
+
+// TakeRate is net revenue as a percentage of the gross fare
+func TakeRate(netRevenue, grossFare float64) float64 {
+	if grossFare <= 0 {
+		return 0
+	}
+	return math.Round(netRevenue/grossFare*10000) / 100
+}
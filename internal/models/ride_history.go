@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// RideHistoryFilter selects a rider's or driver's rides, newest first; zero values
+// are ignored. A page starts after the ride identified by AfterCreatedAt and AfterID.
+type RideHistoryFilter struct {
+	UserID         string
+	DriverID       string
+	Statuses       []string
+	From           *time.Time
+	To             *time.Time
+	AfterCreatedAt *time.Time
+	AfterID        string
+	Limit          int
+}
+
+// RideHistoryPage is one page of ride history. NextCursor is empty on the last page.
+type RideHistoryPage struct {
+	Rides      []*RideResponse `json:"rides"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
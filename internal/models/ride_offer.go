@@ -1,17 +1,32 @@
 package models
 
 import (
+	"strings"
 	"time"
+
+	"github.com/aditya/go-comet/internal/statemachine"
 )
 
 // Ride offer status constants
 const (
-	OfferStatusPending  = "pending"
-	OfferStatusAccepted = "accepted"
-	OfferStatusDeclined = "declined"
-	OfferStatusExpired  = "expired"
+	OfferStatusPending   = "pending"
+	OfferStatusAccepted  = "accepted"
+	OfferStatusDeclined  = "declined"
+	OfferStatusExpired   = "expired"
+	OfferStatusCountered = "countered" // bid mode: driver proposed a different fare
+	OfferStatusQueued    = "queued"    // accepted as the driver's next ride, activates when their trip ends
 )
 
+// OfferStates holds the valid offer status transitions
+var OfferStates = statemachine.New(AuditEntityOffer, map[string][]string{
+	OfferStatusPending:   {OfferStatusAccepted, OfferStatusQueued, OfferStatusCountered, OfferStatusDeclined, OfferStatusExpired},
+	OfferStatusCountered: {OfferStatusAccepted, OfferStatusExpired},
+	OfferStatusQueued:    {OfferStatusAccepted, OfferStatusExpired},
+	OfferStatusAccepted:  {},
+	OfferStatusDeclined:  {},
+	OfferStatusExpired:   {},
+})
+
 type RideOffer struct {
 	ID          string     `db:"id" json:"id"`
 	RideID      string     `db:"ride_id" json:"ride_id"`
@@ -20,6 +35,35 @@ type RideOffer struct {
 	OfferedAt   time.Time  `db:"offered_at" json:"offered_at"`
 	RespondedAt *time.Time `db:"responded_at" json:"responded_at,omitempty"`
 	ExpiresAt   time.Time  `db:"expires_at" json:"expires_at"`
+	OfferedFare *float64   `db:"offered_fare" json:"offered_fare,omitempty"`
+	CounteredAt *time.Time `db:"countered_at" json:"countered_at,omitempty"`
+	Chained     bool       `db:"chained" json:"chained"`
+	// Seconds the driver was given and the comma-separated rules that set it
+	TimeoutSeconds *int    `db:"timeout_seconds" json:"timeout_seconds,omitempty"`
+	TimeoutReasons *string `db:"timeout_reasons" json:"timeout_reasons,omitempty"`
+	// Matching funnel: the dispatch round that made the offer and when the driver first saw it
+	DispatchRoundID *string    `db:"dispatch_round_id" json:"-"`
+	ViewedAt        *time.Time `db:"viewed_at" json:"viewed_at,omitempty"`
+	// Milliseconds from the offer to the driver accepting, declining or countering it
+	ResponseMs *int `db:"response_ms" json:"response_ms,omitempty"`
+}
+
+// OfferResponseWeight is the share of a driver's typical response time given to
+// their latest response
+const OfferResponseWeight = 0.2
+
+// IsOfferResponse reports whether an offer moving between the statuses was
+// answered by its driver; expiry isn't an answer
+func IsOfferResponse(from, to string) bool {
+	return from == OfferStatusPending && (to == OfferStatusAccepted || to == OfferStatusQueued ||
+		to == OfferStatusDeclined || to == OfferStatusCountered)
+}
+
+// IsOfferTimeout reports whether an offer expiring at the given time ran out
+// unanswered. It counts as a response taking the whole timeout; offers withdrawn
+// early, e.g. because another driver took the ride, don't count.
+func IsOfferTimeout(o *RideOffer, to string, at time.Time) bool {
+	return o.Status == OfferStatusPending && to == OfferStatusExpired && !at.Before(o.ExpiresAt)
 }
 
 type AcceptRideRequest struct {
@@ -32,6 +76,10 @@ type RideOfferResponse struct {
 	RideID    string    `json:"ride_id"`
 	Status    string    `json:"status"`
 	ExpiresAt time.Time `json:"expires_at"`
+	OfferedFare *float64 `json:"offered_fare,omitempty"`
+	Chained   bool      `json:"chained,omitempty"`
+	TimeoutSeconds *int     `json:"timeout_seconds,omitempty"`
+	TimeoutReasons []string `json:"timeout_reasons,omitempty"`
 	Ride      *RideResponse `json:"ride,omitempty"`
 }
 
@@ -40,10 +88,17 @@ func (o *RideOffer) IsExpired() bool {
 }
 
 func (o *RideOffer) ToResponse() *RideOfferResponse {
-	return &RideOfferResponse{
+	response := &RideOfferResponse{
 		ID:        o.ID,
 		RideID:    o.RideID,
 		Status:    o.Status,
 		ExpiresAt: o.ExpiresAt,
+		OfferedFare: o.OfferedFare,
+		Chained:   o.Chained,
+		TimeoutSeconds: o.TimeoutSeconds,
+	}
+	if o.TimeoutReasons != nil && *o.TimeoutReasons != "" {
+		response.TimeoutReasons = strings.Split(*o.TimeoutReasons, ",")
 	}
+	return response
 }
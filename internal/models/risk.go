@@ -0,0 +1,98 @@
+package models
+
+import "time"
+
+// Payment risk rules
+const (
+	// A new account paying for a high-value ride with a card it hasn't used before
+	RiskRuleNewCardHighValue = "new_card_high_value"
+	// A new account booking above its fare limit without prepaying
+	RiskRuleNewAccountLimit = "new_account_limit"
+)
+
+// Risk event outcomes
+const (
+	RiskOutcomeBlocked    = "blocked"
+	RiskOutcomeOverridden = "overridden"
+)
+
+// RiskPolicy holds the thresholds for payment risk rules. Wallet rides are
+// prepaid and never blocked.
+type RiskPolicy struct {
+	// Accounts younger than this are new
+	NewAccountAge time.Duration
+	// Card rides from new accounts at or above this fare need a known card
+	HighValueFare float64
+	// Largest fare a new account can book without prepaying (0 disables)
+	NewAccountFareLimit float64
+}
+
+// RiskCheck describes a booking for the risk rules
+type RiskCheck struct {
+	AccountCreatedAt time.Time
+	PaymentMethod    string
+	// The card hasn't paid for a completed ride on this account
+	NewCard bool
+	Fare    float64
+}
+
+// Evaluate returns the rule the booking breaks, or "" when it is allowed
+func (p RiskPolicy) Evaluate(check RiskCheck, now time.Time) string {
+	if check.PaymentMethod == PaymentMethodWallet || now.Sub(check.AccountCreatedAt) >= p.NewAccountAge {
+		return ""
+	}
+	if check.PaymentMethod == PaymentMethodCard && check.NewCard && check.Fare >= p.HighValueFare {
+		return RiskRuleNewCardHighValue
+	}
+	if p.NewAccountFareLimit > 0 && check.Fare > p.NewAccountFareLimit {
+		return RiskRuleNewAccountLimit
+	}
+	return ""
+}
+
+// RiskOverride exempts a rider from the payment risk rules until it expires or is revoked
+type RiskOverride struct {
+	ID        string     `db:"id" json:"id"`
+	UserID    string     `db:"user_id" json:"user_id"`
+	Reason    string     `db:"reason" json:"reason"`
+	GrantedBy string     `db:"granted_by" json:"granted_by"`
+	ExpiresAt *time.Time `db:"expires_at" json:"expires_at,omitempty"`
+	RevokedAt *time.Time `db:"revoked_at" json:"revoked_at,omitempty"`
+	RevokedBy *string    `db:"revoked_by" json:"revoked_by,omitempty"`
+	CreatedAt time.Time  `db:"created_at" json:"created_at"`
+}
+
+// ActiveAt reports whether the override applies at the given moment
+func (o *RiskOverride) ActiveAt(at time.Time) bool {
+	return o.RevokedAt == nil && (o.ExpiresAt == nil || at.Before(*o.ExpiresAt))
+}
+
+type CreateRiskOverrideRequest struct {
+	Reason    string `json:"reason" validate:"required,max=500"`
+	GrantedBy string `json:"granted_by" validate:"required,max=100"`
+	// Open-ended when omitted
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+type RevokeRiskOverrideRequest struct {
+	RevokedBy string `json:"revoked_by" validate:"required,max=100"`
+}
+
+// RiskEvent records a booking a rule blocked or an override let through
+type RiskEvent struct {
+	ID            string    `db:"id" json:"id"`
+	UserID        string    `db:"user_id" json:"user_id"`
+	Rule          string    `db:"rule" json:"rule"`
+	Outcome       string    `db:"outcome" json:"outcome"`
+	PaymentMethod string    `db:"payment_method" json:"payment_method"`
+	Fare          float64   `db:"fare" json:"fare"`
+	OverrideID    *string   `db:"override_id" json:"override_id,omitempty"`
+	CreatedAt     time.Time `db:"created_at" json:"created_at"`
+}
+
+// UserRisk is a rider's override history and recent risk events
+type UserRisk struct {
+	UserID    string          `json:"user_id"`
+	Overrides []*RiskOverride `json:"overrides"`
+	Events    []*RiskEvent    `json:"events"`
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"hash/fnv"
+	"time"
+)
+
+// Rollout exposure sources
+const (
+	ExposureSourceCatalog = "catalog"
+	ExposureSourceBooking = "booking"
+)
+
+// RolloutExposure records a rider first being let into, or held back from, a
+// product soft-launched in their region
+type RolloutExposure struct {
+	ID         string    `db:"id" json:"id"`
+	RegionCode string    `db:"region_code" json:"region_code"`
+	Product    string    `db:"product" json:"product"`
+	UserID     string    `db:"user_id" json:"user_id"`
+	Enabled    bool      `db:"enabled" json:"enabled"`
+	Percent    float64   `db:"percent" json:"percent"`
+	Source     string    `db:"source" json:"source"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+}
+
+// RolloutSummary counts the riders exposed to a product's rollout in a region
+type RolloutSummary struct {
+	Product string  `db:"product" json:"product"`
+	Percent float64 `db:"-" json:"percent"`
+	// Riders let into the product, and riders held back who serve as the control group
+	EnabledRiders  int `db:"enabled_riders" json:"enabled_riders"`
+	HeldBackRiders int `db:"held_back_riders" json:"held_back_riders"`
+	// Riders in the product who went on to book it
+	BookedRiders int `db:"booked_riders" json:"booked_riders"`
+}
+
+// InRollout buckets the rider by a hash of the product and user id, so they get
+// the same answer on every request and raising the percent only adds riders
+func InRollout(percent float64, product, userID string) bool {
+	if percent >= 100 {
+		return true
+	}
+	if percent <= 0 || userID == "" {
+		return false
+	}
+	h := fnv.New32a()
+	h.Write([]byte(product + ":" + userID))
+	return float64(h.Sum32()%10000) < percent*100
+}
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"time"
+)
+
+// Gender values
+const (
+	GenderFemale = "female"
+	GenderMale   = "male"
+	GenderOther  = "other"
+)
+
+// Safety mode defaults, used where a region does not override them
+const (
+	DefaultSafetyMinDriverRating = 4.5
+	DefaultSafetyMinTenureDays   = 90
+)
+
+// SafetyCriteria are the requirements a driver must meet to be matched with a
+// rider who has safety mode enabled
+type SafetyCriteria struct {
+	MinRating float64
+	MinTenure time.Duration
+	Gender    string // empty means any
+}
+
+type UpdateSafetyPreferencesRequest struct {
+	SafetyMode            bool   `json:"safety_mode"`
+	PreferredDriverGender string `json:"preferred_driver_gender,omitempty" validate:"omitempty,oneof=female male"`
+}
+
+// SafetyCriteriaFor builds the criteria for a rider in a region (region may be
+// nil). Returns nil when the rider has not enabled safety mode. The gender
+// preference only applies where the region permits gender-based filtering.
+func SafetyCriteriaFor(user *User, region *Region) *SafetyCriteria {
+	if user == nil || !user.SafetyMode {
+		return nil
+	}
+
+	criteria := &SafetyCriteria{
+		MinRating: DefaultSafetyMinDriverRating,
+		MinTenure: DefaultSafetyMinTenureDays * 24 * time.Hour,
+	}
+	if region == nil {
+		return criteria
+	}
+
+	if region.Settings.SafetyMinDriverRating > 0 {
+		criteria.MinRating = region.Settings.SafetyMinDriverRating
+	}
+	if region.Settings.SafetyMinTenureDays > 0 {
+		criteria.MinTenure = time.Duration(region.Settings.SafetyMinTenureDays) * 24 * time.Hour
+	}
+	if region.Settings.SafetyGenderFilterAllowed && user.PreferredDriverGender != nil {
+		criteria.Gender = *user.PreferredDriverGender
+	}
+	return criteria
+}
+
+// Allows reports whether the driver meets the criteria: verified for at least
+// the minimum tenure, rated at or above the threshold, and of the requested gender
+func (c *SafetyCriteria) Allows(d *Driver, now time.Time) bool {
+	if d.VerifiedAt == nil || now.Sub(*d.VerifiedAt) < c.MinTenure {
+		return false
+	}
+	if d.Rating < c.MinRating {
+		return false
+	}
+	if c.Gender != "" && (d.Gender == nil || *d.Gender != c.Gender) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// SchedulePolicy bounds how far ahead rides may be booked and when they start matching
+type SchedulePolicy struct {
+	// Pickup must be at least this far from the time of booking
+	MinNotice time.Duration
+	// and at most this far
+	MaxAhead time.Duration
+	// Matching starts this long before the pickup time
+	Lead time.Duration
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+)
+
+// Selfie check status constants
+const (
+	SelfieCheckPassed   = "passed"
+	SelfieCheckFailed   = "failed"
+	SelfieCheckRecorded = "recorded" // stored without automated verification
+)
+
+// DefaultSelfieCheckValidity applies when a region requires checks without setting a validity
+const DefaultSelfieCheckValidity = 12 * time.Hour
+
+type SelfieCheck struct {
+	ID         string    `db:"id" json:"id"`
+	DriverID   string    `db:"driver_id" json:"driver_id"`
+	UploadID   string    `db:"upload_id" json:"upload_id"`
+	Status     string    `db:"status" json:"status"`
+	Provider   *string   `db:"provider" json:"provider,omitempty"`
+	MatchScore *float64  `db:"match_score" json:"match_score,omitempty"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+
+	// When the selfie upload was verified; the check's validity runs from here
+	CapturedAt *time.Time `db:"captured_at" json:"captured_at,omitempty"`
+}
+
+type SubmitSelfieRequest struct {
+	UploadID string `json:"upload_id" validate:"required,uuid"`
+}
+
+// IsValidAt reports whether the check lets the driver go online at the given
+// time. Recorded checks only count when selfies aren't matched automatically.
+func (c *SelfieCheck) IsValidAt(at time.Time, validity time.Duration, matched bool) bool {
+	switch c.Status {
+	case SelfieCheckPassed:
+	case SelfieCheckRecorded:
+		if matched {
+			return false
+		}
+	default:
+		return false
+	}
+	taken := c.CreatedAt
+	if c.CapturedAt != nil {
+		taken = *c.CapturedAt
+	}
+	return at.Sub(taken) <= validity
+}
@@ -0,0 +1,77 @@
+package models
+
+import "time"
+
+// Account types a session can belong to
+const (
+	SessionPrincipalUser   = "user"
+	SessionPrincipalDriver = "driver"
+)
+
+// Why a session stopped being valid
+const (
+	SessionRevokedSignOut      = "signed_out"
+	SessionRevokedReplaced     = "replaced"
+	SessionRevokedPhoneChanged = "phone_changed"
+)
+
+// Session is one signed-in device on a user or driver account
+type Session struct {
+	ID            string     `db:"id" json:"id"`
+	PrincipalType string     `db:"principal_type" json:"principal_type"`
+	PrincipalID   string     `db:"principal_id" json:"principal_id"`
+	TokenHash     string     `db:"token_hash" json:"-"`
+	DeviceID      string     `db:"device_id" json:"device_id"`
+	DeviceName    *string    `db:"device_name" json:"device_name,omitempty"`
+	Platform      *string    `db:"platform" json:"platform,omitempty"`
+	AppVersion    *string    `db:"app_version" json:"app_version,omitempty"`
+	CreatedAt     time.Time  `db:"created_at" json:"created_at"`
+	LastSeenAt    time.Time  `db:"last_seen_at" json:"last_seen_at"`
+	RevokedAt     *time.Time `db:"revoked_at" json:"revoked_at,omitempty"`
+	RevokedReason *string    `db:"revoked_reason" json:"revoked_reason,omitempty"`
+}
+
+func (s *Session) IsActive() bool {
+	return s.RevokedAt == nil
+}
+
+type CreateSessionRequest struct {
+	DeviceID   string `json:"device_id" validate:"required,max=100"`
+	DeviceName string `json:"device_name" validate:"omitempty,max=100"`
+	Platform   string `json:"platform" validate:"omitempty,oneof=android ios web"`
+	AppVersion string `json:"app_version" validate:"omitempty,max=20"`
+}
+
+// CreatedSession is returned once at sign-in; the token can't be retrieved again
+type CreatedSession struct {
+	Session *SessionResponse `json:"session"`
+	Token   string           `json:"token"`
+}
+
+type SessionResponse struct {
+	ID         string    `json:"id"`
+	DeviceID   string    `json:"device_id"`
+	DeviceName *string   `json:"device_name,omitempty"`
+	Platform   *string   `json:"platform,omitempty"`
+	AppVersion *string   `json:"app_version,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	// Set on the session the request was made with
+	Current bool `json:"current"`
+}
+
+func (s *Session) ToResponse() *SessionResponse {
+	return &SessionResponse{
+		ID:         s.ID,
+		DeviceID:   s.DeviceID,
+		DeviceName: s.DeviceName,
+		Platform:   s.Platform,
+		AppVersion: s.AppVersion,
+		CreatedAt:  s.CreatedAt,
+		LastSeenAt: s.LastSeenAt,
+	}
+}
+
+type ChangePhoneRequest struct {
+	Phone string `json:"phone" validate:"required,min=10,max=15"`
+}
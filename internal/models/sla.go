@@ -0,0 +1,100 @@
+package models
+
+import (
+	"time"
+)
+
+// SLA metrics
+const (
+	SLAMetricTimeToMatch     = "time_to_match"    // ride requested until a driver accepted
+	SLAMetricOfferAcceptance = "offer_acceptance" // offer sent until the driver accepted it
+	SLAMetricTimeToPickup    = "time_to_pickup"   // driver accepted until they arrived at pickup
+)
+
+// SLOTargets are the times riders and drivers should be served within, and the
+// share of rides that must meet them. Zero values fall back to the defaults.
+type SLOTargets struct {
+	MatchSeconds           int     `json:"match_seconds,omitempty" validate:"gte=0"`
+	OfferAcceptanceSeconds int     `json:"offer_acceptance_seconds,omitempty" validate:"gte=0"`
+	PickupSeconds          int     `json:"pickup_seconds,omitempty" validate:"gte=0"`
+	CompliancePercent      float64 `json:"compliance_percent,omitempty" validate:"gte=0,lte=100"`
+}
+
+// Or fills unset targets from defaults
+func (t *SLOTargets) Or(defaults SLOTargets) SLOTargets {
+	if t == nil {
+		return defaults
+	}
+	merged := *t
+	if merged.MatchSeconds == 0 {
+		merged.MatchSeconds = defaults.MatchSeconds
+	}
+	if merged.OfferAcceptanceSeconds == 0 {
+		merged.OfferAcceptanceSeconds = defaults.OfferAcceptanceSeconds
+	}
+	if merged.PickupSeconds == 0 {
+		merged.PickupSeconds = defaults.PickupSeconds
+	}
+	if merged.CompliancePercent == 0 {
+		merged.CompliancePercent = defaults.CompliancePercent
+	}
+	return merged
+}
+
+// SLAPolicy configures rolling SLA compliance
+type SLAPolicy struct {
+	Defaults SLOTargets
+	Window   time.Duration
+	// Metrics with fewer rides in the window are reported but never breach
+	MinEvents int
+}
+
+// SLAStats counts rides in a region that met each target over a window
+type SLAStats struct {
+	RegionCode        *string `db:"region_code"`
+	Matches           int     `db:"matches"`
+	MatchesWithin     int     `db:"matches_within"`
+	MatchP90Secs      float64 `db:"match_p90_secs"`
+	Acceptances       int     `db:"acceptances"`
+	AcceptancesWithin int     `db:"acceptances_within"`
+	AcceptanceP90Secs float64 `db:"acceptance_p90_secs"`
+	Pickups           int     `db:"pickups"`
+	PickupsWithin     int     `db:"pickups_within"`
+	PickupP90Secs     float64 `db:"pickup_p90_secs"`
+}
+
+// SLACompliance is how one metric fared against its target
+type SLACompliance struct {
+	Metric                  string  `json:"metric"`
+	TargetSeconds           int     `json:"target_seconds"`
+	Events                  int     `json:"events"`
+	WithinTarget            int     `json:"within_target"`
+	CompliancePercent       float64 `json:"compliance_percent"`
+	TargetCompliancePercent float64 `json:"target_compliance_percent"`
+	P90Seconds              float64 `json:"p90_seconds"`
+	Breached                bool    `json:"breached"`
+}
+
+type RegionSLA struct {
+	RegionCode *string          `json:"region_code,omitempty"`
+	Metrics    []*SLACompliance `json:"metrics"`
+}
+
+// Region names the region in metrics and alerts
+func (r *RegionSLA) Region() string {
+	if r.RegionCode == nil {
+		return "none"
+	}
+	return *r.RegionCode
+}
+
+// Label identifies a region's metric in metrics and alerts
+func (r *RegionSLA) Label(metric string) string {
+	return r.Region() + "/" + metric
+}
+
+type SLAReport struct {
+	From    time.Time    `json:"from"`
+	To      time.Time    `json:"to"`
+	Regions []*RegionSLA `json:"regions"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// SurgeZone is the live demand and supply for one vehicle type around a point
+type SurgeZone struct {
+	VehicleType string `json:"vehicle_type"`
+	// Ride requests in the zone within the demand window
+	Demand int `json:"demand"`
+	// Available drivers near the point
+	Supply     int     `json:"supply"`
+	Multiplier float64 `json:"multiplier"`
+}
+
+// SurgeInfo explains the surge a rider would be quoted at a point
+type SurgeInfo struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+	// Geohash cell of the point; demand is counted in it and its neighbours
+	Zone         string       `json:"zone"`
+	DemandWindow int          `json:"demand_window_seconds"`
+	SupplyRadius float64      `json:"supply_radius_km"`
+	VehicleTypes []*SurgeZone `json:"vehicle_types"`
+	CalculatedAt time.Time    `json:"calculated_at"`
+}
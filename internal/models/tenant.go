@@ -0,0 +1,130 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// DefaultTenant serves requests that don't identify a brand
+const DefaultTenant = "default"
+
+// Tenant is a white-label brand run from this deployment
+type Tenant struct {
+	Code       string         `db:"code" json:"code"`
+	Name       string         `db:"name" json:"name"`
+	APIKeyHash *string        `db:"api_key_hash" json:"-"`
+	Hosts      pq.StringArray `db:"hosts" json:"hosts"`
+	Branding   TenantBranding `db:"branding" json:"branding"`
+	// Fare overrides per vehicle type; unset fields use the platform fares
+	FareConfigs TenantFares `db:"fare_configs" json:"fare_configs"`
+	// PSP account the tenant's card payments go to; empty uses the platform's
+	PSPURL    *string   `db:"psp_url" json:"psp_url,omitempty"`
+	PSPAPIKey *string   `db:"psp_api_key" json:"-"`
+	Active    bool      `db:"active" json:"active"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// ServesHost reports whether requests to host belong to the tenant
+func (t *Tenant) ServesHost(host string) bool {
+	for _, h := range t.Hosts {
+		if h == host {
+			return true
+		}
+	}
+	return false
+}
+
+// TenantBranding is what client apps show for the brand
+type TenantBranding struct {
+	AppName      string `json:"app_name,omitempty" validate:"max=100"`
+	LogoURL      string `json:"logo_url,omitempty" validate:"omitempty,url"`
+	PrimaryColor string `json:"primary_color,omitempty" validate:"omitempty,hexcolor"`
+	SupportEmail string `json:"support_email,omitempty" validate:"omitempty,email"`
+	SupportPhone string `json:"support_phone,omitempty" validate:"max=20"`
+}
+
+func (b TenantBranding) Value() (driver.Value, error) {
+	return json.Marshal(b)
+}
+
+func (b *TenantBranding) Scan(src interface{}) error {
+	return scanJSON(src, b, "tenant branding")
+}
+
+// FareOverride replaces parts of a vehicle type's fare; zero values keep the platform fare
+type FareOverride struct {
+	BaseFare        float64 `json:"base_fare,omitempty" validate:"gte=0"`
+	PerKmRate       float64 `json:"per_km_rate,omitempty" validate:"gte=0"`
+	PerMinRate      float64 `json:"per_min_rate,omitempty" validate:"gte=0"`
+	MinFare         float64 `json:"min_fare,omitempty" validate:"gte=0"`
+	CancellationFee float64 `json:"cancellation_fee,omitempty" validate:"gte=0"`
+	WaitingPerMin   float64 `json:"waiting_per_min,omitempty" validate:"gte=0"`
+}
+
+// TenantFares maps vehicle type to the tenant's fare override
+type TenantFares map[string]FareOverride
+
+// VehicleTypes returns the vehicle types the tenant overrides fares for
+func (f TenantFares) VehicleTypes() []string {
+	types := make([]string, 0, len(f))
+	for vt := range f {
+		types = append(types, vt)
+	}
+	return types
+}
+
+func (f TenantFares) Value() (driver.Value, error) {
+	if f == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(f)
+}
+
+func (f *TenantFares) Scan(src interface{}) error {
+	return scanJSON(src, f, "tenant fares")
+}
+
+func scanJSON(src, dest interface{}, what string) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, dest)
+	case string:
+		return json.Unmarshal([]byte(v), dest)
+	case nil:
+		return nil
+	default:
+		return fmt.Errorf("unsupported %s type %T", what, src)
+	}
+}
+
+type CreateTenantRequest struct {
+	Code        string         `json:"code" validate:"required,max=50,alphanum,lowercase"`
+	Name        string         `json:"name" validate:"required,max=100"`
+	Hosts       []string       `json:"hosts,omitempty" validate:"omitempty,dive,hostname"`
+	Branding    TenantBranding `json:"branding"`
+	FareConfigs TenantFares    `json:"fare_configs,omitempty" validate:"omitempty,dive,keys,max=30,endkeys"`
+	PSPURL      string         `json:"psp_url,omitempty" validate:"omitempty,url"`
+	PSPAPIKey   string         `json:"psp_api_key,omitempty" validate:"required_with=PSPURL"`
+}
+
+// UpdateTenantRequest replaces the tenant's settings; the API key is rotated separately
+type UpdateTenantRequest struct {
+	Name        string         `json:"name" validate:"required,max=100"`
+	Hosts       []string       `json:"hosts,omitempty" validate:"omitempty,dive,hostname"`
+	Branding    TenantBranding `json:"branding"`
+	FareConfigs TenantFares    `json:"fare_configs,omitempty" validate:"omitempty,dive,keys,max=30,endkeys"`
+	PSPURL      string         `json:"psp_url,omitempty" validate:"omitempty,url"`
+	PSPAPIKey   string         `json:"psp_api_key,omitempty" validate:"required_with=PSPURL"`
+	Active      bool           `json:"active"`
+}
+
+// TenantCredentials is returned once when a tenant's API key is issued
+type TenantCredentials struct {
+	Tenant *Tenant `json:"tenant"`
+	APIKey string  `json:"api_key"`
+}
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// Training modules drivers complete before taking part in newer products
+const (
+	TrainingPool         = "pool"
+	TrainingIntercity    = "intercity"
+	TrainingEVIncentives = "ev_incentives"
+)
+
+// TrainingModules lists every module the training module can report on
+var TrainingModules = []string{TrainingPool, TrainingIntercity, TrainingEVIncentives}
+
+// IsTrainingModule reports whether module is a known training module
+func IsTrainingModule(module string) bool {
+	for _, m := range TrainingModules {
+		if m == module {
+			return true
+		}
+	}
+	return false
+}
+
+type DriverTraining struct {
+	DriverID    string    `db:"driver_id" json:"driver_id"`
+	Module      string    `db:"module" json:"module"`
+	Score       *float64  `db:"score" json:"score,omitempty"`
+	CompletedAt time.Time `db:"completed_at" json:"completed_at"`
+}
+
+// RecordTrainingRequest is sent by the training module when a driver passes or
+// loses (e.g. after a content update) a module
+type RecordTrainingRequest struct {
+	Completed bool     `json:"completed"`
+	Score     *float64 `json:"score,omitempty" validate:"omitempty,gte=0,lte=100"`
+}
+
+// DriverTrainingStatus lists the modules a driver has completed and those still to do
+type DriverTrainingStatus struct {
+	DriverID  string            `json:"driver_id"`
+	Completed []*DriverTraining `json:"completed"`
+	Pending   []string          `json:"pending"`
+}
@@ -1,24 +1,43 @@
 package models
 
 import (
+	"math"
 	"time"
+
+	"github.com/aditya/go-comet/internal/statemachine"
 )
 
 // Trip status constants
 const (
 	TripStatusStarted   = "started"
 	TripStatusPaused    = "paused"
+	TripStatusHandover  = "handover" // frozen after a breakdown until a rescue driver takes over
 	TripStatusCompleted = "completed"
 	TripStatusCancelled = "cancelled"
 )
 
-// Valid trip state transitions
-var ValidTripTransitions = map[string][]string{
-	TripStatusStarted:   {TripStatusPaused, TripStatusCompleted, TripStatusCancelled},
-	TripStatusPaused:    {TripStatusStarted, TripStatusCompleted, TripStatusCancelled},
+// Mileage audit status constants
+const (
+	MileageStatusOK       = "ok"
+	MileageStatusFlagged  = "flagged"
+	MileageStatusApproved = "approved"
+	MileageStatusRejected = "rejected"
+)
+
+// Odometer reading stages
+const (
+	OdometerStageStart = "start"
+	OdometerStageEnd   = "end"
+)
+
+// TripStates holds the valid trip status transitions
+var TripStates = statemachine.New(AuditEntityTrip, map[string][]string{
+	TripStatusStarted:   {TripStatusPaused, TripStatusHandover, TripStatusCompleted, TripStatusCancelled},
+	TripStatusPaused:    {TripStatusStarted, TripStatusHandover, TripStatusCompleted, TripStatusCancelled},
+	TripStatusHandover:  {TripStatusStarted, TripStatusCancelled},
 	TripStatusCompleted: {},
 	TripStatusCancelled: {},
-}
+})
 
 type Trip struct {
 	ID                string     `db:"id" json:"id"`
@@ -39,6 +58,50 @@ type Trip struct {
 	TotalFare         *float64   `db:"total_fare" json:"total_fare,omitempty"`
 	CreatedAt         time.Time  `db:"created_at" json:"created_at"`
 	UpdatedAt         time.Time  `db:"updated_at" json:"updated_at"`
+
+	// Mileage audit
+	GPSDistanceKm         float64  `db:"gps_distance_km" json:"gps_distance_km"`
+	StartOdometerKm       *float64 `db:"start_odometer_km" json:"start_odometer_km,omitempty"`
+	StartOdometerUploadID *string  `db:"start_odometer_upload_id" json:"start_odometer_upload_id,omitempty"`
+	EndOdometerKm         *float64 `db:"end_odometer_km" json:"end_odometer_km,omitempty"`
+	EndOdometerUploadID   *string  `db:"end_odometer_upload_id" json:"end_odometer_upload_id,omitempty"`
+	MileageStatus         *string  `db:"mileage_status" json:"mileage_status,omitempty"`
+	MileageDiscrepancyKm  *float64 `db:"mileage_discrepancy_km" json:"mileage_discrepancy_km,omitempty"`
+	MileageReviewNote     *string  `db:"mileage_review_note" json:"mileage_review_note,omitempty"`
+
+	CO2Grams   *float64 `db:"co2_grams" json:"co2_grams,omitempty"`
+	EVDiscount *float64 `db:"ev_discount" json:"ev_discount,omitempty"`
+	WaitingFee *float64 `db:"waiting_fee" json:"waiting_fee,omitempty"`
+
+	PackageSurcharge       *float64 `db:"package_surcharge" json:"package_surcharge,omitempty"`
+	DeclaredValueSurcharge *float64 `db:"declared_value_surcharge" json:"declared_value_surcharge,omitempty"`
+
+	EventSurcharge *float64   `db:"event_surcharge" json:"event_surcharge,omitempty"`
+	PricingEvents  FareEvents `db:"pricing_events" json:"pricing_events,omitempty"`
+
+	PromoDiscount *float64 `db:"promo_discount" json:"promo_discount,omitempty"`
+
+	// Set once the trip's GPS detail and personal data were removed under the
+	// region's data retention policy
+	AnonymizedAt *time.Time `db:"anonymized_at" json:"anonymized_at,omitempty"`
+
+	// When the driver confirmed helmets at the start of a trip that needs them
+	HelmetConfirmedAt *time.Time `db:"helmet_confirmed_at" json:"helmet_confirmed_at,omitempty"`
+}
+
+// MileageTolerance is how far the odometer distance may drift from GPS before a
+// trip is flagged: the larger of an absolute allowance and a share of GPS distance.
+type MileageTolerance struct {
+	Km      float64
+	Percent float64
+}
+
+func (t MileageTolerance) Allowed(gpsKm float64) float64 {
+	pct := gpsKm * t.Percent / 100
+	if pct > t.Km {
+		return pct
+	}
+	return t.Km
 }
 
 type FareBreakdown struct {
@@ -46,7 +109,37 @@ type FareBreakdown struct {
 	DistanceFare float64 `json:"distance_fare"`
 	TimeFare     float64 `json:"time_fare"`
 	SurgeAmount  float64 `json:"surge_amount"`
-	Total        float64 `json:"total"`
+	EVDiscount   float64 `json:"ev_discount,omitempty"`
+	WaitingFee   float64 `json:"waiting_fee,omitempty"`
+	// Delivery surcharges for the parcel's size class and declared value
+	PackageSurcharge       float64 `json:"package_surcharge,omitempty"`
+	DeclaredValueSurcharge float64 `json:"declared_value_surcharge,omitempty"`
+	// Holiday and event pricing from the pricing calendar, with the events behind it
+	EventSurcharge float64    `json:"event_surcharge,omitempty"`
+	Events         FareEvents `json:"events,omitempty"`
+	// Taken off by the rider's promo code
+	PromoDiscount float64 `json:"promo_discount,omitempty"`
+	Total         float64 `json:"total"`
+}
+
+// FareTick is the running fare of a trip in progress, as ending the trip at At
+// would charge it. Fixed is set for fares agreed in bid mode, which don't run.
+type FareTick struct {
+	TripID       string         `json:"trip_id"`
+	RideID       string         `json:"ride_id"`
+	DistanceKm   float64        `json:"distance_km"`
+	DurationMins int            `json:"duration_mins"`
+	Fare         *FareBreakdown `json:"fare"`
+	Fixed        bool           `json:"fixed,omitempty"`
+	Paused       bool           `json:"paused,omitempty"`
+	At           time.Time      `json:"at"`
+}
+
+type StartTripRequest struct {
+	RideID string `json:"ride_id" validate:"required,uuid"`
+	// The driver checked that they and the rider wear helmets; required for
+	// vehicle types that need them
+	HelmetConfirmed bool `json:"helmet_confirmed,omitempty"`
 }
 
 type EndTripRequest struct {
@@ -55,6 +148,17 @@ type EndTripRequest struct {
 	OdometerKm *float64 `json:"odometer_km,omitempty"`
 }
 
+type RecordOdometerRequest struct {
+	Stage     string  `json:"stage" validate:"required,oneof=start end"`
+	ReadingKm float64 `json:"reading_km" validate:"gte=0"`
+	UploadID  string  `json:"upload_id" validate:"required,uuid"`
+}
+
+type ReviewMileageRequest struct {
+	Decision string `json:"decision" validate:"required,oneof=approved rejected"`
+	Note     string `json:"note,omitempty"`
+}
+
 type TripResponse struct {
 	ID                string         `json:"id"`
 	RideID            string         `json:"ride_id"`
@@ -64,6 +168,15 @@ type TripResponse struct {
 	ActualDistanceKm  *float64       `json:"actual_distance_km,omitempty"`
 	ActualDurationMin *int           `json:"actual_duration_mins,omitempty"`
 	FareBreakdown     *FareBreakdown `json:"fare_breakdown,omitempty"`
+	GPSDistanceKm     float64        `json:"gps_distance_km"`
+	MileageStatus     *string        `json:"mileage_status,omitempty"`
+	CO2Grams          *float64       `json:"co2_grams,omitempty"`
+	HelmetConfirmedAt *time.Time     `json:"helmet_confirmed_at,omitempty"`
+	NextRideID        *string        `json:"next_ride_id,omitempty"`
+	// Credited to the driver when the trip fell short of a minimum-earnings guarantee
+	IncentiveTopUp *float64 `json:"incentive_top_up,omitempty"`
+	// Set when the trip pushed the driver over the back-to-back driving limit
+	DriverCooldown *DriverCooldown `json:"driver_cooldown,omitempty"`
 }
 
 func (t *Trip) ToResponse() *TripResponse {
@@ -75,34 +188,61 @@ func (t *Trip) ToResponse() *TripResponse {
 		EndTime:           t.EndTime,
 		ActualDistanceKm:  t.ActualDistanceKm,
 		ActualDurationMin: t.ActualDurationMin,
+		GPSDistanceKm:     t.GPSDistanceKm,
+		MileageStatus:     t.MileageStatus,
+		CO2Grams:          t.CO2Grams,
+		HelmetConfirmedAt: t.HelmetConfirmedAt,
 	}
 
 	if t.TotalFare != nil {
 		resp.FareBreakdown = &FareBreakdown{
-			BaseFare:     ptrToFloat(t.BaseFare),
-			DistanceFare: ptrToFloat(t.DistanceFare),
-			TimeFare:     ptrToFloat(t.TimeFare),
-			SurgeAmount:  ptrToFloat(t.SurgeAmount),
-			Total:        *t.TotalFare,
+			BaseFare:               ptrToFloat(t.BaseFare),
+			DistanceFare:           ptrToFloat(t.DistanceFare),
+			TimeFare:               ptrToFloat(t.TimeFare),
+			SurgeAmount:            ptrToFloat(t.SurgeAmount),
+			EVDiscount:             ptrToFloat(t.EVDiscount),
+			WaitingFee:             ptrToFloat(t.WaitingFee),
+			PackageSurcharge:       ptrToFloat(t.PackageSurcharge),
+			DeclaredValueSurcharge: ptrToFloat(t.DeclaredValueSurcharge),
+			EventSurcharge:         ptrToFloat(t.EventSurcharge),
+			Events:                 t.PricingEvents,
+			PromoDiscount:          ptrToFloat(t.PromoDiscount),
+			Total:                  *t.TotalFare,
 		}
 	}
 
 	return resp
 }
 
-// CanTransitionTo checks if a trip can transition to a new status
-func (t *Trip) CanTransitionTo(newStatus string) bool {
-	validNextStates, exists := ValidTripTransitions[t.Status]
-	if !exists {
-		return false
+// OdometerDistanceKm returns the distance claimed by the start and end odometer
+// readings, or nil if either is missing
+func (t *Trip) OdometerDistanceKm() *float64 {
+	if t.StartOdometerKm == nil || t.EndOdometerKm == nil {
+		return nil
 	}
+	d := *t.EndOdometerKm - *t.StartOdometerKm
+	return &d
+}
 
-	for _, state := range validNextStates {
-		if state == newStatus {
-			return true
-		}
+// AuditMileage compares the claimed distance with GPS distance and sets the
+// mileage status. A status already decided by an admin is left untouched.
+func (t *Trip) AuditMileage(claimedKm float64, tolerance MileageTolerance) {
+	if t.MileageStatus != nil && (*t.MileageStatus == MileageStatusApproved || *t.MileageStatus == MileageStatusRejected) {
+		return
+	}
+
+	discrepancy := claimedKm - t.GPSDistanceKm
+	status := MileageStatusOK
+	if math.Abs(discrepancy) > tolerance.Allowed(t.GPSDistanceKm) {
+		status = MileageStatusFlagged
 	}
-	return false
+	t.MileageDiscrepancyKm = &discrepancy
+	t.MileageStatus = &status
+}
+
+// CanTransitionTo checks if a trip can transition to a new status
+func (t *Trip) CanTransitionTo(newStatus string) bool {
+	return TripStates.Can(t.Status, newStatus)
 }
 
 func ptrToFloat(f *float64) float64 {
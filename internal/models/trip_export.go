@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+)
+
+// Trip export status constants
+const (
+	TripExportStatusPending    = "pending"
+	TripExportStatusProcessing = "processing"
+	TripExportStatusReady      = "ready"
+	TripExportStatusFailed     = "failed"
+)
+
+// TripExport is a CSV of a driver's completed trips in a calendar year
+type TripExport struct {
+	ID          string     `db:"id" json:"id"`
+	DriverID    string     `db:"driver_id" json:"driver_id"`
+	Year        int        `db:"year" json:"year"`
+	Status      string     `db:"status" json:"status"`
+	ObjectKey   *string    `db:"object_key" json:"-"`
+	TripCount   *int       `db:"trip_count" json:"trip_count,omitempty"`
+	Error       *string    `db:"error" json:"error,omitempty"`
+	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
+	StartedAt   *time.Time `db:"started_at" json:"started_at,omitempty"`
+	CompletedAt *time.Time `db:"completed_at" json:"completed_at,omitempty"`
+
+	// Set on ready exports; the link stops working at DownloadExpiresAt
+	DownloadURL       *string    `db:"-" json:"download_url,omitempty"`
+	DownloadExpiresAt *time.Time `db:"-" json:"download_expires_at,omitempty"`
+}
+
+// TripExportRow is one completed trip with its payment, as exported
+type TripExportRow struct {
+	TripID             string     `db:"trip_id"`
+	RideID             string     `db:"ride_id"`
+	StartTime          *time.Time `db:"start_time"`
+	EndTime            *time.Time `db:"end_time"`
+	VehicleType        string     `db:"vehicle_type"`
+	PickupAddress      *string    `db:"pickup_address"`
+	DropoffAddress     *string    `db:"dropoff_address"`
+	DistanceKm         *float64   `db:"actual_distance_km"`
+	DurationMins       *int       `db:"actual_duration_mins"`
+	TotalFare          *float64   `db:"total_fare"`
+	PaymentMethod      *string    `db:"payment_method"`
+	PaidAmount         *float64   `db:"paid_amount"`
+	PaidAt             *time.Time `db:"paid_at"`
+	CarbonOffsetAmount *float64   `db:"carbon_offset_amount"`
+	CommissionPercent  *float64   `db:"commission_percent"`
+	CommissionAmount   *float64   `db:"commission_amount"`
+	DriverEarnings     *float64   `db:"driver_earnings"`
+}
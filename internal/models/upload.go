@@ -0,0 +1,127 @@
+package models
+
+import (
+	"time"
+)
+
+// Upload status constants
+const (
+	UploadStatusPending  = "pending"
+	UploadStatusVerified = "verified"
+	UploadStatusRejected = "rejected"
+)
+
+// Upload owner types
+const (
+	UploadOwnerUser   = "user"
+	UploadOwnerDriver = "driver"
+	UploadOwnerRide   = "ride"
+)
+
+// Upload purposes
+const (
+	UploadPurposeProfilePhoto   = "profile_photo"
+	UploadPurposeDriverDocument = "driver_document"
+	UploadPurposeLostItemPhoto  = "lost_item_photo"
+	UploadPurposeDriverSelfie   = "driver_selfie"
+	UploadPurposeOdometerPhoto  = "odometer_photo"
+	UploadPurposeDeliveryPhoto  = "delivery_photo"
+)
+
+// UploadPolicy constrains what may be uploaded for a purpose
+type UploadPolicy struct {
+	OwnerTypes   []string
+	ContentTypes []string
+	MaxSizeBytes int64
+}
+
+var imageTypes = []string{"image/jpeg", "image/png", "image/webp"}
+
+// UploadPolicies maps each purpose to its allowed owners, MIME types and size
+var UploadPolicies = map[string]UploadPolicy{
+	UploadPurposeProfilePhoto: {
+		OwnerTypes:   []string{UploadOwnerUser, UploadOwnerDriver},
+		ContentTypes: imageTypes,
+		MaxSizeBytes: 5 << 20,
+	},
+	UploadPurposeDriverDocument: {
+		OwnerTypes:   []string{UploadOwnerDriver},
+		ContentTypes: append([]string{"application/pdf"}, imageTypes...),
+		MaxSizeBytes: 10 << 20,
+	},
+	UploadPurposeLostItemPhoto: {
+		OwnerTypes:   []string{UploadOwnerRide},
+		ContentTypes: imageTypes,
+		MaxSizeBytes: 5 << 20,
+	},
+	UploadPurposeDriverSelfie: {
+		OwnerTypes:   []string{UploadOwnerDriver},
+		ContentTypes: imageTypes,
+		MaxSizeBytes: 5 << 20,
+	},
+	UploadPurposeOdometerPhoto: {
+		OwnerTypes:   []string{UploadOwnerDriver},
+		ContentTypes: imageTypes,
+		MaxSizeBytes: 5 << 20,
+	},
+	UploadPurposeDeliveryPhoto: {
+		OwnerTypes:   []string{UploadOwnerRide},
+		ContentTypes: imageTypes,
+		MaxSizeBytes: 5 << 20,
+	},
+}
+
+func (p UploadPolicy) AllowsOwner(ownerType string) bool {
+	return containsString(p.OwnerTypes, ownerType)
+}
+
+func (p UploadPolicy) AllowsContentType(contentType string) bool {
+	return containsString(p.ContentTypes, contentType)
+}
+
+type Upload struct {
+	ID           string     `db:"id" json:"id"`
+	OwnerType    string     `db:"owner_type" json:"owner_type"`
+	OwnerID      string     `db:"owner_id" json:"owner_id"`
+	Purpose      string     `db:"purpose" json:"purpose"`
+	ObjectKey    string     `db:"object_key" json:"object_key"`
+	ContentType  string     `db:"content_type" json:"content_type"`
+	MaxSizeBytes int64      `db:"max_size_bytes" json:"max_size_bytes"`
+	SizeBytes    *int64     `db:"size_bytes" json:"size_bytes,omitempty"`
+	Status       string     `db:"status" json:"status"`
+	RejectReason *string    `db:"reject_reason" json:"reject_reason,omitempty"`
+	VerifiedAt   *time.Time `db:"verified_at" json:"verified_at,omitempty"`
+	CreatedAt    time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt    time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+type CreateUploadRequest struct {
+	Purpose     string `json:"purpose" validate:"required"`
+	OwnerType   string `json:"owner_type" validate:"required,oneof=user driver ride"`
+	OwnerID     string `json:"owner_id" validate:"required,uuid"`
+	ContentType string `json:"content_type" validate:"required"`
+	SizeBytes   int64  `json:"size_bytes" validate:"required,gt=0"`
+}
+
+func containsString(values []string, v string) bool {
+	for _, candidate := range values {
+		if candidate == v {
+			return true
+		}
+	}
+	return false
+}
+
+// UploadTicket is returned when an upload is created: the client PUTs the file to UploadURL
+type UploadTicket struct {
+	Upload    *Upload           `json:"upload"`
+	Method    string            `json:"method"`
+	UploadURL string            `json:"upload_url"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+type UploadResponse struct {
+	*Upload
+	DownloadURL string `json:"download_url,omitempty"`
+}
@@ -12,12 +12,19 @@ type User struct {
 	Rating    float64   `db:"rating" json:"rating"`
 	CreatedAt time.Time `db:"created_at" json:"created_at"`
 	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+
+	Gender                *string `db:"gender" json:"gender,omitempty"`
+	SafetyMode            bool    `db:"safety_mode" json:"safety_mode"`
+	PreferredDriverGender *string `db:"preferred_driver_gender" json:"preferred_driver_gender,omitempty"`
+
+	TenantCode string `db:"tenant_code" json:"tenant_code"`
 }
 
 type CreateUserRequest struct {
-	Phone string `json:"phone" validate:"required,min=10,max=15"`
-	Name  string `json:"name" validate:"required,min=2,max=100"`
-	Email string `json:"email,omitempty" validate:"omitempty,email"`
+	Phone  string `json:"phone" validate:"required,min=10,max=15"`
+	Name   string `json:"name" validate:"required,min=2,max=100"`
+	Email  string `json:"email,omitempty" validate:"omitempty,email"`
+	Gender string `json:"gender,omitempty" validate:"omitempty,oneof=female male other"`
 }
 
 type UserResponse struct {
@@ -26,6 +33,13 @@ type UserResponse struct {
 	Name   string  `json:"name"`
 	Email  *string `json:"email,omitempty"`
 	Rating float64 `json:"rating"`
+
+	SafetyMode            bool    `json:"safety_mode"`
+	PreferredDriverGender *string `json:"preferred_driver_gender,omitempty"`
+
+	// Required terms to accept before booking; addenda for the pickup region are
+	// reported when booking there
+	PendingConsents []*LegalDocument `json:"pending_consents,omitempty"`
 }
 
 func (u *User) ToResponse() *UserResponse {
@@ -35,5 +49,8 @@ func (u *User) ToResponse() *UserResponse {
 		Name:   u.Name,
 		Email:  u.Email,
 		Rating: u.Rating,
+
+		SafetyMode:            u.SafetyMode,
+		PreferredDriverGender: u.PreferredDriverGender,
 	}
 }
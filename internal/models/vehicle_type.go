@@ -0,0 +1,124 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// VehicleTypeDefinition is an entry in the vehicle type registry. Drivers sign
+// up with, riders book and fares are priced by one of these codes; the
+// VehicleType* constants are the types every deployment starts with.
+type VehicleTypeDefinition struct {
+	Code     string `db:"code" json:"code"`
+	Label    string `db:"label" json:"label"`
+	Capacity int    `db:"capacity" json:"capacity"`
+	// Icon name or URL for client apps
+	Icon string `db:"icon" json:"icon"`
+	// Whether the type can be booked and driven; Regions overrides it per region
+	Active  bool               `db:"active" json:"active"`
+	Regions VehicleTypeRegions `db:"regions" json:"regions,omitempty"`
+	// Display order in client apps and fare lists
+	SortOrder int `db:"sort_order" json:"sort_order"`
+	// Trips start only once the driver confirms everyone wears a helmet
+	HelmetRequired bool `db:"helmet_required" json:"helmet_required"`
+	VehicleFares   `json:"fares"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// IsBikeClass reports whether the type is a bike or rides like one: a single
+// seat, or a helmet required
+func (v *VehicleTypeDefinition) IsBikeClass() bool {
+	return v.Code == VehicleTypeBike || v.Capacity <= 1 || v.HelmetRequired
+}
+
+// ActiveIn reports whether the type can be booked in the region. With no region
+// it reports whether it can be booked anywhere.
+func (v *VehicleTypeDefinition) ActiveIn(regionCode string) bool {
+	if regionCode == "" {
+		for _, active := range v.Regions {
+			if active {
+				return true
+			}
+		}
+		return v.Active
+	}
+	if active, ok := v.Regions[regionCode]; ok {
+		return active
+	}
+	return v.Active
+}
+
+// VehicleFares is the platform fare for a vehicle type, before tenant overrides
+type VehicleFares struct {
+	BaseFare        float64 `db:"base_fare" json:"base_fare" validate:"gte=0"`
+	PerKmRate       float64 `db:"per_km_rate" json:"per_km_rate" validate:"gte=0"`
+	PerMinRate      float64 `db:"per_min_rate" json:"per_min_rate" validate:"gte=0"`
+	MinFare         float64 `db:"min_fare" json:"min_fare" validate:"gte=0"`
+	CancellationFee float64 `db:"cancellation_fee" json:"cancellation_fee" validate:"gte=0"`
+	WaitingPerMin   float64 `db:"waiting_per_min" json:"waiting_per_min" validate:"gte=0"`
+}
+
+// VehicleTypeRegions maps region code to whether the type is active there
+type VehicleTypeRegions map[string]bool
+
+func (r VehicleTypeRegions) Value() (driver.Value, error) {
+	if r == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(r)
+}
+
+func (r *VehicleTypeRegions) Scan(src interface{}) error {
+	return scanJSON(src, r, "vehicle type regions")
+}
+
+// DefaultVehicleTypes are the registry rows the migrations insert
+var DefaultVehicleTypes = []VehicleTypeDefinition{
+	{Code: VehicleTypeBike, Label: "Bike", Capacity: 1, Icon: "bike", Active: true, SortOrder: 0, HelmetRequired: true,
+		VehicleFares: VehicleFares{BaseFare: 15, PerKmRate: 6, PerMinRate: 0.5, MinFare: 20, CancellationFee: 10, WaitingPerMin: 0.5}},
+	{Code: VehicleTypeAuto, Label: "Auto", Capacity: 3, Icon: "auto", Active: true, SortOrder: 1,
+		VehicleFares: VehicleFares{BaseFare: 25, PerKmRate: 12, PerMinRate: 1.0, MinFare: 30, CancellationFee: 25, WaitingPerMin: 1.0}},
+	{Code: VehicleTypeMini, Label: "Mini", Capacity: 4, Icon: "mini", Active: true, SortOrder: 2,
+		VehicleFares: VehicleFares{BaseFare: 40, PerKmRate: 14, PerMinRate: 1.2, MinFare: 50, CancellationFee: 40, WaitingPerMin: 1.5}},
+	{Code: VehicleTypeSedan, Label: "Sedan", Capacity: 4, Icon: "sedan", Active: true, SortOrder: 3,
+		VehicleFares: VehicleFares{BaseFare: 50, PerKmRate: 17, PerMinRate: 1.5, MinFare: 80, CancellationFee: 50, WaitingPerMin: 2.0}},
+	{Code: VehicleTypeSUV, Label: "SUV", Capacity: 6, Icon: "suv", Active: true, SortOrder: 4,
+		VehicleFares: VehicleFares{BaseFare: 80, PerKmRate: 22, PerMinRate: 2.0, MinFare: 120, CancellationFee: 80, WaitingPerMin: 2.5}},
+}
+
+// FindDefaultVehicleType returns the built-in registry row for a code
+func FindDefaultVehicleType(code string) (VehicleTypeDefinition, bool) {
+	for _, v := range DefaultVehicleTypes {
+		if v.Code == code {
+			return v, true
+		}
+	}
+	return VehicleTypeDefinition{}, false
+}
+
+type CreateVehicleTypeRequest struct {
+	Code     string `json:"code" validate:"required,max=30,alphanum,lowercase"`
+	Label    string `json:"label" validate:"required,max=50"`
+	Capacity int    `json:"capacity" validate:"required,gte=1,lte=50"`
+	Icon     string `json:"icon,omitempty" validate:"max=255"`
+	// Defaults to true; launch in a few regions with false and regions set
+	Active         *bool              `json:"active,omitempty"`
+	Regions        VehicleTypeRegions `json:"regions,omitempty" validate:"omitempty,dive,keys,max=50,endkeys"`
+	SortOrder      int                `json:"sort_order,omitempty"`
+	HelmetRequired bool               `json:"helmet_required,omitempty"`
+	Fares          VehicleFares       `json:"fares"`
+}
+
+// UpdateVehicleTypeRequest replaces everything but the code
+type UpdateVehicleTypeRequest struct {
+	Label          string             `json:"label" validate:"required,max=50"`
+	Capacity       int                `json:"capacity" validate:"required,gte=1,lte=50"`
+	Icon           string             `json:"icon,omitempty" validate:"max=255"`
+	Active         bool               `json:"active"`
+	Regions        VehicleTypeRegions `json:"regions,omitempty" validate:"omitempty,dive,keys,max=50,endkeys"`
+	SortOrder      int                `json:"sort_order,omitempty"`
+	HelmetRequired bool               `json:"helmet_required,omitempty"`
+	Fares          VehicleFares       `json:"fares"`
+}
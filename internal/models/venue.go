@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+)
+
+// Venue is a place like an airport terminal or mall where pickups happen at
+// named points (gates, pillars, bays) rather than wherever the rider stands
+type Venue struct {
+	ID        string      `db:"id" json:"id"`
+	Name      string      `db:"name" json:"name"`
+	Polygon   ServiceArea `db:"polygon" json:"polygon"`
+	Active    bool        `db:"active" json:"active"`
+	CreatedAt time.Time   `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time   `db:"updated_at" json:"updated_at"`
+
+	Points []*PickupSpot `db:"-" json:"points"`
+}
+
+// VenueInfo identifies the venue a pickup falls in
+type VenueInfo struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type VenuePointRequest struct {
+	Name string  `json:"name" validate:"required,max=100"`
+	Kind string  `json:"kind,omitempty" validate:"omitempty,oneof=entrance landmark bay"`
+	Lat  float64 `json:"lat" validate:"required,latitude"`
+	Lng  float64 `json:"lng" validate:"required,longitude"`
+}
+
+type CreateVenueRequest struct {
+	Name    string               `json:"name" validate:"required,max=100"`
+	Polygon ServiceArea          `json:"polygon" validate:"required,min=3,dive"`
+	Points  []*VenuePointRequest `json:"points" validate:"required,min=1,dive"`
+}
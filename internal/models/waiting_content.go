@@ -0,0 +1,89 @@
+package models
+
+import "time"
+
+// Waiting screen content kinds
+const (
+	WaitingContentSafetyTip   = "safety_tip"
+	WaitingContentPromoBanner = "promo_banner"
+)
+
+// WaitingContent is a safety tip or promo banner shown to riders while they
+// wait for a driver. Content without a region is shown everywhere.
+type WaitingContent struct {
+	ID         string  `db:"id" json:"id"`
+	Kind       string  `db:"kind" json:"kind"`
+	RegionCode *string `db:"region_code" json:"region_code,omitempty"`
+	Title      string  `db:"title" json:"title"`
+	Body       string  `db:"body" json:"body"`
+	ImageURL   *string `db:"image_url" json:"image_url,omitempty"`
+	ActionURL  *string `db:"action_url" json:"action_url,omitempty"`
+	// A banner advertising a promo is hidden once the promo can't be used
+	PromoCode *string `db:"promo_code" json:"promo_code,omitempty"`
+	// Higher priority content is shown first
+	Priority  int        `db:"priority" json:"priority"`
+	StartsAt  *time.Time `db:"starts_at" json:"starts_at,omitempty"`
+	EndsAt    *time.Time `db:"ends_at" json:"ends_at,omitempty"`
+	Active    bool       `db:"active" json:"active"`
+	CreatedAt time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// LiveAt reports whether the content is shown at the given moment
+func (c *WaitingContent) LiveAt(at time.Time) bool {
+	return c.Active && (c.StartsAt == nil || !at.Before(*c.StartsAt)) && (c.EndsAt == nil || at.Before(*c.EndsAt))
+}
+
+// Item is the content as riders see it
+func (c *WaitingContent) Item() *WaitingItem {
+	return &WaitingItem{
+		ID:        c.ID,
+		Title:     c.Title,
+		Body:      c.Body,
+		ImageURL:  c.ImageURL,
+		ActionURL: c.ActionURL,
+		PromoCode: c.PromoCode,
+	}
+}
+
+// DefaultSafetyTips are shown when no safety tips are configured for the region
+var DefaultSafetyTips = []*WaitingItem{
+	{ID: "default-check-ride", Title: "Check your ride", Body: "Match the number plate and driver photo before you get in."},
+	{ID: "default-share-trip", Title: "Share your trip", Body: "Send your live trip to someone you trust from the ride screen."},
+	{ID: "default-seat-belt", Title: "Wear a seat belt", Body: "Buckle up, including in the back seat."},
+}
+
+type CreateWaitingContentRequest struct {
+	Kind       string     `json:"kind" validate:"required,oneof=safety_tip promo_banner"`
+	RegionCode *string    `json:"region_code,omitempty"`
+	Title      string     `json:"title" validate:"required,max=100"`
+	Body       string     `json:"body" validate:"required,max=500"`
+	ImageURL   *string    `json:"image_url,omitempty" validate:"omitempty,url"`
+	ActionURL  *string    `json:"action_url,omitempty" validate:"omitempty,url"`
+	PromoCode  *string    `json:"promo_code,omitempty"`
+	Priority   int        `json:"priority,omitempty"`
+	StartsAt   *time.Time `json:"starts_at,omitempty"`
+	EndsAt     *time.Time `json:"ends_at,omitempty"`
+}
+
+// WaitingScreen is everything the app shows a rider waiting for their driver,
+// assembled server-side so it can change without an app release
+type WaitingScreen struct {
+	RideID string `json:"ride_id"`
+	Status string `json:"status"`
+	// The estimated wait and nearby driver count, while the ride is looking for a driver
+	MatchEstimate *MatchEstimate `json:"match_estimate,omitempty"`
+	SafetyTips    []*WaitingItem `json:"safety_tips"`
+	PromoBanners  []*WaitingItem `json:"promo_banners"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+}
+
+// WaitingItem is a safety tip or promo banner on the waiting screen
+type WaitingItem struct {
+	ID        string  `json:"id"`
+	Title     string  `json:"title"`
+	Body      string  `json:"body"`
+	ImageURL  *string `json:"image_url,omitempty"`
+	ActionURL *string `json:"action_url,omitempty"`
+	PromoCode *string `json:"promo_code,omitempty"`
+}
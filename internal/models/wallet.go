@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// Wallet transaction types
+const (
+	WalletTxnTopUp   = "topup"
+	WalletTxnPayment = "payment"
+	WalletTxnRefund  = "refund"
+	// A rider's cancellation fee taken from their balance
+	WalletTxnCancellationFee = "cancellation_fee"
+)
+
+// Wallet is a rider's prepaid balance
+type Wallet struct {
+	UserID    string    `db:"user_id" json:"user_id"`
+	Balance   float64   `db:"balance" json:"balance"`
+	Currency  string    `db:"currency" json:"currency"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// WalletTransaction is a signed movement on a wallet
+type WalletTransaction struct {
+	ID               string    `db:"id" json:"id"`
+	UserID           string    `db:"user_id" json:"user_id"`
+	TxnType          string    `db:"txn_type" json:"type"`
+	Amount           float64   `db:"amount" json:"amount"`
+	BalanceAfter     float64   `db:"balance_after" json:"balance_after"`
+	PaymentID        *string   `db:"payment_id" json:"payment_id,omitempty"`
+	PSPTransactionID *string   `db:"psp_transaction_id" json:"psp_transaction_id,omitempty"`
+	IdempotencyKey   *string   `db:"idempotency_key" json:"-"`
+	CreatedAt        time.Time `db:"created_at" json:"created_at"`
+}
+
+type TopUpWalletRequest struct {
+	Amount float64 `json:"amount" validate:"required,gt=0,lte=10000"`
+	// How the top-up is paid for
+	Method string `json:"method" validate:"required,oneof=card upi"`
+}
+
+// WalletResponse is a wallet's balance with its latest transactions
+type WalletResponse struct {
+	UserID       string               `json:"user_id"`
+	Balance      float64              `json:"balance"`
+	Currency     string               `json:"currency"`
+	Transactions []*WalletTransaction `json:"transactions"`
+}
@@ -0,0 +1,171 @@
+// Package moderation screens free text riders and drivers send each other:
+// abusive language is blocked and contact details are masked so trips aren't
+// arranged off-platform
+package moderation
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Mask replaces contact details found in text
+const Mask = "[hidden]"
+
+// minPhoneDigits keeps short numbers such as gate codes and flat numbers visible
+const minPhoneDigits = 8
+
+// defaultWordLists are used for locales without a configured list; they are
+// matched as whole words, case-insensitively
+var defaultWordLists = map[string][]string{
+	"en": {
+		"asshole", "bastard", "bitch", "bullshit", "cunt", "dick", "fuck", "fucker",
+		"fucking", "motherfucker", "shit", "slut", "whore",
+	},
+	"hi": {
+		"bhenchod", "chutiya", "harami", "kamina", "madarchod", "randi",
+	},
+}
+
+var (
+	emailPattern = regexp.MustCompile(`(?i)[a-z0-9._%+\-]+@[a-z0-9.\-]+\.[a-z]{2,}`)
+	phonePattern = regexp.MustCompile(`\+?\d[\d\s\-().]{5,}\d`)
+)
+
+// Result is the outcome of filtering a piece of text
+type Result struct {
+	// Text with contact details masked
+	Text    string
+	Blocked bool
+	Masked  bool
+}
+
+// Filter applies per-locale blocked word lists. English is always checked since
+// riders mix it with their own language.
+type Filter struct {
+	lists map[string]map[string]bool
+}
+
+// NewFilter builds a filter from word lists keyed by language tag (e.g. "en",
+// "hi", "pt-br"). A list replaces the built-in default for its locale.
+func NewFilter(lists map[string][]string) *Filter {
+	f := &Filter{lists: make(map[string]map[string]bool)}
+	for locale, words := range defaultWordLists {
+		f.add(locale, words)
+	}
+	for locale, words := range lists {
+		delete(f.lists, normalizeLocale(locale))
+		f.add(locale, words)
+	}
+	return f
+}
+
+// LoadWordLists reads one list per locale from dir, named <locale>.txt with a
+// word per line. Blank lines and lines starting with # are ignored.
+func LoadWordLists(dir string) (map[string][]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.txt"))
+	if err != nil {
+		return nil, err
+	}
+
+	lists := make(map[string][]string)
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+
+		locale := strings.TrimSuffix(filepath.Base(path), ".txt")
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			lists[locale] = append(lists[locale], line)
+		}
+		err = scanner.Err()
+		file.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return lists, nil
+}
+
+// Check masks phone numbers and emails in text and reports whether it contains
+// a word blocked for the locale
+func (f *Filter) Check(text, locale string) Result {
+	result := Result{Text: maskContacts(text)}
+	result.Masked = result.Text != text
+
+	blocked := f.blockedWords(locale)
+	for _, word := range words(text) {
+		for _, list := range blocked {
+			if list[word] {
+				result.Blocked = true
+				return result
+			}
+		}
+	}
+	return result
+}
+
+func (f *Filter) add(locale string, words []string) {
+	locale = normalizeLocale(locale)
+	if f.lists[locale] == nil {
+		f.lists[locale] = make(map[string]bool)
+	}
+	for _, word := range words {
+		f.lists[locale][strings.ToLower(word)] = true
+	}
+}
+
+// blockedWords returns the lists for the locale, its base language and English
+func (f *Filter) blockedWords(locale string) []map[string]bool {
+	locale = normalizeLocale(locale)
+	base, _, _ := strings.Cut(locale, "-")
+
+	var lists []map[string]bool
+	seen := make(map[string]bool)
+	for _, key := range []string{locale, base, "en"} {
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		if list, ok := f.lists[key]; ok {
+			lists = append(lists, list)
+		}
+	}
+	return lists
+}
+
+func maskContacts(text string) string {
+	text = emailPattern.ReplaceAllString(text, Mask)
+	return phonePattern.ReplaceAllStringFunc(text, func(match string) string {
+		digits := 0
+		for _, r := range match {
+			if unicode.IsDigit(r) {
+				digits++
+			}
+		}
+		if digits < minPhoneDigits {
+			return match
+		}
+		return Mask
+	})
+}
+
+func normalizeLocale(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+}
+
+// words splits text into lowercase runs of letters
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+}
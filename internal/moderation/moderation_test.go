@@ -0,0 +1,89 @@
+package moderation
+
+import (
+	"testing"
+)
+
+func TestFilterCheck(t *testing.T) {
+	f := NewFilter(map[string][]string{
+		"pt-BR": {"idiota"},
+	})
+
+	tests := []struct {
+		name        string
+		text        string
+		locale      string
+		wantText    string
+		wantBlocked bool
+		wantMasked  bool
+	}{
+		{
+			name:     "Short codes stay visible",
+			text:     "gate code 1234, call on arrival",
+			locale:   "en",
+			wantText: "gate code 1234, call on arrival",
+		},
+		{
+			name:       "Phone number masked",
+			text:       "call me on +91 98765-43210 instead",
+			locale:     "en",
+			wantText:   "call me on [hidden] instead",
+			wantMasked: true,
+		},
+		{
+			name:       "Email masked",
+			text:       "mail Rider.One@example.com",
+			locale:     "en",
+			wantText:   "mail [hidden]",
+			wantMasked: true,
+		},
+		{
+			name:        "English blocked in any locale",
+			text:        "you are a BASTARD",
+			locale:      "hi-IN",
+			wantText:    "you are a BASTARD",
+			wantBlocked: true,
+		},
+		{
+			name:        "Base language list applies to regional locale",
+			text:        "harami",
+			locale:      "hi-IN",
+			wantText:    "harami",
+			wantBlocked: true,
+		},
+		{
+			name:        "Configured locale list",
+			text:        "seu idiota",
+			locale:      "pt_br",
+			wantText:    "seu idiota",
+			wantBlocked: true,
+		},
+		{
+			name:     "Other locale lists not applied",
+			text:     "seu idiota",
+			locale:   "en",
+			wantText: "seu idiota",
+		},
+		{
+			name:     "Blocked words only match whole words",
+			text:     "Dickens street, Scunthorpe",
+			locale:   "en",
+			wantText: "Dickens street, Scunthorpe",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := f.Check(tt.text, tt.locale)
+			if got.Text != tt.wantText {
+				t.Errorf("Text = %q, want %q", got.Text, tt.wantText)
+			}
+			if got.Blocked != tt.wantBlocked {
+				t.Errorf("Blocked = %v, want %v", got.Blocked, tt.wantBlocked)
+			}
+			if got.Masked != tt.wantMasked {
+				t.Errorf("Masked = %v, want %v", got.Masked, tt.wantMasked)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package psp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aditya/go-comet/internal/httpclient"
+)
+
+// HTTPGateway calls a PSP's REST API: POST {base}/authorizations,
+// POST {base}/authorizations/{id}/capture and POST {base}/authorizations/{id}/void
+type HTTPGateway struct {
+	baseURL string
+	apiKey  string
+	client  *httpclient.Client
+}
+
+func NewHTTPGateway(baseURL, apiKey string) *HTTPGateway {
+	return &HTTPGateway{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		client:  httpclient.New(httpclient.Config{Name: "psp", Timeout: 10 * time.Second}),
+	}
+}
+
+func (g *HTTPGateway) Authorize(ctx context.Context, req *AuthorizeRequest) (*Authorization, error) {
+	var auth Authorization
+	if err := g.post(ctx, "/authorizations", req, &auth); err != nil {
+		return nil, err
+	}
+	if auth.ID == "" {
+		return nil, fmt.Errorf("psp: response missing authorization id")
+	}
+	return &auth, nil
+}
+
+func (g *HTTPGateway) Capture(ctx context.Context, authorizationID string, amount float64) (*Capture, error) {
+	var capture Capture
+	path := "/authorizations/" + url.PathEscape(authorizationID) + "/capture"
+	if err := g.post(ctx, path, map[string]float64{"amount": amount}, &capture); err != nil {
+		return nil, err
+	}
+	return &capture, nil
+}
+
+func (g *HTTPGateway) Void(ctx context.Context, authorizationID string) error {
+	path := "/authorizations/" + url.PathEscape(authorizationID) + "/void"
+	return g.post(ctx, path, struct{}{}, nil)
+}
+
+func (g *HTTPGateway) post(ctx context.Context, path string, body, out interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.baseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if g.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+g.apiKey)
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("psp: %s returned status %d", path, resp.StatusCode)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
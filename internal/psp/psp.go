@@ -0,0 +1,30 @@
+package psp
+
+import "context"
+
+type AuthorizeRequest struct {
+	// Reference ties the authorization to our record, e.g. the ride ID
+	Reference  string  `json:"reference"`
+	CustomerID string  `json:"customer_id"`
+	Amount     float64 `json:"amount"`
+	Currency   string  `json:"currency"`
+}
+
+type Authorization struct {
+	ID     string `json:"authorization_id"`
+	Status string `json:"status"`
+}
+
+type Capture struct {
+	TransactionID string  `json:"transaction_id"`
+	Amount        float64 `json:"amount"`
+	Status        string  `json:"status"`
+}
+
+// Gateway places card holds and settles them. Capturing less than the
+// authorized amount releases the remainder of the hold.
+type Gateway interface {
+	Authorize(ctx context.Context, req *AuthorizeRequest) (*Authorization, error)
+	Capture(ctx context.Context, authorizationID string, amount float64) (*Capture, error)
+	Void(ctx context.Context, authorizationID string) error
+}
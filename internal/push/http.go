@@ -0,0 +1,55 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aditya/go-comet/internal/httpclient"
+)
+
+// HTTPSender posts messages to a push gateway: POST {base}/messages
+type HTTPSender struct {
+	baseURL string
+	apiKey  string
+	client  *httpclient.Client
+}
+
+func NewHTTPSender(baseURL, apiKey string) *HTTPSender {
+	return &HTTPSender{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		client:  httpclient.New(httpclient.Config{Name: "push", Timeout: 5 * time.Second}),
+	}
+}
+
+func (s *HTTPSender) Send(ctx context.Context, msg *Message) error {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.baseURL+"/messages", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("push: gateway returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,16 @@
+package push
+
+import "context"
+
+// Message is a push notification to every device registered to a rider or driver
+type Message struct {
+	UserID string            `json:"user_id"`
+	Title  string            `json:"title"`
+	Body   string            `json:"body"`
+	Data   map[string]string `json:"data,omitempty"`
+}
+
+// Sender delivers push notifications through a gateway that owns device registrations
+type Sender interface {
+	Send(ctx context.Context, msg *Message) error
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+type AuditRepository interface {
+	GetByRideIDUntil(ctx context.Context, rideID string, until time.Time) ([]*models.AuditEntry, error)
+}
+
+type auditRepository struct {
+	db *sqlx.DB
+}
+
+func NewAuditRepository(db *sqlx.DB) AuditRepository {
+	return &auditRepository{db: db}
+}
+
+func (r *auditRepository) GetByRideIDUntil(ctx context.Context, rideID string, until time.Time) ([]*models.AuditEntry, error) {
+	var entries []*models.AuditEntry
+	query := `
+		SELECT * FROM audit_log
+		WHERE ride_id = $1 AND recorded_at <= $2
+		ORDER BY recorded_at ASC, id ASC
+	`
+	err := r.db.SelectContext(ctx, &entries, query, rideID, until)
+	return entries, err
+}
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type BackupRepository interface {
+	Create(ctx context.Context, backup *models.Backup) error
+	GetByID(ctx context.Context, id string) (*models.Backup, error)
+	// GetLatest returns the most recent backup, or nil
+	GetLatest(ctx context.Context) (*models.Backup, error)
+	// List returns backups, newest first
+	List(ctx context.Context, limit int) ([]*models.Backup, error)
+	// ClaimPending marks up to limit pending backups as processing and returns them.
+	// Backups stuck processing since before staleBefore are claimed again.
+	ClaimPending(ctx context.Context, limit int, staleBefore time.Time) ([]*models.Backup, error)
+	MarkReady(ctx context.Context, id, manifestKey string, rowCount int, snapshotAt time.Time) error
+	MarkFailed(ctx context.Context, id, reason string) error
+	// Dump reads every row of the tables from one consistent snapshot, passing each
+	// to write as a JSON object keyed by column name, and returns the snapshot time
+	Dump(ctx context.Context, tables []string, write func(table string, row []byte) error) (time.Time, error)
+}
+
+type backupRepository struct {
+	db *sqlx.DB
+}
+
+func NewBackupRepository(db *sqlx.DB) BackupRepository {
+	return &backupRepository{db: db}
+}
+
+func (r *backupRepository) Create(ctx context.Context, backup *models.Backup) error {
+	if backup.ID == "" {
+		backup.ID = uuid.New().String()
+	}
+	backup.CreatedAt = time.Now()
+	backup.Status = models.BackupStatusPending
+
+	query := `INSERT INTO backups (id, status, created_at) VALUES ($1, $2, $3)`
+	_, err := r.db.ExecContext(ctx, query, backup.ID, backup.Status, backup.CreatedAt)
+	return err
+}
+
+func (r *backupRepository) GetByID(ctx context.Context, id string) (*models.Backup, error) {
+	var backup models.Backup
+	query := `SELECT * FROM backups WHERE id = $1`
+	err := r.db.GetContext(ctx, &backup, query, id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &backup, err
+}
+
+func (r *backupRepository) GetLatest(ctx context.Context) (*models.Backup, error) {
+	var backup models.Backup
+	query := `SELECT * FROM backups ORDER BY created_at DESC LIMIT 1`
+	err := r.db.GetContext(ctx, &backup, query)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &backup, err
+}
+
+func (r *backupRepository) List(ctx context.Context, limit int) ([]*models.Backup, error) {
+	var backups []*models.Backup
+	query := `SELECT * FROM backups ORDER BY created_at DESC LIMIT $1`
+	err := r.db.SelectContext(ctx, &backups, query, limit)
+	return backups, err
+}
+
+func (r *backupRepository) ClaimPending(ctx context.Context, limit int, staleBefore time.Time) ([]*models.Backup, error) {
+	var backups []*models.Backup
+	query := `
+		UPDATE backups SET status = $1, started_at = $2
+		WHERE id IN (
+			SELECT id FROM backups
+			WHERE status = $3 OR (status = $1 AND started_at < $4)
+			ORDER BY created_at
+			LIMIT $5
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *
+	`
+	err := r.db.SelectContext(ctx, &backups, query, models.BackupStatusProcessing, time.Now(),
+		models.BackupStatusPending, staleBefore, limit)
+	return backups, err
+}
+
+func (r *backupRepository) MarkReady(ctx context.Context, id, manifestKey string, rowCount int, snapshotAt time.Time) error {
+	query := `
+		UPDATE backups SET status = $1, manifest_key = $2, row_count = $3, snapshot_at = $4, completed_at = $5
+		WHERE id = $6
+	`
+	_, err := r.db.ExecContext(ctx, query, models.BackupStatusReady, manifestKey, rowCount, snapshotAt, time.Now(), id)
+	return err
+}
+
+func (r *backupRepository) MarkFailed(ctx context.Context, id, reason string) error {
+	query := `UPDATE backups SET status = $1, error = $2, completed_at = $3 WHERE id = $4`
+	_, err := r.db.ExecContext(ctx, query, models.BackupStatusFailed, reason, time.Now(), id)
+	return err
+}
+
+func (r *backupRepository) Dump(ctx context.Context, tables []string, write func(table string, row []byte) error) (time.Time, error) {
+	// A repeatable read transaction sees every table as of its first query, so
+	// rows written during the dump can't leave trips pointing at missing rides
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer tx.Rollback()
+
+	var snapshotAt time.Time
+	if err := tx.GetContext(ctx, &snapshotAt, `SELECT NOW()`); err != nil {
+		return time.Time{}, err
+	}
+
+	for _, table := range tables {
+		if err := dumpTable(ctx, tx, table, write); err != nil {
+			return time.Time{}, err
+		}
+	}
+	return snapshotAt, tx.Commit()
+}
+
+func dumpTable(ctx context.Context, tx *sqlx.Tx, table string, write func(table string, row []byte) error) error {
+	rows, err := tx.QueryContext(ctx, `SELECT row_to_json(t) FROM `+pq.QuoteIdentifier(table)+` t ORDER BY id`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row []byte
+		if err := rows.Scan(&row); err != nil {
+			return err
+		}
+		if err := write(table, row); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
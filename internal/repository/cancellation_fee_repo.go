@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type CancellationFeeRepository interface {
+	// Create stores the fee, returning false if the ride was already billed one.
+	// With debit set, the fee is also taken from the rider's wallet in the same
+	// transaction when the balance covers it, and stored as charged with the
+	// wallet transaction; otherwise it's stored outstanding.
+	Create(ctx context.Context, fee *models.CancellationFee, debit *models.WalletTransaction) (bool, error)
+	// GetOutstandingByUserID returns the rider's unpaid fees, oldest first
+	GetOutstandingByUserID(ctx context.Context, userID string) ([]*models.CancellationFee, error)
+	// Settle marks outstanding fees as paid by the payment
+	Settle(ctx context.Context, ids []string, paymentID string) error
+}
+
+type cancellationFeeRepository struct {
+	db *sqlx.DB
+}
+
+func NewCancellationFeeRepository(db *sqlx.DB) CancellationFeeRepository {
+	return &cancellationFeeRepository{db: db}
+}
+
+func (r *cancellationFeeRepository) Create(ctx context.Context, fee *models.CancellationFee, debit *models.WalletTransaction) (bool, error) {
+	if fee.ID == "" {
+		fee.ID = uuid.New().String()
+	}
+	fee.CreatedAt = time.Now()
+	fee.UpdatedAt = fee.CreatedAt
+	if fee.Currency == "" {
+		fee.Currency = "INR"
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	// The fee row goes in first: a concurrent cancel of the same ride finds it
+	// and debits nothing
+	query := `
+		INSERT INTO cancellation_fees (id, ride_id, user_id, driver_id, amount, currency,
+			status, wallet_txn_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (ride_id) DO NOTHING
+	`
+	result, err := tx.ExecContext(ctx, query,
+		fee.ID, fee.RideID, fee.UserID, fee.DriverID, fee.Amount, fee.Currency,
+		fee.Status, fee.WalletTxnID, fee.CreatedAt, fee.UpdatedAt)
+	if err != nil {
+		return false, err
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+
+	if debit != nil {
+		if debit.ID == "" {
+			debit.ID = uuid.New().String()
+		}
+		debit.CreatedAt = fee.CreatedAt
+		charged, err := debitWallet(ctx, tx, debit)
+		if err != nil {
+			return false, err
+		}
+		if charged {
+			query := `UPDATE cancellation_fees SET status = $1, wallet_txn_id = $2 WHERE id = $3`
+			if _, err := tx.ExecContext(ctx, query, models.CancellationFeeCharged, debit.ID, fee.ID); err != nil {
+				return false, err
+			}
+			fee.Status = models.CancellationFeeCharged
+			fee.WalletTxnID = &debit.ID
+		}
+	}
+
+	return true, tx.Commit()
+}
+
+func (r *cancellationFeeRepository) GetOutstandingByUserID(ctx context.Context, userID string) ([]*models.CancellationFee, error) {
+	var fees []*models.CancellationFee
+	query := `
+		SELECT * FROM cancellation_fees
+		WHERE user_id = $1 AND status = $2
+		ORDER BY created_at
+	`
+	err := r.db.SelectContext(ctx, &fees, query, userID, models.CancellationFeeOutstanding)
+	return fees, err
+}
+
+func (r *cancellationFeeRepository) Settle(ctx context.Context, ids []string, paymentID string) error {
+	query := `
+		UPDATE cancellation_fees
+		SET status = $1, payment_id = $2, updated_at = $3
+		WHERE id = ANY($4) AND status = $5
+	`
+	_, err := r.db.ExecContext(ctx, query, models.CancellationFeeSettled, paymentID, time.Now(),
+		pq.Array(ids), models.CancellationFeeOutstanding)
+	return err
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type CommissionRepository interface {
+	Create(ctx context.Context, override *models.CommissionOverride) error
+	GetByID(ctx context.Context, id string) (*models.CommissionOverride, error)
+	GetByDriverID(ctx context.Context, driverID string) ([]*models.CommissionOverride, error)
+	GetEffective(ctx context.Context, driverID string, at time.Time) (*models.CommissionOverride, error)
+	End(ctx context.Context, id string, at time.Time) error
+}
+
+type commissionRepository struct {
+	db *sqlx.DB
+}
+
+func NewCommissionRepository(db *sqlx.DB) CommissionRepository {
+	return &commissionRepository{db: db}
+}
+
+func (r *commissionRepository) Create(ctx context.Context, override *models.CommissionOverride) error {
+	if override.ID == "" {
+		override.ID = uuid.New().String()
+	}
+	override.CreatedAt = time.Now()
+
+	query := `
+		INSERT INTO driver_commission_overrides (id, driver_id, kind, commission_percent,
+			effective_from, effective_to, note, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		override.ID, override.DriverID, override.Kind, override.CommissionPercent,
+		override.EffectiveFrom, override.EffectiveTo, override.Note, override.CreatedAt)
+	return err
+}
+
+func (r *commissionRepository) GetByID(ctx context.Context, id string) (*models.CommissionOverride, error) {
+	var override models.CommissionOverride
+	query := `SELECT * FROM driver_commission_overrides WHERE id = $1`
+	err := r.db.GetContext(ctx, &override, query, id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &override, err
+}
+
+func (r *commissionRepository) GetByDriverID(ctx context.Context, driverID string) ([]*models.CommissionOverride, error) {
+	var overrides []*models.CommissionOverride
+	query := `
+		SELECT * FROM driver_commission_overrides
+		WHERE driver_id = $1
+		ORDER BY effective_from DESC, created_at DESC
+	`
+	err := r.db.SelectContext(ctx, &overrides, query, driverID)
+	return overrides, err
+}
+
+// GetEffective returns the override in force at the given time. When several
+// overlap, the one that started most recently wins.
+func (r *commissionRepository) GetEffective(ctx context.Context, driverID string, at time.Time) (*models.CommissionOverride, error) {
+	var override models.CommissionOverride
+	query := `
+		SELECT * FROM driver_commission_overrides
+		WHERE driver_id = $1 AND effective_from <= $2
+		AND (effective_to IS NULL OR effective_to > $2)
+		ORDER BY effective_from DESC, created_at DESC
+		LIMIT 1
+	`
+	err := r.db.GetContext(ctx, &override, query, driverID, at)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &override, err
+}
+
+func (r *commissionRepository) End(ctx context.Context, id string, at time.Time) error {
+	query := `UPDATE driver_commission_overrides SET effective_to = $1 WHERE id = $2`
+	_, err := r.db.ExecContext(ctx, query, at, id)
+	return err
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type ConsentRepository interface {
+	// CreateDocument stores a document version. Returns false if that version of
+	// the document already exists.
+	CreateDocument(ctx context.Context, doc *models.LegalDocument) (bool, error)
+	GetDocument(ctx context.Context, id string) (*models.LegalDocument, error)
+	// ListDocuments returns every document version, newest first
+	ListDocuments(ctx context.Context) ([]*models.LegalDocument, error)
+	// CurrentDocuments returns the newest version of each document
+	CurrentDocuments(ctx context.Context) ([]*models.LegalDocument, error)
+	// Accept records the acceptance. Returns false if the party already accepted
+	// that document version.
+	Accept(ctx context.Context, record *models.ConsentRecord) (bool, error)
+	// GetRecords returns the party's acceptances, newest first
+	GetRecords(ctx context.Context, partyType, partyID string) ([]*models.ConsentRecord, error)
+}
+
+type consentRepository struct {
+	db *sqlx.DB
+}
+
+func NewConsentRepository(db *sqlx.DB) ConsentRepository {
+	return &consentRepository{db: db}
+}
+
+func (r *consentRepository) CreateDocument(ctx context.Context, doc *models.LegalDocument) (bool, error) {
+	if doc.ID == "" {
+		doc.ID = uuid.New().String()
+	}
+	doc.CreatedAt = time.Now()
+
+	query := `
+		INSERT INTO legal_documents (id, doc_type, region_code, audience, version, title, url, required, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT DO NOTHING
+	`
+	result, err := r.db.ExecContext(ctx, query,
+		doc.ID, doc.DocType, doc.RegionCode, doc.Audience, doc.Version, doc.Title, doc.URL,
+		doc.Required, doc.CreatedAt)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+func (r *consentRepository) GetDocument(ctx context.Context, id string) (*models.LegalDocument, error) {
+	var doc models.LegalDocument
+	query := `SELECT * FROM legal_documents WHERE id = $1`
+	err := r.db.GetContext(ctx, &doc, query, id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &doc, err
+}
+
+func (r *consentRepository) ListDocuments(ctx context.Context) ([]*models.LegalDocument, error) {
+	var docs []*models.LegalDocument
+	query := `SELECT * FROM legal_documents ORDER BY created_at DESC`
+	err := r.db.SelectContext(ctx, &docs, query)
+	return docs, err
+}
+
+func (r *consentRepository) CurrentDocuments(ctx context.Context) ([]*models.LegalDocument, error) {
+	var docs []*models.LegalDocument
+	query := `
+		SELECT DISTINCT ON (doc_type, COALESCE(region_code, ''), audience) *
+		FROM legal_documents
+		ORDER BY doc_type, COALESCE(region_code, ''), audience, created_at DESC
+	`
+	err := r.db.SelectContext(ctx, &docs, query)
+	return docs, err
+}
+
+func (r *consentRepository) Accept(ctx context.Context, record *models.ConsentRecord) (bool, error) {
+	if record.ID == "" {
+		record.ID = uuid.New().String()
+	}
+	record.AcceptedAt = time.Now()
+
+	query := `
+		INSERT INTO consent_records (id, party_type, party_id, document_id, doc_type, region_code, version,
+			ip_address, accepted_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (party_type, party_id, document_id) DO NOTHING
+	`
+	result, err := r.db.ExecContext(ctx, query,
+		record.ID, record.PartyType, record.PartyID, record.DocumentID, record.DocType, record.RegionCode,
+		record.Version, record.IPAddress, record.AcceptedAt)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+func (r *consentRepository) GetRecords(ctx context.Context, partyType, partyID string) ([]*models.ConsentRecord, error) {
+	var records []*models.ConsentRecord
+	query := `
+		SELECT * FROM consent_records
+		WHERE party_type = $1 AND party_id = $2
+		ORDER BY accepted_at DESC
+	`
+	err := r.db.SelectContext(ctx, &records, query, partyType, partyID)
+	return records, err
+}
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type DeductionRepository interface {
+	CreateSchedule(ctx context.Context, schedule *models.DeductionSchedule) error
+	GetScheduleByID(ctx context.Context, id string) (*models.DeductionSchedule, error)
+	GetSchedulesByDriverID(ctx context.Context, driverID string) ([]*models.DeductionSchedule, error)
+	GetActiveSchedules(ctx context.Context, today time.Time) ([]*models.DeductionSchedule, error)
+	UpdateScheduleStatus(ctx context.Context, id, status string) error
+	ChargePeriod(ctx context.Context, schedule *models.DeductionSchedule, periodDate time.Time) (bool, error)
+	GetLedgerEntries(ctx context.Context, driverID string, from, to time.Time) ([]*models.LedgerEntry, error)
+	GetRecentLedgerEntries(ctx context.Context, driverID string, limit int) ([]*models.LedgerEntry, error)
+}
+
+type deductionRepository struct {
+	db *sqlx.DB
+}
+
+func NewDeductionRepository(db *sqlx.DB) DeductionRepository {
+	return &deductionRepository{db: db}
+}
+
+func (r *deductionRepository) CreateSchedule(ctx context.Context, schedule *models.DeductionSchedule) error {
+	if schedule.ID == "" {
+		schedule.ID = uuid.New().String()
+	}
+	now := time.Now()
+	schedule.CreatedAt = now
+	schedule.UpdatedAt = now
+	schedule.Status = models.DeductionStatusActive
+
+	query := `
+		INSERT INTO driver_deduction_schedules (id, driver_id, kind, amount, frequency,
+			start_date, end_date, description, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		schedule.ID, schedule.DriverID, schedule.Kind, schedule.Amount, schedule.Frequency,
+		schedule.StartDate, schedule.EndDate, schedule.Description, schedule.Status,
+		schedule.CreatedAt, schedule.UpdatedAt)
+	return err
+}
+
+func (r *deductionRepository) GetScheduleByID(ctx context.Context, id string) (*models.DeductionSchedule, error) {
+	var schedule models.DeductionSchedule
+	query := `SELECT * FROM driver_deduction_schedules WHERE id = $1`
+	err := r.db.GetContext(ctx, &schedule, query, id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &schedule, err
+}
+
+func (r *deductionRepository) GetSchedulesByDriverID(ctx context.Context, driverID string) ([]*models.DeductionSchedule, error) {
+	var schedules []*models.DeductionSchedule
+	query := `SELECT * FROM driver_deduction_schedules WHERE driver_id = $1 ORDER BY created_at DESC`
+	err := r.db.SelectContext(ctx, &schedules, query, driverID)
+	return schedules, err
+}
+
+// GetActiveSchedules returns active schedules that have started by today
+func (r *deductionRepository) GetActiveSchedules(ctx context.Context, today time.Time) ([]*models.DeductionSchedule, error) {
+	var schedules []*models.DeductionSchedule
+	query := `
+		SELECT * FROM driver_deduction_schedules
+		WHERE status = $1 AND start_date <= $2
+	`
+	err := r.db.SelectContext(ctx, &schedules, query, models.DeductionStatusActive, today)
+	return schedules, err
+}
+
+func (r *deductionRepository) UpdateScheduleStatus(ctx context.Context, id, status string) error {
+	query := `UPDATE driver_deduction_schedules SET status = $1, updated_at = $2 WHERE id = $3`
+	_, err := r.db.ExecContext(ctx, query, status, time.Now(), id)
+	return err
+}
+
+// ChargePeriod posts the schedule's deduction for one period and advances its
+// last charged date. Returns false if that period was already charged.
+func (r *deductionRepository) ChargePeriod(ctx context.Context, schedule *models.DeductionSchedule, periodDate time.Time) (bool, error) {
+	var inserted int
+	query := `
+		WITH entry AS (
+			INSERT INTO driver_ledger_entries (id, driver_id, entry_type, amount, description,
+				schedule_id, period_date, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			ON CONFLICT (schedule_id, period_date) DO NOTHING
+			RETURNING id
+		), advanced AS (
+			UPDATE driver_deduction_schedules
+			SET last_charged_on = GREATEST(COALESCE(last_charged_on, $7), $7), updated_at = $8
+			WHERE id = $6
+		)
+		SELECT COUNT(*) FROM entry
+	`
+	err := r.db.GetContext(ctx, &inserted, query,
+		uuid.New().String(), schedule.DriverID, models.LedgerEntryDeduction, -schedule.Amount,
+		schedule.Description, schedule.ID, periodDate, time.Now())
+	return inserted > 0, err
+}
+
+// GetLedgerEntries returns the incentives and deductions posted to the driver's
+// ledger in [from, to), oldest first
+func (r *deductionRepository) GetLedgerEntries(ctx context.Context, driverID string, from, to time.Time) ([]*models.LedgerEntry, error) {
+	var entries []*models.LedgerEntry
+	query := `
+		SELECT * FROM driver_ledger_entries
+		WHERE driver_id = $1 AND created_at >= $2 AND created_at < $3 AND entry_type = ANY($4)
+		ORDER BY created_at
+	`
+	err := r.db.SelectContext(ctx, &entries, query, driverID, from, to, pq.Array(models.LedgerAdjustmentTypes))
+	return entries, err
+}
+
+func (r *deductionRepository) GetRecentLedgerEntries(ctx context.Context, driverID string, limit int) ([]*models.LedgerEntry, error) {
+	var entries []*models.LedgerEntry
+	query := `
+		SELECT * FROM driver_ledger_entries
+		WHERE driver_id = $1 AND entry_type = ANY($3)
+		ORDER BY created_at DESC
+		LIMIT $2
+	`
+	err := r.db.SelectContext(ctx, &entries, query, driverID, limit, pq.Array(models.LedgerAdjustmentTypes))
+	return entries, err
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+type DeliveryRepository interface {
+	Create(ctx context.Context, delivery *models.Delivery) error
+	GetByRideID(ctx context.Context, rideID string) (*models.Delivery, error)
+	MarkPickedUp(ctx context.Context, rideID, uploadID string) error
+	// RecordFailedCode counts a wrong code and returns the attempts made so far
+	RecordFailedCode(ctx context.Context, rideID string) (int, error)
+	MarkDelivered(ctx context.Context, rideID, uploadID, receivedBy string) error
+}
+
+type deliveryRepository struct {
+	db *sqlx.DB
+}
+
+func NewDeliveryRepository(db *sqlx.DB) DeliveryRepository {
+	return &deliveryRepository{db: db}
+}
+
+func (r *deliveryRepository) Create(ctx context.Context, delivery *models.Delivery) error {
+	now := time.Now()
+	delivery.CreatedAt = now
+	delivery.UpdatedAt = now
+	if delivery.Status == "" {
+		delivery.Status = models.DeliveryStatusAwaitingPickup
+	}
+
+	query := `
+		INSERT INTO deliveries (ride_id, status, recipient_name, recipient_phone, package_description,
+			package_size, weight_kg, declared_value, otp, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		delivery.RideID, delivery.Status, delivery.RecipientName, delivery.RecipientPhone,
+		delivery.PackageDescription, delivery.PackageSize, delivery.WeightKg, delivery.DeclaredValue,
+		delivery.OTP, delivery.CreatedAt, delivery.UpdatedAt)
+	return err
+}
+
+func (r *deliveryRepository) GetByRideID(ctx context.Context, rideID string) (*models.Delivery, error) {
+	var delivery models.Delivery
+	query := `SELECT * FROM deliveries WHERE ride_id = $1`
+	err := r.db.GetContext(ctx, &delivery, query, rideID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &delivery, err
+}
+
+func (r *deliveryRepository) MarkPickedUp(ctx context.Context, rideID, uploadID string) error {
+	query := `
+		UPDATE deliveries
+		SET status = $1, pickup_photo_upload_id = $2, picked_up_at = $3, updated_at = $3
+		WHERE ride_id = $4
+	`
+	_, err := r.db.ExecContext(ctx, query, models.DeliveryStatusPickedUp, uploadID, time.Now(), rideID)
+	return err
+}
+
+func (r *deliveryRepository) RecordFailedCode(ctx context.Context, rideID string) (int, error) {
+	var attempts int
+	query := `
+		UPDATE deliveries SET otp_attempts = otp_attempts + 1, updated_at = $1
+		WHERE ride_id = $2
+		RETURNING otp_attempts
+	`
+	err := r.db.QueryRowxContext(ctx, query, time.Now(), rideID).Scan(&attempts)
+	return attempts, err
+}
+
+func (r *deliveryRepository) MarkDelivered(ctx context.Context, rideID, uploadID, receivedBy string) error {
+	query := `
+		UPDATE deliveries
+		SET status = $1, dropoff_photo_upload_id = $2, received_by = $3, delivered_at = $4, updated_at = $4
+		WHERE ride_id = $5
+	`
+	_, err := r.db.ExecContext(ctx, query, models.DeliveryStatusDelivered, uploadID, receivedBy, time.Now(), rideID)
+	return err
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type DispatchRoundRepository interface {
+	Create(ctx context.Context, round *models.DispatchRound) error
+	// GetFunnel sums rounds since the given time per region and vehicle type,
+	// optionally for a single region
+	GetFunnel(ctx context.Context, since time.Time, regionCode string) ([]*models.MatchingFunnel, error)
+}
+
+type dispatchRoundRepository struct {
+	db *sqlx.DB
+}
+
+func NewDispatchRoundRepository(db *sqlx.DB) DispatchRoundRepository {
+	return &dispatchRoundRepository{db: db}
+}
+
+func (r *dispatchRoundRepository) Create(ctx context.Context, round *models.DispatchRound) error {
+	if round.ID == "" {
+		round.ID = uuid.New().String()
+	}
+	round.CreatedAt = time.Now()
+
+	query := `
+		INSERT INTO dispatch_rounds (id, ride_id, region_code, vehicle_type, candidates, passed_filters, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		round.ID, round.RideID, round.RegionCode, round.VehicleType, round.Candidates, round.PassedFilters, round.CreatedAt)
+	return err
+}
+
+func (r *dispatchRoundRepository) GetFunnel(ctx context.Context, since time.Time, regionCode string) ([]*models.MatchingFunnel, error) {
+	var funnels []*models.MatchingFunnel
+	// Queued offers were accepted by drivers still finishing a trip
+	query := `
+		WITH funnel AS (
+			SELECT dr.region_code, dr.vehicle_type,
+				COUNT(DISTINCT dr.ride_id) AS rides,
+				COUNT(*) AS rounds,
+				COUNT(*) FILTER (WHERE dr.candidates = 0) AS empty_rounds,
+				COALESCE(SUM(dr.candidates), 0) AS candidates,
+				COALESCE(SUM(dr.passed_filters), 0) AS passed_filters,
+				COALESCE(SUM(o.offered), 0) AS offered,
+				COALESCE(SUM(o.viewed), 0) AS viewed,
+				COALESCE(SUM(o.accepted), 0) AS accepted
+			FROM dispatch_rounds dr
+			LEFT JOIN (
+				SELECT dispatch_round_id,
+					COUNT(*) AS offered,
+					COUNT(*) FILTER (WHERE viewed_at IS NOT NULL) AS viewed,
+					COUNT(*) FILTER (WHERE status IN ($3, $4)) AS accepted
+				FROM ride_offers
+				WHERE dispatch_round_id IS NOT NULL AND offered_at >= $1
+				GROUP BY dispatch_round_id
+			) o ON o.dispatch_round_id = dr.id
+			WHERE dr.created_at >= $1 AND ($2 = '' OR dr.region_code = $2)
+			GROUP BY dr.region_code, dr.vehicle_type
+		), response AS (
+			SELECT dr.region_code, dr.vehicle_type,
+				COUNT(*) AS responded,
+				AVG(o.response_ms) / 1000 AS avg_response_secs,
+				percentile_cont(0.5) WITHIN GROUP (ORDER BY o.response_ms) / 1000 AS median_response_secs,
+				percentile_cont(0.9) WITHIN GROUP (ORDER BY o.response_ms) / 1000 AS p90_response_secs
+			FROM dispatch_rounds dr
+			JOIN ride_offers o ON o.dispatch_round_id = dr.id
+			WHERE dr.created_at >= $1 AND ($2 = '' OR dr.region_code = $2)
+				AND o.offered_at >= $1 AND o.response_ms IS NOT NULL
+			GROUP BY dr.region_code, dr.vehicle_type
+		)
+		SELECT f.*,
+			COALESCE(r.responded, 0) AS responded,
+			COALESCE(r.avg_response_secs, 0) AS avg_response_secs,
+			COALESCE(r.median_response_secs, 0) AS median_response_secs,
+			COALESCE(r.p90_response_secs, 0) AS p90_response_secs
+		FROM funnel f
+		LEFT JOIN response r ON r.region_code IS NOT DISTINCT FROM f.region_code AND r.vehicle_type = f.vehicle_type
+		ORDER BY f.region_code NULLS LAST, f.vehicle_type
+	`
+	err := r.db.SelectContext(ctx, &funnels, query, since, regionCode,
+		models.OfferStatusAccepted, models.OfferStatusQueued)
+	return funnels, err
+}
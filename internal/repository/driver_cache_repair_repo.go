@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+type DriverCacheRepairRepository interface {
+	// Enqueue queues the driver for repair; a driver already queued is due again now
+	Enqueue(ctx context.Context, driverID, reason string) error
+	GetDue(ctx context.Context, limit int) ([]*models.DriverCacheRepair, error)
+	// Delete removes the repair unless the driver was queued again since it was read
+	Delete(ctx context.Context, repair *models.DriverCacheRepair) error
+	RecordFailure(ctx context.Context, driverID, reason string, nextAttempt time.Time) error
+}
+
+type driverCacheRepairRepository struct {
+	db *sqlx.DB
+}
+
+func NewDriverCacheRepairRepository(db *sqlx.DB) DriverCacheRepairRepository {
+	return &driverCacheRepairRepository{db: db}
+}
+
+func (r *driverCacheRepairRepository) Enqueue(ctx context.Context, driverID, reason string) error {
+	query := `
+		INSERT INTO driver_cache_repairs (driver_id, reason, created_at, next_attempt_at)
+		VALUES ($1, $2, NOW(), NOW())
+		ON CONFLICT (driver_id) DO UPDATE SET reason = EXCLUDED.reason, next_attempt_at = NOW()
+	`
+	_, err := r.db.ExecContext(ctx, query, driverID, reason)
+	return err
+}
+
+func (r *driverCacheRepairRepository) GetDue(ctx context.Context, limit int) ([]*models.DriverCacheRepair, error) {
+	var repairs []*models.DriverCacheRepair
+	query := `
+		SELECT * FROM driver_cache_repairs
+		WHERE next_attempt_at <= NOW()
+		ORDER BY next_attempt_at ASC
+		LIMIT $1
+	`
+	err := r.db.SelectContext(ctx, &repairs, query, limit)
+	return repairs, err
+}
+
+func (r *driverCacheRepairRepository) Delete(ctx context.Context, repair *models.DriverCacheRepair) error {
+	query := `DELETE FROM driver_cache_repairs WHERE driver_id = $1 AND next_attempt_at = $2`
+	_, err := r.db.ExecContext(ctx, query, repair.DriverID, repair.NextAttemptAt)
+	return err
+}
+
+func (r *driverCacheRepairRepository) RecordFailure(ctx context.Context, driverID, reason string, nextAttempt time.Time) error {
+	query := `
+		UPDATE driver_cache_repairs
+		SET attempts = attempts + 1, last_error = $1, next_attempt_at = $2
+		WHERE driver_id = $3
+	`
+	_, err := r.db.ExecContext(ctx, query, reason, nextAttempt, driverID)
+	return err
+}
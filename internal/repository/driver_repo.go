@@ -3,23 +3,44 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/tenant"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 type DriverRepository interface {
 	Create(ctx context.Context, driver *models.Driver) error
+	// CreateBatch inserts drivers in one statement, offline like Create, and returns
+	// the phones inserted. The others already belong to a driver of the tenant.
+	CreateBatch(ctx context.Context, drivers []*models.Driver) (map[string]bool, error)
 	GetByID(ctx context.Context, id string) (*models.Driver, error)
 	GetByPhone(ctx context.Context, phone string) (*models.Driver, error)
 	Update(ctx context.Context, driver *models.Driver) error
 	UpdateStatus(ctx context.Context, id string, status string) error
+	// GetByIDForUpdate gets a driver with a FOR UPDATE lock held until tx ends
+	GetByIDForUpdate(ctx context.Context, tx *sqlx.Tx, id string) (*models.Driver, error)
+	SetStatus(ctx context.Context, tx *sqlx.Tx, id, status string, at time.Time) error
 	UpdateLocation(ctx context.Context, id string, lat, lng float64) error
-	UpdateRating(ctx context.Context, id string, rating float64) error
+	SetRating(ctx context.Context, id string, rating float64) error
+	UpdatePhone(ctx context.Context, id, phone string) error
 	IncrementTotalTrips(ctx context.Context, id string) error
 	GetOnlineDriversByVehicleType(ctx context.Context, vehicleType string) ([]*models.Driver, error)
+	// GetOnlineInBounds returns online drivers, other than those resting, whose last
+	// stored location is inside a lat/lng bounding box
+	GetOnlineInBounds(ctx context.Context, vehicleType string, minLat, minLng, maxLat, maxLng float64) ([]*models.Driver, error)
+	GetByStatuses(ctx context.Context, statuses ...string) ([]*models.Driver, error)
+	GetByIDs(ctx context.Context, ids []string) ([]*models.Driver, error)
+	MarkVerified(ctx context.Context, id string, at time.Time) error
+	UpdateEarningsGoal(ctx context.Context, id string, goal *float64) error
+	// UpdatePaymentMethods sets the payment methods the driver takes; empty takes all
+	UpdatePaymentMethods(ctx context.Context, id string, methods []string) error
+	SetCooldown(ctx context.Context, id string, until time.Time, reason string) error
 }
 
 type driverRepository struct {
@@ -39,23 +60,71 @@ func (r *driverRepository) Create(ctx context.Context, driver *models.Driver) er
 	driver.Rating = 5.0
 	driver.TotalTrips = 0
 	driver.Status = models.DriverStatusOffline
+	driver.TenantCode = tenant.CodeOrDefault(ctx)
 
 	query := `
 		INSERT INTO drivers (id, phone, name, email, license_number, vehicle_type, vehicle_number,
-			status, rating, total_trips, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+			status, rating, total_trips, gender, is_ev, tenant_code, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
 	`
 	_, err := r.db.ExecContext(ctx, query,
 		driver.ID, driver.Phone, driver.Name, driver.Email, driver.LicenseNumber,
 		driver.VehicleType, driver.VehicleNumber, driver.Status, driver.Rating,
-		driver.TotalTrips, driver.CreatedAt, driver.UpdatedAt)
+		driver.TotalTrips, driver.Gender, driver.IsEV, driver.TenantCode, driver.CreatedAt, driver.UpdatedAt)
 	return err
 }
 
+func (r *driverRepository) CreateBatch(ctx context.Context, drivers []*models.Driver) (map[string]bool, error) {
+	created := make(map[string]bool)
+	if len(drivers) == 0 {
+		return created, nil
+	}
+
+	now := time.Now()
+	tenantCode := tenant.CodeOrDefault(ctx)
+	var values []string
+	var args []interface{}
+	for _, driver := range drivers {
+		if driver.ID == "" {
+			driver.ID = uuid.New().String()
+		}
+		driver.CreatedAt = now
+		driver.UpdatedAt = now
+		driver.Rating = 5.0
+		driver.TotalTrips = 0
+		driver.Status = models.DriverStatusOffline
+		driver.TenantCode = tenantCode
+
+		n := len(args)
+		values = append(values, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)",
+			n+1, n+2, n+3, n+4, n+5, n+6, n+7, n+8, n+9, n+10, n+11, n+12, n+13, n+14, n+15))
+		args = append(args,
+			driver.ID, driver.Phone, driver.Name, driver.Email, driver.LicenseNumber,
+			driver.VehicleType, driver.VehicleNumber, driver.Status, driver.Rating,
+			driver.TotalTrips, driver.Gender, driver.IsEV, driver.TenantCode, driver.CreatedAt, driver.UpdatedAt)
+	}
+
+	query := `
+		INSERT INTO drivers (id, phone, name, email, license_number, vehicle_type, vehicle_number,
+			status, rating, total_trips, gender, is_ev, tenant_code, created_at, updated_at)
+		VALUES ` + strings.Join(values, ", ") + `
+		ON CONFLICT (tenant_code, phone) DO NOTHING
+		RETURNING phone
+	`
+	var phones []string
+	if err := r.db.SelectContext(ctx, &phones, query, args...); err != nil {
+		return nil, err
+	}
+	for _, phone := range phones {
+		created[phone] = true
+	}
+	return created, nil
+}
+
 func (r *driverRepository) GetByID(ctx context.Context, id string) (*models.Driver, error) {
 	var driver models.Driver
-	query := `SELECT * FROM drivers WHERE id = $1`
-	err := r.db.GetContext(ctx, &driver, query, id)
+	query := `SELECT * FROM drivers WHERE id = $1 AND ($2 = '' OR tenant_code = $2)`
+	err := r.db.GetContext(ctx, &driver, query, id, tenant.Code(ctx))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -64,8 +133,8 @@ func (r *driverRepository) GetByID(ctx context.Context, id string) (*models.Driv
 
 func (r *driverRepository) GetByPhone(ctx context.Context, phone string) (*models.Driver, error) {
 	var driver models.Driver
-	query := `SELECT * FROM drivers WHERE phone = $1`
-	err := r.db.GetContext(ctx, &driver, query, phone)
+	query := `SELECT * FROM drivers WHERE phone = $1 AND tenant_code = $2`
+	err := r.db.GetContext(ctx, &driver, query, phone, tenant.CodeOrDefault(ctx))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -91,13 +160,35 @@ func (r *driverRepository) UpdateStatus(ctx context.Context, id string, status s
 	return err
 }
 
+func (r *driverRepository) GetByIDForUpdate(ctx context.Context, tx *sqlx.Tx, id string) (*models.Driver, error) {
+	var driver models.Driver
+	query := `SELECT * FROM drivers WHERE id = $1 FOR UPDATE`
+	err := tx.GetContext(ctx, &driver, query, id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &driver, err
+}
+
+func (r *driverRepository) SetStatus(ctx context.Context, tx *sqlx.Tx, id, status string, at time.Time) error {
+	query := `UPDATE drivers SET status = $1, updated_at = $2 WHERE id = $3`
+	_, err := tx.ExecContext(ctx, query, status, at, id)
+	return err
+}
+
 func (r *driverRepository) UpdateLocation(ctx context.Context, id string, lat, lng float64) error {
 	query := `UPDATE drivers SET current_lat = $1, current_lng = $2, updated_at = $3 WHERE id = $4`
 	_, err := r.db.ExecContext(ctx, query, lat, lng, time.Now(), id)
 	return err
 }
 
-func (r *driverRepository) UpdateRating(ctx context.Context, id string, rating float64) error {
+func (r *driverRepository) UpdatePhone(ctx context.Context, id, phone string) error {
+	query := `UPDATE drivers SET phone = $1, updated_at = $2 WHERE id = $3`
+	_, err := r.db.ExecContext(ctx, query, phone, time.Now(), id)
+	return err
+}
+
+func (r *driverRepository) SetRating(ctx context.Context, id string, rating float64) error {
 	query := `UPDATE drivers SET rating = $1, updated_at = $2 WHERE id = $3`
 	_, err := r.db.ExecContext(ctx, query, rating, time.Now(), id)
 	return err
@@ -115,7 +206,67 @@ func (r *driverRepository) GetOnlineDriversByVehicleType(ctx context.Context, ve
 		SELECT * FROM drivers
 		WHERE status = $1 AND vehicle_type = $2
 		AND current_lat IS NOT NULL AND current_lng IS NOT NULL
+		AND ($3 = '' OR tenant_code = $3)
+	`
+	err := r.db.SelectContext(ctx, &drivers, query, models.DriverStatusOnline, vehicleType, tenant.Code(ctx))
+	return drivers, err
+}
+
+func (r *driverRepository) GetOnlineInBounds(ctx context.Context, vehicleType string, minLat, minLng, maxLat, maxLng float64) ([]*models.Driver, error) {
+	var drivers []*models.Driver
+	query := `
+		SELECT * FROM drivers
+		WHERE status = $1 AND vehicle_type = $2
+		AND current_lat BETWEEN $3 AND $4 AND current_lng BETWEEN $5 AND $6
+		AND (cooldown_until IS NULL OR cooldown_until <= $7)
+		AND ($8 = '' OR tenant_code = $8)
 	`
-	err := r.db.SelectContext(ctx, &drivers, query, models.DriverStatusOnline, vehicleType)
+	err := r.db.SelectContext(ctx, &drivers, query, models.DriverStatusOnline, vehicleType,
+		minLat, maxLat, minLng, maxLng, time.Now(), tenant.Code(ctx))
+	return drivers, err
+}
+
+func (r *driverRepository) GetByStatuses(ctx context.Context, statuses ...string) ([]*models.Driver, error) {
+	var drivers []*models.Driver
+	query := `SELECT * FROM drivers WHERE status = ANY($1)`
+	err := r.db.SelectContext(ctx, &drivers, query, pq.Array(statuses))
+	return drivers, err
+}
+
+func (r *driverRepository) GetByIDs(ctx context.Context, ids []string) ([]*models.Driver, error) {
+	var drivers []*models.Driver
+	if len(ids) == 0 {
+		return drivers, nil
+	}
+	query := `SELECT * FROM drivers WHERE id = ANY($1) AND ($2 = '' OR tenant_code = $2)`
+	err := r.db.SelectContext(ctx, &drivers, query, pq.Array(ids), tenant.Code(ctx))
 	return drivers, err
 }
+
+func (r *driverRepository) MarkVerified(ctx context.Context, id string, at time.Time) error {
+	query := `UPDATE drivers SET verified_at = $1, updated_at = $2 WHERE id = $3`
+	_, err := r.db.ExecContext(ctx, query, at, time.Now(), id)
+	return err
+}
+
+func (r *driverRepository) UpdateEarningsGoal(ctx context.Context, id string, goal *float64) error {
+	query := `UPDATE drivers SET daily_earnings_goal = $1, updated_at = $2 WHERE id = $3`
+	_, err := r.db.ExecContext(ctx, query, goal, time.Now(), id)
+	return err
+}
+
+func (r *driverRepository) UpdatePaymentMethods(ctx context.Context, id string, methods []string) error {
+	var accepted pq.StringArray
+	if len(methods) > 0 {
+		accepted = methods
+	}
+	query := `UPDATE drivers SET accepted_payment_methods = $1, updated_at = $2 WHERE id = $3`
+	_, err := r.db.ExecContext(ctx, query, accepted, time.Now(), id)
+	return err
+}
+
+func (r *driverRepository) SetCooldown(ctx context.Context, id string, until time.Time, reason string) error {
+	query := `UPDATE drivers SET cooldown_until = $1, cooldown_reason = $2, updated_at = $3 WHERE id = $4`
+	_, err := r.db.ExecContext(ctx, query, until, reason, time.Now(), id)
+	return err
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type FareAdjustmentRepository interface {
+	// ApplySurge records the adjustment and reprices the ride in one statement
+	ApplySurge(ctx context.Context, adj *models.FareAdjustment) error
+	GetByRideID(ctx context.Context, rideID string) ([]*models.FareAdjustment, error)
+}
+
+type fareAdjustmentRepository struct {
+	db *sqlx.DB
+}
+
+func NewFareAdjustmentRepository(db *sqlx.DB) FareAdjustmentRepository {
+	return &fareAdjustmentRepository{db: db}
+}
+
+func (r *fareAdjustmentRepository) ApplySurge(ctx context.Context, adj *models.FareAdjustment) error {
+	if adj.ID == "" {
+		adj.ID = uuid.New().String()
+	}
+	adj.CreatedAt = time.Now()
+
+	query := `
+		WITH adjustment AS (
+			INSERT INTO ride_fare_adjustments (id, ride_id, reason, original_surge, adjusted_surge,
+				original_fare, adjusted_fare, original_eta_mins, replacement_eta_mins, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		)
+		UPDATE rides SET surge_multiplier = $5, estimated_fare = $7, updated_at = $10
+		WHERE id = $2
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		adj.ID, adj.RideID, adj.Reason, adj.OriginalSurge, adj.AdjustedSurge,
+		adj.OriginalFare, adj.AdjustedFare, adj.OriginalETAMin, adj.ReplacementETAMin, adj.CreatedAt)
+	return err
+}
+
+func (r *fareAdjustmentRepository) GetByRideID(ctx context.Context, rideID string) ([]*models.FareAdjustment, error) {
+	var adjustments []*models.FareAdjustment
+	query := `SELECT * FROM ride_fare_adjustments WHERE ride_id = $1 ORDER BY created_at`
+	err := r.db.SelectContext(ctx, &adjustments, query, rideID)
+	return adjustments, err
+}
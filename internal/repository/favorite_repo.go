@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+type FavoriteRepository interface {
+	Add(ctx context.Context, fav *models.FavoriteDriver) error
+	Remove(ctx context.Context, userID, driverID string) (bool, error)
+	GetByUserID(ctx context.Context, userID string) ([]*models.FavoriteDriver, error)
+	CountByUserID(ctx context.Context, userID string) (int, error)
+}
+
+type favoriteRepository struct {
+	db *sqlx.DB
+}
+
+func NewFavoriteRepository(db *sqlx.DB) FavoriteRepository {
+	return &favoriteRepository{db: db}
+}
+
+func (r *favoriteRepository) Add(ctx context.Context, fav *models.FavoriteDriver) error {
+	fav.CreatedAt = time.Now()
+
+	query := `
+		INSERT INTO favorite_drivers (user_id, driver_id, created_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, driver_id) DO NOTHING
+	`
+	_, err := r.db.ExecContext(ctx, query, fav.UserID, fav.DriverID, fav.CreatedAt)
+	return err
+}
+
+func (r *favoriteRepository) Remove(ctx context.Context, userID, driverID string) (bool, error) {
+	query := `DELETE FROM favorite_drivers WHERE user_id = $1 AND driver_id = $2`
+	result, err := r.db.ExecContext(ctx, query, userID, driverID)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+func (r *favoriteRepository) GetByUserID(ctx context.Context, userID string) ([]*models.FavoriteDriver, error) {
+	var favorites []*models.FavoriteDriver
+	query := `SELECT * FROM favorite_drivers WHERE user_id = $1 ORDER BY created_at DESC`
+	err := r.db.SelectContext(ctx, &favorites, query, userID)
+	return favorites, err
+}
+
+func (r *favoriteRepository) CountByUserID(ctx context.Context, userID string) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM favorite_drivers WHERE user_id = $1`
+	err := r.db.GetContext(ctx, &count, query, userID)
+	return count, err
+}
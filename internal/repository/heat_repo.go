@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+type HeatRepository interface {
+	// Capture snapshots driver supply now and ride requests since the given time
+	// per grid cell and vehicle type, returning the number of cells recorded
+	Capture(ctx context.Context, cellDegrees float64, since, at time.Time) (int, error)
+	GetHistory(ctx context.Context, q *models.HeatQuery) ([]*models.HeatCell, error)
+	DeleteBefore(ctx context.Context, before time.Time) (int, error)
+}
+
+type heatRepository struct {
+	db *sqlx.DB
+}
+
+func NewHeatRepository(db *sqlx.DB) HeatRepository {
+	return &heatRepository{db: db}
+}
+
+func (r *heatRepository) Capture(ctx context.Context, cellDegrees float64, since, at time.Time) (int, error) {
+	query := `
+		WITH supply AS (
+			SELECT FLOOR(current_lat / $1::numeric) * $1::numeric AS cell_lat,
+				FLOOR(current_lng / $1::numeric) * $1::numeric AS cell_lng,
+				vehicle_type,
+				COUNT(*) FILTER (WHERE status = $2) AS online_drivers,
+				COUNT(*) FILTER (WHERE status = $3) AS busy_drivers
+			FROM drivers
+			WHERE status IN ($2, $3) AND current_lat IS NOT NULL AND current_lng IS NOT NULL
+			GROUP BY 1, 2, 3
+		), demand AS (
+			SELECT FLOOR(pickup_lat / $1::numeric) * $1::numeric AS cell_lat,
+				FLOOR(pickup_lng / $1::numeric) * $1::numeric AS cell_lng,
+				vehicle_type,
+				COUNT(*) AS ride_requests,
+				AVG(surge_multiplier) AS avg_surge
+			FROM rides
+			WHERE created_at >= $4 AND created_at < $5
+			GROUP BY 1, 2, 3
+		)
+		INSERT INTO heat_snapshots (cell_lat, cell_lng, vehicle_type, online_drivers, busy_drivers,
+			ride_requests, avg_surge, hour_of_week, captured_at)
+		SELECT COALESCE(s.cell_lat, d.cell_lat), COALESCE(s.cell_lng, d.cell_lng),
+			COALESCE(s.vehicle_type, d.vehicle_type),
+			COALESCE(s.online_drivers, 0), COALESCE(s.busy_drivers, 0),
+			COALESCE(d.ride_requests, 0), ROUND(d.avg_surge, 2), $6, $5
+		FROM supply s
+		FULL OUTER JOIN demand d
+			ON d.cell_lat = s.cell_lat AND d.cell_lng = s.cell_lng AND d.vehicle_type = s.vehicle_type
+	`
+	result, err := r.db.ExecContext(ctx, query, cellDegrees,
+		models.DriverStatusOnline, models.DriverStatusBusy, since, at, models.HourOfWeek(at))
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+func (r *heatRepository) GetHistory(ctx context.Context, q *models.HeatQuery) ([]*models.HeatCell, error) {
+	var cells []*models.HeatCell
+	// Cells with no drivers and no requests aren't stored, so averages are taken
+	// over every capture in the hour rather than only the ones that saw the cell
+	query := `
+		WITH captures AS (
+			SELECT hour_of_week, COUNT(DISTINCT captured_at) AS samples
+			FROM heat_snapshots
+			WHERE captured_at >= $5
+			GROUP BY hour_of_week
+		)
+		SELECT h.cell_lat, h.cell_lng, h.vehicle_type, h.hour_of_week, c.samples,
+			ROUND(SUM(h.online_drivers)::numeric / c.samples, 2) AS avg_online_drivers,
+			ROUND(SUM(h.busy_drivers)::numeric / c.samples, 2) AS avg_busy_drivers,
+			ROUND(SUM(h.ride_requests)::numeric / c.samples, 2) AS avg_ride_requests,
+			ROUND(AVG(h.avg_surge), 2) AS avg_surge
+		FROM heat_snapshots h
+		JOIN captures c ON c.hour_of_week = h.hour_of_week
+		WHERE h.cell_lat BETWEEN $1 AND $3 AND h.cell_lng BETWEEN $2 AND $4
+			AND h.captured_at >= $5
+			AND ($6 = '' OR h.vehicle_type = $6)
+			AND ($7 < 0 OR h.hour_of_week = $7)
+		GROUP BY h.cell_lat, h.cell_lng, h.vehicle_type, h.hour_of_week, c.samples
+		ORDER BY h.hour_of_week, h.cell_lat, h.cell_lng, h.vehicle_type
+	`
+	err := r.db.SelectContext(ctx, &cells, query,
+		q.MinLat, q.MinLng, q.MaxLat, q.MaxLng, q.Since, q.VehicleType, q.HourOfWeek)
+	return cells, err
+}
+
+func (r *heatRepository) DeleteBefore(ctx context.Context, before time.Time) (int, error) {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM heat_snapshots WHERE captured_at < $1", before)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
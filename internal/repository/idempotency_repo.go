@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// IdempotencyRepository keeps Idempotency-Key responses in Postgres for when
+// Redis is unreachable
+type IdempotencyRepository interface {
+	// Get returns the unexpired entry for key, or nil
+	Get(ctx context.Context, key string) (*models.IdempotentResponse, error)
+	// Lock claims key for a request in flight. It fails while another request holds
+	// the key or its response is stored, unless that entry has expired.
+	Lock(ctx context.Context, key, bodyHash string, lockTTL, ttl time.Duration) (bool, error)
+	Save(ctx context.Context, key string, statusCode int, contentType string, body []byte) error
+	// Release frees a key whose request produced no response worth replaying
+	Release(ctx context.Context, key string) error
+}
+
+type idempotencyRepository struct {
+	db *sqlx.DB
+}
+
+func NewIdempotencyRepository(db *sqlx.DB) IdempotencyRepository {
+	return &idempotencyRepository{db: db}
+}
+
+func (r *idempotencyRepository) Get(ctx context.Context, key string) (*models.IdempotentResponse, error) {
+	var resp models.IdempotentResponse
+	query := `SELECT * FROM idempotency_keys WHERE key = $1 AND expires_at > NOW()`
+	err := r.db.GetContext(ctx, &resp, query, key)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &resp, err
+}
+
+func (r *idempotencyRepository) Lock(ctx context.Context, key, bodyHash string, lockTTL, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	query := `
+		INSERT INTO idempotency_keys (key, body_hash, locked_until, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (key) DO UPDATE
+		SET body_hash = EXCLUDED.body_hash, status_code = NULL, content_type = NULL, body = NULL,
+			locked_until = EXCLUDED.locked_until, expires_at = EXCLUDED.expires_at, created_at = EXCLUDED.created_at
+		WHERE idempotency_keys.expires_at <= $5
+			OR (idempotency_keys.status_code IS NULL AND idempotency_keys.locked_until <= $5)
+	`
+	result, err := r.db.ExecContext(ctx, query, key, bodyHash, now.Add(lockTTL), now.Add(ttl), now)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+func (r *idempotencyRepository) Save(ctx context.Context, key string, statusCode int, contentType string, body []byte) error {
+	query := `UPDATE idempotency_keys SET status_code = $1, content_type = $2, body = $3 WHERE key = $4`
+	_, err := r.db.ExecContext(ctx, query, statusCode, contentType, body, key)
+	return err
+}
+
+func (r *idempotencyRepository) Release(ctx context.Context, key string) error {
+	query := `DELETE FROM idempotency_keys WHERE key = $1 AND status_code IS NULL`
+	_, err := r.db.ExecContext(ctx, query, key)
+	return err
+}
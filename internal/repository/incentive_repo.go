@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type IncentiveRepository interface {
+	Create(ctx context.Context, guarantee *models.IncentiveGuarantee) error
+	GetByID(ctx context.Context, id string) (*models.IncentiveGuarantee, error)
+	List(ctx context.Context) ([]*models.IncentiveGuarantee, error)
+	GetActive(ctx context.Context) ([]*models.IncentiveGuarantee, error)
+	SetActive(ctx context.Context, id string, active bool) error
+	// RecordTopUp posts a guarantee top-up to the driver's ledger, once per trip
+	RecordTopUp(ctx context.Context, entry *models.LedgerEntry) (bool, error)
+}
+
+type incentiveRepository struct {
+	db *sqlx.DB
+}
+
+func NewIncentiveRepository(db *sqlx.DB) IncentiveRepository {
+	return &incentiveRepository{db: db}
+}
+
+func (r *incentiveRepository) Create(ctx context.Context, guarantee *models.IncentiveGuarantee) error {
+	if guarantee.ID == "" {
+		guarantee.ID = uuid.New().String()
+	}
+	now := time.Now()
+	guarantee.CreatedAt = now
+	guarantee.UpdatedAt = now
+	guarantee.Active = true
+
+	query := `
+		INSERT INTO incentive_guarantees (id, name, region_code, vehicle_type, center_lat, center_lng,
+			radius_km, start_minute, end_minute, min_earnings, required_training, active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		guarantee.ID, guarantee.Name, guarantee.RegionCode, guarantee.VehicleType,
+		guarantee.CenterLat, guarantee.CenterLng, guarantee.RadiusKm,
+		guarantee.StartMinute, guarantee.EndMinute, guarantee.MinEarnings, guarantee.RequiredTraining, guarantee.Active,
+		guarantee.CreatedAt, guarantee.UpdatedAt)
+	return err
+}
+
+func (r *incentiveRepository) GetByID(ctx context.Context, id string) (*models.IncentiveGuarantee, error) {
+	var guarantee models.IncentiveGuarantee
+	query := `SELECT * FROM incentive_guarantees WHERE id = $1`
+	err := r.db.GetContext(ctx, &guarantee, query, id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &guarantee, err
+}
+
+func (r *incentiveRepository) List(ctx context.Context) ([]*models.IncentiveGuarantee, error) {
+	var guarantees []*models.IncentiveGuarantee
+	query := `SELECT * FROM incentive_guarantees ORDER BY created_at DESC`
+	err := r.db.SelectContext(ctx, &guarantees, query)
+	return guarantees, err
+}
+
+func (r *incentiveRepository) GetActive(ctx context.Context) ([]*models.IncentiveGuarantee, error) {
+	var guarantees []*models.IncentiveGuarantee
+	query := `SELECT * FROM incentive_guarantees WHERE active = TRUE`
+	err := r.db.SelectContext(ctx, &guarantees, query)
+	return guarantees, err
+}
+
+func (r *incentiveRepository) SetActive(ctx context.Context, id string, active bool) error {
+	query := `UPDATE incentive_guarantees SET active = $1, updated_at = $2 WHERE id = $3`
+	_, err := r.db.ExecContext(ctx, query, active, time.Now(), id)
+	return err
+}
+
+func (r *incentiveRepository) RecordTopUp(ctx context.Context, entry *models.LedgerEntry) (bool, error) {
+	if entry.ID == "" {
+		entry.ID = uuid.New().String()
+	}
+	entry.CreatedAt = time.Now()
+	entry.EntryType = models.LedgerEntryIncentive
+
+	query := `
+		INSERT INTO driver_ledger_entries (id, driver_id, entry_type, amount, description,
+			trip_id, incentive_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (trip_id) WHERE entry_type = 'incentive' DO NOTHING
+	`
+	result, err := r.db.ExecContext(ctx, query,
+		entry.ID, entry.DriverID, entry.EntryType, entry.Amount, entry.Description,
+		entry.TripID, entry.IncentiveID, entry.CreatedAt)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type InsuranceRepository interface {
+	CreatePolicy(ctx context.Context, policy *models.TripInsurance) error
+	GetPolicyByTripID(ctx context.Context, tripID string) (*models.TripInsurance, error)
+	// GetUninsuredTrips returns trips in progress, started before the given time,
+	// that have no policy, oldest first
+	GetUninsuredTrips(ctx context.Context, startedBefore time.Time, limit int) ([]*models.Trip, error)
+	CreateClaim(ctx context.Context, claim *models.InsuranceClaim) error
+	GetClaimByID(ctx context.Context, id string) (*models.InsuranceClaim, error)
+	MarkClaimSubmitted(ctx context.Context, id, providerReference string) error
+}
+
+type insuranceRepository struct {
+	db *sqlx.DB
+}
+
+func NewInsuranceRepository(db *sqlx.DB) InsuranceRepository {
+	return &insuranceRepository{db: db}
+}
+
+func (r *insuranceRepository) CreatePolicy(ctx context.Context, policy *models.TripInsurance) error {
+	if policy.ID == "" {
+		policy.ID = uuid.New().String()
+	}
+	policy.IssuedAt = time.Now()
+
+	query := `
+		INSERT INTO trip_insurance (id, trip_id, provider, policy_number, coverage, issued_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (trip_id) DO NOTHING
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		policy.ID, policy.TripID, policy.Provider, policy.PolicyNumber, policy.Coverage, policy.IssuedAt)
+	return err
+}
+
+func (r *insuranceRepository) GetPolicyByTripID(ctx context.Context, tripID string) (*models.TripInsurance, error) {
+	var policy models.TripInsurance
+	query := `SELECT * FROM trip_insurance WHERE trip_id = $1`
+	err := r.db.GetContext(ctx, &policy, query, tripID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &policy, err
+}
+
+func (r *insuranceRepository) GetUninsuredTrips(ctx context.Context, startedBefore time.Time, limit int) ([]*models.Trip, error) {
+	var trips []*models.Trip
+	query := `
+		SELECT * FROM trips t
+		WHERE t.status IN ($1, $2, $3) AND t.start_time < $4
+		AND NOT EXISTS (SELECT 1 FROM trip_insurance i WHERE i.trip_id = t.id)
+		ORDER BY t.start_time
+		LIMIT $5
+	`
+	err := r.db.SelectContext(ctx, &trips, query, models.TripStatusStarted, models.TripStatusPaused,
+		models.TripStatusHandover, startedBefore, limit)
+	return trips, err
+}
+
+func (r *insuranceRepository) CreateClaim(ctx context.Context, claim *models.InsuranceClaim) error {
+	if claim.ID == "" {
+		claim.ID = uuid.New().String()
+	}
+	now := time.Now()
+	claim.CreatedAt = now
+	claim.UpdatedAt = now
+	claim.Status = models.ClaimStatusReceived
+
+	query := `
+		INSERT INTO insurance_claims (id, trip_id, claimant_type, claimant_id, incident_type,
+			incident_at, description, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		claim.ID, claim.TripID, claim.ClaimantType, claim.ClaimantID, claim.IncidentType,
+		claim.IncidentAt, claim.Description, claim.Status, claim.CreatedAt, claim.UpdatedAt)
+	return err
+}
+
+func (r *insuranceRepository) GetClaimByID(ctx context.Context, id string) (*models.InsuranceClaim, error) {
+	var claim models.InsuranceClaim
+	query := `SELECT * FROM insurance_claims WHERE id = $1`
+	err := r.db.GetContext(ctx, &claim, query, id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &claim, err
+}
+
+func (r *insuranceRepository) MarkClaimSubmitted(ctx context.Context, id, providerReference string) error {
+	query := `
+		UPDATE insurance_claims
+		SET status = $1, provider_reference = $2, updated_at = $3
+		WHERE id = $4
+	`
+	_, err := r.db.ExecContext(ctx, query, models.ClaimStatusSubmitted, providerReference, time.Now(), id)
+	return err
+}
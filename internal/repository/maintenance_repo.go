@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type MaintenanceRepository interface {
+	Create(ctx context.Context, window *models.MaintenanceWindow) error
+	GetByID(ctx context.Context, id string) (*models.MaintenanceWindow, error)
+	List(ctx context.Context) ([]*models.MaintenanceWindow, error)
+	// GetNext returns the earliest active window that hasn't ended at the given
+	// time and starts before until, or nil
+	GetNext(ctx context.Context, at, until time.Time) (*models.MaintenanceWindow, error)
+	SetActive(ctx context.Context, id string, active bool) error
+}
+
+type maintenanceRepository struct {
+	db *sqlx.DB
+}
+
+func NewMaintenanceRepository(db *sqlx.DB) MaintenanceRepository {
+	return &maintenanceRepository{db: db}
+}
+
+func (r *maintenanceRepository) Create(ctx context.Context, window *models.MaintenanceWindow) error {
+	if window.ID == "" {
+		window.ID = uuid.New().String()
+	}
+	now := time.Now()
+	window.Active = true
+	window.CreatedAt = now
+	window.UpdatedAt = now
+
+	query := `
+		INSERT INTO maintenance_windows (id, message, starts_at, ends_at, active, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		window.ID, window.Message, window.StartsAt, window.EndsAt, window.Active, window.CreatedBy,
+		window.CreatedAt, window.UpdatedAt)
+	return err
+}
+
+func (r *maintenanceRepository) GetByID(ctx context.Context, id string) (*models.MaintenanceWindow, error) {
+	var window models.MaintenanceWindow
+	query := `SELECT * FROM maintenance_windows WHERE id = $1`
+	err := r.db.GetContext(ctx, &window, query, id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &window, err
+}
+
+func (r *maintenanceRepository) List(ctx context.Context) ([]*models.MaintenanceWindow, error) {
+	var windows []*models.MaintenanceWindow
+	query := `SELECT * FROM maintenance_windows ORDER BY starts_at DESC`
+	err := r.db.SelectContext(ctx, &windows, query)
+	return windows, err
+}
+
+func (r *maintenanceRepository) GetNext(ctx context.Context, at, until time.Time) (*models.MaintenanceWindow, error) {
+	var window models.MaintenanceWindow
+	query := `
+		SELECT * FROM maintenance_windows
+		WHERE active = TRUE AND ends_at > $1 AND starts_at <= $2
+		ORDER BY starts_at
+		LIMIT 1
+	`
+	err := r.db.GetContext(ctx, &window, query, at, until)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &window, err
+}
+
+func (r *maintenanceRepository) SetActive(ctx context.Context, id string, active bool) error {
+	query := `UPDATE maintenance_windows SET active = $1, updated_at = $2 WHERE id = $3`
+	_, err := r.db.ExecContext(ctx, query, active, time.Now(), id)
+	return err
+}
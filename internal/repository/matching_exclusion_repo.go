@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type MatchingExclusionRepository interface {
+	Create(ctx context.Context, exclusion *models.MatchingExclusion) error
+	GetByID(ctx context.Context, id string) (*models.MatchingExclusion, error)
+	// List returns exclusions newest first, including expired and revoked ones
+	// unless activeAt is set
+	List(ctx context.Context, activeAt *time.Time) ([]*models.MatchingExclusion, error)
+	Revoke(ctx context.Context, id, revokedBy string, at time.Time) error
+}
+
+type matchingExclusionRepository struct {
+	db *sqlx.DB
+}
+
+func NewMatchingExclusionRepository(db *sqlx.DB) MatchingExclusionRepository {
+	return &matchingExclusionRepository{db: db}
+}
+
+func (r *matchingExclusionRepository) Create(ctx context.Context, exclusion *models.MatchingExclusion) error {
+	if exclusion.ID == "" {
+		exclusion.ID = uuid.New().String()
+	}
+	exclusion.CreatedAt = time.Now()
+
+	query := `
+		INSERT INTO matching_exclusions (id, kind, driver_id, center_lat, center_lng, radius_km,
+			reason, created_by, starts_at, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		exclusion.ID, exclusion.Kind, exclusion.DriverID, exclusion.CenterLat, exclusion.CenterLng,
+		exclusion.RadiusKm, exclusion.Reason, exclusion.CreatedBy, exclusion.StartsAt, exclusion.ExpiresAt,
+		exclusion.CreatedAt)
+	return err
+}
+
+func (r *matchingExclusionRepository) GetByID(ctx context.Context, id string) (*models.MatchingExclusion, error) {
+	var exclusion models.MatchingExclusion
+	query := `SELECT * FROM matching_exclusions WHERE id = $1`
+	err := r.db.GetContext(ctx, &exclusion, query, id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &exclusion, err
+}
+
+func (r *matchingExclusionRepository) List(ctx context.Context, activeAt *time.Time) ([]*models.MatchingExclusion, error) {
+	var exclusions []*models.MatchingExclusion
+	if activeAt == nil {
+		query := `SELECT * FROM matching_exclusions ORDER BY created_at DESC`
+		err := r.db.SelectContext(ctx, &exclusions, query)
+		return exclusions, err
+	}
+
+	query := `
+		SELECT * FROM matching_exclusions
+		WHERE revoked_at IS NULL AND starts_at <= $1 AND expires_at > $1
+		ORDER BY created_at DESC
+	`
+	err := r.db.SelectContext(ctx, &exclusions, query, *activeAt)
+	return exclusions, err
+}
+
+func (r *matchingExclusionRepository) Revoke(ctx context.Context, id, revokedBy string, at time.Time) error {
+	query := `UPDATE matching_exclusions SET revoked_at = $1, revoked_by = $2 WHERE id = $3`
+	_, err := r.db.ExecContext(ctx, query, at, revokedBy, id)
+	return err
+}
@@ -0,0 +1,40 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+// auditRepository reads the entries added with Store.RecordAudit. In Postgres the
+// audit log is written by triggers, which the store doesn't emulate.
+type auditRepository struct {
+	s *Store
+}
+
+func NewAuditRepository(s *Store) repository.AuditRepository {
+	return &auditRepository{s: s}
+}
+
+func (r *auditRepository) GetByRideIDUntil(ctx context.Context, rideID string, until time.Time) ([]*models.AuditEntry, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var entries []*models.AuditEntry
+	for _, e := range r.s.audit {
+		if e.RideID == rideID && !e.RecordedAt.After(until) {
+			c := *e
+			entries = append(entries, &c)
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if !entries[i].RecordedAt.Equal(entries[j].RecordedAt) {
+			return entries[i].RecordedAt.Before(entries[j].RecordedAt)
+		}
+		return entries[i].ID < entries[j].ID
+	})
+	return entries, nil
+}
@@ -0,0 +1,170 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+type backupRepository struct {
+	s *Store
+}
+
+func NewBackupRepository(s *Store) repository.BackupRepository {
+	return &backupRepository{s: s}
+}
+
+func (r *backupRepository) Create(ctx context.Context, backup *models.Backup) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if backup.ID == "" {
+		backup.ID = newID()
+	}
+	backup.CreatedAt = time.Now()
+	backup.Status = models.BackupStatusPending
+
+	c := *backup
+	r.s.backups[backup.ID] = &c
+	return nil
+}
+
+func (r *backupRepository) GetByID(ctx context.Context, id string) (*models.Backup, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	backup, ok := r.s.backups[id]
+	if !ok {
+		return nil, nil
+	}
+	c := *backup
+	return &c, nil
+}
+
+func (r *backupRepository) GetLatest(ctx context.Context) (*models.Backup, error) {
+	backups, err := r.List(ctx, 1)
+	if err != nil || len(backups) == 0 {
+		return nil, err
+	}
+	return backups[0], nil
+}
+
+func (r *backupRepository) List(ctx context.Context, limit int) ([]*models.Backup, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var backups []*models.Backup
+	for _, b := range r.s.backups {
+		c := *b
+		backups = append(backups, &c)
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].CreatedAt.After(backups[j].CreatedAt) })
+	return page(backups, limit, 0), nil
+}
+
+func (r *backupRepository) ClaimPending(ctx context.Context, limit int, staleBefore time.Time) ([]*models.Backup, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var claimable []*models.Backup
+	for _, b := range r.s.backups {
+		if b.Status == models.BackupStatusPending ||
+			(b.Status == models.BackupStatusProcessing && b.StartedAt != nil && b.StartedAt.Before(staleBefore)) {
+			claimable = append(claimable, b)
+		}
+	}
+	sort.Slice(claimable, func(i, j int) bool { return claimable[i].CreatedAt.Before(claimable[j].CreatedAt) })
+
+	now := time.Now()
+	var backups []*models.Backup
+	for _, b := range page(claimable, limit, 0) {
+		b.Status = models.BackupStatusProcessing
+		b.StartedAt = &now
+		c := *b
+		backups = append(backups, &c)
+	}
+	return backups, nil
+}
+
+func (r *backupRepository) MarkReady(ctx context.Context, id, manifestKey string, rowCount int, snapshotAt time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if backup, ok := r.s.backups[id]; ok {
+		now := time.Now()
+		backup.Status = models.BackupStatusReady
+		backup.ManifestKey = &manifestKey
+		backup.RowCount = &rowCount
+		backup.SnapshotAt = &snapshotAt
+		backup.CompletedAt = &now
+	}
+	return nil
+}
+
+func (r *backupRepository) MarkFailed(ctx context.Context, id, reason string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if backup, ok := r.s.backups[id]; ok {
+		now := time.Now()
+		backup.Status = models.BackupStatusFailed
+		backup.Error = &reason
+		backup.CompletedAt = &now
+	}
+	return nil
+}
+
+// Dump holds the store lock for the whole dump, which keeps it consistent. Rows
+// are encoded with their API field names rather than column names.
+func (r *backupRepository) Dump(ctx context.Context, tables []string, write func(table string, row []byte) error) (time.Time, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	snapshotAt := time.Now()
+	for _, table := range tables {
+		var err error
+		switch table {
+		case "users":
+			err = dumpRows(table, r.s.users, write)
+		case "drivers":
+			err = dumpRows(table, r.s.drivers, write)
+		case "rides":
+			err = dumpRows(table, r.s.rides, write)
+		case "trips":
+			err = dumpRows(table, r.s.trips, write)
+		case "payments":
+			err = dumpRows(table, r.s.payments, write)
+		default:
+			err = fmt.Errorf("backup of table %s not supported", table)
+		}
+		if err != nil {
+			return time.Time{}, err
+		}
+	}
+	return snapshotAt, nil
+}
+
+// dumpRows writes a table's rows ordered by ID, like the SQL dump
+func dumpRows[T any](table string, rows map[string]*T, write func(table string, row []byte) error) error {
+	ids := make([]string, 0, len(rows))
+	for id := range rows {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		row, err := json.Marshal(rows[id])
+		if err != nil {
+			return err
+		}
+		if err := write(table, row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+type cancellationFeeRepository struct {
+	s *Store
+}
+
+func NewCancellationFeeRepository(s *Store) repository.CancellationFeeRepository {
+	return &cancellationFeeRepository{s: s}
+}
+
+func (r *cancellationFeeRepository) Create(ctx context.Context, fee *models.CancellationFee, debit *models.WalletTransaction) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, existing := range r.s.cancellationFees {
+		if existing.RideID == fee.RideID {
+			return false, nil
+		}
+	}
+	if fee.ID == "" {
+		fee.ID = newID()
+	}
+	fee.CreatedAt = time.Now()
+	fee.UpdatedAt = fee.CreatedAt
+	if fee.Currency == "" {
+		fee.Currency = "INR"
+	}
+
+	if wallet, ok := r.s.wallets[fee.UserID]; ok && debit != nil && wallet.Balance+debit.Amount >= 0 {
+		if debit.ID == "" {
+			debit.ID = newID()
+		}
+		debit.CreatedAt = fee.CreatedAt
+		wallet.Balance += debit.Amount
+		wallet.UpdatedAt = debit.CreatedAt
+		debit.BalanceAfter = wallet.Balance
+		t := *debit
+		r.s.walletTxns = append(r.s.walletTxns, &t)
+
+		fee.Status = models.CancellationFeeCharged
+		fee.WalletTxnID = &debit.ID
+	}
+
+	c := *fee
+	r.s.cancellationFees = append(r.s.cancellationFees, &c)
+	return true, nil
+}
+
+func (r *cancellationFeeRepository) GetOutstandingByUserID(ctx context.Context, userID string) ([]*models.CancellationFee, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	// Appended in creation order already
+	var fees []*models.CancellationFee
+	for _, fee := range r.s.cancellationFees {
+		if fee.UserID == userID && fee.Status == models.CancellationFeeOutstanding {
+			c := *fee
+			fees = append(fees, &c)
+		}
+	}
+	return fees, nil
+}
+
+func (r *cancellationFeeRepository) Settle(ctx context.Context, ids []string, paymentID string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	now := time.Now()
+	for _, fee := range r.s.cancellationFees {
+		if fee.Status == models.CancellationFeeOutstanding && contains(ids, fee.ID) {
+			fee.Status = models.CancellationFeeSettled
+			fee.PaymentID = &paymentID
+			fee.UpdatedAt = now
+		}
+	}
+	return nil
+}
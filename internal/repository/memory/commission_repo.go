@@ -0,0 +1,86 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+type commissionRepository struct {
+	s *Store
+}
+
+func NewCommissionRepository(s *Store) repository.CommissionRepository {
+	return &commissionRepository{s: s}
+}
+
+func (r *commissionRepository) Create(ctx context.Context, override *models.CommissionOverride) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if override.ID == "" {
+		override.ID = newID()
+	}
+	override.CreatedAt = time.Now()
+
+	c := *override
+	r.s.commissionOverrides[override.ID] = &c
+	return nil
+}
+
+func (r *commissionRepository) GetByID(ctx context.Context, id string) (*models.CommissionOverride, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	override, ok := r.s.commissionOverrides[id]
+	if !ok {
+		return nil, nil
+	}
+	c := *override
+	return &c, nil
+}
+
+func (r *commissionRepository) GetByDriverID(ctx context.Context, driverID string) ([]*models.CommissionOverride, error) {
+	return r.byDriver(driverID, func(o *models.CommissionOverride) bool { return true }), nil
+}
+
+// GetEffective returns the override in force at the given time. When several
+// overlap, the one that started most recently wins.
+func (r *commissionRepository) GetEffective(ctx context.Context, driverID string, at time.Time) (*models.CommissionOverride, error) {
+	return first(r.byDriver(driverID, func(o *models.CommissionOverride) bool { return o.ActiveAt(at) })), nil
+}
+
+func (r *commissionRepository) End(ctx context.Context, id string, at time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if override, ok := r.s.commissionOverrides[id]; ok {
+		override.EffectiveTo = &at
+	}
+	return nil
+}
+
+// byDriver returns the driver's matching overrides, latest effective_from first
+func (r *commissionRepository) byDriver(driverID string, match func(o *models.CommissionOverride) bool) []*models.CommissionOverride {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var overrides []*models.CommissionOverride
+	for _, o := range r.s.commissionOverrides {
+		if o.DriverID == driverID && match(o) {
+			c := *o
+			overrides = append(overrides, &c)
+		}
+	}
+	sort.Slice(overrides, func(i, j int) bool {
+		a, b := overrides[i], overrides[j]
+		if !a.EffectiveFrom.Equal(b.EffectiveFrom) {
+			return a.EffectiveFrom.After(b.EffectiveFrom)
+		}
+		return a.CreatedAt.After(b.CreatedAt)
+	})
+	return overrides
+}
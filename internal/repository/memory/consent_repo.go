@@ -0,0 +1,125 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+type consentRepository struct {
+	s *Store
+}
+
+func NewConsentRepository(s *Store) repository.ConsentRepository {
+	return &consentRepository{s: s}
+}
+
+// sameDocument reports whether two versions belong to the same document
+func sameDocument(a, b *models.LegalDocument) bool {
+	return a.DocType == b.DocType && a.Audience == b.Audience && deref(a.RegionCode) == deref(b.RegionCode)
+}
+
+func (r *consentRepository) CreateDocument(ctx context.Context, doc *models.LegalDocument) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, existing := range r.s.legalDocuments {
+		if sameDocument(existing, doc) && existing.Version == doc.Version {
+			return false, nil
+		}
+	}
+	if doc.ID == "" {
+		doc.ID = newID()
+	}
+	doc.CreatedAt = time.Now()
+
+	c := *doc
+	r.s.legalDocuments = append(r.s.legalDocuments, &c)
+	return true, nil
+}
+
+func (r *consentRepository) GetDocument(ctx context.Context, id string) (*models.LegalDocument, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, doc := range r.s.legalDocuments {
+		if doc.ID == id {
+			c := *doc
+			return &c, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *consentRepository) ListDocuments(ctx context.Context) ([]*models.LegalDocument, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	// Appended oldest first
+	docs := make([]*models.LegalDocument, 0, len(r.s.legalDocuments))
+	for i := len(r.s.legalDocuments) - 1; i >= 0; i-- {
+		c := *r.s.legalDocuments[i]
+		docs = append(docs, &c)
+	}
+	return docs, nil
+}
+
+func (r *consentRepository) CurrentDocuments(ctx context.Context) ([]*models.LegalDocument, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var current []*models.LegalDocument
+	for _, doc := range r.s.legalDocuments {
+		replaced := false
+		for i, existing := range current {
+			if sameDocument(existing, doc) {
+				c := *doc
+				current[i] = &c
+				replaced = true
+			}
+		}
+		if !replaced {
+			c := *doc
+			current = append(current, &c)
+		}
+	}
+	return current, nil
+}
+
+func (r *consentRepository) Accept(ctx context.Context, record *models.ConsentRecord) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, existing := range r.s.consentRecords {
+		if existing.PartyType == record.PartyType && existing.PartyID == record.PartyID &&
+			existing.DocumentID == record.DocumentID {
+			return false, nil
+		}
+	}
+	if record.ID == "" {
+		record.ID = newID()
+	}
+	record.AcceptedAt = time.Now()
+
+	c := *record
+	r.s.consentRecords = append(r.s.consentRecords, &c)
+	return true, nil
+}
+
+func (r *consentRepository) GetRecords(ctx context.Context, partyType, partyID string) ([]*models.ConsentRecord, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var records []*models.ConsentRecord
+	for _, record := range r.s.consentRecords {
+		if record.PartyType == partyType && record.PartyID == partyID {
+			c := *record
+			records = append(records, &c)
+		}
+	}
+	sort.SliceStable(records, func(i, j int) bool { return records[i].AcceptedAt.After(records[j].AcceptedAt) })
+	return records, nil
+}
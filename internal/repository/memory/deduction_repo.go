@@ -0,0 +1,131 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+type deductionRepository struct {
+	s *Store
+}
+
+func NewDeductionRepository(s *Store) repository.DeductionRepository {
+	return &deductionRepository{s: s}
+}
+
+func (r *deductionRepository) CreateSchedule(ctx context.Context, schedule *models.DeductionSchedule) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if schedule.ID == "" {
+		schedule.ID = newID()
+	}
+	now := time.Now()
+	schedule.CreatedAt = now
+	schedule.UpdatedAt = now
+	schedule.Status = models.DeductionStatusActive
+
+	c := *schedule
+	r.s.deductionSchedules[schedule.ID] = &c
+	return nil
+}
+
+func (r *deductionRepository) GetScheduleByID(ctx context.Context, id string) (*models.DeductionSchedule, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	schedule, ok := r.s.deductionSchedules[id]
+	if !ok {
+		return nil, nil
+	}
+	c := *schedule
+	return &c, nil
+}
+
+func (r *deductionRepository) GetSchedulesByDriverID(ctx context.Context, driverID string) ([]*models.DeductionSchedule, error) {
+	schedules := r.filterSchedules(func(s *models.DeductionSchedule) bool { return s.DriverID == driverID })
+	sort.Slice(schedules, func(i, j int) bool { return schedules[i].CreatedAt.After(schedules[j].CreatedAt) })
+	return schedules, nil
+}
+
+func (r *deductionRepository) GetActiveSchedules(ctx context.Context, today time.Time) ([]*models.DeductionSchedule, error) {
+	return r.filterSchedules(func(s *models.DeductionSchedule) bool {
+		return s.Status == models.DeductionStatusActive && !s.StartDate.After(today)
+	}), nil
+}
+
+func (r *deductionRepository) UpdateScheduleStatus(ctx context.Context, id, status string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if schedule, ok := r.s.deductionSchedules[id]; ok {
+		schedule.Status = status
+		schedule.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+func (r *deductionRepository) ChargePeriod(ctx context.Context, schedule *models.DeductionSchedule, periodDate time.Time) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	now := time.Now()
+	if stored, ok := r.s.deductionSchedules[schedule.ID]; ok {
+		if stored.LastChargedOn == nil || periodDate.After(*stored.LastChargedOn) {
+			stored.LastChargedOn = &periodDate
+		}
+		stored.UpdatedAt = now
+	}
+
+	for _, e := range r.s.ledger {
+		if e.ScheduleID != nil && *e.ScheduleID == schedule.ID && e.PeriodDate != nil && e.PeriodDate.Equal(periodDate) {
+			return false, nil
+		}
+	}
+	r.s.ledger = append(r.s.ledger, &models.LedgerEntry{
+		ID:          newID(),
+		DriverID:    schedule.DriverID,
+		EntryType:   models.LedgerEntryDeduction,
+		Amount:      -schedule.Amount,
+		Description: schedule.Description,
+		ScheduleID:  &schedule.ID,
+		PeriodDate:  &periodDate,
+		CreatedAt:   now,
+	})
+	return true, nil
+}
+
+func (r *deductionRepository) GetLedgerEntries(ctx context.Context, driverID string, from, to time.Time) ([]*models.LedgerEntry, error) {
+	entries := r.s.ledgerEntries(func(e *models.LedgerEntry) bool {
+		return e.DriverID == driverID && !e.CreatedAt.Before(from) && e.CreatedAt.Before(to) &&
+			contains(models.LedgerAdjustmentTypes, e.EntryType)
+	})
+	sort.Slice(entries, func(i, j int) bool { return entries[i].CreatedAt.Before(entries[j].CreatedAt) })
+	return entries, nil
+}
+
+func (r *deductionRepository) GetRecentLedgerEntries(ctx context.Context, driverID string, limit int) ([]*models.LedgerEntry, error) {
+	entries := r.s.ledgerEntries(func(e *models.LedgerEntry) bool {
+		return e.DriverID == driverID && contains(models.LedgerAdjustmentTypes, e.EntryType)
+	})
+	sort.Slice(entries, func(i, j int) bool { return entries[i].CreatedAt.After(entries[j].CreatedAt) })
+	return page(entries, limit, 0), nil
+}
+
+func (r *deductionRepository) filterSchedules(match func(s *models.DeductionSchedule) bool) []*models.DeductionSchedule {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var schedules []*models.DeductionSchedule
+	for _, s := range r.s.deductionSchedules {
+		if match(s) {
+			c := *s
+			schedules = append(schedules, &c)
+		}
+	}
+	return schedules
+}
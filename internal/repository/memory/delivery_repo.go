@@ -0,0 +1,87 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+type deliveryRepository struct {
+	s *Store
+}
+
+func NewDeliveryRepository(s *Store) repository.DeliveryRepository {
+	return &deliveryRepository{s: s}
+}
+
+func (r *deliveryRepository) Create(ctx context.Context, delivery *models.Delivery) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.deliveries[delivery.RideID]; ok {
+		return errDuplicate("deliveries_pkey")
+	}
+	now := time.Now()
+	delivery.CreatedAt = now
+	delivery.UpdatedAt = now
+	if delivery.Status == "" {
+		delivery.Status = models.DeliveryStatusAwaitingPickup
+	}
+
+	c := *delivery
+	r.s.deliveries[delivery.RideID] = &c
+	return nil
+}
+
+func (r *deliveryRepository) GetByRideID(ctx context.Context, rideID string) (*models.Delivery, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	delivery, ok := r.s.deliveries[rideID]
+	if !ok {
+		return nil, nil
+	}
+	c := *delivery
+	return &c, nil
+}
+
+func (r *deliveryRepository) MarkPickedUp(ctx context.Context, rideID, uploadID string) error {
+	r.update(rideID, func(d *models.Delivery, now time.Time) {
+		d.Status = models.DeliveryStatusPickedUp
+		d.PickupPhotoUploadID = &uploadID
+		d.PickedUpAt = &now
+	})
+	return nil
+}
+
+func (r *deliveryRepository) RecordFailedCode(ctx context.Context, rideID string) (int, error) {
+	var attempts int
+	r.update(rideID, func(d *models.Delivery, now time.Time) {
+		d.OTPAttempts++
+		attempts = d.OTPAttempts
+	})
+	return attempts, nil
+}
+
+func (r *deliveryRepository) MarkDelivered(ctx context.Context, rideID, uploadID, receivedBy string) error {
+	r.update(rideID, func(d *models.Delivery, now time.Time) {
+		d.Status = models.DeliveryStatusDelivered
+		d.DropoffPhotoUploadID = &uploadID
+		d.ReceivedBy = &receivedBy
+		d.DeliveredAt = &now
+	})
+	return nil
+}
+
+func (r *deliveryRepository) update(rideID string, fn func(d *models.Delivery, now time.Time)) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if d, ok := r.s.deliveries[rideID]; ok {
+		now := time.Now()
+		fn(d, now)
+		d.UpdatedAt = now
+	}
+}
@@ -0,0 +1,113 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+type dispatchRoundRepository struct {
+	s *Store
+}
+
+func NewDispatchRoundRepository(s *Store) repository.DispatchRoundRepository {
+	return &dispatchRoundRepository{s: s}
+}
+
+func (r *dispatchRoundRepository) Create(ctx context.Context, round *models.DispatchRound) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if round.ID == "" {
+		round.ID = newID()
+	}
+	round.CreatedAt = time.Now()
+
+	c := *round
+	r.s.dispatchRounds = append(r.s.dispatchRounds, &c)
+	return nil
+}
+
+func (r *dispatchRoundRepository) GetFunnel(ctx context.Context, since time.Time, regionCode string) ([]*models.MatchingFunnel, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	type key struct{ region, vehicleType string }
+	funnels := make(map[key]*models.MatchingFunnel)
+	rides := make(map[key]map[string]bool)
+	rounds := make(map[string]*models.MatchingFunnel)
+	responses := make(map[*models.MatchingFunnel][]float64)
+
+	for _, dr := range r.s.dispatchRounds {
+		if dr.CreatedAt.Before(since) || (regionCode != "" && deref(dr.RegionCode) != regionCode) {
+			continue
+		}
+		k := key{deref(dr.RegionCode), dr.VehicleType}
+		f, ok := funnels[k]
+		if !ok {
+			f = &models.MatchingFunnel{RegionCode: dr.RegionCode, VehicleType: dr.VehicleType}
+			funnels[k] = f
+			rides[k] = make(map[string]bool)
+		}
+		rides[k][dr.RideID] = true
+		f.Rides = len(rides[k])
+		f.Rounds++
+		if dr.Candidates == 0 {
+			f.EmptyRounds++
+		}
+		f.Candidates += dr.Candidates
+		f.PassedFilters += dr.PassedFilters
+		rounds[dr.ID] = f
+	}
+
+	// Queued offers were accepted by drivers still finishing a trip
+	for _, o := range r.s.offers {
+		if o.DispatchRoundID == nil || o.OfferedAt.Before(since) {
+			continue
+		}
+		f, ok := rounds[*o.DispatchRoundID]
+		if !ok {
+			continue
+		}
+		f.Offered++
+		if o.ViewedAt != nil {
+			f.Viewed++
+		}
+		if o.Status == models.OfferStatusAccepted || o.Status == models.OfferStatusQueued {
+			f.Accepted++
+		}
+		if o.ResponseMs != nil {
+			responses[f] = append(responses[f], float64(*o.ResponseMs)/1000)
+		}
+	}
+
+	for f, secs := range responses {
+		f.Responded = len(secs)
+		total := 0.0
+		for _, s := range secs {
+			total += s
+		}
+		f.AvgResponseSecs = total / float64(len(secs))
+		f.MedianResponseSecs = percentile(secs, 0.5)
+		f.P90ResponseSecs = percentile(secs, 0.9)
+	}
+
+	var result []*models.MatchingFunnel
+	for _, f := range funnels {
+		result = append(result, f)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if deref(a.RegionCode) != deref(b.RegionCode) || (a.RegionCode == nil) != (b.RegionCode == nil) {
+			if a.RegionCode == nil || b.RegionCode == nil {
+				return b.RegionCode == nil
+			}
+			return *a.RegionCode < *b.RegionCode
+		}
+		return a.VehicleType < b.VehicleType
+	})
+	return result, nil
+}
@@ -0,0 +1,75 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+type driverCacheRepairRepository struct {
+	s *Store
+}
+
+func NewDriverCacheRepairRepository(s *Store) repository.DriverCacheRepairRepository {
+	return &driverCacheRepairRepository{s: s}
+}
+
+func (r *driverCacheRepairRepository) Enqueue(ctx context.Context, driverID, reason string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	now := time.Now()
+	if repair, ok := r.s.cacheRepairs[driverID]; ok {
+		repair.Reason = reason
+		repair.NextAttemptAt = now
+		return nil
+	}
+	r.s.cacheRepairs[driverID] = &models.DriverCacheRepair{
+		DriverID:      driverID,
+		Reason:        reason,
+		CreatedAt:     now,
+		NextAttemptAt: now,
+	}
+	return nil
+}
+
+func (r *driverCacheRepairRepository) GetDue(ctx context.Context, limit int) ([]*models.DriverCacheRepair, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	now := time.Now()
+	var repairs []*models.DriverCacheRepair
+	for _, repair := range r.s.cacheRepairs {
+		if !repair.NextAttemptAt.After(now) {
+			c := *repair
+			repairs = append(repairs, &c)
+		}
+	}
+	sort.Slice(repairs, func(i, j int) bool { return repairs[i].NextAttemptAt.Before(repairs[j].NextAttemptAt) })
+	return page(repairs, limit, 0), nil
+}
+
+func (r *driverCacheRepairRepository) Delete(ctx context.Context, repair *models.DriverCacheRepair) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if queued, ok := r.s.cacheRepairs[repair.DriverID]; ok && queued.NextAttemptAt.Equal(repair.NextAttemptAt) {
+		delete(r.s.cacheRepairs, repair.DriverID)
+	}
+	return nil
+}
+
+func (r *driverCacheRepairRepository) RecordFailure(ctx context.Context, driverID, reason string, nextAttempt time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if repair, ok := r.s.cacheRepairs[driverID]; ok {
+		repair.Attempts++
+		repair.LastError = &reason
+		repair.NextAttemptAt = nextAttempt
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+	"github.com/aditya/go-comet/internal/tenant"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type driverRepository struct {
+	s *Store
+}
+
+func NewDriverRepository(s *Store) repository.DriverRepository {
+	return &driverRepository{s: s}
+}
+
+func (r *driverRepository) Create(ctx context.Context, driver *models.Driver) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if !r.insert(ctx, driver) {
+		return errDuplicate("drivers_tenant_phone_key")
+	}
+	return nil
+}
+
+func (r *driverRepository) CreateBatch(ctx context.Context, drivers []*models.Driver) (map[string]bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	created := make(map[string]bool)
+	for _, driver := range drivers {
+		if r.insert(ctx, driver) {
+			created[driver.Phone] = true
+		}
+	}
+	return created, nil
+}
+
+// insert stores a new driver unless the phone is taken in its tenant; the caller holds mu
+func (r *driverRepository) insert(ctx context.Context, driver *models.Driver) bool {
+	driver.TenantCode = tenant.CodeOrDefault(ctx)
+	for _, d := range r.s.drivers {
+		if d.Phone == driver.Phone && d.TenantCode == driver.TenantCode {
+			return false
+		}
+	}
+	if driver.ID == "" {
+		driver.ID = newID()
+	}
+	driver.CreatedAt = time.Now()
+	driver.UpdatedAt = time.Now()
+	driver.Rating = 5.0
+	driver.TotalTrips = 0
+	driver.Status = models.DriverStatusOffline
+
+	d := *driver
+	r.s.drivers[driver.ID] = &d
+	return true
+}
+
+func (r *driverRepository) GetByID(ctx context.Context, id string) (*models.Driver, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	d, ok := r.s.drivers[id]
+	if !ok || !inTenant(ctx, d.TenantCode) {
+		return nil, nil
+	}
+	c := *d
+	return &c, nil
+}
+
+func (r *driverRepository) GetByPhone(ctx context.Context, phone string) (*models.Driver, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	code := tenant.CodeOrDefault(ctx)
+	for _, d := range r.s.drivers {
+		if d.Phone == phone && d.TenantCode == code {
+			c := *d
+			return &c, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *driverRepository) Update(ctx context.Context, driver *models.Driver) error {
+	driver.UpdatedAt = time.Now()
+	r.update(driver.ID, func(d *models.Driver) {
+		d.Name = driver.Name
+		d.Email = driver.Email
+		d.VehicleType = driver.VehicleType
+		d.VehicleNumber = driver.VehicleNumber
+	})
+	return nil
+}
+
+func (r *driverRepository) UpdateStatus(ctx context.Context, id string, status string) error {
+	r.update(id, func(d *models.Driver) { d.Status = status })
+	return nil
+}
+
+// GetByIDForUpdate ignores tx; the store's lock is held only for the read
+func (r *driverRepository) GetByIDForUpdate(ctx context.Context, tx *sqlx.Tx, id string) (*models.Driver, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	d, ok := r.s.drivers[id]
+	if !ok {
+		return nil, nil
+	}
+	c := *d
+	return &c, nil
+}
+
+func (r *driverRepository) SetStatus(ctx context.Context, tx *sqlx.Tx, id, status string, at time.Time) error {
+	r.update(id, func(d *models.Driver) { d.Status = status })
+	return nil
+}
+
+func (r *driverRepository) UpdateLocation(ctx context.Context, id string, lat, lng float64) error {
+	r.update(id, func(d *models.Driver) {
+		d.CurrentLat = &lat
+		d.CurrentLng = &lng
+	})
+	return nil
+}
+
+func (r *driverRepository) UpdatePhone(ctx context.Context, id, phone string) error {
+	r.update(id, func(d *models.Driver) { d.Phone = phone })
+	return nil
+}
+
+func (r *driverRepository) SetRating(ctx context.Context, id string, rating float64) error {
+	r.update(id, func(d *models.Driver) { d.Rating = rating })
+	return nil
+}
+
+func (r *driverRepository) IncrementTotalTrips(ctx context.Context, id string) error {
+	r.update(id, func(d *models.Driver) { d.TotalTrips++ })
+	return nil
+}
+
+func (r *driverRepository) GetOnlineDriversByVehicleType(ctx context.Context, vehicleType string) ([]*models.Driver, error) {
+	return r.filter(func(d *models.Driver) bool {
+		return d.Status == models.DriverStatusOnline && d.VehicleType == vehicleType &&
+			d.CurrentLat != nil && d.CurrentLng != nil && inTenant(ctx, d.TenantCode)
+	}), nil
+}
+
+func (r *driverRepository) GetOnlineInBounds(ctx context.Context, vehicleType string, minLat, minLng, maxLat, maxLng float64) ([]*models.Driver, error) {
+	now := time.Now()
+	return r.filter(func(d *models.Driver) bool {
+		return d.Status == models.DriverStatusOnline && d.VehicleType == vehicleType &&
+			d.CurrentLat != nil && *d.CurrentLat >= minLat && *d.CurrentLat <= maxLat &&
+			d.CurrentLng != nil && *d.CurrentLng >= minLng && *d.CurrentLng <= maxLng &&
+			(d.CooldownUntil == nil || !d.CooldownUntil.After(now)) && inTenant(ctx, d.TenantCode)
+	}), nil
+}
+
+func (r *driverRepository) GetByStatuses(ctx context.Context, statuses ...string) ([]*models.Driver, error) {
+	return r.filter(func(d *models.Driver) bool {
+		return contains(statuses, d.Status)
+	}), nil
+}
+
+func (r *driverRepository) GetByIDs(ctx context.Context, ids []string) ([]*models.Driver, error) {
+	return r.filter(func(d *models.Driver) bool {
+		return contains(ids, d.ID) && inTenant(ctx, d.TenantCode)
+	}), nil
+}
+
+func (r *driverRepository) MarkVerified(ctx context.Context, id string, at time.Time) error {
+	r.update(id, func(d *models.Driver) { d.VerifiedAt = &at })
+	return nil
+}
+
+func (r *driverRepository) UpdateEarningsGoal(ctx context.Context, id string, goal *float64) error {
+	r.update(id, func(d *models.Driver) { d.DailyEarningsGoal = goal })
+	return nil
+}
+
+func (r *driverRepository) UpdatePaymentMethods(ctx context.Context, id string, methods []string) error {
+	var accepted pq.StringArray
+	if len(methods) > 0 {
+		accepted = append(pq.StringArray(nil), methods...)
+	}
+	r.update(id, func(d *models.Driver) { d.AcceptedPaymentMethods = accepted })
+	return nil
+}
+
+func (r *driverRepository) SetCooldown(ctx context.Context, id string, until time.Time, reason string) error {
+	r.update(id, func(d *models.Driver) {
+		d.CooldownUntil = &until
+		d.CooldownReason = &reason
+	})
+	return nil
+}
+
+// update applies fn to the stored driver, if there is one, and bumps updated_at
+func (r *driverRepository) update(id string, fn func(d *models.Driver)) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if d, ok := r.s.drivers[id]; ok {
+		fn(d)
+		d.UpdatedAt = time.Now()
+	}
+}
+
+func (r *driverRepository) filter(match func(d *models.Driver) bool) []*models.Driver {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var drivers []*models.Driver
+	for _, d := range r.s.drivers {
+		if match(d) {
+			c := *d
+			drivers = append(drivers, &c)
+		}
+	}
+	return drivers
+}
@@ -0,0 +1,56 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+type fareAdjustmentRepository struct {
+	s *Store
+}
+
+func NewFareAdjustmentRepository(s *Store) repository.FareAdjustmentRepository {
+	return &fareAdjustmentRepository{s: s}
+}
+
+func (r *fareAdjustmentRepository) ApplySurge(ctx context.Context, adj *models.FareAdjustment) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if adj.ID == "" {
+		adj.ID = newID()
+	}
+	adj.CreatedAt = time.Now()
+
+	c := *adj
+	r.s.fareAdjustments = append(r.s.fareAdjustments, &c)
+
+	if ride, ok := r.s.rides[adj.RideID]; ok {
+		ride.SurgeMultiplier = adj.AdjustedSurge
+		ride.EstimatedFare = nil
+		if adj.AdjustedFare != nil {
+			fare := *adj.AdjustedFare
+			ride.EstimatedFare = &fare
+		}
+		ride.UpdatedAt = adj.CreatedAt
+	}
+	return nil
+}
+
+func (r *fareAdjustmentRepository) GetByRideID(ctx context.Context, rideID string) ([]*models.FareAdjustment, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	// Appended in creation order already
+	var adjustments []*models.FareAdjustment
+	for _, a := range r.s.fareAdjustments {
+		if a.RideID == rideID {
+			c := *a
+			adjustments = append(adjustments, &c)
+		}
+	}
+	return adjustments, nil
+}
@@ -0,0 +1,66 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+type favoriteRepository struct {
+	s *Store
+}
+
+func NewFavoriteRepository(s *Store) repository.FavoriteRepository {
+	return &favoriteRepository{s: s}
+}
+
+func (r *favoriteRepository) Add(ctx context.Context, fav *models.FavoriteDriver) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	fav.CreatedAt = time.Now()
+	for _, f := range r.s.favorites {
+		if f.UserID == fav.UserID && f.DriverID == fav.DriverID {
+			return nil
+		}
+	}
+	c := *fav
+	r.s.favorites = append(r.s.favorites, &c)
+	return nil
+}
+
+func (r *favoriteRepository) Remove(ctx context.Context, userID, driverID string) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for i, f := range r.s.favorites {
+		if f.UserID == userID && f.DriverID == driverID {
+			r.s.favorites = append(r.s.favorites[:i], r.s.favorites[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *favoriteRepository) GetByUserID(ctx context.Context, userID string) ([]*models.FavoriteDriver, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var favorites []*models.FavoriteDriver
+	for _, f := range r.s.favorites {
+		if f.UserID == userID {
+			c := *f
+			favorites = append(favorites, &c)
+		}
+	}
+	sort.Slice(favorites, func(i, j int) bool { return favorites[i].CreatedAt.After(favorites[j].CreatedAt) })
+	return favorites, nil
+}
+
+func (r *favoriteRepository) CountByUserID(ctx context.Context, userID string) (int, error) {
+	favorites, err := r.GetByUserID(ctx, userID)
+	return len(favorites), err
+}
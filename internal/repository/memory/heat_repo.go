@@ -0,0 +1,191 @@
+package memory
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+// heatSnapshot is a row of the heat_snapshots table
+type heatSnapshot struct {
+	cellLat, cellLng float64
+	vehicleType      string
+	onlineDrivers    int
+	busyDrivers      int
+	rideRequests     int
+	avgSurge         *float64
+	hourOfWeek       int
+	capturedAt       time.Time
+}
+
+type heatRepository struct {
+	s *Store
+}
+
+func NewHeatRepository(s *Store) repository.HeatRepository {
+	return &heatRepository{s: s}
+}
+
+func (r *heatRepository) Capture(ctx context.Context, cellDegrees float64, since, at time.Time) (int, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	type key struct {
+		lat, lng    float64
+		vehicleType string
+	}
+	cell := func(v float64) float64 { return math.Floor(v/cellDegrees) * cellDegrees }
+
+	snapshots := make(map[key]*heatSnapshot)
+	snapshot := func(k key) *heatSnapshot {
+		s, ok := snapshots[k]
+		if !ok {
+			s = &heatSnapshot{
+				cellLat:     k.lat,
+				cellLng:     k.lng,
+				vehicleType: k.vehicleType,
+				hourOfWeek:  models.HourOfWeek(at),
+				capturedAt:  at,
+			}
+			snapshots[k] = s
+		}
+		return s
+	}
+
+	for _, d := range r.s.drivers {
+		if d.CurrentLat == nil || d.CurrentLng == nil {
+			continue
+		}
+		switch d.Status {
+		case models.DriverStatusOnline:
+			snapshot(key{cell(*d.CurrentLat), cell(*d.CurrentLng), d.VehicleType}).onlineDrivers++
+		case models.DriverStatusBusy:
+			snapshot(key{cell(*d.CurrentLat), cell(*d.CurrentLng), d.VehicleType}).busyDrivers++
+		}
+	}
+
+	surges := make(map[key]float64)
+	for _, ride := range r.s.rides {
+		if ride.CreatedAt.Before(since) || !ride.CreatedAt.Before(at) {
+			continue
+		}
+		k := key{cell(ride.PickupLat), cell(ride.PickupLng), ride.VehicleType}
+		snapshot(k).rideRequests++
+		surges[k] += ride.SurgeMultiplier
+	}
+	for k, total := range surges {
+		s := snapshots[k]
+		avg := math.Round(total/float64(s.rideRequests)*100) / 100
+		s.avgSurge = &avg
+	}
+
+	for _, s := range snapshots {
+		r.s.heatSnapshots = append(r.s.heatSnapshots, s)
+	}
+	return len(snapshots), nil
+}
+
+func (r *heatRepository) GetHistory(ctx context.Context, q *models.HeatQuery) ([]*models.HeatCell, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	// Cells with no drivers and no requests aren't stored, so averages are taken
+	// over every capture in the hour rather than only the ones that saw the cell
+	captures := make(map[int]map[time.Time]bool)
+	for _, s := range r.s.heatSnapshots {
+		if s.capturedAt.Before(q.Since) {
+			continue
+		}
+		if captures[s.hourOfWeek] == nil {
+			captures[s.hourOfWeek] = make(map[time.Time]bool)
+		}
+		captures[s.hourOfWeek][s.capturedAt] = true
+	}
+
+	type key struct {
+		lat, lng    float64
+		vehicleType string
+		hourOfWeek  int
+	}
+	type totals struct {
+		online, busy, requests int
+		surge                  float64
+		surges                 int
+	}
+	groups := make(map[key]*totals)
+	for _, s := range r.s.heatSnapshots {
+		if s.cellLat < q.MinLat || s.cellLat > q.MaxLat || s.cellLng < q.MinLng || s.cellLng > q.MaxLng ||
+			s.capturedAt.Before(q.Since) ||
+			(q.VehicleType != "" && s.vehicleType != q.VehicleType) ||
+			(q.HourOfWeek >= 0 && s.hourOfWeek != q.HourOfWeek) {
+			continue
+		}
+		k := key{s.cellLat, s.cellLng, s.vehicleType, s.hourOfWeek}
+		t, ok := groups[k]
+		if !ok {
+			t = &totals{}
+			groups[k] = t
+		}
+		t.online += s.onlineDrivers
+		t.busy += s.busyDrivers
+		t.requests += s.rideRequests
+		if s.avgSurge != nil {
+			t.surge += *s.avgSurge
+			t.surges++
+		}
+	}
+
+	round := func(v float64) float64 { return math.Round(v*100) / 100 }
+	var cells []*models.HeatCell
+	for k, t := range groups {
+		samples := len(captures[k.hourOfWeek])
+		cell := &models.HeatCell{
+			CellLat:          k.lat,
+			CellLng:          k.lng,
+			VehicleType:      k.vehicleType,
+			HourOfWeek:       k.hourOfWeek,
+			Samples:          samples,
+			AvgOnlineDrivers: round(float64(t.online) / float64(samples)),
+			AvgBusyDrivers:   round(float64(t.busy) / float64(samples)),
+			AvgRideRequests:  round(float64(t.requests) / float64(samples)),
+		}
+		if t.surges > 0 {
+			surge := round(t.surge / float64(t.surges))
+			cell.AvgSurge = &surge
+		}
+		cells = append(cells, cell)
+	}
+	sort.Slice(cells, func(i, j int) bool {
+		a, b := cells[i], cells[j]
+		if a.HourOfWeek != b.HourOfWeek {
+			return a.HourOfWeek < b.HourOfWeek
+		}
+		if a.CellLat != b.CellLat {
+			return a.CellLat < b.CellLat
+		}
+		if a.CellLng != b.CellLng {
+			return a.CellLng < b.CellLng
+		}
+		return a.VehicleType < b.VehicleType
+	})
+	return cells, nil
+}
+
+func (r *heatRepository) DeleteBefore(ctx context.Context, before time.Time) (int, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	kept := r.s.heatSnapshots[:0]
+	for _, s := range r.s.heatSnapshots {
+		if !s.capturedAt.Before(before) {
+			kept = append(kept, s)
+		}
+	}
+	deleted := len(r.s.heatSnapshots) - len(kept)
+	r.s.heatSnapshots = kept
+	return deleted, nil
+}
@@ -0,0 +1,70 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+type idempotencyRepository struct {
+	s *Store
+}
+
+func NewIdempotencyRepository(s *Store) repository.IdempotencyRepository {
+	return &idempotencyRepository{s: s}
+}
+
+func (r *idempotencyRepository) Get(ctx context.Context, key string) (*models.IdempotentResponse, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	resp, ok := r.s.idempotencyKeys[key]
+	if !ok || !resp.ExpiresAt.After(time.Now()) {
+		return nil, nil
+	}
+	c := *resp
+	return &c, nil
+}
+
+func (r *idempotencyRepository) Lock(ctx context.Context, key, bodyHash string, lockTTL, ttl time.Duration) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	now := time.Now()
+	if existing, ok := r.s.idempotencyKeys[key]; ok && existing.ExpiresAt.After(now) &&
+		(existing.StatusCode != nil || existing.LockedUntil.After(now)) {
+		return false, nil
+	}
+	r.s.idempotencyKeys[key] = &models.IdempotentResponse{
+		Key:         key,
+		BodyHash:    bodyHash,
+		LockedUntil: now.Add(lockTTL),
+		ExpiresAt:   now.Add(ttl),
+		CreatedAt:   now,
+	}
+	return true, nil
+}
+
+func (r *idempotencyRepository) Save(ctx context.Context, key string, statusCode int, contentType string, body []byte) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if resp, ok := r.s.idempotencyKeys[key]; ok {
+		resp.StatusCode = &statusCode
+		resp.ContentType = &contentType
+		resp.Body = body
+	}
+	return nil
+}
+
+func (r *idempotencyRepository) Release(ctx context.Context, key string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if resp, ok := r.s.idempotencyKeys[key]; ok && resp.StatusCode == nil {
+		delete(r.s.idempotencyKeys, key)
+	}
+	return nil
+}
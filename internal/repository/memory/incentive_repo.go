@@ -0,0 +1,96 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+type incentiveRepository struct {
+	s *Store
+}
+
+func NewIncentiveRepository(s *Store) repository.IncentiveRepository {
+	return &incentiveRepository{s: s}
+}
+
+func (r *incentiveRepository) Create(ctx context.Context, guarantee *models.IncentiveGuarantee) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if guarantee.ID == "" {
+		guarantee.ID = newID()
+	}
+	now := time.Now()
+	guarantee.CreatedAt = now
+	guarantee.UpdatedAt = now
+	guarantee.Active = true
+
+	c := *guarantee
+	r.s.incentives[guarantee.ID] = &c
+	return nil
+}
+
+func (r *incentiveRepository) GetByID(ctx context.Context, id string) (*models.IncentiveGuarantee, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	guarantee, ok := r.s.incentives[id]
+	if !ok {
+		return nil, nil
+	}
+	c := *guarantee
+	return &c, nil
+}
+
+func (r *incentiveRepository) List(ctx context.Context) ([]*models.IncentiveGuarantee, error) {
+	guarantees := r.filter(func(g *models.IncentiveGuarantee) bool { return true })
+	sort.Slice(guarantees, func(i, j int) bool { return guarantees[i].CreatedAt.After(guarantees[j].CreatedAt) })
+	return guarantees, nil
+}
+
+func (r *incentiveRepository) GetActive(ctx context.Context) ([]*models.IncentiveGuarantee, error) {
+	return r.filter(func(g *models.IncentiveGuarantee) bool { return g.Active }), nil
+}
+
+func (r *incentiveRepository) SetActive(ctx context.Context, id string, active bool) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if guarantee, ok := r.s.incentives[id]; ok {
+		guarantee.Active = active
+		guarantee.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+func (r *incentiveRepository) RecordTopUp(ctx context.Context, entry *models.LedgerEntry) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if entry.ID == "" {
+		entry.ID = newID()
+	}
+	entry.CreatedAt = time.Now()
+	entry.EntryType = models.LedgerEntryIncentive
+	return r.s.postOnce(entry, func(e *models.LedgerEntry) bool {
+		return equalPtr(e.TripID, entry.TripID)
+	}), nil
+}
+
+func (r *incentiveRepository) filter(match func(g *models.IncentiveGuarantee) bool) []*models.IncentiveGuarantee {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var guarantees []*models.IncentiveGuarantee
+	for _, g := range r.s.incentives {
+		if match(g) {
+			c := *g
+			guarantees = append(guarantees, &c)
+		}
+	}
+	return guarantees
+}
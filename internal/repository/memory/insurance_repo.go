@@ -0,0 +1,109 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+type insuranceRepository struct {
+	s *Store
+}
+
+func NewInsuranceRepository(s *Store) repository.InsuranceRepository {
+	return &insuranceRepository{s: s}
+}
+
+func (r *insuranceRepository) CreatePolicy(ctx context.Context, policy *models.TripInsurance) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if policy.ID == "" {
+		policy.ID = newID()
+	}
+	policy.IssuedAt = time.Now()
+	if _, ok := r.s.insurancePolicies[policy.TripID]; ok {
+		return nil
+	}
+	c := *policy
+	r.s.insurancePolicies[policy.TripID] = &c
+	return nil
+}
+
+func (r *insuranceRepository) GetPolicyByTripID(ctx context.Context, tripID string) (*models.TripInsurance, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	policy, ok := r.s.insurancePolicies[tripID]
+	if !ok {
+		return nil, nil
+	}
+	c := *policy
+	return &c, nil
+}
+
+func (r *insuranceRepository) GetUninsuredTrips(ctx context.Context, startedBefore time.Time, limit int) ([]*models.Trip, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var trips []*models.Trip
+	for _, t := range r.s.trips {
+		if t.Status == models.TripStatusCompleted || t.Status == models.TripStatusCancelled {
+			continue
+		}
+		if t.StartTime == nil || !t.StartTime.Before(startedBefore) {
+			continue
+		}
+		if _, ok := r.s.insurancePolicies[t.ID]; ok {
+			continue
+		}
+		c := *t
+		trips = append(trips, &c)
+	}
+	sort.Slice(trips, func(i, j int) bool { return trips[i].StartTime.Before(*trips[j].StartTime) })
+	return page(trips, limit, 0), nil
+}
+
+func (r *insuranceRepository) CreateClaim(ctx context.Context, claim *models.InsuranceClaim) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if claim.ID == "" {
+		claim.ID = newID()
+	}
+	now := time.Now()
+	claim.CreatedAt = now
+	claim.UpdatedAt = now
+	claim.Status = models.ClaimStatusReceived
+
+	c := *claim
+	r.s.insuranceClaims[claim.ID] = &c
+	return nil
+}
+
+func (r *insuranceRepository) GetClaimByID(ctx context.Context, id string) (*models.InsuranceClaim, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	claim, ok := r.s.insuranceClaims[id]
+	if !ok {
+		return nil, nil
+	}
+	c := *claim
+	return &c, nil
+}
+
+func (r *insuranceRepository) MarkClaimSubmitted(ctx context.Context, id, providerReference string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if claim, ok := r.s.insuranceClaims[id]; ok {
+		claim.Status = models.ClaimStatusSubmitted
+		claim.ProviderReference = &providerReference
+		claim.UpdatedAt = time.Now()
+	}
+	return nil
+}
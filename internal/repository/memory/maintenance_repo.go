@@ -0,0 +1,86 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+type maintenanceRepository struct {
+	s *Store
+}
+
+func NewMaintenanceRepository(s *Store) repository.MaintenanceRepository {
+	return &maintenanceRepository{s: s}
+}
+
+func (r *maintenanceRepository) Create(ctx context.Context, window *models.MaintenanceWindow) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if window.ID == "" {
+		window.ID = newID()
+	}
+	now := time.Now()
+	window.Active = true
+	window.CreatedAt = now
+	window.UpdatedAt = now
+
+	c := *window
+	r.s.maintenanceWindows[window.ID] = &c
+	return nil
+}
+
+func (r *maintenanceRepository) GetByID(ctx context.Context, id string) (*models.MaintenanceWindow, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	window, ok := r.s.maintenanceWindows[id]
+	if !ok {
+		return nil, nil
+	}
+	c := *window
+	return &c, nil
+}
+
+func (r *maintenanceRepository) List(ctx context.Context) ([]*models.MaintenanceWindow, error) {
+	windows := r.filter(func(w *models.MaintenanceWindow) bool { return true })
+	sort.Slice(windows, func(i, j int) bool { return windows[i].StartsAt.After(windows[j].StartsAt) })
+	return windows, nil
+}
+
+func (r *maintenanceRepository) GetNext(ctx context.Context, at, until time.Time) (*models.MaintenanceWindow, error) {
+	windows := r.filter(func(w *models.MaintenanceWindow) bool {
+		return w.Active && w.EndsAt.After(at) && !w.StartsAt.After(until)
+	})
+	sort.Slice(windows, func(i, j int) bool { return windows[i].StartsAt.Before(windows[j].StartsAt) })
+	return first(windows), nil
+}
+
+func (r *maintenanceRepository) SetActive(ctx context.Context, id string, active bool) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if window, ok := r.s.maintenanceWindows[id]; ok {
+		window.Active = active
+		window.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+func (r *maintenanceRepository) filter(match func(w *models.MaintenanceWindow) bool) []*models.MaintenanceWindow {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var windows []*models.MaintenanceWindow
+	for _, w := range r.s.maintenanceWindows {
+		if match(w) {
+			c := *w
+			windows = append(windows, &c)
+		}
+	}
+	return windows
+}
@@ -0,0 +1,71 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+type matchingExclusionRepository struct {
+	s *Store
+}
+
+func NewMatchingExclusionRepository(s *Store) repository.MatchingExclusionRepository {
+	return &matchingExclusionRepository{s: s}
+}
+
+func (r *matchingExclusionRepository) Create(ctx context.Context, exclusion *models.MatchingExclusion) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if exclusion.ID == "" {
+		exclusion.ID = newID()
+	}
+	exclusion.CreatedAt = time.Now()
+
+	c := *exclusion
+	r.s.matchingExclusions[exclusion.ID] = &c
+	return nil
+}
+
+func (r *matchingExclusionRepository) GetByID(ctx context.Context, id string) (*models.MatchingExclusion, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	exclusion, ok := r.s.matchingExclusions[id]
+	if !ok {
+		return nil, nil
+	}
+	c := *exclusion
+	return &c, nil
+}
+
+func (r *matchingExclusionRepository) List(ctx context.Context, activeAt *time.Time) ([]*models.MatchingExclusion, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var exclusions []*models.MatchingExclusion
+	for _, e := range r.s.matchingExclusions {
+		if activeAt != nil && (e.RevokedAt != nil || e.StartsAt.After(*activeAt) || !e.ExpiresAt.After(*activeAt)) {
+			continue
+		}
+		c := *e
+		exclusions = append(exclusions, &c)
+	}
+	sort.Slice(exclusions, func(i, j int) bool { return exclusions[i].CreatedAt.After(exclusions[j].CreatedAt) })
+	return exclusions, nil
+}
+
+func (r *matchingExclusionRepository) Revoke(ctx context.Context, id, revokedBy string, at time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if exclusion, ok := r.s.matchingExclusions[id]; ok {
+		exclusion.RevokedAt = &at
+		exclusion.RevokedBy = &revokedBy
+	}
+	return nil
+}
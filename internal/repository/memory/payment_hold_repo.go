@@ -0,0 +1,96 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+	"github.com/aditya/go-comet/internal/tenant"
+)
+
+type paymentHoldRepository struct {
+	s *Store
+}
+
+func NewPaymentHoldRepository(s *Store) repository.PaymentHoldRepository {
+	return &paymentHoldRepository{s: s}
+}
+
+func (r *paymentHoldRepository) Create(ctx context.Context, hold *models.PaymentHold) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, h := range r.s.paymentHolds {
+		if h.RideID == hold.RideID {
+			return errDuplicate("payment_holds_ride_id_key")
+		}
+	}
+	if hold.ID == "" {
+		hold.ID = newID()
+	}
+	now := time.Now()
+	hold.CreatedAt = now
+	hold.UpdatedAt = now
+	if hold.Currency == "" {
+		hold.Currency = "INR"
+	}
+	hold.TenantCode = tenant.CodeOrDefault(ctx)
+
+	c := *hold
+	r.s.paymentHolds[hold.ID] = &c
+	return nil
+}
+
+func (r *paymentHoldRepository) GetByRideID(ctx context.Context, rideID string) (*models.PaymentHold, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, h := range r.s.paymentHolds {
+		if h.RideID == rideID {
+			c := *h
+			return &c, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *paymentHoldRepository) UpdateStatus(ctx context.Context, id, status string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if h, ok := r.s.paymentHolds[id]; ok {
+		h.Status = status
+		h.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+func (r *paymentHoldRepository) MarkCaptured(ctx context.Context, id, paymentID string, amount float64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if h, ok := r.s.paymentHolds[id]; ok {
+		h.Status = models.HoldStatusCaptured
+		h.PaymentID = &paymentID
+		h.CapturedAmount = &amount
+		h.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+func (r *paymentHoldRepository) GetReleasable(ctx context.Context, before time.Time) ([]*models.PaymentHold, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var holds []*models.PaymentHold
+	for _, h := range r.s.paymentHolds {
+		ride, ok := r.s.rides[h.RideID]
+		if ok && h.Status == models.HoldStatusAuthorized &&
+			(ride.Status == models.RideStatusCancelled || h.CreatedAt.Before(before)) {
+			c := *h
+			holds = append(holds, &c)
+		}
+	}
+	return holds, nil
+}
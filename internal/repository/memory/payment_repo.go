@@ -0,0 +1,110 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+type paymentRepository struct {
+	s *Store
+}
+
+func NewPaymentRepository(s *Store) repository.PaymentRepository {
+	return &paymentRepository{s: s}
+}
+
+func (r *paymentRepository) Create(ctx context.Context, payment *models.Payment) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if payment.IdempotencyKey != nil {
+		for _, p := range r.s.payments {
+			if p.IdempotencyKey != nil && *p.IdempotencyKey == *payment.IdempotencyKey {
+				return errDuplicate("payments_idempotency_key_key")
+			}
+		}
+	}
+	if payment.ID == "" {
+		payment.ID = newID()
+	}
+	payment.CreatedAt = time.Now()
+	payment.UpdatedAt = time.Now()
+	payment.Status = models.PaymentStatusPending
+	if payment.Currency == "" {
+		payment.Currency = "INR"
+	}
+
+	c := *payment
+	r.s.payments[payment.ID] = &c
+	return nil
+}
+
+func (r *paymentRepository) GetByID(ctx context.Context, id string) (*models.Payment, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	p, ok := r.s.payments[id]
+	if !ok {
+		return nil, nil
+	}
+	c := *p
+	return &c, nil
+}
+
+func (r *paymentRepository) GetByTripID(ctx context.Context, tripID string) (*models.Payment, error) {
+	payments := r.filter(func(p *models.Payment) bool { return p.TripID == tripID })
+	sort.Slice(payments, func(i, j int) bool { return payments[i].CreatedAt.After(payments[j].CreatedAt) })
+	return first(payments), nil
+}
+
+func (r *paymentRepository) GetByIdempotencyKey(ctx context.Context, key string) (*models.Payment, error) {
+	return first(r.filter(func(p *models.Payment) bool {
+		return p.IdempotencyKey != nil && *p.IdempotencyKey == key
+	})), nil
+}
+
+func (r *paymentRepository) Update(ctx context.Context, payment *models.Payment) error {
+	payment.UpdatedAt = time.Now()
+	return r.UpdateStatus(ctx, payment.ID, payment.Status, payment.PSPTransactionID, payment.PSPResponse)
+}
+
+func (r *paymentRepository) UpdateStatus(ctx context.Context, id, status string, pspTxnID *string, pspResponse json.RawMessage) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if p, ok := r.s.payments[id]; ok {
+		p.Status = status
+		p.PSPTransactionID = pspTxnID
+		p.PSPResponse = pspResponse
+		p.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+func (r *paymentRepository) GetCompletedByDriver(ctx context.Context, driverID string, from, to time.Time) ([]*models.Payment, error) {
+	payments := r.filter(func(p *models.Payment) bool {
+		return p.DriverID == driverID && p.Status == models.PaymentStatusCompleted &&
+			!p.CreatedAt.Before(from) && p.CreatedAt.Before(to)
+	})
+	sort.Slice(payments, func(i, j int) bool { return payments[i].CreatedAt.Before(payments[j].CreatedAt) })
+	return payments, nil
+}
+
+func (r *paymentRepository) filter(match func(p *models.Payment) bool) []*models.Payment {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var payments []*models.Payment
+	for _, p := range r.s.payments {
+		if match(p) {
+			c := *p
+			payments = append(payments, &c)
+		}
+	}
+	return payments
+}
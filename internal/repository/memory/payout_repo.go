@@ -0,0 +1,139 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+type payoutRepository struct {
+	s *Store
+}
+
+func NewPayoutRepository(s *Store) repository.PayoutRepository {
+	return &payoutRepository{s: s}
+}
+
+func (r *payoutRepository) PostEarning(ctx context.Context, entry *models.LedgerEntry) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if entry.ID == "" {
+		entry.ID = newID()
+	}
+	entry.CreatedAt = time.Now()
+	entry.EntryType = models.LedgerEntryEarning
+	return r.s.postOnce(entry, func(e *models.LedgerEntry) bool {
+		return equalPtr(e.PaymentID, entry.PaymentID)
+	}), nil
+}
+
+func (r *payoutRepository) ReverseEarning(ctx context.Context, paymentID string, at time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, e := range r.s.ledger {
+		if e.PaymentID == nil || *e.PaymentID != paymentID || e.EntryType != models.LedgerEntryEarning {
+			continue
+		}
+		description := "Refunded trip"
+		r.s.postOnce(&models.LedgerEntry{
+			ID:          newID(),
+			DriverID:    e.DriverID,
+			EntryType:   models.LedgerEntryEarningReversal,
+			Amount:      -e.Amount,
+			Description: &description,
+			TripID:      e.TripID,
+			PaymentID:   e.PaymentID,
+			CreatedAt:   at,
+		}, func(other *models.LedgerEntry) bool {
+			return equalPtr(other.PaymentID, e.PaymentID)
+		})
+	}
+	return nil
+}
+
+func (r *payoutRepository) GetTripEarnings(ctx context.Context, driverID string, from, to time.Time) ([]*models.TripEarning, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var earnings []*models.TripEarning
+	for _, e := range r.s.rideEconomics {
+		payment, ok := r.s.payments[e.PaymentID]
+		if !ok || e.DriverID != driverID || e.CreatedAt.Before(from) || !e.CreatedAt.Before(to) {
+			continue
+		}
+		earnings = append(earnings, &models.TripEarning{
+			RideID:         e.RideID,
+			TripID:         e.TripID,
+			PaymentID:      e.PaymentID,
+			Method:         payment.Method,
+			Currency:       e.Currency,
+			Fare:           e.RiderPaid,
+			Commission:     e.Commission,
+			Earnings:       e.DriverEarnings,
+			IncentiveTopUp: e.IncentiveTopUp,
+			RefundedAt:     e.RefundedAt,
+			PaidAt:         e.CreatedAt,
+		})
+	}
+	sort.Slice(earnings, func(i, j int) bool { return earnings[i].PaidAt.After(earnings[j].PaidAt) })
+	return earnings, nil
+}
+
+func (r *payoutRepository) GetBalance(ctx context.Context, driverID string) (float64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	return r.s.ledgerBalance(driverID), nil
+}
+
+func (r *payoutRepository) CreatePayout(ctx context.Context, payout *models.Payout) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if payout.Amount > r.s.ledgerBalance(payout.DriverID) {
+		return false, nil
+	}
+
+	if payout.ID == "" {
+		payout.ID = newID()
+	}
+	now := time.Now()
+	payout.Status = models.PayoutStatusRequested
+	payout.CreatedAt = now
+	payout.UpdatedAt = now
+
+	c := *payout
+	r.s.payouts = append(r.s.payouts, &c)
+
+	description := "Payout"
+	r.s.ledger = append(r.s.ledger, &models.LedgerEntry{
+		ID:          newID(),
+		DriverID:    payout.DriverID,
+		EntryType:   models.LedgerEntryPayout,
+		Amount:      -payout.Amount,
+		Description: &description,
+		PayoutID:    &c.ID,
+		CreatedAt:   now,
+	})
+	return true, nil
+}
+
+func (r *payoutRepository) GetRecentPayouts(ctx context.Context, driverID string, limit int) ([]*models.Payout, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var payouts []*models.Payout
+	for _, p := range r.s.payouts {
+		if p.DriverID == driverID {
+			c := *p
+			payouts = append(payouts, &c)
+		}
+	}
+	sort.Slice(payouts, func(i, j int) bool { return payouts[i].CreatedAt.After(payouts[j].CreatedAt) })
+	return page(payouts, limit, 0), nil
+}
@@ -0,0 +1,86 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+type pickupSpotRepository struct {
+	s *Store
+}
+
+func NewPickupSpotRepository(s *Store) repository.PickupSpotRepository {
+	return &pickupSpotRepository{s: s}
+}
+
+func (r *pickupSpotRepository) Create(ctx context.Context, spot *models.PickupSpot) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if spot.ID == "" {
+		spot.ID = newID()
+	}
+	now := time.Now()
+	spot.CreatedAt = now
+	spot.UpdatedAt = now
+	spot.Active = true
+
+	c := *spot
+	r.s.pickupSpots[spot.ID] = &c
+	return nil
+}
+
+func (r *pickupSpotRepository) GetByID(ctx context.Context, id string) (*models.PickupSpot, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	spot, ok := r.s.pickupSpots[id]
+	if !ok {
+		return nil, nil
+	}
+	c := *spot
+	return &c, nil
+}
+
+func (r *pickupSpotRepository) GetInBounds(ctx context.Context, minLat, minLng, maxLat, maxLng float64) ([]*models.PickupSpot, error) {
+	return r.filter(func(s *models.PickupSpot) bool {
+		return s.Active && s.Lat >= minLat && s.Lat <= maxLat && s.Lng >= minLng && s.Lng <= maxLng
+	}), nil
+}
+
+func (r *pickupSpotRepository) SetActive(ctx context.Context, id string, active bool) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if spot, ok := r.s.pickupSpots[id]; ok {
+		spot.Active = active
+		spot.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+func (r *pickupSpotRepository) GetByVenueID(ctx context.Context, venueID string) ([]*models.PickupSpot, error) {
+	spots := r.filter(func(s *models.PickupSpot) bool {
+		return s.Active && s.VenueID != nil && *s.VenueID == venueID
+	})
+	sort.Slice(spots, func(i, j int) bool { return spots[i].Name < spots[j].Name })
+	return spots, nil
+}
+
+func (r *pickupSpotRepository) filter(match func(s *models.PickupSpot) bool) []*models.PickupSpot {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var spots []*models.PickupSpot
+	for _, s := range r.s.pickupSpots {
+		if match(s) {
+			c := *s
+			spots = append(spots, &c)
+		}
+	}
+	return spots
+}
@@ -0,0 +1,84 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+type pricingEventRepository struct {
+	s *Store
+}
+
+func NewPricingEventRepository(s *Store) repository.PricingEventRepository {
+	return &pricingEventRepository{s: s}
+}
+
+func (r *pricingEventRepository) Create(ctx context.Context, event *models.PricingEvent) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if event.ID == "" {
+		event.ID = newID()
+	}
+	now := time.Now()
+	event.CreatedAt = now
+	event.UpdatedAt = now
+	event.Active = true
+
+	c := *event
+	r.s.pricingEvents[event.ID] = &c
+	return nil
+}
+
+func (r *pricingEventRepository) GetByID(ctx context.Context, id string) (*models.PricingEvent, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	event, ok := r.s.pricingEvents[id]
+	if !ok {
+		return nil, nil
+	}
+	c := *event
+	return &c, nil
+}
+
+func (r *pricingEventRepository) List(ctx context.Context) ([]*models.PricingEvent, error) {
+	events := r.filter(func(e *models.PricingEvent) bool { return true })
+	sort.Slice(events, func(i, j int) bool { return events[i].StartsAt.After(events[j].StartsAt) })
+	return events, nil
+}
+
+func (r *pricingEventRepository) GetActiveAt(ctx context.Context, at time.Time) ([]*models.PricingEvent, error) {
+	return r.filter(func(e *models.PricingEvent) bool {
+		return e.Active && !e.StartsAt.After(at) && e.EndsAt.After(at)
+	}), nil
+}
+
+func (r *pricingEventRepository) SetActive(ctx context.Context, id string, active bool) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if event, ok := r.s.pricingEvents[id]; ok {
+		event.Active = active
+		event.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+func (r *pricingEventRepository) filter(match func(e *models.PricingEvent) bool) []*models.PricingEvent {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var events []*models.PricingEvent
+	for _, e := range r.s.pricingEvents {
+		if match(e) {
+			c := *e
+			events = append(events, &c)
+		}
+	}
+	return events
+}
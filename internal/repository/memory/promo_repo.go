@@ -0,0 +1,123 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+type promoRepository struct {
+	s *Store
+}
+
+func NewPromoRepository(s *Store) repository.PromoRepository {
+	return &promoRepository{s: s}
+}
+
+func (r *promoRepository) Create(ctx context.Context, promo *models.PromoCode) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.promos[promo.Code]; ok {
+		return false, nil
+	}
+	if promo.ID == "" {
+		promo.ID = newID()
+	}
+	now := time.Now()
+	promo.CreatedAt = now
+	promo.UpdatedAt = now
+	promo.Active = true
+
+	c := *promo
+	r.s.promos[promo.Code] = &c
+	return true, nil
+}
+
+func (r *promoRepository) GetByCode(ctx context.Context, code string) (*models.PromoCode, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	promo, ok := r.s.promos[code]
+	if !ok {
+		return nil, nil
+	}
+	c := *promo
+	return &c, nil
+}
+
+func (r *promoRepository) List(ctx context.Context) ([]*models.PromoCode, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	promos := make([]*models.PromoCode, 0, len(r.s.promos))
+	for _, promo := range r.s.promos {
+		c := *promo
+		promos = append(promos, &c)
+	}
+	sort.Slice(promos, func(i, j int) bool { return promos[i].CreatedAt.After(promos[j].CreatedAt) })
+	return promos, nil
+}
+
+func (r *promoRepository) Update(ctx context.Context, promo *models.PromoCode) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	promo.UpdatedAt = time.Now()
+	if existing, ok := r.s.promos[promo.Code]; ok {
+		existing.MaxDiscount = promo.MaxDiscount
+		existing.MinFare = promo.MinFare
+		existing.MaxUses = promo.MaxUses
+		existing.ExpiresAt = promo.ExpiresAt
+		existing.Active = promo.Active
+		existing.UpdatedAt = promo.UpdatedAt
+	}
+	return nil
+}
+
+func (r *promoRepository) Redeem(ctx context.Context, redemption *models.PromoRedemption) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var promo *models.PromoCode
+	for _, p := range r.s.promos {
+		if p.ID == redemption.PromoID {
+			promo = p
+		}
+	}
+	if promo == nil || promo.Exhausted() {
+		return false, nil
+	}
+	for _, existing := range r.s.promoRedemptions {
+		if existing.RideID == redemption.RideID {
+			return false, nil
+		}
+	}
+
+	if redemption.ID == "" {
+		redemption.ID = newID()
+	}
+	redemption.CreatedAt = time.Now()
+	promo.Uses++
+	promo.UpdatedAt = redemption.CreatedAt
+
+	c := *redemption
+	r.s.promoRedemptions = append(r.s.promoRedemptions, &c)
+	return true, nil
+}
+
+func (r *promoRepository) GetRedemptionByRideID(ctx context.Context, rideID string) (*models.PromoRedemption, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, redemption := range r.s.promoRedemptions {
+		if redemption.RideID == rideID {
+			c := *redemption
+			return &c, nil
+		}
+	}
+	return nil, nil
+}
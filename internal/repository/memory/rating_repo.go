@@ -0,0 +1,161 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+type ratingRepository struct {
+	s *Store
+}
+
+func NewRatingRepository(s *Store) repository.RatingRepository {
+	return &ratingRepository{s: s}
+}
+
+func (r *ratingRepository) Create(ctx context.Context, rating *models.TripRating) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, existing := range r.s.ratings {
+		if existing.TripID == rating.TripID && existing.RaterType == rating.RaterType {
+			return false, nil
+		}
+	}
+	if rating.ID == "" {
+		rating.ID = newID()
+	}
+	rating.CreatedAt = time.Now()
+
+	c := *rating
+	r.s.ratings = append(r.s.ratings, &c)
+	return true, nil
+}
+
+func (r *ratingRepository) GetByID(ctx context.Context, id string) (*models.TripRating, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, rating := range r.s.ratings {
+		if rating.ID == id {
+			c := *rating
+			return &c, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *ratingRepository) GetByFeedbackStatus(ctx context.Context, statuses []string, limit int) ([]*models.TripRating, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	// Appended in creation order already
+	var ratings []*models.TripRating
+	for _, rating := range r.s.ratings {
+		if rating.FeedbackIs(statuses...) {
+			c := *rating
+			ratings = append(ratings, &c)
+		}
+	}
+	return page(ratings, limit, 0), nil
+}
+
+func (r *ratingRepository) UpdateFeedbackStatus(ctx context.Context, rating *models.TripRating, from []string) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, existing := range r.s.ratings {
+		if existing.ID != rating.ID {
+			continue
+		}
+		if !existing.FeedbackIs(from...) {
+			return false, nil
+		}
+		existing.FeedbackStatus = rating.FeedbackStatus
+		existing.AppealReason = rating.AppealReason
+		existing.ModeratedAt = rating.ModeratedAt
+		return true, nil
+	}
+	return false, nil
+}
+
+func (r *ratingRepository) GetByTripID(ctx context.Context, tripID string) ([]*models.TripRating, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	// Appended in creation order already
+	var ratings []*models.TripRating
+	for _, rating := range r.s.ratings {
+		if rating.TripID == tripID {
+			c := *rating
+			ratings = append(ratings, &c)
+		}
+	}
+	return ratings, nil
+}
+
+func (r *ratingRepository) GetUnratedTrips(ctx context.Context, userID string, since time.Time, limit int) ([]*models.TripSummary, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	rated := make(map[string]bool)
+	for _, rating := range r.s.ratings {
+		if rating.RaterType == models.RaterUser {
+			rated[rating.TripID] = true
+		}
+	}
+
+	var summaries []*models.TripSummary
+	for _, trip := range r.s.trips {
+		if trip.UserID != userID || trip.Status != models.TripStatusCompleted || rated[trip.ID] ||
+			trip.EndTime == nil || trip.EndTime.Before(since) {
+			continue
+		}
+		driver, ok := r.s.drivers[trip.DriverID]
+		if !ok {
+			continue
+		}
+		endedAt := *trip.EndTime
+		summaries = append(summaries, &models.TripSummary{
+			TripID:       trip.ID,
+			RideID:       trip.RideID,
+			DriverID:     trip.DriverID,
+			DriverName:   driver.Name,
+			TotalFare:    trip.TotalFare,
+			DurationMins: trip.ActualDurationMin,
+			EndedAt:      &endedAt,
+		})
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].EndedAt.After(*summaries[j].EndedAt) })
+	if limit > 0 && len(summaries) > limit {
+		summaries = summaries[:limit]
+	}
+	return summaries, nil
+}
+
+func (r *ratingRepository) GetLatestForDriver(ctx context.Context, driverID string, limit int) ([]*models.TripRating, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var ratings []*models.TripRating
+	for _, rating := range r.s.ratings {
+		if rating.RateeID != driverID || rating.RaterType != models.RaterUser {
+			continue
+		}
+		trip, ok := r.s.trips[rating.TripID]
+		if !ok || trip.Status != models.TripStatusCompleted {
+			continue
+		}
+		if ride, ok := r.s.rides[trip.RideID]; !ok || ride.Status == models.RideStatusCancelled {
+			continue
+		}
+		c := *rating
+		ratings = append(ratings, &c)
+	}
+	sort.SliceStable(ratings, func(i, j int) bool { return ratings[i].CreatedAt.After(ratings[j].CreatedAt) })
+	return page(ratings, limit, 0), nil
+}
@@ -0,0 +1,78 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+type regionRepository struct {
+	s *Store
+}
+
+func NewRegionRepository(s *Store) repository.RegionRepository {
+	return &regionRepository{s: s}
+}
+
+func (r *regionRepository) GetByCode(ctx context.Context, code string) (*models.Region, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	region, ok := r.s.regions[code]
+	if !ok {
+		return nil, nil
+	}
+	c := *region
+	return &c, nil
+}
+
+func (r *regionRepository) GetActive(ctx context.Context) ([]*models.Region, error) {
+	return r.list(true), nil
+}
+
+func (r *regionRepository) GetAll(ctx context.Context) ([]*models.Region, error) {
+	return r.list(false), nil
+}
+
+func (r *regionRepository) UpdateSettings(ctx context.Context, code string, settings models.RegionSettings) error {
+	r.update(code, func(region *models.Region) { region.Settings = settings })
+	return nil
+}
+
+func (r *regionRepository) UpdateServiceArea(ctx context.Context, code string, area models.ServiceArea) error {
+	r.update(code, func(region *models.Region) { region.ServiceArea = area })
+	return nil
+}
+
+func (r *regionRepository) UpdateTenant(ctx context.Context, code, tenantCode string) error {
+	r.update(code, func(region *models.Region) { region.TenantCode = tenantCode })
+	return nil
+}
+
+func (r *regionRepository) update(code string, fn func(region *models.Region)) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if region, ok := r.s.regions[code]; ok {
+		fn(region)
+		region.UpdatedAt = time.Now()
+	}
+}
+
+func (r *regionRepository) list(activeOnly bool) []*models.Region {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var regions []*models.Region
+	for _, region := range r.s.regions {
+		if !activeOnly || region.Active {
+			c := *region
+			regions = append(regions, &c)
+		}
+	}
+	sort.Slice(regions, func(i, j int) bool { return regions[i].Code < regions[j].Code })
+	return regions
+}
@@ -0,0 +1,133 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+type retentionRepository struct {
+	s *Store
+}
+
+func NewRetentionRepository(s *Store) repository.RetentionRepository {
+	return &retentionRepository{s: s}
+}
+
+// expiredTrips returns the region's finished, unanonymized trips that ended
+// before the cutoff, oldest first. Callers hold the lock.
+func (r *retentionRepository) expiredTrips(regionCode string, before time.Time) []*models.Trip {
+	var expired []*models.Trip
+	for _, trip := range r.s.trips {
+		if trip.AnonymizedAt != nil ||
+			(trip.Status != models.TripStatusCompleted && trip.Status != models.TripStatusCancelled) {
+			continue
+		}
+		if !tripEndedAt(trip).Before(before) {
+			continue
+		}
+		ride, ok := r.s.rides[trip.RideID]
+		if !ok || deref(ride.RegionCode) != regionCode {
+			continue
+		}
+		expired = append(expired, trip)
+	}
+	sort.Slice(expired, func(i, j int) bool { return tripEndedAt(expired[i]).Before(tripEndedAt(expired[j])) })
+	return expired
+}
+
+func tripEndedAt(trip *models.Trip) time.Time {
+	if trip.EndTime != nil {
+		return *trip.EndTime
+	}
+	return trip.UpdatedAt
+}
+
+func (r *retentionRepository) CountExpiredTrips(ctx context.Context, regionCode string, before time.Time) (int, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	return len(r.expiredTrips(regionCode, before)), nil
+}
+
+func (r *retentionRepository) AnonymizeTrips(ctx context.Context, regionCode string, before time.Time, limit int) (int, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	expired := page(r.expiredTrips(regionCode, before), limit, 0)
+	now := time.Now()
+	for _, trip := range expired {
+		trip.RoutePolyline = nil
+		trip.AnonymizedAt = &now
+
+		if ride, ok := r.s.rides[trip.RideID]; ok {
+			ride.PickupLat, ride.PickupLng = coarsen(ride.PickupLat), coarsen(ride.PickupLng)
+			ride.DropoffLat, ride.DropoffLng = coarsen(ride.DropoffLat), coarsen(ride.DropoffLng)
+			ride.PickupAddress = nil
+			ride.DropoffAddress = nil
+			ride.RiderNote = nil
+			ride.PickupSpotName = nil
+			ride.CardFingerprint = nil
+		}
+		for _, seg := range r.s.tripSegments {
+			if seg.TripID != trip.ID {
+				continue
+			}
+			seg.StartLat, seg.StartLng = coarsen(seg.StartLat), coarsen(seg.StartLng)
+			if seg.EndLat != nil && seg.EndLng != nil {
+				endLat, endLng := coarsen(*seg.EndLat), coarsen(*seg.EndLng)
+				seg.EndLat, seg.EndLng = &endLat, &endLng
+			}
+		}
+		for _, delivery := range r.s.deliveries {
+			if delivery.RideID != trip.RideID {
+				continue
+			}
+			delivery.RecipientName = ""
+			delivery.RecipientPhone = ""
+			delivery.PackageDescription = nil
+			delivery.ReceivedBy = nil
+		}
+		for _, entry := range r.s.audit {
+			if entry.RideID == trip.RideID {
+				entry.Snapshot = redactSnapshot(entry.Snapshot)
+			}
+		}
+	}
+	return len(expired), nil
+}
+
+// redactSnapshot rounds and clears an audit snapshot's columns as anonymization
+// does the rows; snapshots that aren't JSON objects are dropped
+func redactSnapshot(snapshot json.RawMessage) json.RawMessage {
+	var row map[string]any
+	if err := json.Unmarshal(snapshot, &row); err != nil {
+		return json.RawMessage("{}")
+	}
+	for _, key := range models.AnonymizedSnapshotCoords {
+		if v, ok := row[key].(float64); ok {
+			row[key] = coarsen(v)
+		}
+	}
+	for _, key := range models.AnonymizedSnapshotFields {
+		if _, ok := row[key]; ok {
+			row[key] = nil
+		}
+	}
+	redacted, err := json.Marshal(row)
+	if err != nil {
+		return json.RawMessage("{}")
+	}
+	return redacted
+}
+
+// coarsen rounds a coordinate to the precision kept after anonymization
+func coarsen(v float64) float64 {
+	scale := math.Pow(10, models.AnonymizedCoordDecimals)
+	return math.Round(v*scale) / scale
+}
@@ -0,0 +1,129 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+type rideEconomicsRepository struct {
+	s *Store
+}
+
+func NewRideEconomicsRepository(s *Store) repository.RideEconomicsRepository {
+	return &rideEconomicsRepository{s: s}
+}
+
+func (r *rideEconomicsRepository) Record(ctx context.Context, e *models.RideEconomics) (*models.RideEconomics, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	ride, ok := r.s.rides[e.RideID]
+	if !ok {
+		return nil, fmt.Errorf("ride %s not found", e.RideID)
+	}
+
+	var topUp float64
+	for _, entry := range r.s.ledger {
+		if entry.TripID != nil && *entry.TripID == e.TripID && entry.EntryType == models.LedgerEntryIncentive {
+			topUp += entry.Amount
+		}
+	}
+
+	now := time.Now()
+	recorded, ok := r.s.rideEconomics[e.RideID]
+	if !ok {
+		recorded = &models.RideEconomics{
+			RideID:      ride.ID,
+			TripID:      e.TripID,
+			DriverID:    e.DriverID,
+			RegionCode:  ride.RegionCode,
+			VehicleType: ride.VehicleType,
+			CreatedAt:   now,
+		}
+		r.s.rideEconomics[e.RideID] = recorded
+	}
+	recorded.PaymentID = e.PaymentID
+	recorded.Currency = e.Currency
+	recorded.GrossFare = e.GrossFare
+	recorded.Discounts = e.Discounts
+	recorded.RiderPaid = e.RiderPaid
+	recorded.Commission = e.Commission
+	recorded.DriverEarnings = e.DriverEarnings
+	recorded.IncentiveTopUp = topUp
+	recorded.NetRevenue = e.Commission - topUp
+	recorded.RefundedAt = nil
+	recorded.UpdatedAt = now
+
+	c := *recorded
+	return &c, nil
+}
+
+func (r *rideEconomicsRepository) GetByRideID(ctx context.Context, rideID string) (*models.RideEconomics, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	e, ok := r.s.rideEconomics[rideID]
+	if !ok {
+		return nil, nil
+	}
+	c := *e
+	return &c, nil
+}
+
+func (r *rideEconomicsRepository) MarkRefunded(ctx context.Context, paymentID string, at time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, e := range r.s.rideEconomics {
+		if e.PaymentID == paymentID {
+			e.RefundedAt = &at
+			e.UpdatedAt = at
+		}
+	}
+	return nil
+}
+
+func (r *rideEconomicsRepository) GetTakeRates(ctx context.Context, from, to time.Time) ([]*models.RegionTakeRate, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	groups := make(map[string]*models.RegionTakeRate)
+	for _, e := range r.s.rideEconomics {
+		if e.CreatedAt.Before(from) || !e.CreatedAt.Before(to) || e.RefundedAt != nil {
+			continue
+		}
+		key := deref(e.RegionCode)
+		rate, ok := groups[key]
+		if !ok {
+			rate = &models.RegionTakeRate{RegionCode: e.RegionCode}
+			groups[key] = rate
+		}
+		rate.Rides++
+		rate.GrossFare += e.GrossFare
+		rate.Discounts += e.Discounts
+		rate.RiderPaid += e.RiderPaid
+		rate.Commission += e.Commission
+		rate.DriverEarnings += e.DriverEarnings
+		rate.IncentiveTopUp += e.IncentiveTopUp
+		rate.NetRevenue += e.NetRevenue
+	}
+
+	var rates []*models.RegionTakeRate
+	for _, rate := range groups {
+		rates = append(rates, rate)
+	}
+	// Rides outside any region sort last
+	sort.Slice(rates, func(i, j int) bool {
+		a, b := rates[i].RegionCode, rates[j].RegionCode
+		if a == nil || b == nil {
+			return b == nil && a != nil
+		}
+		return *a < *b
+	})
+	return rates, nil
+}
@@ -0,0 +1,304 @@
+package memory
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+	"github.com/jmoiron/sqlx"
+)
+
+type rideOfferRepository struct {
+	s *Store
+}
+
+func NewRideOfferRepository(s *Store) repository.RideOfferRepository {
+	return &rideOfferRepository{s: s}
+}
+
+func (r *rideOfferRepository) Create(ctx context.Context, offer *models.RideOffer) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if offer.ID == "" {
+		offer.ID = newID()
+	}
+	offer.OfferedAt = time.Now()
+	offer.Status = models.OfferStatusPending
+
+	c := *offer
+	r.s.offers[offer.ID] = &c
+	return nil
+}
+
+func (r *rideOfferRepository) GetByID(ctx context.Context, id string) (*models.RideOffer, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	offer, ok := r.s.offers[id]
+	if !ok {
+		return nil, nil
+	}
+	c := *offer
+	return &c, nil
+}
+
+func (r *rideOfferRepository) GetByRideAndDriver(ctx context.Context, rideID, driverID string) (*models.RideOffer, error) {
+	return first(r.filter(func(o *models.RideOffer) bool {
+		return o.RideID == rideID && o.DriverID == driverID
+	})), nil
+}
+
+func (r *rideOfferRepository) GetPendingByRideID(ctx context.Context, rideID string) ([]*models.RideOffer, error) {
+	now := time.Now()
+	offers := r.filter(func(o *models.RideOffer) bool {
+		return o.RideID == rideID && o.Status == models.OfferStatusPending && o.ExpiresAt.After(now)
+	})
+	sort.Slice(offers, func(i, j int) bool { return offers[i].OfferedAt.Before(offers[j].OfferedAt) })
+	return offers, nil
+}
+
+func (r *rideOfferRepository) GetPendingByDriverID(ctx context.Context, driverID string) ([]*models.RideOffer, error) {
+	now := time.Now()
+	offers := r.filter(func(o *models.RideOffer) bool {
+		return o.DriverID == driverID && o.Status == models.OfferStatusPending && o.ExpiresAt.After(now)
+	})
+	sort.Slice(offers, func(i, j int) bool { return offers[i].OfferedAt.After(offers[j].OfferedAt) })
+	return offers, nil
+}
+
+func (r *rideOfferRepository) UpdateStatus(ctx context.Context, id, status string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if o, ok := r.s.offers[id]; ok {
+		now := time.Now()
+		r.recordResponse(o, status, now)
+		o.Status = status
+		o.RespondedAt = &now
+	}
+	return nil
+}
+
+func (r *rideOfferRepository) SetStatus(ctx context.Context, tx *sqlx.Tx, id, status string, at time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if o, ok := r.s.offers[id]; ok {
+		r.recordResponse(o, status, at)
+		o.Status = status
+		o.RespondedAt = &at
+	}
+	return nil
+}
+
+func (r *rideOfferRepository) ExpireOpen(ctx context.Context, tx *sqlx.Tx, rideID string, at time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, o := range r.s.offers {
+		if o.RideID == rideID && (o.Status == models.OfferStatusPending || o.Status == models.OfferStatusCountered) {
+			r.recordResponse(o, models.OfferStatusExpired, at)
+			o.Status = models.OfferStatusExpired
+			o.RespondedAt = &at
+		}
+	}
+	return nil
+}
+
+// recordResponse measures the driver's answer to the offer, or the timeout of
+// one left unanswered, and folds it into their typical response time, as the
+// ride_offers_response trigger does
+func (r *rideOfferRepository) recordResponse(o *models.RideOffer, status string, at time.Time) {
+	var ms int
+	switch {
+	case models.IsOfferResponse(o.Status, status):
+		ms = int(max(0, at.Sub(o.OfferedAt).Milliseconds()))
+		o.ResponseMs = &ms
+	case models.IsOfferTimeout(o, status, at):
+		ms = int(max(0, o.ExpiresAt.Sub(o.OfferedAt).Milliseconds()))
+	default:
+		return
+	}
+
+	d, ok := r.s.drivers[o.DriverID]
+	if !ok {
+		return
+	}
+	typical := ms
+	if d.OfferResponseMs != nil {
+		typical = int(math.Round(float64(*d.OfferResponseMs)*(1-models.OfferResponseWeight) + float64(ms)*models.OfferResponseWeight))
+	}
+	d.OfferResponseMs = &typical
+}
+
+func (r *rideOfferRepository) ExpireOldOffers(ctx context.Context, rideID string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	now := time.Now()
+	for _, o := range r.s.offers {
+		if o.RideID == rideID && (o.Status == models.OfferStatusPending ||
+			o.Status == models.OfferStatusCountered || o.Status == models.OfferStatusQueued) {
+			r.recordResponse(o, models.OfferStatusExpired, now)
+			o.Status = models.OfferStatusExpired
+			o.RespondedAt = &now
+		}
+	}
+	return nil
+}
+
+func (r *rideOfferRepository) GetOfferedDriverIDs(ctx context.Context, rideID string) (map[string]bool, error) {
+	offered := make(map[string]bool)
+	for _, o := range r.filter(func(o *models.RideOffer) bool { return o.RideID == rideID }) {
+		offered[o.DriverID] = true
+	}
+	return offered, nil
+}
+
+// GetByIDForUpdate ignores tx; the store's lock is held only for the read
+func (r *rideOfferRepository) GetByIDForUpdate(ctx context.Context, tx *sqlx.Tx, id string) (*models.RideOffer, error) {
+	return r.GetByID(ctx, id)
+}
+
+func (r *rideOfferRepository) Counter(ctx context.Context, id string, fare float64, expiresAt time.Time) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	o, ok := r.s.offers[id]
+	if !ok || o.Status != models.OfferStatusPending {
+		return false, nil
+	}
+	now := time.Now()
+	r.recordResponse(o, models.OfferStatusCountered, now)
+	o.Status = models.OfferStatusCountered
+	o.OfferedFare = &fare
+	o.CounteredAt = &now
+	o.RespondedAt = &now
+	o.ExpiresAt = expiresAt
+	return true, nil
+}
+
+func (r *rideOfferRepository) GetCounteredByRideID(ctx context.Context, rideID string) ([]*models.RideOffer, error) {
+	now := time.Now()
+	offers := r.filter(func(o *models.RideOffer) bool {
+		return o.RideID == rideID && o.Status == models.OfferStatusCountered && o.ExpiresAt.After(now)
+	})
+	sort.Slice(offers, func(i, j int) bool {
+		a, b := offers[i], offers[j]
+		if a.OfferedFare != nil && b.OfferedFare != nil && *a.OfferedFare != *b.OfferedFare {
+			return *a.OfferedFare < *b.OfferedFare
+		}
+		return a.CounteredAt != nil && b.CounteredAt != nil && a.CounteredAt.Before(*b.CounteredAt)
+	})
+	return offers, nil
+}
+
+func (r *rideOfferRepository) GetQueuedByDriverID(ctx context.Context, driverID string) (*models.RideOffer, error) {
+	return first(r.filter(func(o *models.RideOffer) bool {
+		return o.DriverID == driverID && o.Status == models.OfferStatusQueued
+	})), nil
+}
+
+func (r *rideOfferRepository) GetSLAStats(ctx context.Context, since time.Time, targets map[string]models.SLOTargets, defaults models.SLOTargets) ([]*models.SLAStats, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	type samples struct {
+		region                 *string
+		match, accept, pickups []float64
+		target                 models.SLOTargets
+		stats                  models.SLAStats
+	}
+	groups := make(map[string]*samples)
+	for _, o := range r.s.offers {
+		ride, ok := r.s.rides[o.RideID]
+		if !ok || o.Status != models.OfferStatusAccepted || o.Chained || o.RespondedAt == nil ||
+			o.RespondedAt.Before(since) {
+			continue
+		}
+
+		key := deref(ride.RegionCode)
+		g, ok := groups[key]
+		if !ok {
+			g = &samples{region: ride.RegionCode, target: defaults}
+			if t, ok := targets[key]; ok && ride.RegionCode != nil {
+				g.target = t
+			}
+			groups[key] = g
+		}
+
+		// Matching restarts from the reassignment when the first driver cancelled
+		started := ride.CreatedAt
+		if ride.ReassignedAt != nil && !ride.ReassignedAt.After(o.OfferedAt) {
+			started = *ride.ReassignedAt
+		}
+		matchSecs := o.RespondedAt.Sub(started).Seconds()
+		g.match = append(g.match, matchSecs)
+		if matchSecs <= float64(g.target.MatchSeconds) {
+			g.stats.MatchesWithin++
+		}
+
+		responded := *o.RespondedAt
+		if o.CounteredAt != nil {
+			responded = *o.CounteredAt
+		}
+		acceptSecs := responded.Sub(o.OfferedAt).Seconds()
+		g.accept = append(g.accept, acceptSecs)
+		if acceptSecs <= float64(g.target.OfferAcceptanceSeconds) {
+			g.stats.AcceptancesWithin++
+		}
+
+		if ride.DriverID != nil && *ride.DriverID == o.DriverID && ride.ArrivedAt != nil {
+			pickupSecs := ride.ArrivedAt.Sub(*o.RespondedAt).Seconds()
+			g.pickups = append(g.pickups, pickupSecs)
+			if pickupSecs <= float64(g.target.PickupSeconds) {
+				g.stats.PickupsWithin++
+			}
+		}
+	}
+
+	var stats []*models.SLAStats
+	for _, g := range groups {
+		s := g.stats
+		s.RegionCode = g.region
+		s.Matches = len(g.match)
+		s.MatchP90Secs = percentile(g.match, 0.9)
+		s.Acceptances = len(g.accept)
+		s.AcceptanceP90Secs = percentile(g.accept, 0.9)
+		s.Pickups = len(g.pickups)
+		s.PickupP90Secs = percentile(g.pickups, 0.9)
+		stats = append(stats, &s)
+	}
+	sort.Slice(stats, func(i, j int) bool { return deref(stats[i].RegionCode) < deref(stats[j].RegionCode) })
+	return stats, nil
+}
+
+func (r *rideOfferRepository) MarkViewed(ctx context.Context, ids []string, at time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, id := range ids {
+		if o, ok := r.s.offers[id]; ok && o.ViewedAt == nil {
+			o.ViewedAt = &at
+		}
+	}
+	return nil
+}
+
+func (r *rideOfferRepository) filter(match func(o *models.RideOffer) bool) []*models.RideOffer {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var offers []*models.RideOffer
+	for _, o := range r.s.offers {
+		if match(o) {
+			c := *o
+			offers = append(offers, &c)
+		}
+	}
+	return offers
+}
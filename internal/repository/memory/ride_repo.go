@@ -0,0 +1,429 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+	"github.com/aditya/go-comet/internal/tenant"
+	"github.com/jmoiron/sqlx"
+)
+
+type rideRepository struct {
+	s *Store
+}
+
+func NewRideRepository(s *Store) repository.RideRepository {
+	return &rideRepository{s: s}
+}
+
+func (r *rideRepository) Create(ctx context.Context, ride *models.Ride) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if ride.ID == "" {
+		ride.ID = newID()
+	}
+	ride.CreatedAt = time.Now()
+	ride.UpdatedAt = time.Now()
+	ride.Status = models.RideStatusPending
+	ride.SurgeMultiplier = 1.0
+	if ride.PricingMode == "" {
+		ride.PricingMode = models.PricingModeStandard
+	}
+	ride.TenantCode = tenant.CodeOrDefault(ctx)
+
+	c := *ride
+	c.Delivery = nil
+	r.s.rides[ride.ID] = &c
+	return nil
+}
+
+func (r *rideRepository) GetByID(ctx context.Context, id string) (*models.Ride, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	ride, ok := r.s.rides[id]
+	if !ok || !inTenant(ctx, ride.TenantCode) {
+		return nil, nil
+	}
+	c := *ride
+	return &c, nil
+}
+
+func (r *rideRepository) GetByIdempotencyKey(ctx context.Context, key string) (*models.Ride, error) {
+	rides := r.filter(func(ride *models.Ride) bool {
+		return ride.IdempotencyKey != nil && *ride.IdempotencyKey == key && inTenant(ctx, ride.TenantCode)
+	})
+	return first(rides), nil
+}
+
+func (r *rideRepository) Update(ctx context.Context, ride *models.Ride) error {
+	ride.UpdatedAt = time.Now()
+	r.update(ride.ID, func(s *models.Ride) bool {
+		s.Status = ride.Status
+		s.DriverID = ride.DriverID
+		s.EstimatedFare = ride.EstimatedFare
+		s.SurgeMultiplier = ride.SurgeMultiplier
+		s.EstimatedDistanceKm = ride.EstimatedDistanceKm
+		s.EstimatedDurationMin = ride.EstimatedDurationMin
+		return true
+	})
+	return nil
+}
+
+func (r *rideRepository) UpdateStatus(ctx context.Context, id, status string) error {
+	r.update(id, func(ride *models.Ride) bool {
+		ride.Status = status
+		return true
+	})
+	return nil
+}
+
+func (r *rideRepository) Assign(ctx context.Context, tx *sqlx.Tx, id, driverID, status string, agreedFare *float64, etaMin *int, at time.Time) error {
+	r.updateAt(id, at, func(ride *models.Ride) bool {
+		ride.DriverID = &driverID
+		ride.Status = status
+		ride.AgreedFare = agreedFare
+		ride.PickupETAMin = etaMin
+		return true
+	})
+	return nil
+}
+
+func (r *rideRepository) SetStatus(ctx context.Context, tx *sqlx.Tx, id, status string, at time.Time) error {
+	r.updateAt(id, at, func(ride *models.Ride) bool {
+		ride.Status = status
+		return true
+	})
+	return nil
+}
+
+func (r *rideRepository) SetDriver(ctx context.Context, tx *sqlx.Tx, id, driverID string, at time.Time) error {
+	r.updateAt(id, at, func(ride *models.Ride) bool {
+		ride.DriverID = &driverID
+		return true
+	})
+	return nil
+}
+
+func (r *rideRepository) AssignDriver(ctx context.Context, rideID, driverID string) error {
+	r.update(rideID, func(ride *models.Ride) bool {
+		ride.DriverID = &driverID
+		ride.Status = models.RideStatusDriverAssigned
+		return true
+	})
+	return nil
+}
+
+func (r *rideRepository) Cancel(ctx context.Context, id, cancelledBy, reason string) error {
+	r.update(id, func(ride *models.Ride) bool {
+		cancel(ride, cancelledBy, reason)
+		return true
+	})
+	return nil
+}
+
+func (r *rideRepository) GetActiveRideByUserID(ctx context.Context, userID string) (*models.Ride, error) {
+	rides := r.filter(func(ride *models.Ride) bool {
+		return ride.UserID == userID && ride.Status != models.RideStatusCompleted &&
+			ride.Status != models.RideStatusCancelled && ride.Status != models.RideStatusScheduled
+	})
+	sortRidesNewestFirst(rides)
+	return first(rides), nil
+}
+
+func (r *rideRepository) GetActiveRideByDriverID(ctx context.Context, driverID string) (*models.Ride, error) {
+	rides := r.filter(func(ride *models.Ride) bool {
+		return ride.DriverID != nil && *ride.DriverID == driverID &&
+			ride.Status != models.RideStatusCompleted && ride.Status != models.RideStatusCancelled &&
+			ride.Status != models.RideStatusQueued
+	})
+	sortRidesNewestFirst(rides)
+	return first(rides), nil
+}
+
+// GetByIDForUpdate ignores tx; the store's lock is held only for the read
+func (r *rideRepository) GetByIDForUpdate(ctx context.Context, tx *sqlx.Tx, id string) (*models.Ride, error) {
+	return r.GetByID(tenant.WithCode(ctx, ""), id)
+}
+
+// Search matches the query against the addresses as case-insensitive substrings
+// rather than Postgres full-text search
+func (r *rideRepository) Search(ctx context.Context, filter *models.RideSearchFilter) ([]*models.Ride, error) {
+	query := strings.ToLower(filter.Query)
+	rides := r.filter(func(ride *models.Ride) bool {
+		switch {
+		case filter.Status != "" && ride.Status != filter.Status,
+			filter.RegionCode != "" && (ride.RegionCode == nil || *ride.RegionCode != filter.RegionCode),
+			filter.TenantCode != "" && ride.TenantCode != filter.TenantCode,
+			filter.PaymentMethod != "" && ride.PaymentMethod != filter.PaymentMethod,
+			filter.From != nil && ride.CreatedAt.Before(*filter.From),
+			filter.To != nil && !ride.CreatedAt.Before(*filter.To),
+			filter.MinSurge != nil && ride.SurgeMultiplier <= *filter.MinSurge:
+			return false
+		}
+		if query == "" {
+			return true
+		}
+		for _, addr := range []*string{ride.PickupAddress, ride.DropoffAddress} {
+			if addr != nil && strings.Contains(strings.ToLower(*addr), query) {
+				return true
+			}
+		}
+		return false
+	})
+	sortRidesNewestFirst(rides)
+	return page(rides, filter.Limit, filter.Offset), nil
+}
+
+func (r *rideRepository) GetHistory(ctx context.Context, filter *models.RideHistoryFilter) ([]*models.Ride, error) {
+	rides := r.filter(func(ride *models.Ride) bool {
+		switch {
+		case filter.UserID != "" && ride.UserID != filter.UserID,
+			filter.DriverID != "" && (ride.DriverID == nil || *ride.DriverID != filter.DriverID),
+			len(filter.Statuses) > 0 && !contains(filter.Statuses, ride.Status),
+			filter.From != nil && ride.CreatedAt.Before(*filter.From),
+			filter.To != nil && !ride.CreatedAt.Before(*filter.To):
+			return false
+		}
+		if filter.AfterCreatedAt == nil {
+			return true
+		}
+		return ride.CreatedAt.Before(*filter.AfterCreatedAt) ||
+			ride.CreatedAt.Equal(*filter.AfterCreatedAt) && ride.ID < filter.AfterID
+	})
+	sort.Slice(rides, func(i, j int) bool {
+		if !rides[i].CreatedAt.Equal(rides[j].CreatedAt) {
+			return rides[i].CreatedAt.After(rides[j].CreatedAt)
+		}
+		return rides[i].ID > rides[j].ID
+	})
+	return page(rides, filter.Limit, 0), nil
+}
+
+func (r *rideRepository) CancelStaleBidRides(ctx context.Context, createdBefore time.Time, reason string) ([]string, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var ids []string
+	for _, ride := range r.s.rides {
+		if ride.PricingMode == models.PricingModeBid && ride.Status == models.RideStatusMatching &&
+			ride.CreatedAt.Before(createdBefore) {
+			cancel(ride, "system", reason)
+			ride.UpdatedAt = time.Now()
+			ids = append(ids, ride.ID)
+		}
+	}
+	return ids, nil
+}
+
+func (r *rideRepository) GetMatchingCreatedBefore(ctx context.Context, createdBefore time.Time) ([]*models.Ride, error) {
+	rides := r.filter(func(ride *models.Ride) bool {
+		return ride.Status == models.RideStatusMatching && ride.CreatedAt.Before(createdBefore)
+	})
+	sort.Slice(rides, func(i, j int) bool { return rides[i].CreatedAt.Before(rides[j].CreatedAt) })
+	return rides, nil
+}
+
+func (r *rideRepository) CancelIfMatching(ctx context.Context, id, cancelledBy, reason string) (bool, error) {
+	return r.CancelIfStatus(ctx, id, models.RideStatusMatching, cancelledBy, reason)
+}
+
+func (r *rideRepository) RecordDispatch(ctx context.Context, id string, attempts int, radiusKm float64, at time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if ride, ok := r.s.rides[id]; ok {
+		ride.MatchingAttempts = attempts
+		ride.MatchRadiusKm = &radiusKm
+		ride.LastDispatchedAt = &at
+		ride.UpdatedAt = at
+	}
+	return nil
+}
+
+func (r *rideRepository) GetDispatchDue(ctx context.Context, dispatchedBefore time.Time) ([]*models.Ride, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	now := time.Now()
+	pending := make(map[string]bool)
+	for _, o := range r.s.offers {
+		if o.Status == models.OfferStatusPending && o.ExpiresAt.After(now) {
+			pending[o.RideID] = true
+		}
+	}
+
+	var rides []*models.Ride
+	for _, ride := range r.s.rides {
+		if ride.Status == models.RideStatusMatching && ride.PricingMode != models.PricingModeBid &&
+			lastDispatched(ride).Before(dispatchedBefore) && !pending[ride.ID] {
+			c := *ride
+			rides = append(rides, &c)
+		}
+	}
+	sort.Slice(rides, func(i, j int) bool { return lastDispatched(rides[i]).Before(lastDispatched(rides[j])) })
+	return rides, nil
+}
+
+// lastDispatched is when the ride's last dispatch round ran, or when it started
+// matching if it hasn't had one
+func lastDispatched(ride *models.Ride) time.Time {
+	if ride.LastDispatchedAt != nil {
+		return *ride.LastDispatchedAt
+	}
+	return ride.UpdatedAt
+}
+
+func (r *rideRepository) GetStuck(ctx context.Context, statuses []string, updatedBefore time.Time) ([]*models.Ride, error) {
+	rides := r.filter(func(ride *models.Ride) bool {
+		return contains(statuses, ride.Status) && ride.PricingMode != models.PricingModeBid &&
+			ride.UpdatedAt.Before(updatedBefore)
+	})
+	sort.Slice(rides, func(i, j int) bool { return rides[i].UpdatedAt.Before(rides[j].UpdatedAt) })
+	return rides, nil
+}
+
+func (r *rideRepository) CancelIfStatus(ctx context.Context, id, status, cancelledBy, reason string) (bool, error) {
+	return r.update(id, func(ride *models.Ride) bool {
+		if ride.Status != status {
+			return false
+		}
+		cancel(ride, cancelledBy, reason)
+		return true
+	}), nil
+}
+
+func (r *rideRepository) MarkEnRoute(ctx context.Context, id string, at time.Time) (bool, error) {
+	return r.updateAt(id, at, func(ride *models.Ride) bool {
+		if ride.Status != models.RideStatusDriverAssigned {
+			return false
+		}
+		nav := models.NavigationEnRouteToPickup
+		ride.NavigationStatus = &nav
+		if ride.EnRouteAt == nil {
+			ride.EnRouteAt = &at
+		}
+		return true
+	}), nil
+}
+
+func (r *rideRepository) MarkArrived(ctx context.Context, id string, at time.Time) (bool, error) {
+	return r.updateAt(id, at, func(ride *models.Ride) bool {
+		if ride.Status != models.RideStatusDriverAssigned {
+			return false
+		}
+		nav := models.NavigationWaitingAtPickup
+		ride.Status = models.RideStatusDriverArrived
+		ride.NavigationStatus = &nav
+		ride.ArrivedAt = &at
+		return true
+	}), nil
+}
+
+func (r *rideRepository) CountMatchingAhead(ctx context.Context, ride *models.Ride) (int, error) {
+	ahead := r.filter(func(other *models.Ride) bool {
+		return other.Status == models.RideStatusMatching && other.VehicleType == ride.VehicleType &&
+			other.CreatedAt.Before(ride.CreatedAt) && equalPtr(other.RegionCode, ride.RegionCode)
+	})
+	return len(ahead), nil
+}
+
+func (r *rideRepository) Reassign(ctx context.Context, id, driverID string, at time.Time) (bool, error) {
+	return r.updateAt(id, at, func(ride *models.Ride) bool {
+		if ride.DriverID == nil || *ride.DriverID != driverID ||
+			(ride.Status != models.RideStatusDriverAssigned && ride.Status != models.RideStatusDriverArrived) {
+			return false
+		}
+		ride.Status = models.RideStatusMatching
+		ride.ReassignedFrom = ride.DriverID
+		ride.DriverID = nil
+		ride.ReassignedAt = &at
+		if ride.OriginalPickupETAMin == nil {
+			ride.OriginalPickupETAMin = ride.PickupETAMin
+		}
+		ride.PickupETAMin = nil
+		ride.NavigationStatus = nil
+		ride.EnRouteAt = nil
+		ride.ArrivedAt = nil
+		ride.MatchingAttempts = 0
+		ride.MatchRadiusKm = nil
+		ride.LastDispatchedAt = nil
+		return true
+	}), nil
+}
+
+func (r *rideRepository) GetScheduledByUserID(ctx context.Context, userID string) ([]*models.Ride, error) {
+	rides := r.filter(func(ride *models.Ride) bool {
+		return ride.UserID == userID && ride.Status == models.RideStatusScheduled
+	})
+	sortRidesByPickupTime(rides)
+	return rides, nil
+}
+
+func (r *rideRepository) GetScheduledDue(ctx context.Context, pickupBefore time.Time) ([]*models.Ride, error) {
+	rides := r.filter(func(ride *models.Ride) bool {
+		return ride.Status == models.RideStatusScheduled && ride.ScheduledAt != nil && !ride.ScheduledAt.After(pickupBefore)
+	})
+	sortRidesByPickupTime(rides)
+	return rides, nil
+}
+
+func (r *rideRepository) StartScheduled(ctx context.Context, id string, at time.Time) (bool, error) {
+	return r.updateAt(id, at, func(ride *models.Ride) bool {
+		if ride.Status != models.RideStatusScheduled {
+			return false
+		}
+		ride.Status = models.RideStatusMatching
+		return true
+	}), nil
+}
+
+// update applies fn to the stored ride, bumping updated_at if fn reports a change
+func (r *rideRepository) update(id string, fn func(ride *models.Ride) bool) bool {
+	return r.updateAt(id, time.Now(), fn)
+}
+
+func (r *rideRepository) updateAt(id string, at time.Time, fn func(ride *models.Ride) bool) bool {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	ride, ok := r.s.rides[id]
+	if !ok || !fn(ride) {
+		return false
+	}
+	ride.UpdatedAt = at
+	return true
+}
+
+func (r *rideRepository) filter(match func(ride *models.Ride) bool) []*models.Ride {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var rides []*models.Ride
+	for _, ride := range r.s.rides {
+		if match(ride) {
+			c := *ride
+			rides = append(rides, &c)
+		}
+	}
+	return rides
+}
+
+func cancel(ride *models.Ride, cancelledBy, reason string) {
+	ride.Status = models.RideStatusCancelled
+	ride.CancelledBy = &cancelledBy
+	ride.CancellationReason = &reason
+}
+
+func sortRidesNewestFirst(rides []*models.Ride) {
+	sort.Slice(rides, func(i, j int) bool { return rides[i].CreatedAt.After(rides[j].CreatedAt) })
+}
+
+func sortRidesByPickupTime(rides []*models.Ride) {
+	sort.Slice(rides, func(i, j int) bool { return deref(rides[i].ScheduledAt).Before(deref(rides[j].ScheduledAt)) })
+}
@@ -0,0 +1,121 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+type riskRepository struct {
+	s *Store
+}
+
+func NewRiskRepository(s *Store) repository.RiskRepository {
+	return &riskRepository{s: s}
+}
+
+func (r *riskRepository) CreateOverride(ctx context.Context, override *models.RiskOverride) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if override.ID == "" {
+		override.ID = newID()
+	}
+	override.CreatedAt = time.Now()
+
+	c := *override
+	r.s.riskOverrides[override.ID] = &c
+	return nil
+}
+
+func (r *riskRepository) GetOverrideByID(ctx context.Context, id string) (*models.RiskOverride, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	override, ok := r.s.riskOverrides[id]
+	if !ok {
+		return nil, nil
+	}
+	c := *override
+	return &c, nil
+}
+
+func (r *riskRepository) GetOverridesByUserID(ctx context.Context, userID string) ([]*models.RiskOverride, error) {
+	return r.overrides(userID, func(o *models.RiskOverride) bool { return true }), nil
+}
+
+func (r *riskRepository) GetActiveOverride(ctx context.Context, userID string, at time.Time) (*models.RiskOverride, error) {
+	return first(r.overrides(userID, func(o *models.RiskOverride) bool { return o.ActiveAt(at) })), nil
+}
+
+func (r *riskRepository) RevokeOverride(ctx context.Context, id, revokedBy string, at time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if override, ok := r.s.riskOverrides[id]; ok {
+		override.RevokedAt = &at
+		override.RevokedBy = &revokedBy
+	}
+	return nil
+}
+
+func (r *riskRepository) RecordEvent(ctx context.Context, event *models.RiskEvent) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if event.ID == "" {
+		event.ID = newID()
+	}
+	event.CreatedAt = time.Now()
+
+	c := *event
+	r.s.riskEvents = append(r.s.riskEvents, &c)
+	return nil
+}
+
+func (r *riskRepository) GetRecentEvents(ctx context.Context, userID string, limit int) ([]*models.RiskEvent, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var events []*models.RiskEvent
+	for _, e := range r.s.riskEvents {
+		if e.UserID == userID {
+			c := *e
+			events = append(events, &c)
+		}
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].CreatedAt.After(events[j].CreatedAt) })
+	return page(events, limit, 0), nil
+}
+
+func (r *riskRepository) HasPaidWithCard(ctx context.Context, userID, fingerprint string) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, ride := range r.s.rides {
+		if ride.UserID == userID && ride.CardFingerprint != nil && *ride.CardFingerprint == fingerprint &&
+			ride.Status == models.RideStatusCompleted {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// overrides returns the user's matching overrides, newest first
+func (r *riskRepository) overrides(userID string, match func(o *models.RiskOverride) bool) []*models.RiskOverride {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var overrides []*models.RiskOverride
+	for _, o := range r.s.riskOverrides {
+		if o.UserID == userID && match(o) {
+			c := *o
+			overrides = append(overrides, &c)
+		}
+	}
+	sort.Slice(overrides, func(i, j int) bool { return overrides[i].CreatedAt.After(overrides[j].CreatedAt) })
+	return overrides
+}
@@ -0,0 +1,83 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+type rolloutRepository struct {
+	s *Store
+}
+
+func NewRolloutRepository(s *Store) repository.RolloutRepository {
+	return &rolloutRepository{s: s}
+}
+
+func (r *rolloutRepository) RecordExposure(ctx context.Context, exposure *models.RolloutExposure) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, e := range r.s.rolloutExposures {
+		if e.RegionCode == exposure.RegionCode && e.Product == exposure.Product &&
+			e.UserID == exposure.UserID && e.Enabled == exposure.Enabled {
+			return nil
+		}
+	}
+	if exposure.ID == "" {
+		exposure.ID = newID()
+	}
+	exposure.CreatedAt = time.Now()
+
+	c := *exposure
+	r.s.rolloutExposures = append(r.s.rolloutExposures, &c)
+	return nil
+}
+
+func (r *rolloutRepository) GetSummary(ctx context.Context, regionCode string) ([]*models.RolloutSummary, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	type riders struct{ enabled, heldBack, booked map[string]bool }
+	byProduct := make(map[string]*riders)
+	for _, e := range r.s.rolloutExposures {
+		if e.RegionCode != regionCode {
+			continue
+		}
+		p, ok := byProduct[e.Product]
+		if !ok {
+			p = &riders{enabled: map[string]bool{}, heldBack: map[string]bool{}, booked: map[string]bool{}}
+			byProduct[e.Product] = p
+		}
+		if !e.Enabled {
+			p.heldBack[e.UserID] = true
+			continue
+		}
+		p.enabled[e.UserID] = true
+		for _, ride := range r.s.rides {
+			product := ride.VehicleType
+			if ride.Product != nil {
+				product = *ride.Product
+			}
+			if ride.UserID == e.UserID && deref(ride.RegionCode) == regionCode && product == e.Product &&
+				!ride.CreatedAt.Before(e.CreatedAt) {
+				p.booked[e.UserID] = true
+			}
+		}
+	}
+
+	summaries := make([]*models.RolloutSummary, 0, len(byProduct))
+	for product, p := range byProduct {
+		summaries = append(summaries, &models.RolloutSummary{
+			Product:        product,
+			EnabledRiders:  len(p.enabled),
+			HeldBackRiders: len(p.heldBack),
+			BookedRiders:   len(p.booked),
+		})
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Product < summaries[j].Product })
+	return summaries, nil
+}
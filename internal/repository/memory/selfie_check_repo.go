@@ -0,0 +1,66 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+type selfieCheckRepository struct {
+	s *Store
+}
+
+func NewSelfieCheckRepository(s *Store) repository.SelfieCheckRepository {
+	return &selfieCheckRepository{s: s}
+}
+
+func (r *selfieCheckRepository) Create(ctx context.Context, check *models.SelfieCheck) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, existing := range r.s.selfieChecks {
+		if existing.UploadID == check.UploadID {
+			return false, nil
+		}
+	}
+	if check.ID == "" {
+		check.ID = newID()
+	}
+	check.CreatedAt = time.Now()
+
+	c := *check
+	r.s.selfieChecks = append(r.s.selfieChecks, &c)
+	return true, nil
+}
+
+func (r *selfieCheckRepository) GetLatestByDriverID(ctx context.Context, driverID string) (*models.SelfieCheck, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var latest *models.SelfieCheck
+	for _, check := range r.s.selfieChecks {
+		if check.DriverID == driverID && (latest == nil || !check.CreatedAt.Before(latest.CreatedAt)) {
+			latest = check
+		}
+	}
+	if latest == nil {
+		return nil, nil
+	}
+	c := *latest
+	return &c, nil
+}
+
+func (r *selfieCheckRepository) GetByUploadID(ctx context.Context, uploadID string) (*models.SelfieCheck, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, check := range r.s.selfieChecks {
+		if check.UploadID == uploadID {
+			c := *check
+			return &c, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,104 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+type sessionRepository struct {
+	s *Store
+}
+
+func NewSessionRepository(s *Store) repository.SessionRepository {
+	return &sessionRepository{s: s}
+}
+
+func (r *sessionRepository) Create(ctx context.Context, session *models.Session) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if session.ID == "" {
+		session.ID = newID()
+	}
+	now := time.Now()
+	session.CreatedAt = now
+	session.LastSeenAt = now
+
+	c := *session
+	r.s.sessions[session.ID] = &c
+	return nil
+}
+
+func (r *sessionRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*models.Session, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, s := range r.s.sessions {
+		if s.TokenHash == tokenHash {
+			c := *s
+			return &c, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *sessionRepository) ListActive(ctx context.Context, principalType, principalID string) ([]*models.Session, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var sessions []*models.Session
+	for _, s := range r.s.sessions {
+		if s.PrincipalType == principalType && s.PrincipalID == principalID && s.IsActive() {
+			c := *s
+			sessions = append(sessions, &c)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt) })
+	return sessions, nil
+}
+
+func (r *sessionRepository) Revoke(ctx context.Context, principalType, principalID, id, reason string, at time.Time) (bool, error) {
+	n := r.revoke(principalType, principalID, reason, at, func(s *models.Session) bool { return s.ID == id })
+	return n > 0, nil
+}
+
+func (r *sessionRepository) RevokeOthers(ctx context.Context, principalType, principalID, keepID, reason string, at time.Time) (int64, error) {
+	return r.revoke(principalType, principalID, reason, at, func(s *models.Session) bool {
+		return keepID == "" || s.ID != keepID
+	}), nil
+}
+
+func (r *sessionRepository) RevokeDevice(ctx context.Context, principalType, principalID, deviceID, reason string, at time.Time) error {
+	r.revoke(principalType, principalID, reason, at, func(s *models.Session) bool { return s.DeviceID == deviceID })
+	return nil
+}
+
+func (r *sessionRepository) Touch(ctx context.Context, id string, at time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if s, ok := r.s.sessions[id]; ok {
+		s.LastSeenAt = at
+	}
+	return nil
+}
+
+// revoke ends the account's active sessions that match and returns how many
+func (r *sessionRepository) revoke(principalType, principalID, reason string, at time.Time, match func(s *models.Session) bool) int64 {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var n int64
+	for _, s := range r.s.sessions {
+		if s.PrincipalType == principalType && s.PrincipalID == principalID && s.IsActive() && match(s) {
+			s.RevokedAt = &at
+			s.RevokedReason = &reason
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,297 @@
+// Package memory implements the repository interfaces on in-process maps, for
+// running the API without Postgres (see --demo). Data is lost on restart and
+// the database triggers and row locks of the SQL repositories aren't emulated.
+package memory
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/tenant"
+	"github.com/google/uuid"
+)
+
+// Store holds every table. One mutex guards it all; the repositories copy rows
+// in and out so callers never share memory with the store.
+type Store struct {
+	mu sync.Mutex
+	// Held by the transactor for the length of a unit of work
+	txMu sync.Mutex
+
+	users               map[string]*models.User
+	drivers             map[string]*models.Driver
+	rides               map[string]*models.Ride
+	offers              map[string]*models.RideOffer
+	trips               map[string]*models.Trip
+	payments            map[string]*models.Payment
+	idempotencyKeys     map[string]*models.IdempotentResponse
+	audit               []*models.AuditEntry
+	regions             map[string]*models.Region
+	uploads             map[string]*models.Upload
+	selfieChecks        []*models.SelfieCheck
+	favorites           []*models.FavoriteDriver
+	insurancePolicies   map[string]*models.TripInsurance
+	insuranceClaims     map[string]*models.InsuranceClaim
+	tripSegments        map[string]*models.TripSegment
+	commissionOverrides map[string]*models.CommissionOverride
+	deductionSchedules  map[string]*models.DeductionSchedule
+	ledger              []*models.LedgerEntry
+	incentives          map[string]*models.IncentiveGuarantee
+	paymentHolds        map[string]*models.PaymentHold
+	riskOverrides       map[string]*models.RiskOverride
+	riskEvents          []*models.RiskEvent
+	rideEconomics       map[string]*models.RideEconomics
+	payouts             []*models.Payout
+	wallets             map[string]*models.Wallet
+	walletTxns          []*models.WalletTransaction
+	maintenanceWindows  map[string]*models.MaintenanceWindow
+	matchingExclusions  map[string]*models.MatchingExclusion
+	fareAdjustments     []*models.FareAdjustment
+	trainings           map[string]*models.DriverTraining
+	pickupSpots         map[string]*models.PickupSpot
+	venues              map[string]*models.Venue
+	tenants             map[string]*models.Tenant
+	tripExports         map[string]*models.TripExport
+	cacheRepairs        map[string]*models.DriverCacheRepair
+	deliveries          map[string]*models.Delivery
+	dispatchRounds      []*models.DispatchRound
+	heatSnapshots       []*heatSnapshot
+	pricingEvents       map[string]*models.PricingEvent
+	sessions            map[string]*models.Session
+	ratings             []*models.TripRating
+	cancellationFees    []*models.CancellationFee
+	promos              map[string]*models.PromoCode
+	promoRedemptions    []*models.PromoRedemption
+	rolloutExposures    []*models.RolloutExposure
+	waitingContent      map[string]*models.WaitingContent
+	legalDocuments      []*models.LegalDocument
+	consentRecords      []*models.ConsentRecord
+	backups             map[string]*models.Backup
+	workerLeases        map[string]workerLease
+	vehicleTypes        map[string]*models.VehicleTypeDefinition
+}
+
+func NewStore() *Store {
+	return &Store{
+		users:               make(map[string]*models.User),
+		drivers:             make(map[string]*models.Driver),
+		rides:               make(map[string]*models.Ride),
+		offers:              make(map[string]*models.RideOffer),
+		trips:               make(map[string]*models.Trip),
+		payments:            make(map[string]*models.Payment),
+		idempotencyKeys:     make(map[string]*models.IdempotentResponse),
+		regions:             make(map[string]*models.Region),
+		uploads:             make(map[string]*models.Upload),
+		insurancePolicies:   make(map[string]*models.TripInsurance),
+		insuranceClaims:     make(map[string]*models.InsuranceClaim),
+		tripSegments:        make(map[string]*models.TripSegment),
+		commissionOverrides: make(map[string]*models.CommissionOverride),
+		deductionSchedules:  make(map[string]*models.DeductionSchedule),
+		incentives:          make(map[string]*models.IncentiveGuarantee),
+		paymentHolds:        make(map[string]*models.PaymentHold),
+		riskOverrides:       make(map[string]*models.RiskOverride),
+		rideEconomics:       make(map[string]*models.RideEconomics),
+		wallets:             make(map[string]*models.Wallet),
+		maintenanceWindows:  make(map[string]*models.MaintenanceWindow),
+		waitingContent:      make(map[string]*models.WaitingContent),
+		matchingExclusions:  make(map[string]*models.MatchingExclusion),
+		trainings:           make(map[string]*models.DriverTraining),
+		pickupSpots:         make(map[string]*models.PickupSpot),
+		venues:              make(map[string]*models.Venue),
+		tenants:             make(map[string]*models.Tenant),
+		tripExports:         make(map[string]*models.TripExport),
+		backups:             make(map[string]*models.Backup),
+		workerLeases:        make(map[string]workerLease),
+		vehicleTypes:        make(map[string]*models.VehicleTypeDefinition),
+		cacheRepairs:        make(map[string]*models.DriverCacheRepair),
+		deliveries:          make(map[string]*models.Delivery),
+		pricingEvents:       make(map[string]*models.PricingEvent),
+		sessions:            make(map[string]*models.Session),
+		promos:              make(map[string]*models.PromoCode),
+	}
+}
+
+// Seed loads the rows the migrations insert: the default tenant, the launch
+// regions and the built-in vehicle types
+func (s *Store) Seed() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.tenants[models.DefaultTenant] = &models.Tenant{
+		Code:      models.DefaultTenant,
+		Name:      "Default",
+		Active:    true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	for _, r := range []models.Region{
+		{Code: "blr", Name: "Bengaluru", MinLat: 12.734, MinLng: 77.379, MaxLat: 13.173, MaxLng: 77.882},
+		{Code: "mum", Name: "Mumbai", MinLat: 18.892, MinLng: 72.775, MaxLat: 19.270, MaxLng: 72.986},
+		{Code: "del", Name: "Delhi NCR", MinLat: 28.404, MinLng: 76.839, MaxLat: 28.883, MaxLng: 77.346},
+	} {
+		r.Active = true
+		r.TenantCode = models.DefaultTenant
+		r.CreatedAt = now
+		r.UpdatedAt = now
+		s.regions[r.Code] = &r
+	}
+	for _, v := range models.DefaultVehicleTypes {
+		v.CreatedAt = now
+		v.UpdatedAt = now
+		s.vehicleTypes[v.Code] = &v
+	}
+}
+
+// RecordAudit appends an audit entry. The SQL store writes these from triggers;
+// here callers that want replayable history record them explicitly.
+func (s *Store) RecordAudit(entry *models.AuditEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := *entry
+	c.ID = int64(len(s.audit) + 1)
+	if c.RecordedAt.IsZero() {
+		c.RecordedAt = time.Now()
+	}
+	s.audit = append(s.audit, &c)
+}
+
+// ledgerEntries returns copies of the matching ledger entries
+func (s *Store) ledgerEntries(match func(e *models.LedgerEntry) bool) []*models.LedgerEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var entries []*models.LedgerEntry
+	for _, e := range s.ledger {
+		if match(e) {
+			c := *e
+			entries = append(entries, &c)
+		}
+	}
+	return entries
+}
+
+// postOnce appends the entry unless one of the same type matches, standing in
+// for the ledger's partial unique indexes. The caller holds mu.
+func (s *Store) postOnce(entry *models.LedgerEntry, match func(e *models.LedgerEntry) bool) bool {
+	for _, e := range s.ledger {
+		if e.EntryType == entry.EntryType && match(e) {
+			return false
+		}
+	}
+	c := *entry
+	s.ledger = append(s.ledger, &c)
+	return true
+}
+
+// ledgerBalance sums the driver's ledger. The caller holds mu.
+func (s *Store) ledgerBalance(driverID string) float64 {
+	var balance float64
+	for _, e := range s.ledger {
+		if e.DriverID == driverID {
+			balance += e.Amount
+		}
+	}
+	return balance
+}
+
+// averageRating averages the latest window ratings the account received from
+// raterType, rounded like the rating column; ok is false if there are none. The
+// caller holds mu.
+func (s *Store) averageRating(rateeID, raterType string, window int) (float64, bool) {
+	var received []*models.TripRating
+	for _, rating := range s.ratings {
+		if rating.RateeID == rateeID && rating.RaterType == raterType {
+			received = append(received, rating)
+		}
+	}
+	if len(received) == 0 {
+		return 0, false
+	}
+	sort.SliceStable(received, func(i, j int) bool { return received[i].CreatedAt.After(received[j].CreatedAt) })
+
+	var total int
+	latest := page(received, window, 0)
+	for _, rating := range latest {
+		total += rating.Rating
+	}
+	return math.Round(float64(total)/float64(len(latest))*10) / 10, true
+}
+
+func newID() string {
+	return uuid.New().String()
+}
+
+// inTenant mirrors the SQL repositories' tenant scoping: unscoped contexts see
+// every tenant
+func inTenant(ctx context.Context, code string) bool {
+	scope := tenant.Code(ctx)
+	return scope == "" || scope == code
+}
+
+// errDuplicate reports a unique constraint violation the way Postgres words it
+func errDuplicate(constraint string) error {
+	return fmt.Errorf("duplicate key value violates unique constraint %q", constraint)
+}
+
+// first returns the first item or nil, like a LIMIT 1 query finding no row
+func first[T any](items []*T) *T {
+	if len(items) == 0 {
+		return nil
+	}
+	return items[0]
+}
+
+// page applies LIMIT and OFFSET; a limit of zero or less returns everything
+// after the offset
+func page[T any](items []T, limit, offset int) []T {
+	if offset >= len(items) {
+		return nil
+	}
+	items = items[offset:]
+	if limit > 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
+
+func contains(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+func equalPtr(a, b *string) bool {
+	return a != nil && b != nil && *a == *b
+}
+
+func deref[T any](p *T) T {
+	var zero T
+	if p == nil {
+		return zero
+	}
+	return *p
+}
+
+// percentile interpolates like Postgres' percentile_cont; 0 for no values
+func percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	pos := p * float64(len(sorted)-1)
+	lower := int(math.Floor(pos))
+	upper := int(math.Ceil(pos))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(pos-float64(lower))
+}
@@ -0,0 +1,88 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+type tenantRepository struct {
+	s *Store
+}
+
+func NewTenantRepository(s *Store) repository.TenantRepository {
+	return &tenantRepository{s: s}
+}
+
+func (r *tenantRepository) Create(ctx context.Context, t *models.Tenant) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.tenants[t.Code]; ok {
+		return errDuplicate("tenants_pkey")
+	}
+	now := time.Now()
+	t.CreatedAt = now
+	t.UpdatedAt = now
+	t.Active = true
+
+	c := *t
+	r.s.tenants[t.Code] = &c
+	return nil
+}
+
+func (r *tenantRepository) GetByCode(ctx context.Context, code string) (*models.Tenant, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	t, ok := r.s.tenants[code]
+	if !ok {
+		return nil, nil
+	}
+	c := *t
+	return &c, nil
+}
+
+func (r *tenantRepository) GetAll(ctx context.Context) ([]*models.Tenant, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var tenants []*models.Tenant
+	for _, t := range r.s.tenants {
+		c := *t
+		tenants = append(tenants, &c)
+	}
+	sort.Slice(tenants, func(i, j int) bool { return tenants[i].Code < tenants[j].Code })
+	return tenants, nil
+}
+
+func (r *tenantRepository) Update(ctx context.Context, t *models.Tenant) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	t.UpdatedAt = time.Now()
+	existing, ok := r.s.tenants[t.Code]
+	if !ok {
+		return nil
+	}
+	// The API key hash only changes through SetAPIKeyHash
+	c := *t
+	c.APIKeyHash = existing.APIKeyHash
+	c.CreatedAt = existing.CreatedAt
+	r.s.tenants[t.Code] = &c
+	return nil
+}
+
+func (r *tenantRepository) SetAPIKeyHash(ctx context.Context, code, hash string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if t, ok := r.s.tenants[code]; ok {
+		t.APIKeyHash = &hash
+		t.UpdatedAt = time.Now()
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+type trainingRepository struct {
+	s *Store
+}
+
+func NewTrainingRepository(s *Store) repository.TrainingRepository {
+	return &trainingRepository{s: s}
+}
+
+func (r *trainingRepository) Complete(ctx context.Context, training *models.DriverTraining) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	training.CompletedAt = time.Now()
+
+	c := *training
+	r.s.trainings[trainingKey(training.DriverID, training.Module)] = &c
+	return nil
+}
+
+func (r *trainingRepository) Revoke(ctx context.Context, driverID, module string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	delete(r.s.trainings, trainingKey(driverID, module))
+	return nil
+}
+
+func (r *trainingRepository) GetByDriverID(ctx context.Context, driverID string) ([]*models.DriverTraining, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var trainings []*models.DriverTraining
+	for _, t := range r.s.trainings {
+		if t.DriverID == driverID {
+			c := *t
+			trainings = append(trainings, &c)
+		}
+	}
+	sort.Slice(trainings, func(i, j int) bool { return trainings[i].CompletedAt.Before(trainings[j].CompletedAt) })
+	return trainings, nil
+}
+
+func (r *trainingRepository) HasCompleted(ctx context.Context, driverID, module string) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	_, ok := r.s.trainings[trainingKey(driverID, module)]
+	return ok, nil
+}
+
+func (r *trainingRepository) FilterCompleted(ctx context.Context, module string, driverIDs []string) (map[string]bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	completed := make(map[string]bool)
+	for _, id := range driverIDs {
+		if _, ok := r.s.trainings[trainingKey(id, module)]; ok {
+			completed[id] = true
+		}
+	}
+	return completed, nil
+}
+
+func trainingKey(driverID, module string) string {
+	return driverID + "/" + module
+}
@@ -0,0 +1,26 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/aditya/go-comet/internal/repository"
+	"github.com/jmoiron/sqlx"
+)
+
+// transactor runs units of work one at a time, passing a nil tx that the
+// repositories ignore. Each repository call takes the store's lock itself, so
+// units don't interleave with each other, but writes made before fn fails
+// aren't rolled back; callers check everything before writing.
+type transactor struct {
+	s *Store
+}
+
+func NewTransactor(s *Store) repository.Transactor {
+	return &transactor{s: s}
+}
+
+func (t *transactor) InTx(ctx context.Context, fn func(tx *sqlx.Tx) error) error {
+	t.s.txMu.Lock()
+	defer t.s.txMu.Unlock()
+	return fn(nil)
+}
@@ -0,0 +1,169 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+type tripExportRepository struct {
+	s *Store
+}
+
+func NewTripExportRepository(s *Store) repository.TripExportRepository {
+	return &tripExportRepository{s: s}
+}
+
+func (r *tripExportRepository) Create(ctx context.Context, export *models.TripExport) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if export.ID == "" {
+		export.ID = newID()
+	}
+	export.CreatedAt = time.Now()
+	export.Status = models.TripExportStatusPending
+
+	c := *export
+	r.s.tripExports[export.ID] = &c
+	return nil
+}
+
+func (r *tripExportRepository) GetByID(ctx context.Context, id string) (*models.TripExport, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	export, ok := r.s.tripExports[id]
+	if !ok {
+		return nil, nil
+	}
+	c := *export
+	return &c, nil
+}
+
+func (r *tripExportRepository) GetLatest(ctx context.Context, driverID string, year int) (*models.TripExport, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var latest *models.TripExport
+	for _, e := range r.s.tripExports {
+		if e.DriverID == driverID && e.Year == year && (latest == nil || e.CreatedAt.After(latest.CreatedAt)) {
+			latest = e
+		}
+	}
+	if latest == nil {
+		return nil, nil
+	}
+	c := *latest
+	return &c, nil
+}
+
+func (r *tripExportRepository) ClaimPending(ctx context.Context, limit int, staleBefore time.Time) ([]*models.TripExport, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var claimable []*models.TripExport
+	for _, e := range r.s.tripExports {
+		if e.Status == models.TripExportStatusPending ||
+			(e.Status == models.TripExportStatusProcessing && e.StartedAt != nil && e.StartedAt.Before(staleBefore)) {
+			claimable = append(claimable, e)
+		}
+	}
+	sort.Slice(claimable, func(i, j int) bool { return claimable[i].CreatedAt.Before(claimable[j].CreatedAt) })
+
+	now := time.Now()
+	var exports []*models.TripExport
+	for _, e := range page(claimable, limit, 0) {
+		e.Status = models.TripExportStatusProcessing
+		e.StartedAt = &now
+		c := *e
+		exports = append(exports, &c)
+	}
+	return exports, nil
+}
+
+func (r *tripExportRepository) MarkReady(ctx context.Context, id, objectKey string, tripCount int) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if export, ok := r.s.tripExports[id]; ok {
+		now := time.Now()
+		export.Status = models.TripExportStatusReady
+		export.ObjectKey = &objectKey
+		export.TripCount = &tripCount
+		export.CompletedAt = &now
+	}
+	return nil
+}
+
+func (r *tripExportRepository) MarkFailed(ctx context.Context, id, reason string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if export, ok := r.s.tripExports[id]; ok {
+		now := time.Now()
+		export.Status = models.TripExportStatusFailed
+		export.Error = &reason
+		export.CompletedAt = &now
+	}
+	return nil
+}
+
+func (r *tripExportRepository) GetRows(ctx context.Context, driverID string, from, to time.Time) ([]*models.TripExportRow, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var trips []*models.Trip
+	for _, t := range r.s.trips {
+		if t.DriverID == driverID && t.Status == models.TripStatusCompleted && t.EndTime != nil &&
+			!t.EndTime.Before(from) && t.EndTime.Before(to) {
+			trips = append(trips, t)
+		}
+	}
+	sort.Slice(trips, func(i, j int) bool { return trips[i].EndTime.Before(*trips[j].EndTime) })
+
+	var rows []*models.TripExportRow
+	for _, t := range trips {
+		ride, ok := r.s.rides[t.RideID]
+		if !ok {
+			continue
+		}
+		row := &models.TripExportRow{
+			TripID:         t.ID,
+			RideID:         t.RideID,
+			StartTime:      t.StartTime,
+			EndTime:        t.EndTime,
+			VehicleType:    ride.VehicleType,
+			PickupAddress:  ride.PickupAddress,
+			DropoffAddress: ride.DropoffAddress,
+			DistanceKm:     t.ActualDistanceKm,
+			DurationMins:   t.ActualDurationMin,
+			TotalFare:      t.TotalFare,
+		}
+		// One row per completed payment, as with the SQL left join
+		paid := false
+		for _, p := range r.s.payments {
+			if p.TripID != t.ID || p.Status != models.PaymentStatusCompleted {
+				continue
+			}
+			p := *p
+			paidRow := *row
+			paidRow.PaymentMethod = &p.Method
+			paidRow.PaidAmount = &p.Amount
+			paidRow.PaidAt = &p.CreatedAt
+			paidRow.CarbonOffsetAmount = &p.CarbonOffsetAmount
+			paidRow.CommissionPercent = p.CommissionPercent
+			paidRow.CommissionAmount = p.CommissionAmount
+			paidRow.DriverEarnings = p.DriverEarnings
+			rows = append(rows, &paidRow)
+			paid = true
+		}
+		if !paid {
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
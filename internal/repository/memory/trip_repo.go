@@ -0,0 +1,297 @@
+package memory
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+	"github.com/jmoiron/sqlx"
+)
+
+type tripRepository struct {
+	s *Store
+}
+
+func NewTripRepository(s *Store) repository.TripRepository {
+	return &tripRepository{s: s}
+}
+
+func (r *tripRepository) Create(ctx context.Context, trip *models.Trip) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if trip.ID == "" {
+		trip.ID = newID()
+	}
+	now := time.Now()
+	trip.CreatedAt = now
+	trip.UpdatedAt = now
+	trip.StartTime = &now
+	trip.Status = models.TripStatusStarted
+	trip.PauseDurationSecs = 0
+
+	c := *trip
+	r.s.trips[trip.ID] = &c
+	return nil
+}
+
+func (r *tripRepository) GetByID(ctx context.Context, id string) (*models.Trip, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	trip, ok := r.s.trips[id]
+	if !ok {
+		return nil, nil
+	}
+	c := *trip
+	return &c, nil
+}
+
+func (r *tripRepository) GetByRideID(ctx context.Context, rideID string) (*models.Trip, error) {
+	return first(r.filter(func(t *models.Trip) bool { return t.RideID == rideID })), nil
+}
+
+func (r *tripRepository) Update(ctx context.Context, trip *models.Trip) error {
+	trip.UpdatedAt = time.Now()
+	r.update(trip.ID, func(t *models.Trip) {
+		t.Status = trip.Status
+		t.PauseDurationSecs = trip.PauseDurationSecs
+	})
+	return nil
+}
+
+func (r *tripRepository) UpdateStatus(ctx context.Context, id, status string) error {
+	r.update(id, func(t *models.Trip) { t.Status = status })
+	return nil
+}
+
+func (r *tripRepository) SetStatus(ctx context.Context, tx *sqlx.Tx, id, status string, at time.Time) error {
+	r.update(id, func(t *models.Trip) { t.Status = status })
+	return nil
+}
+
+func (r *tripRepository) SetDriver(ctx context.Context, tx *sqlx.Tx, id, driverID, status string, at time.Time) error {
+	r.update(id, func(t *models.Trip) {
+		t.DriverID = driverID
+		t.Status = status
+	})
+	return nil
+}
+
+func (r *tripRepository) EndTrip(ctx context.Context, trip *models.Trip) error {
+	now := time.Now()
+	trip.EndTime = &now
+	trip.UpdatedAt = now
+	trip.Status = models.TripStatusCompleted
+
+	r.update(trip.ID, func(t *models.Trip) {
+		t.Status = trip.Status
+		t.EndTime = trip.EndTime
+		t.ActualDistanceKm = trip.ActualDistanceKm
+		t.ActualDurationMin = trip.ActualDurationMin
+		t.BaseFare = trip.BaseFare
+		t.DistanceFare = trip.DistanceFare
+		t.TimeFare = trip.TimeFare
+		t.SurgeAmount = trip.SurgeAmount
+		t.TotalFare = trip.TotalFare
+		t.GPSDistanceKm = trip.GPSDistanceKm
+		t.MileageStatus = trip.MileageStatus
+		t.MileageDiscrepancyKm = trip.MileageDiscrepancyKm
+		t.CO2Grams = trip.CO2Grams
+		t.EVDiscount = trip.EVDiscount
+		t.WaitingFee = trip.WaitingFee
+		t.PackageSurcharge = trip.PackageSurcharge
+		t.DeclaredValueSurcharge = trip.DeclaredValueSurcharge
+		t.EventSurcharge = trip.EventSurcharge
+		t.PricingEvents = trip.PricingEvents
+		t.PromoDiscount = trip.PromoDiscount
+		t.RoutePolyline = trip.RoutePolyline
+	})
+	return nil
+}
+
+func (r *tripRepository) GetActiveTripByDriverID(ctx context.Context, driverID string) (*models.Trip, error) {
+	trips := r.filter(func(t *models.Trip) bool {
+		return t.DriverID == driverID &&
+			(t.Status == models.TripStatusStarted || t.Status == models.TripStatusPaused)
+	})
+	sort.Slice(trips, func(i, j int) bool { return trips[i].CreatedAt.After(trips[j].CreatedAt) })
+	return first(trips), nil
+}
+
+func (r *tripRepository) SetOdometerReading(ctx context.Context, id, stage string, readingKm float64, uploadID string) error {
+	r.update(id, func(t *models.Trip) {
+		if stage == models.OdometerStageEnd {
+			t.EndOdometerKm = &readingKm
+			t.EndOdometerUploadID = &uploadID
+		} else {
+			t.StartOdometerKm = &readingKm
+			t.StartOdometerUploadID = &uploadID
+		}
+	})
+	return nil
+}
+
+func (r *tripRepository) UpdateMileageAudit(ctx context.Context, trip *models.Trip) error {
+	trip.UpdatedAt = time.Now()
+	r.update(trip.ID, func(t *models.Trip) {
+		t.MileageStatus = trip.MileageStatus
+		t.MileageDiscrepancyKm = trip.MileageDiscrepancyKm
+		t.MileageReviewNote = trip.MileageReviewNote
+	})
+	return nil
+}
+
+func (r *tripRepository) GetByMileageStatus(ctx context.Context, status string, limit int) ([]*models.Trip, error) {
+	trips := r.filter(func(t *models.Trip) bool {
+		return t.MileageStatus != nil && *t.MileageStatus == status
+	})
+	sortTripsByEndTimeDesc(trips)
+	return page(trips, limit, 0), nil
+}
+
+func (r *tripRepository) HasCompletedTrip(ctx context.Context, userID, driverID string) (bool, error) {
+	trips := r.filter(func(t *models.Trip) bool {
+		return t.UserID == userID && t.DriverID == driverID && t.Status == models.TripStatusCompleted
+	})
+	return len(trips) > 0, nil
+}
+
+func (r *tripRepository) GetCarbonStats(ctx context.Context, ownerType, ownerID string) (*models.CarbonStats, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	stats := &models.CarbonStats{OwnerType: ownerType, OwnerID: ownerID}
+	for _, t := range r.s.trips {
+		owner := t.UserID
+		if ownerType == models.CarbonOwnerDriver {
+			owner = t.DriverID
+		}
+		if owner != ownerID || t.Status != models.TripStatusCompleted {
+			continue
+		}
+
+		// One row per completed payment, as the join would produce
+		var payments []*models.Payment
+		for _, p := range r.s.payments {
+			if p.TripID == t.ID && p.Status == models.PaymentStatusCompleted {
+				payments = append(payments, p)
+			}
+		}
+		if len(payments) == 0 {
+			payments = []*models.Payment{{}}
+		}
+		for _, p := range payments {
+			stats.Trips++
+			stats.DistanceKm += deref(t.ActualDistanceKm)
+			stats.CO2Grams += deref(t.CO2Grams)
+			if p.CarbonOffsetAmount > 0 {
+				stats.OffsetGrams += deref(t.CO2Grams)
+			}
+			stats.OffsetDonated += p.CarbonOffsetAmount
+		}
+	}
+	return stats, nil
+}
+
+func (r *tripRepository) GetEarningsSummary(ctx context.Context, driverID string, since time.Time) (*models.EarningsSummary, error) {
+	summary := &models.EarningsSummary{}
+	for _, t := range r.completedSince(driverID, since) {
+		summary.Gross += deref(t.TotalFare)
+		summary.Trips++
+		if t.StartTime != nil && (summary.FirstTripAt == nil || t.StartTime.Before(*summary.FirstTripAt)) {
+			summary.FirstTripAt = t.StartTime
+		}
+	}
+	return summary, nil
+}
+
+func (r *tripRepository) GetCompletedSince(ctx context.Context, driverID string, since time.Time) ([]*models.Trip, error) {
+	trips := r.completedSince(driverID, since)
+	sortTripsByEndTimeDesc(trips)
+	return trips, nil
+}
+
+func (r *tripRepository) GetFareVariance(ctx context.Context, since time.Time) ([]*models.FareVarianceGroup, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	type key struct{ region, vehicleType string }
+	groups := make(map[key]*models.FareVarianceGroup)
+	variances := make(map[key][]float64)
+	for _, t := range r.s.trips {
+		ride, ok := r.s.rides[t.RideID]
+		// Bid rides are charged the agreed fare, so they say nothing about estimation
+		if !ok || t.Status != models.TripStatusCompleted || t.EndTime == nil || t.EndTime.Before(since) ||
+			t.TotalFare == nil || ride.EstimatedFare == nil || *ride.EstimatedFare <= 0 || ride.AgreedFare != nil {
+			continue
+		}
+
+		k := key{deref(ride.RegionCode), ride.VehicleType}
+		if _, ok := groups[k]; !ok {
+			groups[k] = &models.FareVarianceGroup{RegionCode: ride.RegionCode, VehicleType: ride.VehicleType}
+		}
+		groups[k].Trips++
+		variances[k] = append(variances[k], (*t.TotalFare-*ride.EstimatedFare) / *ride.EstimatedFare * 100)
+	}
+
+	var result []*models.FareVarianceGroup
+	for k, g := range groups {
+		abs := make([]float64, len(variances[k]))
+		for i, v := range variances[k] {
+			abs[i] = math.Abs(v)
+		}
+		g.MedianVariancePercent = percentile(variances[k], 0.5)
+		g.P90AbsVariancePercent = percentile(abs, 0.9)
+		result = append(result, g)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if deref(a.RegionCode) != deref(b.RegionCode) {
+			return deref(a.RegionCode) < deref(b.RegionCode)
+		}
+		return a.VehicleType < b.VehicleType
+	})
+	return result, nil
+}
+
+func (r *tripRepository) completedSince(driverID string, since time.Time) []*models.Trip {
+	return r.filter(func(t *models.Trip) bool {
+		return t.DriverID == driverID && t.Status == models.TripStatusCompleted &&
+			t.EndTime != nil && !t.EndTime.Before(since)
+	})
+}
+
+func (r *tripRepository) update(id string, fn func(t *models.Trip)) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if t, ok := r.s.trips[id]; ok {
+		fn(t)
+		t.UpdatedAt = time.Now()
+	}
+}
+
+func (r *tripRepository) filter(match func(t *models.Trip) bool) []*models.Trip {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var trips []*models.Trip
+	for _, t := range r.s.trips {
+		if match(t) {
+			c := *t
+			trips = append(trips, &c)
+		}
+	}
+	return trips
+}
+
+func sortTripsByEndTimeDesc(trips []*models.Trip) {
+	sort.Slice(trips, func(i, j int) bool {
+		a, b := trips[i].EndTime, trips[j].EndTime
+		return a != nil && (b == nil || a.After(*b))
+	})
+}
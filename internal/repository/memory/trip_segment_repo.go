@@ -0,0 +1,66 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+	"github.com/jmoiron/sqlx"
+)
+
+// tripSegmentRepository ignores the transactions it is passed; each write is
+// applied as soon as it is made
+type tripSegmentRepository struct {
+	s *Store
+}
+
+func NewTripSegmentRepository(s *Store) repository.TripSegmentRepository {
+	return &tripSegmentRepository{s: s}
+}
+
+func (r *tripSegmentRepository) Create(ctx context.Context, tx *sqlx.Tx, segment *models.TripSegment) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if segment.ID == "" {
+		segment.ID = newID()
+	}
+	segment.CreatedAt = time.Now()
+
+	c := *segment
+	r.s.tripSegments[segment.ID] = &c
+	return nil
+}
+
+func (r *tripSegmentRepository) Update(ctx context.Context, tx *sqlx.Tx, segment *models.TripSegment) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if s, ok := r.s.tripSegments[segment.ID]; ok {
+		s.EndTime = segment.EndTime
+		s.EndLat = segment.EndLat
+		s.EndLng = segment.EndLng
+		s.DistanceKm = segment.DistanceKm
+		s.DurationMins = segment.DurationMins
+		s.FareShare = segment.FareShare
+		s.EndReason = segment.EndReason
+	}
+	return nil
+}
+
+func (r *tripSegmentRepository) GetByTripID(ctx context.Context, tripID string) ([]*models.TripSegment, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var segments []*models.TripSegment
+	for _, s := range r.s.tripSegments {
+		if s.TripID == tripID {
+			c := *s
+			segments = append(segments, &c)
+		}
+	}
+	sort.Slice(segments, func(i, j int) bool { return segments[i].Sequence < segments[j].Sequence })
+	return segments, nil
+}
@@ -0,0 +1,76 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+type uploadRepository struct {
+	s *Store
+}
+
+func NewUploadRepository(s *Store) repository.UploadRepository {
+	return &uploadRepository{s: s}
+}
+
+func (r *uploadRepository) Create(ctx context.Context, upload *models.Upload) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if upload.ID == "" {
+		upload.ID = newID()
+	}
+	upload.CreatedAt = time.Now()
+	upload.UpdatedAt = time.Now()
+	upload.Status = models.UploadStatusPending
+
+	c := *upload
+	r.s.uploads[upload.ID] = &c
+	return nil
+}
+
+func (r *uploadRepository) GetByID(ctx context.Context, id string) (*models.Upload, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	upload, ok := r.s.uploads[id]
+	if !ok {
+		return nil, nil
+	}
+	c := *upload
+	return &c, nil
+}
+
+func (r *uploadRepository) GetByOwner(ctx context.Context, ownerType, ownerID string) ([]*models.Upload, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var uploads []*models.Upload
+	for _, u := range r.s.uploads {
+		if u.OwnerType == ownerType && u.OwnerID == ownerID {
+			c := *u
+			uploads = append(uploads, &c)
+		}
+	}
+	sort.Slice(uploads, func(i, j int) bool { return uploads[i].CreatedAt.After(uploads[j].CreatedAt) })
+	return uploads, nil
+}
+
+func (r *uploadRepository) UpdateStatus(ctx context.Context, upload *models.Upload) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	upload.UpdatedAt = time.Now()
+	if u, ok := r.s.uploads[upload.ID]; ok {
+		u.Status = upload.Status
+		u.SizeBytes = upload.SizeBytes
+		u.RejectReason = upload.RejectReason
+		u.VerifiedAt = upload.VerifiedAt
+		u.UpdatedAt = upload.UpdatedAt
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+	"github.com/aditya/go-comet/internal/tenant"
+)
+
+type userRepository struct {
+	s *Store
+}
+
+func NewUserRepository(s *Store) repository.UserRepository {
+	return &userRepository{s: s}
+}
+
+func (r *userRepository) Create(ctx context.Context, user *models.User) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	user.TenantCode = tenant.CodeOrDefault(ctx)
+	for _, u := range r.s.users {
+		if u.Phone == user.Phone && u.TenantCode == user.TenantCode {
+			return errDuplicate("users_tenant_phone_key")
+		}
+	}
+	if user.ID == "" {
+		user.ID = newID()
+	}
+	user.CreatedAt = time.Now()
+	user.UpdatedAt = time.Now()
+	user.Rating = 5.0
+
+	u := *user
+	r.s.users[user.ID] = &u
+	return nil
+}
+
+func (r *userRepository) GetByID(ctx context.Context, id string) (*models.User, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	u, ok := r.s.users[id]
+	if !ok || !inTenant(ctx, u.TenantCode) {
+		return nil, nil
+	}
+	c := *u
+	return &c, nil
+}
+
+func (r *userRepository) GetByPhone(ctx context.Context, phone string) (*models.User, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	code := tenant.CodeOrDefault(ctx)
+	for _, u := range r.s.users {
+		if u.Phone == phone && u.TenantCode == code {
+			c := *u
+			return &c, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *userRepository) Update(ctx context.Context, user *models.User) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	user.UpdatedAt = time.Now()
+	if u, ok := r.s.users[user.ID]; ok {
+		u.Name = user.Name
+		u.Email = user.Email
+		u.UpdatedAt = user.UpdatedAt
+	}
+	return nil
+}
+
+func (r *userRepository) UpdatePhone(ctx context.Context, id, phone string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if u, ok := r.s.users[id]; ok {
+		u.Phone = phone
+		u.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+func (r *userRepository) UpdateRating(ctx context.Context, id string, window int) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if u, ok := r.s.users[id]; ok {
+		if rating, ok := r.s.averageRating(id, models.RaterDriver, window); ok {
+			u.Rating = rating
+		}
+		u.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+func (r *userRepository) UpdateSafetyPreferences(ctx context.Context, user *models.User) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	user.UpdatedAt = time.Now()
+	if u, ok := r.s.users[user.ID]; ok {
+		u.SafetyMode = user.SafetyMode
+		u.PreferredDriverGender = user.PreferredDriverGender
+		u.UpdatedAt = user.UpdatedAt
+	}
+	return nil
+}
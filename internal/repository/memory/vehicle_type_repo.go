@@ -0,0 +1,79 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+type vehicleTypeRepository struct {
+	s *Store
+}
+
+func NewVehicleTypeRepository(s *Store) repository.VehicleTypeRepository {
+	return &vehicleTypeRepository{s: s}
+}
+
+func (r *vehicleTypeRepository) Create(ctx context.Context, v *models.VehicleTypeDefinition) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.vehicleTypes[v.Code]; ok {
+		return errDuplicate("vehicle_types_pkey")
+	}
+	now := time.Now()
+	v.CreatedAt = now
+	v.UpdatedAt = now
+
+	c := *v
+	r.s.vehicleTypes[v.Code] = &c
+	return nil
+}
+
+func (r *vehicleTypeRepository) GetByCode(ctx context.Context, code string) (*models.VehicleTypeDefinition, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	v, ok := r.s.vehicleTypes[code]
+	if !ok {
+		return nil, nil
+	}
+	c := *v
+	return &c, nil
+}
+
+func (r *vehicleTypeRepository) GetAll(ctx context.Context) ([]*models.VehicleTypeDefinition, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var types []*models.VehicleTypeDefinition
+	for _, v := range r.s.vehicleTypes {
+		c := *v
+		types = append(types, &c)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		if types[i].SortOrder != types[j].SortOrder {
+			return types[i].SortOrder < types[j].SortOrder
+		}
+		return types[i].Code < types[j].Code
+	})
+	return types, nil
+}
+
+func (r *vehicleTypeRepository) Update(ctx context.Context, v *models.VehicleTypeDefinition) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	v.UpdatedAt = time.Now()
+	existing, ok := r.s.vehicleTypes[v.Code]
+	if !ok {
+		return nil
+	}
+	c := *v
+	c.CreatedAt = existing.CreatedAt
+	r.s.vehicleTypes[v.Code] = &c
+	return nil
+}
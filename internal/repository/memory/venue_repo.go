@@ -0,0 +1,82 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+type venueRepository struct {
+	s *Store
+}
+
+func NewVenueRepository(s *Store) repository.VenueRepository {
+	return &venueRepository{s: s}
+}
+
+func (r *venueRepository) Create(ctx context.Context, venue *models.Venue) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if venue.ID == "" {
+		venue.ID = newID()
+	}
+	now := time.Now()
+	venue.CreatedAt = now
+	venue.UpdatedAt = now
+	venue.Active = true
+
+	c := *venue
+	r.s.venues[venue.ID] = &c
+	return nil
+}
+
+func (r *venueRepository) GetByID(ctx context.Context, id string) (*models.Venue, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	venue, ok := r.s.venues[id]
+	if !ok {
+		return nil, nil
+	}
+	c := *venue
+	return &c, nil
+}
+
+func (r *venueRepository) List(ctx context.Context) ([]*models.Venue, error) {
+	venues := r.filter(func(v *models.Venue) bool { return true })
+	sort.Slice(venues, func(i, j int) bool { return venues[i].Name < venues[j].Name })
+	return venues, nil
+}
+
+func (r *venueRepository) GetActive(ctx context.Context) ([]*models.Venue, error) {
+	return r.filter(func(v *models.Venue) bool { return v.Active }), nil
+}
+
+func (r *venueRepository) SetActive(ctx context.Context, id string, active bool) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if venue, ok := r.s.venues[id]; ok {
+		venue.Active = active
+		venue.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+func (r *venueRepository) filter(match func(v *models.Venue) bool) []*models.Venue {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var venues []*models.Venue
+	for _, v := range r.s.venues {
+		if match(v) {
+			c := *v
+			venues = append(venues, &c)
+		}
+	}
+	return venues
+}
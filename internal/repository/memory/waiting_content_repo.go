@@ -0,0 +1,91 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+type waitingContentRepository struct {
+	s *Store
+}
+
+func NewWaitingContentRepository(s *Store) repository.WaitingContentRepository {
+	return &waitingContentRepository{s: s}
+}
+
+func (r *waitingContentRepository) Create(ctx context.Context, content *models.WaitingContent) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if content.ID == "" {
+		content.ID = newID()
+	}
+	now := time.Now()
+	content.Active = true
+	content.CreatedAt = now
+	content.UpdatedAt = now
+
+	c := *content
+	r.s.waitingContent[content.ID] = &c
+	return nil
+}
+
+func (r *waitingContentRepository) GetByID(ctx context.Context, id string) (*models.WaitingContent, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	content, ok := r.s.waitingContent[id]
+	if !ok {
+		return nil, nil
+	}
+	c := *content
+	return &c, nil
+}
+
+func (r *waitingContentRepository) List(ctx context.Context) ([]*models.WaitingContent, error) {
+	contents := r.filter(func(c *models.WaitingContent) bool { return true })
+	sort.Slice(contents, func(i, j int) bool { return contents[i].CreatedAt.After(contents[j].CreatedAt) })
+	return contents, nil
+}
+
+func (r *waitingContentRepository) ListLive(ctx context.Context, regionCode string, at time.Time) ([]*models.WaitingContent, error) {
+	contents := r.filter(func(c *models.WaitingContent) bool {
+		return c.LiveAt(at) && (c.RegionCode == nil || *c.RegionCode == regionCode)
+	})
+	sort.Slice(contents, func(i, j int) bool {
+		if contents[i].Priority != contents[j].Priority {
+			return contents[i].Priority > contents[j].Priority
+		}
+		return contents[i].CreatedAt.After(contents[j].CreatedAt)
+	})
+	return contents, nil
+}
+
+func (r *waitingContentRepository) SetActive(ctx context.Context, id string, active bool) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if content, ok := r.s.waitingContent[id]; ok {
+		content.Active = active
+		content.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+func (r *waitingContentRepository) filter(match func(c *models.WaitingContent) bool) []*models.WaitingContent {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var contents []*models.WaitingContent
+	for _, content := range r.s.waitingContent {
+		if match(content) {
+			c := *content
+			contents = append(contents, &c)
+		}
+	}
+	return contents
+}
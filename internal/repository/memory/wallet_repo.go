@@ -0,0 +1,115 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+type walletRepository struct {
+	s *Store
+}
+
+func NewWalletRepository(s *Store) repository.WalletRepository {
+	return &walletRepository{s: s}
+}
+
+func (r *walletRepository) GetByUserID(ctx context.Context, userID string) (*models.Wallet, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	wallet, ok := r.s.wallets[userID]
+	if !ok {
+		return nil, nil
+	}
+	c := *wallet
+	return &c, nil
+}
+
+func (r *walletRepository) Credit(ctx context.Context, txn *models.WalletTransaction) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if txn.ID == "" {
+		txn.ID = newID()
+	}
+	txn.CreatedAt = time.Now()
+
+	// A duplicate leaves the balance as it was
+	for _, t := range r.s.walletTxns {
+		if (txn.IdempotencyKey != nil && equalPtr(t.IdempotencyKey, txn.IdempotencyKey)) ||
+			(txn.PaymentID != nil && equalPtr(t.PaymentID, txn.PaymentID) && t.TxnType == txn.TxnType) {
+			return false, nil
+		}
+	}
+
+	wallet, ok := r.s.wallets[txn.UserID]
+	if !ok {
+		wallet = &models.Wallet{UserID: txn.UserID, Currency: "INR", CreatedAt: txn.CreatedAt}
+		r.s.wallets[txn.UserID] = wallet
+	}
+	wallet.Balance += txn.Amount
+	wallet.UpdatedAt = txn.CreatedAt
+	txn.BalanceAfter = wallet.Balance
+
+	c := *txn
+	r.s.walletTxns = append(r.s.walletTxns, &c)
+	return true, nil
+}
+
+func (r *walletRepository) Debit(ctx context.Context, txn *models.WalletTransaction) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	wallet, ok := r.s.wallets[txn.UserID]
+	if !ok || wallet.Balance+txn.Amount < 0 {
+		return false, nil
+	}
+
+	if txn.ID == "" {
+		txn.ID = newID()
+	}
+	txn.CreatedAt = time.Now()
+	wallet.Balance += txn.Amount
+	wallet.UpdatedAt = txn.CreatedAt
+	txn.BalanceAfter = wallet.Balance
+
+	c := *txn
+	r.s.walletTxns = append(r.s.walletTxns, &c)
+	return true, nil
+}
+
+func (r *walletRepository) GetTransactionByPayment(ctx context.Context, paymentID, txnType string) (*models.WalletTransaction, error) {
+	return first(r.transactions(func(t *models.WalletTransaction) bool {
+		return t.PaymentID != nil && *t.PaymentID == paymentID && t.TxnType == txnType
+	}, 0)), nil
+}
+
+func (r *walletRepository) GetTransactionByIdempotencyKey(ctx context.Context, key string) (*models.WalletTransaction, error) {
+	return first(r.transactions(func(t *models.WalletTransaction) bool {
+		return t.IdempotencyKey != nil && *t.IdempotencyKey == key
+	}, 0)), nil
+}
+
+func (r *walletRepository) GetTransactions(ctx context.Context, userID string, limit int) ([]*models.WalletTransaction, error) {
+	return r.transactions(func(t *models.WalletTransaction) bool { return t.UserID == userID }, limit), nil
+}
+
+// transactions returns matching transactions, newest first
+func (r *walletRepository) transactions(match func(t *models.WalletTransaction) bool, limit int) []*models.WalletTransaction {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var txns []*models.WalletTransaction
+	for _, t := range r.s.walletTxns {
+		if match(t) {
+			c := *t
+			txns = append(txns, &c)
+		}
+	}
+	sort.SliceStable(txns, func(i, j int) bool { return txns[i].CreatedAt.After(txns[j].CreatedAt) })
+	return page(txns, limit, 0)
+}
@@ -0,0 +1,43 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+type workerLease struct {
+	holder    string
+	expiresAt time.Time
+}
+
+type workerLeaseRepository struct {
+	s *Store
+}
+
+func NewWorkerLeaseRepository(s *Store) repository.WorkerLeaseRepository {
+	return &workerLeaseRepository{s: s}
+}
+
+func (r *workerLeaseRepository) Acquire(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	now := time.Now()
+	if lease, ok := r.s.workerLeases[name]; ok && lease.holder != holder && lease.expiresAt.After(now) {
+		return false, nil
+	}
+	r.s.workerLeases[name] = workerLease{holder: holder, expiresAt: now.Add(ttl)}
+	return true, nil
+}
+
+func (r *workerLeaseRepository) Release(ctx context.Context, name, holder string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if lease, ok := r.s.workerLeases[name]; ok && lease.holder == holder {
+		delete(r.s.workerLeases, name)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/tenant"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type PaymentHoldRepository interface {
+	Create(ctx context.Context, hold *models.PaymentHold) error
+	GetByRideID(ctx context.Context, rideID string) (*models.PaymentHold, error)
+	UpdateStatus(ctx context.Context, id, status string) error
+	MarkCaptured(ctx context.Context, id, paymentID string, amount float64) error
+	// GetReleasable returns authorized holds whose ride was cancelled or that
+	// were placed before the cutoff
+	GetReleasable(ctx context.Context, before time.Time) ([]*models.PaymentHold, error)
+}
+
+type paymentHoldRepository struct {
+	db *sqlx.DB
+}
+
+func NewPaymentHoldRepository(db *sqlx.DB) PaymentHoldRepository {
+	return &paymentHoldRepository{db: db}
+}
+
+func (r *paymentHoldRepository) Create(ctx context.Context, hold *models.PaymentHold) error {
+	if hold.ID == "" {
+		hold.ID = uuid.New().String()
+	}
+	now := time.Now()
+	hold.CreatedAt = now
+	hold.UpdatedAt = now
+	if hold.Currency == "" {
+		hold.Currency = "INR"
+	}
+	hold.TenantCode = tenant.CodeOrDefault(ctx)
+
+	query := `
+		INSERT INTO payment_holds (id, ride_id, user_id, amount, currency, status,
+			psp_authorization_id, tenant_code, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		hold.ID, hold.RideID, hold.UserID, hold.Amount, hold.Currency, hold.Status,
+		hold.PSPAuthorizationID, hold.TenantCode, hold.CreatedAt, hold.UpdatedAt)
+	return err
+}
+
+func (r *paymentHoldRepository) GetByRideID(ctx context.Context, rideID string) (*models.PaymentHold, error) {
+	var hold models.PaymentHold
+	query := `SELECT * FROM payment_holds WHERE ride_id = $1`
+	err := r.db.GetContext(ctx, &hold, query, rideID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &hold, err
+}
+
+func (r *paymentHoldRepository) UpdateStatus(ctx context.Context, id, status string) error {
+	query := `UPDATE payment_holds SET status = $1, updated_at = $2 WHERE id = $3`
+	_, err := r.db.ExecContext(ctx, query, status, time.Now(), id)
+	return err
+}
+
+func (r *paymentHoldRepository) MarkCaptured(ctx context.Context, id, paymentID string, amount float64) error {
+	query := `
+		UPDATE payment_holds
+		SET status = $1, payment_id = $2, captured_amount = $3, updated_at = $4
+		WHERE id = $5
+	`
+	_, err := r.db.ExecContext(ctx, query, models.HoldStatusCaptured, paymentID, amount, time.Now(), id)
+	return err
+}
+
+func (r *paymentHoldRepository) GetReleasable(ctx context.Context, before time.Time) ([]*models.PaymentHold, error) {
+	var holds []*models.PaymentHold
+	query := `
+		SELECT h.* FROM payment_holds h
+		JOIN rides r ON r.id = h.ride_id
+		WHERE h.status = $1 AND (r.status = $2 OR h.created_at < $3)
+	`
+	err := r.db.SelectContext(ctx, &holds, query, models.HoldStatusAuthorized, models.RideStatusCancelled, before)
+	return holds, err
+}
@@ -18,6 +18,7 @@ type PaymentRepository interface {
 	GetByIdempotencyKey(ctx context.Context, key string) (*models.Payment, error)
 	Update(ctx context.Context, payment *models.Payment) error
 	UpdateStatus(ctx context.Context, id, status string, pspTxnID *string, pspResponse json.RawMessage) error
+	GetCompletedByDriver(ctx context.Context, driverID string, from, to time.Time) ([]*models.Payment, error)
 }
 
 type paymentRepository struct {
@@ -41,13 +42,16 @@ func (r *paymentRepository) Create(ctx context.Context, payment *models.Payment)
 
 	query := `
 		INSERT INTO payments (id, trip_id, user_id, driver_id, amount, currency,
-			method, status, idempotency_key, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			method, status, idempotency_key, carbon_offset_amount, commission_percent,
+			commission_amount, driver_earnings, cancellation_fee_amount, promo_discount, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
 	`
 	_, err := r.db.ExecContext(ctx, query,
 		payment.ID, payment.TripID, payment.UserID, payment.DriverID,
 		payment.Amount, payment.Currency, payment.Method, payment.Status,
-		payment.IdempotencyKey, payment.CreatedAt, payment.UpdatedAt)
+		payment.IdempotencyKey, payment.CarbonOffsetAmount, payment.CommissionPercent,
+		payment.CommissionAmount, payment.DriverEarnings, payment.CancellationFeeAmount,
+		payment.PromoDiscount, payment.CreatedAt, payment.UpdatedAt)
 	return err
 }
 
@@ -103,3 +107,15 @@ func (r *paymentRepository) UpdateStatus(ctx context.Context, id, status string,
 	_, err := r.db.ExecContext(ctx, query, status, pspTxnID, pspResponse, time.Now(), id)
 	return err
 }
+
+// GetCompletedByDriver returns the driver's completed payments taken in [from, to), oldest first
+func (r *paymentRepository) GetCompletedByDriver(ctx context.Context, driverID string, from, to time.Time) ([]*models.Payment, error) {
+	var payments []*models.Payment
+	query := `
+		SELECT * FROM payments
+		WHERE driver_id = $1 AND status = $2 AND created_at >= $3 AND created_at < $4
+		ORDER BY created_at
+	`
+	err := r.db.SelectContext(ctx, &payments, query, driverID, models.PaymentStatusCompleted, from, to)
+	return payments, err
+}
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// PayoutRepository keeps drivers' balances: fares posted to the ledger as trips
+// are paid, and payouts debited from it
+type PayoutRepository interface {
+	// PostEarning posts a paid trip's entry to the ledger. Returns false if the
+	// payment was already posted.
+	PostEarning(ctx context.Context, entry *models.LedgerEntry) (bool, error)
+	// ReverseEarning posts the opposite of the payment's earning entry, once
+	ReverseEarning(ctx context.Context, paymentID string, at time.Time) error
+	// GetTripEarnings returns the driver's trips paid in [from, to), newest first
+	GetTripEarnings(ctx context.Context, driverID string, from, to time.Time) ([]*models.TripEarning, error)
+	GetBalance(ctx context.Context, driverID string) (float64, error)
+	// CreatePayout debits the payout from the driver's balance. Returns false,
+	// creating nothing, if the balance doesn't cover it.
+	CreatePayout(ctx context.Context, payout *models.Payout) (bool, error)
+	GetRecentPayouts(ctx context.Context, driverID string, limit int) ([]*models.Payout, error)
+}
+
+type payoutRepository struct {
+	db *sqlx.DB
+}
+
+func NewPayoutRepository(db *sqlx.DB) PayoutRepository {
+	return &payoutRepository{db: db}
+}
+
+func (r *payoutRepository) PostEarning(ctx context.Context, entry *models.LedgerEntry) (bool, error) {
+	if entry.ID == "" {
+		entry.ID = uuid.New().String()
+	}
+	entry.CreatedAt = time.Now()
+	entry.EntryType = models.LedgerEntryEarning
+
+	query := `
+		INSERT INTO driver_ledger_entries (id, driver_id, entry_type, amount, description,
+			trip_id, payment_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (payment_id) WHERE entry_type = 'earning' DO NOTHING
+	`
+	result, err := r.db.ExecContext(ctx, query,
+		entry.ID, entry.DriverID, entry.EntryType, entry.Amount, entry.Description,
+		entry.TripID, entry.PaymentID, entry.CreatedAt)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+func (r *payoutRepository) ReverseEarning(ctx context.Context, paymentID string, at time.Time) error {
+	query := `
+		INSERT INTO driver_ledger_entries (id, driver_id, entry_type, amount, description,
+			trip_id, payment_id, created_at)
+		SELECT $1, driver_id, $2, -amount, 'Refunded trip', trip_id, payment_id, $3
+		FROM driver_ledger_entries
+		WHERE payment_id = $4 AND entry_type = $5
+		ON CONFLICT (payment_id) WHERE entry_type = 'earning_reversal' DO NOTHING
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		uuid.New().String(), models.LedgerEntryEarningReversal, at, paymentID, models.LedgerEntryEarning)
+	return err
+}
+
+func (r *payoutRepository) GetTripEarnings(ctx context.Context, driverID string, from, to time.Time) ([]*models.TripEarning, error) {
+	var earnings []*models.TripEarning
+	query := `
+		SELECT e.ride_id, e.trip_id, e.payment_id, p.method, e.currency,
+			e.rider_paid AS fare, e.commission, e.driver_earnings AS earnings,
+			e.incentive_top_up, e.refunded_at, e.created_at AS paid_at
+		FROM ride_economics e
+		JOIN payments p ON p.id = e.payment_id
+		WHERE e.driver_id = $1 AND e.created_at >= $2 AND e.created_at < $3
+		ORDER BY e.created_at DESC
+	`
+	err := r.db.SelectContext(ctx, &earnings, query, driverID, from, to)
+	return earnings, err
+}
+
+func (r *payoutRepository) GetBalance(ctx context.Context, driverID string) (float64, error) {
+	var balance float64
+	query := `SELECT COALESCE(SUM(amount), 0) FROM driver_ledger_entries WHERE driver_id = $1`
+	err := r.db.GetContext(ctx, &balance, query, driverID)
+	return balance, err
+}
+
+func (r *payoutRepository) CreatePayout(ctx context.Context, payout *models.Payout) (bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	// Serialises payouts per driver so two requests can't both spend the balance
+	if _, err := tx.ExecContext(ctx, `SELECT id FROM drivers WHERE id = $1 FOR UPDATE`, payout.DriverID); err != nil {
+		return false, err
+	}
+	var balance float64
+	query := `SELECT COALESCE(SUM(amount), 0) FROM driver_ledger_entries WHERE driver_id = $1`
+	if err := tx.GetContext(ctx, &balance, query, payout.DriverID); err != nil {
+		return false, err
+	}
+	if payout.Amount > balance {
+		return false, nil
+	}
+
+	if payout.ID == "" {
+		payout.ID = uuid.New().String()
+	}
+	now := time.Now()
+	payout.Status = models.PayoutStatusRequested
+	payout.CreatedAt = now
+	payout.UpdatedAt = now
+
+	query = `
+		INSERT INTO driver_payouts (id, driver_id, amount, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	if _, err := tx.ExecContext(ctx, query,
+		payout.ID, payout.DriverID, payout.Amount, payout.Status, payout.CreatedAt, payout.UpdatedAt); err != nil {
+		return false, err
+	}
+
+	query = `
+		INSERT INTO driver_ledger_entries (id, driver_id, entry_type, amount, description, payout_id, created_at)
+		VALUES ($1, $2, $3, $4, 'Payout', $5, $6)
+	`
+	if _, err := tx.ExecContext(ctx, query,
+		uuid.New().String(), payout.DriverID, models.LedgerEntryPayout, -payout.Amount, payout.ID, now); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+func (r *payoutRepository) GetRecentPayouts(ctx context.Context, driverID string, limit int) ([]*models.Payout, error) {
+	var payouts []*models.Payout
+	query := `SELECT * FROM driver_payouts WHERE driver_id = $1 ORDER BY created_at DESC LIMIT $2`
+	err := r.db.SelectContext(ctx, &payouts, query, driverID, limit)
+	return payouts, err
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type PickupSpotRepository interface {
+	Create(ctx context.Context, spot *models.PickupSpot) error
+	GetByID(ctx context.Context, id string) (*models.PickupSpot, error)
+	// GetInBounds returns active spots inside a lat/lng bounding box
+	GetInBounds(ctx context.Context, minLat, minLng, maxLat, maxLng float64) ([]*models.PickupSpot, error)
+	SetActive(ctx context.Context, id string, active bool) error
+	// GetByVenueID returns a venue's active named pickup points
+	GetByVenueID(ctx context.Context, venueID string) ([]*models.PickupSpot, error)
+}
+
+type pickupSpotRepository struct {
+	db *sqlx.DB
+}
+
+func NewPickupSpotRepository(db *sqlx.DB) PickupSpotRepository {
+	return &pickupSpotRepository{db: db}
+}
+
+func (r *pickupSpotRepository) Create(ctx context.Context, spot *models.PickupSpot) error {
+	if spot.ID == "" {
+		spot.ID = uuid.New().String()
+	}
+	now := time.Now()
+	spot.CreatedAt = now
+	spot.UpdatedAt = now
+	spot.Active = true
+
+	query := `
+		INSERT INTO pickup_spots (id, name, kind, lat, lng, venue_id, active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		spot.ID, spot.Name, spot.Kind, spot.Lat, spot.Lng, spot.VenueID, spot.Active, spot.CreatedAt, spot.UpdatedAt)
+	return err
+}
+
+func (r *pickupSpotRepository) GetByID(ctx context.Context, id string) (*models.PickupSpot, error) {
+	var spot models.PickupSpot
+	query := `SELECT * FROM pickup_spots WHERE id = $1`
+	err := r.db.GetContext(ctx, &spot, query, id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &spot, err
+}
+
+func (r *pickupSpotRepository) GetInBounds(ctx context.Context, minLat, minLng, maxLat, maxLng float64) ([]*models.PickupSpot, error) {
+	var spots []*models.PickupSpot
+	query := `
+		SELECT * FROM pickup_spots
+		WHERE active = TRUE AND lat BETWEEN $1 AND $2 AND lng BETWEEN $3 AND $4
+	`
+	err := r.db.SelectContext(ctx, &spots, query, minLat, maxLat, minLng, maxLng)
+	return spots, err
+}
+
+func (r *pickupSpotRepository) SetActive(ctx context.Context, id string, active bool) error {
+	query := `UPDATE pickup_spots SET active = $1, updated_at = $2 WHERE id = $3`
+	_, err := r.db.ExecContext(ctx, query, active, time.Now(), id)
+	return err
+}
+
+func (r *pickupSpotRepository) GetByVenueID(ctx context.Context, venueID string) ([]*models.PickupSpot, error) {
+	var spots []*models.PickupSpot
+	query := `SELECT * FROM pickup_spots WHERE venue_id = $1 AND active = TRUE ORDER BY name`
+	err := r.db.SelectContext(ctx, &spots, query, venueID)
+	return spots, err
+}
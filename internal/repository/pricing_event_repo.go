@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type PricingEventRepository interface {
+	Create(ctx context.Context, event *models.PricingEvent) error
+	GetByID(ctx context.Context, id string) (*models.PricingEvent, error)
+	List(ctx context.Context) ([]*models.PricingEvent, error)
+	// GetActiveAt returns active events whose window covers the given time
+	GetActiveAt(ctx context.Context, at time.Time) ([]*models.PricingEvent, error)
+	SetActive(ctx context.Context, id string, active bool) error
+}
+
+type pricingEventRepository struct {
+	db *sqlx.DB
+}
+
+func NewPricingEventRepository(db *sqlx.DB) PricingEventRepository {
+	return &pricingEventRepository{db: db}
+}
+
+func (r *pricingEventRepository) Create(ctx context.Context, event *models.PricingEvent) error {
+	if event.ID == "" {
+		event.ID = uuid.New().String()
+	}
+	now := time.Now()
+	event.CreatedAt = now
+	event.UpdatedAt = now
+	event.Active = true
+
+	query := `
+		INSERT INTO pricing_events (id, name, kind, multiplier, surcharge, region_code, vehicle_type,
+			center_lat, center_lng, radius_km, starts_at, ends_at, active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		event.ID, event.Name, event.Kind, event.Multiplier, event.Surcharge, event.RegionCode, event.VehicleType,
+		event.CenterLat, event.CenterLng, event.RadiusKm, event.StartsAt, event.EndsAt, event.Active,
+		event.CreatedAt, event.UpdatedAt)
+	return err
+}
+
+func (r *pricingEventRepository) GetByID(ctx context.Context, id string) (*models.PricingEvent, error) {
+	var event models.PricingEvent
+	query := `SELECT * FROM pricing_events WHERE id = $1`
+	err := r.db.GetContext(ctx, &event, query, id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &event, err
+}
+
+func (r *pricingEventRepository) List(ctx context.Context) ([]*models.PricingEvent, error) {
+	var events []*models.PricingEvent
+	query := `SELECT * FROM pricing_events ORDER BY starts_at DESC`
+	err := r.db.SelectContext(ctx, &events, query)
+	return events, err
+}
+
+func (r *pricingEventRepository) GetActiveAt(ctx context.Context, at time.Time) ([]*models.PricingEvent, error) {
+	var events []*models.PricingEvent
+	query := `SELECT * FROM pricing_events WHERE active = TRUE AND starts_at <= $1 AND ends_at > $1`
+	err := r.db.SelectContext(ctx, &events, query, at)
+	return events, err
+}
+
+func (r *pricingEventRepository) SetActive(ctx context.Context, id string, active bool) error {
+	query := `UPDATE pricing_events SET active = $1, updated_at = $2 WHERE id = $3`
+	_, err := r.db.ExecContext(ctx, query, active, time.Now(), id)
+	return err
+}
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type PromoRepository interface {
+	// Create stores the promo. Returns false if the code is taken.
+	Create(ctx context.Context, promo *models.PromoCode) (bool, error)
+	GetByCode(ctx context.Context, code string) (*models.PromoCode, error)
+	List(ctx context.Context) ([]*models.PromoCode, error)
+	Update(ctx context.Context, promo *models.PromoCode) error
+	// Redeem records the ride's discount and counts a use. Returns false if the
+	// promo has no uses left or the ride already redeemed a promo.
+	Redeem(ctx context.Context, redemption *models.PromoRedemption) (bool, error)
+	GetRedemptionByRideID(ctx context.Context, rideID string) (*models.PromoRedemption, error)
+}
+
+type promoRepository struct {
+	db *sqlx.DB
+}
+
+func NewPromoRepository(db *sqlx.DB) PromoRepository {
+	return &promoRepository{db: db}
+}
+
+func (r *promoRepository) Create(ctx context.Context, promo *models.PromoCode) (bool, error) {
+	if promo.ID == "" {
+		promo.ID = uuid.New().String()
+	}
+	now := time.Now()
+	promo.CreatedAt = now
+	promo.UpdatedAt = now
+	promo.Active = true
+
+	query := `
+		INSERT INTO promo_codes (id, code, discount_type, value, max_discount, min_fare, max_uses,
+			expires_at, active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (code) DO NOTHING
+	`
+	result, err := r.db.ExecContext(ctx, query,
+		promo.ID, promo.Code, promo.DiscountType, promo.Value, promo.MaxDiscount, promo.MinFare, promo.MaxUses,
+		promo.ExpiresAt, promo.Active, promo.CreatedAt, promo.UpdatedAt)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+func (r *promoRepository) GetByCode(ctx context.Context, code string) (*models.PromoCode, error) {
+	var promo models.PromoCode
+	query := `SELECT * FROM promo_codes WHERE code = $1`
+	err := r.db.GetContext(ctx, &promo, query, code)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &promo, err
+}
+
+func (r *promoRepository) List(ctx context.Context) ([]*models.PromoCode, error) {
+	var promos []*models.PromoCode
+	query := `SELECT * FROM promo_codes ORDER BY created_at DESC`
+	err := r.db.SelectContext(ctx, &promos, query)
+	return promos, err
+}
+
+func (r *promoRepository) Update(ctx context.Context, promo *models.PromoCode) error {
+	promo.UpdatedAt = time.Now()
+	query := `
+		UPDATE promo_codes
+		SET max_discount = $1, min_fare = $2, max_uses = $3, expires_at = $4, active = $5, updated_at = $6
+		WHERE id = $7
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		promo.MaxDiscount, promo.MinFare, promo.MaxUses, promo.ExpiresAt, promo.Active, promo.UpdatedAt, promo.ID)
+	return err
+}
+
+func (r *promoRepository) Redeem(ctx context.Context, redemption *models.PromoRedemption) (bool, error) {
+	if redemption.ID == "" {
+		redemption.ID = uuid.New().String()
+	}
+	redemption.CreatedAt = time.Now()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE promo_codes SET uses = uses + 1, updated_at = $1
+		WHERE id = $2 AND (max_uses IS NULL OR uses < max_uses)
+	`
+	result, err := tx.ExecContext(ctx, query, redemption.CreatedAt, redemption.PromoID)
+	if err != nil {
+		return false, err
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+
+	query = `
+		INSERT INTO promo_redemptions (id, promo_id, ride_id, user_id, discount, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (ride_id) DO NOTHING
+	`
+	result, err = tx.ExecContext(ctx, query,
+		redemption.ID, redemption.PromoID, redemption.RideID, redemption.UserID, redemption.Discount,
+		redemption.CreatedAt)
+	if err != nil {
+		return false, err
+	}
+	// A ride redeeming twice leaves the use count as it was
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+func (r *promoRepository) GetRedemptionByRideID(ctx context.Context, rideID string) (*models.PromoRedemption, error) {
+	var redemption models.PromoRedemption
+	query := `SELECT * FROM promo_redemptions WHERE ride_id = $1`
+	err := r.db.GetContext(ctx, &redemption, query, rideID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &redemption, err
+}
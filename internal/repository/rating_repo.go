@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type RatingRepository interface {
+	// Create stores the rating. Returns false if that side already rated the trip.
+	Create(ctx context.Context, rating *models.TripRating) (bool, error)
+	GetByID(ctx context.Context, id string) (*models.TripRating, error)
+	GetByTripID(ctx context.Context, tripID string) ([]*models.TripRating, error)
+	// GetByFeedbackStatus returns ratings whose feedback is in one of the statuses,
+	// oldest first
+	GetByFeedbackStatus(ctx context.Context, statuses []string, limit int) ([]*models.TripRating, error)
+	// UpdateFeedbackStatus writes the rating's feedback status, appeal reason and
+	// moderation time if its current status is one of from. Returns false otherwise.
+	UpdateFeedbackStatus(ctx context.Context, rating *models.TripRating, from []string) (bool, error)
+	// GetLatestForDriver returns up to limit of the latest ratings riders gave the
+	// driver, skipping trips that were cancelled rather than completed
+	GetLatestForDriver(ctx context.Context, driverID string, limit int) ([]*models.TripRating, error)
+	// GetUnratedTrips returns the rider's trips completed since the given time that
+	// they haven't rated, newest first
+	GetUnratedTrips(ctx context.Context, userID string, since time.Time, limit int) ([]*models.TripSummary, error)
+}
+
+type ratingRepository struct {
+	db *sqlx.DB
+}
+
+func NewRatingRepository(db *sqlx.DB) RatingRepository {
+	return &ratingRepository{db: db}
+}
+
+func (r *ratingRepository) Create(ctx context.Context, rating *models.TripRating) (bool, error) {
+	if rating.ID == "" {
+		rating.ID = uuid.New().String()
+	}
+	rating.CreatedAt = time.Now()
+
+	query := `
+		INSERT INTO trip_ratings (id, trip_id, rater_type, rater_id, ratee_id, rating, feedback,
+			feedback_status, flag_reason, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (trip_id, rater_type) DO NOTHING
+	`
+	result, err := r.db.ExecContext(ctx, query,
+		rating.ID, rating.TripID, rating.RaterType, rating.RaterID, rating.RateeID, rating.Rating,
+		rating.Feedback, rating.FeedbackStatus, rating.FlagReason, rating.CreatedAt)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+func (r *ratingRepository) GetByID(ctx context.Context, id string) (*models.TripRating, error) {
+	var rating models.TripRating
+	query := `SELECT * FROM trip_ratings WHERE id = $1`
+	err := r.db.GetContext(ctx, &rating, query, id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rating, nil
+}
+
+func (r *ratingRepository) GetByFeedbackStatus(ctx context.Context, statuses []string, limit int) ([]*models.TripRating, error) {
+	var ratings []*models.TripRating
+	query := `
+		SELECT * FROM trip_ratings
+		WHERE feedback_status = ANY($1)
+		ORDER BY created_at
+		LIMIT $2
+	`
+	err := r.db.SelectContext(ctx, &ratings, query, pq.Array(statuses), limit)
+	return ratings, err
+}
+
+func (r *ratingRepository) UpdateFeedbackStatus(ctx context.Context, rating *models.TripRating, from []string) (bool, error) {
+	query := `
+		UPDATE trip_ratings
+		SET feedback_status = $1, appeal_reason = $2, moderated_at = $3
+		WHERE id = $4 AND feedback_status = ANY($5)
+	`
+	result, err := r.db.ExecContext(ctx, query,
+		rating.FeedbackStatus, rating.AppealReason, rating.ModeratedAt, rating.ID, pq.Array(from))
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+func (r *ratingRepository) GetByTripID(ctx context.Context, tripID string) ([]*models.TripRating, error) {
+	var ratings []*models.TripRating
+	query := `SELECT * FROM trip_ratings WHERE trip_id = $1 ORDER BY created_at`
+	err := r.db.SelectContext(ctx, &ratings, query, tripID)
+	return ratings, err
+}
+
+func (r *ratingRepository) GetUnratedTrips(ctx context.Context, userID string, since time.Time, limit int) ([]*models.TripSummary, error) {
+	var summaries []*models.TripSummary
+	query := `
+		SELECT t.id AS trip_id, t.ride_id, t.driver_id, d.name AS driver_name, t.total_fare,
+			t.actual_duration_mins AS duration_mins, t.end_time AS ended_at
+		FROM trips t
+		JOIN drivers d ON d.id = t.driver_id
+		WHERE t.user_id = $1 AND t.status = $2 AND t.end_time >= $3
+			AND NOT EXISTS (
+				SELECT 1 FROM trip_ratings r WHERE r.trip_id = t.id AND r.rater_type = $4
+			)
+		ORDER BY t.end_time DESC
+		LIMIT $5
+	`
+	err := r.db.SelectContext(ctx, &summaries, query, userID, models.TripStatusCompleted, since, models.RaterUser, limit)
+	return summaries, err
+}
+
+func (r *ratingRepository) GetLatestForDriver(ctx context.Context, driverID string, limit int) ([]*models.TripRating, error) {
+	var ratings []*models.TripRating
+	query := `
+		SELECT tr.* FROM trip_ratings tr
+		JOIN trips t ON t.id = tr.trip_id
+		JOIN rides r ON r.id = t.ride_id
+		WHERE tr.ratee_id = $1 AND tr.rater_type = $2
+			AND t.status = $3 AND r.status <> $4
+		ORDER BY tr.created_at DESC
+		LIMIT $5
+	`
+	err := r.db.SelectContext(ctx, &ratings, query,
+		driverID, models.RaterUser, models.TripStatusCompleted, models.RideStatusCancelled, limit)
+	return ratings, err
+}
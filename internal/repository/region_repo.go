@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+type RegionRepository interface {
+	GetByCode(ctx context.Context, code string) (*models.Region, error)
+	GetActive(ctx context.Context) ([]*models.Region, error)
+	GetAll(ctx context.Context) ([]*models.Region, error)
+	UpdateSettings(ctx context.Context, code string, settings models.RegionSettings) error
+	UpdateServiceArea(ctx context.Context, code string, area models.ServiceArea) error
+	UpdateTenant(ctx context.Context, code, tenantCode string) error
+}
+
+type regionRepository struct {
+	db *sqlx.DB
+}
+
+func NewRegionRepository(db *sqlx.DB) RegionRepository {
+	return &regionRepository{db: db}
+}
+
+func (r *regionRepository) GetByCode(ctx context.Context, code string) (*models.Region, error) {
+	var region models.Region
+	query := `SELECT * FROM regions WHERE code = $1`
+	err := r.db.GetContext(ctx, &region, query, code)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &region, err
+}
+
+func (r *regionRepository) GetActive(ctx context.Context) ([]*models.Region, error) {
+	var regions []*models.Region
+	query := `SELECT * FROM regions WHERE active = TRUE ORDER BY code`
+	err := r.db.SelectContext(ctx, &regions, query)
+	return regions, err
+}
+
+func (r *regionRepository) GetAll(ctx context.Context) ([]*models.Region, error) {
+	var regions []*models.Region
+	query := `SELECT * FROM regions ORDER BY code`
+	err := r.db.SelectContext(ctx, &regions, query)
+	return regions, err
+}
+
+func (r *regionRepository) UpdateSettings(ctx context.Context, code string, settings models.RegionSettings) error {
+	query := `UPDATE regions SET settings = $1, updated_at = $2 WHERE code = $3`
+	_, err := r.db.ExecContext(ctx, query, settings, time.Now(), code)
+	return err
+}
+
+func (r *regionRepository) UpdateServiceArea(ctx context.Context, code string, area models.ServiceArea) error {
+	query := `UPDATE regions SET service_area = $1, updated_at = $2 WHERE code = $3`
+	_, err := r.db.ExecContext(ctx, query, area, time.Now(), code)
+	return err
+}
+
+func (r *regionRepository) UpdateTenant(ctx context.Context, code, tenantCode string) error {
+	query := `UPDATE regions SET tenant_code = $1, updated_at = $2 WHERE code = $3`
+	_, err := r.db.ExecContext(ctx, query, tenantCode, time.Now(), code)
+	return err
+}
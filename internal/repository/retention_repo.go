@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// RetentionRepository finds and anonymizes finished trips past their retention
+// period. An empty region code selects trips whose ride has no region.
+type RetentionRepository interface {
+	// CountExpiredTrips counts the region's finished trips that ended before the
+	// cutoff and aren't anonymized yet
+	CountExpiredTrips(ctx context.Context, regionCode string, before time.Time) (int, error)
+	// AnonymizeTrips anonymizes up to limit of those trips, oldest first, and
+	// returns how many it changed. Coordinates are rounded, the route polyline is
+	// dropped, and addresses, notes and delivery recipient details are cleared;
+	// fares, distances and durations are kept. The ride's audit log snapshots
+	// are redacted the same way.
+	AnonymizeTrips(ctx context.Context, regionCode string, before time.Time, limit int) (int, error)
+}
+
+type retentionRepository struct {
+	db *sqlx.DB
+}
+
+func NewRetentionRepository(db *sqlx.DB) RetentionRepository {
+	return &retentionRepository{db: db}
+}
+
+// expiredTripsQuery selects finished, unanonymized trips of a region ($1) that
+// ended before $2
+const expiredTripsQuery = `
+	SELECT t.id, t.ride_id
+	FROM trips t
+	JOIN rides r ON r.id = t.ride_id
+	WHERE t.anonymized_at IS NULL
+		AND t.status IN ('completed', 'cancelled')
+		AND COALESCE(t.end_time, t.updated_at) < $2
+		AND r.region_code IS NOT DISTINCT FROM $1::varchar
+`
+
+func (r *retentionRepository) CountExpiredTrips(ctx context.Context, regionCode string, before time.Time) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM (` + expiredTripsQuery + `) expired`
+	err := r.db.GetContext(ctx, &count, query, nullableRegion(regionCode), before)
+	return count, err
+}
+
+func (r *retentionRepository) AnonymizeTrips(ctx context.Context, regionCode string, before time.Time, limit int) (int, error) {
+	query := `
+		WITH expired AS (` + expiredTripsQuery + `
+			ORDER BY COALESCE(t.end_time, t.updated_at)
+			LIMIT $3
+			FOR UPDATE OF t SKIP LOCKED
+		), anonymized_rides AS (
+			UPDATE rides
+			SET pickup_lat = ROUND(pickup_lat, $4), pickup_lng = ROUND(pickup_lng, $4),
+				dropoff_lat = ROUND(dropoff_lat, $4), dropoff_lng = ROUND(dropoff_lng, $4),
+				pickup_address = NULL, dropoff_address = NULL, rider_note = NULL,
+				pickup_spot_name = NULL, card_fingerprint = NULL
+			WHERE id IN (SELECT ride_id FROM expired)
+		), anonymized_segments AS (
+			UPDATE trip_segments
+			SET start_lat = ROUND(start_lat, $4), start_lng = ROUND(start_lng, $4),
+				end_lat = ROUND(end_lat, $4), end_lng = ROUND(end_lng, $4)
+			WHERE trip_id IN (SELECT id FROM expired)
+		), anonymized_deliveries AS (
+			UPDATE deliveries
+			SET recipient_name = '', recipient_phone = '', package_description = NULL, received_by = NULL
+			WHERE ride_id IN (SELECT ride_id FROM expired)
+		), anonymized_audit AS (
+			UPDATE audit_log a
+			SET snapshot = (
+				SELECT jsonb_object_agg(key, CASE
+					WHEN key = ANY($5) AND jsonb_typeof(value) = 'number'
+						THEN to_jsonb(ROUND((value #>> '{}')::numeric, $4))
+					WHEN key = ANY($6) THEN 'null'::jsonb
+					ELSE value END)
+				FROM jsonb_each(a.snapshot)
+			)
+			WHERE a.ride_id IN (SELECT ride_id FROM expired)
+		)
+		UPDATE trips
+		SET route_polyline = NULL, anonymized_at = NOW()
+		WHERE id IN (SELECT id FROM expired)
+	`
+	result, err := r.db.ExecContext(ctx, query, nullableRegion(regionCode), before, limit, models.AnonymizedCoordDecimals,
+		pq.Array(models.AnonymizedSnapshotCoords), pq.Array(models.AnonymizedSnapshotFields))
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+func nullableRegion(regionCode string) *string {
+	if regionCode == "" {
+		return nil
+	}
+	return &regionCode
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+type RideEconomicsRepository interface {
+	// Record writes the ride's economics, filling in its region, vehicle type and
+	// the incentive top-ups already credited for the trip, and returns the row
+	Record(ctx context.Context, e *models.RideEconomics) (*models.RideEconomics, error)
+	GetByRideID(ctx context.Context, rideID string) (*models.RideEconomics, error)
+	MarkRefunded(ctx context.Context, paymentID string, at time.Time) error
+	GetTakeRates(ctx context.Context, from, to time.Time) ([]*models.RegionTakeRate, error)
+}
+
+type rideEconomicsRepository struct {
+	db *sqlx.DB
+}
+
+func NewRideEconomicsRepository(db *sqlx.DB) RideEconomicsRepository {
+	return &rideEconomicsRepository{db: db}
+}
+
+func (r *rideEconomicsRepository) Record(ctx context.Context, e *models.RideEconomics) (*models.RideEconomics, error) {
+	var recorded models.RideEconomics
+	query := `
+		WITH incentives AS (
+			SELECT COALESCE(SUM(amount), 0) AS top_up
+			FROM driver_ledger_entries
+			WHERE trip_id = $2 AND entry_type = $11
+		)
+		INSERT INTO ride_economics (ride_id, trip_id, payment_id, driver_id, region_code, vehicle_type,
+			currency, gross_fare, discounts, rider_paid, commission, driver_earnings,
+			incentive_top_up, net_revenue, created_at, updated_at)
+		SELECT r.id, $2, $3, $4, r.region_code, r.vehicle_type,
+			$5, $6, $7, $8, $9, $10,
+			i.top_up, $9 - i.top_up, NOW(), NOW()
+		FROM rides r, incentives i
+		WHERE r.id = $1
+		ON CONFLICT (ride_id) DO UPDATE SET
+			payment_id = EXCLUDED.payment_id,
+			currency = EXCLUDED.currency,
+			gross_fare = EXCLUDED.gross_fare,
+			discounts = EXCLUDED.discounts,
+			rider_paid = EXCLUDED.rider_paid,
+			commission = EXCLUDED.commission,
+			driver_earnings = EXCLUDED.driver_earnings,
+			incentive_top_up = EXCLUDED.incentive_top_up,
+			net_revenue = EXCLUDED.net_revenue,
+			refunded_at = NULL,
+			updated_at = NOW()
+		RETURNING *
+	`
+	err := r.db.GetContext(ctx, &recorded, query,
+		e.RideID, e.TripID, e.PaymentID, e.DriverID, e.Currency, e.GrossFare, e.Discounts,
+		e.RiderPaid, e.Commission, e.DriverEarnings, models.LedgerEntryIncentive)
+	if err != nil {
+		return nil, err
+	}
+	return &recorded, nil
+}
+
+func (r *rideEconomicsRepository) GetByRideID(ctx context.Context, rideID string) (*models.RideEconomics, error) {
+	var e models.RideEconomics
+	err := r.db.GetContext(ctx, &e, "SELECT * FROM ride_economics WHERE ride_id = $1", rideID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (r *rideEconomicsRepository) MarkRefunded(ctx context.Context, paymentID string, at time.Time) error {
+	query := `UPDATE ride_economics SET refunded_at = $1, updated_at = $1 WHERE payment_id = $2`
+	_, err := r.db.ExecContext(ctx, query, at, paymentID)
+	return err
+}
+
+func (r *rideEconomicsRepository) GetTakeRates(ctx context.Context, from, to time.Time) ([]*models.RegionTakeRate, error) {
+	var rates []*models.RegionTakeRate
+	query := `
+		SELECT region_code,
+			COUNT(*) AS rides,
+			SUM(gross_fare) AS gross_fare,
+			SUM(discounts) AS discounts,
+			SUM(rider_paid) AS rider_paid,
+			SUM(commission) AS commission,
+			SUM(driver_earnings) AS driver_earnings,
+			SUM(incentive_top_up) AS incentive_top_up,
+			SUM(net_revenue) AS net_revenue
+		FROM ride_economics
+		WHERE created_at >= $1 AND created_at < $2 AND refunded_at IS NULL
+		GROUP BY region_code
+		ORDER BY region_code NULLS LAST
+	`
+	err := r.db.SelectContext(ctx, &rates, query, from, to)
+	return rates, err
+}
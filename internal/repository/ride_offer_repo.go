@@ -8,6 +8,7 @@ import (
 	"github.com/aditya/go-comet/internal/models"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 type RideOfferRepository interface {
@@ -18,7 +19,21 @@ type RideOfferRepository interface {
 	GetPendingByDriverID(ctx context.Context, driverID string) ([]*models.RideOffer, error)
 	UpdateStatus(ctx context.Context, id, status string) error
 	ExpireOldOffers(ctx context.Context, rideID string) error
+	// GetOfferedDriverIDs returns every driver the ride has been offered to
+	GetOfferedDriverIDs(ctx context.Context, rideID string) (map[string]bool, error)
 	GetByIDForUpdate(ctx context.Context, tx *sqlx.Tx, id string) (*models.RideOffer, error)
+	SetStatus(ctx context.Context, tx *sqlx.Tx, id, status string, at time.Time) error
+	// ExpireOpen expires the ride's pending and countered offers within tx,
+	// closing them out once the ride is taken
+	ExpireOpen(ctx context.Context, tx *sqlx.Tx, rideID string, at time.Time) error
+	Counter(ctx context.Context, id string, fare float64, expiresAt time.Time) (bool, error)
+	GetCounteredByRideID(ctx context.Context, rideID string) ([]*models.RideOffer, error)
+	GetQueuedByDriverID(ctx context.Context, driverID string) (*models.RideOffer, error)
+	// MarkViewed records when the driver first saw each offer
+	MarkViewed(ctx context.Context, ids []string, at time.Time) error
+	// GetSLAStats measures offers accepted since the given time against each region's
+	// targets; rides outside targets' regions are measured against defaults
+	GetSLAStats(ctx context.Context, since time.Time, targets map[string]models.SLOTargets, defaults models.SLOTargets) ([]*models.SLAStats, error)
 }
 
 type rideOfferRepository struct {
@@ -37,11 +52,13 @@ func (r *rideOfferRepository) Create(ctx context.Context, offer *models.RideOffe
 	offer.Status = models.OfferStatusPending
 
 	query := `
-		INSERT INTO ride_offers (id, ride_id, driver_id, status, offered_at, expires_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO ride_offers (id, ride_id, driver_id, status, offered_at, expires_at, chained,
+			timeout_seconds, timeout_reasons, dispatch_round_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 	`
 	_, err := r.db.ExecContext(ctx, query,
-		offer.ID, offer.RideID, offer.DriverID, offer.Status, offer.OfferedAt, offer.ExpiresAt)
+		offer.ID, offer.RideID, offer.DriverID, offer.Status, offer.OfferedAt, offer.ExpiresAt, offer.Chained,
+		offer.TimeoutSeconds, offer.TimeoutReasons, offer.DispatchRoundID)
 	return err
 }
 
@@ -98,12 +115,27 @@ func (r *rideOfferRepository) ExpireOldOffers(ctx context.Context, rideID string
 	query := `
 		UPDATE ride_offers
 		SET status = $1, responded_at = NOW()
-		WHERE ride_id = $2 AND status = $3
+		WHERE ride_id = $2 AND status IN ($3, $4, $5)
 	`
-	_, err := r.db.ExecContext(ctx, query, models.OfferStatusExpired, rideID, models.OfferStatusPending)
+	_, err := r.db.ExecContext(ctx, query, models.OfferStatusExpired, rideID,
+		models.OfferStatusPending, models.OfferStatusCountered, models.OfferStatusQueued)
 	return err
 }
 
+func (r *rideOfferRepository) GetOfferedDriverIDs(ctx context.Context, rideID string) (map[string]bool, error) {
+	var ids []string
+	query := `SELECT driver_id FROM ride_offers WHERE ride_id = $1`
+	if err := r.db.SelectContext(ctx, &ids, query, rideID); err != nil {
+		return nil, err
+	}
+
+	offered := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		offered[id] = true
+	}
+	return offered, nil
+}
+
 func (r *rideOfferRepository) GetByIDForUpdate(ctx context.Context, tx *sqlx.Tx, id string) (*models.RideOffer, error) {
 	var offer models.RideOffer
 	query := `SELECT * FROM ride_offers WHERE id = $1 FOR UPDATE`
@@ -113,3 +145,114 @@ func (r *rideOfferRepository) GetByIDForUpdate(ctx context.Context, tx *sqlx.Tx,
 	}
 	return &offer, err
 }
+
+func (r *rideOfferRepository) SetStatus(ctx context.Context, tx *sqlx.Tx, id, status string, at time.Time) error {
+	query := `UPDATE ride_offers SET status = $1, responded_at = $2 WHERE id = $3`
+	_, err := tx.ExecContext(ctx, query, status, at, id)
+	return err
+}
+
+func (r *rideOfferRepository) ExpireOpen(ctx context.Context, tx *sqlx.Tx, rideID string, at time.Time) error {
+	query := `UPDATE ride_offers SET status = $1, responded_at = $2 WHERE ride_id = $3 AND status IN ($4, $5)`
+	_, err := tx.ExecContext(ctx, query, models.OfferStatusExpired, at, rideID,
+		models.OfferStatusPending, models.OfferStatusCountered)
+	return err
+}
+
+// Counter records a driver's counter-offer on a pending bid ride offer and
+// extends its expiry so the rider has time to consider it
+func (r *rideOfferRepository) Counter(ctx context.Context, id string, fare float64, expiresAt time.Time) (bool, error) {
+	query := `
+		UPDATE ride_offers
+		SET status = $1, offered_fare = $2, countered_at = NOW(), responded_at = NOW(), expires_at = $3
+		WHERE id = $4 AND status = $5
+	`
+	result, err := r.db.ExecContext(ctx, query, models.OfferStatusCountered, fare, expiresAt, id, models.OfferStatusPending)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+func (r *rideOfferRepository) GetCounteredByRideID(ctx context.Context, rideID string) ([]*models.RideOffer, error) {
+	var offers []*models.RideOffer
+	query := `
+		SELECT * FROM ride_offers
+		WHERE ride_id = $1 AND status = $2 AND expires_at > NOW()
+		ORDER BY offered_fare ASC, countered_at ASC
+	`
+	err := r.db.SelectContext(ctx, &offers, query, rideID, models.OfferStatusCountered)
+	return offers, err
+}
+
+// GetQueuedByDriverID returns the next ride a driver has queued behind their current trip, if any
+func (r *rideOfferRepository) GetQueuedByDriverID(ctx context.Context, driverID string) (*models.RideOffer, error) {
+	var offer models.RideOffer
+	query := `SELECT * FROM ride_offers WHERE driver_id = $1 AND status = $2`
+	err := r.db.GetContext(ctx, &offer, query, driverID, models.OfferStatusQueued)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &offer, err
+}
+
+func (r *rideOfferRepository) GetSLAStats(ctx context.Context, since time.Time, targets map[string]models.SLOTargets, defaults models.SLOTargets) ([]*models.SLAStats, error) {
+	var codes []string
+	var matchSecs, acceptSecs, pickupSecs []int64
+	for code, t := range targets {
+		codes = append(codes, code)
+		matchSecs = append(matchSecs, int64(t.MatchSeconds))
+		acceptSecs = append(acceptSecs, int64(t.OfferAcceptanceSeconds))
+		pickupSecs = append(pickupSecs, int64(t.PickupSeconds))
+	}
+
+	// Chained offers are re-stamped when the queued ride activates, so their timings
+	// say nothing about matching. A bid driver responds by countering, and matching
+	// restarts from the reassignment when the first driver cancelled.
+	var stats []*models.SLAStats
+	query := `
+		WITH targets AS (
+			SELECT * FROM unnest($2::text[], $3::int[], $4::int[], $5::int[])
+				AS t(region_code, match_secs, accept_secs, pickup_secs)
+		),
+		accepted AS (
+			SELECT r.region_code,
+				EXTRACT(EPOCH FROM o.responded_at - CASE WHEN r.reassigned_at <= o.offered_at
+					THEN r.reassigned_at ELSE r.created_at END) AS match_secs,
+				EXTRACT(EPOCH FROM COALESCE(o.countered_at, o.responded_at) - o.offered_at) AS accept_secs,
+				CASE WHEN o.driver_id = r.driver_id
+					THEN EXTRACT(EPOCH FROM r.arrived_at - o.responded_at) END AS pickup_secs
+			FROM ride_offers o
+			JOIN rides r ON r.id = o.ride_id
+			WHERE o.status = $1 AND o.chained = FALSE AND o.responded_at >= $6
+		)
+		SELECT a.region_code,
+			COUNT(*) AS matches,
+			COUNT(*) FILTER (WHERE a.match_secs <= COALESCE(t.match_secs, $7)) AS matches_within,
+			COALESCE(percentile_cont(0.9) WITHIN GROUP (ORDER BY a.match_secs), 0) AS match_p90_secs,
+			COUNT(*) AS acceptances,
+			COUNT(*) FILTER (WHERE a.accept_secs <= COALESCE(t.accept_secs, $8)) AS acceptances_within,
+			COALESCE(percentile_cont(0.9) WITHIN GROUP (ORDER BY a.accept_secs), 0) AS acceptance_p90_secs,
+			COUNT(a.pickup_secs) AS pickups,
+			COUNT(*) FILTER (WHERE a.pickup_secs <= COALESCE(t.pickup_secs, $9)) AS pickups_within,
+			COALESCE(percentile_cont(0.9) WITHIN GROUP (ORDER BY a.pickup_secs), 0) AS pickup_p90_secs
+		FROM accepted a
+		LEFT JOIN targets t ON t.region_code = a.region_code
+		GROUP BY a.region_code
+		ORDER BY a.region_code
+	`
+	err := r.db.SelectContext(ctx, &stats, query, models.OfferStatusAccepted,
+		pq.Array(codes), pq.Array(matchSecs), pq.Array(acceptSecs), pq.Array(pickupSecs), since,
+		defaults.MatchSeconds, defaults.OfferAcceptanceSeconds, defaults.PickupSeconds)
+	return stats, err
+}
+
+func (r *rideOfferRepository) MarkViewed(ctx context.Context, ids []string, at time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	query := `UPDATE ride_offers SET viewed_at = $1 WHERE id = ANY($2) AND viewed_at IS NULL`
+	_, err := r.db.ExecContext(ctx, query, at, pq.Array(ids))
+	return err
+}
@@ -3,11 +3,15 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/tenant"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 type RideRepository interface {
@@ -21,6 +25,40 @@ type RideRepository interface {
 	GetActiveRideByUserID(ctx context.Context, userID string) (*models.Ride, error)
 	GetActiveRideByDriverID(ctx context.Context, driverID string) (*models.Ride, error)
 	GetByIDForUpdate(ctx context.Context, tx *sqlx.Tx, id string) (*models.Ride, error)
+	// Assign gives the ride to a driver within tx, agreeing its fare and promising a pickup time
+	Assign(ctx context.Context, tx *sqlx.Tx, id, driverID, status string, agreedFare *float64, etaMin *int, at time.Time) error
+	SetStatus(ctx context.Context, tx *sqlx.Tx, id, status string, at time.Time) error
+	SetDriver(ctx context.Context, tx *sqlx.Tx, id, driverID string, at time.Time) error
+	Search(ctx context.Context, filter *models.RideSearchFilter) ([]*models.Ride, error)
+	// GetHistory returns a rider's or driver's rides, newest first, ordered by
+	// (created_at, id) so pages never skip or repeat a ride
+	GetHistory(ctx context.Context, filter *models.RideHistoryFilter) ([]*models.Ride, error)
+	CountMatchingAhead(ctx context.Context, ride *models.Ride) (int, error)
+	CancelStaleBidRides(ctx context.Context, createdBefore time.Time, reason string) ([]string, error)
+	GetMatchingCreatedBefore(ctx context.Context, createdBefore time.Time) ([]*models.Ride, error)
+	CancelIfMatching(ctx context.Context, id, cancelledBy, reason string) (bool, error)
+	// RecordDispatch notes a dispatch round; it also counts as activity for the stuck-ride janitor
+	RecordDispatch(ctx context.Context, id string, attempts int, radiusKm float64, at time.Time) error
+	// GetDispatchDue returns non-bid rides still matching whose last round was before
+	// the given time and has no offer left pending. Rides that have had no round
+	// since they started matching, e.g. because the instance that took the request
+	// shut down first, are due once they have been matching that long.
+	GetDispatchDue(ctx context.Context, dispatchedBefore time.Time) ([]*models.Ride, error)
+	// GetStuck returns fixed-price rides in one of the statuses that haven't changed since the cutoff
+	GetStuck(ctx context.Context, statuses []string, updatedBefore time.Time) ([]*models.Ride, error)
+	CancelIfStatus(ctx context.Context, id, status, cancelledBy, reason string) (bool, error)
+	MarkEnRoute(ctx context.Context, id string, at time.Time) (bool, error)
+	MarkArrived(ctx context.Context, id string, at time.Time) (bool, error)
+	// Reassign takes an assigned ride off its driver before pickup and returns it to
+	// matching, keeping the first pickup ETA promised to the rider
+	Reassign(ctx context.Context, id, driverID string, at time.Time) (bool, error)
+	// GetScheduledByUserID returns the user's rides booked ahead, soonest pickup first
+	GetScheduledByUserID(ctx context.Context, userID string) ([]*models.Ride, error)
+	// GetScheduledDue returns scheduled rides picking up at or before the given time
+	GetScheduledDue(ctx context.Context, pickupBefore time.Time) ([]*models.Ride, error)
+	// StartScheduled moves a scheduled ride into matching. Returns false if it was
+	// no longer scheduled, e.g. because the rider cancelled it.
+	StartScheduled(ctx context.Context, id string, at time.Time) (bool, error)
 }
 
 type rideRepository struct {
@@ -39,26 +77,35 @@ func (r *rideRepository) Create(ctx context.Context, ride *models.Ride) error {
 	ride.UpdatedAt = time.Now()
 	ride.Status = models.RideStatusPending
 	ride.SurgeMultiplier = 1.0
+	if ride.PricingMode == "" {
+		ride.PricingMode = models.PricingModeStandard
+	}
+	ride.TenantCode = tenant.CodeOrDefault(ctx)
 
 	query := `
 		INSERT INTO rides (id, user_id, pickup_lat, pickup_lng, pickup_address,
 			dropoff_lat, dropoff_lng, dropoff_address, vehicle_type, status,
 			estimated_fare, surge_multiplier, estimated_distance_km, estimated_duration_mins,
-			payment_method, idempotency_key, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+			payment_method, region_code, idempotency_key, pricing_mode, proposed_fare,
+			rider_note, card_fingerprint, product, pickup_spot_id, pickup_spot_name, pickup_spot_source,
+			tenant_code, scheduled_at, promo_code, safety_mode, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19,
+			$20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31)
 	`
 	_, err := r.db.ExecContext(ctx, query,
 		ride.ID, ride.UserID, ride.PickupLat, ride.PickupLng, ride.PickupAddress,
 		ride.DropoffLat, ride.DropoffLng, ride.DropoffAddress, ride.VehicleType, ride.Status,
 		ride.EstimatedFare, ride.SurgeMultiplier, ride.EstimatedDistanceKm, ride.EstimatedDurationMin,
-		ride.PaymentMethod, ride.IdempotencyKey, ride.CreatedAt, ride.UpdatedAt)
+		ride.PaymentMethod, ride.RegionCode, ride.IdempotencyKey, ride.PricingMode, ride.ProposedFare,
+		ride.RiderNote, ride.CardFingerprint, ride.Product, ride.PickupSpotID, ride.PickupSpotName, ride.PickupSpotSource,
+		ride.TenantCode, ride.ScheduledAt, ride.PromoCode, ride.SafetyMode, ride.CreatedAt, ride.UpdatedAt)
 	return err
 }
 
 func (r *rideRepository) GetByID(ctx context.Context, id string) (*models.Ride, error) {
 	var ride models.Ride
-	query := `SELECT * FROM rides WHERE id = $1`
-	err := r.db.GetContext(ctx, &ride, query, id)
+	query := `SELECT * FROM rides WHERE id = $1 AND ($2 = '' OR tenant_code = $2)`
+	err := r.db.GetContext(ctx, &ride, query, id, tenant.Code(ctx))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -67,8 +114,8 @@ func (r *rideRepository) GetByID(ctx context.Context, id string) (*models.Ride,
 
 func (r *rideRepository) GetByIdempotencyKey(ctx context.Context, key string) (*models.Ride, error) {
 	var ride models.Ride
-	query := `SELECT * FROM rides WHERE idempotency_key = $1`
-	err := r.db.GetContext(ctx, &ride, query, key)
+	query := `SELECT * FROM rides WHERE idempotency_key = $1 AND ($2 = '' OR tenant_code = $2)`
+	err := r.db.GetContext(ctx, &ride, query, key, tenant.Code(ctx))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -101,6 +148,28 @@ func (r *rideRepository) AssignDriver(ctx context.Context, rideID, driverID stri
 	return err
 }
 
+func (r *rideRepository) Assign(ctx context.Context, tx *sqlx.Tx, id, driverID, status string, agreedFare *float64, etaMin *int, at time.Time) error {
+	query := `
+		UPDATE rides
+		SET driver_id = $1, status = $2, agreed_fare = $3, pickup_eta_mins = $4, updated_at = $5
+		WHERE id = $6
+	`
+	_, err := tx.ExecContext(ctx, query, driverID, status, agreedFare, etaMin, at, id)
+	return err
+}
+
+func (r *rideRepository) SetStatus(ctx context.Context, tx *sqlx.Tx, id, status string, at time.Time) error {
+	query := `UPDATE rides SET status = $1, updated_at = $2 WHERE id = $3`
+	_, err := tx.ExecContext(ctx, query, status, at, id)
+	return err
+}
+
+func (r *rideRepository) SetDriver(ctx context.Context, tx *sqlx.Tx, id, driverID string, at time.Time) error {
+	query := `UPDATE rides SET driver_id = $1, updated_at = $2 WHERE id = $3`
+	_, err := tx.ExecContext(ctx, query, driverID, at, id)
+	return err
+}
+
 func (r *rideRepository) Cancel(ctx context.Context, id, cancelledBy, reason string) error {
 	query := `
 		UPDATE rides
@@ -116,11 +185,12 @@ func (r *rideRepository) GetActiveRideByUserID(ctx context.Context, userID strin
 	var ride models.Ride
 	query := `
 		SELECT * FROM rides
-		WHERE user_id = $1 AND status NOT IN ($2, $3)
+		WHERE user_id = $1 AND status NOT IN ($2, $3, $4)
 		ORDER BY created_at DESC
 		LIMIT 1
 	`
-	err := r.db.GetContext(ctx, &ride, query, userID, models.RideStatusCompleted, models.RideStatusCancelled)
+	err := r.db.GetContext(ctx, &ride, query, userID,
+		models.RideStatusCompleted, models.RideStatusCancelled, models.RideStatusScheduled)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -131,11 +201,12 @@ func (r *rideRepository) GetActiveRideByDriverID(ctx context.Context, driverID s
 	var ride models.Ride
 	query := `
 		SELECT * FROM rides
-		WHERE driver_id = $1 AND status NOT IN ($2, $3)
+		WHERE driver_id = $1 AND status NOT IN ($2, $3, $4)
 		ORDER BY created_at DESC
 		LIMIT 1
 	`
-	err := r.db.GetContext(ctx, &ride, query, driverID, models.RideStatusCompleted, models.RideStatusCancelled)
+	err := r.db.GetContext(ctx, &ride, query, driverID,
+		models.RideStatusCompleted, models.RideStatusCancelled, models.RideStatusQueued)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -152,3 +223,272 @@ func (r *rideRepository) GetByIDForUpdate(ctx context.Context, tx *sqlx.Tx, id s
 	}
 	return &ride, err
 }
+
+// Search filters rides for admin tooling. Address text search uses the
+// idx_rides_address_fts expression index, so the expression must match it exactly.
+func (r *rideRepository) Search(ctx context.Context, filter *models.RideSearchFilter) ([]*models.Ride, error) {
+	var conditions []string
+	var args []interface{}
+
+	addCondition := func(clause string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(clause, len(args)))
+	}
+
+	if filter.Status != "" {
+		addCondition("status = $%d", filter.Status)
+	}
+	if filter.RegionCode != "" {
+		addCondition("region_code = $%d", filter.RegionCode)
+	}
+	if filter.TenantCode != "" {
+		addCondition("tenant_code = $%d", filter.TenantCode)
+	}
+	if filter.PaymentMethod != "" {
+		addCondition("payment_method = $%d", filter.PaymentMethod)
+	}
+	if filter.From != nil {
+		addCondition("created_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		addCondition("created_at < $%d", *filter.To)
+	}
+	if filter.MinSurge != nil {
+		addCondition("surge_multiplier > $%d", *filter.MinSurge)
+	}
+	if filter.Query != "" {
+		addCondition(`to_tsvector('simple', coalesce(pickup_address, '') || ' ' || coalesce(dropoff_address, ''))
+			@@ plainto_tsquery('simple', $%d)`, filter.Query)
+	}
+
+	query := `SELECT * FROM rides`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	query += fmt.Sprintf(" ORDER BY created_at DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	var rides []*models.Ride
+	err := r.db.SelectContext(ctx, &rides, query, args...)
+	return rides, err
+}
+
+func (r *rideRepository) GetHistory(ctx context.Context, filter *models.RideHistoryFilter) ([]*models.Ride, error) {
+	var conditions []string
+	var args []interface{}
+
+	addCondition := func(clause string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(clause, len(args)))
+	}
+
+	if filter.UserID != "" {
+		addCondition("user_id = $%d", filter.UserID)
+	}
+	if filter.DriverID != "" {
+		addCondition("driver_id = $%d", filter.DriverID)
+	}
+	if len(filter.Statuses) > 0 {
+		addCondition("status = ANY($%d)", pq.Array(filter.Statuses))
+	}
+	if filter.From != nil {
+		addCondition("created_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		addCondition("created_at < $%d", *filter.To)
+	}
+	if filter.AfterCreatedAt != nil {
+		args = append(args, *filter.AfterCreatedAt, filter.AfterID)
+		conditions = append(conditions, fmt.Sprintf("(created_at, id) < ($%d, $%d)", len(args)-1, len(args)))
+	}
+
+	query := `SELECT * FROM rides`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	args = append(args, filter.Limit)
+	query += fmt.Sprintf(" ORDER BY created_at DESC, id DESC LIMIT $%d", len(args))
+
+	var rides []*models.Ride
+	err := r.db.SelectContext(ctx, &rides, query, args...)
+	return rides, err
+}
+
+// CancelStaleBidRides cancels bid-mode rides still waiting for a driver that were
+// created before the cutoff, returning the cancelled ride IDs
+func (r *rideRepository) CancelStaleBidRides(ctx context.Context, createdBefore time.Time, reason string) ([]string, error) {
+	var ids []string
+	query := `
+		UPDATE rides
+		SET status = $1, cancelled_by = $2, cancellation_reason = $3, updated_at = NOW()
+		WHERE pricing_mode = $4 AND status = $5 AND created_at < $6
+		RETURNING id
+	`
+	err := r.db.SelectContext(ctx, &ids, query,
+		models.RideStatusCancelled, "system", reason, models.PricingModeBid, models.RideStatusMatching, createdBefore)
+	return ids, err
+}
+
+func (r *rideRepository) GetMatchingCreatedBefore(ctx context.Context, createdBefore time.Time) ([]*models.Ride, error) {
+	var rides []*models.Ride
+	query := `SELECT * FROM rides WHERE status = $1 AND created_at < $2 ORDER BY created_at ASC`
+	err := r.db.SelectContext(ctx, &rides, query, models.RideStatusMatching, createdBefore)
+	return rides, err
+}
+
+// CancelIfMatching cancels the ride only if no driver has taken it in the meantime
+func (r *rideRepository) CancelIfMatching(ctx context.Context, id, cancelledBy, reason string) (bool, error) {
+	return r.CancelIfStatus(ctx, id, models.RideStatusMatching, cancelledBy, reason)
+}
+
+func (r *rideRepository) RecordDispatch(ctx context.Context, id string, attempts int, radiusKm float64, at time.Time) error {
+	query := `
+		UPDATE rides
+		SET matching_attempts = $1, match_radius_km = $2, last_dispatched_at = $3, updated_at = $3
+		WHERE id = $4
+	`
+	_, err := r.db.ExecContext(ctx, query, attempts, radiusKm, at, id)
+	return err
+}
+
+func (r *rideRepository) GetDispatchDue(ctx context.Context, dispatchedBefore time.Time) ([]*models.Ride, error) {
+	var rides []*models.Ride
+	query := `
+		SELECT * FROM rides
+		WHERE status = $1 AND pricing_mode <> $2 AND COALESCE(last_dispatched_at, updated_at) < $3
+			AND NOT EXISTS (
+				SELECT 1 FROM ride_offers o
+				WHERE o.ride_id = rides.id AND o.status = $4 AND o.expires_at > NOW()
+			)
+		ORDER BY COALESCE(last_dispatched_at, updated_at) ASC
+	`
+	err := r.db.SelectContext(ctx, &rides, query,
+		models.RideStatusMatching, models.PricingModeBid, dispatchedBefore, models.OfferStatusPending)
+	return rides, err
+}
+
+// Bid rides are left to bid expiry, which has its own window
+func (r *rideRepository) GetStuck(ctx context.Context, statuses []string, updatedBefore time.Time) ([]*models.Ride, error) {
+	var rides []*models.Ride
+	query := `
+		SELECT * FROM rides
+		WHERE status = ANY($1) AND pricing_mode <> $2 AND updated_at < $3
+		ORDER BY updated_at ASC
+	`
+	err := r.db.SelectContext(ctx, &rides, query, pq.Array(statuses), models.PricingModeBid, updatedBefore)
+	return rides, err
+}
+
+// CancelIfStatus cancels the ride only if it is still in the given status
+func (r *rideRepository) CancelIfStatus(ctx context.Context, id, status, cancelledBy, reason string) (bool, error) {
+	query := `
+		UPDATE rides
+		SET status = $1, cancelled_by = $2, cancellation_reason = $3, updated_at = $4
+		WHERE id = $5 AND status = $6
+	`
+	result, err := r.db.ExecContext(ctx, query,
+		models.RideStatusCancelled, cancelledBy, reason, time.Now(), id, status)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+// MarkEnRoute records the driver heading to pickup; the first report's time is kept
+func (r *rideRepository) MarkEnRoute(ctx context.Context, id string, at time.Time) (bool, error) {
+	query := `
+		UPDATE rides
+		SET navigation_status = $1, en_route_at = COALESCE(en_route_at, $2), updated_at = $2
+		WHERE id = $3 AND status = $4
+	`
+	result, err := r.db.ExecContext(ctx, query,
+		models.NavigationEnRouteToPickup, at, id, models.RideStatusDriverAssigned)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+// MarkArrived moves the ride to driver_arrived and starts the waiting clock
+func (r *rideRepository) MarkArrived(ctx context.Context, id string, at time.Time) (bool, error) {
+	query := `
+		UPDATE rides
+		SET status = $1, navigation_status = $2, arrived_at = $3, updated_at = $3
+		WHERE id = $4 AND status = $5
+	`
+	result, err := r.db.ExecContext(ctx, query,
+		models.RideStatusDriverArrived, models.NavigationWaitingAtPickup, at, id, models.RideStatusDriverAssigned)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+// CountMatchingAhead counts rides of the same vehicle type and region that started matching before this one
+func (r *rideRepository) CountMatchingAhead(ctx context.Context, ride *models.Ride) (int, error) {
+	var count int
+	query := `
+		SELECT COUNT(*) FROM rides
+		WHERE status = $1 AND vehicle_type = $2 AND created_at < $3
+			AND region_code IS NOT DISTINCT FROM $4
+	`
+	err := r.db.GetContext(ctx, &count, query,
+		models.RideStatusMatching, ride.VehicleType, ride.CreatedAt, ride.RegionCode)
+	return count, err
+}
+
+func (r *rideRepository) Reassign(ctx context.Context, id, driverID string, at time.Time) (bool, error) {
+	query := `
+		UPDATE rides
+		SET status = $1, driver_id = NULL, reassigned_from_driver_id = driver_id, reassigned_at = $2,
+			original_pickup_eta_mins = COALESCE(original_pickup_eta_mins, pickup_eta_mins),
+			pickup_eta_mins = NULL, navigation_status = NULL, en_route_at = NULL, arrived_at = NULL,
+			matching_attempts = 0, match_radius_km = NULL, last_dispatched_at = NULL, updated_at = $2
+		WHERE id = $3 AND driver_id = $4 AND status IN ($5, $6)
+	`
+	result, err := r.db.ExecContext(ctx, query,
+		models.RideStatusMatching, at, id, driverID,
+		models.RideStatusDriverAssigned, models.RideStatusDriverArrived)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+func (r *rideRepository) GetScheduledByUserID(ctx context.Context, userID string) ([]*models.Ride, error) {
+	var rides []*models.Ride
+	query := `
+		SELECT * FROM rides
+		WHERE user_id = $1 AND status = $2
+		ORDER BY scheduled_at
+	`
+	err := r.db.SelectContext(ctx, &rides, query, userID, models.RideStatusScheduled)
+	return rides, err
+}
+
+func (r *rideRepository) GetScheduledDue(ctx context.Context, pickupBefore time.Time) ([]*models.Ride, error) {
+	var rides []*models.Ride
+	query := `
+		SELECT * FROM rides
+		WHERE status = $1 AND scheduled_at <= $2
+		ORDER BY scheduled_at
+	`
+	err := r.db.SelectContext(ctx, &rides, query, models.RideStatusScheduled, pickupBefore)
+	return rides, err
+}
+
+func (r *rideRepository) StartScheduled(ctx context.Context, id string, at time.Time) (bool, error) {
+	query := `UPDATE rides SET status = $1, updated_at = $2 WHERE id = $3 AND status = $4`
+	result, err := r.db.ExecContext(ctx, query, models.RideStatusMatching, at, id, models.RideStatusScheduled)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
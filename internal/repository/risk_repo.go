@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type RiskRepository interface {
+	CreateOverride(ctx context.Context, override *models.RiskOverride) error
+	GetOverrideByID(ctx context.Context, id string) (*models.RiskOverride, error)
+	GetOverridesByUserID(ctx context.Context, userID string) ([]*models.RiskOverride, error)
+	// GetActiveOverride returns the user's most recent override in effect at the given time
+	GetActiveOverride(ctx context.Context, userID string, at time.Time) (*models.RiskOverride, error)
+	RevokeOverride(ctx context.Context, id, revokedBy string, at time.Time) error
+	RecordEvent(ctx context.Context, event *models.RiskEvent) error
+	GetRecentEvents(ctx context.Context, userID string, limit int) ([]*models.RiskEvent, error)
+	// HasPaidWithCard reports whether the card has paid for a completed ride on the account
+	HasPaidWithCard(ctx context.Context, userID, fingerprint string) (bool, error)
+}
+
+type riskRepository struct {
+	db *sqlx.DB
+}
+
+func NewRiskRepository(db *sqlx.DB) RiskRepository {
+	return &riskRepository{db: db}
+}
+
+func (r *riskRepository) CreateOverride(ctx context.Context, override *models.RiskOverride) error {
+	if override.ID == "" {
+		override.ID = uuid.New().String()
+	}
+	override.CreatedAt = time.Now()
+
+	query := `
+		INSERT INTO risk_overrides (id, user_id, reason, granted_by, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		override.ID, override.UserID, override.Reason, override.GrantedBy, override.ExpiresAt, override.CreatedAt)
+	return err
+}
+
+func (r *riskRepository) GetOverrideByID(ctx context.Context, id string) (*models.RiskOverride, error) {
+	var override models.RiskOverride
+	query := `SELECT * FROM risk_overrides WHERE id = $1`
+	err := r.db.GetContext(ctx, &override, query, id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &override, err
+}
+
+func (r *riskRepository) GetOverridesByUserID(ctx context.Context, userID string) ([]*models.RiskOverride, error) {
+	var overrides []*models.RiskOverride
+	query := `SELECT * FROM risk_overrides WHERE user_id = $1 ORDER BY created_at DESC`
+	err := r.db.SelectContext(ctx, &overrides, query, userID)
+	return overrides, err
+}
+
+func (r *riskRepository) GetActiveOverride(ctx context.Context, userID string, at time.Time) (*models.RiskOverride, error) {
+	var override models.RiskOverride
+	query := `
+		SELECT * FROM risk_overrides
+		WHERE user_id = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > $2)
+		ORDER BY created_at DESC
+		LIMIT 1
+	`
+	err := r.db.GetContext(ctx, &override, query, userID, at)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &override, err
+}
+
+func (r *riskRepository) RevokeOverride(ctx context.Context, id, revokedBy string, at time.Time) error {
+	query := `UPDATE risk_overrides SET revoked_at = $1, revoked_by = $2 WHERE id = $3`
+	_, err := r.db.ExecContext(ctx, query, at, revokedBy, id)
+	return err
+}
+
+func (r *riskRepository) RecordEvent(ctx context.Context, event *models.RiskEvent) error {
+	if event.ID == "" {
+		event.ID = uuid.New().String()
+	}
+	event.CreatedAt = time.Now()
+
+	query := `
+		INSERT INTO risk_events (id, user_id, rule, outcome, payment_method, fare, override_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		event.ID, event.UserID, event.Rule, event.Outcome, event.PaymentMethod, event.Fare,
+		event.OverrideID, event.CreatedAt)
+	return err
+}
+
+func (r *riskRepository) GetRecentEvents(ctx context.Context, userID string, limit int) ([]*models.RiskEvent, error) {
+	var events []*models.RiskEvent
+	query := `SELECT * FROM risk_events WHERE user_id = $1 ORDER BY created_at DESC LIMIT $2`
+	err := r.db.SelectContext(ctx, &events, query, userID, limit)
+	return events, err
+}
+
+func (r *riskRepository) HasPaidWithCard(ctx context.Context, userID, fingerprint string) (bool, error) {
+	var exists bool
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM rides
+			WHERE user_id = $1 AND card_fingerprint = $2 AND status = $3
+		)
+	`
+	err := r.db.GetContext(ctx, &exists, query, userID, fingerprint, models.RideStatusCompleted)
+	return exists, err
+}
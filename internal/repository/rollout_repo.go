@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type RolloutRepository interface {
+	// RecordExposure stores the rider's first exposure with this outcome; later
+	// ones are ignored
+	RecordExposure(ctx context.Context, exposure *models.RolloutExposure) error
+	// GetSummary counts exposed riders per product in the region, and how many
+	// riders let in booked the product afterwards
+	GetSummary(ctx context.Context, regionCode string) ([]*models.RolloutSummary, error)
+}
+
+type rolloutRepository struct {
+	db *sqlx.DB
+}
+
+func NewRolloutRepository(db *sqlx.DB) RolloutRepository {
+	return &rolloutRepository{db: db}
+}
+
+func (r *rolloutRepository) RecordExposure(ctx context.Context, exposure *models.RolloutExposure) error {
+	if exposure.ID == "" {
+		exposure.ID = uuid.New().String()
+	}
+	exposure.CreatedAt = time.Now()
+
+	query := `
+		INSERT INTO rollout_exposures (id, region_code, product, user_id, enabled, percent, source, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (region_code, product, user_id, enabled) DO NOTHING
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		exposure.ID, exposure.RegionCode, exposure.Product, exposure.UserID, exposure.Enabled,
+		exposure.Percent, exposure.Source, exposure.CreatedAt)
+	return err
+}
+
+func (r *rolloutRepository) GetSummary(ctx context.Context, regionCode string) ([]*models.RolloutSummary, error) {
+	var summaries []*models.RolloutSummary
+	// Plain vehicle-type products aren't recorded on the ride, only its vehicle type
+	query := `
+		SELECT e.product,
+			COUNT(DISTINCT e.user_id) FILTER (WHERE e.enabled) AS enabled_riders,
+			COUNT(DISTINCT e.user_id) FILTER (WHERE NOT e.enabled) AS held_back_riders,
+			COUNT(DISTINCT r.user_id) FILTER (WHERE e.enabled) AS booked_riders
+		FROM rollout_exposures e
+		LEFT JOIN rides r ON r.user_id = e.user_id AND r.region_code = e.region_code
+			AND COALESCE(r.product, r.vehicle_type) = e.product AND r.created_at >= e.created_at
+		WHERE e.region_code = $1
+		GROUP BY e.product
+		ORDER BY e.product
+	`
+	err := r.db.SelectContext(ctx, &summaries, query, regionCode)
+	return summaries, err
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type SelfieCheckRepository interface {
+	// Create stores the check, returning false if its upload already backs one
+	Create(ctx context.Context, check *models.SelfieCheck) (bool, error)
+	GetLatestByDriverID(ctx context.Context, driverID string) (*models.SelfieCheck, error)
+	GetByUploadID(ctx context.Context, uploadID string) (*models.SelfieCheck, error)
+}
+
+type selfieCheckRepository struct {
+	db *sqlx.DB
+}
+
+func NewSelfieCheckRepository(db *sqlx.DB) SelfieCheckRepository {
+	return &selfieCheckRepository{db: db}
+}
+
+func (r *selfieCheckRepository) Create(ctx context.Context, check *models.SelfieCheck) (bool, error) {
+	if check.ID == "" {
+		check.ID = uuid.New().String()
+	}
+	check.CreatedAt = time.Now()
+
+	query := `
+		INSERT INTO driver_selfie_checks (id, driver_id, upload_id, status, provider, match_score,
+			captured_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (upload_id) DO NOTHING
+	`
+	result, err := r.db.ExecContext(ctx, query,
+		check.ID, check.DriverID, check.UploadID, check.Status, check.Provider,
+		check.MatchScore, check.CapturedAt, check.CreatedAt)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+func (r *selfieCheckRepository) GetLatestByDriverID(ctx context.Context, driverID string) (*models.SelfieCheck, error) {
+	var check models.SelfieCheck
+	query := `
+		SELECT * FROM driver_selfie_checks
+		WHERE driver_id = $1
+		ORDER BY created_at DESC
+		LIMIT 1
+	`
+	err := r.db.GetContext(ctx, &check, query, driverID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &check, err
+}
+
+func (r *selfieCheckRepository) GetByUploadID(ctx context.Context, uploadID string) (*models.SelfieCheck, error) {
+	var check models.SelfieCheck
+	query := `SELECT * FROM driver_selfie_checks WHERE upload_id = $1`
+	err := r.db.GetContext(ctx, &check, query, uploadID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &check, err
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type SessionRepository interface {
+	Create(ctx context.Context, session *models.Session) error
+	GetByTokenHash(ctx context.Context, tokenHash string) (*models.Session, error)
+	ListActive(ctx context.Context, principalType, principalID string) ([]*models.Session, error)
+	// Revoke ends one of the account's sessions, reporting whether it was active
+	Revoke(ctx context.Context, principalType, principalID, id, reason string, at time.Time) (bool, error)
+	// RevokeOthers ends every active session of the account except keepID
+	// (none when empty) and returns how many were ended
+	RevokeOthers(ctx context.Context, principalType, principalID, keepID, reason string, at time.Time) (int64, error)
+	RevokeDevice(ctx context.Context, principalType, principalID, deviceID, reason string, at time.Time) error
+	Touch(ctx context.Context, id string, at time.Time) error
+}
+
+type sessionRepository struct {
+	db *sqlx.DB
+}
+
+func NewSessionRepository(db *sqlx.DB) SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+func (r *sessionRepository) Create(ctx context.Context, session *models.Session) error {
+	if session.ID == "" {
+		session.ID = uuid.New().String()
+	}
+	now := time.Now()
+	session.CreatedAt = now
+	session.LastSeenAt = now
+
+	query := `
+		INSERT INTO sessions (id, principal_type, principal_id, token_hash, device_id, device_name,
+			platform, app_version, created_at, last_seen_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		session.ID, session.PrincipalType, session.PrincipalID, session.TokenHash, session.DeviceID,
+		session.DeviceName, session.Platform, session.AppVersion, session.CreatedAt, session.LastSeenAt)
+	return err
+}
+
+func (r *sessionRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*models.Session, error) {
+	var session models.Session
+	query := `SELECT * FROM sessions WHERE token_hash = $1`
+	err := r.db.GetContext(ctx, &session, query, tokenHash)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &session, err
+}
+
+func (r *sessionRepository) ListActive(ctx context.Context, principalType, principalID string) ([]*models.Session, error) {
+	var sessions []*models.Session
+	query := `
+		SELECT * FROM sessions
+		WHERE principal_type = $1 AND principal_id = $2 AND revoked_at IS NULL
+		ORDER BY last_seen_at DESC
+	`
+	err := r.db.SelectContext(ctx, &sessions, query, principalType, principalID)
+	return sessions, err
+}
+
+func (r *sessionRepository) Revoke(ctx context.Context, principalType, principalID, id, reason string, at time.Time) (bool, error) {
+	query := `
+		UPDATE sessions SET revoked_at = $1, revoked_reason = $2
+		WHERE id = $3 AND principal_type = $4 AND principal_id = $5 AND revoked_at IS NULL
+	`
+	result, err := r.db.ExecContext(ctx, query, at, reason, id, principalType, principalID)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+func (r *sessionRepository) RevokeOthers(ctx context.Context, principalType, principalID, keepID, reason string, at time.Time) (int64, error) {
+	query := `
+		UPDATE sessions SET revoked_at = $1, revoked_reason = $2
+		WHERE principal_type = $3 AND principal_id = $4 AND revoked_at IS NULL
+			AND ($5 = '' OR id::text <> $5)
+	`
+	result, err := r.db.ExecContext(ctx, query, at, reason, principalType, principalID, keepID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (r *sessionRepository) RevokeDevice(ctx context.Context, principalType, principalID, deviceID, reason string, at time.Time) error {
+	query := `
+		UPDATE sessions SET revoked_at = $1, revoked_reason = $2
+		WHERE principal_type = $3 AND principal_id = $4 AND device_id = $5 AND revoked_at IS NULL
+	`
+	_, err := r.db.ExecContext(ctx, query, at, reason, principalType, principalID, deviceID)
+	return err
+}
+
+func (r *sessionRepository) Touch(ctx context.Context, id string, at time.Time) error {
+	query := `UPDATE sessions SET last_seen_at = $1 WHERE id = $2`
+	_, err := r.db.ExecContext(ctx, query, at, id)
+	return err
+}
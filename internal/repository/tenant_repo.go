@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+type TenantRepository interface {
+	Create(ctx context.Context, t *models.Tenant) error
+	GetByCode(ctx context.Context, code string) (*models.Tenant, error)
+	GetAll(ctx context.Context) ([]*models.Tenant, error)
+	Update(ctx context.Context, t *models.Tenant) error
+	SetAPIKeyHash(ctx context.Context, code, hash string) error
+}
+
+type tenantRepository struct {
+	db *sqlx.DB
+}
+
+func NewTenantRepository(db *sqlx.DB) TenantRepository {
+	return &tenantRepository{db: db}
+}
+
+func (r *tenantRepository) Create(ctx context.Context, t *models.Tenant) error {
+	now := time.Now()
+	t.CreatedAt = now
+	t.UpdatedAt = now
+	t.Active = true
+
+	query := `
+		INSERT INTO tenants (code, name, api_key_hash, hosts, branding, fare_configs,
+			psp_url, psp_api_key, active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		t.Code, t.Name, t.APIKeyHash, t.Hosts, t.Branding, t.FareConfigs,
+		t.PSPURL, t.PSPAPIKey, t.Active, t.CreatedAt, t.UpdatedAt)
+	return err
+}
+
+func (r *tenantRepository) GetByCode(ctx context.Context, code string) (*models.Tenant, error) {
+	var t models.Tenant
+	query := `SELECT * FROM tenants WHERE code = $1`
+	err := r.db.GetContext(ctx, &t, query, code)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &t, err
+}
+
+func (r *tenantRepository) GetAll(ctx context.Context) ([]*models.Tenant, error) {
+	var tenants []*models.Tenant
+	query := `SELECT * FROM tenants ORDER BY code`
+	err := r.db.SelectContext(ctx, &tenants, query)
+	return tenants, err
+}
+
+func (r *tenantRepository) Update(ctx context.Context, t *models.Tenant) error {
+	t.UpdatedAt = time.Now()
+	query := `
+		UPDATE tenants
+		SET name = $1, hosts = $2, branding = $3, fare_configs = $4, psp_url = $5, psp_api_key = $6,
+			active = $7, updated_at = $8
+		WHERE code = $9
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		t.Name, t.Hosts, t.Branding, t.FareConfigs, t.PSPURL, t.PSPAPIKey, t.Active, t.UpdatedAt, t.Code)
+	return err
+}
+
+func (r *tenantRepository) SetAPIKeyHash(ctx context.Context, code, hash string) error {
+	query := `UPDATE tenants SET api_key_hash = $1, updated_at = $2 WHERE code = $3`
+	_, err := r.db.ExecContext(ctx, query, hash, time.Now(), code)
+	return err
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type TrainingRepository interface {
+	// Complete records a module as completed, replacing any earlier completion
+	Complete(ctx context.Context, training *models.DriverTraining) error
+	Revoke(ctx context.Context, driverID, module string) error
+	GetByDriverID(ctx context.Context, driverID string) ([]*models.DriverTraining, error)
+	HasCompleted(ctx context.Context, driverID, module string) (bool, error)
+	// FilterCompleted returns which of the drivers have completed the module
+	FilterCompleted(ctx context.Context, module string, driverIDs []string) (map[string]bool, error)
+}
+
+type trainingRepository struct {
+	db *sqlx.DB
+}
+
+func NewTrainingRepository(db *sqlx.DB) TrainingRepository {
+	return &trainingRepository{db: db}
+}
+
+func (r *trainingRepository) Complete(ctx context.Context, training *models.DriverTraining) error {
+	training.CompletedAt = time.Now()
+
+	query := `
+		INSERT INTO driver_trainings (driver_id, module, score, completed_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (driver_id, module) DO UPDATE SET score = EXCLUDED.score, completed_at = EXCLUDED.completed_at
+	`
+	_, err := r.db.ExecContext(ctx, query, training.DriverID, training.Module, training.Score, training.CompletedAt)
+	return err
+}
+
+func (r *trainingRepository) Revoke(ctx context.Context, driverID, module string) error {
+	query := `DELETE FROM driver_trainings WHERE driver_id = $1 AND module = $2`
+	_, err := r.db.ExecContext(ctx, query, driverID, module)
+	return err
+}
+
+func (r *trainingRepository) GetByDriverID(ctx context.Context, driverID string) ([]*models.DriverTraining, error) {
+	var trainings []*models.DriverTraining
+	query := `SELECT * FROM driver_trainings WHERE driver_id = $1 ORDER BY completed_at`
+	err := r.db.SelectContext(ctx, &trainings, query, driverID)
+	return trainings, err
+}
+
+func (r *trainingRepository) HasCompleted(ctx context.Context, driverID, module string) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM driver_trainings WHERE driver_id = $1 AND module = $2)`
+	err := r.db.GetContext(ctx, &exists, query, driverID, module)
+	return exists, err
+}
+
+func (r *trainingRepository) FilterCompleted(ctx context.Context, module string, driverIDs []string) (map[string]bool, error) {
+	completed := make(map[string]bool)
+	if len(driverIDs) == 0 {
+		return completed, nil
+	}
+
+	var ids []string
+	query := `SELECT driver_id FROM driver_trainings WHERE module = $1 AND driver_id = ANY($2)`
+	if err := r.db.SelectContext(ctx, &ids, query, module, pq.Array(driverIDs)); err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		completed[id] = true
+	}
+	return completed, nil
+}
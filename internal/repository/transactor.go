@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Transactor runs a unit of work spanning several repositories atomically.
+// Repository methods that take a tx read and write through it.
+type Transactor interface {
+	// InTx runs fn in a transaction, committed if fn returns nil and rolled
+	// back otherwise
+	InTx(ctx context.Context, fn func(tx *sqlx.Tx) error) error
+}
+
+type transactor struct {
+	db *sqlx.DB
+}
+
+func NewTransactor(db *sqlx.DB) Transactor {
+	return &transactor{db: db}
+}
+
+func (t *transactor) InTx(ctx context.Context, fn func(tx *sqlx.Tx) error) error {
+	tx, err := t.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
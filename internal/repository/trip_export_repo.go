@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type TripExportRepository interface {
+	Create(ctx context.Context, export *models.TripExport) error
+	GetByID(ctx context.Context, id string) (*models.TripExport, error)
+	// GetLatest returns the driver's most recent export for the year, or nil
+	GetLatest(ctx context.Context, driverID string, year int) (*models.TripExport, error)
+	// ClaimPending marks up to limit pending exports as processing and returns them.
+	// Exports stuck processing since before staleBefore are claimed again.
+	ClaimPending(ctx context.Context, limit int, staleBefore time.Time) ([]*models.TripExport, error)
+	MarkReady(ctx context.Context, id, objectKey string, tripCount int) error
+	MarkFailed(ctx context.Context, id, reason string) error
+	// GetRows returns the driver's trips completed in [from, to) with their payments, oldest first
+	GetRows(ctx context.Context, driverID string, from, to time.Time) ([]*models.TripExportRow, error)
+}
+
+type tripExportRepository struct {
+	db *sqlx.DB
+}
+
+func NewTripExportRepository(db *sqlx.DB) TripExportRepository {
+	return &tripExportRepository{db: db}
+}
+
+func (r *tripExportRepository) Create(ctx context.Context, export *models.TripExport) error {
+	if export.ID == "" {
+		export.ID = uuid.New().String()
+	}
+	export.CreatedAt = time.Now()
+	export.Status = models.TripExportStatusPending
+
+	query := `
+		INSERT INTO trip_exports (id, driver_id, year, status, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	_, err := r.db.ExecContext(ctx, query, export.ID, export.DriverID, export.Year, export.Status, export.CreatedAt)
+	return err
+}
+
+func (r *tripExportRepository) GetByID(ctx context.Context, id string) (*models.TripExport, error) {
+	var export models.TripExport
+	query := `SELECT * FROM trip_exports WHERE id = $1`
+	err := r.db.GetContext(ctx, &export, query, id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &export, err
+}
+
+func (r *tripExportRepository) GetLatest(ctx context.Context, driverID string, year int) (*models.TripExport, error) {
+	var export models.TripExport
+	query := `
+		SELECT * FROM trip_exports
+		WHERE driver_id = $1 AND year = $2
+		ORDER BY created_at DESC
+		LIMIT 1
+	`
+	err := r.db.GetContext(ctx, &export, query, driverID, year)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &export, err
+}
+
+func (r *tripExportRepository) ClaimPending(ctx context.Context, limit int, staleBefore time.Time) ([]*models.TripExport, error) {
+	var exports []*models.TripExport
+	query := `
+		UPDATE trip_exports SET status = $1, started_at = $2
+		WHERE id IN (
+			SELECT id FROM trip_exports
+			WHERE status = $3 OR (status = $1 AND started_at < $4)
+			ORDER BY created_at
+			LIMIT $5
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *
+	`
+	err := r.db.SelectContext(ctx, &exports, query, models.TripExportStatusProcessing, time.Now(),
+		models.TripExportStatusPending, staleBefore, limit)
+	return exports, err
+}
+
+func (r *tripExportRepository) MarkReady(ctx context.Context, id, objectKey string, tripCount int) error {
+	query := `
+		UPDATE trip_exports SET status = $1, object_key = $2, trip_count = $3, completed_at = $4
+		WHERE id = $5
+	`
+	_, err := r.db.ExecContext(ctx, query, models.TripExportStatusReady, objectKey, tripCount, time.Now(), id)
+	return err
+}
+
+func (r *tripExportRepository) MarkFailed(ctx context.Context, id, reason string) error {
+	query := `UPDATE trip_exports SET status = $1, error = $2, completed_at = $3 WHERE id = $4`
+	_, err := r.db.ExecContext(ctx, query, models.TripExportStatusFailed, reason, time.Now(), id)
+	return err
+}
+
+func (r *tripExportRepository) GetRows(ctx context.Context, driverID string, from, to time.Time) ([]*models.TripExportRow, error) {
+	var rows []*models.TripExportRow
+	query := `
+		SELECT t.id AS trip_id, t.ride_id, t.start_time, t.end_time, r.vehicle_type,
+			r.pickup_address, r.dropoff_address, t.actual_distance_km, t.actual_duration_mins, t.total_fare,
+			p.method AS payment_method, p.amount AS paid_amount, p.created_at AS paid_at, p.carbon_offset_amount,
+			p.commission_percent, p.commission_amount, p.driver_earnings
+		FROM trips t
+		JOIN rides r ON r.id = t.ride_id
+		LEFT JOIN payments p ON p.trip_id = t.id AND p.status = $2
+		WHERE t.driver_id = $1 AND t.status = $3 AND t.end_time >= $4 AND t.end_time < $5
+		ORDER BY t.end_time
+	`
+	err := r.db.SelectContext(ctx, &rows, query, driverID, models.PaymentStatusCompleted,
+		models.TripStatusCompleted, from, to)
+	return rows, err
+}
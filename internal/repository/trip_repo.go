@@ -16,8 +16,22 @@ type TripRepository interface {
 	GetByRideID(ctx context.Context, rideID string) (*models.Trip, error)
 	Update(ctx context.Context, trip *models.Trip) error
 	UpdateStatus(ctx context.Context, id, status string) error
+	SetStatus(ctx context.Context, tx *sqlx.Tx, id, status string, at time.Time) error
+	// SetDriver hands the trip to another driver within tx
+	SetDriver(ctx context.Context, tx *sqlx.Tx, id, driverID, status string, at time.Time) error
 	EndTrip(ctx context.Context, trip *models.Trip) error
 	GetActiveTripByDriverID(ctx context.Context, driverID string) (*models.Trip, error)
+	SetOdometerReading(ctx context.Context, id, stage string, readingKm float64, uploadID string) error
+	UpdateMileageAudit(ctx context.Context, trip *models.Trip) error
+	GetByMileageStatus(ctx context.Context, status string, limit int) ([]*models.Trip, error)
+	HasCompletedTrip(ctx context.Context, userID, driverID string) (bool, error)
+	GetCarbonStats(ctx context.Context, ownerType, ownerID string) (*models.CarbonStats, error)
+	GetEarningsSummary(ctx context.Context, driverID string, since time.Time) (*models.EarningsSummary, error)
+	// GetCompletedSince returns the driver's trips that ended after since, newest first
+	GetCompletedSince(ctx context.Context, driverID string, since time.Time) ([]*models.Trip, error)
+	// GetFareVariance summarises how actual fares compared with estimates for trips
+	// completed since, per region and vehicle type
+	GetFareVariance(ctx context.Context, since time.Time) ([]*models.FareVarianceGroup, error)
 }
 
 type tripRepository struct {
@@ -40,12 +54,12 @@ func (r *tripRepository) Create(ctx context.Context, trip *models.Trip) error {
 
 	query := `
 		INSERT INTO trips (id, ride_id, driver_id, user_id, status, start_time,
-			pause_duration_secs, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			pause_duration_secs, helmet_confirmed_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 	`
 	_, err := r.db.ExecContext(ctx, query,
 		trip.ID, trip.RideID, trip.DriverID, trip.UserID, trip.Status,
-		trip.StartTime, 0, trip.CreatedAt, trip.UpdatedAt)
+		trip.StartTime, 0, trip.HelmetConfirmedAt, trip.CreatedAt, trip.UpdatedAt)
 	return err
 }
 
@@ -87,6 +101,18 @@ func (r *tripRepository) UpdateStatus(ctx context.Context, id, status string) er
 	return err
 }
 
+func (r *tripRepository) SetStatus(ctx context.Context, tx *sqlx.Tx, id, status string, at time.Time) error {
+	query := `UPDATE trips SET status = $1, updated_at = $2 WHERE id = $3`
+	_, err := tx.ExecContext(ctx, query, status, at, id)
+	return err
+}
+
+func (r *tripRepository) SetDriver(ctx context.Context, tx *sqlx.Tx, id, driverID, status string, at time.Time) error {
+	query := `UPDATE trips SET driver_id = $1, status = $2, updated_at = $3 WHERE id = $4`
+	_, err := tx.ExecContext(ctx, query, driverID, status, at, id)
+	return err
+}
+
 func (r *tripRepository) EndTrip(ctx context.Context, trip *models.Trip) error {
 	now := time.Now()
 	trip.EndTime = &now
@@ -97,13 +123,19 @@ func (r *tripRepository) EndTrip(ctx context.Context, trip *models.Trip) error {
 		UPDATE trips
 		SET status = $1, end_time = $2, actual_distance_km = $3, actual_duration_mins = $4,
 			base_fare = $5, distance_fare = $6, time_fare = $7, surge_amount = $8,
-			total_fare = $9, updated_at = $10
-		WHERE id = $11
+			total_fare = $9, updated_at = $10, gps_distance_km = $11,
+			mileage_status = $12, mileage_discrepancy_km = $13, co2_grams = $14,
+			ev_discount = $15, waiting_fee = $16, package_surcharge = $17, declared_value_surcharge = $18,
+			event_surcharge = $19, pricing_events = $20, promo_discount = $21, route_polyline = $22
+		WHERE id = $23
 	`
 	_, err := r.db.ExecContext(ctx, query,
 		trip.Status, trip.EndTime, trip.ActualDistanceKm, trip.ActualDurationMin,
 		trip.BaseFare, trip.DistanceFare, trip.TimeFare, trip.SurgeAmount,
-		trip.TotalFare, trip.UpdatedAt, trip.ID)
+		trip.TotalFare, trip.UpdatedAt, trip.GPSDistanceKm,
+		trip.MileageStatus, trip.MileageDiscrepancyKm, trip.CO2Grams,
+		trip.EVDiscount, trip.WaitingFee, trip.PackageSurcharge, trip.DeclaredValueSurcharge,
+		trip.EventSurcharge, trip.PricingEvents, trip.PromoDiscount, trip.RoutePolyline, trip.ID)
 	return err
 }
 
@@ -121,3 +153,125 @@ func (r *tripRepository) GetActiveTripByDriverID(ctx context.Context, driverID s
 	}
 	return &trip, err
 }
+
+func (r *tripRepository) SetOdometerReading(ctx context.Context, id, stage string, readingKm float64, uploadID string) error {
+	query := `
+		UPDATE trips
+		SET start_odometer_km = $1, start_odometer_upload_id = $2, updated_at = $3
+		WHERE id = $4
+	`
+	if stage == models.OdometerStageEnd {
+		query = `
+			UPDATE trips
+			SET end_odometer_km = $1, end_odometer_upload_id = $2, updated_at = $3
+			WHERE id = $4
+		`
+	}
+	_, err := r.db.ExecContext(ctx, query, readingKm, uploadID, time.Now(), id)
+	return err
+}
+
+func (r *tripRepository) UpdateMileageAudit(ctx context.Context, trip *models.Trip) error {
+	trip.UpdatedAt = time.Now()
+	query := `
+		UPDATE trips
+		SET mileage_status = $1, mileage_discrepancy_km = $2, mileage_review_note = $3, updated_at = $4
+		WHERE id = $5
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		trip.MileageStatus, trip.MileageDiscrepancyKm, trip.MileageReviewNote, trip.UpdatedAt, trip.ID)
+	return err
+}
+
+func (r *tripRepository) GetByMileageStatus(ctx context.Context, status string, limit int) ([]*models.Trip, error) {
+	var trips []*models.Trip
+	query := `
+		SELECT * FROM trips
+		WHERE mileage_status = $1
+		ORDER BY end_time DESC
+		LIMIT $2
+	`
+	err := r.db.SelectContext(ctx, &trips, query, status, limit)
+	return trips, err
+}
+
+func (r *tripRepository) HasCompletedTrip(ctx context.Context, userID, driverID string) (bool, error) {
+	var exists bool
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM trips
+			WHERE user_id = $1 AND driver_id = $2 AND status = $3
+		)
+	`
+	err := r.db.GetContext(ctx, &exists, query, userID, driverID, models.TripStatusCompleted)
+	return exists, err
+}
+
+// GetCarbonStats sums emissions over completed trips for a user or driver.
+// Offsets count towards the rider who paid for them.
+func (r *tripRepository) GetCarbonStats(ctx context.Context, ownerType, ownerID string) (*models.CarbonStats, error) {
+	column := "t.user_id"
+	if ownerType == models.CarbonOwnerDriver {
+		column = "t.driver_id"
+	}
+
+	stats := &models.CarbonStats{OwnerType: ownerType, OwnerID: ownerID}
+	query := `
+		SELECT COUNT(*) AS trips,
+			COALESCE(SUM(t.actual_distance_km), 0) AS distance_km,
+			COALESCE(SUM(t.co2_grams), 0) AS co2_grams,
+			COALESCE(SUM(t.co2_grams) FILTER (WHERE p.carbon_offset_amount > 0), 0) AS offset_grams,
+			COALESCE(SUM(p.carbon_offset_amount), 0) AS offset_donated
+		FROM trips t
+		LEFT JOIN payments p ON p.trip_id = t.id AND p.status = $3
+		WHERE ` + column + ` = $1 AND t.status = $2
+	`
+	err := r.db.GetContext(ctx, stats, query, ownerID, models.TripStatusCompleted, models.PaymentStatusCompleted)
+	return stats, err
+}
+
+// GetEarningsSummary totals the fares of the driver's trips completed since the given time
+func (r *tripRepository) GetEarningsSummary(ctx context.Context, driverID string, since time.Time) (*models.EarningsSummary, error) {
+	var summary models.EarningsSummary
+	query := `
+		SELECT COALESCE(SUM(total_fare), 0) AS gross, COUNT(*) AS trips, MIN(start_time) AS first_trip_at
+		FROM trips
+		WHERE driver_id = $1 AND status = $2 AND end_time >= $3
+	`
+	err := r.db.GetContext(ctx, &summary, query, driverID, models.TripStatusCompleted, since)
+	return &summary, err
+}
+
+func (r *tripRepository) GetCompletedSince(ctx context.Context, driverID string, since time.Time) ([]*models.Trip, error) {
+	var trips []*models.Trip
+	query := `
+		SELECT * FROM trips
+		WHERE driver_id = $1 AND status = $2 AND end_time >= $3
+		ORDER BY end_time DESC
+	`
+	err := r.db.SelectContext(ctx, &trips, query, driverID, models.TripStatusCompleted, since)
+	return trips, err
+}
+
+func (r *tripRepository) GetFareVariance(ctx context.Context, since time.Time) ([]*models.FareVarianceGroup, error) {
+	var groups []*models.FareVarianceGroup
+	// Bid rides are charged the agreed fare, so they say nothing about estimation
+	query := `
+		WITH variance AS (
+			SELECT r.region_code, r.vehicle_type,
+				(t.total_fare - r.estimated_fare) / r.estimated_fare * 100 AS pct
+			FROM trips t
+			JOIN rides r ON r.id = t.ride_id
+			WHERE t.status = $1 AND t.end_time >= $2
+				AND t.total_fare IS NOT NULL AND r.estimated_fare > 0 AND r.agreed_fare IS NULL
+		)
+		SELECT region_code, vehicle_type, COUNT(*) AS trips,
+			percentile_cont(0.5) WITHIN GROUP (ORDER BY pct) AS median_variance_pct,
+			percentile_cont(0.9) WITHIN GROUP (ORDER BY ABS(pct)) AS p90_abs_variance_pct
+		FROM variance
+		GROUP BY region_code, vehicle_type
+		ORDER BY region_code, vehicle_type
+	`
+	err := r.db.SelectContext(ctx, &groups, query, models.TripStatusCompleted, since)
+	return groups, err
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type TripSegmentRepository interface {
+	Create(ctx context.Context, tx *sqlx.Tx, segment *models.TripSegment) error
+	Update(ctx context.Context, tx *sqlx.Tx, segment *models.TripSegment) error
+	GetByTripID(ctx context.Context, tripID string) ([]*models.TripSegment, error)
+}
+
+type tripSegmentRepository struct {
+	db *sqlx.DB
+}
+
+func NewTripSegmentRepository(db *sqlx.DB) TripSegmentRepository {
+	return &tripSegmentRepository{db: db}
+}
+
+// Create inserts a segment; pass a transaction to write it atomically with the handover
+func (r *tripSegmentRepository) Create(ctx context.Context, tx *sqlx.Tx, segment *models.TripSegment) error {
+	if segment.ID == "" {
+		segment.ID = uuid.New().String()
+	}
+	segment.CreatedAt = time.Now()
+
+	query := `
+		INSERT INTO trip_segments (id, trip_id, driver_id, sequence, start_time, end_time,
+			start_lat, start_lng, end_lat, end_lng, distance_km, duration_mins, end_reason, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+	`
+	_, err := r.exec(tx).ExecContext(ctx, query,
+		segment.ID, segment.TripID, segment.DriverID, segment.Sequence, segment.StartTime, segment.EndTime,
+		segment.StartLat, segment.StartLng, segment.EndLat, segment.EndLng, segment.DistanceKm,
+		segment.DurationMins, segment.EndReason, segment.CreatedAt)
+	return err
+}
+
+func (r *tripSegmentRepository) Update(ctx context.Context, tx *sqlx.Tx, segment *models.TripSegment) error {
+	query := `
+		UPDATE trip_segments
+		SET end_time = $1, end_lat = $2, end_lng = $3, distance_km = $4, duration_mins = $5,
+			fare_share = $6, end_reason = $7
+		WHERE id = $8
+	`
+	_, err := r.exec(tx).ExecContext(ctx, query,
+		segment.EndTime, segment.EndLat, segment.EndLng, segment.DistanceKm, segment.DurationMins,
+		segment.FareShare, segment.EndReason, segment.ID)
+	return err
+}
+
+func (r *tripSegmentRepository) GetByTripID(ctx context.Context, tripID string) ([]*models.TripSegment, error) {
+	var segments []*models.TripSegment
+	query := `SELECT * FROM trip_segments WHERE trip_id = $1 ORDER BY sequence ASC`
+	err := r.db.SelectContext(ctx, &segments, query, tripID)
+	return segments, err
+}
+
+func (r *tripSegmentRepository) exec(tx *sqlx.Tx) sqlx.ExecerContext {
+	if tx != nil {
+		return tx
+	}
+	return r.db
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type UploadRepository interface {
+	Create(ctx context.Context, upload *models.Upload) error
+	GetByID(ctx context.Context, id string) (*models.Upload, error)
+	GetByOwner(ctx context.Context, ownerType, ownerID string) ([]*models.Upload, error)
+	UpdateStatus(ctx context.Context, upload *models.Upload) error
+}
+
+type uploadRepository struct {
+	db *sqlx.DB
+}
+
+func NewUploadRepository(db *sqlx.DB) UploadRepository {
+	return &uploadRepository{db: db}
+}
+
+func (r *uploadRepository) Create(ctx context.Context, upload *models.Upload) error {
+	if upload.ID == "" {
+		upload.ID = uuid.New().String()
+	}
+	upload.CreatedAt = time.Now()
+	upload.UpdatedAt = time.Now()
+	upload.Status = models.UploadStatusPending
+
+	query := `
+		INSERT INTO uploads (id, owner_type, owner_id, purpose, object_key, content_type,
+			max_size_bytes, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		upload.ID, upload.OwnerType, upload.OwnerID, upload.Purpose, upload.ObjectKey,
+		upload.ContentType, upload.MaxSizeBytes, upload.Status, upload.CreatedAt, upload.UpdatedAt)
+	return err
+}
+
+func (r *uploadRepository) GetByID(ctx context.Context, id string) (*models.Upload, error) {
+	var upload models.Upload
+	query := `SELECT * FROM uploads WHERE id = $1`
+	err := r.db.GetContext(ctx, &upload, query, id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &upload, err
+}
+
+func (r *uploadRepository) GetByOwner(ctx context.Context, ownerType, ownerID string) ([]*models.Upload, error) {
+	var uploads []*models.Upload
+	query := `
+		SELECT * FROM uploads
+		WHERE owner_type = $1 AND owner_id = $2
+		ORDER BY created_at DESC
+	`
+	err := r.db.SelectContext(ctx, &uploads, query, ownerType, ownerID)
+	return uploads, err
+}
+
+func (r *uploadRepository) UpdateStatus(ctx context.Context, upload *models.Upload) error {
+	upload.UpdatedAt = time.Now()
+	query := `
+		UPDATE uploads
+		SET status = $1, size_bytes = $2, reject_reason = $3, verified_at = $4, updated_at = $5
+		WHERE id = $6
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		upload.Status, upload.SizeBytes, upload.RejectReason, upload.VerifiedAt,
+		upload.UpdatedAt, upload.ID)
+	return err
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/tenant"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 )
@@ -15,7 +16,11 @@ type UserRepository interface {
 	GetByID(ctx context.Context, id string) (*models.User, error)
 	GetByPhone(ctx context.Context, phone string) (*models.User, error)
 	Update(ctx context.Context, user *models.User) error
-	UpdateRating(ctx context.Context, id string, rating float64) error
+	// UpdateRating recalculates the rating as the average of the latest window
+	// ratings received, keeping it unchanged until the first one
+	UpdateRating(ctx context.Context, id string, window int) error
+	UpdatePhone(ctx context.Context, id, phone string) error
+	UpdateSafetyPreferences(ctx context.Context, user *models.User) error
 }
 
 type userRepository struct {
@@ -33,20 +38,21 @@ func (r *userRepository) Create(ctx context.Context, user *models.User) error {
 	user.CreatedAt = time.Now()
 	user.UpdatedAt = time.Now()
 	user.Rating = 5.0
+	user.TenantCode = tenant.CodeOrDefault(ctx)
 
 	query := `
-		INSERT INTO users (id, phone, name, email, rating, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO users (id, phone, name, email, gender, rating, tenant_code, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 	`
 	_, err := r.db.ExecContext(ctx, query,
-		user.ID, user.Phone, user.Name, user.Email, user.Rating, user.CreatedAt, user.UpdatedAt)
+		user.ID, user.Phone, user.Name, user.Email, user.Gender, user.Rating, user.TenantCode, user.CreatedAt, user.UpdatedAt)
 	return err
 }
 
 func (r *userRepository) GetByID(ctx context.Context, id string) (*models.User, error) {
 	var user models.User
-	query := `SELECT * FROM users WHERE id = $1`
-	err := r.db.GetContext(ctx, &user, query, id)
+	query := `SELECT * FROM users WHERE id = $1 AND ($2 = '' OR tenant_code = $2)`
+	err := r.db.GetContext(ctx, &user, query, id, tenant.Code(ctx))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -55,8 +61,8 @@ func (r *userRepository) GetByID(ctx context.Context, id string) (*models.User,
 
 func (r *userRepository) GetByPhone(ctx context.Context, phone string) (*models.User, error) {
 	var user models.User
-	query := `SELECT * FROM users WHERE phone = $1`
-	err := r.db.GetContext(ctx, &user, query, phone)
+	query := `SELECT * FROM users WHERE phone = $1 AND tenant_code = $2`
+	err := r.db.GetContext(ctx, &user, query, phone, tenant.CodeOrDefault(ctx))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -74,8 +80,35 @@ func (r *userRepository) Update(ctx context.Context, user *models.User) error {
 	return err
 }
 
-func (r *userRepository) UpdateRating(ctx context.Context, id string, rating float64) error {
-	query := `UPDATE users SET rating = $1, updated_at = $2 WHERE id = $3`
-	_, err := r.db.ExecContext(ctx, query, rating, time.Now(), id)
+func (r *userRepository) UpdatePhone(ctx context.Context, id, phone string) error {
+	query := `UPDATE users SET phone = $1, updated_at = $2 WHERE id = $3`
+	_, err := r.db.ExecContext(ctx, query, phone, time.Now(), id)
+	return err
+}
+
+func (r *userRepository) UpdateRating(ctx context.Context, id string, window int) error {
+	query := `
+		UPDATE users SET rating = COALESCE((
+			SELECT ROUND(AVG(rating), 1) FROM (
+				SELECT rating FROM trip_ratings
+				WHERE ratee_id = $1 AND rater_type = $2
+				ORDER BY created_at DESC
+				LIMIT $3
+			) latest
+		), rating), updated_at = $4
+		WHERE id = $1
+	`
+	_, err := r.db.ExecContext(ctx, query, id, models.RaterDriver, window, time.Now())
+	return err
+}
+
+func (r *userRepository) UpdateSafetyPreferences(ctx context.Context, user *models.User) error {
+	user.UpdatedAt = time.Now()
+	query := `
+		UPDATE users
+		SET safety_mode = $1, preferred_driver_gender = $2, updated_at = $3
+		WHERE id = $4
+	`
+	_, err := r.db.ExecContext(ctx, query, user.SafetyMode, user.PreferredDriverGender, user.UpdatedAt, user.ID)
 	return err
 }
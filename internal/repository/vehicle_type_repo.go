@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+type VehicleTypeRepository interface {
+	Create(ctx context.Context, v *models.VehicleTypeDefinition) error
+	GetByCode(ctx context.Context, code string) (*models.VehicleTypeDefinition, error)
+	// GetAll returns every vehicle type, including inactive ones, in display order
+	GetAll(ctx context.Context) ([]*models.VehicleTypeDefinition, error)
+	Update(ctx context.Context, v *models.VehicleTypeDefinition) error
+}
+
+type vehicleTypeRepository struct {
+	db *sqlx.DB
+}
+
+func NewVehicleTypeRepository(db *sqlx.DB) VehicleTypeRepository {
+	return &vehicleTypeRepository{db: db}
+}
+
+func (r *vehicleTypeRepository) Create(ctx context.Context, v *models.VehicleTypeDefinition) error {
+	now := time.Now()
+	v.CreatedAt = now
+	v.UpdatedAt = now
+
+	query := `
+		INSERT INTO vehicle_types (code, label, capacity, icon, active, regions, sort_order,
+			helmet_required, base_fare, per_km_rate, per_min_rate, min_fare, cancellation_fee,
+			waiting_per_min, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		v.Code, v.Label, v.Capacity, v.Icon, v.Active, v.Regions, v.SortOrder, v.HelmetRequired,
+		v.BaseFare, v.PerKmRate, v.PerMinRate, v.MinFare, v.CancellationFee, v.WaitingPerMin,
+		v.CreatedAt, v.UpdatedAt)
+	return err
+}
+
+func (r *vehicleTypeRepository) GetByCode(ctx context.Context, code string) (*models.VehicleTypeDefinition, error) {
+	var v models.VehicleTypeDefinition
+	query := `SELECT * FROM vehicle_types WHERE code = $1`
+	err := r.db.GetContext(ctx, &v, query, code)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &v, err
+}
+
+func (r *vehicleTypeRepository) GetAll(ctx context.Context) ([]*models.VehicleTypeDefinition, error) {
+	var types []*models.VehicleTypeDefinition
+	query := `SELECT * FROM vehicle_types ORDER BY sort_order, code`
+	err := r.db.SelectContext(ctx, &types, query)
+	return types, err
+}
+
+func (r *vehicleTypeRepository) Update(ctx context.Context, v *models.VehicleTypeDefinition) error {
+	v.UpdatedAt = time.Now()
+	query := `
+		UPDATE vehicle_types
+		SET label = $1, capacity = $2, icon = $3, active = $4, regions = $5, sort_order = $6,
+			helmet_required = $7, base_fare = $8, per_km_rate = $9, per_min_rate = $10, min_fare = $11,
+			cancellation_fee = $12, waiting_per_min = $13, updated_at = $14
+		WHERE code = $15
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		v.Label, v.Capacity, v.Icon, v.Active, v.Regions, v.SortOrder, v.HelmetRequired,
+		v.BaseFare, v.PerKmRate, v.PerMinRate, v.MinFare, v.CancellationFee, v.WaitingPerMin,
+		v.UpdatedAt, v.Code)
+	return err
+}
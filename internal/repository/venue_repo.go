@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type VenueRepository interface {
+	Create(ctx context.Context, venue *models.Venue) error
+	GetByID(ctx context.Context, id string) (*models.Venue, error)
+	List(ctx context.Context) ([]*models.Venue, error)
+	GetActive(ctx context.Context) ([]*models.Venue, error)
+	SetActive(ctx context.Context, id string, active bool) error
+}
+
+type venueRepository struct {
+	db *sqlx.DB
+}
+
+func NewVenueRepository(db *sqlx.DB) VenueRepository {
+	return &venueRepository{db: db}
+}
+
+func (r *venueRepository) Create(ctx context.Context, venue *models.Venue) error {
+	if venue.ID == "" {
+		venue.ID = uuid.New().String()
+	}
+	now := time.Now()
+	venue.CreatedAt = now
+	venue.UpdatedAt = now
+	venue.Active = true
+
+	query := `
+		INSERT INTO venues (id, name, polygon, active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		venue.ID, venue.Name, venue.Polygon, venue.Active, venue.CreatedAt, venue.UpdatedAt)
+	return err
+}
+
+func (r *venueRepository) GetByID(ctx context.Context, id string) (*models.Venue, error) {
+	var venue models.Venue
+	query := `SELECT * FROM venues WHERE id = $1`
+	err := r.db.GetContext(ctx, &venue, query, id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &venue, err
+}
+
+func (r *venueRepository) List(ctx context.Context) ([]*models.Venue, error) {
+	var venues []*models.Venue
+	query := `SELECT * FROM venues ORDER BY name`
+	err := r.db.SelectContext(ctx, &venues, query)
+	return venues, err
+}
+
+func (r *venueRepository) GetActive(ctx context.Context) ([]*models.Venue, error) {
+	var venues []*models.Venue
+	query := `SELECT * FROM venues WHERE active = TRUE`
+	err := r.db.SelectContext(ctx, &venues, query)
+	return venues, err
+}
+
+func (r *venueRepository) SetActive(ctx context.Context, id string, active bool) error {
+	query := `UPDATE venues SET active = $1, updated_at = $2 WHERE id = $3`
+	_, err := r.db.ExecContext(ctx, query, active, time.Now(), id)
+	return err
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type WaitingContentRepository interface {
+	Create(ctx context.Context, content *models.WaitingContent) error
+	GetByID(ctx context.Context, id string) (*models.WaitingContent, error)
+	List(ctx context.Context) ([]*models.WaitingContent, error)
+	// ListLive returns content shown at the given time in the region, including
+	// content for every region, highest priority first
+	ListLive(ctx context.Context, regionCode string, at time.Time) ([]*models.WaitingContent, error)
+	SetActive(ctx context.Context, id string, active bool) error
+}
+
+type waitingContentRepository struct {
+	db *sqlx.DB
+}
+
+func NewWaitingContentRepository(db *sqlx.DB) WaitingContentRepository {
+	return &waitingContentRepository{db: db}
+}
+
+func (r *waitingContentRepository) Create(ctx context.Context, content *models.WaitingContent) error {
+	if content.ID == "" {
+		content.ID = uuid.New().String()
+	}
+	now := time.Now()
+	content.Active = true
+	content.CreatedAt = now
+	content.UpdatedAt = now
+
+	query := `
+		INSERT INTO waiting_content (id, kind, region_code, title, body, image_url, action_url, promo_code,
+			priority, starts_at, ends_at, active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		content.ID, content.Kind, content.RegionCode, content.Title, content.Body, content.ImageURL,
+		content.ActionURL, content.PromoCode, content.Priority, content.StartsAt, content.EndsAt,
+		content.Active, content.CreatedAt, content.UpdatedAt)
+	return err
+}
+
+func (r *waitingContentRepository) GetByID(ctx context.Context, id string) (*models.WaitingContent, error) {
+	var content models.WaitingContent
+	query := `SELECT * FROM waiting_content WHERE id = $1`
+	err := r.db.GetContext(ctx, &content, query, id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &content, err
+}
+
+func (r *waitingContentRepository) List(ctx context.Context) ([]*models.WaitingContent, error) {
+	var contents []*models.WaitingContent
+	query := `SELECT * FROM waiting_content ORDER BY created_at DESC`
+	err := r.db.SelectContext(ctx, &contents, query)
+	return contents, err
+}
+
+func (r *waitingContentRepository) ListLive(ctx context.Context, regionCode string, at time.Time) ([]*models.WaitingContent, error) {
+	var contents []*models.WaitingContent
+	query := `
+		SELECT * FROM waiting_content
+		WHERE active = TRUE AND (region_code IS NULL OR region_code = $1)
+			AND (starts_at IS NULL OR starts_at <= $2) AND (ends_at IS NULL OR ends_at > $2)
+		ORDER BY priority DESC, created_at DESC
+	`
+	err := r.db.SelectContext(ctx, &contents, query, regionCode, at)
+	return contents, err
+}
+
+func (r *waitingContentRepository) SetActive(ctx context.Context, id string, active bool) error {
+	query := `UPDATE waiting_content SET active = $2, updated_at = NOW() WHERE id = $1`
+	_, err := r.db.ExecContext(ctx, query, id, active)
+	return err
+}
@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type WalletRepository interface {
+	// GetByUserID returns the user's wallet, or nil if they never topped up
+	GetByUserID(ctx context.Context, userID string) (*models.Wallet, error)
+	// Credit adds to the wallet, opening it on the first top-up, and records the
+	// transaction. Returns false if a transaction with the same idempotency key or
+	// the same payment and type was already recorded.
+	Credit(ctx context.Context, txn *models.WalletTransaction) (bool, error)
+	// Debit applies the transaction's (negative) amount to the wallet and records
+	// it in one statement. Returns false, changing nothing, if the balance doesn't
+	// cover it.
+	Debit(ctx context.Context, txn *models.WalletTransaction) (bool, error)
+	GetTransactionByPayment(ctx context.Context, paymentID, txnType string) (*models.WalletTransaction, error)
+	GetTransactionByIdempotencyKey(ctx context.Context, key string) (*models.WalletTransaction, error)
+	GetTransactions(ctx context.Context, userID string, limit int) ([]*models.WalletTransaction, error)
+}
+
+type walletRepository struct {
+	db *sqlx.DB
+}
+
+func NewWalletRepository(db *sqlx.DB) WalletRepository {
+	return &walletRepository{db: db}
+}
+
+func (r *walletRepository) GetByUserID(ctx context.Context, userID string) (*models.Wallet, error) {
+	var wallet models.Wallet
+	query := `SELECT * FROM wallets WHERE user_id = $1`
+	err := r.db.GetContext(ctx, &wallet, query, userID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &wallet, err
+}
+
+func (r *walletRepository) Credit(ctx context.Context, txn *models.WalletTransaction) (bool, error) {
+	if txn.ID == "" {
+		txn.ID = uuid.New().String()
+	}
+	txn.CreatedAt = time.Now()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO wallets (user_id, balance, created_at, updated_at)
+		VALUES ($1, $2, $3, $3)
+		ON CONFLICT (user_id) DO UPDATE SET balance = wallets.balance + EXCLUDED.balance, updated_at = EXCLUDED.updated_at
+		RETURNING balance
+	`
+	if err := tx.GetContext(ctx, &txn.BalanceAfter, query, txn.UserID, txn.Amount, txn.CreatedAt); err != nil {
+		return false, err
+	}
+
+	query = `
+		INSERT INTO wallet_transactions (id, user_id, txn_type, amount, balance_after, payment_id,
+			psp_transaction_id, idempotency_key, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT DO NOTHING
+	`
+	result, err := tx.ExecContext(ctx, query,
+		txn.ID, txn.UserID, txn.TxnType, txn.Amount, txn.BalanceAfter, txn.PaymentID,
+		txn.PSPTransactionID, txn.IdempotencyKey, txn.CreatedAt)
+	if err != nil {
+		return false, err
+	}
+	// A duplicate leaves the balance as it was
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+func (r *walletRepository) Debit(ctx context.Context, txn *models.WalletTransaction) (bool, error) {
+	if txn.ID == "" {
+		txn.ID = uuid.New().String()
+	}
+	txn.CreatedAt = time.Now()
+	return debitWallet(ctx, r.db, txn)
+}
+
+// debitWallet applies a negative transaction, returning false without writing
+// anything when the balance doesn't cover it. Other repositories call it inside
+// their own transactions.
+func debitWallet(ctx context.Context, q sqlx.QueryerContext, txn *models.WalletTransaction) (bool, error) {
+	query := `
+		WITH debited AS (
+			UPDATE wallets SET balance = balance + $3, updated_at = $6
+			WHERE user_id = $2 AND balance + $3 >= 0
+			RETURNING balance
+		)
+		INSERT INTO wallet_transactions (id, user_id, txn_type, amount, balance_after, payment_id, created_at)
+		SELECT $1, $2, $4, $3, balance, $5, $6 FROM debited
+		RETURNING balance_after
+	`
+	err := sqlx.GetContext(ctx, q, &txn.BalanceAfter, query,
+		txn.ID, txn.UserID, txn.Amount, txn.TxnType, txn.PaymentID, txn.CreatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (r *walletRepository) GetTransactionByPayment(ctx context.Context, paymentID, txnType string) (*models.WalletTransaction, error) {
+	var txn models.WalletTransaction
+	query := `SELECT * FROM wallet_transactions WHERE payment_id = $1 AND txn_type = $2`
+	err := r.db.GetContext(ctx, &txn, query, paymentID, txnType)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &txn, err
+}
+
+func (r *walletRepository) GetTransactionByIdempotencyKey(ctx context.Context, key string) (*models.WalletTransaction, error) {
+	var txn models.WalletTransaction
+	query := `SELECT * FROM wallet_transactions WHERE idempotency_key = $1`
+	err := r.db.GetContext(ctx, &txn, query, key)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &txn, err
+}
+
+func (r *walletRepository) GetTransactions(ctx context.Context, userID string, limit int) ([]*models.WalletTransaction, error) {
+	var txns []*models.WalletTransaction
+	query := `SELECT * FROM wallet_transactions WHERE user_id = $1 ORDER BY created_at DESC LIMIT $2`
+	err := r.db.SelectContext(ctx, &txns, query, userID, limit)
+	return txns, err
+}
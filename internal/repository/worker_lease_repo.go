@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// WorkerLeaseRepository decides which instance runs each background job. Leases
+// live in Postgres rather than Redis so they keep working in degraded mode.
+type WorkerLeaseRepository interface {
+	// Acquire takes or renews the lease on name for holder until ttl from now. It
+	// fails while another holder's lease is unexpired.
+	Acquire(ctx context.Context, name, holder string, ttl time.Duration) (bool, error)
+	// Release gives up holder's lease on name so another instance can take it at once
+	Release(ctx context.Context, name, holder string) error
+}
+
+type workerLeaseRepository struct {
+	db *sqlx.DB
+}
+
+func NewWorkerLeaseRepository(db *sqlx.DB) WorkerLeaseRepository {
+	return &workerLeaseRepository{db: db}
+}
+
+// Expiry uses the database clock so instances with skewed clocks agree on it
+func (r *workerLeaseRepository) Acquire(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	query := `
+		INSERT INTO worker_leases (name, holder, acquired_at, expires_at)
+		VALUES ($1, $2, NOW(), NOW() + make_interval(secs => $3))
+		ON CONFLICT (name) DO UPDATE
+		SET holder = EXCLUDED.holder, expires_at = EXCLUDED.expires_at,
+			acquired_at = CASE WHEN worker_leases.holder = EXCLUDED.holder
+				THEN worker_leases.acquired_at ELSE EXCLUDED.acquired_at END
+		WHERE worker_leases.holder = EXCLUDED.holder OR worker_leases.expires_at <= NOW()
+	`
+	result, err := r.db.ExecContext(ctx, query, name, holder, ttl.Seconds())
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+func (r *workerLeaseRepository) Release(ctx context.Context, name, holder string) error {
+	query := `DELETE FROM worker_leases WHERE name = $1 AND holder = $2`
+	_, err := r.db.ExecContext(ctx, query, name, holder)
+	return err
+}
@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	apperrors "github.com/aditya/go-comet/internal/errors"
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+type AdminService interface {
+	ReplayRide(ctx context.Context, rideID string, at time.Time) (*models.RideReplay, error)
+	SearchRides(ctx context.Context, filter *models.RideSearchFilter) ([]*models.Ride, error)
+	ListMileageFlags(ctx context.Context, status string, limit int) ([]*models.Trip, error)
+	ReviewMileage(ctx context.Context, tripID string, req *models.ReviewMileageRequest) (*models.Trip, error)
+	VerifyDriver(ctx context.Context, driverID string) (*models.Driver, error)
+	// ImportDrivers creates accounts for validated import rows in batches. Rows
+	// whose phone is already registered, or repeats an earlier row, are reported
+	// as errors.
+	ImportDrivers(ctx context.Context, rows []*models.DriverImportRow) (*models.DriverImportResult, error)
+}
+
+const (
+	defaultSearchLimit = 50
+	maxSearchLimit     = 200
+)
+
+// driverImportBatchSize is how many drivers each insert of a bulk import carries
+const driverImportBatchSize = 200
+
+type adminService struct {
+	auditRepo  repository.AuditRepository
+	rideRepo   repository.RideRepository
+	tripRepo   repository.TripRepository
+	driverRepo repository.DriverRepository
+}
+
+func NewAdminService(
+	auditRepo repository.AuditRepository,
+	rideRepo repository.RideRepository,
+	tripRepo repository.TripRepository,
+	driverRepo repository.DriverRepository,
+) AdminService {
+	return &adminService{
+		auditRepo:  auditRepo,
+		rideRepo:   rideRepo,
+		tripRepo:   tripRepo,
+		driverRepo: driverRepo,
+	}
+}
+
+// ReplayRide rebuilds ride, trip and offer state as of the given moment by folding
+// the audit log: the last snapshot recorded for each row at or before `at` wins.
+func (s *adminService) ReplayRide(ctx context.Context, rideID string, at time.Time) (*models.RideReplay, error) {
+	entries, err := s.auditRepo.GetByRideIDUntil(ctx, rideID, at)
+	if err != nil {
+		return nil, err
+	}
+
+	replay := &models.RideReplay{
+		At:       at,
+		Offers:   []*models.RideOffer{},
+		Timeline: entries,
+	}
+	offers := make(map[string]*models.RideOffer)
+	var offerOrder []string
+
+	for _, entry := range entries {
+		switch entry.EntityType {
+		case models.AuditEntityRide:
+			var ride models.Ride
+			if err := entry.DecodeSnapshot(&ride); err != nil {
+				return nil, err
+			}
+			replay.Ride = &ride
+		case models.AuditEntityTrip:
+			var trip models.Trip
+			if err := entry.DecodeSnapshot(&trip); err != nil {
+				return nil, err
+			}
+			replay.Trip = &trip
+		case models.AuditEntityOffer:
+			var offer models.RideOffer
+			if err := entry.DecodeSnapshot(&offer); err != nil {
+				return nil, err
+			}
+			if _, seen := offers[offer.ID]; !seen {
+				offerOrder = append(offerOrder, offer.ID)
+			}
+			offers[offer.ID] = &offer
+		}
+	}
+
+	if replay.Ride == nil {
+		return nil, apperrors.NotFound("ride state at requested time")
+	}
+
+	for _, id := range offerOrder {
+		replay.Offers = append(replay.Offers, offers[id])
+	}
+
+	return replay, nil
+}
+
+func (s *adminService) SearchRides(ctx context.Context, filter *models.RideSearchFilter) ([]*models.Ride, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultSearchLimit
+	}
+	if filter.Limit > maxSearchLimit {
+		filter.Limit = maxSearchLimit
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, apperrors.BadRequest("from must be before to")
+	}
+
+	rides, err := s.rideRepo.Search(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if rides == nil {
+		rides = []*models.Ride{}
+	}
+	return rides, nil
+}
+
+// ListMileageFlags returns trips in the given mileage audit status, flagged by default
+func (s *adminService) ListMileageFlags(ctx context.Context, status string, limit int) ([]*models.Trip, error) {
+	if status == "" {
+		status = models.MileageStatusFlagged
+	}
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+
+	trips, err := s.tripRepo.GetByMileageStatus(ctx, status, limit)
+	if err != nil {
+		return nil, err
+	}
+	if trips == nil {
+		trips = []*models.Trip{}
+	}
+	return trips, nil
+}
+
+func (s *adminService) ReviewMileage(ctx context.Context, tripID string, req *models.ReviewMileageRequest) (*models.Trip, error) {
+	trip, err := s.tripRepo.GetByID(ctx, tripID)
+	if err != nil {
+		return nil, err
+	}
+	if trip == nil {
+		return nil, apperrors.NotFound("trip")
+	}
+	if trip.MileageStatus == nil || *trip.MileageStatus != models.MileageStatusFlagged {
+		return nil, apperrors.BadRequest("trip mileage is not flagged for review")
+	}
+
+	trip.MileageStatus = &req.Decision
+	if req.Note != "" {
+		trip.MileageReviewNote = &req.Note
+	}
+
+	if err := s.tripRepo.UpdateMileageAudit(ctx, trip); err != nil {
+		return nil, err
+	}
+	return trip, nil
+}
+
+// VerifyDriver marks a driver's identity and documents as verified. Verification
+// starts the tenure clock used by rider safety mode; re-verifying keeps the original date.
+func (s *adminService) VerifyDriver(ctx context.Context, driverID string) (*models.Driver, error) {
+	driver, err := s.driverRepo.GetByID(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+	if driver == nil {
+		return nil, apperrors.NotFound("driver")
+	}
+	if driver.VerifiedAt != nil {
+		return driver, nil
+	}
+
+	now := time.Now()
+	if err := s.driverRepo.MarkVerified(ctx, driverID, now); err != nil {
+		return nil, err
+	}
+	driver.VerifiedAt = &now
+	return driver, nil
+}
+
+func (s *adminService) ImportDrivers(ctx context.Context, rows []*models.DriverImportRow) (*models.DriverImportResult, error) {
+	result := &models.DriverImportResult{
+		Total:   len(rows),
+		Created: []*models.DriverImportCreated{},
+		Errors:  []*models.DriverImportRowError{},
+	}
+
+	seen := make(map[string]bool)
+	var pending []*models.DriverImportRow
+	for _, row := range rows {
+		if seen[row.Driver.Phone] {
+			result.Errors = append(result.Errors, &models.DriverImportRowError{
+				Row: row.Row, Phone: row.Driver.Phone, Error: "phone appears earlier in the import",
+			})
+			continue
+		}
+		seen[row.Driver.Phone] = true
+		pending = append(pending, row)
+	}
+
+	for start := 0; start < len(pending); start += driverImportBatchSize {
+		batch := pending[start:min(start+driverImportBatchSize, len(pending))]
+		drivers := make([]*models.Driver, len(batch))
+		for i, row := range batch {
+			drivers[i] = newDriver(&row.Driver)
+		}
+
+		created, err := s.driverRepo.CreateBatch(ctx, drivers)
+		if err != nil {
+			return nil, err
+		}
+		for i, row := range batch {
+			if !created[row.Driver.Phone] {
+				result.Errors = append(result.Errors, &models.DriverImportRowError{
+					Row: row.Row, Phone: row.Driver.Phone, Error: "driver with this phone already exists",
+				})
+				continue
+			}
+			result.Created = append(result.Created, &models.DriverImportCreated{
+				Row: row.Row, ID: drivers[i].ID, Phone: row.Driver.Phone,
+			})
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository/memory"
+)
+
+func TestReplayRideKeepsRiderNote(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	as := NewAdminService(memory.NewAuditRepository(store), memory.NewRideRepository(store),
+		memory.NewTripRepository(store), memory.NewDriverRepository(store))
+
+	// Snapshots are row_to_json output, keyed by column name
+	snapshot, err := json.Marshal(map[string]interface{}{
+		"id":          "ride-1",
+		"user_id":     "user-1",
+		"status":      models.RideStatusMatching,
+		"rider_note":  "gate code 1234",
+		"pickup_lat":  12.97,
+		"created_at":  "2026-10-16T09:30:00.123456+00:00",
+		"unknown_col": "ignored",
+	})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	recordedAt := time.Now().Add(-time.Minute)
+	store.RecordAudit(&models.AuditEntry{
+		EntityType: models.AuditEntityRide,
+		EntityID:   "ride-1",
+		RideID:     "ride-1",
+		Operation:  "INSERT",
+		Snapshot:   snapshot,
+		RecordedAt: recordedAt,
+	})
+
+	replay, err := as.ReplayRide(ctx, "ride-1", time.Now())
+	if err != nil {
+		t.Fatalf("ReplayRide: %v", err)
+	}
+	if replay.Ride.RiderNote == nil || *replay.Ride.RiderNote != "gate code 1234" {
+		t.Errorf("rider note = %v, want %q", replay.Ride.RiderNote, "gate code 1234")
+	}
+	if replay.Ride.Status != models.RideStatusMatching || replay.Ride.PickupLat != 12.97 {
+		t.Errorf("replayed ride = %+v, want matching at pickup lat 12.97", replay.Ride)
+	}
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	apperrors "github.com/aditya/go-comet/internal/errors"
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+	"github.com/aditya/go-comet/internal/storage"
+)
+
+// backupStaleAfter is how long a backup may stay processing before it is assumed
+// abandoned (e.g. the instance restarted) and taken again
+const backupStaleAfter = time.Hour
+
+// BackupService takes logical backups of the core tables to object storage for
+// deployments without managed database backups
+type BackupService interface {
+	// RequestBackup queues a backup, or returns the one already queued or running
+	RequestBackup(ctx context.Context) (*models.Backup, error)
+	// GetBackup returns a backup, with a link to its manifest once ready
+	GetBackup(ctx context.Context, id string) (*models.Backup, error)
+	ListBackups(ctx context.Context, limit int) ([]*models.Backup, error)
+	// ProcessPending takes queued backups and returns how many succeeded
+	ProcessPending(ctx context.Context) (int, error)
+}
+
+type backupService struct {
+	backupRepo repository.BackupRepository
+	store      storage.ObjectStore
+	urlTTL     time.Duration
+}
+
+// NewBackupService creates the backup service. store may be nil when object
+// storage is not configured, in which case backups are unavailable.
+func NewBackupService(backupRepo repository.BackupRepository, store storage.ObjectStore, urlTTL time.Duration) BackupService {
+	return &backupService{
+		backupRepo: backupRepo,
+		store:      store,
+		urlTTL:     urlTTL,
+	}
+}
+
+func (s *backupService) RequestBackup(ctx context.Context) (*models.Backup, error) {
+	if s.store == nil {
+		return nil, apperrors.ServiceUnavailable("backups_unavailable", "backups need object storage")
+	}
+
+	latest, err := s.backupRepo.GetLatest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if latest != nil && (latest.Status == models.BackupStatusPending || latest.Status == models.BackupStatusProcessing) {
+		return latest, nil
+	}
+
+	backup := &models.Backup{}
+	if err := s.backupRepo.Create(ctx, backup); err != nil {
+		return nil, err
+	}
+	return backup, nil
+}
+
+func (s *backupService) GetBackup(ctx context.Context, id string) (*models.Backup, error) {
+	backup, err := s.backupRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if backup == nil {
+		return nil, apperrors.NotFound("backup")
+	}
+
+	if backup.Status == models.BackupStatusReady && s.store != nil {
+		presigned, err := s.store.PresignGet(ctx, *backup.ManifestKey, s.urlTTL)
+		if err != nil {
+			return nil, err
+		}
+		backup.DownloadURL = &presigned.URL
+		backup.DownloadExpiresAt = &presigned.ExpiresAt
+	}
+	return backup, nil
+}
+
+func (s *backupService) ListBackups(ctx context.Context, limit int) ([]*models.Backup, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	return s.backupRepo.List(ctx, limit)
+}
+
+func (s *backupService) ProcessPending(ctx context.Context) (int, error) {
+	if s.store == nil {
+		return 0, nil
+	}
+	// One at a time; each backup reads every core table
+	backups, err := s.backupRepo.ClaimPending(ctx, 1, time.Now().Add(-backupStaleAfter))
+	if err != nil {
+		return 0, err
+	}
+
+	taken := 0
+	for _, backup := range backups {
+		if err := s.take(ctx, backup); err != nil {
+			log.Printf("failed to take backup %s: %v", backup.ID, err)
+			if err := s.backupRepo.MarkFailed(ctx, backup.ID, err.Error()); err != nil {
+				log.Printf("failed to mark backup %s failed: %v", backup.ID, err)
+			}
+			continue
+		}
+		taken++
+	}
+	return taken, nil
+}
+
+// backupFile is one table's gzipped rows as they are dumped
+type backupFile struct {
+	buf  bytes.Buffer
+	gz   *gzip.Writer
+	rows int
+}
+
+func (s *backupService) take(ctx context.Context, backup *models.Backup) error {
+	files := make(map[string]*backupFile, len(models.BackupTables))
+	for _, table := range models.BackupTables {
+		f := &backupFile{}
+		f.gz = gzip.NewWriter(&f.buf)
+		files[table] = f
+	}
+
+	snapshotAt, err := s.backupRepo.Dump(ctx, models.BackupTables, func(table string, row []byte) error {
+		f := files[table]
+		f.rows++
+		if _, err := f.gz.Write(row); err != nil {
+			return err
+		}
+		_, err := f.gz.Write([]byte("\n"))
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	manifest := &models.BackupManifest{
+		BackupID:   backup.ID,
+		Format:     models.BackupFormat,
+		SnapshotAt: snapshotAt,
+		CreatedAt:  time.Now(),
+	}
+	rowCount := 0
+	for _, table := range models.BackupTables {
+		f := files[table]
+		if err := f.gz.Close(); err != nil {
+			return err
+		}
+		data := f.buf.Bytes()
+		sum := sha256.Sum256(data)
+
+		key := fmt.Sprintf("backups/%s/%s.jsonl.gz", backup.ID, table)
+		if err := s.store.Put(ctx, key, "application/gzip", data); err != nil {
+			return err
+		}
+		manifest.Tables = append(manifest.Tables, &models.BackupManifestTable{
+			Name:      table,
+			ObjectKey: key,
+			Rows:      f.rows,
+			Bytes:     len(data),
+			SHA256:    hex.EncodeToString(sum[:]),
+		})
+		rowCount += f.rows
+	}
+
+	// The manifest goes last so its presence means every table file is in place
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	manifestKey := fmt.Sprintf("backups/%s/manifest.json", backup.ID)
+	if err := s.store.Put(ctx, manifestKey, "application/json", data); err != nil {
+		return err
+	}
+
+	log.Printf("backups: took backup %s of %d rows as of %s", backup.ID, rowCount, snapshotAt.Format(time.RFC3339))
+	return s.backupRepo.MarkReady(ctx, backup.ID, manifestKey, rowCount, snapshotAt)
+}
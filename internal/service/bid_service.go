@@ -0,0 +1,276 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/aditya/go-comet/internal/cache"
+	apperrors "github.com/aditya/go-comet/internal/errors"
+	"github.com/aditya/go-comet/internal/metrics"
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+	"github.com/jmoiron/sqlx"
+)
+
+type BidService interface {
+	CounterOffer(ctx context.Context, driverID, offerID string, req *models.CounterOfferRequest) (*models.RideOfferResponse, error)
+	ListBids(ctx context.Context, rideID string) ([]*models.Bid, error)
+	AcceptBid(ctx context.Context, rideID, offerID string, req *models.AcceptBidRequest) (*models.RideResponse, error)
+	ExpireStaleBids(ctx context.Context) (int, error)
+}
+
+type bidService struct {
+	transactor  repository.Transactor
+	rideRepo    repository.RideRepository
+	offerRepo   repository.RideOfferRepository
+	driverRepo  repository.DriverRepository
+	userRepo    repository.UserRepository
+	driverCache cache.DriverLocationCache
+	// Repairs the driver's cache entries when a write fails after accepting
+	reconciliation ReconciliationService
+	policy         models.BidPolicy
+}
+
+func NewBidService(
+	transactor repository.Transactor,
+	rideRepo repository.RideRepository,
+	offerRepo repository.RideOfferRepository,
+	driverRepo repository.DriverRepository,
+	userRepo repository.UserRepository,
+	driverCache cache.DriverLocationCache,
+	reconciliation ReconciliationService,
+	policy models.BidPolicy,
+) BidService {
+	return &bidService{
+		transactor:     transactor,
+		rideRepo:       rideRepo,
+		offerRepo:      offerRepo,
+		driverRepo:     driverRepo,
+		userRepo:       userRepo,
+		driverCache:    driverCache,
+		reconciliation: reconciliation,
+		policy:         policy,
+	}
+}
+
+// CounterOffer lets a driver answer a bid ride offer with their own fare
+func (s *bidService) CounterOffer(ctx context.Context, driverID, offerID string, req *models.CounterOfferRequest) (*models.RideOfferResponse, error) {
+	offer, err := s.offerRepo.GetByID(ctx, offerID)
+	if err != nil {
+		return nil, err
+	}
+	if offer == nil {
+		return nil, apperrors.NotFound("offer")
+	}
+	if offer.DriverID != driverID {
+		return nil, apperrors.Unauthorized("offer not for this driver")
+	}
+	if offer.Status != models.OfferStatusPending {
+		return nil, apperrors.BadRequest("offer already responded")
+	}
+	if offer.IsExpired() {
+		return nil, apperrors.OfferExpired()
+	}
+
+	ride, err := s.rideRepo.GetByID(ctx, offer.RideID)
+	if err != nil {
+		return nil, err
+	}
+	if ride == nil {
+		return nil, apperrors.NotFound("ride")
+	}
+	if ride.PricingMode != models.PricingModeBid || ride.ProposedFare == nil {
+		return nil, apperrors.BadRequest("ride does not accept counter-offers")
+	}
+	if ride.Status != models.RideStatusMatching {
+		return nil, apperrors.RideAlreadyAssigned()
+	}
+
+	// Counters can't undercut the rider's offer and are capped above it
+	maxFare := math.Round(*ride.ProposedFare*s.policy.MaxCounterRatio*100) / 100
+	if req.Fare < *ride.ProposedFare || req.Fare > maxFare {
+		return nil, apperrors.BadRequest(fmt.Sprintf("counter-offer must be between %.2f and %.2f", *ride.ProposedFare, maxFare))
+	}
+
+	expiresAt := time.Now().Add(s.policy.CounterTTL)
+	countered, err := s.offerRepo.Counter(ctx, offer.ID, req.Fare, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	if !countered {
+		return nil, apperrors.BadRequest("offer already responded")
+	}
+	models.OfferStates.Record(ctx, offer.ID, offer.Status, models.OfferStatusCountered)
+
+	offer.Status = models.OfferStatusCountered
+	offer.OfferedFare = &req.Fare
+	offer.ExpiresAt = expiresAt
+
+	response := offer.ToResponse()
+	response.Ride = ride.ToResponse()
+	return response, nil
+}
+
+// ListBids returns the open counter-offers on a ride, cheapest first
+func (s *bidService) ListBids(ctx context.Context, rideID string) ([]*models.Bid, error) {
+	ride, err := s.rideRepo.GetByID(ctx, rideID)
+	if err != nil {
+		return nil, err
+	}
+	if ride == nil {
+		return nil, apperrors.NotFound("ride")
+	}
+	if ride.PricingMode != models.PricingModeBid {
+		return nil, apperrors.BadRequest("ride is not in bid mode")
+	}
+
+	offers, err := s.offerRepo.GetCounteredByRideID(ctx, rideID)
+	if err != nil {
+		return nil, err
+	}
+
+	bids := make([]*models.Bid, 0, len(offers))
+	for _, offer := range offers {
+		if offer.OfferedFare == nil || offer.CounteredAt == nil {
+			continue
+		}
+		bid := &models.Bid{
+			OfferID:     offer.ID,
+			DriverID:    offer.DriverID,
+			Fare:        *offer.OfferedFare,
+			CounteredAt: *offer.CounteredAt,
+			ExpiresAt:   offer.ExpiresAt,
+		}
+
+		driver, err := s.driverRepo.GetByID(ctx, offer.DriverID)
+		if err == nil && driver != nil {
+			bid.Driver = driver.ToResponse()
+		}
+
+		bids = append(bids, bid)
+	}
+
+	return bids, nil
+}
+
+// AcceptBid assigns the ride to the driver whose counter-offer the rider picked
+func (s *bidService) AcceptBid(ctx context.Context, rideID, offerID string, req *models.AcceptBidRequest) (*models.RideResponse, error) {
+	var (
+		offer *models.RideOffer
+		ride  *models.Ride
+	)
+	now := time.Now()
+	err := s.transactor.InTx(ctx, func(tx *sqlx.Tx) error {
+		var err error
+		offer, err = s.offerRepo.GetByIDForUpdate(ctx, tx, offerID)
+		if err != nil {
+			return err
+		}
+		if offer == nil || offer.RideID != rideID {
+			return apperrors.NotFound("bid")
+		}
+		if offer.Status != models.OfferStatusCountered || offer.OfferedFare == nil {
+			return apperrors.BadRequest("bid is no longer open")
+		}
+		if offer.IsExpired() {
+			return apperrors.OfferExpired()
+		}
+
+		ride, err = s.rideRepo.GetByIDForUpdate(ctx, tx, rideID)
+		if err != nil {
+			return err
+		}
+		if ride == nil {
+			return apperrors.NotFound("ride")
+		}
+		if ride.UserID != req.UserID {
+			return apperrors.Unauthorized("ride does not belong to this user")
+		}
+		if ride.Status != models.RideStatusMatching {
+			return apperrors.RideAlreadyAssigned()
+		}
+
+		// The driver may have taken another ride since countering
+		driver, err := s.driverRepo.GetByIDForUpdate(ctx, tx, offer.DriverID)
+		if err != nil {
+			return err
+		}
+		if driver == nil || driver.Status != models.DriverStatusOnline {
+			return apperrors.Conflict("driver is no longer available")
+		}
+
+		if err := s.offerRepo.SetStatus(ctx, tx, offer.ID, models.OfferStatusAccepted, now); err != nil {
+			return err
+		}
+		if err := s.rideRepo.Assign(ctx, tx, ride.ID, offer.DriverID, models.RideStatusDriverAssigned,
+			offer.OfferedFare, ride.PickupETAMin, now); err != nil {
+			return err
+		}
+		if err := s.driverRepo.SetStatus(ctx, tx, offer.DriverID, models.DriverStatusBusy, now); err != nil {
+			return err
+		}
+
+		// Close out every other offer and bid on this ride
+		return s.offerRepo.ExpireOpen(ctx, tx, ride.ID, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+	models.OfferStates.Record(ctx, offer.ID, offer.Status, models.OfferStatusAccepted)
+	metrics.CounterMap("matching_funnel").Add(models.FunnelStageAccepted, 1)
+	models.RideStates.Record(ctx, ride.ID, ride.Status, models.RideStatusDriverAssigned)
+
+	cacheErr := syncDriverStatus(ctx, s.driverCache, offer.DriverID, models.DriverStatusBusy, now)
+	if s.driverCache != nil {
+		if err := s.driverCache.SetActiveRide(ctx, offer.DriverID, ride.ID); err != nil {
+			cacheErr = err
+		}
+		if err := s.driverCache.RecordMatchTime(ctx, ride.VehicleType, now.Sub(ride.CreatedAt)); err != nil {
+			log.Printf("failed to record match time: %v", err)
+		}
+	}
+	if cacheErr != nil {
+		s.reconciliation.QueueRepair(ctx, offer.DriverID, models.CacheRepairBidAccept)
+	}
+
+	ride.DriverID = &offer.DriverID
+	ride.Status = models.RideStatusDriverAssigned
+	ride.AgreedFare = offer.OfferedFare
+
+	response := ride.ToResponse()
+
+	user, err := s.userRepo.GetByID(ctx, ride.UserID)
+	if err == nil && user != nil {
+		response.User = user.ToResponse()
+	}
+
+	driver, err := s.driverRepo.GetByID(ctx, offer.DriverID)
+	if err == nil && driver != nil {
+		response.Driver = driver.ToResponse()
+	}
+
+	return response, nil
+}
+
+// ExpireStaleBids cancels bid rides where the rider never accepted a counter-offer
+func (s *bidService) ExpireStaleBids(ctx context.Context) (int, error) {
+	rideIDs, err := s.rideRepo.CancelStaleBidRides(ctx, time.Now().Add(-s.policy.Window), "no bid accepted")
+	if err != nil {
+		return 0, err
+	}
+
+	for _, rideID := range rideIDs {
+		models.RideStates.Record(ctx, rideID, models.RideStatusMatching, models.RideStatusCancelled)
+		if err := s.offerRepo.ExpireOldOffers(ctx, rideID); err != nil {
+			log.Printf("failed to expire offers for bid ride %s: %v", rideID, err)
+		}
+	}
+
+	if len(rideIDs) > 0 {
+		log.Printf("bid expiry: cancelled %d rides with no accepted bid", len(rideIDs))
+	}
+	return len(rideIDs), nil
+}
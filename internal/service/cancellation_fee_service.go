@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+// CancellationFeeService bills riders who cancel after the free window or once
+// the driver has arrived. The fee comes out of the wallet when the balance
+// covers it; otherwise it's added to the rider's next trip payment.
+type CancellationFeeService interface {
+	// Charge bills the rider for cancelling the ride, returning nil when the
+	// cancellation is free
+	Charge(ctx context.Context, ride *models.Ride) (*models.CancellationFee, error)
+	// Outstanding returns the rider's fees still to be paid with a trip
+	Outstanding(ctx context.Context, userID string) ([]*models.CancellationFee, error)
+	// Settle marks fees as paid by a trip payment
+	Settle(ctx context.Context, fees []*models.CancellationFee, paymentID string) error
+}
+
+type cancellationFeeService struct {
+	feeRepo       repository.CancellationFeeRepository
+	offerRepo     repository.RideOfferRepository
+	regionService RegionService
+	vehicleTypes  VehicleTypeService
+	freeWindow    time.Duration
+}
+
+func NewCancellationFeeService(
+	feeRepo repository.CancellationFeeRepository,
+	offerRepo repository.RideOfferRepository,
+	regionService RegionService,
+	vehicleTypes VehicleTypeService,
+	freeWindow time.Duration,
+) CancellationFeeService {
+	return &cancellationFeeService{
+		feeRepo:       feeRepo,
+		offerRepo:     offerRepo,
+		regionService: regionService,
+		vehicleTypes:  vehicleTypes,
+		freeWindow:    freeWindow,
+	}
+}
+
+func (s *cancellationFeeService) Charge(ctx context.Context, ride *models.Ride) (*models.CancellationFee, error) {
+	if ride.DriverID == nil {
+		return nil, nil
+	}
+	due, err := s.feeDue(ctx, ride, time.Now())
+	if err != nil || !due {
+		return nil, err
+	}
+
+	amount := fareConfigFor(ctx, s.vehicleTypes, ride.VehicleType).CancellationFee
+	if amount <= 0 {
+		return nil, nil
+	}
+	fee := &models.CancellationFee{
+		RideID:   ride.ID,
+		UserID:   ride.UserID,
+		DriverID: *ride.DriverID,
+		Amount:   amount,
+		Currency: "INR",
+		Status:   models.CancellationFeeOutstanding,
+	}
+
+	// Taken from the wallet with the fee recorded when the balance covers it;
+	// otherwise it's collected with the rider's next trip
+	debit := &models.WalletTransaction{
+		UserID:  ride.UserID,
+		TxnType: models.WalletTxnCancellationFee,
+		Amount:  -amount,
+	}
+	created, err := s.feeRepo.Create(ctx, fee, debit)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		return nil, nil
+	}
+	return fee, nil
+}
+
+// feeDue reports whether cancelling now costs the rider: always once the driver
+// is at the pickup, otherwise only after the free window since they accepted
+func (s *cancellationFeeService) feeDue(ctx context.Context, ride *models.Ride, now time.Time) (bool, error) {
+	switch ride.Status {
+	case models.RideStatusDriverArrived:
+		return true, nil
+	case models.RideStatusDriverAssigned:
+	default:
+		return false, nil
+	}
+
+	offer, err := s.offerRepo.GetByRideAndDriver(ctx, ride.ID, *ride.DriverID)
+	if err != nil {
+		return false, err
+	}
+	if offer == nil || offer.RespondedAt == nil {
+		return false, nil
+	}
+	return now.Sub(*offer.RespondedAt) > s.window(ctx, ride), nil
+}
+
+// window is the ride's region's free cancellation window, or the default
+func (s *cancellationFeeService) window(ctx context.Context, ride *models.Ride) time.Duration {
+	if ride.RegionCode == nil {
+		return s.freeWindow
+	}
+	region, err := s.regionService.GetRegion(ctx, *ride.RegionCode)
+	if err != nil {
+		log.Printf("failed to load region %s for cancellation window: %v", *ride.RegionCode, err)
+		return s.freeWindow
+	}
+	if region == nil || region.Settings.FreeCancellationWindowSecs <= 0 {
+		return s.freeWindow
+	}
+	return time.Duration(region.Settings.FreeCancellationWindowSecs) * time.Second
+}
+
+func (s *cancellationFeeService) Outstanding(ctx context.Context, userID string) ([]*models.CancellationFee, error) {
+	return s.feeRepo.GetOutstandingByUserID(ctx, userID)
+}
+
+func (s *cancellationFeeService) Settle(ctx context.Context, fees []*models.CancellationFee, paymentID string) error {
+	if len(fees) == 0 {
+		return nil
+	}
+	ids := make([]string, 0, len(fees))
+	for _, fee := range fees {
+		ids = append(ids, fee.ID)
+	}
+	return s.feeRepo.Settle(ctx, ids, paymentID)
+}
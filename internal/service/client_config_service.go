@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"log"
+
+	apperrors "github.com/aditya/go-comet/internal/errors"
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/tenant"
+)
+
+type ClientConfigService interface {
+	// GetClientConfig resolves the region by code, or else by coordinates, and
+	// falls back to deployment defaults when neither identifies one
+	GetClientConfig(ctx context.Context, regionCode string, lat, lng *float64) (*models.ClientConfig, error)
+}
+
+type clientConfigService struct {
+	regionService RegionService
+	maintenance   MaintenanceService
+	vehicleTypes  VehicleTypeService
+	defaults      models.ClientDefaults
+}
+
+func NewClientConfigService(regionService RegionService, maintenance MaintenanceService, vehicleTypes VehicleTypeService, defaults models.ClientDefaults) ClientConfigService {
+	return &clientConfigService{
+		regionService: regionService,
+		maintenance:   maintenance,
+		vehicleTypes:  vehicleTypes,
+		defaults:      defaults,
+	}
+}
+
+func (s *clientConfigService) GetClientConfig(ctx context.Context, regionCode string, lat, lng *float64) (*models.ClientConfig, error) {
+	var region *models.Region
+	var err error
+	switch {
+	case regionCode != "":
+		region, err = s.regionService.GetRegion(ctx, regionCode)
+		if err != nil {
+			return nil, err
+		}
+		if region == nil {
+			return nil, apperrors.NotFound("region")
+		}
+	case lat != nil && lng != nil:
+		region, err = s.regionService.Resolve(ctx, *lat, *lng)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	cfg := &models.ClientConfig{
+		Features: make(map[string]bool, len(s.defaults.Features)),
+		Polling: models.ClientPollingConfig{
+			LocationUpdateSeconds: s.defaults.LocationUpdateSeconds,
+			OfferPollSeconds:      s.defaults.OfferPollSeconds,
+			RideStatusPollSeconds: s.defaults.RideStatusPollSeconds,
+		},
+		Map: models.ClientMapConfig{
+			TileURL: s.defaults.MapTileURL,
+			TileKey: s.defaults.MapTileKey,
+		},
+		Cancellation: models.ClientCancelConfig{
+			FreeWindowSeconds: s.defaults.FreeCancellationWindowSecs,
+		},
+	}
+	for name, enabled := range s.defaults.Features {
+		cfg.Features[name] = enabled
+	}
+	if t := tenant.FromContext(ctx); t != nil {
+		cfg.Tenant = t.Code
+		cfg.Branding = &t.Branding
+	}
+
+	// The app keeps working without the banner if the lookup fails
+	notice, err := s.maintenance.Notice(ctx)
+	if err != nil {
+		log.Printf("failed to load maintenance notice: %v", err)
+	}
+	cfg.Maintenance = notice
+
+	if err := s.addVehicleTypes(ctx, cfg, region); err != nil {
+		return nil, err
+	}
+
+	if region == nil {
+		return cfg, nil
+	}
+
+	settings := region.Settings
+	cfg.Region = &region.Code
+	cfg.Features[models.FeatureSelfieCheck] = settings.SelfieCheckRequired
+	cfg.Features[models.FeatureSafetyGender] = settings.SafetyGenderFilterAllowed
+	for name, enabled := range settings.FeatureFlags {
+		cfg.Features[name] = enabled
+	}
+	if settings.FreeCancellationWindowSecs > 0 {
+		cfg.Cancellation.FreeWindowSeconds = settings.FreeCancellationWindowSecs
+	}
+
+	return cfg, nil
+}
+
+// addVehicleTypes lists the registered types bookable in the region, or
+// anywhere when there is none, with what the app needs to display them
+func (s *clientConfigService) addVehicleTypes(ctx context.Context, cfg *models.ClientConfig, region *models.Region) error {
+	regionCode := ""
+	if region != nil {
+		regionCode = region.Code
+	}
+	active, err := s.vehicleTypes.Active(ctx, regionCode)
+	if err != nil {
+		return err
+	}
+
+	cfg.VehicleTypes = make([]string, 0, len(active))
+	cfg.VehicleTypeDetails = make([]*models.ClientVehicleType, 0, len(active))
+	for _, vt := range active {
+		if region != nil && !region.Settings.OffersVehicleType(vt.Code) {
+			continue
+		}
+		cfg.VehicleTypes = append(cfg.VehicleTypes, vt.Code)
+		cfg.VehicleTypeDetails = append(cfg.VehicleTypeDetails, &models.ClientVehicleType{
+			Code:     vt.Code,
+			Label:    vt.Label,
+			Capacity: vt.Capacity,
+			Icon:     vt.Icon,
+		})
+	}
+	return nil
+}
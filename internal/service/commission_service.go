@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	apperrors "github.com/aditya/go-comet/internal/errors"
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+// CommissionService resolves the share of each fare the platform keeps from a driver
+type CommissionService interface {
+	CreateOverride(ctx context.Context, driverID string, req *models.CreateCommissionOverrideRequest) (*models.CommissionOverride, error)
+	// EndOverride stops an override from applying to payments taken from now on
+	EndOverride(ctx context.Context, driverID, overrideID string) (*models.CommissionOverride, error)
+	GetDriverCommission(ctx context.Context, driverID string) (*models.DriverCommission, error)
+	// RateAt returns the commission percent in effect for the driver at the given time
+	RateAt(ctx context.Context, driverID string, at time.Time) (float64, error)
+}
+
+type commissionService struct {
+	commissionRepo repository.CommissionRepository
+	driverRepo     repository.DriverRepository
+	defaultPercent float64
+}
+
+func NewCommissionService(
+	commissionRepo repository.CommissionRepository,
+	driverRepo repository.DriverRepository,
+	defaultPercent float64,
+) CommissionService {
+	return &commissionService{
+		commissionRepo: commissionRepo,
+		driverRepo:     driverRepo,
+		defaultPercent: defaultPercent,
+	}
+}
+
+func (s *commissionService) CreateOverride(ctx context.Context, driverID string, req *models.CreateCommissionOverrideRequest) (*models.CommissionOverride, error) {
+	driver, err := s.driverRepo.GetByID(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+	if driver == nil {
+		return nil, apperrors.NotFound("driver")
+	}
+
+	override := &models.CommissionOverride{
+		DriverID:          driverID,
+		Kind:              req.Kind,
+		CommissionPercent: req.CommissionPercent,
+		EffectiveFrom:     time.Now(),
+		EffectiveTo:       req.EffectiveTo,
+	}
+	if req.EffectiveFrom != nil {
+		override.EffectiveFrom = *req.EffectiveFrom
+	}
+	if override.EffectiveTo != nil && !override.EffectiveFrom.Before(*override.EffectiveTo) {
+		return nil, apperrors.BadRequest("effective_from must be before effective_to")
+	}
+	if req.Note != "" {
+		override.Note = &req.Note
+	}
+
+	if err := s.commissionRepo.Create(ctx, override); err != nil {
+		return nil, err
+	}
+	return override, nil
+}
+
+func (s *commissionService) EndOverride(ctx context.Context, driverID, overrideID string) (*models.CommissionOverride, error) {
+	override, err := s.commissionRepo.GetByID(ctx, overrideID)
+	if err != nil {
+		return nil, err
+	}
+	if override == nil || override.DriverID != driverID {
+		return nil, apperrors.NotFound("commission override")
+	}
+
+	now := time.Now()
+	if override.EffectiveTo != nil && !override.EffectiveTo.After(now) {
+		return nil, apperrors.BadRequest("commission override has already ended")
+	}
+	// An override that has not started yet is ended before it ever applies
+	end := now
+	if override.EffectiveFrom.After(now) {
+		end = override.EffectiveFrom
+	}
+
+	if err := s.commissionRepo.End(ctx, overrideID, end); err != nil {
+		return nil, err
+	}
+	override.EffectiveTo = &end
+	return override, nil
+}
+
+func (s *commissionService) GetDriverCommission(ctx context.Context, driverID string) (*models.DriverCommission, error) {
+	driver, err := s.driverRepo.GetByID(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+	if driver == nil {
+		return nil, apperrors.NotFound("driver")
+	}
+
+	history, err := s.commissionRepo.GetByDriverID(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+	if history == nil {
+		history = []*models.CommissionOverride{}
+	}
+
+	commission := &models.DriverCommission{
+		DriverID:          driverID,
+		CommissionPercent: s.defaultPercent,
+		DefaultPercent:    s.defaultPercent,
+		History:           history,
+	}
+	// History is newest-first, so the first active entry is the effective one
+	now := time.Now()
+	for _, override := range history {
+		if override.ActiveAt(now) {
+			commission.Override = override
+			commission.CommissionPercent = override.CommissionPercent
+			break
+		}
+	}
+	return commission, nil
+}
+
+func (s *commissionService) RateAt(ctx context.Context, driverID string, at time.Time) (float64, error) {
+	override, err := s.commissionRepo.GetEffective(ctx, driverID, at)
+	if err != nil {
+		return 0, err
+	}
+	if override == nil {
+		return s.defaultPercent, nil
+	}
+	return override.CommissionPercent, nil
+}
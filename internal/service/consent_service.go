@@ -0,0 +1,200 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	apperrors "github.com/aditya/go-comet/internal/errors"
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+// ConsentService tracks which versions of the terms, privacy policy and regional
+// addenda riders and drivers have accepted. Party types are models.RaterUser and
+// models.RaterDriver.
+type ConsentService interface {
+	// PublishDocument adds a document version; a required one must be accepted
+	// again by everyone it applies to
+	PublishDocument(ctx context.Context, req *models.CreateLegalDocumentRequest) (*models.LegalDocument, error)
+	ListDocuments(ctx context.Context) ([]*models.LegalDocument, error)
+	// Pending returns the current required documents for the party in the region
+	// that they haven't accepted. An empty region only covers documents without one.
+	Pending(ctx context.Context, partyType, partyID, regionCode string) ([]*models.LegalDocument, error)
+	// Check fails with consent_required, listing the pending documents, unless the
+	// party has accepted every current required document for the region
+	Check(ctx context.Context, partyType, partyID, regionCode string) error
+	// Accept records the party's acceptance of document versions from the given IP
+	// and returns the new acceptances; versions accepted before keep their record
+	Accept(ctx context.Context, partyType, partyID string, req *models.AcceptConsentRequest, ip string) ([]*models.ConsentRecord, error)
+	History(ctx context.Context, partyType, partyID string) ([]*models.ConsentRecord, error)
+}
+
+type consentService struct {
+	consentRepo repository.ConsentRepository
+	userRepo    repository.UserRepository
+	driverRepo  repository.DriverRepository
+}
+
+func NewConsentService(consentRepo repository.ConsentRepository, userRepo repository.UserRepository, driverRepo repository.DriverRepository) ConsentService {
+	return &consentService{
+		consentRepo: consentRepo,
+		userRepo:    userRepo,
+		driverRepo:  driverRepo,
+	}
+}
+
+func (s *consentService) PublishDocument(ctx context.Context, req *models.CreateLegalDocumentRequest) (*models.LegalDocument, error) {
+	if req.RegionCode != nil && *req.RegionCode == "" {
+		req.RegionCode = nil
+	}
+	if req.DocType == models.LegalDocAddendum && req.RegionCode == nil {
+		return nil, apperrors.BadRequest("addenda apply to a region; region_code is required")
+	}
+
+	doc := &models.LegalDocument{
+		DocType:    req.DocType,
+		RegionCode: req.RegionCode,
+		Audience:   req.Audience,
+		Version:    req.Version,
+		Title:      req.Title,
+		URL:        req.URL,
+		Required:   req.Required,
+	}
+	if doc.Audience == "" {
+		doc.Audience = models.ConsentAudienceAll
+	}
+
+	created, err := s.consentRepo.CreateDocument(ctx, doc)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		return nil, apperrors.Conflict(fmt.Sprintf("version %s of this document already exists", doc.Version))
+	}
+	return doc, nil
+}
+
+func (s *consentService) ListDocuments(ctx context.Context) ([]*models.LegalDocument, error) {
+	docs, err := s.consentRepo.ListDocuments(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if docs == nil {
+		docs = []*models.LegalDocument{}
+	}
+	return docs, nil
+}
+
+func (s *consentService) Pending(ctx context.Context, partyType, partyID, regionCode string) ([]*models.LegalDocument, error) {
+	current, err := s.consentRepo.CurrentDocuments(ctx)
+	if err != nil {
+		return nil, err
+	}
+	records, err := s.consentRepo.GetRecords(ctx, partyType, partyID)
+	if err != nil {
+		return nil, err
+	}
+	accepted := make(map[string]bool, len(records))
+	for _, record := range records {
+		accepted[record.DocumentID] = true
+	}
+
+	pending := []*models.LegalDocument{}
+	for _, doc := range current {
+		if doc.Required && doc.AppliesTo(partyType, regionCode) && !accepted[doc.ID] {
+			pending = append(pending, doc)
+		}
+	}
+	return pending, nil
+}
+
+func (s *consentService) Check(ctx context.Context, partyType, partyID, regionCode string) error {
+	pending, err := s.Pending(ctx, partyType, partyID, regionCode)
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		return apperrors.ConsentRequired(map[string]interface{}{"pending_consents": pending})
+	}
+	return nil
+}
+
+func (s *consentService) Accept(ctx context.Context, partyType, partyID string, req *models.AcceptConsentRequest, ip string) ([]*models.ConsentRecord, error) {
+	if err := s.checkParty(ctx, partyType, partyID); err != nil {
+		return nil, err
+	}
+
+	docs := make([]*models.LegalDocument, 0, len(req.DocumentIDs))
+	for _, id := range req.DocumentIDs {
+		doc, err := s.consentRepo.GetDocument(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if doc == nil {
+			return nil, apperrors.NotFound("legal document")
+		}
+		if doc.Audience != models.ConsentAudienceAll && doc.Audience != partyType {
+			return nil, apperrors.BadRequest(fmt.Sprintf("%s %s doesn't apply to %ss", doc.DocType, doc.Version, partyType))
+		}
+		docs = append(docs, doc)
+	}
+
+	records := make([]*models.ConsentRecord, 0, len(docs))
+	for _, doc := range docs {
+		record := &models.ConsentRecord{
+			PartyType:  partyType,
+			PartyID:    partyID,
+			DocumentID: doc.ID,
+			DocType:    doc.DocType,
+			RegionCode: doc.RegionCode,
+			Version:    doc.Version,
+			IPAddress:  ip,
+		}
+		created, err := s.consentRepo.Accept(ctx, record)
+		if err != nil {
+			return nil, err
+		}
+		if created {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+func (s *consentService) History(ctx context.Context, partyType, partyID string) ([]*models.ConsentRecord, error) {
+	if err := s.checkParty(ctx, partyType, partyID); err != nil {
+		return nil, err
+	}
+
+	records, err := s.consentRepo.GetRecords(ctx, partyType, partyID)
+	if err != nil {
+		return nil, err
+	}
+	if records == nil {
+		records = []*models.ConsentRecord{}
+	}
+	return records, nil
+}
+
+// checkParty returns not found unless the rider or driver exists
+func (s *consentService) checkParty(ctx context.Context, partyType, partyID string) error {
+	if partyType == models.RaterDriver {
+		driver, err := s.driverRepo.GetByID(ctx, partyID)
+		if err != nil {
+			return err
+		}
+		if driver == nil {
+			return apperrors.NotFound("driver")
+		}
+		return nil
+	}
+
+	user, err := s.userRepo.GetByID(ctx, partyID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return apperrors.NotFound("user")
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/aditya/go-comet/internal/cache"
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+// cooldownLookback bounds how far back a driving streak is traced
+const cooldownLookback = 24 * time.Hour
+
+// CooldownService enforces rest breaks for drivers doing too many back-to-back trips
+type CooldownService interface {
+	// AfterTrip checks the driver's streak once a trip ends and starts a cooldown
+	// when it is over the limit. Returns nil if the driver can keep driving.
+	AfterTrip(ctx context.Context, driverID string) (*models.DriverCooldown, error)
+}
+
+type cooldownService struct {
+	driverRepo  repository.DriverRepository
+	tripRepo    repository.TripRepository
+	driverCache cache.DriverLocationCache
+	policy      models.CooldownPolicy
+}
+
+func NewCooldownService(
+	driverRepo repository.DriverRepository,
+	tripRepo repository.TripRepository,
+	driverCache cache.DriverLocationCache,
+	policy models.CooldownPolicy,
+) CooldownService {
+	return &cooldownService{
+		driverRepo:  driverRepo,
+		tripRepo:    tripRepo,
+		driverCache: driverCache,
+		policy:      policy,
+	}
+}
+
+func (s *cooldownService) AfterTrip(ctx context.Context, driverID string) (*models.DriverCooldown, error) {
+	if !s.policy.Enabled() {
+		return nil, nil
+	}
+
+	driver, err := s.driverRepo.GetByID(ctx, driverID)
+	if err != nil || driver == nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	trips, err := s.tripRepo.GetCompletedSince(ctx, driverID, now.Add(-cooldownLookback))
+	if err != nil {
+		return nil, err
+	}
+
+	// A previous cooldown was a break, so the streak starts after it
+	var since time.Time
+	if driver.CooldownUntil != nil {
+		since = *driver.CooldownUntil
+	}
+	streak := s.policy.CurrentStreak(trips, since)
+	reason := s.policy.Exceeded(streak)
+	if reason == "" {
+		return nil, nil
+	}
+
+	until := now.Add(s.policy.Cooldown)
+	if err := s.driverRepo.SetCooldown(ctx, driverID, until, reason); err != nil {
+		return nil, err
+	}
+	if s.driverCache != nil {
+		if err := s.driverCache.SetCooldown(ctx, driverID, until); err != nil {
+			log.Printf("failed to cache cooldown for driver %s: %v", driverID, err)
+		}
+	}
+
+	log.Printf("cooldown: driver %s resting until %s after %d trips over %s (%s)",
+		driverID, until.Format(time.RFC3339), streak.Trips, streak.Driving.Round(time.Minute), reason)
+	driver.CooldownUntil, driver.CooldownReason = &until, &reason
+	return driver.ActiveCooldown(now), nil
+}
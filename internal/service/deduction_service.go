@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	apperrors "github.com/aditya/go-comet/internal/errors"
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+// recentLedgerEntries is how many ledger entries accompany a driver's schedules
+const recentLedgerEntries = 30
+
+// DeductionService manages recurring charges such as vehicle rent against driver balances
+type DeductionService interface {
+	CreateSchedule(ctx context.Context, driverID string, req *models.CreateDeductionScheduleRequest) (*models.DeductionSchedule, error)
+	CancelSchedule(ctx context.Context, driverID, scheduleID string) (*models.DeductionSchedule, error)
+	GetDriverDeductions(ctx context.Context, driverID string) (*models.DriverDeductions, error)
+	// ChargeDueDeductions posts every period due up to today, catching up missed days
+	ChargeDueDeductions(ctx context.Context) (int, error)
+}
+
+type deductionService struct {
+	deductionRepo repository.DeductionRepository
+	driverRepo    repository.DriverRepository
+}
+
+func NewDeductionService(
+	deductionRepo repository.DeductionRepository,
+	driverRepo repository.DriverRepository,
+) DeductionService {
+	return &deductionService{
+		deductionRepo: deductionRepo,
+		driverRepo:    driverRepo,
+	}
+}
+
+func (s *deductionService) CreateSchedule(ctx context.Context, driverID string, req *models.CreateDeductionScheduleRequest) (*models.DeductionSchedule, error) {
+	driver, err := s.driverRepo.GetByID(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+	if driver == nil {
+		return nil, apperrors.NotFound("driver")
+	}
+
+	today := deductionDate(time.Now())
+	schedule := &models.DeductionSchedule{
+		DriverID:  driverID,
+		Kind:      req.Kind,
+		Amount:    req.Amount,
+		Frequency: req.Frequency,
+		StartDate: today,
+	}
+	if req.StartDate != "" {
+		start, _ := time.Parse("2006-01-02", req.StartDate)
+		// Backdating would bill the driver for periods already settled
+		if start.Before(today) {
+			return nil, apperrors.BadRequest("start_date cannot be in the past")
+		}
+		schedule.StartDate = start
+	}
+	if req.EndDate != "" {
+		end, _ := time.Parse("2006-01-02", req.EndDate)
+		if end.Before(schedule.StartDate) {
+			return nil, apperrors.BadRequest("end_date must not be before start_date")
+		}
+		schedule.EndDate = &end
+	}
+	if req.Description != "" {
+		schedule.Description = &req.Description
+	}
+
+	if err := s.deductionRepo.CreateSchedule(ctx, schedule); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// CancelSchedule stops future charges; periods already posted stay on the ledger
+func (s *deductionService) CancelSchedule(ctx context.Context, driverID, scheduleID string) (*models.DeductionSchedule, error) {
+	schedule, err := s.deductionRepo.GetScheduleByID(ctx, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	if schedule == nil || schedule.DriverID != driverID {
+		return nil, apperrors.NotFound("deduction schedule")
+	}
+	if schedule.Status != models.DeductionStatusActive {
+		return nil, apperrors.BadRequest("deduction schedule is not active")
+	}
+
+	if err := s.deductionRepo.UpdateScheduleStatus(ctx, scheduleID, models.DeductionStatusCancelled); err != nil {
+		return nil, err
+	}
+	schedule.Status = models.DeductionStatusCancelled
+	return schedule, nil
+}
+
+func (s *deductionService) GetDriverDeductions(ctx context.Context, driverID string) (*models.DriverDeductions, error) {
+	driver, err := s.driverRepo.GetByID(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+	if driver == nil {
+		return nil, apperrors.NotFound("driver")
+	}
+
+	schedules, err := s.deductionRepo.GetSchedulesByDriverID(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := s.deductionRepo.GetRecentLedgerEntries(ctx, driverID, recentLedgerEntries)
+	if err != nil {
+		return nil, err
+	}
+
+	deductions := &models.DriverDeductions{
+		Schedules: schedules,
+		Recent:    entries,
+	}
+	if deductions.Schedules == nil {
+		deductions.Schedules = []*models.DeductionSchedule{}
+	}
+	if deductions.Recent == nil {
+		deductions.Recent = []*models.LedgerEntry{}
+	}
+	return deductions, nil
+}
+
+func (s *deductionService) ChargeDueDeductions(ctx context.Context) (int, error) {
+	today := deductionDate(time.Now())
+	schedules, err := s.deductionRepo.GetActiveSchedules(ctx, today)
+	if err != nil {
+		return 0, err
+	}
+
+	charged := 0
+	for _, schedule := range schedules {
+		for _, date := range schedule.DueDates(today) {
+			posted, err := s.deductionRepo.ChargePeriod(ctx, schedule, date)
+			if err != nil {
+				log.Printf("failed to charge deduction schedule %s for %s: %v", schedule.ID, date.Format("2006-01-02"), err)
+				break
+			}
+			schedule.LastChargedOn = &date
+			if posted {
+				charged++
+			}
+		}
+
+		if schedule.Finished() {
+			if err := s.deductionRepo.UpdateScheduleStatus(ctx, schedule.ID, models.DeductionStatusEnded); err != nil {
+				log.Printf("failed to end deduction schedule %s: %v", schedule.ID, err)
+			}
+		}
+	}
+
+	if charged > 0 {
+		log.Printf("deductions: posted %d ledger entries", charged)
+	}
+	return charged, nil
+}
+
+// deductionDate is the calendar day (local time) a deduction period falls on,
+// normalised to midnight UTC to compare with DATE columns
+func deductionDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,222 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"fmt"
+	"math/big"
+	"strings"
+
+	apperrors "github.com/aditya/go-comet/internal/errors"
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+// DeliveryService runs the parcel lifecycle of delivery rides: the driver
+// photographs the parcel at pickup before the trip can start, and photographs the
+// handover and enters the recipient's code before it can end
+type DeliveryService interface {
+	// Create records the parcel for a newly booked delivery ride
+	Create(ctx context.Context, ride *models.Ride, details *models.DeliveryDetails) (*models.Delivery, error)
+	GetDelivery(ctx context.Context, rideID string) (*models.Delivery, error)
+	ConfirmPickup(ctx context.Context, rideID string, req *models.ConfirmPickupRequest) (*models.Delivery, error)
+	ConfirmDropoff(ctx context.Context, rideID string, req *models.ConfirmDropoffRequest) (*models.Delivery, error)
+	// RequirePickup fails if the ride is a delivery whose parcel hasn't been collected
+	RequirePickup(ctx context.Context, ride *models.Ride) error
+	// RequireDropoff fails if the ride is a delivery whose parcel hasn't been handed over
+	RequireDropoff(ctx context.Context, ride *models.Ride) error
+}
+
+type deliveryService struct {
+	deliveryRepo repository.DeliveryRepository
+	rideRepo     repository.RideRepository
+	uploadRepo   repository.UploadRepository
+}
+
+func NewDeliveryService(
+	deliveryRepo repository.DeliveryRepository,
+	rideRepo repository.RideRepository,
+	uploadRepo repository.UploadRepository,
+) DeliveryService {
+	return &deliveryService{
+		deliveryRepo: deliveryRepo,
+		rideRepo:     rideRepo,
+		uploadRepo:   uploadRepo,
+	}
+}
+
+func (s *deliveryService) Create(ctx context.Context, ride *models.Ride, details *models.DeliveryDetails) (*models.Delivery, error) {
+	otp, err := newDeliveryCode()
+	if err != nil {
+		return nil, err
+	}
+
+	delivery := &models.Delivery{
+		RideID:         ride.ID,
+		RecipientName:  strings.TrimSpace(details.RecipientName),
+		RecipientPhone: details.RecipientPhone,
+		PackageSize:    details.PackageSize,
+		WeightKg:       &details.WeightKg,
+		DeclaredValue:  details.DeclaredValue,
+		OTP:            otp,
+	}
+	if description := strings.TrimSpace(details.PackageDescription); description != "" {
+		delivery.PackageDescription = &description
+	}
+
+	if err := s.deliveryRepo.Create(ctx, delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+func (s *deliveryService) GetDelivery(ctx context.Context, rideID string) (*models.Delivery, error) {
+	delivery, err := s.deliveryRepo.GetByRideID(ctx, rideID)
+	if err != nil {
+		return nil, err
+	}
+	if delivery == nil {
+		return nil, apperrors.NotFound("delivery")
+	}
+	return delivery, nil
+}
+
+func (s *deliveryService) ConfirmPickup(ctx context.Context, rideID string, req *models.ConfirmPickupRequest) (*models.Delivery, error) {
+	ride, delivery, err := s.load(ctx, rideID)
+	if err != nil {
+		return nil, err
+	}
+	if ride.Status != models.RideStatusDriverArrived {
+		return nil, apperrors.BadRequest("driver must arrive at pickup before collecting the parcel")
+	}
+	if delivery.Status != models.DeliveryStatusAwaitingPickup {
+		return nil, apperrors.BadRequest("parcel has already been picked up")
+	}
+	if err := s.checkPhoto(ctx, ride, req.UploadID); err != nil {
+		return nil, err
+	}
+
+	if err := s.deliveryRepo.MarkPickedUp(ctx, rideID, req.UploadID); err != nil {
+		return nil, err
+	}
+	return s.reload(ctx, rideID)
+}
+
+func (s *deliveryService) ConfirmDropoff(ctx context.Context, rideID string, req *models.ConfirmDropoffRequest) (*models.Delivery, error) {
+	ride, delivery, err := s.load(ctx, rideID)
+	if err != nil {
+		return nil, err
+	}
+	if ride.Status != models.RideStatusInProgress || delivery.Status != models.DeliveryStatusPickedUp {
+		return nil, apperrors.BadRequest("parcel is not out for delivery")
+	}
+	if delivery.OTPAttempts >= models.DeliveryMaxCodeAttempts {
+		return nil, apperrors.DeliveryCodeLocked()
+	}
+	if req.UploadID == *delivery.PickupPhotoUploadID {
+		return nil, apperrors.BadRequest("dropoff photo must differ from the pickup photo")
+	}
+	if err := s.checkPhoto(ctx, ride, req.UploadID); err != nil {
+		return nil, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(req.OTP), []byte(delivery.OTP)) != 1 {
+		attempts, err := s.deliveryRepo.RecordFailedCode(ctx, rideID)
+		if err != nil {
+			return nil, err
+		}
+		if attempts >= models.DeliveryMaxCodeAttempts {
+			return nil, apperrors.DeliveryCodeLocked()
+		}
+		return nil, apperrors.InvalidDeliveryCode()
+	}
+
+	if err := s.deliveryRepo.MarkDelivered(ctx, rideID, req.UploadID, strings.TrimSpace(req.ReceivedBy)); err != nil {
+		return nil, err
+	}
+	return s.reload(ctx, rideID)
+}
+
+func (s *deliveryService) RequirePickup(ctx context.Context, ride *models.Ride) error {
+	return s.require(ctx, ride, models.DeliveryStatusPickedUp, "confirm the parcel pickup with a photo before starting the trip")
+}
+
+func (s *deliveryService) RequireDropoff(ctx context.Context, ride *models.Ride) error {
+	return s.require(ctx, ride, models.DeliveryStatusDelivered, "confirm the dropoff with a photo and the recipient's code before ending the trip")
+}
+
+func (s *deliveryService) require(ctx context.Context, ride *models.Ride, status, message string) error {
+	if !ride.IsDelivery() {
+		return nil
+	}
+	delivery, err := s.deliveryRepo.GetByRideID(ctx, ride.ID)
+	if err != nil {
+		return err
+	}
+	if delivery == nil {
+		return apperrors.NotFound("delivery")
+	}
+
+	reached := delivery.Status == status ||
+		(status == models.DeliveryStatusPickedUp && delivery.Status == models.DeliveryStatusDelivered)
+	if !reached {
+		return apperrors.DeliveryProofRequired(message)
+	}
+	return nil
+}
+
+func (s *deliveryService) load(ctx context.Context, rideID string) (*models.Ride, *models.Delivery, error) {
+	ride, err := s.rideRepo.GetByID(ctx, rideID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if ride == nil {
+		return nil, nil, apperrors.NotFound("ride")
+	}
+	delivery, err := s.GetDelivery(ctx, rideID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ride, delivery, nil
+}
+
+// reload returns the updated delivery as shown to the driver, without the code
+func (s *deliveryService) reload(ctx context.Context, rideID string) (*models.Delivery, error) {
+	delivery, err := s.GetDelivery(ctx, rideID)
+	if err != nil {
+		return nil, err
+	}
+	delivery.OTP = ""
+	return delivery, nil
+}
+
+// checkPhoto verifies the upload is a completed delivery photo of this ride
+func (s *deliveryService) checkPhoto(ctx context.Context, ride *models.Ride, uploadID string) error {
+	upload, err := s.uploadRepo.GetByID(ctx, uploadID)
+	if err != nil {
+		return err
+	}
+	if upload == nil {
+		return apperrors.NotFound("upload")
+	}
+	if upload.OwnerType != models.UploadOwnerRide || upload.OwnerID != ride.ID {
+		return apperrors.Unauthorized("upload does not belong to the ride")
+	}
+	if upload.Purpose != models.UploadPurposeDeliveryPhoto {
+		return apperrors.BadRequest("upload is not a delivery photo")
+	}
+	if upload.Status != models.UploadStatusVerified {
+		return apperrors.BadRequest("delivery photo upload has not been completed")
+	}
+	return nil
+}
+
+// newDeliveryCode returns a random 4-digit code
+func newDeliveryCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(10000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%04d", n.Int64()), nil
+}
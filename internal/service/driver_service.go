@@ -3,10 +3,13 @@ package service
 import (
 	"context"
 	"log"
+	"math"
 	"time"
 
 	"github.com/aditya/go-comet/internal/cache"
 	apperrors "github.com/aditya/go-comet/internal/errors"
+	"github.com/aditya/go-comet/internal/events"
+	"github.com/aditya/go-comet/internal/metrics"
 	"github.com/aditya/go-comet/internal/models"
 	"github.com/aditya/go-comet/internal/repository"
 	"github.com/jmoiron/sqlx"
@@ -20,35 +23,73 @@ type DriverService interface {
 	GoOffline(ctx context.Context, driverID string) error
 	AcceptRide(ctx context.Context, driverID string, req *models.AcceptRideRequest) (*models.RideResponse, error)
 	DeclineRide(ctx context.Context, driverID, offerID string) error
+	// CancelAssignedRide gives up a ride before pickup; it returns to matching for
+	// another driver and the updated ride is returned
+	CancelAssignedRide(ctx context.Context, driverID string, req *models.DriverCancelRideRequest) (*models.Ride, error)
+	SubmitSelfie(ctx context.Context, driverID string, req *models.SubmitSelfieRequest) (*models.SelfieCheck, error)
+	// PendingConsents lists the required legal documents the driver must accept
+	// before going online, including addenda for the region they were last in
+	PendingConsents(ctx context.Context, driver *models.Driver) ([]*models.LegalDocument, error)
+	// SetPaymentMethods limits the rides the driver is matched with to those paid
+	// by one of the methods, such as no cash or UPI only
+	SetPaymentMethods(ctx context.Context, driverID string, req *models.SetPaymentMethodsRequest) (*models.Driver, error)
 }
 
 type driverService struct {
-	db            *sqlx.DB
+	transactor    repository.Transactor
 	driverRepo    repository.DriverRepository
 	rideRepo      repository.RideRepository
 	tripRepo      repository.TripRepository
 	offerRepo     repository.RideOfferRepository
 	userRepo      repository.UserRepository
 	driverCache   cache.DriverLocationCache
+	regionService RegionService
+	selfieChecks  SelfieCheckService
+	repricing     RepricingService
+	// Repairs the driver's cache entries when a write fails after accepting
+	reconciliation ReconciliationService
+	router         Router
+	consentService ConsentService
+	vehicleTypes   VehicleTypeService
+	// Streams drivers going online and offline; nil when events are off
+	events *events.Stream
 }
 
+// NewDriverService returns the driver service. Status events are skipped when
+// eventStream is nil.
 func NewDriverService(
-	db *sqlx.DB,
+	transactor repository.Transactor,
 	driverRepo repository.DriverRepository,
 	rideRepo repository.RideRepository,
 	tripRepo repository.TripRepository,
 	offerRepo repository.RideOfferRepository,
 	userRepo repository.UserRepository,
 	driverCache cache.DriverLocationCache,
+	regionService RegionService,
+	selfieChecks SelfieCheckService,
+	repricing RepricingService,
+	reconciliation ReconciliationService,
+	router Router,
+	consentService ConsentService,
+	vehicleTypes VehicleTypeService,
+	eventStream *events.Stream,
 ) DriverService {
 	return &driverService{
-		db:            db,
-		driverRepo:    driverRepo,
-		rideRepo:      rideRepo,
-		tripRepo:      tripRepo,
-		offerRepo:     offerRepo,
-		userRepo:      userRepo,
-		driverCache:   driverCache,
+		transactor:     transactor,
+		driverRepo:     driverRepo,
+		rideRepo:       rideRepo,
+		tripRepo:       tripRepo,
+		offerRepo:      offerRepo,
+		userRepo:       userRepo,
+		driverCache:    driverCache,
+		regionService:  regionService,
+		selfieChecks:   selfieChecks,
+		repricing:      repricing,
+		reconciliation: reconciliation,
+		router:         router,
+		consentService: consentService,
+		vehicleTypes:   vehicleTypes,
+		events:         eventStream,
 	}
 }
 
@@ -61,24 +102,36 @@ func (s *driverService) CreateDriver(ctx context.Context, req *models.CreateDriv
 	if existing != nil {
 		return nil, apperrors.Conflict("driver with this phone already exists")
 	}
+	if err := s.vehicleTypes.CheckActive(ctx, req.VehicleType, ""); err != nil {
+		return nil, err
+	}
+
+	driver := newDriver(req)
+	if err := s.driverRepo.Create(ctx, driver); err != nil {
+		return nil, err
+	}
 
+	return driver, nil
+}
+
+// newDriver builds the account for a sign-up or import; the repository fills in
+// the starting status and rating
+func newDriver(req *models.CreateDriverRequest) *models.Driver {
 	driver := &models.Driver{
 		Phone:         req.Phone,
 		Name:          req.Name,
 		LicenseNumber: req.LicenseNumber,
 		VehicleType:   req.VehicleType,
 		VehicleNumber: req.VehicleNumber,
+		IsEV:          req.IsEV,
 	}
-
 	if req.Email != "" {
 		driver.Email = &req.Email
 	}
-
-	if err := s.driverRepo.Create(ctx, driver); err != nil {
-		return nil, err
+	if req.Gender != "" {
+		driver.Gender = &req.Gender
 	}
-
-	return driver, nil
+	return driver
 }
 
 func (s *driverService) GetDriver(ctx context.Context, id string) (*models.Driver, error) {
@@ -102,6 +155,25 @@ func (s *driverService) GetDriver(ctx context.Context, id string) (*models.Drive
 	return driver, nil
 }
 
+func (s *driverService) SetPaymentMethods(ctx context.Context, driverID string, req *models.SetPaymentMethodsRequest) (*models.Driver, error) {
+	driver, err := s.driverRepo.GetByID(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+	if driver == nil {
+		return nil, apperrors.NotFound("driver")
+	}
+
+	if err := s.driverRepo.UpdatePaymentMethods(ctx, driverID, req.Methods); err != nil {
+		return nil, err
+	}
+	driver.AcceptedPaymentMethods = nil
+	if len(req.Methods) > 0 {
+		driver.AcceptedPaymentMethods = req.Methods
+	}
+	return driver, nil
+}
+
 func (s *driverService) UpdateLocation(ctx context.Context, driverID string, req *models.UpdateDriverLocationRequest) error {
 	driver, err := s.driverRepo.GetByID(ctx, driverID)
 	if err != nil {
@@ -117,9 +189,15 @@ func (s *driverService) UpdateLocation(ctx context.Context, driverID string, req
 
 	// Update cache (primary - fast)
 	if s.driverCache != nil {
+		s.trackTripDistance(ctx, driverID, req.Lat, req.Lng)
 		if err := s.driverCache.UpdateLocation(ctx, driverID, req.Lat, req.Lng, req.Heading, req.Speed, req.Accuracy); err != nil {
 			log.Printf("failed to update driver location in cache: %v", err)
 		}
+		if driver.IsEV && req.RangeKm != nil {
+			if err := s.driverCache.SetEVRange(ctx, driverID, *req.RangeKm, req.BatteryPercent); err != nil {
+				log.Printf("failed to update EV range in cache: %v", err)
+			}
+		}
 	}
 
 	// Update database (secondary - for persistence)
@@ -130,6 +208,22 @@ func (s *driverService) UpdateLocation(ctx context.Context, driverID string, req
 	return nil
 }
 
+// trackTripDistance adds the distance since the last known position to the
+// driver's trip odometer; the cache ignores it when no trip is in progress
+func (s *driverService) trackTripDistance(ctx context.Context, driverID string, lat, lng float64) {
+	prev, err := s.driverCache.GetDriverLocation(ctx, driverID)
+	if err != nil || prev == nil {
+		return
+	}
+	km := haversineDistance(prev.Lat, prev.Lng, lat, lng)
+	if km <= 0 {
+		return
+	}
+	if err := s.driverCache.AddTripDistance(ctx, driverID, km); err != nil {
+		log.Printf("failed to track trip distance: %v", err)
+	}
+}
+
 func (s *driverService) GoOnline(ctx context.Context, driverID string) error {
 	driver, err := s.driverRepo.GetByID(ctx, driverID)
 	if err != nil {
@@ -139,9 +233,21 @@ func (s *driverService) GoOnline(ctx context.Context, driverID string) error {
 		return apperrors.NotFound("driver")
 	}
 
+	if err := s.checkSelfieRequirement(ctx, driver); err != nil {
+		return err
+	}
+	pending, err := s.PendingConsents(ctx, driver)
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		return apperrors.ConsentRequired(map[string]interface{}{"pending_consents": pending})
+	}
+
 	if err := s.driverRepo.UpdateStatus(ctx, driverID, models.DriverStatusOnline); err != nil {
 		return err
 	}
+	s.emitStatus(driverID, driver.Status, models.DriverStatusOnline)
 
 	// Update cache
 	if s.driverCache != nil {
@@ -174,6 +280,7 @@ func (s *driverService) GoOffline(ctx context.Context, driverID string) error {
 	if err := s.driverRepo.UpdateStatus(ctx, driverID, models.DriverStatusOffline); err != nil {
 		return err
 	}
+	s.emitStatus(driverID, driver.Status, models.DriverStatusOffline)
 
 	// Update cache
 	if s.driverCache != nil {
@@ -186,110 +293,227 @@ func (s *driverService) GoOffline(ctx context.Context, driverID string) error {
 
 func (s *driverService) AcceptRide(ctx context.Context, driverID string, req *models.AcceptRideRequest) (*models.RideResponse, error) {
 	// Use transaction for atomicity
-	tx, err := s.db.BeginTxx(ctx, nil)
+	var (
+		offer                   *models.RideOffer
+		ride                    *models.Ride
+		offerStatus, rideStatus string
+		etaMin                  *int
+	)
+	now := time.Now()
+	err := s.transactor.InTx(ctx, func(tx *sqlx.Tx) error {
+		// Get offer with lock
+		var err error
+		offer, err = s.offerRepo.GetByIDForUpdate(ctx, tx, req.OfferID)
+		if err != nil {
+			return err
+		}
+		if offer == nil {
+			return apperrors.NotFound("offer")
+		}
+
+		// Validate offer
+		if offer.DriverID != driverID {
+			return apperrors.Unauthorized("offer not for this driver")
+		}
+		if offer.RideID != req.RideID {
+			return apperrors.BadRequest("offer ride mismatch")
+		}
+		if offer.IsExpired() {
+			return apperrors.OfferExpired()
+		}
+		if offer.Status != models.OfferStatusPending {
+			return apperrors.BadRequest("offer already responded")
+		}
+
+		// Get ride with lock
+		ride, err = s.rideRepo.GetByIDForUpdate(ctx, tx, req.RideID)
+		if err != nil {
+			return err
+		}
+		if ride == nil {
+			return apperrors.NotFound("ride")
+		}
+
+		// Check if ride is still available
+		if ride.Status != models.RideStatusMatching {
+			return apperrors.RideAlreadyAssigned()
+		}
+
+		// A chained offer accepted while the driver is still on a trip is queued behind it
+		offerStatus, rideStatus = models.OfferStatusAccepted, models.RideStatusDriverAssigned
+		if offer.Chained {
+			current, err := s.rideRepo.GetActiveRideByDriverID(ctx, driverID)
+			if err != nil {
+				return err
+			}
+			if current != nil {
+				offerStatus, rideStatus = models.OfferStatusQueued, models.RideStatusQueued
+			}
+		}
+
+		// Promise the rider a pickup time based on where the driver is now
+		if rideStatus == models.RideStatusDriverAssigned {
+			etaMin = s.pickupETA(ctx, driverID, ride)
+		}
+
+		// Update offer status
+		if err := s.offerRepo.SetStatus(ctx, tx, offer.ID, offerStatus, now); err != nil {
+			return err
+		}
+
+		// Assign driver to ride; accepting a bid ride as-is agrees to the rider's proposed fare
+		if err := s.rideRepo.Assign(ctx, tx, ride.ID, driverID, rideStatus, ride.ProposedFare, etaMin, now); err != nil {
+			return err
+		}
+
+		// Update driver status to busy
+		if err := s.driverRepo.SetStatus(ctx, tx, driverID, models.DriverStatusBusy, now); err != nil {
+			return err
+		}
+
+		// Expire other open offers for this ride
+		return s.offerRepo.ExpireOpen(ctx, tx, ride.ID, now)
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer tx.Rollback()
+	models.OfferStates.Record(ctx, offer.ID, offer.Status, offerStatus)
+	metrics.CounterMap("matching_funnel").Add(models.FunnelStageAccepted, 1)
+	models.RideStates.Record(ctx, ride.ID, ride.Status, rideStatus)
 
-	// Get offer with lock
-	offer, err := s.offerRepo.GetByIDForUpdate(ctx, tx, req.OfferID)
-	if err != nil {
-		return nil, err
+	// Update cache. The assignment has committed, so a failed write is queued for
+	// repair rather than leaving matching to treat the driver as free.
+	cacheErr := syncDriverStatus(ctx, s.driverCache, driverID, models.DriverStatusBusy, now)
+	if s.driverCache != nil {
+		if rideStatus == models.RideStatusDriverAssigned {
+			if err := s.driverCache.SetActiveRide(ctx, driverID, ride.ID); err != nil {
+				cacheErr = err
+			}
+		}
+		if err := s.driverCache.RecordMatchTime(ctx, ride.VehicleType, now.Sub(ride.CreatedAt)); err != nil {
+			log.Printf("failed to record match time: %v", err)
+		}
 	}
-	if offer == nil {
-		return nil, apperrors.NotFound("offer")
+	if cacheErr != nil {
+		s.reconciliation.QueueRepair(ctx, driverID, models.CacheRepairAccept)
 	}
 
-	// Validate offer
-	if offer.DriverID != driverID {
-		return nil, apperrors.Unauthorized("offer not for this driver")
-	}
-	if offer.RideID != req.RideID {
-		return nil, apperrors.BadRequest("offer ride mismatch")
+	// Get updated ride with user info
+	ride.DriverID = &driverID
+	ride.Status = rideStatus
+	ride.AgreedFare = ride.ProposedFare
+	ride.PickupETAMin = etaMin
+
+	// A replacement after the first driver cancelled may ease surge if the rider now waits longer
+	if etaMin != nil {
+		if _, err := s.repricing.AfterReassignment(ctx, ride, *etaMin); err != nil {
+			log.Printf("failed to reprice reassigned ride %s: %v", ride.ID, err)
+		}
 	}
-	if offer.IsExpired() {
-		return nil, apperrors.OfferExpired()
+
+	response := ride.ToResponse()
+
+	// Fetch user
+	user, err := s.userRepo.GetByID(ctx, ride.UserID)
+	if err == nil && user != nil {
+		response.User = user.ToResponse()
 	}
-	if offer.Status != models.OfferStatusPending {
-		return nil, apperrors.BadRequest("offer already responded")
+
+	// Fetch driver
+	driver, err := s.driverRepo.GetByID(ctx, driverID)
+	if err == nil && driver != nil {
+		response.Driver = driver.ToResponse()
 	}
 
-	// Get ride with lock
-	ride, err := s.rideRepo.GetByIDForUpdate(ctx, tx, req.RideID)
+	return response, nil
+}
+
+func (s *driverService) CancelAssignedRide(ctx context.Context, driverID string, req *models.DriverCancelRideRequest) (*models.Ride, error) {
+	ride, err := s.rideRepo.GetByID(ctx, req.RideID)
 	if err != nil {
 		return nil, err
 	}
 	if ride == nil {
 		return nil, apperrors.NotFound("ride")
 	}
-
-	// Check if ride is still available
-	if ride.Status != models.RideStatusMatching {
-		return nil, apperrors.RideAlreadyAssigned()
+	if ride.DriverID == nil || *ride.DriverID != driverID {
+		return nil, apperrors.Unauthorized("ride not assigned to this driver")
 	}
-
-	// Update offer status
-	now := time.Now()
-	_, err = tx.ExecContext(ctx,
-		"UPDATE ride_offers SET status = $1, responded_at = $2 WHERE id = $3",
-		models.OfferStatusAccepted, now, offer.ID)
-	if err != nil {
+	if err := models.RideStates.Check(ride.Status, models.RideStatusMatching); err != nil {
 		return nil, err
 	}
 
-	// Assign driver to ride
-	_, err = tx.ExecContext(ctx,
-		"UPDATE rides SET driver_id = $1, status = $2, updated_at = $3 WHERE id = $4",
-		driverID, models.RideStatusDriverAssigned, now, ride.ID)
+	reassigned, err := s.rideRepo.Reassign(ctx, ride.ID, driverID, time.Now())
 	if err != nil {
 		return nil, err
 	}
-
-	// Update driver status to busy
-	_, err = tx.ExecContext(ctx,
-		"UPDATE drivers SET status = $1, updated_at = $2 WHERE id = $3",
-		models.DriverStatusBusy, now, driverID)
-	if err != nil {
-		return nil, err
+	if !reassigned {
+		return nil, apperrors.Conflict("ride changed while cancelling; fetch it and try again")
 	}
-
-	// Expire other pending offers for this ride
-	_, err = tx.ExecContext(ctx,
-		"UPDATE ride_offers SET status = $1, responded_at = $2 WHERE ride_id = $3 AND status = $4",
-		models.OfferStatusExpired, now, ride.ID, models.OfferStatusPending)
-	if err != nil {
-		return nil, err
+	models.RideStates.Record(ctx, ride.ID, ride.Status, models.RideStatusMatching)
+	if req.Reason != "" {
+		log.Printf("driver %s cancelled ride %s: %s", driverID, ride.ID, req.Reason)
 	}
 
-	if err := tx.Commit(); err != nil {
-		return nil, err
+	if err := s.driverRepo.UpdateStatus(ctx, driverID, models.DriverStatusOnline); err != nil {
+		log.Printf("failed to update driver status after cancellation: %v", err)
 	}
-
-	// Update cache
 	if s.driverCache != nil {
-		s.driverCache.SetActiveRide(ctx, driverID, ride.ID)
+		s.driverCache.ClearActiveRide(ctx, driverID)
+		if driver, err := s.driverRepo.GetByID(ctx, driverID); err == nil && driver != nil {
+			s.driverCache.SetDriverMeta(ctx, driverID, models.DriverStatusOnline, driver.VehicleType, driver.Rating)
+		}
 	}
 
-	// Get updated ride with user info
-	ride.DriverID = &driverID
-	ride.Status = models.RideStatusDriverAssigned
+	return s.rideRepo.GetByID(ctx, ride.ID)
+}
 
-	response := ride.ToResponse()
+// pickupETA estimates minutes to pickup from the driver's last known location
+func (s *driverService) pickupETA(ctx context.Context, driverID string, ride *models.Ride) *int {
+	if s.driverCache == nil {
+		return nil
+	}
+	loc, err := s.driverCache.GetDriverLocation(ctx, driverID)
+	if err != nil || loc == nil {
+		return nil
+	}
+	eta := driveMins(ctx, s.router, loc.Lat, loc.Lng, ride.PickupLat, ride.PickupLng)
+	return &eta
+}
 
-	// Fetch user
-	user, err := s.userRepo.GetByID(ctx, ride.UserID)
-	if err == nil && user != nil {
-		response.User = user.ToResponse()
+// driveMins estimates driving minutes between two points, over the road network
+// when a router is configured and at average city speed otherwise
+func driveMins(ctx context.Context, router Router, fromLat, fromLng, toLat, toLng float64) int {
+	if router != nil {
+		if route, err := router.Route(ctx, fromLat, fromLng, toLat, toLng); err == nil {
+			return route.DurationMins
+		}
 	}
+	km := haversineDistance(fromLat, fromLng, toLat, toLng)
+	return int(math.Ceil(km / avgCitySpeedKmh * 60))
+}
 
-	// Fetch driver
-	driver, err := s.driverRepo.GetByID(ctx, driverID)
-	if err == nil && driver != nil {
-		response.Driver = driver.ToResponse()
+// emitStatus streams a driver going online or offline. Drivers turning busy and
+// back are carried by their rides' events.
+func (s *driverService) emitStatus(driverID, from, to string) {
+	if s.events == nil || from == to {
+		return
 	}
+	s.events.Emit(events.StatusChanged(events.EntityDriver, driverID, from, to, time.Now()))
+}
 
-	return response, nil
+// syncDriverStatus mirrors a driver status written to Postgres at the given time
+// into the cache matching reads. Failures are logged and returned for callers that
+// queue a repair; otherwise periodic reconciliation fixes them.
+func syncDriverStatus(ctx context.Context, driverCache cache.DriverLocationCache, driverID, status string, at time.Time) error {
+	if driverCache == nil {
+		return nil
+	}
+	if _, err := driverCache.SetDriverStatus(ctx, driverID, status, at); err != nil {
+		log.Printf("failed to cache status %s for driver %s: %v", status, driverID, err)
+		return err
+	}
+	return nil
 }
 
 func (s *driverService) DeclineRide(ctx context.Context, driverID, offerID string) error {
@@ -309,5 +533,74 @@ func (s *driverService) DeclineRide(ctx context.Context, driverID, offerID strin
 		return apperrors.BadRequest("offer already responded")
 	}
 
-	return s.offerRepo.UpdateStatus(ctx, offerID, models.OfferStatusDeclined)
+	err = models.OfferStates.Apply(ctx, offerID, offer.Status, models.OfferStatusDeclined, func() error {
+		return s.offerRepo.UpdateStatus(ctx, offerID, models.OfferStatusDeclined)
+	})
+	if err != nil {
+		return err
+	}
+
+	// The driver is free to be offered other rides straight away
+	if s.driverCache != nil {
+		if err := s.driverCache.ReleaseDriver(ctx, driverID, offer.RideID); err != nil {
+			log.Printf("failed to release driver %s from ride %s: %v", driverID, offer.RideID, err)
+		}
+	}
+	return nil
+}
+
+func (s *driverService) SubmitSelfie(ctx context.Context, driverID string, req *models.SubmitSelfieRequest) (*models.SelfieCheck, error) {
+	driver, err := s.driverRepo.GetByID(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+	if driver == nil {
+		return nil, apperrors.NotFound("driver")
+	}
+
+	return s.selfieChecks.SubmitSelfie(ctx, driverID, req)
+}
+
+func (s *driverService) PendingConsents(ctx context.Context, driver *models.Driver) ([]*models.LegalDocument, error) {
+	regionCode := ""
+	if driver.CurrentLat != nil && driver.CurrentLng != nil {
+		region, err := s.regionService.Resolve(ctx, *driver.CurrentLat, *driver.CurrentLng)
+		if err != nil {
+			return nil, err
+		}
+		if region != nil {
+			regionCode = region.Code
+		}
+	}
+	return s.consentService.Pending(ctx, models.RaterDriver, driver.ID, regionCode)
+}
+
+// checkSelfieRequirement enforces the selfie check for drivers whose last known
+// location is in a region that requires one
+func (s *driverService) checkSelfieRequirement(ctx context.Context, driver *models.Driver) error {
+	if driver.CurrentLat == nil || driver.CurrentLng == nil {
+		return nil
+	}
+
+	region, err := s.regionService.Resolve(ctx, *driver.CurrentLat, *driver.CurrentLng)
+	if err != nil {
+		return err
+	}
+	if region == nil || !region.Settings.SelfieCheckRequired {
+		return nil
+	}
+
+	validity := models.DefaultSelfieCheckValidity
+	if region.Settings.SelfieCheckValidityMins > 0 {
+		validity = time.Duration(region.Settings.SelfieCheckValidityMins) * time.Minute
+	}
+
+	ok, err := s.selfieChecks.HasValidCheck(ctx, driver.ID, validity)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return apperrors.SelfieCheckRequired()
+	}
+	return nil
 }
@@ -0,0 +1,361 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/aditya/go-comet/internal/cache"
+	apperrors "github.com/aditya/go-comet/internal/errors"
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/push"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+// EarningsService tracks drivers' progress towards their daily earnings goal and
+// reports what they were paid
+type EarningsService interface {
+	SetDailyGoal(ctx context.Context, driverID string, req *models.SetEarningsGoalRequest) (*models.EarningsGoalProgress, error)
+	// GetGoalProgress returns today's progress, or nil if the driver has no goal
+	GetGoalProgress(ctx context.Context, driverID string) (*models.EarningsGoalProgress, error)
+	// NotifyMilestones pushes any goal milestones the driver's latest trip crossed
+	NotifyMilestones(ctx context.Context, driverID string)
+	// GetStatement itemises payments taken for the driver's trips in [from, to)
+	// and the ledger entries posted against their balance
+	GetStatement(ctx context.Context, driverID string, from, to time.Time) (*models.EarningsStatement, error)
+	// PostTripEarning adds a completed payment to the driver's balance: their share
+	// of the fare, or less the commission they owe when the rider paid cash
+	PostTripEarning(ctx context.Context, payment *models.Payment) error
+	// ReverseTripEarning takes a refunded payment back off the driver's balance
+	ReverseTripEarning(ctx context.Context, paymentID string) error
+	// GetEarnings lists the driver's trips paid in [from, to) with the commission
+	// taken from each
+	GetEarnings(ctx context.Context, driverID string, from, to time.Time) (*models.DriverEarnings, error)
+	RequestPayout(ctx context.Context, driverID string, req *models.RequestPayoutRequest) (*models.Payout, error)
+	GetPayouts(ctx context.Context, driverID string) (*models.DriverPayouts, error)
+}
+
+// recentPayouts is how many payouts accompany a driver's balance
+const recentPayouts = 20
+
+type earningsService struct {
+	driverRepo        repository.DriverRepository
+	tripRepo          repository.TripRepository
+	paymentRepo       repository.PaymentRepository
+	deductionRepo     repository.DeductionRepository
+	payoutRepo        repository.PayoutRepository
+	driverCache       cache.DriverLocationCache
+	pusher            push.Sender
+	commissionService CommissionService
+	minPayout         float64
+}
+
+func NewEarningsService(
+	driverRepo repository.DriverRepository,
+	tripRepo repository.TripRepository,
+	paymentRepo repository.PaymentRepository,
+	deductionRepo repository.DeductionRepository,
+	payoutRepo repository.PayoutRepository,
+	driverCache cache.DriverLocationCache,
+	pusher push.Sender,
+	commissionService CommissionService,
+	minPayout float64,
+) EarningsService {
+	return &earningsService{
+		driverRepo:        driverRepo,
+		tripRepo:          tripRepo,
+		paymentRepo:       paymentRepo,
+		deductionRepo:     deductionRepo,
+		payoutRepo:        payoutRepo,
+		driverCache:       driverCache,
+		pusher:            pusher,
+		commissionService: commissionService,
+		minPayout:         minPayout,
+	}
+}
+
+func (s *earningsService) SetDailyGoal(ctx context.Context, driverID string, req *models.SetEarningsGoalRequest) (*models.EarningsGoalProgress, error) {
+	driver, err := s.driverRepo.GetByID(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+	if driver == nil {
+		return nil, apperrors.NotFound("driver")
+	}
+
+	var goal *float64
+	if req.DailyGoal > 0 {
+		goal = &req.DailyGoal
+	}
+	if err := s.driverRepo.UpdateEarningsGoal(ctx, driverID, goal); err != nil {
+		return nil, err
+	}
+	if goal == nil {
+		return nil, nil
+	}
+
+	return s.progress(ctx, driverID, *goal)
+}
+
+func (s *earningsService) GetGoalProgress(ctx context.Context, driverID string) (*models.EarningsGoalProgress, error) {
+	driver, err := s.driverRepo.GetByID(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+	if driver == nil {
+		return nil, apperrors.NotFound("driver")
+	}
+	if driver.DailyEarningsGoal == nil {
+		return nil, nil
+	}
+
+	return s.progress(ctx, driverID, *driver.DailyEarningsGoal)
+}
+
+func (s *earningsService) NotifyMilestones(ctx context.Context, driverID string) {
+	if s.pusher == nil || s.driverCache == nil {
+		return
+	}
+
+	progress, err := s.GetGoalProgress(ctx, driverID)
+	if err != nil {
+		log.Printf("failed to load earnings goal for driver %s: %v", driverID, err)
+		return
+	}
+	if progress == nil {
+		return
+	}
+
+	// Only the highest newly crossed milestone is worth a notification
+	day := time.Now().Format("2006-01-02")
+	reached := 0
+	for _, milestone := range models.EarningsGoalMilestones {
+		if progress.PercentComplete < float64(milestone) {
+			break
+		}
+		isNew, err := s.driverCache.MarkGoalMilestone(ctx, driverID, day, milestone)
+		if err != nil {
+			log.Printf("failed to record goal milestone for driver %s: %v", driverID, err)
+			return
+		}
+		if isNew {
+			reached = milestone
+		}
+	}
+	if reached == 0 {
+		return
+	}
+
+	msg := &push.Message{
+		UserID: driverID,
+		Title:  fmt.Sprintf("%d%% of today's goal", reached),
+		Body:   fmt.Sprintf("You've earned %.2f of your %.2f goal.", progress.NetEarnings, progress.DailyGoal),
+		Data: map[string]string{
+			"type":      "earnings_goal_milestone",
+			"milestone": fmt.Sprintf("%d", reached),
+		},
+	}
+	if reached == 100 {
+		msg.Title = "Daily goal reached"
+	}
+	if err := s.pusher.Send(ctx, msg); err != nil {
+		log.Printf("failed to send goal milestone push to driver %s: %v", driverID, err)
+	}
+}
+
+func (s *earningsService) GetStatement(ctx context.Context, driverID string, from, to time.Time) (*models.EarningsStatement, error) {
+	if !from.Before(to) {
+		return nil, apperrors.BadRequest("from must be before to")
+	}
+
+	driver, err := s.driverRepo.GetByID(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+	if driver == nil {
+		return nil, apperrors.NotFound("driver")
+	}
+
+	payments, err := s.paymentRepo.GetCompletedByDriver(ctx, driverID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	statement := &models.EarningsStatement{
+		DriverID: driverID,
+		From:     from,
+		To:       to,
+		Lines:    []*models.EarningsStatementLine{},
+		Ledger:   []*models.LedgerEntry{},
+	}
+	for _, payment := range payments {
+		// Payments taken before splits were recorded use the rate in effect at the time
+		var split models.CommissionSplit
+		if payment.CommissionPercent != nil && payment.CommissionAmount != nil && payment.DriverEarnings != nil {
+			split = models.CommissionSplit{
+				Percent:        *payment.CommissionPercent,
+				Commission:     *payment.CommissionAmount,
+				DriverEarnings: *payment.DriverEarnings,
+			}
+		} else {
+			rate, err := s.commissionService.RateAt(ctx, driverID, payment.CreatedAt)
+			if err != nil {
+				return nil, err
+			}
+			split = models.NewCommissionSplit(payment.Amount-payment.CarbonOffsetAmount, rate)
+		}
+		statement.AddPayment(payment, split)
+	}
+
+	entries, err := s.deductionRepo.GetLedgerEntries(ctx, driverID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		statement.AddLedgerEntry(entry)
+	}
+
+	return statement, nil
+}
+
+func (s *earningsService) PostTripEarning(ctx context.Context, payment *models.Payment) error {
+	if payment.CommissionAmount == nil || payment.DriverEarnings == nil {
+		return apperrors.BadRequest("payment has no commission split")
+	}
+
+	// Cash stays with the driver, who owes the platform its commission and any
+	// carbon offset the rider added
+	amount := *payment.DriverEarnings
+	description := "Trip earnings"
+	if payment.Method == models.PaymentMethodCash {
+		amount = -round(*payment.CommissionAmount + payment.CarbonOffsetAmount)
+		description = "Commission on cash trip"
+	}
+
+	_, err := s.payoutRepo.PostEarning(ctx, &models.LedgerEntry{
+		DriverID:    payment.DriverID,
+		Amount:      amount,
+		Description: &description,
+		TripID:      &payment.TripID,
+		PaymentID:   &payment.ID,
+	})
+	return err
+}
+
+func (s *earningsService) ReverseTripEarning(ctx context.Context, paymentID string) error {
+	return s.payoutRepo.ReverseEarning(ctx, paymentID, time.Now())
+}
+
+func (s *earningsService) GetEarnings(ctx context.Context, driverID string, from, to time.Time) (*models.DriverEarnings, error) {
+	if !from.Before(to) {
+		return nil, apperrors.BadRequest("from must be before to")
+	}
+
+	driver, err := s.driverRepo.GetByID(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+	if driver == nil {
+		return nil, apperrors.NotFound("driver")
+	}
+
+	trips, err := s.payoutRepo.GetTripEarnings(ctx, driverID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	balance, err := s.payoutRepo.GetBalance(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+
+	earnings := &models.DriverEarnings{
+		DriverID: driverID,
+		From:     from,
+		To:       to,
+		Balance:  round(balance),
+		Records:  []*models.TripEarning{},
+	}
+	for _, trip := range trips {
+		earnings.Add(trip)
+	}
+	return earnings, nil
+}
+
+func (s *earningsService) RequestPayout(ctx context.Context, driverID string, req *models.RequestPayoutRequest) (*models.Payout, error) {
+	driver, err := s.driverRepo.GetByID(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+	if driver == nil {
+		return nil, apperrors.NotFound("driver")
+	}
+
+	amount := round(req.Amount)
+	if amount == 0 {
+		balance, err := s.payoutRepo.GetBalance(ctx, driverID)
+		if err != nil {
+			return nil, err
+		}
+		amount = round(balance)
+	}
+	if amount <= 0 {
+		return nil, apperrors.BadRequest("balance is too low for this payout")
+	}
+	if amount < s.minPayout {
+		return nil, apperrors.BadRequest(fmt.Sprintf("payouts must be at least %.2f", s.minPayout))
+	}
+
+	payout := &models.Payout{DriverID: driverID, Amount: amount}
+	ok, err := s.payoutRepo.CreatePayout(ctx, payout)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, apperrors.BadRequest("balance is too low for this payout")
+	}
+	return payout, nil
+}
+
+func (s *earningsService) GetPayouts(ctx context.Context, driverID string) (*models.DriverPayouts, error) {
+	driver, err := s.driverRepo.GetByID(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+	if driver == nil {
+		return nil, apperrors.NotFound("driver")
+	}
+
+	balance, err := s.payoutRepo.GetBalance(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+	payouts, err := s.payoutRepo.GetRecentPayouts(ctx, driverID, recentPayouts)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.DriverPayouts{Balance: round(balance), Payouts: payouts}
+	if result.Payouts == nil {
+		result.Payouts = []*models.Payout{}
+	}
+	return result, nil
+}
+
+// progress measures net earnings (after the driver's commission) since local midnight
+func (s *earningsService) progress(ctx context.Context, driverID string, goal float64) (*models.EarningsGoalProgress, error) {
+	now := time.Now()
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	summary, err := s.tripRepo.GetEarningsSummary(ctx, driverID, midnight)
+	if err != nil {
+		return nil, err
+	}
+
+	rate, err := s.commissionService.RateAt(ctx, driverID, now)
+	if err != nil {
+		return nil, err
+	}
+	net := summary.Gross * (1 - rate/100)
+	return models.NewEarningsGoalProgress(goal, net, summary.Trips, summary.FirstTripAt, now), nil
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aditya/go-comet/internal/httpclient"
+)
+
+// FaceMatcher compares a driver selfie with a reference photo and returns a
+// similarity score between 0 and 1.
+type FaceMatcher interface {
+	Name() string
+	Match(ctx context.Context, selfieURL, referenceURL string) (float64, error)
+}
+
+// httpFaceMatcher calls an external face-match API that accepts image URLs
+type httpFaceMatcher struct {
+	endpoint string
+	apiKey   string
+	client   *httpclient.Client
+}
+
+func NewHTTPFaceMatcher(endpoint, apiKey string) FaceMatcher {
+	return &httpFaceMatcher{
+		endpoint: endpoint,
+		apiKey:   apiKey,
+		client:   httpclient.New(httpclient.Config{Name: "face-match", Timeout: 10 * time.Second}),
+	}
+}
+
+func (m *httpFaceMatcher) Name() string {
+	return "http"
+}
+
+func (m *httpFaceMatcher) Match(ctx context.Context, selfieURL, referenceURL string) (float64, error) {
+	body, _ := json.Marshal(map[string]string{
+		"selfie_url":    selfieURL,
+		"reference_url": referenceURL,
+	})
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if m.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+m.apiKey)
+	}
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("face match: unexpected status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Score float64 `json:"score"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return 0, err
+	}
+	return result.Score, nil
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"log"
+	"math"
+	"time"
+
+	"github.com/aditya/go-comet/internal/metrics"
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+// FareVarianceService compares estimated with actual fares so broken distance
+// estimation or routing shows up before riders complain about surprise fares
+type FareVarianceService interface {
+	Report(ctx context.Context) (*models.FareVarianceReport, error)
+	// Check publishes the report as metrics and alerts on groups over their threshold
+	Check(ctx context.Context) (int, error)
+}
+
+type fareVarianceService struct {
+	tripRepo      repository.TripRepository
+	regionService RegionService
+	policy        models.FareVariancePolicy
+}
+
+func NewFareVarianceService(
+	tripRepo repository.TripRepository,
+	regionService RegionService,
+	policy models.FareVariancePolicy,
+) FareVarianceService {
+	return &fareVarianceService{
+		tripRepo:      tripRepo,
+		regionService: regionService,
+		policy:        policy,
+	}
+}
+
+func (s *fareVarianceService) Report(ctx context.Context) (*models.FareVarianceReport, error) {
+	now := time.Now()
+	from := now.Add(-s.policy.Window)
+	groups, err := s.tripRepo.GetFareVariance(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+
+	regions, err := s.regionService.ListRegions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	thresholds := make(map[string]float64, len(regions))
+	for _, r := range regions {
+		if r.Settings.FareVarianceThresholdPercent > 0 {
+			thresholds[r.Code] = r.Settings.FareVarianceThresholdPercent
+		}
+	}
+
+	for _, g := range groups {
+		g.MedianVariancePercent = round(g.MedianVariancePercent)
+		g.P90AbsVariancePercent = round(g.P90AbsVariancePercent)
+		g.ThresholdPercent = s.policy.ThresholdPercent
+		if g.RegionCode != nil {
+			if t, ok := thresholds[*g.RegionCode]; ok {
+				g.ThresholdPercent = t
+			}
+		}
+		g.Alerting = g.Trips >= s.policy.MinTrips && math.Abs(g.MedianVariancePercent) > g.ThresholdPercent
+	}
+
+	if groups == nil {
+		groups = []*models.FareVarianceGroup{}
+	}
+	return &models.FareVarianceReport{From: from, To: now, Groups: groups}, nil
+}
+
+func (s *fareVarianceService) Check(ctx context.Context) (int, error) {
+	report, err := s.Report(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	alerts := 0
+	for _, g := range report.Groups {
+		label := g.Label()
+		metrics.SetGauge("fare_variance_median_percent", label, g.MedianVariancePercent)
+		metrics.SetGauge("fare_variance_p90_abs_percent", label, g.P90AbsVariancePercent)
+		if !g.Alerting {
+			continue
+		}
+		alerts++
+		metrics.CounterMap("fare_variance_alerts").Add(label, 1)
+		log.Printf("ALERT fare variance: %s median %.1f%% over %d trips exceeds %.1f%%",
+			label, g.MedianVariancePercent, g.Trips, g.ThresholdPercent)
+	}
+	return alerts, nil
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+
+	apperrors "github.com/aditya/go-comet/internal/errors"
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+type FavoriteService interface {
+	AddFavorite(ctx context.Context, userID, driverID string) (*models.FavoriteDriver, error)
+	RemoveFavorite(ctx context.Context, userID, driverID string) error
+	ListFavorites(ctx context.Context, userID string) ([]*models.FavoriteDriver, error)
+}
+
+type favoriteService struct {
+	favoriteRepo repository.FavoriteRepository
+	userRepo     repository.UserRepository
+	tripRepo     repository.TripRepository
+}
+
+func NewFavoriteService(
+	favoriteRepo repository.FavoriteRepository,
+	userRepo repository.UserRepository,
+	tripRepo repository.TripRepository,
+) FavoriteService {
+	return &favoriteService{
+		favoriteRepo: favoriteRepo,
+		userRepo:     userRepo,
+		tripRepo:     tripRepo,
+	}
+}
+
+// AddFavorite favorites a driver the rider has completed a trip with
+func (s *favoriteService) AddFavorite(ctx context.Context, userID, driverID string) (*models.FavoriteDriver, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, apperrors.NotFound("user")
+	}
+
+	hasTrip, err := s.tripRepo.HasCompletedTrip(ctx, userID, driverID)
+	if err != nil {
+		return nil, err
+	}
+	if !hasTrip {
+		return nil, apperrors.BadRequest("drivers can only be favorited after a completed trip")
+	}
+
+	count, err := s.favoriteRepo.CountByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= models.MaxFavoriteDrivers {
+		return nil, apperrors.BadRequest("favorite driver limit reached")
+	}
+
+	fav := &models.FavoriteDriver{UserID: userID, DriverID: driverID}
+	if err := s.favoriteRepo.Add(ctx, fav); err != nil {
+		return nil, err
+	}
+	return fav, nil
+}
+
+func (s *favoriteService) RemoveFavorite(ctx context.Context, userID, driverID string) error {
+	removed, err := s.favoriteRepo.Remove(ctx, userID, driverID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return apperrors.NotFound("favorite driver")
+	}
+	return nil
+}
+
+func (s *favoriteService) ListFavorites(ctx context.Context, userID string) ([]*models.FavoriteDriver, error) {
+	favorites, err := s.favoriteRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if favorites == nil {
+		favorites = []*models.FavoriteDriver{}
+	}
+	return favorites, nil
+}
@@ -0,0 +1,275 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/aditya/go-comet/internal/cache"
+	apperrors "github.com/aditya/go-comet/internal/errors"
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+	"github.com/jmoiron/sqlx"
+)
+
+// rescueSearchRadius bounds the automatic search for a rescue driver, in km
+const rescueSearchRadius = 10.0
+
+// HandoverService moves a trip to a rescue driver after a mid-trip breakdown
+type HandoverService interface {
+	FreezeTrip(ctx context.Context, tripID string, req *models.HandoverRequest) (*models.HandoverResponse, error)
+	AssignRescueDriver(ctx context.Context, tripID string, req *models.AssignRescueDriverRequest) (*models.HandoverResponse, error)
+}
+
+type handoverService struct {
+	transactor     repository.Transactor
+	tripRepo       repository.TripRepository
+	rideRepo       repository.RideRepository
+	driverRepo     repository.DriverRepository
+	segmentRepo    repository.TripSegmentRepository
+	pricingService PricingService
+	driverCache    cache.DriverLocationCache
+}
+
+func NewHandoverService(
+	transactor repository.Transactor,
+	tripRepo repository.TripRepository,
+	rideRepo repository.RideRepository,
+	driverRepo repository.DriverRepository,
+	segmentRepo repository.TripSegmentRepository,
+	pricingService PricingService,
+	driverCache cache.DriverLocationCache,
+) HandoverService {
+	return &handoverService{
+		transactor:     transactor,
+		tripRepo:       tripRepo,
+		rideRepo:       rideRepo,
+		driverRepo:     driverRepo,
+		segmentRepo:    segmentRepo,
+		pricingService: pricingService,
+		driverCache:    driverCache,
+	}
+}
+
+// FreezeTrip stops the clock on a trip where the vehicle broke down, closing the
+// current driver's segment at the breakdown point and taking them off the road
+func (s *handoverService) FreezeTrip(ctx context.Context, tripID string, req *models.HandoverRequest) (*models.HandoverResponse, error) {
+	trip, err := s.tripRepo.GetByID(ctx, tripID)
+	if err != nil {
+		return nil, err
+	}
+	if trip == nil {
+		return nil, apperrors.NotFound("trip")
+	}
+	if err := models.TripStates.Check(trip.Status, models.TripStatusHandover); err != nil {
+		return nil, err
+	}
+
+	ride, err := s.rideRepo.GetByID(ctx, trip.RideID)
+	if err != nil {
+		return nil, err
+	}
+	if ride == nil {
+		return nil, apperrors.NotFound("ride")
+	}
+
+	segments, err := s.segmentRepo.GetByTripID(ctx, trip.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The first handover opens the original driver's segment retroactively
+	var current *models.TripSegment
+	isNew := len(segments) == 0
+	if isNew {
+		start := trip.CreatedAt
+		if trip.StartTime != nil {
+			start = *trip.StartTime
+		}
+		current = &models.TripSegment{
+			TripID:    trip.ID,
+			DriverID:  trip.DriverID,
+			Sequence:  1,
+			StartTime: start,
+			StartLat:  ride.PickupLat,
+			StartLng:  ride.PickupLng,
+		}
+		segments = append(segments, current)
+	} else {
+		current = segments[len(segments)-1]
+	}
+
+	now := time.Now()
+	distanceKm := s.segmentDistance(ctx, trip.DriverID, current, req.Lat, req.Lng)
+	current.Close(req.Lat, req.Lng, distanceKm, now, req.Reason)
+
+	err = s.transactor.InTx(ctx, func(tx *sqlx.Tx) error {
+		var err error
+		if isNew {
+			err = s.segmentRepo.Create(ctx, tx, current)
+		} else {
+			err = s.segmentRepo.Update(ctx, tx, current)
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := s.tripRepo.SetStatus(ctx, tx, trip.ID, models.TripStatusHandover, now); err != nil {
+			return err
+		}
+
+		// The broken-down vehicle can't take new rides
+		return s.driverRepo.SetStatus(ctx, tx, trip.DriverID, models.DriverStatusOffline, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+	models.TripStates.Record(ctx, trip.ID, trip.Status, models.TripStatusHandover)
+
+	if s.driverCache != nil {
+		s.driverCache.ClearActiveRide(ctx, trip.DriverID)
+		s.driverCache.ClearTripDistance(ctx, trip.DriverID)
+		if driver, err := s.driverRepo.GetByID(ctx, trip.DriverID); err == nil && driver != nil {
+			s.driverCache.SetDriverMeta(ctx, driver.ID, models.DriverStatusOffline, driver.VehicleType, driver.Rating)
+			s.driverCache.RemoveDriver(ctx, driver.ID, driver.VehicleType)
+		}
+	}
+
+	trip.Status = models.TripStatusHandover
+	return &models.HandoverResponse{Trip: trip.ToResponse(), Segments: segments}, nil
+}
+
+// AssignRescueDriver hands a frozen trip to a rescue driver, either the one ops
+// picked or the nearest available driver to the breakdown point
+func (s *handoverService) AssignRescueDriver(ctx context.Context, tripID string, req *models.AssignRescueDriverRequest) (*models.HandoverResponse, error) {
+	trip, err := s.tripRepo.GetByID(ctx, tripID)
+	if err != nil {
+		return nil, err
+	}
+	if trip == nil {
+		return nil, apperrors.NotFound("trip")
+	}
+	if trip.Status != models.TripStatusHandover {
+		return nil, apperrors.BadRequest("trip is not awaiting a rescue driver")
+	}
+
+	ride, err := s.rideRepo.GetByID(ctx, trip.RideID)
+	if err != nil {
+		return nil, err
+	}
+	if ride == nil {
+		return nil, apperrors.NotFound("ride")
+	}
+
+	segments, err := s.segmentRepo.GetByTripID(ctx, trip.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(segments) == 0 {
+		return nil, apperrors.BadRequest("trip has no handover point")
+	}
+	last := segments[len(segments)-1]
+	if last.EndLat == nil || last.EndLng == nil {
+		return nil, apperrors.BadRequest("trip has no handover point")
+	}
+
+	rescueID := req.DriverID
+	if rescueID == "" {
+		rescueID, err = s.nearestRescueDriver(ctx, *last.EndLat, *last.EndLng, ride.VehicleType, trip.DriverID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if rescueID == trip.DriverID {
+		return nil, apperrors.BadRequest("rescue driver must differ from the current driver")
+	}
+
+	now := time.Now()
+	next := &models.TripSegment{
+		TripID:    trip.ID,
+		DriverID:  rescueID,
+		Sequence:  last.Sequence + 1,
+		StartTime: now,
+		StartLat:  *last.EndLat,
+		StartLng:  *last.EndLng,
+	}
+	err = s.transactor.InTx(ctx, func(tx *sqlx.Tx) error {
+		rescue, err := s.driverRepo.GetByIDForUpdate(ctx, tx, rescueID)
+		if err != nil {
+			return err
+		}
+		if rescue == nil {
+			return apperrors.NotFound("driver")
+		}
+		if rescue.Status != models.DriverStatusOnline {
+			return apperrors.Conflict("rescue driver is not available")
+		}
+
+		if err := s.driverRepo.SetStatus(ctx, tx, rescueID, models.DriverStatusBusy, now); err != nil {
+			return err
+		}
+		if err := s.tripRepo.SetDriver(ctx, tx, trip.ID, rescueID, models.TripStatusStarted, now); err != nil {
+			return err
+		}
+		if err := s.rideRepo.SetDriver(ctx, tx, ride.ID, rescueID, now); err != nil {
+			return err
+		}
+
+		return s.segmentRepo.Create(ctx, tx, next)
+	})
+	if err != nil {
+		return nil, err
+	}
+	models.TripStates.Record(ctx, trip.ID, trip.Status, models.TripStatusStarted)
+	syncDriverStatus(ctx, s.driverCache, rescueID, models.DriverStatusBusy, now)
+
+	if s.driverCache != nil {
+		s.driverCache.SetActiveRide(ctx, rescueID, ride.ID)
+		if err := s.driverCache.StartTripDistance(ctx, rescueID); err != nil {
+			log.Printf("failed to start trip distance tracking: %v", err)
+		}
+	}
+
+	trip.DriverID = rescueID
+	trip.Status = models.TripStatusStarted
+	return &models.HandoverResponse{Trip: trip.ToResponse(), Segments: append(segments, next)}, nil
+}
+
+// segmentDistance prefers the GPS distance tracked for the driver, falling back
+// to a road estimate between the segment's endpoints
+func (s *handoverService) segmentDistance(ctx context.Context, driverID string, segment *models.TripSegment, lat, lng float64) float64 {
+	if s.driverCache != nil {
+		km, err := s.driverCache.GetTripDistance(ctx, driverID)
+		if err != nil {
+			log.Printf("failed to read trip distance: %v", err)
+		} else if km > 0 {
+			return round(km)
+		}
+	}
+	return s.pricingService.EstimateDistance(segment.StartLat, segment.StartLng, lat, lng)
+}
+
+func (s *handoverService) nearestRescueDriver(ctx context.Context, lat, lng float64, vehicleType, excludeID string) (string, error) {
+	if s.driverCache == nil {
+		return "", apperrors.NoDriversAvailable()
+	}
+
+	nearby, err := s.driverCache.GetNearbyDrivers(ctx, lat, lng, rescueSearchRadius, vehicleType)
+	if err != nil {
+		return "", err
+	}
+	for _, d := range nearby {
+		if d.DriverID == excludeID {
+			continue
+		}
+		meta, err := s.driverCache.GetDriverMeta(ctx, d.DriverID)
+		if err != nil || meta["status"] != models.DriverStatusOnline {
+			continue
+		}
+		if active, _ := s.driverCache.GetActiveRide(ctx, d.DriverID); active != "" {
+			continue
+		}
+		return d.DriverID, nil
+	}
+	return "", apperrors.NoDriversAvailable()
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"log"
+	"math"
+	"time"
+
+	apperrors "github.com/aditya/go-comet/internal/errors"
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+const (
+	// Keeps a heat query to a city rather than a continent
+	maxHeatSpanDegrees = 1.0
+	maxHeatWeeks       = 52
+)
+
+// HeatService snapshots supply and demand per grid cell and answers which cells
+// are usually busy at a given hour of the week, for driver guidance and ops planning
+type HeatService interface {
+	Capture(ctx context.Context) (int, error)
+	History(ctx context.Context, q *models.HeatQuery, weeks int) (*models.HeatHistoryResponse, error)
+}
+
+type heatService struct {
+	heatRepo    repository.HeatRepository
+	cellDegrees float64
+	// Each capture counts the rides requested since the previous one
+	interval  time.Duration
+	retention time.Duration
+}
+
+func NewHeatService(heatRepo repository.HeatRepository, cellDegrees float64, interval, retention time.Duration) HeatService {
+	return &heatService{
+		heatRepo:    heatRepo,
+		cellDegrees: cellDegrees,
+		interval:    interval,
+		retention:   retention,
+	}
+}
+
+func (s *heatService) Capture(ctx context.Context) (int, error) {
+	now := time.Now()
+	cells, err := s.heatRepo.Capture(ctx, s.cellDegrees, now.Add(-s.interval), now)
+	if err != nil {
+		return 0, err
+	}
+
+	if s.retention > 0 {
+		deleted, err := s.heatRepo.DeleteBefore(ctx, now.Add(-s.retention))
+		if err != nil {
+			log.Printf("failed to prune heat snapshots: %v", err)
+		} else if deleted > 0 {
+			log.Printf("heat: pruned %d snapshots older than %s", deleted, s.retention)
+		}
+	}
+	return cells, nil
+}
+
+// History averages the snapshots of every cell overlapping the bounding box over
+// the last weeks, per hour of the week
+func (s *heatService) History(ctx context.Context, q *models.HeatQuery, weeks int) (*models.HeatHistoryResponse, error) {
+	if q.MinLat > q.MaxLat || q.MinLng > q.MaxLng {
+		return nil, apperrors.BadRequest("min_lat and min_lng must not exceed max_lat and max_lng")
+	}
+	if q.MaxLat-q.MinLat > maxHeatSpanDegrees || q.MaxLng-q.MinLng > maxHeatSpanDegrees {
+		return nil, apperrors.BadRequest("bounding box must span at most 1 degree")
+	}
+	if weeks < 1 || weeks > maxHeatWeeks {
+		return nil, apperrors.BadRequest("weeks must be between 1 and 52")
+	}
+
+	now := time.Now()
+	// Cells are keyed by their south-west corner, so widen the box to include
+	// cells the minimum edge falls inside
+	q.MinLat = math.Floor(q.MinLat/s.cellDegrees) * s.cellDegrees
+	q.MinLng = math.Floor(q.MinLng/s.cellDegrees) * s.cellDegrees
+	q.Since = now.AddDate(0, 0, -7*weeks)
+
+	cells, err := s.heatRepo.GetHistory(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	if cells == nil {
+		cells = []*models.HeatCell{}
+	}
+
+	return &models.HeatHistoryResponse{
+		CellDegrees: s.cellDegrees,
+		From:        q.Since,
+		To:          now,
+		Cells:       cells,
+	}, nil
+}
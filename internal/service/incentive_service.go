@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	apperrors "github.com/aditya/go-comet/internal/errors"
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+// IncentiveService manages minimum-earnings guarantees and tops up trips that fall short
+type IncentiveService interface {
+	CreateGuarantee(ctx context.Context, req *models.CreateIncentiveGuaranteeRequest) (*models.IncentiveGuarantee, error)
+	ListGuarantees(ctx context.Context) ([]*models.IncentiveGuarantee, error)
+	SetGuaranteeActive(ctx context.Context, id string, active bool) (*models.IncentiveGuarantee, error)
+	// ApplyGuarantee credits the driver the difference between their net earnings on a
+	// completed trip and the best guarantee it qualifies for. Returns nil when no top-up is due.
+	ApplyGuarantee(ctx context.Context, trip *models.Trip, ride *models.Ride) (*models.LedgerEntry, error)
+}
+
+type incentiveService struct {
+	incentiveRepo     repository.IncentiveRepository
+	trainingRepo      repository.TrainingRepository
+	commissionService CommissionService
+}
+
+func NewIncentiveService(
+	incentiveRepo repository.IncentiveRepository,
+	trainingRepo repository.TrainingRepository,
+	commissionService CommissionService,
+) IncentiveService {
+	return &incentiveService{
+		incentiveRepo:     incentiveRepo,
+		trainingRepo:      trainingRepo,
+		commissionService: commissionService,
+	}
+}
+
+func (s *incentiveService) CreateGuarantee(ctx context.Context, req *models.CreateIncentiveGuaranteeRequest) (*models.IncentiveGuarantee, error) {
+	start, err := models.ParseMinuteOfDay(req.StartTime)
+	if err != nil {
+		return nil, apperrors.BadRequest(err.Error())
+	}
+	end, err := models.ParseMinuteOfDay(req.EndTime)
+	if err != nil {
+		return nil, apperrors.BadRequest(err.Error())
+	}
+	if start == end {
+		return nil, apperrors.BadRequest("start_time and end_time must differ")
+	}
+
+	guarantee := &models.IncentiveGuarantee{
+		Name:        req.Name,
+		CenterLat:   req.CenterLat,
+		CenterLng:   req.CenterLng,
+		RadiusKm:    req.RadiusKm,
+		StartMinute: start,
+		EndMinute:   end,
+		MinEarnings: req.MinEarnings,
+	}
+	if req.RegionCode != "" {
+		guarantee.RegionCode = &req.RegionCode
+	}
+	if req.VehicleType != "" {
+		guarantee.VehicleType = &req.VehicleType
+	}
+	if req.RequiredTraining != "" {
+		guarantee.RequiredTraining = &req.RequiredTraining
+	}
+
+	if err := s.incentiveRepo.Create(ctx, guarantee); err != nil {
+		return nil, err
+	}
+	return guarantee, nil
+}
+
+func (s *incentiveService) ListGuarantees(ctx context.Context) ([]*models.IncentiveGuarantee, error) {
+	guarantees, err := s.incentiveRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if guarantees == nil {
+		guarantees = []*models.IncentiveGuarantee{}
+	}
+	return guarantees, nil
+}
+
+func (s *incentiveService) SetGuaranteeActive(ctx context.Context, id string, active bool) (*models.IncentiveGuarantee, error) {
+	guarantee, err := s.incentiveRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if guarantee == nil {
+		return nil, apperrors.NotFound("incentive guarantee")
+	}
+
+	if err := s.incentiveRepo.SetActive(ctx, id, active); err != nil {
+		return nil, err
+	}
+	guarantee.Active = active
+	return guarantee, nil
+}
+
+func (s *incentiveService) ApplyGuarantee(ctx context.Context, trip *models.Trip, ride *models.Ride) (*models.LedgerEntry, error) {
+	if trip.TotalFare == nil || trip.StartTime == nil {
+		return nil, nil
+	}
+
+	guarantees, err := s.incentiveRepo.GetActive(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// The window is judged on when the driver picked the rider up
+	pickedUpAt := trip.StartTime.Local()
+	trained := make(map[string]bool)
+	var best *models.IncentiveGuarantee
+	for _, g := range guarantees {
+		if !guaranteeApplies(g, ride, pickedUpAt) {
+			continue
+		}
+		if g.RequiredTraining != nil {
+			done, checked := trained[*g.RequiredTraining]
+			if !checked {
+				done, err = s.trainingRepo.HasCompleted(ctx, trip.DriverID, *g.RequiredTraining)
+				if err != nil {
+					return nil, err
+				}
+				trained[*g.RequiredTraining] = done
+			}
+			if !done {
+				continue
+			}
+		}
+		if best == nil || g.MinEarnings > best.MinEarnings {
+			best = g
+		}
+	}
+	if best == nil {
+		return nil, nil
+	}
+
+	rate, err := s.commissionService.RateAt(ctx, trip.DriverID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	net := models.NewCommissionSplit(*trip.TotalFare, rate).DriverEarnings
+	topUp := round(best.MinEarnings - net)
+	if topUp <= 0 {
+		return nil, nil
+	}
+
+	description := fmt.Sprintf("%s guarantee top-up", best.Name)
+	entry := &models.LedgerEntry{
+		DriverID:    trip.DriverID,
+		Amount:      topUp,
+		Description: &description,
+		TripID:      &trip.ID,
+		IncentiveID: &best.ID,
+	}
+	recorded, err := s.incentiveRepo.RecordTopUp(ctx, entry)
+	if err != nil {
+		return nil, err
+	}
+	if !recorded {
+		return nil, nil
+	}
+
+	log.Printf("incentives: topped up trip %s by %.2f under %s", trip.ID, topUp, best.Name)
+	return entry, nil
+}
+
+func guaranteeApplies(g *models.IncentiveGuarantee, ride *models.Ride, pickedUpAt time.Time) bool {
+	if g.RegionCode != nil && (ride.RegionCode == nil || *ride.RegionCode != *g.RegionCode) {
+		return false
+	}
+	if g.VehicleType != nil && *g.VehicleType != ride.VehicleType {
+		return false
+	}
+	if g.HasZone() && haversineDistance(*g.CenterLat, *g.CenterLng, ride.PickupLat, ride.PickupLng) > *g.RadiusKm {
+		return false
+	}
+	return g.InWindow(pickedUpAt)
+}
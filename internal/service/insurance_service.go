@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	apperrors "github.com/aditya/go-comet/internal/errors"
+	"github.com/aditya/go-comet/internal/insurance"
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+const (
+	// policyIssueTimeout bounds each insurer call issuing a policy
+	policyIssueTimeout = 3 * time.Second
+	// Trips started this long ago without a policy are picked up by the
+	// backfill, leaving newer ones to the policy issued as they start
+	policyBackfillDelay = time.Minute
+	policyBackfillBatch = 100
+)
+
+type InsuranceService interface {
+	// AttachPolicy issues a policy for a newly started trip. Failures are logged
+	// rather than returned, for AttachMissingPolicies to retry: a trip is never
+	// blocked on the insurer.
+	AttachPolicy(ctx context.Context, trip *models.Trip)
+	// AttachMissingPolicies issues policies for trips in progress that are still
+	// without one, returning how many were issued
+	AttachMissingPolicies(ctx context.Context) (int, error)
+	GetPolicy(ctx context.Context, tripID string) (*models.TripInsurance, error)
+	FileClaim(ctx context.Context, tripID string, req *models.CreateClaimRequest) (*models.InsuranceClaim, error)
+	GetClaim(ctx context.Context, id string) (*models.InsuranceClaim, error)
+}
+
+type insuranceService struct {
+	insuranceRepo repository.InsuranceRepository
+	tripRepo      repository.TripRepository
+	rideRepo      repository.RideRepository
+	insurer       insurance.Insurer
+}
+
+// NewInsuranceService creates the insurance service. insurer may be nil, in which
+// case trips run without policies and claims are only recorded.
+func NewInsuranceService(
+	insuranceRepo repository.InsuranceRepository,
+	tripRepo repository.TripRepository,
+	rideRepo repository.RideRepository,
+	insurer insurance.Insurer,
+) InsuranceService {
+	return &insuranceService{
+		insuranceRepo: insuranceRepo,
+		tripRepo:      tripRepo,
+		rideRepo:      rideRepo,
+		insurer:       insurer,
+	}
+}
+
+func (s *insuranceService) AttachPolicy(ctx context.Context, trip *models.Trip) {
+	if err := s.issuePolicy(ctx, trip); err != nil {
+		log.Printf("failed to issue insurance policy for trip %s: %v", trip.ID, err)
+	}
+}
+
+func (s *insuranceService) AttachMissingPolicies(ctx context.Context) (int, error) {
+	if s.insurer == nil {
+		return 0, nil
+	}
+	trips, err := s.insuranceRepo.GetUninsuredTrips(ctx, time.Now().Add(-policyBackfillDelay), policyBackfillBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	issued := 0
+	for _, trip := range trips {
+		if err := s.issuePolicy(ctx, trip); err != nil {
+			log.Printf("failed to backfill insurance policy for trip %s: %v", trip.ID, err)
+			continue
+		}
+		issued++
+	}
+	if issued > 0 {
+		log.Printf("issued insurance policies for %d trips started without one", issued)
+	}
+	return issued, nil
+}
+
+func (s *insuranceService) issuePolicy(ctx context.Context, trip *models.Trip) error {
+	if s.insurer == nil {
+		return nil
+	}
+	ride, err := s.rideRepo.GetByID(ctx, trip.RideID)
+	if err != nil {
+		return err
+	}
+	if ride == nil {
+		return apperrors.NotFound("ride")
+	}
+
+	req := &insurance.PolicyRequest{
+		TripID:      trip.ID,
+		RideID:      trip.RideID,
+		DriverID:    trip.DriverID,
+		UserID:      trip.UserID,
+		VehicleType: ride.VehicleType,
+		StartedAt:   time.Now(),
+	}
+	if trip.StartTime != nil {
+		req.StartedAt = *trip.StartTime
+	}
+
+	issueCtx, cancel := context.WithTimeout(ctx, policyIssueTimeout)
+	defer cancel()
+	policy, err := s.insurer.IssuePolicy(issueCtx, req)
+	if err != nil {
+		return err
+	}
+
+	record := &models.TripInsurance{
+		TripID:       trip.ID,
+		Provider:     s.insurer.Name(),
+		PolicyNumber: policy.PolicyNumber,
+		Coverage: models.InsuranceCoverage{
+			Currency: policy.Coverage.Currency,
+			Limits:   policy.Coverage.Limits,
+		},
+	}
+	return s.insuranceRepo.CreatePolicy(ctx, record)
+}
+
+func (s *insuranceService) GetPolicy(ctx context.Context, tripID string) (*models.TripInsurance, error) {
+	return s.insuranceRepo.GetPolicyByTripID(ctx, tripID)
+}
+
+func (s *insuranceService) FileClaim(ctx context.Context, tripID string, req *models.CreateClaimRequest) (*models.InsuranceClaim, error) {
+	trip, err := s.tripRepo.GetByID(ctx, tripID)
+	if err != nil {
+		return nil, err
+	}
+	if trip == nil {
+		return nil, apperrors.NotFound("trip")
+	}
+
+	if (req.ClaimantType == models.ClaimantUser && req.ClaimantID != trip.UserID) ||
+		(req.ClaimantType == models.ClaimantDriver && req.ClaimantID != trip.DriverID) {
+		return nil, apperrors.BadRequest("claimant was not part of this trip")
+	}
+	if req.IncidentAt.After(time.Now()) {
+		return nil, apperrors.BadRequest("incident_at cannot be in the future")
+	}
+
+	claim := &models.InsuranceClaim{
+		TripID:       trip.ID,
+		ClaimantType: req.ClaimantType,
+		ClaimantID:   req.ClaimantID,
+		IncidentType: req.IncidentType,
+		IncidentAt:   req.IncidentAt,
+		Description:  req.Description,
+	}
+	if err := s.insuranceRepo.CreateClaim(ctx, claim); err != nil {
+		return nil, err
+	}
+
+	// Forward to the insurer when the trip was covered; the claim stays
+	// "received" for manual follow-up if that fails
+	policy, err := s.insuranceRepo.GetPolicyByTripID(ctx, trip.ID)
+	if err != nil {
+		log.Printf("failed to load policy for claim %s: %v", claim.ID, err)
+		return claim, nil
+	}
+	if policy == nil || s.insurer == nil {
+		return claim, nil
+	}
+
+	ack, err := s.insurer.FileClaim(ctx, &insurance.ClaimRequest{
+		ClaimID:      claim.ID,
+		PolicyNumber: policy.PolicyNumber,
+		TripID:       trip.ID,
+		IncidentType: claim.IncidentType,
+		IncidentAt:   claim.IncidentAt,
+		Description:  claim.Description,
+	})
+	if err != nil {
+		log.Printf("failed to submit claim %s to insurer: %v", claim.ID, err)
+		return claim, nil
+	}
+
+	if err := s.insuranceRepo.MarkClaimSubmitted(ctx, claim.ID, ack.Reference); err != nil {
+		log.Printf("failed to mark claim %s submitted: %v", claim.ID, err)
+		return claim, nil
+	}
+	claim.Status = models.ClaimStatusSubmitted
+	claim.ProviderReference = &ack.Reference
+
+	return claim, nil
+}
+
+func (s *insuranceService) GetClaim(ctx context.Context, id string) (*models.InsuranceClaim, error) {
+	claim, err := s.insuranceRepo.GetClaimByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if claim == nil {
+		return nil, apperrors.NotFound("claim")
+	}
+	return claim, nil
+}
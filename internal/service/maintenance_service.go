@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	apperrors "github.com/aditya/go-comet/internal/errors"
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+// MaintenanceService schedules maintenance windows, during which new rides can't
+// be booked. Rides and trips already under way aren't affected.
+type MaintenanceService interface {
+	CreateWindow(ctx context.Context, req *models.CreateMaintenanceWindowRequest) (*models.MaintenanceWindow, error)
+	ListWindows(ctx context.Context) ([]*models.MaintenanceWindow, error)
+	SetWindowActive(ctx context.Context, id string, active bool) (*models.MaintenanceWindow, error)
+	// Notice returns the window in effect or starting within the warning period,
+	// or nil
+	Notice(ctx context.Context) (*models.MaintenanceNotice, error)
+	// CheckBooking returns a Maintenance error while a window is in effect
+	CheckBooking(ctx context.Context) error
+}
+
+type maintenanceService struct {
+	maintenanceRepo repository.MaintenanceRepository
+	warning         time.Duration
+}
+
+func NewMaintenanceService(maintenanceRepo repository.MaintenanceRepository, warning time.Duration) MaintenanceService {
+	return &maintenanceService{
+		maintenanceRepo: maintenanceRepo,
+		warning:         warning,
+	}
+}
+
+func (s *maintenanceService) CreateWindow(ctx context.Context, req *models.CreateMaintenanceWindowRequest) (*models.MaintenanceWindow, error) {
+	startsAt := time.Now()
+	if req.StartsAt != nil {
+		startsAt = *req.StartsAt
+	}
+	if !startsAt.Before(req.EndsAt) {
+		return nil, apperrors.BadRequest("starts_at must be before ends_at")
+	}
+	if !req.EndsAt.After(time.Now()) {
+		return nil, apperrors.BadRequest("ends_at must be in the future")
+	}
+
+	window := &models.MaintenanceWindow{
+		Message:   req.Message,
+		StartsAt:  startsAt,
+		EndsAt:    req.EndsAt,
+		CreatedBy: req.CreatedBy,
+	}
+	if err := s.maintenanceRepo.Create(ctx, window); err != nil {
+		return nil, err
+	}
+	return window, nil
+}
+
+func (s *maintenanceService) ListWindows(ctx context.Context) ([]*models.MaintenanceWindow, error) {
+	windows, err := s.maintenanceRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if windows == nil {
+		windows = []*models.MaintenanceWindow{}
+	}
+	return windows, nil
+}
+
+func (s *maintenanceService) SetWindowActive(ctx context.Context, id string, active bool) (*models.MaintenanceWindow, error) {
+	window, err := s.maintenanceRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if window == nil {
+		return nil, apperrors.NotFound("maintenance window")
+	}
+
+	if err := s.maintenanceRepo.SetActive(ctx, id, active); err != nil {
+		return nil, err
+	}
+	window.Active = active
+	return window, nil
+}
+
+func (s *maintenanceService) Notice(ctx context.Context) (*models.MaintenanceNotice, error) {
+	now := time.Now()
+	window, err := s.maintenanceRepo.GetNext(ctx, now, now.Add(s.warning))
+	if err != nil || window == nil {
+		return nil, err
+	}
+	return window.Notice(now), nil
+}
+
+func (s *maintenanceService) CheckBooking(ctx context.Context) error {
+	now := time.Now()
+	window, err := s.maintenanceRepo.GetNext(ctx, now, now)
+	if err != nil {
+		// Bookings stay open rather than failing on a lookup error
+		log.Printf("failed to check maintenance windows: %v", err)
+		return nil
+	}
+	if window == nil {
+		return nil
+	}
+	return apperrors.Maintenance(window.Notice(now))
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	apperrors "github.com/aditya/go-comet/internal/errors"
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+// MatchingExclusionService lets ops take drivers and areas out of matching for a
+// limited window, recording who did it and why
+type MatchingExclusionService interface {
+	CreateExclusion(ctx context.Context, req *models.CreateMatchingExclusionRequest) (*models.MatchingExclusion, error)
+	RevokeExclusion(ctx context.Context, id string, req *models.RevokeMatchingExclusionRequest) (*models.MatchingExclusion, error)
+	ListExclusions(ctx context.Context, activeOnly bool) ([]*models.MatchingExclusion, error)
+	// ExcludedAt returns the drivers excluded right now and the area exclusion
+	// covering the point, if any
+	ExcludedAt(ctx context.Context, lat, lng float64) (map[string]bool, *models.MatchingExclusion, error)
+}
+
+type matchingExclusionService struct {
+	exclusionRepo repository.MatchingExclusionRepository
+	driverRepo    repository.DriverRepository
+}
+
+func NewMatchingExclusionService(exclusionRepo repository.MatchingExclusionRepository, driverRepo repository.DriverRepository) MatchingExclusionService {
+	return &matchingExclusionService{
+		exclusionRepo: exclusionRepo,
+		driverRepo:    driverRepo,
+	}
+}
+
+func (s *matchingExclusionService) CreateExclusion(ctx context.Context, req *models.CreateMatchingExclusionRequest) (*models.MatchingExclusion, error) {
+	now := time.Now()
+	startsAt := now
+	if req.StartsAt != nil {
+		startsAt = *req.StartsAt
+	}
+	if !req.ExpiresAt.After(now) || !req.ExpiresAt.After(startsAt) {
+		return nil, apperrors.BadRequest("expires_at must be in the future and after starts_at")
+	}
+
+	exclusion := &models.MatchingExclusion{
+		Kind:      req.Kind,
+		Reason:    req.Reason,
+		CreatedBy: req.CreatedBy,
+		StartsAt:  startsAt,
+		ExpiresAt: req.ExpiresAt,
+	}
+	switch req.Kind {
+	case models.MatchingExclusionDriver:
+		driver, err := s.driverRepo.GetByID(ctx, req.DriverID)
+		if err != nil {
+			return nil, err
+		}
+		if driver == nil {
+			return nil, apperrors.NotFound("driver")
+		}
+		exclusion.DriverID = &req.DriverID
+	case models.MatchingExclusionArea:
+		exclusion.CenterLat = req.CenterLat
+		exclusion.CenterLng = req.CenterLng
+		exclusion.RadiusKm = req.RadiusKm
+	}
+
+	if err := s.exclusionRepo.Create(ctx, exclusion); err != nil {
+		return nil, err
+	}
+	return exclusion, nil
+}
+
+// RevokeExclusion ends an exclusion early; the row is kept for the audit trail
+func (s *matchingExclusionService) RevokeExclusion(ctx context.Context, id string, req *models.RevokeMatchingExclusionRequest) (*models.MatchingExclusion, error) {
+	exclusion, err := s.exclusionRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if exclusion == nil {
+		return nil, apperrors.NotFound("matching exclusion")
+	}
+
+	// Scheduled exclusions can be called off before they start
+	now := time.Now()
+	if exclusion.RevokedAt != nil || !now.Before(exclusion.ExpiresAt) {
+		return nil, apperrors.BadRequest("matching exclusion is no longer active")
+	}
+	if err := s.exclusionRepo.Revoke(ctx, id, req.RevokedBy, now); err != nil {
+		return nil, err
+	}
+	exclusion.RevokedAt = &now
+	exclusion.RevokedBy = &req.RevokedBy
+	return exclusion, nil
+}
+
+func (s *matchingExclusionService) ListExclusions(ctx context.Context, activeOnly bool) ([]*models.MatchingExclusion, error) {
+	var activeAt *time.Time
+	if activeOnly {
+		now := time.Now()
+		activeAt = &now
+	}
+	exclusions, err := s.exclusionRepo.List(ctx, activeAt)
+	if err != nil {
+		return nil, err
+	}
+	if exclusions == nil {
+		exclusions = []*models.MatchingExclusion{}
+	}
+	return exclusions, nil
+}
+
+func (s *matchingExclusionService) ExcludedAt(ctx context.Context, lat, lng float64) (map[string]bool, *models.MatchingExclusion, error) {
+	now := time.Now()
+	exclusions, err := s.exclusionRepo.List(ctx, &now)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	drivers := make(map[string]bool)
+	var area *models.MatchingExclusion
+	for _, e := range exclusions {
+		switch e.Kind {
+		case models.MatchingExclusionDriver:
+			if e.DriverID != nil {
+				drivers[*e.DriverID] = true
+			}
+		case models.MatchingExclusionArea:
+			if area == nil && e.CenterLat != nil && e.CenterLng != nil && e.RadiusKm != nil &&
+				haversineDistance(*e.CenterLat, *e.CenterLng, lat, lng) <= *e.RadiusKm {
+				area = e
+			}
+		}
+	}
+	return drivers, area, nil
+}
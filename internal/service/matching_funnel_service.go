@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+// MatchingFunnelService reports how drivers drop out between being found near a
+// pickup and accepting the offer, so dispatch tuning can target the leakiest stage
+type MatchingFunnelService interface {
+	// Report sums dispatch rounds over the window, for one region or all when region is empty
+	Report(ctx context.Context, window time.Duration, region string) (*models.MatchingFunnelReport, error)
+}
+
+type matchingFunnelService struct {
+	roundRepo repository.DispatchRoundRepository
+}
+
+func NewMatchingFunnelService(roundRepo repository.DispatchRoundRepository) MatchingFunnelService {
+	return &matchingFunnelService{roundRepo: roundRepo}
+}
+
+func (s *matchingFunnelService) Report(ctx context.Context, window time.Duration, region string) (*models.MatchingFunnelReport, error) {
+	now := time.Now()
+	from := now.Add(-window)
+	funnels, err := s.roundRepo.GetFunnel(ctx, from, region)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.MatchingFunnelReport{From: from, To: now, Funnels: []*models.MatchingFunnel{}}
+	for _, f := range funnels {
+		f.Conversion = map[string]float64{
+			models.FunnelStagePassedFilters: conversion(f.PassedFilters, f.Candidates),
+			models.FunnelStageOffered:       conversion(f.Offered, f.PassedFilters),
+			models.FunnelStageViewed:        conversion(f.Viewed, f.Offered),
+			models.FunnelStageAccepted:      conversion(f.Accepted, f.Viewed),
+		}
+		f.AvgResponseSecs = round(f.AvgResponseSecs)
+		f.MedianResponseSecs = round(f.MedianResponseSecs)
+		f.P90ResponseSecs = round(f.P90ResponseSecs)
+		report.Funnels = append(report.Funnels, f)
+	}
+	return report, nil
+}
+
+// conversion is the percentage of the previous stage that reached this one
+func conversion(reached, previous int) float64 {
+	if previous == 0 {
+		return 0
+	}
+	return round(float64(reached) / float64(previous) * 100)
+}
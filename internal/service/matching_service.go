@@ -3,23 +3,46 @@ package service
 import (
 	"context"
 	"log"
+	"math"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/aditya/go-comet/internal/cache"
 	apperrors "github.com/aditya/go-comet/internal/errors"
+	"github.com/aditya/go-comet/internal/metrics"
 	"github.com/aditya/go-comet/internal/models"
 	"github.com/aditya/go-comet/internal/repository"
+	"github.com/aditya/go-comet/internal/statemachine"
+	"github.com/aditya/go-comet/internal/tenant"
 )
 
 const (
 	defaultOfferTimeout = 15 * time.Second
 	defaultMatchRadius  = 5.0 // km
 	maxRetries          = 3
+
+	// Bid rides are offered to more drivers so the rider has counter-offers to compare
+	standardMaxOffers = 3
+	bidMaxOffers      = 5
+
+	// Used to estimate how long a driver has left on their current trip
+	avgCitySpeedKmh = 25.0
+
+	// EVs must have this much more range than the pickup plus trip distance
+	evRangeBuffer = 1.2
+	evRangeMaxAge = 15 * time.Minute
 )
 
 type MatchingService interface {
 	FindAndOfferDrivers(ctx context.Context, ride *models.Ride) error
+	// RematchRide runs a fresh dispatch round for a ride still waiting for a
+	// driver, for ops nudging a ride that looks stuck
+	RematchRide(ctx context.Context, rideID string) (*models.Ride, error)
+	// EscalateUnmatched re-offers rides whose last round ended without an
+	// acceptance to the next batch of drivers further out, and cancels those that
+	// have used up their retries
+	EscalateUnmatched(ctx context.Context) (int, error)
 	GetPendingOffers(ctx context.Context, driverID string) ([]*models.RideOfferResponse, error)
 }
 
@@ -27,97 +50,254 @@ type ScoredDriver struct {
 	DriverID string
 	Score    float64
 	Distance float64
+	Chained  bool // still on a trip ending near this pickup
 }
 
 type matchingService struct {
-	driverRepo    repository.DriverRepository
-	rideRepo      repository.RideRepository
-	offerRepo     repository.RideOfferRepository
-	driverCache   cache.DriverLocationCache
-	offerTimeout  time.Duration
-	matchRadius   float64
+	driverRepo     repository.DriverRepository
+	rideRepo       repository.RideRepository
+	offerRepo      repository.RideOfferRepository
+	roundRepo      repository.DispatchRoundRepository
+	favoriteRepo   repository.FavoriteRepository
+	userRepo       repository.UserRepository
+	trainingRepo   repository.TrainingRepository
+	regionService  RegionService
+	exclusions     MatchingExclusionService
+	driverCache    cache.DriverLocationCache
+	offerTimeout   models.OfferTimeoutPolicy
+	dispatchPolicy models.DispatchPolicy
+	favoriteBoost  float64
+	evReserveKm    float64
+	bidPolicy      models.BidPolicy
+	chainPolicy    models.ChainPolicy
+	// Most a driver who answers offers instantly gains over a slow one
+	responseBoost float64
 }
 
 func NewMatchingService(
 	driverRepo repository.DriverRepository,
 	rideRepo repository.RideRepository,
 	offerRepo repository.RideOfferRepository,
+	roundRepo repository.DispatchRoundRepository,
+	favoriteRepo repository.FavoriteRepository,
+	userRepo repository.UserRepository,
+	trainingRepo repository.TrainingRepository,
+	regionService RegionService,
+	exclusions MatchingExclusionService,
 	driverCache cache.DriverLocationCache,
+	favoriteBoost float64,
+	evReserveKm float64,
+	bidPolicy models.BidPolicy,
+	chainPolicy models.ChainPolicy,
+	offerTimeout models.OfferTimeoutPolicy,
+	dispatchPolicy models.DispatchPolicy,
+	responseBoost float64,
 ) MatchingService {
+	if offerTimeout.Base <= 0 {
+		offerTimeout.Base = defaultOfferTimeout
+	}
+	if len(dispatchPolicy.RadiiKm) == 0 {
+		dispatchPolicy.RadiiKm = []float64{defaultMatchRadius}
+	}
 	return &matchingService{
-		driverRepo:   driverRepo,
-		rideRepo:     rideRepo,
-		offerRepo:    offerRepo,
-		driverCache:  driverCache,
-		offerTimeout: defaultOfferTimeout,
-		matchRadius:  defaultMatchRadius,
+		driverRepo:     driverRepo,
+		rideRepo:       rideRepo,
+		offerRepo:      offerRepo,
+		roundRepo:      roundRepo,
+		favoriteRepo:   favoriteRepo,
+		userRepo:       userRepo,
+		trainingRepo:   trainingRepo,
+		regionService:  regionService,
+		exclusions:     exclusions,
+		driverCache:    driverCache,
+		offerTimeout:   offerTimeout,
+		dispatchPolicy: dispatchPolicy,
+		favoriteBoost:  favoriteBoost,
+		evReserveKm:    evReserveKm,
+		bidPolicy:      bidPolicy,
+		chainPolicy:    chainPolicy,
+		responseBoost:  responseBoost,
 	}
 }
 
 func (s *matchingService) FindAndOfferDrivers(ctx context.Context, ride *models.Ride) error {
+	// Only drivers of the brand the ride was booked under are offered it
+	ctx = tenant.WithCode(ctx, ride.TenantCode)
+
+	// Each round searches further out and skips drivers already offered the ride.
+	// The round is recorded up front so escalation retries it even if it fails.
+	radius := s.dispatchPolicy.RadiusFor(ride.MatchingAttempts)
+	ride.MatchingAttempts++
+	if err := s.rideRepo.RecordDispatch(ctx, ride.ID, ride.MatchingAttempts, radius, time.Now()); err != nil {
+		log.Printf("failed to record dispatch round %d for ride %s: %v", ride.MatchingAttempts, ride.ID, err)
+	}
+	ride.MatchRadiusKm = &radius
+
+	return s.dispatch(ctx, ride, radius, false)
+}
+
+func (s *matchingService) RematchRide(ctx context.Context, rideID string) (*models.Ride, error) {
+	ride, err := s.rideRepo.GetByID(ctx, rideID)
+	if err != nil {
+		return nil, err
+	}
+	if ride == nil {
+		return nil, apperrors.NotFound("ride")
+	}
+	if ride.Status != models.RideStatusMatching {
+		return nil, apperrors.BadRequest("only rides in matching can be rematched")
+	}
+
+	if err := s.FindAndOfferDrivers(ctx, ride); err != nil {
+		return nil, err
+	}
+	return ride, nil
+}
+
+// offerNextInLine offers a sequentially dispatched ride to the next driver within
+// its current round's radius. It returns ErrNoDriversAvailable, without recording
+// anything, once everyone there has been offered the ride.
+func (s *matchingService) offerNextInLine(ctx context.Context, ride *models.Ride) error {
+	if ride.MatchRadiusKm == nil {
+		return apperrors.ErrNoDriversAvailable
+	}
+	ctx = tenant.WithCode(ctx, ride.TenantCode)
+
+	if err := s.dispatch(ctx, ride, *ride.MatchRadiusKm, true); err != nil {
+		return err
+	}
+	// Restarts the clock escalation waits on
+	if err := s.rideRepo.RecordDispatch(ctx, ride.ID, ride.MatchingAttempts, *ride.MatchRadiusKm, time.Now()); err != nil {
+		log.Printf("failed to record dispatch for ride %s: %v", ride.ID, err)
+	}
+	return nil
+}
+
+// dispatch offers the ride to the best drivers within radius who haven't been
+// offered it yet: several at once when broadcasting, the top one when sequential
+func (s *matchingService) dispatch(ctx context.Context, ride *models.Ride, radius float64, nextInLine bool) error {
+	// Pickups in an excluded area wait for escalation; they're matched if the
+	// exclusion ends before the ride runs out of retries
+	excluded, area, err := s.exclusions.ExcludedAt(ctx, ride.PickupLat, ride.PickupLng)
+	if err != nil {
+		log.Printf("failed to load matching exclusions for ride %s: %v", ride.ID, err)
+	}
+	if area != nil {
+		log.Printf("ride %s picks up inside excluded area %s, not dispatching", ride.ID, area.ID)
+		metrics.CounterMap("matching_excluded").Add(models.MatchingExclusionArea, 1)
+		return apperrors.ErrNoDriversAvailable
+	}
+
+	offered, err := s.offerRepo.GetOfferedDriverIDs(ctx, ride.ID)
+	if err != nil {
+		log.Printf("failed to load drivers already offered ride %s: %v", ride.ID, err)
+	}
+	// Excluded drivers are skipped the same way as those already offered the ride
+	for id := range excluded {
+		if offered == nil {
+			offered = make(map[string]bool, len(excluded))
+		}
+		offered[id] = true
+	}
+
 	// Get nearby drivers from cache
+	var scoredDrivers []ScoredDriver
 	nearbyDrivers, err := s.driverCache.GetNearbyDrivers(
 		ctx,
 		ride.PickupLat,
 		ride.PickupLng,
-		s.matchRadius,
+		radius,
 		ride.VehicleType,
 	)
-	if err != nil {
-		log.Printf("error getting nearby drivers: %v", err)
-		return err
-	}
-
-	if len(nearbyDrivers) == 0 {
-		// Try database fallback
-		dbDrivers, err := s.driverRepo.GetOnlineDriversByVehicleType(ctx, ride.VehicleType)
+	if err == nil {
+		nearbyDrivers = withoutDrivers(nearbyDrivers, offered)
+		records := s.loadCandidates(ctx, nearbyDrivers)
+		scoredDrivers = s.confirmAvailability(ctx, s.scoreDrivers(ctx, nearbyDrivers, records, ride), records)
+	} else {
+		// Redis is unreachable: match on the locations drivers last reported to the
+		// database. An empty cache means nobody is online nearby, not that it failed.
+		log.Printf("driver cache unavailable, matching ride %s from database: %v", ride.ID, err)
+		dLat := radius / kmPerDegreeLat
+		dLng := radius / (kmPerDegreeLat * math.Max(math.Cos(ride.PickupLat*math.Pi/180), 0.01))
+		dbDrivers, err := s.driverRepo.GetOnlineInBounds(ctx, ride.VehicleType,
+			ride.PickupLat-dLat, ride.PickupLng-dLng, ride.PickupLat+dLat, ride.PickupLng+dLng)
 		if err != nil {
 			return err
 		}
 
-		if len(dbDrivers) == 0 {
-			// Cancel ride - no drivers
-			if err := s.rideRepo.Cancel(ctx, ride.ID, "system", "no drivers available"); err != nil {
-				log.Printf("failed to cancel ride: %v", err)
-			}
-			return apperrors.ErrNoDriversAvailable
-		}
-
-		// Convert to cache format
+		// Nobody nearby leaves the ride for escalation to retry further out
+		nearbyDrivers = make([]cache.DriverWithDistance, 0, len(dbDrivers))
 		for _, d := range dbDrivers {
-			if d.CurrentLat != nil && d.CurrentLng != nil {
-				nearbyDrivers = append(nearbyDrivers, cache.DriverWithDistance{
-					DriverID: d.ID,
-					Distance: 0, // Will be calculated
-				})
+			if offered[d.ID] {
+				continue
+			}
+			km := haversineDistance(*d.CurrentLat, *d.CurrentLng, ride.PickupLat, ride.PickupLng)
+			if km <= radius {
+				nearbyDrivers = append(nearbyDrivers, cache.DriverWithDistance{DriverID: d.ID, Distance: km})
 			}
 		}
+		scoredDrivers = s.scoreDBDrivers(ctx, nearbyDrivers, dbDrivers, ride)
 	}
 
-	// Score and sort drivers
-	scoredDrivers := s.scoreDrivers(ctx, nearbyDrivers, ride)
+	// Running out of drivers in line isn't a round of its own
+	if nextInLine && len(scoredDrivers) == 0 {
+		return apperrors.ErrNoDriversAvailable
+	}
+	roundID := s.recordRound(ctx, ride, len(nearbyDrivers), len(scoredDrivers))
 	if len(scoredDrivers) == 0 {
 		return apperrors.ErrNoDriversAvailable
 	}
 
-	// Create offers for top drivers (up to 3)
-	maxOffers := 3
-	if len(scoredDrivers) < maxOffers {
-		maxOffers = len(scoredDrivers)
+	// Create offers for top drivers. Bid rides keep their own longer timeout so
+	// drivers have time to counter.
+	maxOffers := standardMaxOffers
+	if s.dispatchPolicy.Sequential() {
+		maxOffers = 1
+	}
+	timeout := s.offerTimeout.Evaluate(time.Now(), len(nearbyDrivers), ride.SurgeMultiplier)
+	if ride.PricingMode == models.PricingModeBid {
+		maxOffers = bidMaxOffers
+		timeout = models.OfferTimeout{Duration: s.bidPolicy.OfferTimeout}
+	}
+	timeoutSecs := int(timeout.Duration / time.Second)
+	var timeoutReasons *string
+	if len(timeout.Reasons) > 0 {
+		reasons := strings.Join(timeout.Reasons, ",")
+		timeoutReasons = &reasons
 	}
 
-	for i := 0; i < maxOffers; i++ {
-		driver := scoredDrivers[i]
+	created := 0
+	for _, driver := range scoredDrivers {
+		if created == maxOffers {
+			break
+		}
+		// Hold the driver for this ride's offer window; another ride may have just taken them
+		if !s.reserve(ctx, driver.DriverID, ride.ID, timeout.Duration) {
+			continue
+		}
+
 		offer := &models.RideOffer{
-			RideID:    ride.ID,
-			DriverID:  driver.DriverID,
-			ExpiresAt: time.Now().Add(s.offerTimeout),
+			RideID:          ride.ID,
+			DriverID:        driver.DriverID,
+			DispatchRoundID: roundID,
+			ExpiresAt:       time.Now().Add(timeout.Duration),
+			Chained:         driver.Chained,
+			TimeoutSeconds:  &timeoutSecs,
+			TimeoutReasons:  timeoutReasons,
 		}
 
 		if err := s.offerRepo.Create(ctx, offer); err != nil {
 			log.Printf("failed to create offer for driver %s: %v", driver.DriverID, err)
+			s.release(ctx, driver.DriverID, ride.ID)
 			continue
 		}
+		created++
+		metrics.CounterMap("matching_funnel").Add(models.FunnelStageOffered, 1)
+
+		// Drivers connected over the socket get the offer pushed instead of polling
+		if err := s.driverCache.PublishDriverOffer(ctx, driver.DriverID); err != nil {
+			log.Printf("failed to publish offer for driver %s: %v", driver.DriverID, err)
+		}
 
 		log.Printf("created offer %s for driver %s (score: %.2f, distance: %.2f km)",
 			offer.ID, driver.DriverID, driver.Score, driver.Distance)
@@ -126,8 +306,129 @@ func (s *matchingService) FindAndOfferDrivers(ctx context.Context, ride *models.
 	return nil
 }
 
-func (s *matchingService) scoreDrivers(ctx context.Context, drivers []cache.DriverWithDistance, ride *models.Ride) []ScoredDriver {
+func (s *matchingService) EscalateUnmatched(ctx context.Context) (int, error) {
+	rides, err := s.rideRepo.GetDispatchDue(ctx, time.Now().Add(-s.dispatchPolicy.RetryDelay))
+	if err != nil {
+		return 0, err
+	}
+
+	escalated := 0
+	for _, ride := range rides {
+		// Offers past their expiry are still pending until marked
+		if err := s.offerRepo.ExpireOldOffers(ctx, ride.ID); err != nil {
+			log.Printf("failed to expire offers for ride %s: %v", ride.ID, err)
+		}
+
+		// Sequential rides work through everyone in the current radius before widening
+		if s.dispatchPolicy.Sequential() {
+			err := s.offerNextInLine(ctx, ride)
+			if err == nil {
+				escalated++
+				continue
+			}
+			if err != apperrors.ErrNoDriversAvailable {
+				log.Printf("failed to offer ride %s to the next driver: %v", ride.ID, err)
+				continue
+			}
+		}
+
+		if s.dispatchPolicy.Exhausted(ride.MatchingAttempts) {
+			// A driver may have accepted since the ride was read
+			ok, err := s.rideRepo.CancelIfMatching(ctx, ride.ID, "system", models.CancelReasonNoDrivers)
+			if err != nil {
+				log.Printf("failed to cancel unmatched ride %s: %v", ride.ID, err)
+				continue
+			}
+			if ok {
+				models.RideStates.Record(ctx, ride.ID, ride.Status, models.RideStatusCancelled)
+			}
+			continue
+		}
+
+		err := s.FindAndOfferDrivers(ctx, ride)
+		if err != nil && err != apperrors.ErrNoDriversAvailable {
+			log.Printf("failed to escalate matching for ride %s: %v", ride.ID, err)
+			continue
+		}
+		escalated++
+	}
+
+	if escalated > 0 {
+		log.Printf("matching escalation: re-dispatched %d rides", escalated)
+	}
+	return escalated, nil
+}
+
+// withoutDrivers drops drivers the ride was already offered to or who are
+// excluded from matching
+func withoutDrivers(drivers []cache.DriverWithDistance, exclude map[string]bool) []cache.DriverWithDistance {
+	if len(exclude) == 0 {
+		return drivers
+	}
+	kept := make([]cache.DriverWithDistance, 0, len(drivers))
+	for _, d := range drivers {
+		if !exclude[d.DriverID] {
+			kept = append(kept, d)
+		}
+	}
+	return kept
+}
+
+// recordRound stores how many drivers a dispatch round found and how many were
+// left after filtering, for the matching funnel report. A round that can't be
+// stored doesn't hold up matching; its offers just aren't attributed to it.
+func (s *matchingService) recordRound(ctx context.Context, ride *models.Ride, candidates, passed int) *string {
+	metrics.CounterMap("matching_funnel").Add(models.FunnelStageCandidates, int64(candidates))
+	metrics.CounterMap("matching_funnel").Add(models.FunnelStagePassedFilters, int64(passed))
+
+	round := &models.DispatchRound{
+		RideID:        ride.ID,
+		RegionCode:    ride.RegionCode,
+		VehicleType:   ride.VehicleType,
+		Candidates:    candidates,
+		PassedFilters: passed,
+	}
+	if err := s.roundRepo.Create(ctx, round); err != nil {
+		log.Printf("failed to record dispatch round for ride %s: %v", ride.ID, err)
+		return nil
+	}
+	return &round.ID
+}
+
+// loadCandidates loads the database records of the drivers found in the cache,
+// once per dispatch, for every filter to share. Drivers of other tenants aren't
+// returned, and nobody is if the load fails.
+func (s *matchingService) loadCandidates(ctx context.Context, drivers []cache.DriverWithDistance) map[string]*models.Driver {
+	if len(drivers) == 0 {
+		return nil
+	}
+	ids := make([]string, 0, len(drivers))
+	for _, d := range drivers {
+		ids = append(ids, d.DriverID)
+	}
+	records, err := s.driverRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		log.Printf("failed to load candidate drivers: %v", err)
+		return nil
+	}
+	return driversByID(records)
+}
+
+func driversByID(drivers []*models.Driver) map[string]*models.Driver {
+	byID := make(map[string]*models.Driver, len(drivers))
+	for _, d := range drivers {
+		byID[d.ID] = d
+	}
+	return byID
+}
+
+func (s *matchingService) scoreDrivers(ctx context.Context, drivers []cache.DriverWithDistance, records map[string]*models.Driver, ride *models.Ride) []ScoredDriver {
 	scored := make([]ScoredDriver, 0, len(drivers))
+	favorites := s.favoriteDriverIDs(ctx, ride.UserID)
+	drivers = applyTenant(drivers, records)
+	drivers = s.applySafetyCriteria(ctx, drivers, records, ride)
+	drivers = s.applyTrainingRequirement(ctx, drivers, ride)
+	drivers = applyPaymentMethod(drivers, records, ride)
 
 	for _, d := range drivers {
 		// Skip if driver already has pending offer for this ride
@@ -142,48 +443,359 @@ func (s *matchingService) scoreDrivers(ctx context.Context, drivers []cache.Driv
 			continue
 		}
 
-		// Skip if not online
-		if meta["status"] != models.DriverStatusOnline {
+		// Busy drivers are only candidates for rides chained after their dropoff
+		status := meta["status"]
+		if status != models.DriverStatusOnline && status != models.DriverStatusBusy {
+			continue
+		}
+
+		if s.heldBack(ctx, d.DriverID, ride.ID) {
 			continue
 		}
 
-		// Check if driver has active ride
+		// Drivers on a trip are only offered rides they can chain after their dropoff
+		distance := d.Distance
+		chained := false
 		activeRide, _ := s.driverCache.GetActiveRide(ctx, d.DriverID)
 		if activeRide != "" {
+			chainKm, ok := s.chainDistance(ctx, d.DriverID, activeRide, ride)
+			if !ok {
+				continue
+			}
+			distance, chained = chainKm, true
+		} else if status != models.DriverStatusOnline {
 			continue
 		}
 
-		// Calculate score
-		score := 100.0
+		// Skip EVs that can't complete the pickup and trip on their remaining charge
+		if rangeKm, ok := cache.ParseEVRange(meta, evRangeMaxAge); ok && rangeKm < s.requiredRangeKm(ride, distance) {
+			continue
+		}
+
+		scored = append(scored, ScoredDriver{
+			DriverID: d.DriverID,
+			Score:    s.driverScore(distance, cache.ParseRating(meta["rating"]), favorites[d.DriverID], records[d.DriverID].OfferResponseMs),
+			Distance: distance,
+			Chained:  chained,
+		})
+	}
+
+	// Sort by score (highest first)
+	sort.Slice(scored, func(i, j int) bool {
+		return scored[i].Score > scored[j].Score
+	})
+
+	return scored
+}
+
+// heldBack reports whether a driver gets no offer for the ride right now: they're
+// resting after back-to-back trips, or considering another ride's offer
+func (s *matchingService) heldBack(ctx context.Context, driverID, rideID string) bool {
+	if resting, _ := s.driverCache.InCooldown(ctx, driverID); resting {
+		return true
+	}
+	held, _ := s.driverCache.GetReservation(ctx, driverID)
+	return held != "" && held != rideID
+}
+
+// reserve holds a driver for the ride's offer window. Without Redis offers go out
+// unreserved rather than not at all.
+func (s *matchingService) reserve(ctx context.Context, driverID, rideID string, ttl time.Duration) bool {
+	ok, err := s.driverCache.ReserveDriver(ctx, driverID, rideID, ttl)
+	if err != nil {
+		log.Printf("failed to reserve driver %s for ride %s: %v", driverID, rideID, err)
+		return true
+	}
+	return ok
+}
+
+func (s *matchingService) release(ctx context.Context, driverID, rideID string) {
+	if err := s.driverCache.ReleaseDriver(ctx, driverID, rideID); err != nil {
+		log.Printf("failed to release driver %s from ride %s: %v", driverID, rideID, err)
+	}
+}
+
+// ReleaseDriverReservations returns a ride transition hook that frees the drivers
+// held for a ride's offers once the ride stops looking for a driver
+func ReleaseDriverReservations(driverCache cache.DriverLocationCache) statemachine.Hook {
+	return func(ctx context.Context, t statemachine.Transition) {
+		if t.From != models.RideStatusMatching {
+			return
+		}
+		if err := driverCache.ReleaseRideReservations(ctx, t.ID); err != nil {
+			log.Printf("failed to release drivers held for ride %s: %v", t.ID, err)
+		}
+	}
+}
+
+// confirmAvailability drops candidates that Postgres, the source of truth, no
+// longer has available, correcting the cached status of each one dropped
+func (s *matchingService) confirmAvailability(ctx context.Context, scored []ScoredDriver, records map[string]*models.Driver) []ScoredDriver {
+	if len(scored) == 0 {
+		return scored
+	}
 
-		// Distance penalty (closer = better)
-		score -= d.Distance * 10 // -10 points per km
+	confirmed := make([]ScoredDriver, 0, len(scored))
+	for _, d := range scored {
+		driver := records[d.DriverID]
+		if driver == nil {
+			continue
+		}
+		// Chained offers go to drivers still finishing a trip
+		available := driver.Status == models.DriverStatusOnline ||
+			(d.Chained && driver.Status == models.DriverStatusBusy)
+		if !available {
+			log.Printf("skipping driver %s: cached as available but %s in database", driver.ID, driver.Status)
+			s.driverCache.SetDriverMeta(ctx, driver.ID, driver.Status, driver.VehicleType, driver.Rating)
+			continue
+		}
+		confirmed = append(confirmed, d)
+	}
+	return confirmed
+}
 
-		// Rating bonus
-		rating := cache.ParseRating(meta["rating"])
-		score += rating * 5 // +25 points for 5-star
+// scoreDBDrivers ranks drivers found in the database when the cache can't be used,
+// through the same filters as scoreDrivers. Only free drivers are candidates (no
+// chaining) and EV range isn't checked, since both live in the cache.
+func (s *matchingService) scoreDBDrivers(ctx context.Context, drivers []cache.DriverWithDistance, records []*models.Driver, ride *models.Ride) []ScoredDriver {
+	byID := driversByID(records)
+	scored := make([]ScoredDriver, 0, len(drivers))
+	favorites := s.favoriteDriverIDs(ctx, ride.UserID)
+	now := time.Now()
+	drivers = applyTenant(drivers, byID)
+	drivers = s.applySafetyCriteria(ctx, drivers, byID, ride)
+	drivers = s.applyTrainingRequirement(ctx, drivers, ride)
+	drivers = applyPaymentMethod(drivers, byID, ride)
 
+	for _, d := range drivers {
+		existing, _ := s.offerRepo.GetByRideAndDriver(ctx, ride.ID, d.DriverID)
+		if existing != nil {
+			continue
+		}
+		driver := byID[d.DriverID]
+		if driver.CooldownUntil != nil && driver.CooldownUntil.After(now) {
+			continue
+		}
+		if s.heldBack(ctx, d.DriverID, ride.ID) {
+			continue
+		}
 		scored = append(scored, ScoredDriver{
 			DriverID: d.DriverID,
-			Score:    score,
+			Score:    s.driverScore(d.Distance, driver.Rating, favorites[d.DriverID], driver.OfferResponseMs),
 			Distance: d.Distance,
 		})
 	}
 
-	// Sort by score (highest first)
 	sort.Slice(scored, func(i, j int) bool {
 		return scored[i].Score > scored[j].Score
 	})
-
 	return scored
 }
 
+// driverScore ranks a candidate: closer, better rated and favorite drivers first,
+// with a slight edge to drivers who answer offers quickly
+func (s *matchingService) driverScore(distance, rating float64, favorite bool, responseMs *int) float64 {
+	score := 100.0
+
+	// Distance penalty (closer = better)
+	score -= distance * 10 // -10 points per km
+
+	// Rating bonus
+	score += rating * 5 // +25 points for 5-star
+
+	// Rider's favorite drivers get priority when nearby and free
+	if favorite {
+		score += s.favoriteBoost
+	}
+
+	// Scaled from the full boost for an instant answer down to none for one
+	// taking the whole offer timeout; drivers without history sit in between
+	if s.responseBoost > 0 {
+		speed := 0.5
+		if responseMs != nil {
+			speed = 1 - math.Min(float64(*responseMs)/float64(s.offerTimeout.Base.Milliseconds()), 1)
+		}
+		score += s.responseBoost * speed
+	}
+	return score
+}
+
+// applyTenant keeps only drivers of the tenant ctx is scoped to; the location
+// cache holds every tenant's drivers, records only those of ctx's tenant
+func applyTenant(drivers []cache.DriverWithDistance, records map[string]*models.Driver) []cache.DriverWithDistance {
+	filtered := drivers[:0]
+	for _, d := range drivers {
+		if records[d.DriverID] != nil {
+			filtered = append(filtered, d)
+		}
+	}
+	return filtered
+}
+
+// applySafetyCriteria drops drivers who don't meet the rider's safety mode
+// requirements. Rides booked without safety mode skip the filter. If the
+// criteria cannot be evaluated, no drivers are eligible: a safety-mode rider
+// is never silently matched without the filter.
+func (s *matchingService) applySafetyCriteria(ctx context.Context, drivers []cache.DriverWithDistance, records map[string]*models.Driver, ride *models.Ride) []cache.DriverWithDistance {
+	if !ride.SafetyMode {
+		return drivers
+	}
+	user, err := s.userRepo.GetByID(ctx, ride.UserID)
+	if err != nil {
+		log.Printf("failed to load rider for safety check: %v", err)
+		return nil
+	}
+
+	var region *models.Region
+	if ride.RegionCode != nil {
+		region, err = s.regionService.GetRegion(ctx, *ride.RegionCode)
+		if err != nil {
+			log.Printf("failed to load region for safety check: %v", err)
+			return nil
+		}
+	}
+
+	criteria := models.SafetyCriteriaFor(user, region)
+	if criteria == nil {
+		return drivers
+	}
+
+	now := time.Now()
+	filtered := drivers[:0]
+	for _, d := range drivers {
+		if driver := records[d.DriverID]; driver != nil && criteria.Allows(driver, now) {
+			filtered = append(filtered, d)
+		}
+	}
+	return filtered
+}
+
+// applyTrainingRequirement keeps only drivers who completed the training the
+// ride's product requires
+func (s *matchingService) applyTrainingRequirement(ctx context.Context, drivers []cache.DriverWithDistance, ride *models.Ride) []cache.DriverWithDistance {
+	if ride.Product == nil || len(drivers) == 0 {
+		return drivers
+	}
+	product, ok := models.FindProduct(*ride.Product)
+	if !ok || product.RequiredTraining == "" {
+		return drivers
+	}
+
+	ids := make([]string, 0, len(drivers))
+	for _, d := range drivers {
+		ids = append(ids, d.DriverID)
+	}
+	trained, err := s.trainingRepo.FilterCompleted(ctx, product.RequiredTraining, ids)
+	if err != nil {
+		log.Printf("failed to load driver training for ride %s: %v", ride.ID, err)
+		return nil
+	}
+
+	filtered := drivers[:0]
+	for _, d := range drivers {
+		if trained[d.DriverID] {
+			filtered = append(filtered, d)
+		}
+	}
+	return filtered
+}
+
+// applyPaymentMethod drops drivers who don't take the ride's payment method
+func applyPaymentMethod(drivers []cache.DriverWithDistance, records map[string]*models.Driver, ride *models.Ride) []cache.DriverWithDistance {
+	filtered := drivers[:0]
+	for _, d := range drivers {
+		if driver := records[d.DriverID]; driver != nil && driver.AcceptsPayment(ride.PaymentMethod) {
+			filtered = append(filtered, d)
+		}
+	}
+	return filtered
+}
+
+// chainDistance reports whether a driver on an active trip can take this ride next:
+// their trip must end within the chain window and drop off near the new pickup.
+// The returned distance covers the rest of the current trip plus the hop to the pickup.
+func (s *matchingService) chainDistance(ctx context.Context, driverID, activeRideID string, ride *models.Ride) (float64, bool) {
+	if s.chainPolicy.Window <= 0 || ride.PricingMode == models.PricingModeBid {
+		return 0, false
+	}
+
+	current, err := s.rideRepo.GetByID(ctx, activeRideID)
+	if err != nil || current == nil || current.Status != models.RideStatusInProgress {
+		return 0, false
+	}
+
+	handoffKm := haversineDistance(current.DropoffLat, current.DropoffLng, ride.PickupLat, ride.PickupLng)
+	if handoffKm > s.chainPolicy.PickupRadiusKm {
+		return 0, false
+	}
+
+	loc, err := s.driverCache.GetDriverLocation(ctx, driverID)
+	if err != nil || loc == nil {
+		return 0, false
+	}
+	remainingKm := haversineDistance(loc.Lat, loc.Lng, current.DropoffLat, current.DropoffLng)
+	remaining := time.Duration(remainingKm / avgCitySpeedKmh * float64(time.Hour))
+	if remaining > s.chainPolicy.Window {
+		return 0, false
+	}
+
+	// Only one ride can be queued behind the current trip
+	queued, err := s.offerRepo.GetQueuedByDriverID(ctx, driverID)
+	if err != nil || queued != nil {
+		return 0, false
+	}
+
+	return remainingKm + handoffKm, true
+}
+
+func (s *matchingService) requiredRangeKm(ride *models.Ride, pickupKm float64) float64 {
+	tripKm := 0.0
+	if ride.EstimatedDistanceKm != nil {
+		tripKm = *ride.EstimatedDistanceKm
+	}
+	return (pickupKm+tripKm)*evRangeBuffer + s.evReserveKm
+}
+
+func (s *matchingService) favoriteDriverIDs(ctx context.Context, userID string) map[string]bool {
+	ids := make(map[string]bool)
+	if s.favoriteRepo == nil || s.favoriteBoost == 0 {
+		return ids
+	}
+
+	favorites, err := s.favoriteRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		log.Printf("failed to load favorite drivers: %v", err)
+		return ids
+	}
+	for _, fav := range favorites {
+		ids[fav.DriverID] = true
+	}
+	return ids
+}
+
 func (s *matchingService) GetPendingOffers(ctx context.Context, driverID string) ([]*models.RideOfferResponse, error) {
 	offers, err := s.offerRepo.GetPendingByDriverID(ctx, driverID)
 	if err != nil {
 		return nil, err
 	}
 
+	// Offers are seen once the driver's app has fetched them
+	now := time.Now()
+	var unviewed []string
+	for _, offer := range offers {
+		if offer.ViewedAt == nil {
+			unviewed = append(unviewed, offer.ID)
+			offer.ViewedAt = &now
+		}
+	}
+	if len(unviewed) > 0 {
+		if err := s.offerRepo.MarkViewed(ctx, unviewed, now); err != nil {
+			log.Printf("failed to mark offers viewed for driver %s: %v", driverID, err)
+		} else {
+			metrics.CounterMap("matching_funnel").Add(models.FunnelStageViewed, int64(len(unviewed)))
+		}
+	}
+
 	responses := make([]*models.RideOfferResponse, 0, len(offers))
 	for _, offer := range offers {
 		response := offer.ToResponse()
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/aditya/go-comet/internal/cache"
+	apperrors "github.com/aditya/go-comet/internal/errors"
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+// NavigationService records the driver's progress to pickup as reported by the driver app
+type NavigationService interface {
+	UpdateStatus(ctx context.Context, driverID string, req *models.UpdateNavigationRequest) (*models.RideResponse, error)
+	// Arrive moves the driver's ride to driver_arrived once their last reported
+	// location is within the arrival radius of the pickup
+	Arrive(ctx context.Context, driverID, rideID string) (*models.RideResponse, error)
+}
+
+type navigationService struct {
+	rideRepo      repository.RideRepository
+	driverCache   cache.DriverLocationCache
+	arrivalRadius float64 // meters; 0 skips the check
+}
+
+func NewNavigationService(rideRepo repository.RideRepository, driverCache cache.DriverLocationCache, arrivalRadiusMeters float64) NavigationService {
+	return &navigationService{
+		rideRepo:      rideRepo,
+		driverCache:   driverCache,
+		arrivalRadius: arrivalRadiusMeters,
+	}
+}
+
+func (s *navigationService) UpdateStatus(ctx context.Context, driverID string, req *models.UpdateNavigationRequest) (*models.RideResponse, error) {
+	ride, err := s.assignedRide(ctx, driverID, req.RideID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Repeated reports from a flaky connection are harmless
+	if ride.NavigationStatus != nil && *ride.NavigationStatus == req.Status {
+		return ride.ToResponse(), nil
+	}
+
+	now := time.Now()
+	var updated bool
+	switch req.Status {
+	case models.NavigationEnRouteToPickup:
+		updated, err = s.rideRepo.MarkEnRoute(ctx, ride.ID, now)
+	case models.NavigationWaitingAtPickup:
+		if err := s.checkAtPickup(ctx, driverID, ride); err != nil {
+			return nil, err
+		}
+		updated, err = s.rideRepo.MarkArrived(ctx, ride.ID, now)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, apperrors.BadRequest("navigation status can only be reported before the driver has arrived")
+	}
+	if req.Status == models.NavigationWaitingAtPickup {
+		models.RideStates.Record(ctx, ride.ID, models.RideStatusDriverAssigned, models.RideStatusDriverArrived)
+	}
+
+	ride, err = s.rideRepo.GetByID(ctx, ride.ID)
+	if err != nil {
+		return nil, err
+	}
+	if ride == nil {
+		return nil, apperrors.NotFound("ride")
+	}
+
+	return ride.ToResponse(), nil
+}
+
+func (s *navigationService) Arrive(ctx context.Context, driverID, rideID string) (*models.RideResponse, error) {
+	ride, err := s.assignedRide(ctx, driverID, rideID)
+	if err != nil {
+		return nil, err
+	}
+	if ride.Status != models.RideStatusDriverAssigned && ride.Status != models.RideStatusDriverArrived {
+		return nil, apperrors.InvalidTransition(ride.Status, models.RideStatusDriverArrived)
+	}
+
+	return s.UpdateStatus(ctx, driverID, &models.UpdateNavigationRequest{
+		RideID: rideID,
+		Status: models.NavigationWaitingAtPickup,
+	})
+}
+
+func (s *navigationService) assignedRide(ctx context.Context, driverID, rideID string) (*models.Ride, error) {
+	ride, err := s.rideRepo.GetByID(ctx, rideID)
+	if err != nil {
+		return nil, err
+	}
+	if ride == nil {
+		return nil, apperrors.NotFound("ride")
+	}
+	if ride.DriverID == nil || *ride.DriverID != driverID {
+		return nil, apperrors.Unauthorized("ride not assigned to this driver")
+	}
+	return ride, nil
+}
+
+// checkAtPickup rejects arrival unless the driver's cached location, which
+// expires when they stop sending updates, is inside the arrival radius
+func (s *navigationService) checkAtPickup(ctx context.Context, driverID string, ride *models.Ride) error {
+	if s.arrivalRadius <= 0 {
+		return nil
+	}
+
+	loc, err := s.driverCache.GetDriverLocation(ctx, driverID)
+	if err != nil {
+		return err
+	}
+	if loc == nil {
+		return apperrors.DriverLocationUnknown()
+	}
+
+	distance := haversineDistance(loc.Lat, loc.Lng, ride.PickupLat, ride.PickupLng) * 1000
+	if distance > s.arrivalRadius {
+		return apperrors.NotAtPickup(map[string]interface{}{
+			"distance_meters": math.Round(distance),
+			"radius_meters":   s.arrivalRadius,
+		})
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	apperrors "github.com/aditya/go-comet/internal/errors"
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/psp"
+	"github.com/aditya/go-comet/internal/repository"
+	"github.com/aditya/go-comet/internal/tenant"
+)
+
+// PaymentHoldService pre-authorizes card rides at booking and settles the hold
+// for the actual fare. Without a gateway configured it does nothing and card
+// rides are charged at trip end as before.
+type PaymentHoldService interface {
+	// Authorize places a hold for the ride's estimated fare plus the buffer.
+	// Returns nil for rides that don't need one.
+	Authorize(ctx context.Context, ride *models.Ride) (*models.PaymentHold, error)
+	// Capture charges the payment against the ride's hold, releasing the rest.
+	// Returns nil when the ride has no open hold.
+	Capture(ctx context.Context, rideID string, payment *models.Payment) (*psp.Capture, error)
+	// Release voids the ride's open hold, if any
+	Release(ctx context.Context, rideID string) error
+	// ReleaseStale voids holds on cancelled rides and holds older than the max age
+	ReleaseStale(ctx context.Context) (int, error)
+}
+
+type paymentHoldService struct {
+	holdRepo      repository.PaymentHoldRepository
+	gateway       psp.Gateway
+	bufferPercent float64
+	maxAge        time.Duration
+}
+
+func NewPaymentHoldService(
+	holdRepo repository.PaymentHoldRepository,
+	gateway psp.Gateway,
+	bufferPercent float64,
+	maxAge time.Duration,
+) PaymentHoldService {
+	return &paymentHoldService{
+		holdRepo:      holdRepo,
+		gateway:       gateway,
+		bufferPercent: bufferPercent,
+		maxAge:        maxAge,
+	}
+}
+
+func (s *paymentHoldService) Authorize(ctx context.Context, ride *models.Ride) (*models.PaymentHold, error) {
+	if s.gateway == nil || ride.PaymentMethod != models.PaymentMethodCard || ride.EstimatedFare == nil {
+		return nil, nil
+	}
+	// Charge through the PSP account of the brand the ride was booked under
+	ctx = tenant.WithCode(ctx, ride.TenantCode)
+
+	// The buffer covers the usual gap between estimate and actual fare
+	hold := &models.PaymentHold{
+		RideID: ride.ID,
+		UserID: ride.UserID,
+		Amount: round(*ride.EstimatedFare * (1 + s.bufferPercent/100)),
+		Status: models.HoldStatusAuthorized,
+	}
+
+	auth, err := s.gateway.Authorize(ctx, &psp.AuthorizeRequest{
+		Reference:  ride.ID,
+		CustomerID: ride.UserID,
+		Amount:     hold.Amount,
+		Currency:   "INR",
+	})
+	if err != nil {
+		log.Printf("card authorization failed for ride %s: %v", ride.ID, err)
+		hold.Status = models.HoldStatusFailed
+		if err := s.holdRepo.Create(ctx, hold); err != nil {
+			log.Printf("failed to record declined hold for ride %s: %v", ride.ID, err)
+		}
+		return nil, apperrors.PaymentDeclined()
+	}
+	hold.PSPAuthorizationID = &auth.ID
+
+	if err := s.holdRepo.Create(ctx, hold); err != nil {
+		// Don't leave the rider's funds held for a ride we can't track
+		if voidErr := s.gateway.Void(ctx, auth.ID); voidErr != nil {
+			log.Printf("failed to void untracked authorization %s: %v", auth.ID, voidErr)
+		}
+		return nil, err
+	}
+	return hold, nil
+}
+
+func (s *paymentHoldService) Capture(ctx context.Context, rideID string, payment *models.Payment) (*psp.Capture, error) {
+	if s.gateway == nil {
+		return nil, nil
+	}
+	hold, err := s.holdRepo.GetByRideID(ctx, rideID)
+	if err != nil {
+		return nil, err
+	}
+	if hold == nil || hold.Status != models.HoldStatusAuthorized {
+		return nil, nil
+	}
+	ctx = tenant.WithCode(ctx, hold.TenantCode)
+
+	authID := *hold.PSPAuthorizationID
+	if payment.Amount > hold.Amount {
+		// The fare outgrew the hold: swap it for a fresh authorization of the full amount
+		if err := s.gateway.Void(ctx, authID); err != nil {
+			return nil, fmt.Errorf("void hold %s: %w", hold.ID, err)
+		}
+		if err := s.holdRepo.UpdateStatus(ctx, hold.ID, models.HoldStatusVoided); err != nil {
+			log.Printf("failed to mark hold %s voided: %v", hold.ID, err)
+		}
+		auth, err := s.gateway.Authorize(ctx, &psp.AuthorizeRequest{
+			Reference:  rideID,
+			CustomerID: payment.UserID,
+			Amount:     payment.Amount,
+			Currency:   payment.Currency,
+		})
+		if err != nil {
+			return nil, apperrors.PaymentDeclined()
+		}
+		authID = auth.ID
+	}
+
+	capture, err := s.gateway.Capture(ctx, authID, payment.Amount)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.holdRepo.MarkCaptured(ctx, hold.ID, payment.ID, payment.Amount); err != nil {
+		log.Printf("failed to mark hold %s captured: %v", hold.ID, err)
+	}
+	return capture, nil
+}
+
+func (s *paymentHoldService) Release(ctx context.Context, rideID string) error {
+	if s.gateway == nil {
+		return nil
+	}
+	hold, err := s.holdRepo.GetByRideID(ctx, rideID)
+	if err != nil {
+		return err
+	}
+	if hold == nil || hold.Status != models.HoldStatusAuthorized {
+		return nil
+	}
+	return s.void(ctx, hold)
+}
+
+func (s *paymentHoldService) ReleaseStale(ctx context.Context) (int, error) {
+	if s.gateway == nil {
+		return 0, nil
+	}
+	holds, err := s.holdRepo.GetReleasable(ctx, time.Now().Add(-s.maxAge))
+	if err != nil {
+		return 0, err
+	}
+
+	released := 0
+	for _, hold := range holds {
+		if err := s.void(ctx, hold); err != nil {
+			log.Printf("failed to release hold %s: %v", hold.ID, err)
+			continue
+		}
+		released++
+	}
+
+	if released > 0 {
+		log.Printf("payment holds: released %d holds", released)
+	}
+	return released, nil
+}
+
+func (s *paymentHoldService) void(ctx context.Context, hold *models.PaymentHold) error {
+	ctx = tenant.WithCode(ctx, hold.TenantCode)
+	if err := s.gateway.Void(ctx, *hold.PSPAuthorizationID); err != nil {
+		return err
+	}
+	return s.holdRepo.UpdateStatus(ctx, hold.ID, models.HoldStatusVoided)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log"
 	"time"
 
 	apperrors "github.com/aditya/go-comet/internal/errors"
@@ -20,17 +21,38 @@ type PaymentService interface {
 }
 
 type paymentService struct {
-	paymentRepo repository.PaymentRepository
-	tripRepo    repository.TripRepository
+	paymentRepo       repository.PaymentRepository
+	tripRepo          repository.TripRepository
+	commissionService CommissionService
+	holdService       PaymentHoldService
+	economicsService  RideEconomicsService
+	earningsService   EarningsService
+	walletService     WalletService
+	cancellationFees  CancellationFeeService
+	carbonOffsetPerKg float64
 }
 
 func NewPaymentService(
 	paymentRepo repository.PaymentRepository,
 	tripRepo repository.TripRepository,
+	commissionService CommissionService,
+	holdService PaymentHoldService,
+	economicsService RideEconomicsService,
+	earningsService EarningsService,
+	walletService WalletService,
+	cancellationFees CancellationFeeService,
+	carbonOffsetPerKg float64,
 ) PaymentService {
 	return &paymentService{
-		paymentRepo: paymentRepo,
-		tripRepo:    tripRepo,
+		paymentRepo:       paymentRepo,
+		tripRepo:          tripRepo,
+		commissionService: commissionService,
+		holdService:       holdService,
+		economicsService:  economicsService,
+		earningsService:   earningsService,
+		walletService:     walletService,
+		cancellationFees:  cancellationFees,
+		carbonOffsetPerKg: carbonOffsetPerKg,
 	}
 }
 
@@ -85,11 +107,41 @@ func (s *paymentService) ProcessPayment(ctx context.Context, req *models.CreateP
 		Method:   req.Method,
 		Status:   models.PaymentStatusPending,
 	}
+	if trip.PromoDiscount != nil {
+		payment.PromoDiscount = *trip.PromoDiscount
+	}
 
 	if req.IdempotencyKey != "" {
 		payment.IdempotencyKey = &req.IdempotencyKey
 	}
 
+	// Split the fare at the driver's commission rate now, so later rate changes
+	// don't rewrite what the driver was paid
+	rate, err := s.commissionService.RateAt(ctx, trip.DriverID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	split := models.NewCommissionSplit(payment.Amount, rate)
+	payment.CommissionPercent = &split.Percent
+	payment.CommissionAmount = &split.Commission
+	payment.DriverEarnings = &split.DriverEarnings
+
+	// Optional donation to offset the trip's emissions, charged on top of the fare
+	if req.CarbonOffset && trip.CO2Grams != nil && s.carbonOffsetPerKg > 0 {
+		payment.CarbonOffsetAmount = models.CarbonOffsetAmount(*trip.CO2Grams, s.carbonOffsetPerKg)
+		payment.Amount += payment.CarbonOffsetAmount
+	}
+
+	// Cancellation fees the wallet couldn't cover are collected with the next trip
+	fees, err := s.cancellationFees.Outstanding(ctx, trip.UserID)
+	if err != nil {
+		return nil, err
+	}
+	for _, fee := range fees {
+		payment.CancellationFeeAmount += fee.Amount
+	}
+	payment.Amount += payment.CancellationFeeAmount
+
 	if err := s.paymentRepo.Create(ctx, payment); err != nil {
 		return nil, err
 	}
@@ -102,30 +154,55 @@ func (s *paymentService) ProcessPayment(ctx context.Context, req *models.CreateP
 	case models.PaymentMethodCash:
 		pspResponse = s.processCashPayment(payment)
 	case models.PaymentMethodWallet:
-		pspResponse, pspErr = s.processWalletPayment(payment)
-	case models.PaymentMethodCard, models.PaymentMethodUPI:
+		pspResponse, pspErr = s.processWalletPayment(ctx, payment)
+	case models.PaymentMethodCard:
+		pspResponse, pspErr = s.processCardPayment(ctx, trip, payment)
+	case models.PaymentMethodUPI:
 		pspResponse, pspErr = s.processExternalPayment(payment)
 	default:
 		return nil, apperrors.BadRequest("invalid payment method")
 	}
 
+	// A rider paying some other way no longer needs the card held
+	if pspErr == nil && req.Method != models.PaymentMethodCard {
+		if err := s.holdService.Release(ctx, trip.RideID); err != nil {
+			log.Printf("failed to release payment hold for ride %s: %v", trip.RideID, err)
+		}
+	}
+
 	if pspErr != nil {
 		// Update payment status to failed
 		responseJSON, _ := json.Marshal(map[string]string{"error": pspErr.Error()})
-		s.paymentRepo.UpdateStatus(ctx, payment.ID, models.PaymentStatusFailed, nil, responseJSON)
+		models.PaymentStates.Apply(ctx, payment.ID, payment.Status, models.PaymentStatusFailed, func() error {
+			return s.paymentRepo.UpdateStatus(ctx, payment.ID, models.PaymentStatusFailed, nil, responseJSON)
+		})
 		return nil, pspErr
 	}
 
 	// Update payment with PSP response
 	pspTxnID := pspResponse.TransactionID
 	responseJSON, _ := json.Marshal(pspResponse)
-	if err := s.paymentRepo.UpdateStatus(ctx, payment.ID, models.PaymentStatusCompleted, &pspTxnID, responseJSON); err != nil {
+	err = models.PaymentStates.Apply(ctx, payment.ID, payment.Status, models.PaymentStatusCompleted, func() error {
+		return s.paymentRepo.UpdateStatus(ctx, payment.ID, models.PaymentStatusCompleted, &pspTxnID, responseJSON)
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	payment.Status = models.PaymentStatusCompleted
 	payment.PSPTransactionID = &pspTxnID
 
+	if err := s.cancellationFees.Settle(ctx, fees, payment.ID); err != nil {
+		log.Printf("failed to settle cancellation fees with payment %s: %v", payment.ID, err)
+	}
+
+	if _, err := s.economicsService.Record(ctx, trip, payment); err != nil {
+		log.Printf("failed to record economics for ride %s: %v", trip.RideID, err)
+	}
+	if err := s.earningsService.PostTripEarning(ctx, payment); err != nil {
+		log.Printf("failed to post earnings for payment %s: %v", payment.ID, err)
+	}
+
 	return payment.ToResponse(), nil
 }
 
@@ -160,8 +237,16 @@ func (s *paymentService) RefundPayment(ctx context.Context, paymentID string) er
 		return apperrors.NotFound("payment")
 	}
 
-	if payment.Status != models.PaymentStatusCompleted {
-		return apperrors.BadRequest("can only refund completed payments")
+	if err := models.PaymentStates.Check(payment.Status, models.PaymentStatusRefunded); err != nil {
+		return err
+	}
+
+	// Wallet payments go back to the wallet before the payment is marked refunded,
+	// so a failed credit can be retried
+	if payment.Method == models.PaymentMethodWallet {
+		if err := s.walletService.Refund(ctx, payment); err != nil {
+			return err
+		}
 	}
 
 	// Mock refund
@@ -171,7 +256,21 @@ func (s *paymentService) RefundPayment(ctx context.Context, paymentID string) er
 	}
 	responseJSON, _ := json.Marshal(refundResponse)
 
-	return s.paymentRepo.UpdateStatus(ctx, paymentID, models.PaymentStatusRefunded, payment.PSPTransactionID, responseJSON)
+	err = models.PaymentStates.Apply(ctx, paymentID, payment.Status, models.PaymentStatusRefunded, func() error {
+		return s.paymentRepo.UpdateStatus(ctx, paymentID, models.PaymentStatusRefunded, payment.PSPTransactionID, responseJSON)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Refunded rides drop out of take-rate reporting
+	if err := s.economicsService.MarkRefunded(ctx, paymentID); err != nil {
+		log.Printf("failed to mark economics refunded for payment %s: %v", paymentID, err)
+	}
+	if err := s.earningsService.ReverseTripEarning(ctx, paymentID); err != nil {
+		log.Printf("failed to reverse earnings for payment %s: %v", paymentID, err)
+	}
+	return nil
 }
 
 // PSP Response types (mock)
@@ -193,13 +292,34 @@ func (s *paymentService) processCashPayment(payment *models.Payment) *PSPRespons
 	}
 }
 
-func (s *paymentService) processWalletPayment(payment *models.Payment) (*PSPResponse, error) {
-	// Mock wallet payment - always succeeds
-	// In real implementation, check wallet balance and deduct
+// processWalletPayment debits the fare from the rider's wallet
+func (s *paymentService) processWalletPayment(ctx context.Context, payment *models.Payment) (*PSPResponse, error) {
+	txn, err := s.walletService.Pay(ctx, payment)
+	if err != nil {
+		return nil, err
+	}
 	return &PSPResponse{
-		TransactionID: fmt.Sprintf("WAL_%s", uuid.New().String()[:8]),
+		TransactionID: fmt.Sprintf("WAL_%s", txn.ID[:8]),
 		Status:        "success",
-		Message:       "Wallet payment successful",
+		Message:       fmt.Sprintf("Wallet payment successful, balance %.2f", txn.BalanceAfter),
+		ProcessedAt:   time.Now().Format(time.RFC3339),
+	}, nil
+}
+
+// processCardPayment captures the fare against the ride's pre-authorization,
+// charging the card directly when there is no hold
+func (s *paymentService) processCardPayment(ctx context.Context, trip *models.Trip, payment *models.Payment) (*PSPResponse, error) {
+	capture, err := s.holdService.Capture(ctx, trip.RideID, payment)
+	if err != nil {
+		return nil, err
+	}
+	if capture == nil {
+		return s.processExternalPayment(payment)
+	}
+	return &PSPResponse{
+		TransactionID: capture.TransactionID,
+		Status:        capture.Status,
+		Message:       fmt.Sprintf("Captured %.2f against card hold", capture.Amount),
 		ProcessedAt:   time.Now().Format(time.RFC3339),
 	}, nil
 }
@@ -0,0 +1,325 @@
+package service
+
+import (
+	"context"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	apperrors "github.com/aditya/go-comet/internal/errors"
+	"github.com/aditya/go-comet/internal/geocoding"
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+const (
+	// Curated spots further than this from the rider aren't suggested
+	pickupSuggestionRadiusKm = 0.3
+	maxPickupSuggestions     = 5
+	roadSnapCandidates       = 3
+	roadSnapTimeout          = 2 * time.Second
+	// A curated spot further than this from the rider's pin can't be what they picked
+	maxPickupSpotDistanceKm = 0.5
+	kmPerDegreeLat          = 111.32
+)
+
+// PickupService suggests nearby pickup points so riders and drivers meet at the
+// same, reachable spot, and keeps pickups inside venues to their named points
+type PickupService interface {
+	Suggest(ctx context.Context, lat, lng float64) (*models.PickupSuggestions, error)
+	// ResolvePickup checks the suggestion the rider chose, if any, and moves the
+	// pickup onto it when it is a curated spot. Pickups inside a venue must choose
+	// one of its points.
+	ResolvePickup(ctx context.Context, choice *models.PickupSpotChoice, pickup *models.Location) (*models.PickupSpotInfo, error)
+	CreateSpot(ctx context.Context, req *models.CreatePickupSpotRequest) (*models.PickupSpot, error)
+	SetSpotActive(ctx context.Context, id string, active bool) (*models.PickupSpot, error)
+	CreateVenue(ctx context.Context, req *models.CreateVenueRequest) (*models.Venue, error)
+	ListVenues(ctx context.Context) ([]*models.Venue, error)
+	SetVenueActive(ctx context.Context, id string, active bool) (*models.Venue, error)
+}
+
+type pickupService struct {
+	spotRepo  repository.PickupSpotRepository
+	venueRepo repository.VenueRepository
+	snapper   geocoding.RoadSnapper
+}
+
+func NewPickupService(
+	spotRepo repository.PickupSpotRepository,
+	venueRepo repository.VenueRepository,
+	snapper geocoding.RoadSnapper,
+) PickupService {
+	return &pickupService{
+		spotRepo:  spotRepo,
+		venueRepo: venueRepo,
+		snapper:   snapper,
+	}
+}
+
+func (s *pickupService) Suggest(ctx context.Context, lat, lng float64) (*models.PickupSuggestions, error) {
+	// Inside a venue the rider can only be picked up at its named points
+	venue, err := s.venueAt(ctx, lat, lng)
+	if err != nil {
+		return nil, err
+	}
+	if venue != nil {
+		points, err := s.spotRepo.GetByVenueID(ctx, venue.ID)
+		if err != nil {
+			return nil, err
+		}
+		suggestions := spotSuggestions(points, lat, lng)
+		sort.SliceStable(suggestions, func(i, j int) bool {
+			return suggestions[i].DistanceM < suggestions[j].DistanceM
+		})
+		return &models.PickupSuggestions{
+			Venue:       &models.VenueInfo{ID: venue.ID, Name: venue.Name},
+			Suggestions: suggestions,
+		}, nil
+	}
+
+	spots, err := s.nearbySpots(ctx, lat, lng, pickupSuggestionRadiusKm)
+	if err != nil {
+		return nil, err
+	}
+	suggestions := spotSuggestions(spots, lat, lng)
+
+	// Road snapping is best effort; curated spots are still useful without it
+	if s.snapper != nil {
+		snapCtx, cancel := context.WithTimeout(ctx, roadSnapTimeout)
+		points, err := s.snapper.Nearest(snapCtx, lat, lng, roadSnapCandidates)
+		cancel()
+		if err != nil {
+			log.Printf("failed to snap %f,%f to road: %v", lat, lng, err)
+		}
+		for _, p := range points {
+			name := p.Street
+			if name == "" {
+				name = "Nearest road"
+			}
+			suggestions = append(suggestions, &models.PickupSuggestion{
+				Name:      name,
+				Lat:       p.Lat,
+				Lng:       p.Lng,
+				Source:    models.PickupSourceRoad,
+				DistanceM: int(math.Round(p.DistanceM)),
+			})
+		}
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return suggestions[i].DistanceM < suggestions[j].DistanceM
+	})
+	if len(suggestions) > maxPickupSuggestions {
+		suggestions = suggestions[:maxPickupSuggestions]
+	}
+	return &models.PickupSuggestions{Suggestions: suggestions}, nil
+}
+
+func (s *pickupService) ResolvePickup(ctx context.Context, choice *models.PickupSpotChoice, pickup *models.Location) (*models.PickupSpotInfo, error) {
+	if pickup.HasCoordinates() {
+		venue, err := s.venueAt(ctx, pickup.Lat, pickup.Lng)
+		if err != nil {
+			return nil, err
+		}
+		if venue != nil {
+			return s.resolveVenuePoint(ctx, venue, choice, pickup)
+		}
+	}
+	if choice == nil {
+		return nil, nil
+	}
+
+	// Road points are already the ride's pickup coordinates
+	if choice.SpotID == "" {
+		if choice.Source != models.PickupSourceRoad {
+			return nil, apperrors.BadRequest("spot_id is required for a curated pickup spot")
+		}
+		return &models.PickupSpotInfo{Name: choice.Name, Source: models.PickupSourceRoad}, nil
+	}
+
+	spot, err := s.spotRepo.GetByID(ctx, choice.SpotID)
+	if err != nil {
+		return nil, err
+	}
+	if spot == nil || !spot.Active {
+		return nil, apperrors.NotFound("pickup spot")
+	}
+
+	// The rider is picked up at the spot itself, wherever they dropped the pin
+	if pickup.HasCoordinates() && haversineDistance(pickup.Lat, pickup.Lng, spot.Lat, spot.Lng) > maxPickupSpotDistanceKm {
+		return nil, apperrors.BadRequest("pickup spot is too far from the pickup location")
+	}
+	pickup.Lat, pickup.Lng = spot.Lat, spot.Lng
+	if pickup.Address == "" {
+		pickup.Address = spot.Name
+	}
+
+	return &models.PickupSpotInfo{ID: &spot.ID, Name: spot.Name, Source: spot.Kind}, nil
+}
+
+func (s *pickupService) resolveVenuePoint(ctx context.Context, venue *models.Venue, choice *models.PickupSpotChoice, pickup *models.Location) (*models.PickupSpotInfo, error) {
+	if choice == nil || choice.SpotID == "" {
+		return nil, apperrors.PickupPointRequired(venue.Name)
+	}
+	spot, err := s.spotRepo.GetByID(ctx, choice.SpotID)
+	if err != nil {
+		return nil, err
+	}
+	if spot == nil || !spot.Active || spot.VenueID == nil || *spot.VenueID != venue.ID {
+		return nil, apperrors.PickupPointRequired(venue.Name)
+	}
+
+	pickup.Lat, pickup.Lng = spot.Lat, spot.Lng
+	pickup.Address = spot.Name + ", " + venue.Name
+	return &models.PickupSpotInfo{ID: &spot.ID, Name: spot.Name, Source: spot.Kind, Venue: &venue.Name}, nil
+}
+
+func (s *pickupService) CreateSpot(ctx context.Context, req *models.CreatePickupSpotRequest) (*models.PickupSpot, error) {
+	spot := &models.PickupSpot{
+		Name: req.Name,
+		Kind: req.Kind,
+		Lat:  req.Lat,
+		Lng:  req.Lng,
+	}
+	if err := s.spotRepo.Create(ctx, spot); err != nil {
+		return nil, err
+	}
+	return spot, nil
+}
+
+func (s *pickupService) SetSpotActive(ctx context.Context, id string, active bool) (*models.PickupSpot, error) {
+	spot, err := s.spotRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if spot == nil {
+		return nil, apperrors.NotFound("pickup spot")
+	}
+
+	if err := s.spotRepo.SetActive(ctx, id, active); err != nil {
+		return nil, err
+	}
+	spot.Active = active
+	return spot, nil
+}
+
+func (s *pickupService) CreateVenue(ctx context.Context, req *models.CreateVenueRequest) (*models.Venue, error) {
+	venue := &models.Venue{
+		Name:    req.Name,
+		Polygon: req.Polygon,
+	}
+	if err := s.venueRepo.Create(ctx, venue); err != nil {
+		return nil, err
+	}
+
+	venue.Points = make([]*models.PickupSpot, 0, len(req.Points))
+	for _, p := range req.Points {
+		point := &models.PickupSpot{
+			Name:    p.Name,
+			Kind:    p.Kind,
+			Lat:     p.Lat,
+			Lng:     p.Lng,
+			VenueID: &venue.ID,
+		}
+		if point.Kind == "" {
+			point.Kind = models.PickupSourceEntrance
+		}
+		if err := s.spotRepo.Create(ctx, point); err != nil {
+			return nil, err
+		}
+		venue.Points = append(venue.Points, point)
+	}
+	return venue, nil
+}
+
+func (s *pickupService) ListVenues(ctx context.Context) ([]*models.Venue, error) {
+	venues, err := s.venueRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, venue := range venues {
+		venue.Points, err = s.spotRepo.GetByVenueID(ctx, venue.ID)
+		if err != nil {
+			return nil, err
+		}
+		if venue.Points == nil {
+			venue.Points = []*models.PickupSpot{}
+		}
+	}
+	if venues == nil {
+		venues = []*models.Venue{}
+	}
+	return venues, nil
+}
+
+func (s *pickupService) SetVenueActive(ctx context.Context, id string, active bool) (*models.Venue, error) {
+	venue, err := s.venueRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if venue == nil {
+		return nil, apperrors.NotFound("venue")
+	}
+
+	if err := s.venueRepo.SetActive(ctx, id, active); err != nil {
+		return nil, err
+	}
+	venue.Active = active
+	venue.Points, err = s.spotRepo.GetByVenueID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return venue, nil
+}
+
+// venueAt returns the active venue whose polygon contains the point, if any
+func (s *pickupService) venueAt(ctx context.Context, lat, lng float64) (*models.Venue, error) {
+	venues, err := s.venueRepo.GetActive(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, venue := range venues {
+		if venue.Polygon.Contains(lat, lng) {
+			return venue, nil
+		}
+	}
+	return nil, nil
+}
+
+func spotSuggestions(spots []*models.PickupSpot, lat, lng float64) []*models.PickupSuggestion {
+	suggestions := make([]*models.PickupSuggestion, 0, len(spots)+roadSnapCandidates)
+	for _, spot := range spots {
+		id := spot.ID
+		suggestions = append(suggestions, &models.PickupSuggestion{
+			SpotID:    &id,
+			Name:      spot.Name,
+			Lat:       spot.Lat,
+			Lng:       spot.Lng,
+			Source:    spot.Kind,
+			DistanceM: int(math.Round(haversineDistance(lat, lng, spot.Lat, spot.Lng) * 1000)),
+		})
+	}
+	return suggestions
+}
+
+// nearbySpots returns active curated spots within radiusKm, closest first
+func (s *pickupService) nearbySpots(ctx context.Context, lat, lng, radiusKm float64) ([]*models.PickupSpot, error) {
+	dLat := radiusKm / kmPerDegreeLat
+	dLng := radiusKm / (kmPerDegreeLat * math.Max(math.Cos(lat*math.Pi/180), 0.01))
+	candidates, err := s.spotRepo.GetInBounds(ctx, lat-dLat, lng-dLng, lat+dLat, lng+dLng)
+	if err != nil {
+		return nil, err
+	}
+
+	var spots []*models.PickupSpot
+	for _, spot := range candidates {
+		if haversineDistance(lat, lng, spot.Lat, spot.Lng) <= radiusKm {
+			spots = append(spots, spot)
+		}
+	}
+	sort.Slice(spots, func(i, j int) bool {
+		return haversineDistance(lat, lng, spots[i].Lat, spots[i].Lng) < haversineDistance(lat, lng, spots[j].Lat, spots[j].Lng)
+	})
+	return spots, nil
+}
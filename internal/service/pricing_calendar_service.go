@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	apperrors "github.com/aditya/go-comet/internal/errors"
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+// PricingCalendarService manages holiday and event pricing and applies it to fares
+type PricingCalendarService interface {
+	CreateEvent(ctx context.Context, req *models.CreatePricingEventRequest) (*models.PricingEvent, error)
+	ListEvents(ctx context.Context) ([]*models.PricingEvent, error)
+	SetEventActive(ctx context.Context, id string, active bool) (*models.PricingEvent, error)
+	// Apply adds the charges of every event covering the time to the fare. An event
+	// with a geofence applies when any of the points (pickup, dropoff) is inside it.
+	// Multipliers don't stack: only the highest applies. Flat surcharges add up.
+	Apply(ctx context.Context, fare *models.FareBreakdown, at time.Time, regionCode, vehicleType string, points ...models.GeoPoint)
+}
+
+type pricingCalendarService struct {
+	eventRepo repository.PricingEventRepository
+}
+
+func NewPricingCalendarService(eventRepo repository.PricingEventRepository) PricingCalendarService {
+	return &pricingCalendarService{eventRepo: eventRepo}
+}
+
+func (s *pricingCalendarService) CreateEvent(ctx context.Context, req *models.CreatePricingEventRequest) (*models.PricingEvent, error) {
+	if !req.StartsAt.Before(req.EndsAt) {
+		return nil, apperrors.BadRequest("starts_at must be before ends_at")
+	}
+	if !req.EndsAt.After(time.Now()) {
+		return nil, apperrors.BadRequest("ends_at must be in the future")
+	}
+
+	event := &models.PricingEvent{
+		Name:      req.Name,
+		Kind:      req.Kind,
+		CenterLat: req.CenterLat,
+		CenterLng: req.CenterLng,
+		RadiusKm:  req.RadiusKm,
+		StartsAt:  req.StartsAt,
+		EndsAt:    req.EndsAt,
+	}
+	switch req.Kind {
+	case models.PricingEventMultiplier:
+		if req.Multiplier == nil {
+			return nil, apperrors.BadRequest("multiplier is required for multiplier events")
+		}
+		event.Multiplier = req.Multiplier
+	case models.PricingEventSurcharge:
+		if req.Surcharge == nil {
+			return nil, apperrors.BadRequest("surcharge is required for surcharge events")
+		}
+		event.Surcharge = req.Surcharge
+	}
+	if req.RegionCode != "" {
+		event.RegionCode = &req.RegionCode
+	}
+	if req.VehicleType != "" {
+		event.VehicleType = &req.VehicleType
+	}
+
+	if err := s.eventRepo.Create(ctx, event); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+func (s *pricingCalendarService) ListEvents(ctx context.Context) ([]*models.PricingEvent, error) {
+	events, err := s.eventRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if events == nil {
+		events = []*models.PricingEvent{}
+	}
+	return events, nil
+}
+
+func (s *pricingCalendarService) SetEventActive(ctx context.Context, id string, active bool) (*models.PricingEvent, error) {
+	event, err := s.eventRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if event == nil {
+		return nil, apperrors.NotFound("pricing event")
+	}
+
+	if err := s.eventRepo.SetActive(ctx, id, active); err != nil {
+		return nil, err
+	}
+	event.Active = active
+	return event, nil
+}
+
+func (s *pricingCalendarService) Apply(ctx context.Context, fare *models.FareBreakdown, at time.Time, regionCode, vehicleType string, points ...models.GeoPoint) {
+	events, err := s.eventRepo.GetActiveAt(ctx, at)
+	if err != nil {
+		// Fares are quoted and charged without calendar pricing rather than failing
+		log.Printf("failed to load pricing events: %v", err)
+		return
+	}
+
+	var multiplier *models.PricingEvent
+	var charges models.FareEvents
+	for _, e := range events {
+		if !eventApplies(e, regionCode, vehicleType, points) {
+			continue
+		}
+		switch e.Kind {
+		case models.PricingEventMultiplier:
+			if e.Multiplier != nil && (multiplier == nil || *e.Multiplier > *multiplier.Multiplier) {
+				multiplier = e
+			}
+		case models.PricingEventSurcharge:
+			if e.Surcharge != nil {
+				charges = append(charges, &models.FareEvent{ID: e.ID, Name: e.Name, Kind: e.Kind, Amount: *e.Surcharge})
+			}
+		}
+	}
+	if multiplier != nil {
+		amount := round(fare.Total * (*multiplier.Multiplier - 1))
+		charges = append(models.FareEvents{{ID: multiplier.ID, Name: multiplier.Name, Kind: multiplier.Kind, Amount: amount}}, charges...)
+	}
+	if len(charges) == 0 {
+		return
+	}
+
+	var total float64
+	for _, c := range charges {
+		total += c.Amount
+	}
+	fare.Events = charges
+	fare.EventSurcharge = round(total)
+	fare.Total = round(fare.Total + fare.EventSurcharge)
+}
+
+func eventApplies(e *models.PricingEvent, regionCode, vehicleType string, points []models.GeoPoint) bool {
+	if e.RegionCode != nil && *e.RegionCode != regionCode {
+		return false
+	}
+	if e.VehicleType != nil && *e.VehicleType != vehicleType {
+		return false
+	}
+	if !e.HasGeofence() {
+		return true
+	}
+	for _, p := range points {
+		if haversineDistance(*e.CenterLat, *e.CenterLng, p.Lat, p.Lng) <= *e.RadiusKm {
+			return true
+		}
+	}
+	return false
+}
@@ -1,9 +1,13 @@
 package service
 
 import (
+	"context"
+	"log"
 	"math"
+	"time"
 
 	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/tenant"
 )
 
 // FareConfig holds pricing configuration for each vehicle type
@@ -13,42 +17,94 @@ type FareConfig struct {
 	PerMinRate      float64
 	MinFare         float64
 	CancellationFee float64
+	WaitingPerMin   float64
 }
 
-var fareConfigs = map[string]FareConfig{
-	models.VehicleTypeAuto:  {BaseFare: 25, PerKmRate: 12, PerMinRate: 1.0, MinFare: 30, CancellationFee: 25},
-	models.VehicleTypeMini:  {BaseFare: 40, PerKmRate: 14, PerMinRate: 1.2, MinFare: 50, CancellationFee: 40},
-	models.VehicleTypeSedan: {BaseFare: 50, PerKmRate: 17, PerMinRate: 1.5, MinFare: 80, CancellationFee: 50},
-	models.VehicleTypeSUV:   {BaseFare: 80, PerKmRate: 22, PerMinRate: 2.0, MinFare: 120, CancellationFee: 80},
+// fareConfigFor returns the vehicle type's fares from the registry with the
+// tenant's overrides applied. Without a registry, or if it can't be read, the
+// built-in fares are used; unknown types are priced as sedans.
+func fareConfigFor(ctx context.Context, vehicleTypes VehicleTypeService, vehicleType string) FareConfig {
+	def, ok := models.FindDefaultVehicleType(vehicleType)
+	if !ok {
+		def, _ = models.FindDefaultVehicleType(models.VehicleTypeSedan)
+	}
+	config := FareConfig(def.VehicleFares)
+	if vehicleTypes != nil {
+		registered, err := vehicleTypes.Get(ctx, vehicleType)
+		if err != nil {
+			log.Printf("failed to load fares for %s, using the built-in fares: %v", vehicleType, err)
+		} else if registered != nil {
+			config = FareConfig(registered.VehicleFares)
+		}
+	}
+
+	t := tenant.FromContext(ctx)
+	if t == nil {
+		return config
+	}
+	override, ok := t.FareConfigs[vehicleType]
+	if !ok {
+		return config
+	}
+	if override.BaseFare > 0 {
+		config.BaseFare = override.BaseFare
+	}
+	if override.PerKmRate > 0 {
+		config.PerKmRate = override.PerKmRate
+	}
+	if override.PerMinRate > 0 {
+		config.PerMinRate = override.PerMinRate
+	}
+	if override.MinFare > 0 {
+		config.MinFare = override.MinFare
+	}
+	if override.CancellationFee > 0 {
+		config.CancellationFee = override.CancellationFee
+	}
+	if override.WaitingPerMin > 0 {
+		config.WaitingPerMin = override.WaitingPerMin
+	}
+	return config
 }
 
+// freeWaitingMins is how long a driver waits at pickup before the waiting fee starts
+const freeWaitingMins = 3
+
 type PricingService interface {
-	CalculateEstimatedFare(vehicleType string, distanceKm float64, durationMins int, surgeMultiplier float64) *models.FareBreakdown
-	CalculateActualFare(vehicleType string, distanceKm float64, durationMins int, surgeMultiplier float64) *models.FareBreakdown
+	// Fares use the overrides of the tenant in ctx, if any
+	CalculateEstimatedFare(ctx context.Context, vehicleType string, distanceKm float64, durationMins int, surgeMultiplier float64) *models.FareBreakdown
+	CalculateActualFare(ctx context.Context, vehicleType string, distanceKm float64, durationMins int, surgeMultiplier float64) *models.FareBreakdown
 	CalculateSurge(demandCount, supplyCount int) float64
 	EstimateDistance(pickupLat, pickupLng, dropoffLat, dropoffLng float64) float64
 	EstimateDuration(distanceKm float64) int
+	ApplyEVDiscount(fare *models.FareBreakdown, percent float64)
+	ApplyWaitingFee(ctx context.Context, fare *models.FareBreakdown, vehicleType string, waited time.Duration)
+	// ApplyPackageSurcharges adds a delivery's size class and declared value surcharges
+	ApplyPackageSurcharges(fare *models.FareBreakdown, packageSize string, declaredValue *float64)
+	// ApplyPromo takes the promo's discount off the fare, never below zero
+	ApplyPromo(fare *models.FareBreakdown, promo *models.PromoCode)
 }
 
-type pricingService struct{}
+type pricingService struct {
+	vehicleTypes VehicleTypeService
+}
 
-func NewPricingService() PricingService {
-	return &pricingService{}
+// NewPricingService prices fares from the vehicle type registry; a nil registry
+// uses the built-in fares
+func NewPricingService(vehicleTypes VehicleTypeService) PricingService {
+	return &pricingService{vehicleTypes: vehicleTypes}
 }
 
-func (s *pricingService) CalculateEstimatedFare(vehicleType string, distanceKm float64, durationMins int, surgeMultiplier float64) *models.FareBreakdown {
-	return s.calculateFare(vehicleType, distanceKm, durationMins, surgeMultiplier)
+func (s *pricingService) CalculateEstimatedFare(ctx context.Context, vehicleType string, distanceKm float64, durationMins int, surgeMultiplier float64) *models.FareBreakdown {
+	return s.calculateFare(ctx, vehicleType, distanceKm, durationMins, surgeMultiplier)
 }
 
-func (s *pricingService) CalculateActualFare(vehicleType string, distanceKm float64, durationMins int, surgeMultiplier float64) *models.FareBreakdown {
-	return s.calculateFare(vehicleType, distanceKm, durationMins, surgeMultiplier)
+func (s *pricingService) CalculateActualFare(ctx context.Context, vehicleType string, distanceKm float64, durationMins int, surgeMultiplier float64) *models.FareBreakdown {
+	return s.calculateFare(ctx, vehicleType, distanceKm, durationMins, surgeMultiplier)
 }
 
-func (s *pricingService) calculateFare(vehicleType string, distanceKm float64, durationMins int, surgeMultiplier float64) *models.FareBreakdown {
-	config, exists := fareConfigs[vehicleType]
-	if !exists {
-		config = fareConfigs[models.VehicleTypeSedan] // default
-	}
+func (s *pricingService) calculateFare(ctx context.Context, vehicleType string, distanceKm float64, durationMins int, surgeMultiplier float64) *models.FareBreakdown {
+	config := fareConfigFor(ctx, s.vehicleTypes, vehicleType)
 
 	baseFare := config.BaseFare
 	distanceFare := distanceKm * config.PerKmRate
@@ -72,6 +128,51 @@ func (s *pricingService) calculateFare(vehicleType string, distanceKm float64, d
 	}
 }
 
+// ApplyEVDiscount takes percent off the fare total and records it on the breakdown
+func (s *pricingService) ApplyEVDiscount(fare *models.FareBreakdown, percent float64) {
+	if percent <= 0 {
+		return
+	}
+	discount := round(fare.Total * percent / 100)
+	fare.EVDiscount = discount
+	fare.Total = round(fare.Total - discount)
+}
+
+// ApplyWaitingFee charges for every started minute the driver waited at pickup
+// beyond the free allowance
+func (s *pricingService) ApplyWaitingFee(ctx context.Context, fare *models.FareBreakdown, vehicleType string, waited time.Duration) {
+	config := fareConfigFor(ctx, s.vehicleTypes, vehicleType)
+
+	billable := math.Ceil(waited.Minutes()) - freeWaitingMins
+	if billable <= 0 {
+		return
+	}
+	fee := round(billable * config.WaitingPerMin)
+	fare.WaitingFee = fee
+	fare.Total = round(fare.Total + fee)
+}
+
+func (s *pricingService) ApplyPackageSurcharges(fare *models.FareBreakdown, packageSize string, declaredValue *float64) {
+	fare.PackageSurcharge = models.PackageClasses[packageSize].Surcharge
+	if declaredValue != nil {
+		fare.DeclaredValueSurcharge = round(*declaredValue * models.DeclaredValueSurchargePercent / 100)
+	}
+	fare.Total = round(fare.Total + fare.PackageSurcharge + fare.DeclaredValueSurcharge)
+}
+
+func (s *pricingService) ApplyPromo(fare *models.FareBreakdown, promo *models.PromoCode) {
+	discount := promo.Value
+	if promo.DiscountType == models.PromoTypePercent {
+		discount = fare.Total * promo.Value / 100
+		if promo.MaxDiscount != nil && discount > *promo.MaxDiscount {
+			discount = *promo.MaxDiscount
+		}
+	}
+	discount = round(math.Min(discount, fare.Total))
+	fare.PromoDiscount = discount
+	fare.Total = round(fare.Total - discount)
+}
+
 func (s *pricingService) CalculateSurge(demandCount, supplyCount int) float64 {
 	if supplyCount == 0 {
 		return 2.0 // Max surge
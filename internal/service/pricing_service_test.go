@@ -1,11 +1,14 @@
 package service
 
 import (
+	"context"
 	"testing"
+
+	"github.com/aditya/go-comet/internal/models"
 )
 
 func TestCalculateEstimatedFare(t *testing.T) {
-	ps := NewPricingService()
+	ps := NewPricingService(nil)
 
 	tests := []struct {
 		name            string
@@ -51,7 +54,7 @@ func TestCalculateEstimatedFare(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := ps.CalculateEstimatedFare(tt.vehicleType, tt.distanceKm, tt.durationMins, tt.surgeMultiplier)
+			result := ps.CalculateEstimatedFare(context.Background(), tt.vehicleType, tt.distanceKm, tt.durationMins, tt.surgeMultiplier)
 			if result == nil {
 				t.Fatal("Expected non-nil result")
 			}
@@ -66,7 +69,7 @@ func TestCalculateEstimatedFare(t *testing.T) {
 }
 
 func TestCalculateSurge(t *testing.T) {
-	ps := NewPricingService()
+	ps := NewPricingService(nil)
 
 	tests := []struct {
 		name     string
@@ -93,7 +96,7 @@ func TestCalculateSurge(t *testing.T) {
 }
 
 func TestEstimateDistance(t *testing.T) {
-	ps := NewPricingService()
+	ps := NewPricingService(nil)
 
 	// Known distance: MG Road to Koramangala is ~5km
 	dist := ps.EstimateDistance(12.9716, 77.5946, 12.9352, 77.6245)
@@ -104,7 +107,7 @@ func TestEstimateDistance(t *testing.T) {
 }
 
 func TestEstimateDuration(t *testing.T) {
-	ps := NewPricingService()
+	ps := NewPricingService(nil)
 
 	tests := []struct {
 		distanceKm float64
@@ -123,3 +126,29 @@ func TestEstimateDuration(t *testing.T) {
 		}
 	}
 }
+
+func TestApplyPackageSurcharges(t *testing.T) {
+	ps := NewPricingService(nil)
+	declared := 2000.0
+
+	tests := []struct {
+		name          string
+		packageSize   string
+		declaredValue *float64
+		wantTotal     float64
+	}{
+		{"Small without cover", models.PackageSizeSmall, nil, 100},
+		{"Medium without cover", models.PackageSizeMedium, nil, 130},
+		{"Large with cover", models.PackageSizeLarge, &declared, 180}, // 100 + 60 + 1% of 2000
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fare := &models.FareBreakdown{Total: 100}
+			ps.ApplyPackageSurcharges(fare, tt.packageSize, tt.declaredValue)
+			if fare.Total != tt.wantTotal {
+				t.Errorf("ApplyPackageSurcharges() total = %v, want %v", fare.Total, tt.wantTotal)
+			}
+		})
+	}
+}
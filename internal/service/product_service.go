@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"log"
+	"math"
+
+	"github.com/aditya/go-comet/internal/cache"
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+// ProductService lists ride products with live availability at a location
+type ProductService interface {
+	// GetCatalog lists products at the location. Given a rider, products being
+	// rolled out in the region show as bookable only if the rider is let in.
+	GetCatalog(ctx context.Context, lat, lng float64, userID string) (*models.ProductCatalog, error)
+}
+
+type productService struct {
+	regionService  RegionService
+	pricingService PricingService
+	trainingRepo   repository.TrainingRepository
+	driverCache    cache.DriverLocationCache
+	matchRadius    float64
+	rolloutService RolloutService
+	surgeService   SurgeService
+	vehicleTypes   VehicleTypeService
+}
+
+func NewProductService(
+	regionService RegionService,
+	pricingService PricingService,
+	trainingRepo repository.TrainingRepository,
+	driverCache cache.DriverLocationCache,
+	matchRadius float64,
+	rolloutService RolloutService,
+	surgeService SurgeService,
+	vehicleTypes VehicleTypeService,
+) ProductService {
+	if matchRadius <= 0 {
+		matchRadius = defaultMatchRadius
+	}
+	return &productService{
+		regionService:  regionService,
+		pricingService: pricingService,
+		trainingRepo:   trainingRepo,
+		driverCache:    driverCache,
+		matchRadius:    matchRadius,
+		rolloutService: rolloutService,
+		surgeService:   surgeService,
+		vehicleTypes:   vehicleTypes,
+	}
+}
+
+func (s *productService) GetCatalog(ctx context.Context, lat, lng float64, userID string) (*models.ProductCatalog, error) {
+	regions, err := s.regionService.ListRegions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var region *models.Region
+	for _, r := range regions {
+		if r.Contains(lat, lng) {
+			region = r
+			break
+		}
+	}
+	if len(regions) > 0 && region == nil {
+		return nil, outsideServiceArea(regions, "pickup", lat, lng)
+	}
+
+	catalog := &models.ProductCatalog{Products: make([]*models.Product, 0, len(models.Products))}
+	regionCode := ""
+	if region != nil {
+		catalog.Region = &region.Code
+		regionCode = region.Code
+	}
+
+	// Vehicle types retired or not yet launched here have no supply to show
+	active, err := s.vehicleTypes.Active(ctx, regionCode)
+	if err != nil {
+		return nil, err
+	}
+	launched := make(map[string]bool, len(active))
+	for _, vt := range active {
+		launched[vt.Code] = true
+	}
+
+	// Products sharing a vehicle type share its drivers
+	supply := make(map[string][]cache.DriverWithDistance)
+	for _, def := range models.Products {
+		if def.CarsOnly() {
+			vt, err := s.vehicleTypes.Get(ctx, def.VehicleType)
+			if err != nil {
+				return nil, err
+			}
+			if vt != nil && !def.ServedBy(vt) {
+				log.Printf("not listing %s: vehicle type %s is bike-class", def.Code, def.VehicleType)
+				continue
+			}
+		}
+		product := &models.Product{
+			Code:             def.Code,
+			Name:             def.Name,
+			Description:      def.Description,
+			VehicleType:      def.VehicleType,
+			Bookable:         def.Bookable,
+			RequiredTraining: def.RequiredTraining,
+			SurgeMultiplier:  1.0,
+		}
+		if region != nil {
+			product.Bookable = s.rolloutService.ProductEnabled(ctx, region, def, userID, models.ExposureSourceCatalog)
+		}
+		catalog.Products = append(catalog.Products, product)
+
+		offered := launched[def.VehicleType] && (region == nil || region.Settings.OffersVehicleType(def.VehicleType))
+		nearby, seen := supply[def.VehicleType]
+		if offered && !seen && s.driverCache != nil {
+			nearby, err = s.driverCache.GetNearbyDrivers(ctx, lat, lng, s.matchRadius, def.VehicleType)
+			if err != nil {
+				log.Printf("failed to load nearby %s drivers: %v", def.VehicleType, err)
+			}
+			supply[def.VehicleType] = nearby
+		}
+
+		// Gated products can only go to drivers who finished their training
+		eligible := nearby
+		if def.RequiredTraining != "" {
+			eligible = s.trained(ctx, def.RequiredTraining, nearby)
+		}
+
+		if offered && len(eligible) > 0 {
+			product.Available = true
+			product.NearbyDrivers = len(eligible)
+			// Nearby drivers come back closest first
+			eta := int(math.Ceil(eligible[0].Distance / avgCitySpeedKmh * 60))
+			product.ETAMinutes = &eta
+			product.SurgeMultiplier = s.surge(ctx, lat, lng, def.VehicleType, nearby)
+		}
+		product.FareRange = s.fareRange(ctx, def, product.SurgeMultiplier)
+	}
+
+	return catalog, nil
+}
+
+func (s *productService) trained(ctx context.Context, module string, drivers []cache.DriverWithDistance) []cache.DriverWithDistance {
+	if len(drivers) == 0 {
+		return nil
+	}
+	ids := make([]string, 0, len(drivers))
+	for _, d := range drivers {
+		ids = append(ids, d.DriverID)
+	}
+	completed, err := s.trainingRepo.FilterCompleted(ctx, module, ids)
+	if err != nil {
+		log.Printf("failed to load %s training for nearby drivers: %v", module, err)
+		return nil
+	}
+
+	var trained []cache.DriverWithDistance
+	for _, d := range drivers {
+		if completed[d.DriverID] {
+			trained = append(trained, d)
+		}
+	}
+	return trained
+}
+
+// surge applies the booking surge rule, which counts drivers within surgeRadiusKm
+func (s *productService) surge(ctx context.Context, lat, lng float64, vehicleType string, nearby []cache.DriverWithDistance) float64 {
+	within := 0
+	for _, d := range nearby {
+		if d.Distance <= surgeRadiusKm {
+			within++
+		}
+	}
+	return s.surgeService.Multiplier(ctx, lat, lng, vehicleType, within)
+}
+
+func (s *productService) fareRange(ctx context.Context, def models.ProductDefinition, surge float64) models.FareRange {
+	quote := func(km float64, mins int) float64 {
+		if mins == 0 {
+			mins = s.pricingService.EstimateDuration(km)
+		}
+		fare := s.pricingService.CalculateEstimatedFare(ctx, def.VehicleType, km, mins, surge)
+		return round(fare.Total * def.FareShare)
+	}
+	return models.FareRange{
+		Min:      quote(def.MinKm, def.MinMins),
+		Max:      quote(def.MaxKm, def.MaxMins),
+		Currency: "INR",
+	}
+}
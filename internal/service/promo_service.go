@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	apperrors "github.com/aditya/go-comet/internal/errors"
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+// PromoService manages promo codes and applies them to fares. A promo is checked
+// and quoted when the ride is booked and redeemed when its trip ends.
+type PromoService interface {
+	CreatePromo(ctx context.Context, req *models.CreatePromoCodeRequest) (*models.PromoCode, error)
+	ListPromos(ctx context.Context) ([]*models.PromoCode, error)
+	GetPromo(ctx context.Context, code string) (*models.PromoCode, error)
+	UpdatePromo(ctx context.Context, code string, req *models.UpdatePromoCodeRequest) (*models.PromoCode, error)
+	// Validate reports whether the code can be used on the fare and what it takes off
+	Validate(ctx context.Context, req *models.ValidatePromoRequest) (*models.PromoValidation, error)
+	// Quote takes the code's discount off a booking's estimated fare, failing
+	// with BadRequest if the code can't be used on it
+	Quote(ctx context.Context, code string, fare *models.FareBreakdown) (*models.PromoCode, error)
+	// Redeem takes the ride's promo off its final fare and counts the use. A promo
+	// deactivated or used up since booking gives no discount.
+	Redeem(ctx context.Context, ride *models.Ride, fare *models.FareBreakdown) error
+}
+
+type promoService struct {
+	promoRepo      repository.PromoRepository
+	pricingService PricingService
+}
+
+func NewPromoService(promoRepo repository.PromoRepository, pricingService PricingService) PromoService {
+	return &promoService{
+		promoRepo:      promoRepo,
+		pricingService: pricingService,
+	}
+}
+
+func (s *promoService) CreatePromo(ctx context.Context, req *models.CreatePromoCodeRequest) (*models.PromoCode, error) {
+	if req.DiscountType == models.PromoTypePercent && req.Value > 100 {
+		return nil, apperrors.BadRequest("percent discounts can't exceed 100")
+	}
+	if req.DiscountType == models.PromoTypeFixed && req.MaxDiscount != nil {
+		return nil, apperrors.BadRequest("max_discount only applies to percent discounts")
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, apperrors.BadRequest("expires_at must be in the future")
+	}
+
+	promo := &models.PromoCode{
+		Code:         normalizePromoCode(req.Code),
+		DiscountType: req.DiscountType,
+		Value:        req.Value,
+		MaxDiscount:  req.MaxDiscount,
+		MinFare:      req.MinFare,
+		MaxUses:      req.MaxUses,
+		ExpiresAt:    req.ExpiresAt,
+	}
+	created, err := s.promoRepo.Create(ctx, promo)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		return nil, apperrors.Conflict("promo code already exists")
+	}
+	return promo, nil
+}
+
+func (s *promoService) ListPromos(ctx context.Context) ([]*models.PromoCode, error) {
+	promos, err := s.promoRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if promos == nil {
+		promos = []*models.PromoCode{}
+	}
+	return promos, nil
+}
+
+func (s *promoService) GetPromo(ctx context.Context, code string) (*models.PromoCode, error) {
+	promo, err := s.promoRepo.GetByCode(ctx, normalizePromoCode(code))
+	if err != nil {
+		return nil, err
+	}
+	if promo == nil {
+		return nil, apperrors.NotFound("promo code")
+	}
+	return promo, nil
+}
+
+func (s *promoService) UpdatePromo(ctx context.Context, code string, req *models.UpdatePromoCodeRequest) (*models.PromoCode, error) {
+	promo, err := s.GetPromo(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	if promo.DiscountType == models.PromoTypeFixed && req.MaxDiscount != nil {
+		return nil, apperrors.BadRequest("max_discount only applies to percent discounts")
+	}
+
+	promo.MaxDiscount = req.MaxDiscount
+	promo.MinFare = req.MinFare
+	promo.MaxUses = req.MaxUses
+	promo.ExpiresAt = req.ExpiresAt
+	promo.Active = req.Active
+	if err := s.promoRepo.Update(ctx, promo); err != nil {
+		return nil, err
+	}
+	return promo, nil
+}
+
+func (s *promoService) Validate(ctx context.Context, req *models.ValidatePromoRequest) (*models.PromoValidation, error) {
+	code := normalizePromoCode(req.Code)
+	promo, err := s.promoRepo.GetByCode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.PromoValidation{Code: code, DiscountedFare: req.Fare}
+	if reason := promoUnusableReason(promo, req.Fare, time.Now()); reason != "" {
+		result.Reason = reason
+		return result, nil
+	}
+
+	fare := &models.FareBreakdown{Total: req.Fare}
+	s.pricingService.ApplyPromo(fare, promo)
+	result.Valid = true
+	result.Discount = fare.PromoDiscount
+	result.DiscountedFare = fare.Total
+	return result, nil
+}
+
+func (s *promoService) Quote(ctx context.Context, code string, fare *models.FareBreakdown) (*models.PromoCode, error) {
+	promo, err := s.promoRepo.GetByCode(ctx, normalizePromoCode(code))
+	if err != nil {
+		return nil, err
+	}
+	if reason := promoUnusableReason(promo, fare.Total, time.Now()); reason != "" {
+		return nil, apperrors.BadRequest(reason)
+	}
+	s.pricingService.ApplyPromo(fare, promo)
+	return promo, nil
+}
+
+func (s *promoService) Redeem(ctx context.Context, ride *models.Ride, fare *models.FareBreakdown) error {
+	if ride.PromoCode == nil {
+		return nil
+	}
+
+	// Ending a trip again after a failed save gives the discount already redeemed
+	existing, err := s.promoRepo.GetRedemptionByRideID(ctx, ride.ID)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		fare.PromoDiscount = existing.Discount
+		fare.Total = round(fare.Total - existing.Discount)
+		return nil
+	}
+
+	promo, err := s.promoRepo.GetByCode(ctx, *ride.PromoCode)
+	if err != nil {
+		return err
+	}
+	// Expiry and minimum fare were checked at booking; the quote is honoured
+	if promo == nil || !promo.Active {
+		log.Printf("promo %s on ride %s is no longer active", *ride.PromoCode, ride.ID)
+		return nil
+	}
+
+	s.pricingService.ApplyPromo(fare, promo)
+	ok, err := s.promoRepo.Redeem(ctx, &models.PromoRedemption{
+		PromoID:  promo.ID,
+		RideID:   ride.ID,
+		UserID:   ride.UserID,
+		Discount: fare.PromoDiscount,
+	})
+	if err != nil || !ok {
+		fare.Total = round(fare.Total + fare.PromoDiscount)
+		fare.PromoDiscount = 0
+	}
+	if err == nil && !ok {
+		log.Printf("promo %s on ride %s was used up before the trip ended", promo.Code, ride.ID)
+	}
+	return err
+}
+
+// promoUnusableReason explains why the promo can't be used on the fare, or
+// returns "" if it can
+func promoUnusableReason(promo *models.PromoCode, fare float64, now time.Time) string {
+	switch {
+	case promo == nil:
+		return "promo code not found"
+	case !promo.Active:
+		return "promo code is no longer active"
+	case promo.ExpiresAt != nil && !now.Before(*promo.ExpiresAt):
+		return "promo code has expired"
+	case promo.Exhausted():
+		return "promo code has been fully redeemed"
+	case fare < promo.MinFare:
+		return fmt.Sprintf("promo code needs a fare of at least %.2f", promo.MinFare)
+	}
+	return ""
+}
+
+// normalizePromoCode makes codes case-insensitive
+func normalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
@@ -0,0 +1,285 @@
+package service
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	apperrors "github.com/aditya/go-comet/internal/errors"
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/moderation"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+const (
+	// Riders are prompted to rate trips from up to a week back
+	unratedTripsMaxAge = 7 * 24 * time.Hour
+	unratedTripsLimit  = 10
+)
+
+// RatingService records the ratings riders and drivers give each other after a
+// trip and keeps their average ratings current
+type RatingService interface {
+	// RateTrip stores one side's rating of the other. Each side rates a trip once.
+	RateTrip(ctx context.Context, tripID string, req *models.RateTripRequest) (*models.TripRating, error)
+	// UnratedTrips lists the rider's recent trips they haven't rated, so apps can
+	// prompt for a rating on next open
+	UnratedTrips(ctx context.Context, userID string) ([]*models.TripSummary, error)
+	// TripRatings returns a trip's ratings as the rated side sees them, with
+	// feedback only once it is published
+	TripRatings(ctx context.Context, tripID string) ([]*models.TripRating, error)
+	// AppealFeedback asks for the author's removed feedback to be reviewed again.
+	// Feedback can be appealed once.
+	AppealFeedback(ctx context.Context, ratingID string, req *models.AppealFeedbackRequest) (*models.TripRating, error)
+	// FeedbackQueue lists feedback in the given moderation status, or all feedback
+	// waiting on an admin when status is empty, oldest first
+	FeedbackQueue(ctx context.Context, status string, limit int) ([]*models.TripRating, error)
+	// ModerateFeedback publishes or removes feedback
+	ModerateFeedback(ctx context.Context, ratingID, status string) (*models.TripRating, error)
+}
+
+type ratingService struct {
+	ratingRepo    repository.RatingRepository
+	tripRepo      repository.TripRepository
+	userRepo      repository.UserRepository
+	driverRepo    repository.DriverRepository
+	contentFilter *moderation.Filter
+	window        int
+	driverPolicy  models.DriverRatingPolicy
+	rateLink      string
+}
+
+func NewRatingService(
+	ratingRepo repository.RatingRepository,
+	tripRepo repository.TripRepository,
+	userRepo repository.UserRepository,
+	driverRepo repository.DriverRepository,
+	contentFilter *moderation.Filter,
+	window int,
+	driverPolicy models.DriverRatingPolicy,
+	rateLink string,
+) RatingService {
+	return &ratingService{
+		ratingRepo:    ratingRepo,
+		tripRepo:      tripRepo,
+		userRepo:      userRepo,
+		driverRepo:    driverRepo,
+		contentFilter: contentFilter,
+		window:        window,
+		driverPolicy:  driverPolicy,
+		rateLink:      rateLink,
+	}
+}
+
+func (s *ratingService) RateTrip(ctx context.Context, tripID string, req *models.RateTripRequest) (*models.TripRating, error) {
+	trip, err := s.tripRepo.GetByID(ctx, tripID)
+	if err != nil {
+		return nil, err
+	}
+	if trip == nil {
+		return nil, apperrors.NotFound("trip")
+	}
+	if trip.Status != models.TripStatusCompleted {
+		return nil, apperrors.BadRequest("only completed trips can be rated")
+	}
+
+	rating := &models.TripRating{
+		TripID:    trip.ID,
+		RaterType: req.RaterType,
+		RaterID:   req.RaterID,
+		Rating:    req.Rating,
+	}
+	switch req.RaterType {
+	case models.RaterUser:
+		if req.RaterID != trip.UserID {
+			return nil, apperrors.BadRequest("rater was not part of this trip")
+		}
+		rating.RateeID = trip.DriverID
+	case models.RaterDriver:
+		if req.RaterID != trip.DriverID {
+			return nil, apperrors.BadRequest("rater was not part of this trip")
+		}
+		rating.RateeID = trip.UserID
+	}
+
+	// Feedback is shown to the other side, so contact details are masked and
+	// abusive feedback is held for an admin instead of being published
+	if feedback := strings.TrimSpace(req.Feedback); feedback != "" {
+		filtered := s.contentFilter.Check(feedback, req.Language)
+		status := models.FeedbackPublished
+		if filtered.Blocked {
+			status = models.FeedbackFlagged
+			reason := models.FlagReasonBlockedLanguage
+			rating.FlagReason = &reason
+		}
+		rating.Feedback = &filtered.Text
+		rating.FeedbackStatus = &status
+	}
+
+	created, err := s.ratingRepo.Create(ctx, rating)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		return nil, apperrors.Conflict("trip was already rated")
+	}
+
+	// The rating is kept even if the average can't be updated; the next rating
+	// recalculates it from scratch
+	if req.RaterType == models.RaterUser {
+		err = s.updateDriverRating(ctx, rating.RateeID)
+	} else {
+		err = s.userRepo.UpdateRating(ctx, rating.RateeID, s.window)
+	}
+	if err != nil {
+		log.Printf("failed to update rating of %s: %v", rating.RateeID, err)
+	}
+	return rating, nil
+}
+
+// updateDriverRating recalculates the driver's public rating from their latest
+// ratings under the driver rating policy
+func (s *ratingService) updateDriverRating(ctx context.Context, driverID string) error {
+	latest, err := s.ratingRepo.GetLatestForDriver(ctx, driverID, s.window)
+	if err != nil {
+		return err
+	}
+	ratings := make([]int, 0, len(latest))
+	for _, r := range latest {
+		ratings = append(ratings, r.Rating)
+	}
+	return s.driverRepo.SetRating(ctx, driverID, s.driverPolicy.Rating(ratings))
+}
+
+func (s *ratingService) UnratedTrips(ctx context.Context, userID string) ([]*models.TripSummary, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, apperrors.NotFound("user")
+	}
+
+	trips, err := s.ratingRepo.GetUnratedTrips(ctx, userID, time.Now().Add(-unratedTripsMaxAge), unratedTripsLimit)
+	if err != nil {
+		return nil, err
+	}
+	if trips == nil {
+		trips = []*models.TripSummary{}
+	}
+	for _, trip := range trips {
+		trip.RateLink = models.RateTripLink(s.rateLink, trip.TripID)
+	}
+	return trips, nil
+}
+
+func (s *ratingService) TripRatings(ctx context.Context, tripID string) ([]*models.TripRating, error) {
+	trip, err := s.tripRepo.GetByID(ctx, tripID)
+	if err != nil {
+		return nil, err
+	}
+	if trip == nil {
+		return nil, apperrors.NotFound("trip")
+	}
+
+	ratings, err := s.ratingRepo.GetByTripID(ctx, trip.ID)
+	if err != nil {
+		return nil, err
+	}
+	visible := make([]*models.TripRating, 0, len(ratings))
+	for _, rating := range ratings {
+		visible = append(visible, rating.ForRatee())
+	}
+	return visible, nil
+}
+
+func (s *ratingService) AppealFeedback(ctx context.Context, ratingID string, req *models.AppealFeedbackRequest) (*models.TripRating, error) {
+	rating, err := s.getRating(ctx, ratingID)
+	if err != nil {
+		return nil, err
+	}
+	if rating.RaterID != req.RaterID {
+		return nil, apperrors.BadRequest("only the author can appeal feedback")
+	}
+	if !rating.FeedbackIs(models.FeedbackRemoved) {
+		return nil, apperrors.BadRequest("only removed feedback can be appealed")
+	}
+	if rating.AppealReason != nil {
+		return nil, apperrors.Conflict("feedback was already appealed")
+	}
+
+	status := models.FeedbackAppealed
+	reason := strings.TrimSpace(req.Reason)
+	rating.FeedbackStatus = &status
+	rating.AppealReason = &reason
+	return s.updateFeedbackStatus(ctx, rating, models.FeedbackRemoved)
+}
+
+func (s *ratingService) FeedbackQueue(ctx context.Context, status string, limit int) ([]*models.TripRating, error) {
+	statuses := models.FeedbackQueueStatuses
+	if status != "" {
+		statuses = []string{status}
+	}
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+
+	ratings, err := s.ratingRepo.GetByFeedbackStatus(ctx, statuses, limit)
+	if err != nil {
+		return nil, err
+	}
+	if ratings == nil {
+		ratings = []*models.TripRating{}
+	}
+	return ratings, nil
+}
+
+func (s *ratingService) ModerateFeedback(ctx context.Context, ratingID, status string) (*models.TripRating, error) {
+	rating, err := s.getRating(ctx, ratingID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Published feedback can still be taken down; removed feedback only comes back
+	// through an appeal
+	from := models.FeedbackQueueStatuses
+	if status == models.FeedbackRemoved {
+		from = append([]string{models.FeedbackPublished}, from...)
+	}
+	if !rating.FeedbackIs(from...) {
+		return nil, apperrors.Conflict("feedback is not awaiting this decision")
+	}
+
+	now := time.Now()
+	rating.FeedbackStatus = &status
+	rating.ModeratedAt = &now
+	return s.updateFeedbackStatus(ctx, rating, from...)
+}
+
+func (s *ratingService) getRating(ctx context.Context, id string) (*models.TripRating, error) {
+	rating, err := s.ratingRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if rating == nil {
+		return nil, apperrors.NotFound("rating")
+	}
+	return rating, nil
+}
+
+// updateFeedbackStatus saves the rating's new feedback status if another request
+// hasn't moved it on from the expected statuses first
+func (s *ratingService) updateFeedbackStatus(ctx context.Context, rating *models.TripRating, from ...string) (*models.TripRating, error) {
+	updated, err := s.ratingRepo.UpdateFeedbackStatus(ctx, rating, from)
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, apperrors.Conflict("feedback was moderated by another request")
+	}
+	return rating, nil
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+
+	apperrors "github.com/aditya/go-comet/internal/errors"
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+type ReceiptService interface {
+	GetReceipt(ctx context.Context, tripID string) (*models.Receipt, error)
+}
+
+type receiptService struct {
+	tripRepo      repository.TripRepository
+	rideRepo      repository.RideRepository
+	paymentRepo   repository.PaymentRepository
+	insuranceRepo repository.InsuranceRepository
+	segmentRepo   repository.TripSegmentRepository
+	driverRepo    repository.DriverRepository
+	deliveryRepo  repository.DeliveryRepository
+}
+
+func NewReceiptService(
+	tripRepo repository.TripRepository,
+	rideRepo repository.RideRepository,
+	paymentRepo repository.PaymentRepository,
+	insuranceRepo repository.InsuranceRepository,
+	segmentRepo repository.TripSegmentRepository,
+	driverRepo repository.DriverRepository,
+	deliveryRepo repository.DeliveryRepository,
+) ReceiptService {
+	return &receiptService{
+		tripRepo:      tripRepo,
+		rideRepo:      rideRepo,
+		paymentRepo:   paymentRepo,
+		insuranceRepo: insuranceRepo,
+		segmentRepo:   segmentRepo,
+		driverRepo:    driverRepo,
+		deliveryRepo:  deliveryRepo,
+	}
+}
+
+func (s *receiptService) GetReceipt(ctx context.Context, tripID string) (*models.Receipt, error) {
+	trip, err := s.tripRepo.GetByID(ctx, tripID)
+	if err != nil {
+		return nil, err
+	}
+	if trip == nil {
+		return nil, apperrors.NotFound("trip")
+	}
+	if trip.Status != models.TripStatusCompleted {
+		return nil, apperrors.BadRequest("trip is not completed")
+	}
+
+	ride, err := s.rideRepo.GetByID(ctx, trip.RideID)
+	if err != nil {
+		return nil, err
+	}
+	if ride == nil {
+		return nil, apperrors.NotFound("ride")
+	}
+
+	rideResp := ride.ToResponse()
+	receipt := &models.Receipt{
+		Type:         models.ReceiptTypeRide,
+		TripID:       trip.ID,
+		RideID:       ride.ID,
+		Pickup:       rideResp.Pickup,
+		Dropoff:      rideResp.Dropoff,
+		VehicleType:  ride.VehicleType,
+		StartTime:    trip.StartTime,
+		EndTime:      trip.EndTime,
+		DistanceKm:   trip.ActualDistanceKm,
+		DurationMins: trip.ActualDurationMin,
+		Fare:         trip.ToResponse().FareBreakdown,
+		CO2Grams:     trip.CO2Grams,
+	}
+
+	payment, err := s.paymentRepo.GetByTripID(ctx, trip.ID)
+	if err != nil {
+		return nil, err
+	}
+	if payment != nil {
+		receipt.Payment = payment.ToResponse()
+	}
+
+	policy, err := s.insuranceRepo.GetPolicyByTripID(ctx, trip.ID)
+	if err != nil {
+		return nil, err
+	}
+	if policy != nil {
+		receipt.Insurance = &models.ReceiptInsurance{
+			Provider:     policy.Provider,
+			PolicyNumber: policy.PolicyNumber,
+			Currency:     policy.Coverage.Currency,
+			Limits:       policy.Coverage.Limits,
+		}
+	}
+
+	// Trips continued by a rescue driver list each leg under the single trip total
+	segments, err := s.segmentRepo.GetByTripID(ctx, trip.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, segment := range segments {
+		item := &models.ReceiptSegment{
+			Sequence:     segment.Sequence,
+			StartTime:    segment.StartTime,
+			EndTime:      segment.EndTime,
+			DistanceKm:   segment.DistanceKm,
+			DurationMins: segment.DurationMins,
+		}
+		driver, err := s.driverRepo.GetByID(ctx, segment.DriverID)
+		if err == nil && driver != nil {
+			item.DriverName = driver.Name
+		}
+		receipt.Segments = append(receipt.Segments, item)
+	}
+
+	// Parcel receipts carry the proof of delivery
+	if ride.IsDelivery() {
+		delivery, err := s.deliveryRepo.GetByRideID(ctx, ride.ID)
+		if err != nil {
+			return nil, err
+		}
+		if delivery != nil {
+			receipt.Type = models.ReceiptTypeDelivery
+			receipt.Delivery = &models.ReceiptDelivery{
+				RecipientName:        delivery.RecipientName,
+				ReceivedBy:           delivery.ReceivedBy,
+				PackageDescription:   delivery.PackageDescription,
+				PackageSize:          delivery.PackageSize,
+				WeightKg:             delivery.WeightKg,
+				DeclaredValue:        delivery.DeclaredValue,
+				PickedUpAt:           delivery.PickedUpAt,
+				DeliveredAt:          delivery.DeliveredAt,
+				PickupPhotoUploadID:  delivery.PickupPhotoUploadID,
+				DropoffPhotoUploadID: delivery.DropoffPhotoUploadID,
+			}
+		}
+	}
+
+	return receipt, nil
+}
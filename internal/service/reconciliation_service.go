@@ -0,0 +1,290 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/aditya/go-comet/internal/cache"
+	"github.com/aditya/go-comet/internal/metrics"
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+const (
+	cacheRepairBatchSize = 100
+	cacheRepairMaxDelay  = 5 * time.Minute
+)
+
+type ReconciliationService interface {
+	ReconcileDrivers(ctx context.Context) (*models.ReconciliationReport, error)
+	// QueueRepair schedules the driver's cache entries to be rewritten from Postgres
+	// after a cache write failed following a committed change
+	QueueRepair(ctx context.Context, driverID, reason string)
+	ProcessRepairs(ctx context.Context) (int, error)
+}
+
+type reconciliationService struct {
+	driverRepo  repository.DriverRepository
+	rideRepo    repository.RideRepository
+	repairRepo  repository.DriverCacheRepairRepository
+	driverCache cache.DriverLocationCache
+	// Every registered type's geo index is checked, inactive ones included
+	vehicleTypes VehicleTypeService
+}
+
+func NewReconciliationService(
+	driverRepo repository.DriverRepository,
+	rideRepo repository.RideRepository,
+	repairRepo repository.DriverCacheRepairRepository,
+	driverCache cache.DriverLocationCache,
+	vehicleTypes VehicleTypeService,
+) ReconciliationService {
+	return &reconciliationService{
+		driverRepo:   driverRepo,
+		rideRepo:     rideRepo,
+		repairRepo:   repairRepo,
+		driverCache:  driverCache,
+		vehicleTypes: vehicleTypes,
+	}
+}
+
+// ReconcileDrivers compares driver status and active-ride markers in Redis against
+// Postgres (the source of truth) and rewrites the cache wherever they disagree.
+func (s *reconciliationService) ReconcileDrivers(ctx context.Context) (*models.ReconciliationReport, error) {
+	report := &models.ReconciliationReport{
+		StartedAt: time.Now(),
+		Repaired:  make(map[string]int),
+	}
+
+	// Drivers Postgres considers available or on a ride
+	drivers, err := s.driverRepo.GetByStatuses(ctx, models.DriverStatusOnline, models.DriverStatusBusy)
+	if err != nil {
+		return nil, err
+	}
+
+	// Vehicle type of each driver available or on a ride
+	known := make(map[string]string, len(drivers))
+	for _, driver := range drivers {
+		known[driver.ID] = driver.VehicleType
+		s.reconcileDriver(ctx, driver, report)
+	}
+
+	// Geo index entries for drivers that are offline (or unknown) in Postgres, or
+	// indexed under a vehicle type they no longer drive
+	vehicleTypes, err := s.vehicleTypes.Codes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, vehicleType := range vehicleTypes {
+		indexed, err := s.driverCache.GetIndexedDrivers(ctx, vehicleType)
+		if err != nil {
+			log.Printf("reconcile: failed to read geo index for %s: %v", vehicleType, err)
+			continue
+		}
+
+		var unknown []string
+		for _, id := range indexed {
+			if known[id] != vehicleType {
+				unknown = append(unknown, id)
+			}
+		}
+		if len(unknown) == 0 {
+			continue
+		}
+
+		offline, err := s.driverRepo.GetByIDs(ctx, unknown)
+		if err != nil {
+			return nil, err
+		}
+		byID := make(map[string]*models.Driver, len(offline))
+		for _, d := range offline {
+			byID[d.ID] = d
+		}
+
+		for _, id := range unknown {
+			// Drivers may have come online since the first read; only entries of
+			// drivers still offline, gone, or driving another type are stale
+			d := byID[id]
+			if d != nil && d.Status != models.DriverStatusOffline && d.VehicleType == vehicleType {
+				continue
+			}
+			report.DriversChecked++
+			if err := s.driverCache.RemoveDriver(ctx, id, vehicleType); err != nil {
+				log.Printf("reconcile: failed to remove driver %s from geo index: %v", id, err)
+				continue
+			}
+			if d != nil {
+				s.driverCache.SetDriverMeta(ctx, id, d.Status, d.VehicleType, d.Rating)
+			}
+			s.record(report, models.DriftStaleGeoEntry, id)
+		}
+	}
+
+	if err := s.reconcileOfflineActiveRides(ctx, known, report); err != nil {
+		return nil, err
+	}
+
+	if total := report.Total(); total > 0 {
+		log.Printf("reconcile: checked %d drivers, repaired %d inconsistencies", report.DriversChecked, total)
+	}
+
+	return report, nil
+}
+
+// reconcileDriver rewrites the driver's cached status and active ride where they
+// differ from Postgres, returning the first failure
+func (s *reconciliationService) reconcileDriver(ctx context.Context, driver *models.Driver, report *models.ReconciliationReport) error {
+	report.DriversChecked++
+
+	meta, err := s.driverCache.GetDriverMeta(ctx, driver.ID)
+	if err != nil {
+		log.Printf("reconcile: failed to read meta for driver %s: %v", driver.ID, err)
+		return err
+	}
+	if meta["status"] != driver.Status || meta["vehicle_type"] != driver.VehicleType {
+		if err := s.driverCache.SetDriverMeta(ctx, driver.ID, driver.Status, driver.VehicleType, driver.Rating); err != nil {
+			return err
+		}
+		s.record(report, models.DriftStatusMismatch, driver.ID)
+	}
+
+	cachedRideID, err := s.driverCache.GetActiveRide(ctx, driver.ID)
+	if err != nil {
+		log.Printf("reconcile: failed to read active ride for driver %s: %v", driver.ID, err)
+		return err
+	}
+
+	activeRide, err := s.rideRepo.GetActiveRideByDriverID(ctx, driver.ID)
+	if err != nil {
+		log.Printf("reconcile: failed to load active ride for driver %s: %v", driver.ID, err)
+		return err
+	}
+
+	switch {
+	case activeRide == nil && cachedRideID != "":
+		if err := s.driverCache.ClearActiveRide(ctx, driver.ID); err != nil {
+			return err
+		}
+		s.record(report, models.DriftStaleActiveRide, driver.ID)
+	case activeRide != nil && cachedRideID != activeRide.ID:
+		if err := s.driverCache.SetActiveRide(ctx, driver.ID, activeRide.ID); err != nil {
+			return err
+		}
+		s.record(report, models.DriftMissingActiveRide, driver.ID)
+	}
+	return nil
+}
+
+// reconcileOfflineActiveRides clears active-ride markers left on drivers who are
+// offline in Postgres and have no active ride there
+func (s *reconciliationService) reconcileOfflineActiveRides(ctx context.Context, known map[string]string, report *models.ReconciliationReport) error {
+	marked, err := s.driverCache.GetActiveRideDrivers(ctx)
+	if err != nil {
+		log.Printf("reconcile: failed to list active-ride markers: %v", err)
+		return nil
+	}
+
+	var unknown []string
+	for _, id := range marked {
+		if _, ok := known[id]; !ok {
+			unknown = append(unknown, id)
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+
+	records, err := s.driverRepo.GetByIDs(ctx, unknown)
+	if err != nil {
+		return err
+	}
+	byID := make(map[string]*models.Driver, len(records))
+	for _, d := range records {
+		byID[d.ID] = d
+	}
+
+	for _, id := range unknown {
+		// Came online since the first read; the next pass checks them
+		if d := byID[id]; d != nil && d.Status != models.DriverStatusOffline {
+			continue
+		}
+		report.DriversChecked++
+		activeRide, err := s.rideRepo.GetActiveRideByDriverID(ctx, id)
+		if err != nil {
+			log.Printf("reconcile: failed to load active ride for driver %s: %v", id, err)
+			continue
+		}
+		if activeRide != nil {
+			continue
+		}
+		if err := s.driverCache.ClearActiveRide(ctx, id); err != nil {
+			log.Printf("reconcile: failed to clear active ride for driver %s: %v", id, err)
+			continue
+		}
+		s.record(report, models.DriftStaleActiveRide, id)
+	}
+	return nil
+}
+
+func (s *reconciliationService) QueueRepair(ctx context.Context, driverID, reason string) {
+	metrics.CounterMap("driver_cache_repairs").Add("queued", 1)
+	if err := s.repairRepo.Enqueue(ctx, driverID, reason); err != nil {
+		// The periodic reconciliation pass still catches the drift
+		log.Printf("failed to queue cache repair for driver %s: %v", driverID, err)
+	}
+}
+
+// ProcessRepairs rewrites the cache entries of queued drivers from Postgres,
+// backing off drivers whose repair fails until Redis is back
+func (s *reconciliationService) ProcessRepairs(ctx context.Context) (int, error) {
+	repairs, err := s.repairRepo.GetDue(ctx, cacheRepairBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	repaired := 0
+	for _, repair := range repairs {
+		err := s.repairDriver(ctx, repair.DriverID)
+		if err != nil {
+			metrics.CounterMap("driver_cache_repairs").Add("failed", 1)
+			delay := time.Duration(repair.Attempts+1) * 5 * time.Second
+			if delay > cacheRepairMaxDelay {
+				delay = cacheRepairMaxDelay
+			}
+			if err := s.repairRepo.RecordFailure(ctx, repair.DriverID, err.Error(), time.Now().Add(delay)); err != nil {
+				log.Printf("failed to record cache repair failure for driver %s: %v", repair.DriverID, err)
+			}
+			continue
+		}
+
+		if err := s.repairRepo.Delete(ctx, repair); err != nil {
+			log.Printf("failed to clear cache repair for driver %s: %v", repair.DriverID, err)
+		}
+		metrics.CounterMap("driver_cache_repairs").Add("repaired", 1)
+		repaired++
+	}
+
+	if repaired > 0 {
+		log.Printf("reconcile: repaired cache entries of %d queued drivers", repaired)
+	}
+	return repaired, nil
+}
+
+func (s *reconciliationService) repairDriver(ctx context.Context, driverID string) error {
+	driver, err := s.driverRepo.GetByID(ctx, driverID)
+	if err != nil {
+		return err
+	}
+	if driver == nil {
+		return nil
+	}
+	report := &models.ReconciliationReport{StartedAt: time.Now(), Repaired: make(map[string]int)}
+	return s.reconcileDriver(ctx, driver, report)
+}
+
+func (s *reconciliationService) record(report *models.ReconciliationReport, kind, driverID string) {
+	report.Repaired[kind]++
+	metrics.CounterMap("driver_reconciliation_inconsistencies").Add(kind, 1)
+	log.Printf("reconcile: repaired %s for driver %s", kind, driverID)
+}
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	apperrors "github.com/aditya/go-comet/internal/errors"
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+	"github.com/aditya/go-comet/internal/tenant"
+)
+
+const regionCacheTTL = time.Minute
+
+type RegionService interface {
+	// Resolve returns the active region containing the point, or nil if none does
+	Resolve(ctx context.Context, lat, lng float64) (*models.Region, error)
+	// ListRegions returns active regions of the tenant in ctx, or of every tenant when unscoped
+	ListRegions(ctx context.Context) ([]*models.Region, error)
+	// GetRegion returns the active region with the given code, or nil
+	GetRegion(ctx context.Context, code string) (*models.Region, error)
+	// ListAllRegions returns every region including inactive ones, bypassing the cache
+	ListAllRegions(ctx context.Context) ([]*models.Region, error)
+	UpdateSettings(ctx context.Context, code string, settings models.RegionSettings) (*models.Region, error)
+	UpdateServiceArea(ctx context.Context, code string, area models.ServiceArea) (*models.Region, error)
+	// AssignTenant makes the region part of a tenant's service area
+	AssignTenant(ctx context.Context, code, tenantCode string) (*models.Region, error)
+}
+
+type regionService struct {
+	regionRepo repository.RegionRepository
+	tenantRepo repository.TenantRepository
+
+	mu       sync.RWMutex
+	regions  []*models.Region
+	loadedAt time.Time
+}
+
+func NewRegionService(regionRepo repository.RegionRepository, tenantRepo repository.TenantRepository) RegionService {
+	return &regionService{
+		regionRepo: regionRepo,
+		tenantRepo: tenantRepo,
+	}
+}
+
+func (s *regionService) Resolve(ctx context.Context, lat, lng float64) (*models.Region, error) {
+	regions, err := s.ListRegions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, region := range regions {
+		if region.Contains(lat, lng) {
+			return region, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *regionService) GetRegion(ctx context.Context, code string) (*models.Region, error) {
+	regions, err := s.ListRegions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, region := range regions {
+		if region.Code == code {
+			return region, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *regionService) ListRegions(ctx context.Context) ([]*models.Region, error) {
+	regions, err := s.active(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	code := tenant.Code(ctx)
+	if code == "" {
+		return regions, nil
+	}
+	var scoped []*models.Region
+	for _, region := range regions {
+		if region.TenantCode == code {
+			scoped = append(scoped, region)
+		}
+	}
+	return scoped, nil
+}
+
+// active returns active regions, served from memory and refreshed every regionCacheTTL
+func (s *regionService) active(ctx context.Context) ([]*models.Region, error) {
+	s.mu.RLock()
+	if time.Since(s.loadedAt) < regionCacheTTL {
+		regions := s.regions
+		s.mu.RUnlock()
+		return regions, nil
+	}
+	s.mu.RUnlock()
+
+	regions, err := s.regionRepo.GetActive(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.regions = regions
+	s.loadedAt = time.Now()
+	s.mu.Unlock()
+
+	return regions, nil
+}
+
+func (s *regionService) ListAllRegions(ctx context.Context) ([]*models.Region, error) {
+	return s.regionRepo.GetAll(ctx)
+}
+
+func (s *regionService) UpdateSettings(ctx context.Context, code string, settings models.RegionSettings) (*models.Region, error) {
+	region, err := s.regionRepo.GetByCode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	if region == nil {
+		return nil, apperrors.NotFound("region")
+	}
+
+	if err := s.regionRepo.UpdateSettings(ctx, code, settings); err != nil {
+		return nil, err
+	}
+	region.Settings = settings
+
+	s.invalidate()
+	return region, nil
+}
+
+func (s *regionService) UpdateServiceArea(ctx context.Context, code string, area models.ServiceArea) (*models.Region, error) {
+	region, err := s.regionRepo.GetByCode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	if region == nil {
+		return nil, apperrors.NotFound("region")
+	}
+
+	if err := s.regionRepo.UpdateServiceArea(ctx, code, area); err != nil {
+		return nil, err
+	}
+	region.ServiceArea = area
+
+	s.invalidate()
+	return region, nil
+}
+
+func (s *regionService) AssignTenant(ctx context.Context, code, tenantCode string) (*models.Region, error) {
+	region, err := s.regionRepo.GetByCode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	if region == nil {
+		return nil, apperrors.NotFound("region")
+	}
+	t, err := s.tenantRepo.GetByCode(ctx, tenantCode)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, apperrors.NotFound("tenant")
+	}
+
+	if err := s.regionRepo.UpdateTenant(ctx, code, tenantCode); err != nil {
+		return nil, err
+	}
+	region.TenantCode = tenantCode
+
+	s.invalidate()
+	return region, nil
+}
+
+// invalidate forces a reload so admin changes apply on this instance immediately
+func (s *regionService) invalidate() {
+	s.mu.Lock()
+	s.loadedAt = time.Time{}
+	s.mu.Unlock()
+}
+
+// outsideServiceArea is the error for a ride end that no region covers, pointing
+// the rider at the closest region
+func outsideServiceArea(regions []*models.Region, location string, lat, lng float64) *apperrors.APIError {
+	miss := &models.ServiceAreaMiss{Location: location}
+	for _, r := range regions {
+		p := r.Boundary().NearestPoint(lat, lng)
+		km := round(haversineDistance(lat, lng, p.Lat, p.Lng))
+		if miss.Nearest == nil || km < miss.Nearest.DistanceKm {
+			miss.Nearest = &models.NearestServiceArea{
+				RegionCode: r.Code,
+				Name:       r.Name,
+				DistanceKm: km,
+				Lat:        p.Lat,
+				Lng:        p.Lng,
+			}
+		}
+	}
+	return apperrors.OutsideServiceArea(miss)
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"log"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+// RepricingService adjusts a booked ride's price when dispatch lets the rider down
+type RepricingService interface {
+	// AfterReassignment relieves surge when the replacement driver's pickup ETA is
+	// well beyond the one first promised. Returns nil when the fare is unchanged.
+	AfterReassignment(ctx context.Context, ride *models.Ride, replacementETAMin int) (*models.FareAdjustment, error)
+}
+
+type repricingService struct {
+	adjustmentRepo repository.FareAdjustmentRepository
+	pricingService PricingService
+	policy         models.RepricingPolicy
+}
+
+func NewRepricingService(
+	adjustmentRepo repository.FareAdjustmentRepository,
+	pricingService PricingService,
+	policy models.RepricingPolicy,
+) RepricingService {
+	return &repricingService{
+		adjustmentRepo: adjustmentRepo,
+		pricingService: pricingService,
+		policy:         policy,
+	}
+}
+
+func (s *repricingService) AfterReassignment(ctx context.Context, ride *models.Ride, replacementETAMin int) (*models.FareAdjustment, error) {
+	// Negotiated bid fares are fixed
+	if ride.ReassignedFrom == nil || ride.OriginalPickupETAMin == nil || ride.AgreedFare != nil {
+		return nil, nil
+	}
+	if replacementETAMin-*ride.OriginalPickupETAMin < s.policy.MinETAIncreaseMins {
+		return nil, nil
+	}
+	surge := s.policy.AdjustedSurge(ride.SurgeMultiplier)
+	if surge >= ride.SurgeMultiplier {
+		return nil, nil
+	}
+
+	adj := &models.FareAdjustment{
+		RideID:            ride.ID,
+		Reason:            models.FareAdjustmentReassignmentDelay,
+		OriginalSurge:     ride.SurgeMultiplier,
+		AdjustedSurge:     surge,
+		OriginalFare:      ride.EstimatedFare,
+		AdjustedFare:      ride.EstimatedFare,
+		OriginalETAMin:    ride.OriginalPickupETAMin,
+		ReplacementETAMin: &replacementETAMin,
+	}
+	if ride.EstimatedDistanceKm != nil && ride.EstimatedDurationMin != nil {
+		fare := s.pricingService.CalculateEstimatedFare(ctx, ride.VehicleType, *ride.EstimatedDistanceKm,
+			*ride.EstimatedDurationMin, surge)
+		adj.AdjustedFare = &fare.Total
+	}
+
+	if err := s.adjustmentRepo.ApplySurge(ctx, adj); err != nil {
+		return nil, err
+	}
+
+	log.Printf("repricing: ride %s surge %.2f -> %.2f after reassignment (eta %d -> %d mins)",
+		ride.ID, adj.OriginalSurge, surge, *ride.OriginalPickupETAMin, replacementETAMin)
+	ride.SurgeMultiplier = surge
+	ride.EstimatedFare = adj.AdjustedFare
+	return adj, nil
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+// RetentionService anonymizes precise locations and personal details on trips
+// past their region's retention period. Fares, distances and durations are kept
+// for billing and aggregate reporting.
+type RetentionService interface {
+	// Run anonymizes expired trips in every region, or only counts them when
+	// dryRun is set
+	Run(ctx context.Context, dryRun bool) (*models.RetentionReport, error)
+}
+
+type retentionService struct {
+	retentionRepo repository.RetentionRepository
+	regionService RegionService
+	defaultDays   int
+	batchSize     int
+}
+
+// NewRetentionService keeps trips for defaultDays unless their region sets its
+// own period; zero keeps them forever. Trips are anonymized batchSize at a time.
+func NewRetentionService(
+	retentionRepo repository.RetentionRepository,
+	regionService RegionService,
+	defaultDays int,
+	batchSize int,
+) RetentionService {
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+	return &retentionService{
+		retentionRepo: retentionRepo,
+		regionService: regionService,
+		defaultDays:   defaultDays,
+		batchSize:     batchSize,
+	}
+}
+
+func (s *retentionService) Run(ctx context.Context, dryRun bool) (*models.RetentionReport, error) {
+	regions, err := s.regionService.ListAllRegions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	report := &models.RetentionReport{DryRun: dryRun, RunAt: now, Regions: []*models.RegionRetentionReport{}}
+
+	// Trips booked outside every region follow the default period
+	scopes := []*models.RegionRetentionReport{{RetentionDays: s.defaultDays}}
+	for _, region := range regions {
+		days := s.defaultDays
+		if region.Settings.DataRetentionDays > 0 {
+			days = region.Settings.DataRetentionDays
+		}
+		code := region.Code
+		scopes = append(scopes, &models.RegionRetentionReport{RegionCode: &code, RetentionDays: days})
+	}
+
+	for _, scope := range scopes {
+		if scope.RetentionDays <= 0 {
+			continue
+		}
+		scope.Cutoff = now.AddDate(0, 0, -scope.RetentionDays)
+
+		regionCode := ""
+		if scope.RegionCode != nil {
+			regionCode = *scope.RegionCode
+		}
+		if dryRun {
+			scope.Trips, err = s.retentionRepo.CountExpiredTrips(ctx, regionCode, scope.Cutoff)
+		} else {
+			scope.Trips, err = s.anonymize(ctx, regionCode, scope.Cutoff)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		report.Regions = append(report.Regions, scope)
+		report.Trips += scope.Trips
+	}
+
+	if report.Trips > 0 {
+		if dryRun {
+			log.Printf("data retention (dry run): %d trips due for anonymization", report.Trips)
+		} else {
+			log.Printf("data retention: anonymized %d trips", report.Trips)
+		}
+	}
+	return report, nil
+}
+
+// anonymize works through a region's expired trips in batches so a large
+// backlog doesn't hold one long transaction
+func (s *retentionService) anonymize(ctx context.Context, regionCode string, before time.Time) (int, error) {
+	total := 0
+	for {
+		n, err := s.retentionRepo.AnonymizeTrips(ctx, regionCode, before, s.batchSize)
+		if err != nil {
+			return total, err
+		}
+		total += n
+		if n < s.batchSize {
+			return total, nil
+		}
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	apperrors "github.com/aditya/go-comet/internal/errors"
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+// RideEconomicsService records what each paid ride earned and cost the platform,
+// for take-rate reporting per trip and per region
+type RideEconomicsService interface {
+	// Record is called once the trip's payment has completed
+	Record(ctx context.Context, trip *models.Trip, payment *models.Payment) (*models.RideEconomics, error)
+	MarkRefunded(ctx context.Context, paymentID string) error
+	GetRideEconomics(ctx context.Context, rideID string) (*models.RideEconomics, error)
+	TakeRateReport(ctx context.Context, from, to time.Time) (*models.TakeRateReport, error)
+}
+
+type rideEconomicsService struct {
+	economicsRepo repository.RideEconomicsRepository
+}
+
+func NewRideEconomicsService(economicsRepo repository.RideEconomicsRepository) RideEconomicsService {
+	return &rideEconomicsService{economicsRepo: economicsRepo}
+}
+
+func (s *rideEconomicsService) Record(ctx context.Context, trip *models.Trip, payment *models.Payment) (*models.RideEconomics, error) {
+	if payment.CommissionAmount == nil || payment.DriverEarnings == nil {
+		return nil, apperrors.BadRequest("payment has no commission split")
+	}
+
+	// Donations and earlier rides' cancellation fees aren't part of this ride's fare
+	riderPaid := round(payment.Amount - payment.CarbonOffsetAmount - payment.CancellationFeeAmount)
+	var discounts float64
+	if trip.EVDiscount != nil {
+		discounts = *trip.EVDiscount
+	}
+	if trip.PromoDiscount != nil {
+		discounts += *trip.PromoDiscount
+	}
+
+	e, err := s.economicsRepo.Record(ctx, &models.RideEconomics{
+		RideID:         trip.RideID,
+		TripID:         trip.ID,
+		PaymentID:      payment.ID,
+		DriverID:       trip.DriverID,
+		Currency:       payment.Currency,
+		GrossFare:      round(riderPaid + discounts),
+		Discounts:      discounts,
+		RiderPaid:      riderPaid,
+		Commission:     *payment.CommissionAmount,
+		DriverEarnings: *payment.DriverEarnings,
+	})
+	if err != nil {
+		return nil, err
+	}
+	e.TakeRatePercent = models.TakeRate(e.NetRevenue, e.GrossFare)
+	return e, nil
+}
+
+func (s *rideEconomicsService) MarkRefunded(ctx context.Context, paymentID string) error {
+	return s.economicsRepo.MarkRefunded(ctx, paymentID, time.Now())
+}
+
+func (s *rideEconomicsService) GetRideEconomics(ctx context.Context, rideID string) (*models.RideEconomics, error) {
+	e, err := s.economicsRepo.GetByRideID(ctx, rideID)
+	if err != nil {
+		return nil, err
+	}
+	if e == nil {
+		return nil, apperrors.NotFound("ride economics")
+	}
+	e.TakeRatePercent = models.TakeRate(e.NetRevenue, e.GrossFare)
+	return e, nil
+}
+
+// TakeRateReport sums unrefunded rides paid in the period per region
+func (s *rideEconomicsService) TakeRateReport(ctx context.Context, from, to time.Time) (*models.TakeRateReport, error) {
+	if !from.Before(to) {
+		return nil, apperrors.BadRequest("from must be before to")
+	}
+
+	rates, err := s.economicsRepo.GetTakeRates(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.TakeRateReport{From: from, To: to, Regions: []*models.RegionTakeRate{}}
+	for _, r := range rates {
+		r.TakeRatePercent = models.TakeRate(r.NetRevenue, r.GrossFare)
+		report.Regions = append(report.Regions, r)
+	}
+	return report, nil
+}
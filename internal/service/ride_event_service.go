@@ -0,0 +1,216 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/aditya/go-comet/internal/cache"
+	"github.com/aditya/go-comet/internal/httpclient"
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/push"
+	"github.com/aditya/go-comet/internal/repository"
+	"github.com/aditya/go-comet/internal/statemachine"
+)
+
+// RideEventService carries out the side effects of a ride changing status. It is
+// registered as a hook on models.RideStates, so every service that moves a ride
+// through the state machine publishes the same events and notifications. The
+// audit trail is written by the rides table trigger.
+type RideEventService interface {
+	OnTransition(ctx context.Context, t statemachine.Transition)
+}
+
+// riderPushes is what the rider is told when their ride reaches a status
+var riderPushes = map[string]push.Message{
+	models.RideStatusDriverAssigned: {Title: "Driver on the way", Body: "A driver accepted your ride and is heading to the pickup."},
+	models.RideStatusDriverArrived:  {Title: "Your driver has arrived", Body: "Your driver is waiting at the pickup point."},
+	models.RideStatusInProgress:     {Title: "Trip started", Body: "Enjoy your ride."},
+	models.RideStatusCompleted:      {Title: "Trip completed", Body: "Thanks for riding. Your receipt is ready."},
+	models.RideStatusCancelled:      {Title: "Ride cancelled", Body: "Your ride has been cancelled."},
+}
+
+// cancellationPushes replace the cancelled push for rides the system gave up on
+var cancellationPushes = map[string]push.Message{
+	models.CancelReasonRiderUnreachable: {
+		Title: "Ride request cancelled",
+		Body:  "We lost contact with your app, so your ride request was cancelled. You have not been charged.",
+	},
+	models.CancelReasonMatchingTimedOut: {
+		Title: "Ride request cancelled",
+		Body:  "We couldn't find you a driver in time, so your ride request was cancelled. You have not been charged.",
+	},
+}
+
+type rideEventService struct {
+	rideRepo    repository.RideRepository
+	tripRepo    repository.TripRepository
+	driverRepo  repository.DriverRepository
+	driverCache cache.DriverLocationCache
+	pusher      push.Sender
+	webhookURL  string
+	rateLink    string
+	client      *httpclient.Client
+}
+
+// NewRideEventService returns the ride event pipeline. Pushes are skipped when
+// pusher is nil and webhook delivery when webhookURL is empty.
+func NewRideEventService(
+	rideRepo repository.RideRepository,
+	tripRepo repository.TripRepository,
+	driverRepo repository.DriverRepository,
+	driverCache cache.DriverLocationCache,
+	pusher push.Sender,
+	webhookURL string,
+	rateLink string,
+) RideEventService {
+	return &rideEventService{
+		rideRepo:    rideRepo,
+		tripRepo:    tripRepo,
+		driverRepo:  driverRepo,
+		driverCache: driverCache,
+		pusher:      pusher,
+		webhookURL:  webhookURL,
+		rateLink:    rateLink,
+		client:      httpclient.New(httpclient.Config{Name: "ride-events-webhook", Timeout: 5 * time.Second}),
+	}
+}
+
+func (s *rideEventService) OnTransition(ctx context.Context, t statemachine.Transition) {
+	ride, err := s.rideRepo.GetByID(ctx, t.ID)
+	if err != nil || ride == nil {
+		log.Printf("ride events: failed to load ride %s: %v", t.ID, err)
+		return
+	}
+
+	event := &models.RideStatusEvent{
+		RideID:         ride.ID,
+		TenantCode:     ride.TenantCode,
+		UserID:         ride.UserID,
+		DriverID:       ride.DriverID,
+		PreviousStatus: t.From,
+		Status:         t.To,
+		At:             t.At,
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("ride events: failed to encode event for ride %s: %v", ride.ID, err)
+		return
+	}
+
+	// Trackers connected to any instance pick the change up from Redis
+	if s.driverCache != nil {
+		if err := s.driverCache.PublishRideStatus(ctx, payload); err != nil {
+			log.Printf("ride events: failed to publish status of ride %s: %v", ride.ID, err)
+		}
+	}
+
+	if s.webhookURL != "" {
+		if err := s.postWebhook(ctx, payload); err != nil {
+			log.Printf("ride events: webhook failed for ride %s: %v", ride.ID, err)
+		}
+	}
+
+	s.notify(ctx, ride, t)
+}
+
+// notify pushes the rider about their ride and, when the rider cancels, the driver
+func (s *rideEventService) notify(ctx context.Context, ride *models.Ride, t statemachine.Transition) {
+	if s.pusher == nil {
+		return
+	}
+
+	cancelledBy := ""
+	if ride.CancelledBy != nil {
+		cancelledBy = *ride.CancelledBy
+	}
+
+	// A finished trip gets its summary and a prompt to rate it instead
+	if t.To == models.RideStatusCompleted {
+		if msg := s.tripSummaryPush(ctx, ride); msg != nil {
+			s.send(ctx, ride.UserID, *msg, ride.ID, t.To)
+			return
+		}
+	}
+
+	if msg, ok := riderPushes[t.To]; ok && !(t.To == models.RideStatusCancelled && cancelledBy == "user") {
+		if t.To == models.RideStatusCancelled && ride.CancellationReason != nil {
+			if reasonMsg, ok := cancellationPushes[*ride.CancellationReason]; ok {
+				msg = reasonMsg
+			}
+		}
+		s.send(ctx, ride.UserID, msg, ride.ID, t.To)
+	}
+
+	if t.To == models.RideStatusCancelled && cancelledBy == "user" && ride.DriverID != nil {
+		msg := push.Message{Title: "Ride cancelled", Body: "The rider cancelled this ride."}
+		s.send(ctx, *ride.DriverID, msg, ride.ID, t.To)
+	}
+}
+
+// tripSummaryPush recaps the ride's trip with a link to rate it, or returns nil
+// if the trip can't be loaded
+func (s *rideEventService) tripSummaryPush(ctx context.Context, ride *models.Ride) *push.Message {
+	trip, err := s.tripRepo.GetByRideID(ctx, ride.ID)
+	if err != nil || trip == nil || trip.TotalFare == nil {
+		log.Printf("ride events: no trip summary for ride %s: %v", ride.ID, err)
+		return nil
+	}
+	driverName := "your driver"
+	if driver, err := s.driverRepo.GetByID(ctx, trip.DriverID); err == nil && driver != nil {
+		driverName = driver.Name
+	}
+
+	body := fmt.Sprintf("%.2f INR", *trip.TotalFare)
+	if trip.ActualDurationMin != nil {
+		body += fmt.Sprintf(" for %d min", *trip.ActualDurationMin)
+	}
+	return &push.Message{
+		Title: "Trip completed",
+		Body:  fmt.Sprintf("%s with %s. How was your ride? Tap to rate.", body, driverName),
+		Data: map[string]string{
+			"trip_id":     trip.ID,
+			"total_fare":  fmt.Sprintf("%.2f", *trip.TotalFare),
+			"driver_name": driverName,
+			"rate_link":   models.RateTripLink(s.rateLink, trip.ID),
+		},
+	}
+}
+
+func (s *rideEventService) send(ctx context.Context, recipientID string, msg push.Message, rideID, status string) {
+	msg.UserID = recipientID
+	data := map[string]string{
+		"type":    status,
+		"ride_id": rideID,
+	}
+	for k, v := range msg.Data {
+		data[k] = v
+	}
+	msg.Data = data
+	if err := s.pusher.Send(ctx, &msg); err != nil {
+		log.Printf("failed to send %s push for ride %s: %v", status, rideID, err)
+	}
+}
+
+func (s *rideEventService) postWebhook(ctx context.Context, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.webhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+// RideJanitorService cancels rides left waiting for a driver when matching never
+// finished, e.g. because the process crashed mid-match, so riders aren't blocked
+// from booking again by a ride nobody is working on
+type RideJanitorService interface {
+	CancelStuckRides(ctx context.Context) (int, error)
+}
+
+type rideJanitorService struct {
+	rideRepo  repository.RideRepository
+	offerRepo repository.RideOfferRepository
+	timeout   time.Duration
+}
+
+func NewRideJanitorService(
+	rideRepo repository.RideRepository,
+	offerRepo repository.RideOfferRepository,
+	timeout time.Duration,
+) RideJanitorService {
+	return &rideJanitorService{
+		rideRepo:  rideRepo,
+		offerRepo: offerRepo,
+		timeout:   timeout,
+	}
+}
+
+// CancelStuckRides cancels pending and matching rides whose status hasn't changed
+// for the timeout. The rider is told through the ride status pipeline.
+func (s *rideJanitorService) CancelStuckRides(ctx context.Context) (int, error) {
+	rides, err := s.rideRepo.GetStuck(ctx,
+		[]string{models.RideStatusPending, models.RideStatusMatching}, time.Now().Add(-s.timeout))
+	if err != nil {
+		return 0, err
+	}
+
+	cancelled := 0
+	for _, ride := range rides {
+		// A driver may have accepted since the ride was read
+		ok, err := s.rideRepo.CancelIfStatus(ctx, ride.ID, ride.Status, "system", models.CancelReasonMatchingTimedOut)
+		if err != nil {
+			log.Printf("failed to cancel stuck ride %s: %v", ride.ID, err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		models.RideStates.Record(ctx, ride.ID, ride.Status, models.RideStatusCancelled)
+		cancelled++
+
+		if err := s.offerRepo.ExpireOldOffers(ctx, ride.ID); err != nil {
+			log.Printf("failed to expire offers for ride %s: %v", ride.ID, err)
+		}
+	}
+
+	if cancelled > 0 {
+		log.Printf("ride janitor: cancelled %d rides stuck waiting for a driver", cancelled)
+	}
+	return cancelled, nil
+}
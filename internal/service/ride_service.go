@@ -2,27 +2,100 @@ package service
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
 	"log"
+	"math"
+	"strings"
+	"time"
 
 	"github.com/aditya/go-comet/internal/cache"
 	apperrors "github.com/aditya/go-comet/internal/errors"
+	"github.com/aditya/go-comet/internal/geocoding"
 	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/moderation"
 	"github.com/aditya/go-comet/internal/repository"
 )
 
+// reverseGeocodeTimeout bounds the best-effort address lookup during ride creation
+const reverseGeocodeTimeout = 2 * time.Second
+
+// sameLocationThresholdKm is how close pickup and dropoff can be before the ride is rejected
+const sameLocationThresholdKm = 0.05
+
+// A repeat booking within duplicateRideWindow whose pickup and dropoff are both
+// within duplicateRideRadiusKm of the user's active ride returns that ride
+const (
+	duplicateRideWindow   = 60 * time.Second
+	duplicateRideRadiusKm = 0.05
+)
+
+// Surge applies when fewer than surgeMinSupply drivers are within surgeRadiusKm of the pickup
+const (
+	surgeRadiusKm  = 2.0
+	surgeMinSupply = 5
+)
+
+// Ride history page sizes
+const (
+	defaultRideHistoryLimit = 20
+	maxRideHistoryLimit     = 100
+)
+
+// matchWaitPollInterval is how often WaitForMatch re-reads the ride
+const matchWaitPollInterval = 250 * time.Millisecond
+
 type RideService interface {
-	CreateRide(ctx context.Context, req *models.CreateRideRequest, idempotencyKey string) (*models.Ride, error)
+	// CreateRide books a ride; created is false when an existing ride was returned
+	// for a repeated request
+	CreateRide(ctx context.Context, req *models.CreateRideRequest, idempotencyKey string) (ride *models.Ride, created bool, err error)
+	// EstimateFare quotes a ride without booking it, applying the same checks as CreateRide
+	EstimateFare(ctx context.Context, req *models.FareEstimateRequest) (*models.FareEstimate, error)
+	// EstimateFares quotes the trip for every vehicle type offered at the pickup
+	EstimateFares(ctx context.Context, req *models.FaresEstimateRequest) (*models.FaresEstimate, error)
 	GetRide(ctx context.Context, id string) (*models.RideResponse, error)
-	CancelRide(ctx context.Context, id string, req *models.CancelRideRequest) error
+	// GetRideHistory returns one page of a rider's or driver's rides, continuing
+	// from cursor, the NextCursor of the previous page
+	GetRideHistory(ctx context.Context, filter *models.RideHistoryFilter, cursor string) (*models.RideHistoryPage, error)
+	// WaitForMatch blocks until the ride leaves matching or timeout passes, then returns it
+	WaitForMatch(ctx context.Context, id string, timeout time.Duration) (*models.RideResponse, error)
+	// CancelRide cancels the ride, returning the fee charged to the rider, if any
+	CancelRide(ctx context.Context, id string, req *models.CancelRideRequest) (*models.CancellationFee, error)
 	UpdateRideStatus(ctx context.Context, id, status string) error
+	EstimateMatch(ctx context.Context, ride *models.Ride) (*models.MatchEstimate, error)
+	// PickupETA estimates minutes until the assigned driver reaches pickup from
+	// their last known location; nil unless the driver is on the way
+	PickupETA(ctx context.Context, ride *models.Ride) (*int, error)
 }
 
 type rideService struct {
-	rideRepo       repository.RideRepository
-	userRepo       repository.UserRepository
-	driverRepo     repository.DriverRepository
-	pricingService PricingService
-	driverCache    cache.DriverLocationCache
+	rideRepo         repository.RideRepository
+	userRepo         repository.UserRepository
+	driverRepo       repository.DriverRepository
+	pricingService   PricingService
+	calendarService  PricingCalendarService
+	regionService    RegionService
+	driverCache      cache.DriverLocationCache
+	geocoder         geocoding.Provider
+	chainService     TripChainService
+	bidPolicy        models.BidPolicy
+	distanceLimits   models.DistanceLimits
+	contentFilter    *moderation.Filter
+	holdService      PaymentHoldService
+	riskService      RiskService
+	pickupService    PickupService
+	deliveryService  DeliveryService
+	walletService    WalletService
+	maintenance      MaintenanceService
+	schedulePolicy   models.SchedulePolicy
+	cancellationFees CancellationFeeService
+	promoService     PromoService
+	rolloutService   RolloutService
+	surgeService     SurgeService
+	router           Router
+	consentService   ConsentService
+	vehicleTypes     VehicleTypeService
 }
 
 func NewRideService(
@@ -30,66 +103,195 @@ func NewRideService(
 	userRepo repository.UserRepository,
 	driverRepo repository.DriverRepository,
 	pricingService PricingService,
+	calendarService PricingCalendarService,
+	regionService RegionService,
 	driverCache cache.DriverLocationCache,
+	geocoder geocoding.Provider,
+	chainService TripChainService,
+	bidPolicy models.BidPolicy,
+	distanceLimits models.DistanceLimits,
+	contentFilter *moderation.Filter,
+	holdService PaymentHoldService,
+	riskService RiskService,
+	pickupService PickupService,
+	deliveryService DeliveryService,
+	walletService WalletService,
+	maintenance MaintenanceService,
+	schedulePolicy models.SchedulePolicy,
+	cancellationFees CancellationFeeService,
+	promoService PromoService,
+	rolloutService RolloutService,
+	surgeService SurgeService,
+	router Router,
+	consentService ConsentService,
+	vehicleTypes VehicleTypeService,
 ) RideService {
 	return &rideService{
-		rideRepo:       rideRepo,
-		userRepo:       userRepo,
-		driverRepo:     driverRepo,
-		pricingService: pricingService,
-		driverCache:    driverCache,
+		rideRepo:         rideRepo,
+		userRepo:         userRepo,
+		driverRepo:       driverRepo,
+		pricingService:   pricingService,
+		calendarService:  calendarService,
+		regionService:    regionService,
+		driverCache:      driverCache,
+		geocoder:         geocoder,
+		chainService:     chainService,
+		bidPolicy:        bidPolicy,
+		distanceLimits:   distanceLimits,
+		contentFilter:    contentFilter,
+		holdService:      holdService,
+		riskService:      riskService,
+		pickupService:    pickupService,
+		deliveryService:  deliveryService,
+		walletService:    walletService,
+		maintenance:      maintenance,
+		schedulePolicy:   schedulePolicy,
+		cancellationFees: cancellationFees,
+		promoService:     promoService,
+		rolloutService:   rolloutService,
+		surgeService:     surgeService,
+		router:           router,
+		consentService:   consentService,
+		vehicleTypes:     vehicleTypes,
 	}
 }
 
-func (s *rideService) CreateRide(ctx context.Context, req *models.CreateRideRequest, idempotencyKey string) (*models.Ride, error) {
+func (s *rideService) CreateRide(ctx context.Context, req *models.CreateRideRequest, idempotencyKey string) (*models.Ride, bool, error) {
 	// Check idempotency
 	if idempotencyKey != "" {
 		existingRide, err := s.rideRepo.GetByIdempotencyKey(ctx, idempotencyKey)
 		if err != nil {
-			return nil, err
+			return nil, false, err
 		}
 		if existingRide != nil {
-			return existingRide, nil
+			return existingRide, false, nil
 		}
 	}
 
 	// Check if user exists
 	user, err := s.userRepo.GetByID(ctx, req.UserID)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 	if user == nil {
-		return nil, apperrors.NotFound("user")
+		return nil, false, apperrors.NotFound("user")
 	}
 
-	// Check if user has active ride
-	activeRide, err := s.rideRepo.GetActiveRideByUserID(ctx, req.UserID)
-	if err != nil {
-		return nil, err
+	if req.ScheduledAt != nil {
+		if err := s.checkSchedule(req); err != nil {
+			return nil, false, err
+		}
+	} else {
+		// Riders can book ahead while on a trip, but only match one ride at a time
+		activeRide, err := s.rideRepo.GetActiveRideByUserID(ctx, req.UserID)
+		if err != nil {
+			return nil, false, err
+		}
+		if activeRide != nil {
+			// A double tap or a retry without an idempotency key gets the ride back
+			if isDuplicateRequest(activeRide, req) {
+				return activeRide, false, nil
+			}
+			return nil, false, apperrors.UserHasActiveRide()
+		}
 	}
-	if activeRide != nil {
-		return nil, apperrors.UserHasActiveRide()
+
+	// Only new bookings are turned away; rides already under way carry on
+	if err := s.maintenance.CheckBooking(ctx); err != nil {
+		return nil, false, err
 	}
 
-	// Calculate estimated distance and duration
-	distanceKm := s.pricingService.EstimateDistance(
-		req.Pickup.Lat, req.Pickup.Lng,
-		req.Dropoff.Lat, req.Dropoff.Lng,
-	)
-	durationMins := s.pricingService.EstimateDuration(distanceKm)
+	// Notes reach the driver, so abuse is rejected and contact details are masked
+	filtered := s.contentFilter.Check(strings.TrimSpace(req.Note), req.Language)
+	if filtered.Blocked {
+		return nil, false, apperrors.BadRequest("note contains language that isn't allowed")
+	}
+	note := filtered.Text
 
-	// Calculate surge based on demand/supply
-	surgeMultiplier := 1.0
-	if s.driverCache != nil {
-		nearbyDrivers, _ := s.driverCache.GetNearbyDrivers(ctx, req.Pickup.Lat, req.Pickup.Lng, 2.0, req.VehicleType)
-		// Simple surge: if less than 5 drivers nearby, apply surge
-		if len(nearbyDrivers) < 5 {
-			surgeMultiplier = s.pricingService.CalculateSurge(10, len(nearbyDrivers))
+	var product *models.ProductDefinition
+	if req.Product != "" {
+		def, ok := models.FindProduct(req.Product)
+		if !ok {
+			return nil, false, apperrors.BadRequest(fmt.Sprintf("%s rides can't be booked yet", req.Product))
+		}
+		if def.VehicleType != req.VehicleType {
+			return nil, false, apperrors.BadRequest(fmt.Sprintf("%s rides use vehicle type %s", req.Product, def.VehicleType))
+		}
+		if def.CarsOnly() {
+			vt, err := s.vehicleTypes.Get(ctx, def.VehicleType)
+			if err != nil {
+				return nil, false, err
+			}
+			if vt != nil && !def.ServedBy(vt) {
+				return nil, false, apperrors.BadRequest(fmt.Sprintf("%s rides can't use bike-class vehicle type %s", req.Product, def.VehicleType))
+			}
+		}
+		product = &def
+	}
+	if req.Passengers > 1 {
+		vt, err := s.vehicleTypes.Get(ctx, req.VehicleType)
+		if err != nil {
+			return nil, false, err
+		}
+		if vt != nil && req.Passengers > vt.Capacity {
+			return nil, false, apperrors.BadRequest(fmt.Sprintf("too many passengers: %s rides seat %d", req.VehicleType, vt.Capacity))
+		}
+	}
+	if req.Delivery != nil {
+		if req.Product != models.ProductDelivery {
+			return nil, false, apperrors.BadRequest("delivery details are only accepted for delivery rides")
+		}
+		class := models.PackageClasses[req.Delivery.PackageSize]
+		if req.Delivery.WeightKg > class.MaxWeightKg {
+			return nil, false, apperrors.BadRequest(fmt.Sprintf("%s packages can weigh at most %.0f kg", req.Delivery.PackageSize, class.MaxWeightKg))
 		}
 	}
 
-	// Calculate fare
-	fare := s.pricingService.CalculateEstimatedFare(req.VehicleType, distanceKm, durationMins, surgeMultiplier)
+	// A chosen curated spot becomes the pickup before the fare is estimated, and
+	// pickups inside a venue must be at one of its named points
+	if err := s.resolveLocation(ctx, &req.Pickup, req.Language); err != nil {
+		return nil, false, err
+	}
+	pickupSpot, err := s.pickupService.ResolvePickup(ctx, req.PickupSpot, &req.Pickup)
+	if err != nil {
+		return nil, false, err
+	}
+
+	estimate, err := s.estimate(ctx, &req.Pickup, &req.Dropoff, req.VehicleType, req.Language)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := s.checkRollout(ctx, req, estimate.RegionCode); err != nil {
+		return nil, false, err
+	}
+	// Riders accept the region's addenda, and any new required terms, before booking
+	if err := s.consentService.Check(ctx, models.RaterUser, req.UserID, estimate.RegionCode); err != nil {
+		return nil, false, err
+	}
+	fare := estimate.Fare
+	if req.Delivery != nil {
+		s.pricingService.ApplyPackageSurcharges(fare, req.Delivery.PackageSize, req.Delivery.DeclaredValue)
+	}
+
+	// Bid rides start from the rider's own offer, which can't undercut the estimate too far
+	if req.PricingMode == models.PricingModeBid {
+		minFare := math.Round(fare.Total*s.bidPolicy.MinFareRatio*100) / 100
+		if *req.ProposedFare < minFare {
+			return nil, false, apperrors.BadRequest(fmt.Sprintf("proposed fare must be at least %.2f", minFare))
+		}
+	}
+
+	// A promo comes off the estimate last, as it will off the final fare
+	var promo *models.PromoCode
+	if req.PromoCode != "" {
+		if req.PricingMode == models.PricingModeBid {
+			return nil, false, apperrors.BadRequest("promo codes can't be used on bid rides")
+		}
+		promo, err = s.promoService.Quote(ctx, req.PromoCode, fare)
+		if err != nil {
+			return nil, false, err
+		}
+	}
 
 	// Create ride
 	ride := &models.Ride{
@@ -101,6 +303,24 @@ func (s *rideService) CreateRide(ctx context.Context, req *models.CreateRideRequ
 		VehicleType:   req.VehicleType,
 		PaymentMethod: req.PaymentMethod,
 		Status:        models.RideStatusPending,
+		PricingMode:   req.PricingMode,
+		ScheduledAt:   req.ScheduledAt,
+		SafetyMode:    user.SafetyMode,
+	}
+	if req.PricingMode == models.PricingModeBid {
+		ride.ProposedFare = req.ProposedFare
+	}
+	// Plain vehicle-type products don't need recording; matching only cares about gated ones
+	if product != nil && product.Code != product.VehicleType {
+		ride.Product = &product.Code
+	}
+	if promo != nil {
+		ride.PromoCode = &promo.Code
+	}
+	if pickupSpot != nil {
+		ride.PickupSpotID = pickupSpot.ID
+		ride.PickupSpotName = &pickupSpot.Name
+		ride.PickupSpotSource = &pickupSpot.Source
 	}
 
 	if req.Pickup.Address != "" {
@@ -112,23 +332,305 @@ func (s *rideService) CreateRide(ctx context.Context, req *models.CreateRideRequ
 	if idempotencyKey != "" {
 		ride.IdempotencyKey = &idempotencyKey
 	}
+	if note != "" {
+		ride.RiderNote = &note
+	}
+	if req.PaymentMethod == models.PaymentMethodCard && req.CardFingerprint != "" {
+		ride.CardFingerprint = &req.CardFingerprint
+	}
+
+	// Tag the ride with the region its pickup falls in
+	if estimate.RegionCode != "" {
+		ride.RegionCode = &estimate.RegionCode
+	}
 
 	ride.EstimatedFare = &fare.Total
-	ride.SurgeMultiplier = surgeMultiplier
-	ride.EstimatedDistanceKm = &distanceKm
-	ride.EstimatedDurationMin = &durationMins
+	ride.SurgeMultiplier = estimate.SurgeMultiplier
+	ride.EstimatedDistanceKm = &estimate.EstimatedDistanceKm
+	ride.EstimatedDurationMin = &estimate.EstimatedDurationMin
+
+	if err := s.riskService.CheckRide(ctx, user, ride); err != nil {
+		return nil, false, err
+	}
+
+	// Wallet rides are prepaid, so the balance must cover the fare when booking
+	if ride.PaymentMethod == models.PaymentMethodWallet {
+		amount := *ride.EstimatedFare
+		if ride.ProposedFare != nil {
+			amount = *ride.ProposedFare
+		}
+		if err := s.walletService.CheckBalance(ctx, user.ID, amount); err != nil {
+			return nil, false, err
+		}
+	}
 
 	if err := s.rideRepo.Create(ctx, ride); err != nil {
-		return nil, err
+		return nil, false, err
+	}
+
+	if req.Delivery != nil {
+		delivery, err := s.deliveryService.Create(ctx, ride, req.Delivery)
+		if err != nil {
+			s.abandonRide(ctx, ride, "delivery_failed")
+			return nil, false, err
+		}
+		ride.Delivery = delivery
+	}
+
+	// Rides booked ahead are held and matched shortly before pickup
+	if ride.ScheduledAt != nil {
+		err = models.RideStates.Apply(ctx, ride.ID, ride.Status, models.RideStatusScheduled, func() error {
+			return s.rideRepo.UpdateStatus(ctx, ride.ID, models.RideStatusScheduled)
+		})
+		if err != nil {
+			s.abandonRide(ctx, ride, "schedule_failed")
+			return nil, false, err
+		}
+		ride.Status = models.RideStatusScheduled
+		return ride, true, nil
+	}
+
+	// Card rides hold the estimated fare before a driver is sought
+	if _, err := s.holdService.Authorize(ctx, ride); err != nil {
+		s.abandonRide(ctx, ride, "payment_declined")
+		return nil, false, err
 	}
 
 	// Update status to matching
-	if err := s.rideRepo.UpdateStatus(ctx, ride.ID, models.RideStatusMatching); err != nil {
+	err = models.RideStates.Apply(ctx, ride.ID, ride.Status, models.RideStatusMatching, func() error {
+		return s.rideRepo.UpdateStatus(ctx, ride.ID, models.RideStatusMatching)
+	})
+	if err != nil {
 		log.Printf("failed to update ride status to matching: %v", err)
 	}
 	ride.Status = models.RideStatusMatching
+	s.surgeService.RecordDemand(ctx, ride)
+
+	return ride, true, nil
+}
+
+// abandonRide cancels a new ride whose setup failed before it was offered to drivers
+func (s *rideService) abandonRide(ctx context.Context, ride *models.Ride, reason string) {
+	err := models.RideStates.Apply(ctx, ride.ID, ride.Status, models.RideStatusCancelled, func() error {
+		return s.rideRepo.Cancel(ctx, ride.ID, "system", reason)
+	})
+	if err != nil {
+		log.Printf("failed to cancel ride %s (%s): %v", ride.ID, reason, err)
+	}
+}
+
+// checkSchedule validates a ride booked ahead against the schedule policy
+func (s *rideService) checkSchedule(req *models.CreateRideRequest) error {
+	if req.PricingMode == models.PricingModeBid {
+		return apperrors.BadRequest("bid rides can't be scheduled")
+	}
+	until := time.Until(*req.ScheduledAt)
+	if until < s.schedulePolicy.MinNotice || until > s.schedulePolicy.MaxAhead {
+		return apperrors.BadRequest(fmt.Sprintf("scheduled_at must be between %.0f minutes and %.0f hours from now",
+			s.schedulePolicy.MinNotice.Minutes(), s.schedulePolicy.MaxAhead.Hours()))
+	}
+	return nil
+}
+
+// checkRollout turns away bookings of a product that isn't launched for the
+// rider in the pickup region. Rides without a product count as their vehicle type.
+func (s *rideService) checkRollout(ctx context.Context, req *models.CreateRideRequest, regionCode string) error {
+	code := req.Product
+	if code == "" {
+		code = req.VehicleType
+	}
+	def, ok := models.FindProduct(code)
+	if !ok {
+		return nil
+	}
+
+	var region *models.Region
+	if regionCode != "" {
+		var err error
+		region, err = s.regionService.GetRegion(ctx, regionCode)
+		if err != nil {
+			return err
+		}
+	}
+	if !s.rolloutService.ProductEnabled(ctx, region, def, req.UserID, models.ExposureSourceBooking) {
+		return apperrors.BadRequest(fmt.Sprintf("%s rides can't be booked yet", code))
+	}
+	return nil
+}
+
+func (s *rideService) EstimateFare(ctx context.Context, req *models.FareEstimateRequest) (*models.FareEstimate, error) {
+	return s.estimate(ctx, &req.Pickup, &req.Dropoff, req.VehicleType, req.Language)
+}
+
+func (s *rideService) EstimateFares(ctx context.Context, req *models.FaresEstimateRequest) (*models.FaresEstimate, error) {
+	route, err := s.estimateRoute(ctx, &req.Pickup, &req.Dropoff, req.Language)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.FaresEstimate{
+		Pickup:               req.Pickup,
+		Dropoff:              req.Dropoff,
+		EstimatedDistanceKm:  route.distanceKm,
+		EstimatedDurationMin: route.durationMins,
+	}
+	if route.region != nil {
+		result.RegionCode = route.region.Code
+	}
+	vehicleTypes, err := s.vehicleTypes.Active(ctx, result.RegionCode)
+	if err != nil {
+		return nil, err
+	}
+	result.Estimates = make([]*models.FareEstimate, 0, len(vehicleTypes))
+	for _, vt := range vehicleTypes {
+		if route.region != nil && !route.region.Settings.OffersVehicleType(vt.Code) {
+			continue
+		}
+		result.Estimates = append(result.Estimates, s.priceEstimate(ctx, route, vt.Code))
+	}
+	return result, nil
+}
+
+// estimatedRoute is a quoted trip's resolved endpoints, region and length
+type estimatedRoute struct {
+	pickup       *models.Location
+	dropoff      *models.Location
+	region       *models.Region
+	distanceKm   float64
+	durationMins int
+}
+
+// estimate resolves and validates the ride's endpoints and prices the trip. The
+// same checks run for a quote as for a booking so riders can't be quoted a ride
+// they aren't allowed to book.
+func (s *rideService) estimate(ctx context.Context, pickup, dropoff *models.Location, vehicleType, language string) (*models.FareEstimate, error) {
+	route, err := s.estimateRoute(ctx, pickup, dropoff, language)
+	if err != nil {
+		return nil, err
+	}
+	regionCode := ""
+	if route.region != nil {
+		regionCode = route.region.Code
+	}
+	if err := s.vehicleTypes.CheckActive(ctx, vehicleType, regionCode); err != nil {
+		return nil, err
+	}
+	if route.region != nil && !route.region.Settings.OffersVehicleType(vehicleType) {
+		return nil, apperrors.BadRequest(fmt.Sprintf("%s rides are not offered in %s", vehicleType, route.region.Name))
+	}
+	return s.priceEstimate(ctx, route, vehicleType), nil
+}
+
+// estimateRoute resolves and validates the endpoints shared by every vehicle type
+func (s *rideService) estimateRoute(ctx context.Context, pickup, dropoff *models.Location, language string) (*estimatedRoute, error) {
+	// Fill in whichever of coordinates/address the rider left out
+	if err := s.resolveLocation(ctx, pickup, language); err != nil {
+		return nil, err
+	}
+	if err := s.resolveLocation(ctx, dropoff, language); err != nil {
+		return nil, err
+	}
+
+	if err := validateRideLocations(pickup, dropoff); err != nil {
+		return nil, err
+	}
+	region, err := s.checkServiceArea(ctx, pickup, dropoff)
+	if err != nil {
+		return nil, err
+	}
+
+	// Calculate estimated distance and duration, over the road network when a router is configured
+	distanceKm := s.pricingService.EstimateDistance(pickup.Lat, pickup.Lng, dropoff.Lat, dropoff.Lng)
+	durationMins := s.pricingService.EstimateDuration(distanceKm)
+	if s.router != nil {
+		route, err := s.router.Route(ctx, pickup.Lat, pickup.Lng, dropoff.Lat, dropoff.Lng)
+		if err != nil {
+			return nil, err
+		}
+		distanceKm, durationMins = route.DistanceKm, route.DurationMins
+	}
+	limits := s.distanceLimits.ForRegion(region)
+	if limits.MinKm > 0 && distanceKm < limits.MinKm {
+		return nil, apperrors.RideTooShort(limits.MinKm)
+	}
+	if limits.MaxKm > 0 && distanceKm > limits.MaxKm {
+		return nil, apperrors.RideTooLong(limits.MaxKm)
+	}
+
+	return &estimatedRoute{
+		pickup:       pickup,
+		dropoff:      dropoff,
+		region:       region,
+		distanceKm:   distanceKm,
+		durationMins: durationMins,
+	}, nil
+}
+
+// priceEstimate prices the route for a vehicle type, with surge from that
+// type's nearby supply
+func (s *rideService) priceEstimate(ctx context.Context, route *estimatedRoute, vehicleType string) *models.FareEstimate {
+	pickup, dropoff := route.pickup, route.dropoff
+
+	// Calculate surge based on demand/supply
+	surgeMultiplier := 1.0
+	available := true
+	if s.driverCache != nil {
+		nearbyDrivers, err := s.driverCache.GetNearbyDrivers(ctx, pickup.Lat, pickup.Lng, surgeRadiusKm, vehicleType)
+		surgeMultiplier = s.surgeService.Multiplier(ctx, pickup.Lat, pickup.Lng, vehicleType, len(nearbyDrivers))
+
+		// Matching looks further out than surge pricing; supply we can't read
+		// doesn't make the ride unavailable
+		if err == nil && len(nearbyDrivers) == 0 {
+			inRange, err := s.driverCache.GetNearbyDrivers(ctx, pickup.Lat, pickup.Lng, defaultMatchRadius, vehicleType)
+			available = err != nil || len(inRange) > 0
+		}
+	}
+
+	estimate := &models.FareEstimate{
+		Pickup:               *pickup,
+		Dropoff:              *dropoff,
+		VehicleType:          vehicleType,
+		EstimatedDistanceKm:  route.distanceKm,
+		EstimatedDurationMin: route.durationMins,
+		SurgeMultiplier:      surgeMultiplier,
+		Fare:                 s.pricingService.CalculateEstimatedFare(ctx, vehicleType, route.distanceKm, route.durationMins, surgeMultiplier),
+		Available:            available,
+	}
+	regionCode := ""
+	if route.region != nil {
+		regionCode = route.region.Code
+	}
+	s.calendarService.Apply(ctx, estimate.Fare, time.Now(), regionCode, vehicleType,
+		models.GeoPoint{Lat: pickup.Lat, Lng: pickup.Lng}, models.GeoPoint{Lat: dropoff.Lat, Lng: dropoff.Lng})
+	if !available {
+		estimate.UnavailableReason = models.EstimateUnavailableNoDrivers
+	}
+	estimate.RegionCode = regionCode
+	return estimate
+}
+
+func (s *rideService) WaitForMatch(ctx context.Context, id string, timeout time.Duration) (*models.RideResponse, error) {
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
 
-	return ride, nil
+	ticker := time.NewTicker(matchWaitPollInterval)
+	defer ticker.Stop()
+
+wait:
+	for {
+		ride, err := s.rideRepo.GetByID(waitCtx, id)
+		if err == nil && ride != nil && (ride.DriverID != nil || ride.Status != models.RideStatusMatching) {
+			break
+		}
+
+		select {
+		case <-waitCtx.Done():
+			break wait
+		case <-ticker.C:
+		}
+	}
+
+	return s.GetRide(ctx, id)
 }
 
 func (s *rideService) GetRide(ctx context.Context, id string) (*models.RideResponse, error) {
@@ -163,36 +665,201 @@ func (s *rideService) GetRide(ctx context.Context, id string) (*models.RideRespo
 				}
 			}
 		}
+
+		eta, err := s.PickupETA(ctx, ride)
+		if err != nil {
+			log.Printf("failed to estimate pickup ETA for ride %s: %v", ride.ID, err)
+		}
+		response.ETAToPickupMin = eta
+	}
+
+	// Riders still waiting on a driver get a time-to-match estimate
+	if ride.Status == models.RideStatusMatching {
+		estimate, err := s.EstimateMatch(ctx, ride)
+		if err != nil {
+			log.Printf("failed to estimate match time for ride %s: %v", ride.ID, err)
+		} else {
+			response.MatchEstimate = estimate
+		}
 	}
 
 	return response, nil
 }
 
-func (s *rideService) CancelRide(ctx context.Context, id string, req *models.CancelRideRequest) error {
+func (s *rideService) GetRideHistory(ctx context.Context, filter *models.RideHistoryFilter, cursor string) (*models.RideHistoryPage, error) {
+	if filter.UserID != "" {
+		user, err := s.userRepo.GetByID(ctx, filter.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil {
+			return nil, apperrors.NotFound("user")
+		}
+	}
+	if filter.DriverID != "" {
+		driver, err := s.driverRepo.GetByID(ctx, filter.DriverID)
+		if err != nil {
+			return nil, err
+		}
+		if driver == nil {
+			return nil, apperrors.NotFound("driver")
+		}
+	}
+	for _, status := range filter.Statuses {
+		if !models.RideStates.Known(status) {
+			return nil, apperrors.BadRequest(fmt.Sprintf("unknown ride status %q", status))
+		}
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, apperrors.BadRequest("from must be before to")
+	}
+	if cursor != "" {
+		createdAt, id, err := decodeRideCursor(cursor)
+		if err != nil {
+			return nil, apperrors.BadRequest("cursor is invalid")
+		}
+		filter.AfterCreatedAt = &createdAt
+		filter.AfterID = id
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultRideHistoryLimit
+	}
+	if filter.Limit > maxRideHistoryLimit {
+		filter.Limit = maxRideHistoryLimit
+	}
+
+	// One extra ride tells us whether there is another page
+	limit := filter.Limit
+	filter.Limit++
+	rides, err := s.rideRepo.GetHistory(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.RideHistoryPage{Rides: []*models.RideResponse{}}
+	if len(rides) > limit {
+		rides = rides[:limit]
+		last := rides[limit-1]
+		page.NextCursor = encodeRideCursor(last.CreatedAt, last.ID)
+	}
+	for _, ride := range rides {
+		page.Rides = append(page.Rides, ride.ToResponse())
+	}
+	return page, nil
+}
+
+func (s *rideService) PickupETA(ctx context.Context, ride *models.Ride) (*int, error) {
+	if ride.DriverID == nil || ride.Status != models.RideStatusDriverAssigned || s.driverCache == nil {
+		return nil, nil
+	}
+	loc, err := s.driverCache.GetDriverLocation(ctx, *ride.DriverID)
+	if err != nil || loc == nil {
+		return nil, err
+	}
+	eta := driveMins(ctx, s.router, loc.Lat, loc.Lng, ride.PickupLat, ride.PickupLng)
+	return &eta, nil
+}
+
+// EstimateMatch estimates how long a ride in matching will wait for a driver,
+// from recent match times and the supply and queue of riders around its pickup
+func (s *rideService) EstimateMatch(ctx context.Context, ride *models.Ride) (*models.MatchEstimate, error) {
+	ahead, err := s.rideRepo.CountMatchingAhead(ctx, ride)
+	if err != nil {
+		return nil, err
+	}
+
+	var recent []time.Duration
+	nearby := 0
+	if s.driverCache != nil {
+		recent, err = s.driverCache.GetRecentMatchTimes(ctx, ride.VehicleType)
+		if err != nil {
+			log.Printf("failed to load recent match times: %v", err)
+		}
+		drivers, err := s.driverCache.GetNearbyDrivers(ctx, ride.PickupLat, ride.PickupLng, defaultMatchRadius, ride.VehicleType)
+		if err != nil {
+			log.Printf("failed to count nearby drivers: %v", err)
+		}
+		nearby = len(drivers)
+	}
+
+	position := ahead + 1
+	wait := models.EstimateMatchWait(recent, position, nearby, time.Since(ride.CreatedAt))
+
+	return &models.MatchEstimate{
+		QueuePosition:    position,
+		NearbyDrivers:    nearby,
+		EstimatedSeconds: int(wait.Seconds()),
+		SampleSize:       len(recent),
+		UpdatedAt:        time.Now(),
+	}, nil
+}
+
+func (s *rideService) CancelRide(ctx context.Context, id string, req *models.CancelRideRequest) (*models.CancellationFee, error) {
 	ride, err := s.rideRepo.GetByID(ctx, id)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if ride == nil {
-		return apperrors.NotFound("ride")
+		return nil, apperrors.NotFound("ride")
+	}
+	switch req.CancelledBy {
+	case models.CancelledByDriver:
+		if ride.DriverID == nil || *ride.DriverID != req.CancelledByID {
+			return nil, apperrors.Forbidden("only the assigned driver can cancel this ride")
+		}
+	case models.CancelledByUser:
+		if req.CancelledByID != "" && ride.UserID != req.CancelledByID {
+			return nil, apperrors.Forbidden("only the rider can cancel this ride")
+		}
 	}
 
-	if !ride.CanTransitionTo(models.RideStatusCancelled) {
-		return apperrors.InvalidTransition(ride.Status, models.RideStatusCancelled)
+	err = models.RideStates.Apply(ctx, id, ride.Status, models.RideStatusCancelled, func() error {
+		return s.rideRepo.Cancel(ctx, id, req.CancelledBy, req.Reason)
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	if err := s.rideRepo.Cancel(ctx, id, req.CancelledBy, req.Reason); err != nil {
-		return err
+	// A failed charge doesn't undo the cancellation; the rider just isn't billed.
+	// Callers who haven't proved they're the rider can't run up a fee for them.
+	var fee *models.CancellationFee
+	if req.CancelledBy == models.CancelledByUser && req.Verified {
+		fee, err = s.cancellationFees.Charge(ctx, ride)
+		if err != nil {
+			log.Printf("failed to charge cancellation fee for ride %s: %v", id, err)
+		}
 	}
 
-	// If driver was assigned, make them available again
+	// Holds on rides cancelled elsewhere are picked up by the release worker
+	if err := s.holdService.Release(ctx, id); err != nil {
+		log.Printf("failed to release payment hold for ride %s: %v", id, err)
+	}
+
+	// A queued ride's driver is still busy with their current trip
+	if ride.Status == models.RideStatusQueued {
+		if err := s.chainService.ReleaseQueuedRide(ctx, ride.ID); err != nil {
+			log.Printf("failed to release queued ride %s: %v", ride.ID, err)
+		}
+		return fee, nil
+	}
+
+	// If driver was assigned, move them on to a queued ride or make them available again
 	if ride.DriverID != nil {
-		if err := s.driverRepo.UpdateStatus(ctx, *ride.DriverID, models.DriverStatusOnline); err != nil {
-			log.Printf("failed to update driver status after cancellation: %v", err)
+		next, err := s.chainService.ActivateQueuedRide(ctx, *ride.DriverID)
+		if err != nil {
+			log.Printf("failed to activate queued ride for driver %s: %v", *ride.DriverID, err)
+		}
+		if next == nil {
+			now := time.Now()
+			if err := s.driverRepo.UpdateStatus(ctx, *ride.DriverID, models.DriverStatusOnline); err != nil {
+				log.Printf("failed to update driver status after cancellation: %v", err)
+			} else {
+				syncDriverStatus(ctx, s.driverCache, *ride.DriverID, models.DriverStatusOnline, now)
+			}
 		}
 	}
 
-	return nil
+	return fee, nil
 }
 
 func (s *rideService) UpdateRideStatus(ctx context.Context, id, status string) error {
@@ -204,9 +871,130 @@ func (s *rideService) UpdateRideStatus(ctx context.Context, id, status string) e
 		return apperrors.NotFound("ride")
 	}
 
-	if !ride.CanTransitionTo(status) {
-		return apperrors.InvalidTransition(ride.Status, status)
+	return models.RideStates.Apply(ctx, id, ride.Status, status, func() error {
+		return s.rideRepo.UpdateStatus(ctx, id, status)
+	})
+}
+
+// isDuplicateRequest reports whether the request repeats the user's ride booked moments ago
+func isDuplicateRequest(ride *models.Ride, req *models.CreateRideRequest) bool {
+	if time.Since(ride.CreatedAt) > duplicateRideWindow {
+		return false
 	}
+	return nearLocation(&req.Pickup, ride.PickupLat, ride.PickupLng, ride.PickupAddress) &&
+		nearLocation(&req.Dropoff, ride.DropoffLat, ride.DropoffLng, ride.DropoffAddress)
+}
 
-	return s.rideRepo.UpdateStatus(ctx, id, status)
+// nearLocation compares by distance, or by address for address-only locations
+func nearLocation(loc *models.Location, lat, lng float64, address *string) bool {
+	if loc.HasCoordinates() {
+		return haversineDistance(loc.Lat, loc.Lng, lat, lng) <= duplicateRideRadiusKm
+	}
+	return address != nil && strings.EqualFold(strings.TrimSpace(*address), strings.TrimSpace(loc.Address))
+}
+
+// validateRideLocations rejects placeholder coordinates and rides that go nowhere
+func validateRideLocations(pickup, dropoff *models.Location) error {
+	if models.IsNullIsland(pickup.Lat, pickup.Lng) {
+		return apperrors.InvalidLocation("pickup coordinates are missing")
+	}
+	if models.IsNullIsland(dropoff.Lat, dropoff.Lng) {
+		return apperrors.InvalidLocation("dropoff coordinates are missing")
+	}
+	if haversineDistance(pickup.Lat, pickup.Lng, dropoff.Lat, dropoff.Lng) < sameLocationThresholdKm {
+		return apperrors.InvalidLocation("pickup and dropoff must be different locations")
+	}
+	return nil
+}
+
+// surgeForSupply is the surge multiplier for the number of drivers near a
+// pickup, for when demand around it can't be counted
+func surgeForSupply(pricing PricingService, nearbyDrivers int) float64 {
+	if nearbyDrivers >= surgeMinSupply {
+		return 1.0
+	}
+	return pricing.CalculateSurge(10, nearbyDrivers)
+}
+
+// checkServiceArea returns the region serving the pickup. Once any region is
+// configured both ends of the ride must fall inside one; a failed region lookup
+// is logged and lets the ride through untagged.
+func (s *rideService) checkServiceArea(ctx context.Context, pickup, dropoff *models.Location) (*models.Region, error) {
+	regions, err := s.regionService.ListRegions(ctx)
+	if err != nil {
+		log.Printf("failed to resolve region for ride: %v", err)
+		return nil, nil
+	}
+	if len(regions) == 0 {
+		return nil, nil
+	}
+
+	var region, dropoffRegion *models.Region
+	for _, r := range regions {
+		if region == nil && r.Contains(pickup.Lat, pickup.Lng) {
+			region = r
+		}
+		if dropoffRegion == nil && r.Contains(dropoff.Lat, dropoff.Lng) {
+			dropoffRegion = r
+		}
+	}
+	if region == nil {
+		return nil, outsideServiceArea(regions, "pickup", pickup.Lat, pickup.Lng)
+	}
+	if dropoffRegion == nil {
+		return nil, outsideServiceArea(regions, "dropoff", dropoff.Lat, dropoff.Lng)
+	}
+	return region, nil
+}
+
+// resolveLocation forward-geocodes an address-only location, which must succeed,
+// and reverse-geocodes a coordinates-only one, which is best effort.
+func (s *rideService) resolveLocation(ctx context.Context, loc *models.Location, language string) error {
+	if !loc.HasCoordinates() {
+		if s.geocoder == nil {
+			return apperrors.BadRequest("coordinates are required: address lookup is not available")
+		}
+		place, err := s.geocoder.Geocode(ctx, loc.Address, language)
+		if errors.Is(err, geocoding.ErrNoResults) {
+			return apperrors.BadRequest("could not find address: " + loc.Address)
+		}
+		if err != nil {
+			return err
+		}
+		loc.Lat = place.Lat
+		loc.Lng = place.Lng
+		return nil
+	}
+
+	if loc.Address != "" || s.geocoder == nil {
+		return nil
+	}
+
+	lookupCtx, cancel := context.WithTimeout(ctx, reverseGeocodeTimeout)
+	defer cancel()
+	place, err := s.geocoder.ReverseGeocode(lookupCtx, loc.Lat, loc.Lng, language)
+	if err != nil {
+		log.Printf("failed to reverse geocode %f,%f: %v", loc.Lat, loc.Lng, err)
+		return nil
+	}
+	loc.Address = place.Address
+	return nil
+}
+
+// encodeRideCursor makes an opaque history cursor from the last ride on a page
+func encodeRideCursor(createdAt time.Time, id string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(createdAt.UTC().Format(time.RFC3339Nano) + "|" + id))
+}
+
+func decodeRideCursor(cursor string) (time.Time, string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, "", err
+	}
+	createdAt, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return time.Time{}, "", errors.New("malformed ride cursor")
+	}
+	at, err := time.Parse(time.RFC3339Nano, createdAt)
+	return at, id, err
 }
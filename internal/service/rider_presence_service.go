@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/aditya/go-comet/internal/cache"
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+// RiderPresenceService tracks whether the rider's app is still around while a
+// ride is matching, so drivers aren't sent to riders who have walked away
+type RiderPresenceService interface {
+	// Heartbeat records that the rider polled or streamed the ride
+	Heartbeat(ctx context.Context, rideID string)
+	CancelUnreachableRides(ctx context.Context) (int, error)
+}
+
+type riderPresenceService struct {
+	rideRepo    repository.RideRepository
+	offerRepo   repository.RideOfferRepository
+	driverCache cache.DriverLocationCache
+	timeout     time.Duration
+}
+
+func NewRiderPresenceService(
+	rideRepo repository.RideRepository,
+	offerRepo repository.RideOfferRepository,
+	driverCache cache.DriverLocationCache,
+	timeout time.Duration,
+) RiderPresenceService {
+	return &riderPresenceService{
+		rideRepo:    rideRepo,
+		offerRepo:   offerRepo,
+		driverCache: driverCache,
+		timeout:     timeout,
+	}
+}
+
+func (s *riderPresenceService) Heartbeat(ctx context.Context, rideID string) {
+	if s.driverCache == nil {
+		return
+	}
+	if err := s.driverCache.TouchRiderHeartbeat(ctx, rideID); err != nil {
+		log.Printf("failed to record rider heartbeat for ride %s: %v", rideID, err)
+	}
+}
+
+// CancelUnreachableRides cancels matching rides whose rider hasn't checked in for
+// the timeout, counting from ride creation when they never did
+func (s *riderPresenceService) CancelUnreachableRides(ctx context.Context) (int, error) {
+	if s.driverCache == nil {
+		return 0, nil
+	}
+
+	cutoff := time.Now().Add(-s.timeout)
+	rides, err := s.rideRepo.GetMatchingCreatedBefore(ctx, cutoff)
+	if err != nil {
+		return 0, err
+	}
+
+	cancelled := 0
+	for _, ride := range rides {
+		lastSeen, err := s.driverCache.GetRiderHeartbeat(ctx, ride.ID)
+		if err != nil {
+			log.Printf("failed to read rider heartbeat for ride %s: %v", ride.ID, err)
+			continue
+		}
+		if lastSeen.IsZero() {
+			lastSeen = ride.CreatedAt
+		}
+		// Riders who booked ahead aren't expected to be watching before pickup time
+		if ride.ScheduledAt != nil && ride.ScheduledAt.After(lastSeen) {
+			lastSeen = *ride.ScheduledAt
+		}
+		if lastSeen.After(cutoff) {
+			continue
+		}
+
+		ok, err := s.rideRepo.CancelIfMatching(ctx, ride.ID, "system", models.CancelReasonRiderUnreachable)
+		if err != nil {
+			log.Printf("failed to cancel unreachable ride %s: %v", ride.ID, err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		models.RideStates.Record(ctx, ride.ID, models.RideStatusMatching, models.RideStatusCancelled)
+		cancelled++
+
+		// Withdraw the offers drivers are still looking at
+		if err := s.offerRepo.ExpireOldOffers(ctx, ride.ID); err != nil {
+			log.Printf("failed to expire offers for ride %s: %v", ride.ID, err)
+		}
+	}
+
+	if cancelled > 0 {
+		log.Printf("rider presence: cancelled %d rides with unreachable riders", cancelled)
+	}
+	return cancelled, nil
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	apperrors "github.com/aditya/go-comet/internal/errors"
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+// recentRiskEvents is how many risk events accompany a rider's overrides
+const recentRiskEvents = 50
+
+// RiskService applies payment risk rules to bookings and manages admin overrides
+type RiskService interface {
+	// CheckRide returns the error to reject a booking with, or nil to allow it.
+	// Blocked bookings and bookings an override let through are recorded.
+	CheckRide(ctx context.Context, user *models.User, ride *models.Ride) error
+	CreateOverride(ctx context.Context, userID string, req *models.CreateRiskOverrideRequest) (*models.RiskOverride, error)
+	RevokeOverride(ctx context.Context, userID, overrideID string, req *models.RevokeRiskOverrideRequest) (*models.RiskOverride, error)
+	GetUserRisk(ctx context.Context, userID string) (*models.UserRisk, error)
+}
+
+type riskService struct {
+	riskRepo repository.RiskRepository
+	userRepo repository.UserRepository
+	policy   models.RiskPolicy
+}
+
+func NewRiskService(
+	riskRepo repository.RiskRepository,
+	userRepo repository.UserRepository,
+	policy models.RiskPolicy,
+) RiskService {
+	return &riskService{
+		riskRepo: riskRepo,
+		userRepo: userRepo,
+		policy:   policy,
+	}
+}
+
+func (s *riskService) CheckRide(ctx context.Context, user *models.User, ride *models.Ride) error {
+	check := models.RiskCheck{
+		AccountCreatedAt: user.CreatedAt,
+		PaymentMethod:    ride.PaymentMethod,
+		NewCard:          true,
+	}
+	switch {
+	case ride.ProposedFare != nil:
+		check.Fare = *ride.ProposedFare
+	case ride.EstimatedFare != nil:
+		check.Fare = *ride.EstimatedFare
+	}
+	if ride.PaymentMethod == models.PaymentMethodCard && ride.CardFingerprint != nil {
+		known, err := s.riskRepo.HasPaidWithCard(ctx, user.ID, *ride.CardFingerprint)
+		if err != nil {
+			return err
+		}
+		check.NewCard = !known
+	}
+
+	now := time.Now()
+	rule := s.policy.Evaluate(check, now)
+	if rule == "" {
+		return nil
+	}
+
+	event := &models.RiskEvent{
+		UserID:        user.ID,
+		Rule:          rule,
+		Outcome:       models.RiskOutcomeBlocked,
+		PaymentMethod: ride.PaymentMethod,
+		Fare:          check.Fare,
+	}
+	override, err := s.riskRepo.GetActiveOverride(ctx, user.ID, now)
+	if err != nil {
+		return err
+	}
+	if override != nil {
+		event.Outcome = models.RiskOutcomeOverridden
+		event.OverrideID = &override.ID
+	}
+	if err := s.riskRepo.RecordEvent(ctx, event); err != nil {
+		log.Printf("failed to record risk event for user %s: %v", user.ID, err)
+	}
+
+	if override != nil {
+		return nil
+	}
+	if rule == models.RiskRuleNewAccountLimit {
+		return apperrors.RideLimitExceeded(s.policy.NewAccountFareLimit)
+	}
+	return apperrors.PrepaymentRequired()
+}
+
+func (s *riskService) CreateOverride(ctx context.Context, userID string, req *models.CreateRiskOverrideRequest) (*models.RiskOverride, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, apperrors.NotFound("user")
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, apperrors.BadRequest("expires_at must be in the future")
+	}
+
+	override := &models.RiskOverride{
+		UserID:    userID,
+		Reason:    req.Reason,
+		GrantedBy: req.GrantedBy,
+		ExpiresAt: req.ExpiresAt,
+	}
+	if err := s.riskRepo.CreateOverride(ctx, override); err != nil {
+		return nil, err
+	}
+	return override, nil
+}
+
+// RevokeOverride ends an override early; the row is kept for the audit trail
+func (s *riskService) RevokeOverride(ctx context.Context, userID, overrideID string, req *models.RevokeRiskOverrideRequest) (*models.RiskOverride, error) {
+	override, err := s.riskRepo.GetOverrideByID(ctx, overrideID)
+	if err != nil {
+		return nil, err
+	}
+	if override == nil || override.UserID != userID {
+		return nil, apperrors.NotFound("risk override")
+	}
+
+	now := time.Now()
+	if !override.ActiveAt(now) {
+		return nil, apperrors.BadRequest("risk override is no longer active")
+	}
+	if err := s.riskRepo.RevokeOverride(ctx, overrideID, req.RevokedBy, now); err != nil {
+		return nil, err
+	}
+	override.RevokedAt = &now
+	override.RevokedBy = &req.RevokedBy
+	return override, nil
+}
+
+func (s *riskService) GetUserRisk(ctx context.Context, userID string) (*models.UserRisk, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, apperrors.NotFound("user")
+	}
+
+	overrides, err := s.riskRepo.GetOverridesByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	events, err := s.riskRepo.GetRecentEvents(ctx, userID, recentRiskEvents)
+	if err != nil {
+		return nil, err
+	}
+
+	risk := &models.UserRisk{
+		UserID:    userID,
+		Overrides: overrides,
+		Events:    events,
+	}
+	if risk.Overrides == nil {
+		risk.Overrides = []*models.RiskOverride{}
+	}
+	if risk.Events == nil {
+		risk.Events = []*models.RiskEvent{}
+	}
+	return risk, nil
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"log"
+
+	apperrors "github.com/aditya/go-comet/internal/errors"
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+// RolloutService soft-launches products to a percentage of riders per region,
+// logging which riders were let in or held back so the launch can be analysed
+type RolloutService interface {
+	// ProductEnabled reports whether the rider can book the product in the region.
+	// Products without a rollout there fall back to whether they're bookable at all.
+	ProductEnabled(ctx context.Context, region *models.Region, def models.ProductDefinition, userID, source string) bool
+	// GetSummary counts exposed and converted riders for each product rolled out in the region
+	GetSummary(ctx context.Context, regionCode string) ([]*models.RolloutSummary, error)
+}
+
+type rolloutService struct {
+	rolloutRepo   repository.RolloutRepository
+	regionService RegionService
+}
+
+func NewRolloutService(rolloutRepo repository.RolloutRepository, regionService RegionService) RolloutService {
+	return &rolloutService{
+		rolloutRepo:   rolloutRepo,
+		regionService: regionService,
+	}
+}
+
+func (s *rolloutService) ProductEnabled(ctx context.Context, region *models.Region, def models.ProductDefinition, userID, source string) bool {
+	if region == nil {
+		return def.Bookable
+	}
+	percent, ok := region.Settings.ProductRollouts[def.Code]
+	if !ok {
+		return def.Bookable
+	}
+
+	enabled := models.InRollout(percent, def.Code, userID)
+	if userID != "" {
+		exposure := &models.RolloutExposure{
+			RegionCode: region.Code,
+			Product:    def.Code,
+			UserID:     userID,
+			Enabled:    enabled,
+			Percent:    percent,
+			Source:     source,
+		}
+		if err := s.rolloutRepo.RecordExposure(ctx, exposure); err != nil {
+			log.Printf("failed to record %s rollout exposure for user %s: %v", def.Code, userID, err)
+		}
+	}
+	return enabled
+}
+
+func (s *rolloutService) GetSummary(ctx context.Context, regionCode string) ([]*models.RolloutSummary, error) {
+	region, err := s.regionService.GetRegion(ctx, regionCode)
+	if err != nil {
+		return nil, err
+	}
+	if region == nil {
+		return nil, apperrors.NotFound("region")
+	}
+
+	summaries, err := s.rolloutRepo.GetSummary(ctx, region.Code)
+	if err != nil {
+		return nil, err
+	}
+	if summaries == nil {
+		summaries = []*models.RolloutSummary{}
+	}
+	for _, summary := range summaries {
+		summary.Percent = region.Settings.ProductRollouts[summary.Product]
+	}
+	return summaries, nil
+}
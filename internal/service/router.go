@@ -0,0 +1,240 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aditya/go-comet/internal/httpclient"
+)
+
+// Route is a driving route between two points
+type Route struct {
+	DistanceKm   float64
+	DurationMins int
+	// Encoded polyline of the route geometry; empty when the router has none
+	Polyline string
+}
+
+// Router plans driving routes for fare estimates, pickup ETAs and trip polylines
+type Router interface {
+	Name() string
+	Route(ctx context.Context, fromLat, fromLng, toLat, toLng float64) (*Route, error)
+}
+
+// NewRouter returns the router for a provider name ("osrm" or "google"), falling
+// back to the straight-line estimate when the provider fails. It returns nil when
+// no provider is configured; callers then keep their straight-line estimates.
+func NewRouter(provider, baseURL, apiKey string, pricingService PricingService) Router {
+	var primary Router
+	switch provider {
+	case "osrm":
+		if baseURL != "" {
+			primary = NewOSRMRouter(baseURL)
+		}
+	case "google":
+		if apiKey != "" {
+			primary = NewGoogleDirectionsRouter(baseURL, apiKey)
+		}
+	}
+	if primary == nil {
+		return nil
+	}
+	return &fallbackRouter{primary: primary, fallback: NewStraightLineRouter(pricingService)}
+}
+
+// straightLineRouter estimates from haversine distance with a road factor
+type straightLineRouter struct {
+	pricingService PricingService
+}
+
+func NewStraightLineRouter(pricingService PricingService) Router {
+	return &straightLineRouter{pricingService: pricingService}
+}
+
+func (r *straightLineRouter) Name() string {
+	return "straight_line"
+}
+
+func (r *straightLineRouter) Route(ctx context.Context, fromLat, fromLng, toLat, toLng float64) (*Route, error) {
+	distanceKm := r.pricingService.EstimateDistance(fromLat, fromLng, toLat, toLng)
+	return &Route{
+		DistanceKm:   distanceKm,
+		DurationMins: r.pricingService.EstimateDuration(distanceKm),
+	}, nil
+}
+
+// fallbackRouter uses the straight-line estimate when the routing provider fails
+type fallbackRouter struct {
+	primary  Router
+	fallback Router
+}
+
+func (r *fallbackRouter) Name() string {
+	return r.primary.Name()
+}
+
+func (r *fallbackRouter) Route(ctx context.Context, fromLat, fromLng, toLat, toLng float64) (*Route, error) {
+	route, err := r.primary.Route(ctx, fromLat, fromLng, toLat, toLng)
+	if err == nil {
+		return route, nil
+	}
+	log.Printf("%s routing failed, using %s estimate: %v", r.primary.Name(), r.fallback.Name(), err)
+	return r.fallback.Route(ctx, fromLat, fromLng, toLat, toLng)
+}
+
+// osrmRouter uses the route service of an OSRM routing server
+type osrmRouter struct {
+	baseURL string
+	client  *httpclient.Client
+}
+
+func NewOSRMRouter(baseURL string) Router {
+	return &osrmRouter{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  httpclient.New(httpclient.Config{Name: "osrm-route", Timeout: 3 * time.Second, MaxRetries: 1}),
+	}
+}
+
+func (r *osrmRouter) Name() string {
+	return "osrm"
+}
+
+func (r *osrmRouter) Route(ctx context.Context, fromLat, fromLng, toLat, toLng float64) (*Route, error) {
+	params := url.Values{}
+	params.Set("overview", "full")
+	params.Set("geometries", "polyline")
+	coords := formatCoord(fromLng) + "," + formatCoord(fromLat) + ";" + formatCoord(toLng) + "," + formatCoord(toLat)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.baseURL+"/route/v1/driving/"+coords+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("osrm route: unexpected status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Code   string `json:"code"`
+		Routes []struct {
+			Distance float64 `json:"distance"` // meters
+			Duration float64 `json:"duration"` // seconds
+			Geometry string  `json:"geometry"`
+		} `json:"routes"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	if result.Code != "Ok" || len(result.Routes) == 0 {
+		return nil, fmt.Errorf("osrm route: no route (%s)", result.Code)
+	}
+
+	route := result.Routes[0]
+	return newRoute(route.Distance, route.Duration, route.Geometry), nil
+}
+
+// googleDirectionsRouter uses the Google Directions API
+type googleDirectionsRouter struct {
+	endpoint string
+	apiKey   string
+	client   *httpclient.Client
+}
+
+const googleDirectionsURL = "https://maps.googleapis.com/maps/api/directions/json"
+
+// NewGoogleDirectionsRouter calls the Directions API at endpoint, or Google's
+// public endpoint when empty
+func NewGoogleDirectionsRouter(endpoint, apiKey string) Router {
+	if endpoint == "" {
+		endpoint = googleDirectionsURL
+	}
+	return &googleDirectionsRouter{
+		endpoint: endpoint,
+		apiKey:   apiKey,
+		client:   httpclient.New(httpclient.Config{Name: "google-directions", Timeout: 3 * time.Second, MaxRetries: 1}),
+	}
+}
+
+func (r *googleDirectionsRouter) Name() string {
+	return "google"
+}
+
+func (r *googleDirectionsRouter) Route(ctx context.Context, fromLat, fromLng, toLat, toLng float64) (*Route, error) {
+	params := url.Values{}
+	params.Set("origin", formatCoord(fromLat)+","+formatCoord(fromLng))
+	params.Set("destination", formatCoord(toLat)+","+formatCoord(toLng))
+	params.Set("mode", "driving")
+	params.Set("key", r.apiKey)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.endpoint+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("google directions: unexpected status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Status string `json:"status"`
+		Routes []struct {
+			Legs []struct {
+				Distance struct {
+					Value float64 `json:"value"` // meters
+				} `json:"distance"`
+				Duration struct {
+					Value float64 `json:"value"` // seconds
+				} `json:"duration"`
+			} `json:"legs"`
+			OverviewPolyline struct {
+				Points string `json:"points"`
+			} `json:"overview_polyline"`
+		} `json:"routes"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	if result.Status != "OK" || len(result.Routes) == 0 || len(result.Routes[0].Legs) == 0 {
+		return nil, fmt.Errorf("google directions: no route (%s)", result.Status)
+	}
+
+	route := result.Routes[0]
+	leg := route.Legs[0]
+	return newRoute(leg.Distance.Value, leg.Duration.Value, route.OverviewPolyline.Points), nil
+}
+
+func newRoute(meters, seconds float64, polyline string) *Route {
+	mins := int(math.Ceil(seconds / 60))
+	if mins < 1 {
+		mins = 1
+	}
+	return &Route{
+		DistanceKm:   round(meters / 1000),
+		DurationMins: mins,
+		Polyline:     polyline,
+	}
+}
+
+func formatCoord(v float64) string {
+	return strconv.FormatFloat(v, 'f', 6, 64)
+}
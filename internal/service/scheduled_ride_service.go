@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	apperrors "github.com/aditya/go-comet/internal/errors"
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+	"github.com/aditya/go-comet/internal/tenant"
+)
+
+// ScheduledRideService looks after rides booked ahead: listing them for the rider
+// and starting matching for each one shortly before its pickup time
+type ScheduledRideService interface {
+	ListScheduled(ctx context.Context, userID string) ([]*models.RideResponse, error)
+	// StartDue places the payment hold and starts matching for scheduled rides
+	// picking up within the lead time
+	StartDue(ctx context.Context) (int, error)
+}
+
+type scheduledRideService struct {
+	rideRepo        repository.RideRepository
+	userRepo        repository.UserRepository
+	holdService     PaymentHoldService
+	matchingService MatchingService
+	surgeService    SurgeService
+	lead            time.Duration
+}
+
+func NewScheduledRideService(
+	rideRepo repository.RideRepository,
+	userRepo repository.UserRepository,
+	holdService PaymentHoldService,
+	matchingService MatchingService,
+	surgeService SurgeService,
+	lead time.Duration,
+) ScheduledRideService {
+	return &scheduledRideService{
+		rideRepo:        rideRepo,
+		userRepo:        userRepo,
+		holdService:     holdService,
+		matchingService: matchingService,
+		surgeService:    surgeService,
+		lead:            lead,
+	}
+}
+
+func (s *scheduledRideService) ListScheduled(ctx context.Context, userID string) ([]*models.RideResponse, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, apperrors.NotFound("user")
+	}
+
+	rides, err := s.rideRepo.GetScheduledByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	responses := make([]*models.RideResponse, 0, len(rides))
+	for _, ride := range rides {
+		responses = append(responses, ride.ToResponse())
+	}
+	return responses, nil
+}
+
+func (s *scheduledRideService) StartDue(ctx context.Context) (int, error) {
+	now := time.Now()
+	rides, err := s.rideRepo.GetScheduledDue(ctx, now.Add(s.lead))
+	if err != nil {
+		return 0, err
+	}
+
+	started := 0
+	for _, ride := range rides {
+		rideCtx := tenant.WithCode(ctx, ride.TenantCode)
+
+		// Card rides are held now rather than at booking, so the hold doesn't
+		// expire before the trip
+		if _, err := s.holdService.Authorize(rideCtx, ride); err != nil {
+			log.Printf("failed to hold payment for scheduled ride %s: %v", ride.ID, err)
+			ok, err := s.rideRepo.CancelIfStatus(ctx, ride.ID, models.RideStatusScheduled, "system", "payment_declined")
+			if err != nil {
+				log.Printf("failed to cancel scheduled ride %s: %v", ride.ID, err)
+			} else if ok {
+				models.RideStates.Record(ctx, ride.ID, models.RideStatusScheduled, models.RideStatusCancelled)
+			}
+			continue
+		}
+
+		ok, err := s.rideRepo.StartScheduled(ctx, ride.ID, now)
+		if err != nil {
+			log.Printf("failed to start scheduled ride %s: %v", ride.ID, err)
+			continue
+		}
+		if !ok {
+			// The rider cancelled in the meantime
+			if err := s.holdService.Release(rideCtx, ride.ID); err != nil {
+				log.Printf("failed to release payment hold for ride %s: %v", ride.ID, err)
+			}
+			continue
+		}
+		models.RideStates.Record(ctx, ride.ID, models.RideStatusScheduled, models.RideStatusMatching)
+		ride.Status = models.RideStatusMatching
+		started++
+		s.surgeService.RecordDemand(ctx, ride)
+
+		if err := s.matchingService.FindAndOfferDrivers(ctx, ride); err != nil {
+			log.Printf("failed to match scheduled ride %s: %v", ride.ID, err)
+		}
+	}
+
+	if started > 0 {
+		log.Printf("scheduled rides: started matching for %d rides", started)
+	}
+	return started, nil
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	apperrors "github.com/aditya/go-comet/internal/errors"
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+	"github.com/aditya/go-comet/internal/storage"
+)
+
+const matchURLTTL = 5 * time.Minute
+
+type SelfieCheckService interface {
+	SubmitSelfie(ctx context.Context, driverID string, req *models.SubmitSelfieRequest) (*models.SelfieCheck, error)
+	HasValidCheck(ctx context.Context, driverID string, validity time.Duration) (bool, error)
+}
+
+type selfieCheckService struct {
+	selfieRepo repository.SelfieCheckRepository
+	uploadRepo repository.UploadRepository
+	store      storage.ObjectStore
+	matcher    FaceMatcher
+	threshold  float64
+}
+
+// NewSelfieCheckService creates the selfie check service. matcher may be nil, in
+// which case selfies are recorded for later review without automated verification.
+// With a matcher, only checks it passed let drivers online.
+func NewSelfieCheckService(
+	selfieRepo repository.SelfieCheckRepository,
+	uploadRepo repository.UploadRepository,
+	store storage.ObjectStore,
+	matcher FaceMatcher,
+	threshold float64,
+) SelfieCheckService {
+	return &selfieCheckService{
+		selfieRepo: selfieRepo,
+		uploadRepo: uploadRepo,
+		store:      store,
+		matcher:    matcher,
+		threshold:  threshold,
+	}
+}
+
+func (s *selfieCheckService) SubmitSelfie(ctx context.Context, driverID string, req *models.SubmitSelfieRequest) (*models.SelfieCheck, error) {
+	upload, err := s.uploadRepo.GetByID(ctx, req.UploadID)
+	if err != nil {
+		return nil, err
+	}
+	if upload == nil {
+		return nil, apperrors.NotFound("upload")
+	}
+	if upload.OwnerType != models.UploadOwnerDriver || upload.OwnerID != driverID {
+		return nil, apperrors.Unauthorized("upload does not belong to this driver")
+	}
+	if upload.Purpose != models.UploadPurposeDriverSelfie {
+		return nil, apperrors.BadRequest("upload is not a driver selfie")
+	}
+	if upload.Status != models.UploadStatusVerified {
+		return nil, apperrors.BadRequest("selfie upload has not been completed")
+	}
+	// A selfie counts once; resubmitting it would extend an old check
+	existing, err := s.selfieRepo.GetByUploadID(ctx, upload.ID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, apperrors.BadRequest("this selfie was already submitted; take a new one")
+	}
+
+	check := &models.SelfieCheck{
+		DriverID:   driverID,
+		UploadID:   upload.ID,
+		Status:     models.SelfieCheckRecorded,
+		CapturedAt: upload.VerifiedAt,
+	}
+
+	if s.matching() {
+		reference, err := s.referencePhoto(ctx, driverID)
+		if err != nil {
+			return nil, err
+		}
+		if reference == nil {
+			return nil, apperrors.ReferencePhotoRequired()
+		}
+
+		selfieURL, err := s.store.PresignGet(ctx, upload.ObjectKey, matchURLTTL)
+		if err != nil {
+			return nil, err
+		}
+		referenceURL, err := s.store.PresignGet(ctx, reference.ObjectKey, matchURLTTL)
+		if err != nil {
+			return nil, err
+		}
+
+		score, err := s.matcher.Match(ctx, selfieURL.URL, referenceURL.URL)
+		if err != nil {
+			return nil, err
+		}
+
+		provider := s.matcher.Name()
+		check.Provider = &provider
+		check.MatchScore = &score
+		check.Status = models.SelfieCheckFailed
+		if score >= s.threshold {
+			check.Status = models.SelfieCheckPassed
+		}
+	}
+
+	created, err := s.selfieRepo.Create(ctx, check)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		return nil, apperrors.BadRequest("this selfie was already submitted; take a new one")
+	}
+
+	return check, nil
+}
+
+// matching reports whether selfies are matched against the reference photo
+func (s *selfieCheckService) matching() bool {
+	return s.matcher != nil && s.store != nil
+}
+
+func (s *selfieCheckService) HasValidCheck(ctx context.Context, driverID string, validity time.Duration) (bool, error) {
+	check, err := s.selfieRepo.GetLatestByDriverID(ctx, driverID)
+	if err != nil {
+		return false, err
+	}
+	if check == nil {
+		return false, nil
+	}
+	return check.IsValidAt(time.Now(), validity, s.matching()), nil
+}
+
+// referencePhoto returns the driver's most recent verified profile photo
+func (s *selfieCheckService) referencePhoto(ctx context.Context, driverID string) (*models.Upload, error) {
+	uploads, err := s.uploadRepo.GetByOwner(ctx, models.UploadOwnerDriver, driverID)
+	if err != nil {
+		return nil, err
+	}
+	for _, upload := range uploads {
+		if upload.Purpose == models.UploadPurposeProfilePhoto && upload.Status == models.UploadStatusVerified {
+			return upload, nil
+		}
+	}
+	return nil, nil
+}
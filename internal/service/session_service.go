@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"log"
+	"time"
+
+	apperrors "github.com/aditya/go-comet/internal/errors"
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+// sessionTouchInterval limits how often a session's last_seen_at is written
+const sessionTouchInterval = 5 * time.Minute
+
+// SessionService signs devices in to user and driver accounts and lets the
+// account holder see and revoke them
+type SessionService interface {
+	// CreateSession signs a device in. Signing in again on the same device replaces
+	// its old session, and a driver signing in anywhere signs out every other device.
+	CreateSession(ctx context.Context, principalType, principalID string, req *models.CreateSessionRequest) (*models.CreatedSession, error)
+	ListSessions(ctx context.Context, principalType, principalID string) ([]*models.SessionResponse, error)
+	RevokeSession(ctx context.Context, principalType, principalID, sessionID string) error
+	// RevokeOtherSessions signs out every device except keepID (all when empty)
+	RevokeOtherSessions(ctx context.Context, principalType, principalID, keepID string) (int64, error)
+	// ChangePhone updates the account's phone number, which is its sign-in
+	// identity, and signs out every device
+	ChangePhone(ctx context.Context, principalType, principalID, phone string) error
+	// Authenticate returns the active session for a bearer token, or nil
+	Authenticate(ctx context.Context, token string) (*models.Session, error)
+}
+
+type sessionService struct {
+	sessionRepo repository.SessionRepository
+	userRepo    repository.UserRepository
+	driverRepo  repository.DriverRepository
+}
+
+func NewSessionService(sessionRepo repository.SessionRepository, userRepo repository.UserRepository, driverRepo repository.DriverRepository) SessionService {
+	return &sessionService{
+		sessionRepo: sessionRepo,
+		userRepo:    userRepo,
+		driverRepo:  driverRepo,
+	}
+}
+
+func (s *sessionService) CreateSession(ctx context.Context, principalType, principalID string, req *models.CreateSessionRequest) (*models.CreatedSession, error) {
+	if err := s.checkAccount(ctx, principalType, principalID); err != nil {
+		return nil, err
+	}
+
+	token, err := newSessionToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := s.sessionRepo.RevokeDevice(ctx, principalType, principalID, req.DeviceID, models.SessionRevokedReplaced, now); err != nil {
+		return nil, err
+	}
+
+	session := &models.Session{
+		PrincipalType: principalType,
+		PrincipalID:   principalID,
+		TokenHash:     hashSessionToken(token),
+		DeviceID:      req.DeviceID,
+	}
+	if req.DeviceName != "" {
+		session.DeviceName = &req.DeviceName
+	}
+	if req.Platform != "" {
+		session.Platform = &req.Platform
+	}
+	if req.AppVersion != "" {
+		session.AppVersion = &req.AppVersion
+	}
+	if err := s.sessionRepo.Create(ctx, session); err != nil {
+		return nil, err
+	}
+
+	// Drivers work from a single device so offers and location come from one place
+	if principalType == models.SessionPrincipalDriver {
+		if _, err := s.sessionRepo.RevokeOthers(ctx, principalType, principalID, session.ID, models.SessionRevokedReplaced, now); err != nil {
+			log.Printf("failed to sign out other devices of driver %s: %v", principalID, err)
+		}
+	}
+
+	return &models.CreatedSession{Session: session.ToResponse(), Token: token}, nil
+}
+
+func (s *sessionService) ListSessions(ctx context.Context, principalType, principalID string) ([]*models.SessionResponse, error) {
+	sessions, err := s.sessionRepo.ListActive(ctx, principalType, principalID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*models.SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		responses = append(responses, session.ToResponse())
+	}
+	return responses, nil
+}
+
+func (s *sessionService) RevokeSession(ctx context.Context, principalType, principalID, sessionID string) error {
+	revoked, err := s.sessionRepo.Revoke(ctx, principalType, principalID, sessionID, models.SessionRevokedSignOut, time.Now())
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return apperrors.NotFound("session")
+	}
+	return nil
+}
+
+func (s *sessionService) RevokeOtherSessions(ctx context.Context, principalType, principalID, keepID string) (int64, error) {
+	return s.sessionRepo.RevokeOthers(ctx, principalType, principalID, keepID, models.SessionRevokedSignOut, time.Now())
+}
+
+func (s *sessionService) ChangePhone(ctx context.Context, principalType, principalID, phone string) error {
+	if err := s.checkAccount(ctx, principalType, principalID); err != nil {
+		return err
+	}
+
+	switch principalType {
+	case models.SessionPrincipalDriver:
+		existing, err := s.driverRepo.GetByPhone(ctx, phone)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			if existing.ID == principalID {
+				return nil
+			}
+			return apperrors.Conflict("driver with this phone already exists")
+		}
+		if err := s.driverRepo.UpdatePhone(ctx, principalID, phone); err != nil {
+			return err
+		}
+	default:
+		existing, err := s.userRepo.GetByPhone(ctx, phone)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			if existing.ID == principalID {
+				return nil
+			}
+			return apperrors.Conflict("user with this phone already exists")
+		}
+		if err := s.userRepo.UpdatePhone(ctx, principalID, phone); err != nil {
+			return err
+		}
+	}
+
+	_, err := s.sessionRepo.RevokeOthers(ctx, principalType, principalID, "", models.SessionRevokedPhoneChanged, time.Now())
+	return err
+}
+
+func (s *sessionService) Authenticate(ctx context.Context, token string) (*models.Session, error) {
+	session, err := s.sessionRepo.GetByTokenHash(ctx, hashSessionToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if session == nil || !session.IsActive() {
+		return nil, nil
+	}
+
+	if now := time.Now(); now.Sub(session.LastSeenAt) > sessionTouchInterval {
+		if err := s.sessionRepo.Touch(ctx, session.ID, now); err != nil {
+			log.Printf("failed to touch session %s: %v", session.ID, err)
+		}
+		session.LastSeenAt = now
+	}
+	return session, nil
+}
+
+func (s *sessionService) checkAccount(ctx context.Context, principalType, principalID string) error {
+	switch principalType {
+	case models.SessionPrincipalDriver:
+		driver, err := s.driverRepo.GetByID(ctx, principalID)
+		if err != nil {
+			return err
+		}
+		if driver == nil {
+			return apperrors.NotFound("driver")
+		}
+	default:
+		user, err := s.userRepo.GetByID(ctx, principalID)
+		if err != nil {
+			return err
+		}
+		if user == nil {
+			return apperrors.NotFound("user")
+		}
+	}
+	return nil
+}
+
+func newSessionToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashSessionToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
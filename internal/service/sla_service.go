@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/aditya/go-comet/internal/alerting"
+	"github.com/aditya/go-comet/internal/metrics"
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+// SLAService tracks how quickly rides are matched and picked up against per-region
+// targets over a rolling window
+type SLAService interface {
+	Report(ctx context.Context) (*models.SLAReport, error)
+	// Check publishes compliance as metrics and alerts when a metric starts breaching
+	Check(ctx context.Context) (int, error)
+}
+
+type slaService struct {
+	offerRepo     repository.RideOfferRepository
+	regionService RegionService
+	notifier      alerting.Notifier
+	policy        models.SLAPolicy
+
+	// Labels currently in breach, so an alert fires once per breach rather than every check
+	mu       sync.Mutex
+	breached map[string]bool
+}
+
+// NewSLAService creates the SLA monitor. notifier may be nil, in which case
+// breaches are only logged.
+func NewSLAService(
+	offerRepo repository.RideOfferRepository,
+	regionService RegionService,
+	notifier alerting.Notifier,
+	policy models.SLAPolicy,
+) SLAService {
+	return &slaService{
+		offerRepo:     offerRepo,
+		regionService: regionService,
+		notifier:      notifier,
+		policy:        policy,
+		breached:      make(map[string]bool),
+	}
+}
+
+func (s *slaService) Report(ctx context.Context) (*models.SLAReport, error) {
+	regions, err := s.regionService.ListRegions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	targets := make(map[string]models.SLOTargets, len(regions))
+	for _, r := range regions {
+		targets[r.Code] = r.Settings.SLO.Or(s.policy.Defaults)
+	}
+
+	now := time.Now()
+	from := now.Add(-s.policy.Window)
+	stats, err := s.offerRepo.GetSLAStats(ctx, from, targets, s.policy.Defaults)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.SLAReport{From: from, To: now, Regions: []*models.RegionSLA{}}
+	for _, st := range stats {
+		t := s.policy.Defaults
+		if st.RegionCode != nil {
+			if rt, ok := targets[*st.RegionCode]; ok {
+				t = rt
+			}
+		}
+		report.Regions = append(report.Regions, &models.RegionSLA{
+			RegionCode: st.RegionCode,
+			Metrics: []*models.SLACompliance{
+				s.compliance(models.SLAMetricTimeToMatch, t.MatchSeconds, t.CompliancePercent,
+					st.Matches, st.MatchesWithin, st.MatchP90Secs),
+				s.compliance(models.SLAMetricOfferAcceptance, t.OfferAcceptanceSeconds, t.CompliancePercent,
+					st.Acceptances, st.AcceptancesWithin, st.AcceptanceP90Secs),
+				s.compliance(models.SLAMetricTimeToPickup, t.PickupSeconds, t.CompliancePercent,
+					st.Pickups, st.PickupsWithin, st.PickupP90Secs),
+			},
+		})
+	}
+	return report, nil
+}
+
+func (s *slaService) compliance(metric string, targetSecs int, targetPct float64, events, within int, p90 float64) *models.SLACompliance {
+	c := &models.SLACompliance{
+		Metric:                  metric,
+		TargetSeconds:           targetSecs,
+		Events:                  events,
+		WithinTarget:            within,
+		CompliancePercent:       100,
+		TargetCompliancePercent: targetPct,
+		P90Seconds:              round(p90),
+	}
+	if events > 0 {
+		c.CompliancePercent = round(float64(within) / float64(events) * 100)
+	}
+	c.Breached = events >= s.policy.MinEvents && c.CompliancePercent < targetPct
+	return c
+}
+
+func (s *slaService) Check(ctx context.Context) (int, error) {
+	report, err := s.Report(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	breaches := 0
+	for _, region := range report.Regions {
+		for _, c := range region.Metrics {
+			label := region.Label(c.Metric)
+			metrics.SetGauge("sla_compliance_percent", label, c.CompliancePercent)
+			metrics.SetGauge("sla_p90_seconds", label, c.P90Seconds)
+			if c.Breached {
+				breaches++
+			}
+			if s.transition(label, c.Breached) && c.Breached {
+				s.alert(ctx, region, c)
+			}
+		}
+	}
+	return breaches, nil
+}
+
+// transition records whether label is breaching and reports whether that changed
+func (s *slaService) transition(label string, breached bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.breached[label] == breached {
+		return false
+	}
+	if breached {
+		s.breached[label] = true
+	} else {
+		delete(s.breached, label)
+	}
+	return true
+}
+
+func (s *slaService) alert(ctx context.Context, region *models.RegionSLA, c *models.SLACompliance) {
+	label := region.Label(c.Metric)
+	metrics.CounterMap("sla_breaches").Add(label, 1)
+	summary := fmt.Sprintf("%s: %.1f%% of %d rides within %ds, target %.1f%%",
+		label, c.CompliancePercent, c.Events, c.TargetSeconds, c.TargetCompliancePercent)
+	log.Printf("ALERT SLA breach: %s", summary)
+
+	if s.notifier == nil {
+		return
+	}
+	err := s.notifier.Notify(ctx, &alerting.Alert{
+		Name:    "sla_breach",
+		Summary: summary,
+		Labels:  map[string]string{"region": region.Region(), "metric": c.Metric},
+	})
+	if err != nil {
+		log.Printf("failed to send SLA alert for %s: %v", label, err)
+	}
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/aditya/go-comet/internal/cache"
+	"github.com/aditya/go-comet/internal/models"
+)
+
+// SurgeService prices surge from live demand: ride requests are counted per
+// geohash cell over a sliding window and compared with the drivers nearby
+type SurgeService interface {
+	// RecordDemand counts a ride that has started looking for a driver
+	RecordDemand(ctx context.Context, ride *models.Ride)
+	// Multiplier is the surge for a pickup given the supply the caller counted
+	// within surgeRadiusKm of it
+	Multiplier(ctx context.Context, lat, lng float64, vehicleType string, supply int) float64
+	// GetSurge reports demand, supply and surge for every vehicle type at a point
+	GetSurge(ctx context.Context, lat, lng float64) (*models.SurgeInfo, error)
+}
+
+type surgeService struct {
+	driverCache    cache.DriverLocationCache
+	pricingService PricingService
+	vehicleTypes   VehicleTypeService
+	window         time.Duration
+	precision      int
+}
+
+func NewSurgeService(driverCache cache.DriverLocationCache, pricingService PricingService, vehicleTypes VehicleTypeService, window time.Duration, precision int) SurgeService {
+	return &surgeService{
+		driverCache:    driverCache,
+		pricingService: pricingService,
+		vehicleTypes:   vehicleTypes,
+		window:         window,
+		precision:      precision,
+	}
+}
+
+func (s *surgeService) RecordDemand(ctx context.Context, ride *models.Ride) {
+	if s.driverCache == nil {
+		return
+	}
+	cell := models.Geohash(ride.PickupLat, ride.PickupLng, s.precision)
+	if err := s.driverCache.RecordDemand(ctx, ride.VehicleType, cell, ride.ID, time.Now(), s.window); err != nil {
+		log.Printf("failed to record demand for ride %s: %v", ride.ID, err)
+	}
+}
+
+func (s *surgeService) Multiplier(ctx context.Context, lat, lng float64, vehicleType string, supply int) float64 {
+	demand, err := s.demand(ctx, lat, lng, vehicleType)
+	if err != nil {
+		// Without demand figures fall back to pricing on supply alone
+		log.Printf("failed to count %s demand: %v", vehicleType, err)
+		return surgeForSupply(s.pricingService, supply)
+	}
+	return s.surge(demand, supply)
+}
+
+func (s *surgeService) GetSurge(ctx context.Context, lat, lng float64) (*models.SurgeInfo, error) {
+	info := &models.SurgeInfo{
+		Lat:          lat,
+		Lng:          lng,
+		Zone:         models.Geohash(lat, lng, s.precision),
+		DemandWindow: int(s.window.Seconds()),
+		SupplyRadius: surgeRadiusKm,
+		VehicleTypes: []*models.SurgeZone{},
+		CalculatedAt: time.Now(),
+	}
+	if s.driverCache == nil {
+		return info, nil
+	}
+
+	vehicleTypes, err := s.vehicleTypes.Active(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, vt := range vehicleTypes {
+		vehicleType := vt.Code
+		demand, err := s.demand(ctx, lat, lng, vehicleType)
+		if err != nil {
+			return nil, err
+		}
+		nearby, err := s.driverCache.GetNearbyDrivers(ctx, lat, lng, surgeRadiusKm, vehicleType)
+		if err != nil {
+			return nil, err
+		}
+		info.VehicleTypes = append(info.VehicleTypes, &models.SurgeZone{
+			VehicleType: vehicleType,
+			Demand:      demand,
+			Supply:      len(nearby),
+			Multiplier:  s.surge(demand, len(nearby)),
+		})
+	}
+	return info, nil
+}
+
+func (s *surgeService) demand(ctx context.Context, lat, lng float64, vehicleType string) (int, error) {
+	if s.driverCache == nil {
+		return 0, nil
+	}
+	cells := models.GeohashZone(lat, lng, s.precision)
+	return s.driverCache.CountDemand(ctx, vehicleType, cells, time.Now().Add(-s.window))
+}
+
+// surge compares demand with supply; no one asking for rides means no surge
+// however few drivers are about
+func (s *surgeService) surge(demand, supply int) float64 {
+	if demand == 0 {
+		return 1.0
+	}
+	return s.pricingService.CalculateSurge(demand, supply)
+}
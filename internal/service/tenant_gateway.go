@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"sync"
+
+	"github.com/aditya/go-comet/internal/psp"
+	"github.com/aditya/go-comet/internal/tenant"
+)
+
+// tenantGateway sends each call to the PSP account of the tenant it's made for,
+// falling back to the platform account for tenants without their own
+type tenantGateway struct {
+	tenantService TenantService
+	fallback      psp.Gateway
+
+	mu       sync.Mutex
+	gateways map[string]*tenantPSP
+}
+
+type tenantPSP struct {
+	url, apiKey string
+	gateway     psp.Gateway
+}
+
+// NewTenantGateway wraps the platform gateway so tenants with PSP credentials
+// are charged through their own account
+func NewTenantGateway(tenantService TenantService, fallback psp.Gateway) psp.Gateway {
+	return &tenantGateway{
+		tenantService: tenantService,
+		fallback:      fallback,
+		gateways:      make(map[string]*tenantPSP),
+	}
+}
+
+func (g *tenantGateway) Authorize(ctx context.Context, req *psp.AuthorizeRequest) (*psp.Authorization, error) {
+	gw, err := g.gateway(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return gw.Authorize(ctx, req)
+}
+
+func (g *tenantGateway) Capture(ctx context.Context, authorizationID string, amount float64) (*psp.Capture, error) {
+	gw, err := g.gateway(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return gw.Capture(ctx, authorizationID, amount)
+}
+
+func (g *tenantGateway) Void(ctx context.Context, authorizationID string) error {
+	gw, err := g.gateway(ctx)
+	if err != nil {
+		return err
+	}
+	return gw.Void(ctx, authorizationID)
+}
+
+func (g *tenantGateway) gateway(ctx context.Context) (psp.Gateway, error) {
+	code := tenant.Code(ctx)
+	if code == "" {
+		return g.fallback, nil
+	}
+	t, err := g.tenantService.GetTenant(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil || t.PSPURL == nil || t.PSPAPIKey == nil {
+		return g.fallback, nil
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	// Rebuild when the tenant's credentials change
+	cached, ok := g.gateways[code]
+	if !ok || cached.url != *t.PSPURL || cached.apiKey != *t.PSPAPIKey {
+		cached = &tenantPSP{url: *t.PSPURL, apiKey: *t.PSPAPIKey, gateway: psp.NewHTTPGateway(*t.PSPURL, *t.PSPAPIKey)}
+		g.gateways[code] = cached
+	}
+	return cached.gateway, nil
+}
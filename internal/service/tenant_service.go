@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	apperrors "github.com/aditya/go-comet/internal/errors"
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+const tenantCacheTTL = time.Minute
+
+// TenantService manages white-label brands and resolves requests to them
+type TenantService interface {
+	// ResolveAPIKey returns the active tenant issued the key, or nil
+	ResolveAPIKey(ctx context.Context, apiKey string) (*models.Tenant, error)
+	// ResolveHost returns the active tenant serving the host, or nil
+	ResolveHost(ctx context.Context, host string) (*models.Tenant, error)
+	// GetTenant returns the active tenant with the code, or nil
+	GetTenant(ctx context.Context, code string) (*models.Tenant, error)
+	// ListTenants returns every tenant including inactive ones, bypassing the cache
+	ListTenants(ctx context.Context) ([]*models.Tenant, error)
+	CreateTenant(ctx context.Context, req *models.CreateTenantRequest) (*models.TenantCredentials, error)
+	UpdateTenant(ctx context.Context, code string, req *models.UpdateTenantRequest) (*models.Tenant, error)
+	// RotateAPIKey issues a new key, revoking the old one
+	RotateAPIKey(ctx context.Context, code string) (*models.TenantCredentials, error)
+}
+
+type tenantService struct {
+	tenantRepo repository.TenantRepository
+
+	mu       sync.RWMutex
+	tenants  []*models.Tenant
+	loadedAt time.Time
+}
+
+func NewTenantService(tenantRepo repository.TenantRepository) TenantService {
+	return &tenantService{
+		tenantRepo: tenantRepo,
+	}
+}
+
+func (s *tenantService) ResolveAPIKey(ctx context.Context, apiKey string) (*models.Tenant, error) {
+	tenants, err := s.active(ctx)
+	if err != nil {
+		return nil, err
+	}
+	hash := hashAPIKey(apiKey)
+	for _, t := range tenants {
+		if t.APIKeyHash != nil && *t.APIKeyHash == hash {
+			return t, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *tenantService) ResolveHost(ctx context.Context, host string) (*models.Tenant, error) {
+	tenants, err := s.active(ctx)
+	if err != nil {
+		return nil, err
+	}
+	host = strings.ToLower(host)
+	for _, t := range tenants {
+		if t.ServesHost(host) {
+			return t, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *tenantService) GetTenant(ctx context.Context, code string) (*models.Tenant, error) {
+	tenants, err := s.active(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tenants {
+		if t.Code == code {
+			return t, nil
+		}
+	}
+	return nil, nil
+}
+
+// active returns active tenants, served from memory and refreshed every tenantCacheTTL
+func (s *tenantService) active(ctx context.Context) ([]*models.Tenant, error) {
+	s.mu.RLock()
+	if time.Since(s.loadedAt) < tenantCacheTTL {
+		tenants := s.tenants
+		s.mu.RUnlock()
+		return tenants, nil
+	}
+	s.mu.RUnlock()
+
+	all, err := s.tenantRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tenants := make([]*models.Tenant, 0, len(all))
+	for _, t := range all {
+		if t.Active {
+			tenants = append(tenants, t)
+		}
+	}
+
+	s.mu.Lock()
+	s.tenants = tenants
+	s.loadedAt = time.Now()
+	s.mu.Unlock()
+
+	return tenants, nil
+}
+
+func (s *tenantService) ListTenants(ctx context.Context) ([]*models.Tenant, error) {
+	return s.tenantRepo.GetAll(ctx)
+}
+
+func (s *tenantService) CreateTenant(ctx context.Context, req *models.CreateTenantRequest) (*models.TenantCredentials, error) {
+	existing, err := s.tenantRepo.GetByCode(ctx, req.Code)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, apperrors.Conflict("tenant already exists")
+	}
+	hosts := normalizeHosts(req.Hosts)
+	if err := s.checkHostsFree(ctx, req.Code, hosts); err != nil {
+		return nil, err
+	}
+
+	apiKey, err := newAPIKey()
+	if err != nil {
+		return nil, err
+	}
+	hash := hashAPIKey(apiKey)
+	t := &models.Tenant{
+		Code:        req.Code,
+		Name:        req.Name,
+		APIKeyHash:  &hash,
+		Hosts:       hosts,
+		Branding:    req.Branding,
+		FareConfigs: req.FareConfigs,
+	}
+	if req.PSPURL != "" {
+		t.PSPURL = &req.PSPURL
+		t.PSPAPIKey = &req.PSPAPIKey
+	}
+
+	if err := s.tenantRepo.Create(ctx, t); err != nil {
+		return nil, err
+	}
+	s.invalidate()
+	return &models.TenantCredentials{Tenant: t, APIKey: apiKey}, nil
+}
+
+func (s *tenantService) UpdateTenant(ctx context.Context, code string, req *models.UpdateTenantRequest) (*models.Tenant, error) {
+	t, err := s.tenantRepo.GetByCode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, apperrors.NotFound("tenant")
+	}
+	if code == models.DefaultTenant && !req.Active {
+		return nil, apperrors.BadRequest("the default tenant can't be deactivated")
+	}
+	hosts := normalizeHosts(req.Hosts)
+	if err := s.checkHostsFree(ctx, code, hosts); err != nil {
+		return nil, err
+	}
+
+	t.Name = req.Name
+	t.Hosts = hosts
+	t.Branding = req.Branding
+	t.FareConfigs = req.FareConfigs
+	t.PSPURL, t.PSPAPIKey = nil, nil
+	if req.PSPURL != "" {
+		t.PSPURL = &req.PSPURL
+		t.PSPAPIKey = &req.PSPAPIKey
+	}
+	t.Active = req.Active
+
+	if err := s.tenantRepo.Update(ctx, t); err != nil {
+		return nil, err
+	}
+	s.invalidate()
+	return t, nil
+}
+
+func (s *tenantService) RotateAPIKey(ctx context.Context, code string) (*models.TenantCredentials, error) {
+	t, err := s.tenantRepo.GetByCode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, apperrors.NotFound("tenant")
+	}
+
+	apiKey, err := newAPIKey()
+	if err != nil {
+		return nil, err
+	}
+	hash := hashAPIKey(apiKey)
+	if err := s.tenantRepo.SetAPIKeyHash(ctx, code, hash); err != nil {
+		return nil, err
+	}
+	t.APIKeyHash = &hash
+
+	s.invalidate()
+	return &models.TenantCredentials{Tenant: t, APIKey: apiKey}, nil
+}
+
+// checkHostsFree rejects hosts already served by another tenant
+func (s *tenantService) checkHostsFree(ctx context.Context, code string, hosts []string) error {
+	tenants, err := s.tenantRepo.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+	for _, t := range tenants {
+		if t.Code == code {
+			continue
+		}
+		for _, h := range hosts {
+			if t.ServesHost(h) {
+				return apperrors.Conflict(fmt.Sprintf("host %s is already served by tenant %s", h, t.Code))
+			}
+		}
+	}
+	return nil
+}
+
+// invalidate forces a reload so admin changes apply on this instance immediately
+func (s *tenantService) invalidate() {
+	s.mu.Lock()
+	s.loadedAt = time.Time{}
+	s.mu.Unlock()
+}
+
+func normalizeHosts(hosts []string) []string {
+	normalized := make([]string, 0, len(hosts))
+	for _, h := range hosts {
+		normalized = append(normalized, strings.ToLower(h))
+	}
+	return normalized
+}
+
+func newAPIKey() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func hashAPIKey(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:])
+}
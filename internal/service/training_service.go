@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+
+	apperrors "github.com/aditya/go-comet/internal/errors"
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+// TrainingService records the training modules drivers complete; newer products
+// and some incentives are only offered to drivers who have finished theirs
+type TrainingService interface {
+	RecordTraining(ctx context.Context, driverID, module string, req *models.RecordTrainingRequest) (*models.DriverTrainingStatus, error)
+	GetDriverTraining(ctx context.Context, driverID string) (*models.DriverTrainingStatus, error)
+}
+
+type trainingService struct {
+	trainingRepo repository.TrainingRepository
+	driverRepo   repository.DriverRepository
+}
+
+func NewTrainingService(
+	trainingRepo repository.TrainingRepository,
+	driverRepo repository.DriverRepository,
+) TrainingService {
+	return &trainingService{
+		trainingRepo: trainingRepo,
+		driverRepo:   driverRepo,
+	}
+}
+
+func (s *trainingService) RecordTraining(ctx context.Context, driverID, module string, req *models.RecordTrainingRequest) (*models.DriverTrainingStatus, error) {
+	if !models.IsTrainingModule(module) {
+		return nil, apperrors.NotFound("training module")
+	}
+	driver, err := s.driverRepo.GetByID(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+	if driver == nil {
+		return nil, apperrors.NotFound("driver")
+	}
+
+	if req.Completed {
+		err = s.trainingRepo.Complete(ctx, &models.DriverTraining{
+			DriverID: driverID,
+			Module:   module,
+			Score:    req.Score,
+		})
+	} else {
+		// A module can be withdrawn, e.g. when its content changes and must be retaken
+		err = s.trainingRepo.Revoke(ctx, driverID, module)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.status(ctx, driverID)
+}
+
+func (s *trainingService) GetDriverTraining(ctx context.Context, driverID string) (*models.DriverTrainingStatus, error) {
+	driver, err := s.driverRepo.GetByID(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+	if driver == nil {
+		return nil, apperrors.NotFound("driver")
+	}
+	return s.status(ctx, driverID)
+}
+
+func (s *trainingService) status(ctx context.Context, driverID string) (*models.DriverTrainingStatus, error) {
+	trainings, err := s.trainingRepo.GetByDriverID(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &models.DriverTrainingStatus{
+		DriverID:  driverID,
+		Completed: []*models.DriverTraining{},
+		Pending:   []string{},
+	}
+	done := make(map[string]bool)
+	for _, t := range trainings {
+		status.Completed = append(status.Completed, t)
+		done[t.Module] = true
+	}
+	for _, module := range models.TrainingModules {
+		if !done[module] {
+			status.Pending = append(status.Pending, module)
+		}
+	}
+	return status, nil
+}
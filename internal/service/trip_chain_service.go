@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/aditya/go-comet/internal/cache"
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+	"github.com/jmoiron/sqlx"
+)
+
+// TripChainService hands a driver their queued next ride once the current one is over
+type TripChainService interface {
+	ActivateQueuedRide(ctx context.Context, driverID string) (*models.Ride, error)
+	ReleaseQueuedRide(ctx context.Context, rideID string) error
+}
+
+type tripChainService struct {
+	transactor  repository.Transactor
+	rideRepo    repository.RideRepository
+	offerRepo   repository.RideOfferRepository
+	driverCache cache.DriverLocationCache
+}
+
+func NewTripChainService(
+	transactor repository.Transactor,
+	rideRepo repository.RideRepository,
+	offerRepo repository.RideOfferRepository,
+	driverCache cache.DriverLocationCache,
+) TripChainService {
+	return &tripChainService{
+		transactor:  transactor,
+		rideRepo:    rideRepo,
+		offerRepo:   offerRepo,
+		driverCache: driverCache,
+	}
+}
+
+// ActivateQueuedRide promotes the driver's queued ride to driver_assigned. It returns
+// nil when nothing was queued or the queued ride was cancelled in the meantime, in
+// which case the caller should make the driver available again.
+func (s *tripChainService) ActivateQueuedRide(ctx context.Context, driverID string) (*models.Ride, error) {
+	offer, err := s.offerRepo.GetQueuedByDriverID(ctx, driverID)
+	if err != nil || offer == nil {
+		return nil, err
+	}
+
+	var ride *models.Ride
+	activated := false
+	now := time.Now()
+	err = s.transactor.InTx(ctx, func(tx *sqlx.Tx) error {
+		var err error
+		ride, err = s.rideRepo.GetByIDForUpdate(ctx, tx, offer.RideID)
+		if err != nil {
+			return err
+		}
+
+		if ride == nil || ride.Status != models.RideStatusQueued || ride.DriverID == nil || *ride.DriverID != driverID {
+			return s.offerRepo.SetStatus(ctx, tx, offer.ID, models.OfferStatusExpired, now)
+		}
+
+		if err := s.offerRepo.SetStatus(ctx, tx, offer.ID, models.OfferStatusAccepted, now); err != nil {
+			return err
+		}
+		activated = true
+		return s.rideRepo.SetStatus(ctx, tx, ride.ID, models.RideStatusDriverAssigned, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !activated {
+		models.OfferStates.Record(ctx, offer.ID, offer.Status, models.OfferStatusExpired)
+		return nil, nil
+	}
+	models.OfferStates.Record(ctx, offer.ID, offer.Status, models.OfferStatusAccepted)
+	models.RideStates.Record(ctx, ride.ID, ride.Status, models.RideStatusDriverAssigned)
+
+	if s.driverCache != nil {
+		s.driverCache.SetActiveRide(ctx, driverID, ride.ID)
+	}
+
+	ride.Status = models.RideStatusDriverAssigned
+	return ride, nil
+}
+
+// ReleaseQueuedRide frees the driver's queue slot when a queued ride is cancelled
+func (s *tripChainService) ReleaseQueuedRide(ctx context.Context, rideID string) error {
+	return s.offerRepo.ExpireOldOffers(ctx, rideID)
+}
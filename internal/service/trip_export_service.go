@@ -0,0 +1,266 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	apperrors "github.com/aditya/go-comet/internal/errors"
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+	"github.com/aditya/go-comet/internal/storage"
+)
+
+const (
+	// tripExportBatch is how many exports one worker run generates
+	tripExportBatch = 5
+	// tripExportStaleAfter is how long an export may stay processing before it is
+	// assumed abandoned (e.g. the instance restarted) and generated again
+	tripExportStaleAfter = 15 * time.Minute
+	// tripExportFreshFor is how long an export of a year still in progress is
+	// reused before a new one is generated with the latest trips
+	tripExportFreshFor = time.Hour
+)
+
+var tripExportHeader = []string{
+	"trip_id", "ride_id", "started_at", "ended_at", "vehicle_type", "pickup_address", "dropoff_address",
+	"distance_km", "duration_mins", "fare", "payment_method", "paid_at",
+	"commission_percent", "commission", "net_earnings",
+}
+
+// TripExportService builds yearly CSVs of a driver's trips in the background
+type TripExportService interface {
+	// RequestExport returns the driver's export for the year, with a download link
+	// once ready, queueing a new one when there is none that is current
+	RequestExport(ctx context.Context, driverID string, year int) (*models.TripExport, error)
+	// ProcessPending generates queued exports and returns how many succeeded
+	ProcessPending(ctx context.Context) (int, error)
+}
+
+type tripExportService struct {
+	exportRepo        repository.TripExportRepository
+	driverRepo        repository.DriverRepository
+	commissionService CommissionService
+	store             storage.ObjectStore
+	urlTTL            time.Duration
+}
+
+// NewTripExportService creates the export service. store may be nil when object
+// storage is not configured, in which case exports are unavailable.
+func NewTripExportService(
+	exportRepo repository.TripExportRepository,
+	driverRepo repository.DriverRepository,
+	commissionService CommissionService,
+	store storage.ObjectStore,
+	urlTTL time.Duration,
+) TripExportService {
+	return &tripExportService{
+		exportRepo:        exportRepo,
+		driverRepo:        driverRepo,
+		commissionService: commissionService,
+		store:             store,
+		urlTTL:            urlTTL,
+	}
+}
+
+func (s *tripExportService) RequestExport(ctx context.Context, driverID string, year int) (*models.TripExport, error) {
+	if s.store == nil {
+		return nil, apperrors.ServiceUnavailable("exports_unavailable", "trip exports are not available")
+	}
+	now := time.Now()
+	if year < 2000 || year > now.Year() {
+		return nil, apperrors.BadRequest(fmt.Sprintf("year must be between 2000 and %d", now.Year()))
+	}
+
+	driver, err := s.driverRepo.GetByID(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+	if driver == nil {
+		return nil, apperrors.NotFound("driver")
+	}
+
+	latest, err := s.exportRepo.GetLatest(ctx, driverID, year)
+	if err != nil {
+		return nil, err
+	}
+	if latest != nil && s.current(latest, now) {
+		if latest.Status == models.TripExportStatusReady {
+			if err := s.attachDownload(ctx, latest); err != nil {
+				return nil, err
+			}
+		}
+		return latest, nil
+	}
+
+	export := &models.TripExport{DriverID: driverID, Year: year}
+	if err := s.exportRepo.Create(ctx, export); err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+// current reports whether an export can be served instead of generating a new one
+func (s *tripExportService) current(export *models.TripExport, now time.Time) bool {
+	switch export.Status {
+	case models.TripExportStatusPending, models.TripExportStatusProcessing:
+		return true
+	case models.TripExportStatusReady:
+		// A finished year doesn't change; the current one gains trips
+		yearEnd := time.Date(export.Year+1, 1, 1, 0, 0, 0, 0, time.Local)
+		return !export.CreatedAt.Before(yearEnd) || now.Sub(export.CreatedAt) < tripExportFreshFor
+	default:
+		return false
+	}
+}
+
+func (s *tripExportService) attachDownload(ctx context.Context, export *models.TripExport) error {
+	presigned, err := s.store.PresignGet(ctx, *export.ObjectKey, s.urlTTL)
+	if err != nil {
+		return err
+	}
+	export.DownloadURL = &presigned.URL
+	export.DownloadExpiresAt = &presigned.ExpiresAt
+	return nil
+}
+
+func (s *tripExportService) ProcessPending(ctx context.Context) (int, error) {
+	if s.store == nil {
+		return 0, nil
+	}
+	exports, err := s.exportRepo.ClaimPending(ctx, tripExportBatch, time.Now().Add(-tripExportStaleAfter))
+	if err != nil {
+		return 0, err
+	}
+
+	generated := 0
+	for _, export := range exports {
+		if err := s.generate(ctx, export); err != nil {
+			log.Printf("failed to generate trip export %s: %v", export.ID, err)
+			if err := s.exportRepo.MarkFailed(ctx, export.ID, err.Error()); err != nil {
+				log.Printf("failed to mark trip export %s failed: %v", export.ID, err)
+			}
+			continue
+		}
+		generated++
+	}
+
+	if generated > 0 {
+		log.Printf("trip exports: generated %d exports", generated)
+	}
+	return generated, nil
+}
+
+func (s *tripExportService) generate(ctx context.Context, export *models.TripExport) error {
+	from := time.Date(export.Year, 1, 1, 0, 0, 0, 0, time.Local)
+	rows, err := s.exportRepo.GetRows(ctx, export.DriverID, from, from.AddDate(1, 0, 0))
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write(tripExportHeader)
+	for _, row := range rows {
+		record, err := s.record(ctx, export.DriverID, row)
+		if err != nil {
+			return err
+		}
+		w.Write(record)
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+
+	key := fmt.Sprintf("drivers/%s/exports/trips-%d-%s.csv", export.DriverID, export.Year, export.ID)
+	if err := s.store.Put(ctx, key, "text/csv", buf.Bytes()); err != nil {
+		return err
+	}
+	return s.exportRepo.MarkReady(ctx, export.ID, key, len(rows))
+}
+
+// record formats one trip. Commission comes from the split stored with the payment,
+// or the rate in effect at the time for payments taken before splits were recorded.
+func (s *tripExportService) record(ctx context.Context, driverID string, row *models.TripExportRow) ([]string, error) {
+	record := []string{
+		row.TripID,
+		row.RideID,
+		formatTime(row.StartTime),
+		formatTime(row.EndTime),
+		row.VehicleType,
+		csvText(row.PickupAddress),
+		csvText(row.DropoffAddress),
+		formatAmount(row.DistanceKm),
+		"",
+		formatAmount(row.TotalFare),
+		formatString(row.PaymentMethod),
+		formatTime(row.PaidAt),
+		"", "", "",
+	}
+	if row.DurationMins != nil {
+		record[8] = strconv.Itoa(*row.DurationMins)
+	}
+	if row.PaidAmount == nil {
+		// Unpaid trips have no commission yet
+		return record, nil
+	}
+
+	var split models.CommissionSplit
+	if row.CommissionPercent != nil && row.CommissionAmount != nil && row.DriverEarnings != nil {
+		split = models.CommissionSplit{
+			Percent:        *row.CommissionPercent,
+			Commission:     *row.CommissionAmount,
+			DriverEarnings: *row.DriverEarnings,
+		}
+	} else {
+		rate, err := s.commissionService.RateAt(ctx, driverID, *row.PaidAt)
+		if err != nil {
+			return nil, err
+		}
+		fare := *row.PaidAmount
+		if row.CarbonOffsetAmount != nil {
+			fare -= *row.CarbonOffsetAmount
+		}
+		split = models.NewCommissionSplit(fare, rate)
+	}
+	record[12] = strconv.FormatFloat(split.Percent, 'f', -1, 64)
+	record[13] = strconv.FormatFloat(split.Commission, 'f', 2, 64)
+	record[14] = strconv.FormatFloat(split.DriverEarnings, 'f', 2, 64)
+	return record, nil
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func formatString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// csvText formats free text riders entered so spreadsheets don't run it as a formula
+func csvText(s *string) string {
+	text := formatString(s)
+	if text != "" && strings.ContainsRune("=+-@\t\r", rune(text[0])) {
+		return "'" + text
+	}
+	return text
+}
+
+func formatAmount(f *float64) string {
+	if f == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*f, 'f', 2, 64)
+}
@@ -12,38 +12,82 @@ import (
 )
 
 type TripService interface {
-	StartTrip(ctx context.Context, rideID string) (*models.Trip, error)
+	StartTrip(ctx context.Context, req *models.StartTripRequest) (*models.Trip, error)
 	EndTrip(ctx context.Context, tripID string, req *models.EndTripRequest) (*models.TripResponse, error)
 	GetTrip(ctx context.Context, tripID string) (*models.Trip, error)
 	PauseTrip(ctx context.Context, tripID string) error
 	ResumeTrip(ctx context.Context, tripID string) error
+	RecordOdometer(ctx context.Context, tripID string, req *models.RecordOdometerRequest) (*models.Trip, error)
+	GetCarbonStats(ctx context.Context, ownerType, ownerID string) (*models.CarbonStats, error)
+	// LiveFare returns the running fare of the ride's trip in progress
+	LiveFare(ctx context.Context, rideID string) (*models.FareTick, error)
 }
 
 type tripService struct {
-	tripRepo       repository.TripRepository
-	rideRepo       repository.RideRepository
-	driverRepo     repository.DriverRepository
-	pricingService PricingService
-	driverCache    cache.DriverLocationCache
+	tripRepo         repository.TripRepository
+	rideRepo         repository.RideRepository
+	driverRepo       repository.DriverRepository
+	uploadRepo       repository.UploadRepository
+	segmentRepo      repository.TripSegmentRepository
+	pricingService   PricingService
+	calendarService  PricingCalendarService
+	regionService    RegionService
+	driverCache      cache.DriverLocationCache
+	chainService     TripChainService
+	earningsService  EarningsService
+	incentiveService IncentiveService
+	cooldownService  CooldownService
+	deliveryService  DeliveryService
+	promoService     PromoService
+	mileageTolerance models.MileageTolerance
+	router           Router
+	vehicleTypes     VehicleTypeService
 }
 
 func NewTripService(
 	tripRepo repository.TripRepository,
 	rideRepo repository.RideRepository,
 	driverRepo repository.DriverRepository,
+	uploadRepo repository.UploadRepository,
+	segmentRepo repository.TripSegmentRepository,
 	pricingService PricingService,
+	calendarService PricingCalendarService,
+	regionService RegionService,
 	driverCache cache.DriverLocationCache,
+	chainService TripChainService,
+	earningsService EarningsService,
+	incentiveService IncentiveService,
+	cooldownService CooldownService,
+	deliveryService DeliveryService,
+	promoService PromoService,
+	mileageTolerance models.MileageTolerance,
+	router Router,
+	vehicleTypes VehicleTypeService,
 ) TripService {
 	return &tripService{
-		tripRepo:       tripRepo,
-		rideRepo:       rideRepo,
-		driverRepo:     driverRepo,
-		pricingService: pricingService,
-		driverCache:    driverCache,
+		tripRepo:         tripRepo,
+		rideRepo:         rideRepo,
+		driverRepo:       driverRepo,
+		uploadRepo:       uploadRepo,
+		segmentRepo:      segmentRepo,
+		pricingService:   pricingService,
+		calendarService:  calendarService,
+		regionService:    regionService,
+		driverCache:      driverCache,
+		chainService:     chainService,
+		earningsService:  earningsService,
+		incentiveService: incentiveService,
+		cooldownService:  cooldownService,
+		deliveryService:  deliveryService,
+		promoService:     promoService,
+		mileageTolerance: mileageTolerance,
+		router:           router,
+		vehicleTypes:     vehicleTypes,
 	}
 }
 
-func (s *tripService) StartTrip(ctx context.Context, rideID string) (*models.Trip, error) {
+func (s *tripService) StartTrip(ctx context.Context, req *models.StartTripRequest) (*models.Trip, error) {
+	rideID := req.RideID
 	ride, err := s.rideRepo.GetByID(ctx, rideID)
 	if err != nil {
 		return nil, err
@@ -61,6 +105,11 @@ func (s *tripService) StartTrip(ctx context.Context, rideID string) (*models.Tri
 		return nil, apperrors.BadRequest("no driver assigned")
 	}
 
+	// Deliveries start only once the parcel is photographed at pickup
+	if err := s.deliveryService.RequirePickup(ctx, ride); err != nil {
+		return nil, err
+	}
+
 	// Check if trip already exists
 	existingTrip, err := s.tripRepo.GetByRideID(ctx, rideID)
 	if err != nil {
@@ -78,15 +127,38 @@ func (s *tripService) StartTrip(ctx context.Context, rideID string) (*models.Tri
 		Status:   models.TripStatusStarted,
 	}
 
+	// Two-wheelers start only once the driver confirms both riders wear helmets
+	vt, err := s.vehicleTypes.Get(ctx, ride.VehicleType)
+	if err != nil {
+		return nil, err
+	}
+	if vt != nil && vt.HelmetRequired {
+		if !req.HelmetConfirmed {
+			return nil, apperrors.HelmetConfirmationRequired()
+		}
+		now := time.Now()
+		trip.HelmetConfirmedAt = &now
+	}
+
 	if err := s.tripRepo.Create(ctx, trip); err != nil {
 		return nil, err
 	}
 
 	// Update ride status
-	if err := s.rideRepo.UpdateStatus(ctx, rideID, models.RideStatusInProgress); err != nil {
+	err = models.RideStates.Apply(ctx, rideID, ride.Status, models.RideStatusInProgress, func() error {
+		return s.rideRepo.UpdateStatus(ctx, rideID, models.RideStatusInProgress)
+	})
+	if err != nil {
 		log.Printf("failed to update ride status: %v", err)
 	}
 
+	// Start accumulating GPS distance for the mileage audit
+	if s.driverCache != nil {
+		if err := s.driverCache.StartTripDistance(ctx, trip.DriverID); err != nil {
+			log.Printf("failed to start trip distance tracking: %v", err)
+		}
+	}
+
 	return trip, nil
 }
 
@@ -99,8 +171,8 @@ func (s *tripService) EndTrip(ctx context.Context, tripID string, req *models.En
 		return nil, apperrors.NotFound("trip")
 	}
 
-	if !trip.CanTransitionTo(models.TripStatusCompleted) {
-		return nil, apperrors.InvalidTransition(trip.Status, models.TripStatusCompleted)
+	if err := models.TripStates.Check(trip.Status, models.TripStatusCompleted); err != nil {
+		return nil, err
 	}
 
 	// Get ride for surge multiplier and vehicle type
@@ -111,11 +183,29 @@ func (s *tripService) EndTrip(ctx context.Context, tripID string, req *models.En
 	if ride == nil {
 		return nil, apperrors.NotFound("ride")
 	}
+	if err := s.deliveryService.RequireDropoff(ctx, ride); err != nil {
+		return nil, err
+	}
+
+	// The route from pickup to where the trip ended is kept on the trip for maps and receipts
+	var route *Route
+	if s.router != nil {
+		route, err = s.router.Route(ctx, ride.PickupLat, ride.PickupLng, req.EndLat, req.EndLng)
+		if err != nil {
+			return nil, err
+		}
+		if route.Polyline != "" {
+			trip.RoutePolyline = &route.Polyline
+		}
+	}
 
 	// Calculate actual distance and duration
 	var actualDistanceKm float64
 	if req.OdometerKm != nil {
 		actualDistanceKm = *req.OdometerKm
+	} else if route != nil {
+		// The road distance to where the trip ended beats the estimate made at booking
+		actualDistanceKm = route.DistanceKm
 	} else if ride.EstimatedDistanceKm != nil {
 		actualDistanceKm = *ride.EstimatedDistanceKm
 	} else {
@@ -129,22 +219,38 @@ func (s *tripService) EndTrip(ctx context.Context, tripID string, req *models.En
 	// Calculate duration
 	var actualDurationMins int
 	if trip.StartTime != nil {
-		duration := time.Since(*trip.StartTime)
-		actualDurationMins = int(duration.Minutes()) - (trip.PauseDurationSecs / 60)
-		if actualDurationMins < 1 {
-			actualDurationMins = 1
-		}
+		actualDurationMins = billedMinutes(trip, time.Now())
 	} else {
 		actualDurationMins = s.pricingService.EstimateDuration(actualDistanceKm)
 	}
 
-	// Calculate fare
-	fare := s.pricingService.CalculateActualFare(
-		ride.VehicleType,
-		actualDistanceKm,
-		actualDurationMins,
-		ride.SurgeMultiplier,
-	)
+	// A trip handed over after a breakdown is billed as one trip over the legs actually driven
+	segments, err := s.segmentRepo.GetByTripID(ctx, trip.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(segments) > 0 {
+		actualDistanceKm, actualDurationMins = s.closeFinalSegment(ctx, trip, segments, req)
+	}
+
+	// EV trips may be discounted per region and emit less
+	driver, err := s.driverRepo.GetByID(ctx, trip.DriverID)
+	if err != nil {
+		log.Printf("failed to load driver for trip %s: %v", trip.ID, err)
+	}
+	isEV := driver != nil && driver.IsEV
+
+	fare, err := s.priceTrip(ctx, trip, ride, actualDistanceKm, actualDurationMins, isEV)
+	if err != nil {
+		return nil, err
+	}
+
+	// The rider's promo comes off last, once everything else is charged
+	if ride.AgreedFare == nil {
+		if err := s.promoService.Redeem(ctx, ride, fare); err != nil {
+			log.Printf("failed to redeem promo for ride %s: %v", ride.ID, err)
+		}
+	}
 
 	// Update trip
 	trip.ActualDistanceKm = &actualDistanceKm
@@ -154,32 +260,118 @@ func (s *tripService) EndTrip(ctx context.Context, tripID string, req *models.En
 	trip.TimeFare = &fare.TimeFare
 	trip.SurgeAmount = &fare.SurgeAmount
 	trip.TotalFare = &fare.Total
+	if fare.EVDiscount > 0 {
+		trip.EVDiscount = &fare.EVDiscount
+	}
+	if fare.WaitingFee > 0 {
+		trip.WaitingFee = &fare.WaitingFee
+	}
+	if fare.PackageSurcharge > 0 {
+		trip.PackageSurcharge = &fare.PackageSurcharge
+	}
+	if fare.DeclaredValueSurcharge > 0 {
+		trip.DeclaredValueSurcharge = &fare.DeclaredValueSurcharge
+	}
+	if fare.EventSurcharge > 0 {
+		trip.EventSurcharge = &fare.EventSurcharge
+		trip.PricingEvents = fare.Events
+	}
+	if fare.PromoDiscount > 0 {
+		trip.PromoDiscount = &fare.PromoDiscount
+	}
+	previousStatus := trip.Status
 	trip.Status = models.TripStatusCompleted
 
+	co2 := models.EstimateCO2Grams(ride.VehicleType, isEV, actualDistanceKm)
+	trip.CO2Grams = &co2
+
+	// Mileage audit: compare the claimed odometer distance with GPS
+	if s.driverCache != nil {
+		gpsKm, err := s.driverCache.GetTripDistance(ctx, trip.DriverID)
+		if err != nil {
+			log.Printf("failed to read trip distance: %v", err)
+		}
+		trip.GPSDistanceKm = round(gpsKm)
+	}
+	// Odometer readings from different vehicles can't be compared after a handover
+	if len(segments) == 0 {
+		if claimed := trip.OdometerDistanceKm(); claimed != nil {
+			trip.AuditMileage(*claimed, s.mileageTolerance)
+		} else if req.OdometerKm != nil {
+			trip.AuditMileage(*req.OdometerKm, s.mileageTolerance)
+		}
+	}
+
 	if err := s.tripRepo.EndTrip(ctx, trip); err != nil {
 		return nil, err
 	}
+	models.TripStates.Record(ctx, trip.ID, previousStatus, models.TripStatusCompleted)
+
+	// Split the fare between the drivers who shared the trip
+	models.SplitFare(fare.Total, segments)
+	for _, segment := range segments {
+		if err := s.segmentRepo.Update(ctx, nil, segment); err != nil {
+			log.Printf("failed to update trip segment %s: %v", segment.ID, err)
+		}
+	}
 
 	// Update ride status
-	if err := s.rideRepo.UpdateStatus(ctx, trip.RideID, models.RideStatusCompleted); err != nil {
+	err = models.RideStates.Apply(ctx, ride.ID, ride.Status, models.RideStatusCompleted, func() error {
+		return s.rideRepo.UpdateStatus(ctx, ride.ID, models.RideStatusCompleted)
+	})
+	if err != nil {
 		log.Printf("failed to update ride status: %v", err)
 	}
 
-	// Update driver status and stats
-	if err := s.driverRepo.UpdateStatus(ctx, trip.DriverID, models.DriverStatusOnline); err != nil {
-		log.Printf("failed to update driver status: %v", err)
+	// Clear cache
+	if s.driverCache != nil {
+		s.driverCache.ClearActiveRide(ctx, trip.DriverID)
+		s.driverCache.ClearUserActiveRide(ctx, trip.UserID)
+		s.driverCache.ClearTripDistance(ctx, trip.DriverID)
+	}
+
+	// Drivers with a queued next ride go straight to it; everyone else is available again
+	next, err := s.chainService.ActivateQueuedRide(ctx, trip.DriverID)
+	if err != nil {
+		log.Printf("failed to activate queued ride for driver %s: %v", trip.DriverID, err)
+	}
+	if next == nil {
+		now := time.Now()
+		if err := s.driverRepo.UpdateStatus(ctx, trip.DriverID, models.DriverStatusOnline); err != nil {
+			log.Printf("failed to update driver status: %v", err)
+		} else {
+			syncDriverStatus(ctx, s.driverCache, trip.DriverID, models.DriverStatusOnline, now)
+		}
 	}
 	if err := s.driverRepo.IncrementTotalTrips(ctx, trip.DriverID); err != nil {
 		log.Printf("failed to increment driver trips: %v", err)
 	}
 
-	// Clear cache
-	if s.driverCache != nil {
-		s.driverCache.ClearActiveRide(ctx, trip.DriverID)
-		s.driverCache.ClearUserActiveRide(ctx, trip.UserID)
+	// Guarantees are per driver, so trips shared after a handover don't qualify
+	var topUp *models.LedgerEntry
+	if len(segments) == 0 {
+		topUp, err = s.incentiveService.ApplyGuarantee(ctx, trip, ride)
+		if err != nil {
+			log.Printf("failed to apply incentive guarantee to trip %s: %v", trip.ID, err)
+		}
+	}
+	s.earningsService.NotifyMilestones(ctx, trip.DriverID)
+
+	// A queued ride was already accepted; the cooldown holds back offers after it
+	cooldown, err := s.cooldownService.AfterTrip(ctx, trip.DriverID)
+	if err != nil {
+		log.Printf("failed to check cooldown for driver %s: %v", trip.DriverID, err)
 	}
 
-	return trip.ToResponse(), nil
+	response := trip.ToResponse()
+	if next != nil {
+		response.NextRideID = &next.ID
+	}
+	if topUp != nil {
+		response.IncentiveTopUp = &topUp.Amount
+	}
+	response.DriverCooldown = cooldown
+	return response, nil
 }
 
 func (s *tripService) GetTrip(ctx context.Context, tripID string) (*models.Trip, error) {
@@ -202,11 +394,9 @@ func (s *tripService) PauseTrip(ctx context.Context, tripID string) error {
 		return apperrors.NotFound("trip")
 	}
 
-	if !trip.CanTransitionTo(models.TripStatusPaused) {
-		return apperrors.InvalidTransition(trip.Status, models.TripStatusPaused)
-	}
-
-	return s.tripRepo.UpdateStatus(ctx, tripID, models.TripStatusPaused)
+	return models.TripStates.Apply(ctx, tripID, trip.Status, models.TripStatusPaused, func() error {
+		return s.tripRepo.UpdateStatus(ctx, tripID, models.TripStatusPaused)
+	})
 }
 
 func (s *tripService) ResumeTrip(ctx context.Context, tripID string) error {
@@ -222,5 +412,245 @@ func (s *tripService) ResumeTrip(ctx context.Context, tripID string) error {
 		return apperrors.BadRequest("trip is not paused")
 	}
 
-	return s.tripRepo.UpdateStatus(ctx, tripID, models.TripStatusStarted)
+	return models.TripStates.Apply(ctx, tripID, trip.Status, models.TripStatusStarted, func() error {
+		return s.tripRepo.UpdateStatus(ctx, tripID, models.TripStatusStarted)
+	})
+}
+
+// RecordOdometer attaches an odometer reading and photo to a trip. When the end
+// reading arrives after the trip has completed, the mileage audit is re-run.
+func (s *tripService) RecordOdometer(ctx context.Context, tripID string, req *models.RecordOdometerRequest) (*models.Trip, error) {
+	trip, err := s.tripRepo.GetByID(ctx, tripID)
+	if err != nil {
+		return nil, err
+	}
+	if trip == nil {
+		return nil, apperrors.NotFound("trip")
+	}
+	if trip.Status == models.TripStatusCancelled {
+		return nil, apperrors.BadRequest("trip was cancelled")
+	}
+	if req.Stage == models.OdometerStageStart && trip.Status == models.TripStatusCompleted {
+		return nil, apperrors.BadRequest("trip has already ended")
+	}
+
+	upload, err := s.uploadRepo.GetByID(ctx, req.UploadID)
+	if err != nil {
+		return nil, err
+	}
+	if upload == nil {
+		return nil, apperrors.NotFound("upload")
+	}
+	if upload.OwnerType != models.UploadOwnerDriver || upload.OwnerID != trip.DriverID {
+		return nil, apperrors.Unauthorized("upload does not belong to the trip driver")
+	}
+	if upload.Purpose != models.UploadPurposeOdometerPhoto {
+		return nil, apperrors.BadRequest("upload is not an odometer photo")
+	}
+	if upload.Status != models.UploadStatusVerified {
+		return nil, apperrors.BadRequest("odometer photo upload has not been completed")
+	}
+
+	reading := req.ReadingKm
+	if req.Stage == models.OdometerStageStart {
+		if trip.EndOdometerKm != nil && reading > *trip.EndOdometerKm {
+			return nil, apperrors.BadRequest("start reading is greater than end reading")
+		}
+		trip.StartOdometerKm = &reading
+		trip.StartOdometerUploadID = &upload.ID
+	} else {
+		if trip.StartOdometerKm != nil && reading < *trip.StartOdometerKm {
+			return nil, apperrors.BadRequest("end reading is less than start reading")
+		}
+		trip.EndOdometerKm = &reading
+		trip.EndOdometerUploadID = &upload.ID
+	}
+
+	if err := s.tripRepo.SetOdometerReading(ctx, trip.ID, req.Stage, reading, upload.ID); err != nil {
+		return nil, err
+	}
+
+	if trip.Status == models.TripStatusCompleted {
+		if claimed := trip.OdometerDistanceKm(); claimed != nil {
+			trip.AuditMileage(*claimed, s.mileageTolerance)
+			if err := s.tripRepo.UpdateMileageAudit(ctx, trip); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return trip, nil
+}
+
+func (s *tripService) GetCarbonStats(ctx context.Context, ownerType, ownerID string) (*models.CarbonStats, error) {
+	return s.tripRepo.GetCarbonStats(ctx, ownerType, ownerID)
+}
+
+// applyEVDiscount discounts the fare of an EV trip in a region that offers an EV discount
+// closeFinalSegment ends the rescue driver's leg at the dropoff and returns the
+// distance and duration summed over all legs, excluding time spent frozen
+func (s *tripService) closeFinalSegment(ctx context.Context, trip *models.Trip, segments []*models.TripSegment, req *models.EndTripRequest) (float64, int) {
+	last := segments[len(segments)-1]
+
+	var km float64
+	if s.driverCache != nil {
+		gpsKm, err := s.driverCache.GetTripDistance(ctx, trip.DriverID)
+		if err != nil {
+			log.Printf("failed to read trip distance: %v", err)
+		}
+		km = round(gpsKm)
+	}
+	if km == 0 {
+		km = s.pricingService.EstimateDistance(last.StartLat, last.StartLng, req.EndLat, req.EndLng)
+	}
+	last.Close(req.EndLat, req.EndLng, km, time.Now(), "")
+
+	var totalKm float64
+	var totalMins int
+	for _, segment := range segments {
+		if segment.DistanceKm != nil {
+			totalKm += *segment.DistanceKm
+		}
+		if segment.DurationMins != nil {
+			totalMins += *segment.DurationMins
+		}
+	}
+	return round(totalKm), totalMins
+}
+
+// LiveFare prices the ride's trip as if it ended now. Distance is the booked
+// estimate when there is one, otherwise the GPS distance driven so far.
+func (s *tripService) LiveFare(ctx context.Context, rideID string) (*models.FareTick, error) {
+	trip, err := s.tripRepo.GetByRideID(ctx, rideID)
+	if err != nil {
+		return nil, err
+	}
+	if trip == nil || trip.StartTime == nil {
+		return nil, apperrors.NotFound("trip")
+	}
+	if trip.Status != models.TripStatusStarted && trip.Status != models.TripStatusPaused {
+		return nil, apperrors.BadRequest("trip is not in progress")
+	}
+
+	ride, err := s.rideRepo.GetByID(ctx, rideID)
+	if err != nil {
+		return nil, err
+	}
+	if ride == nil {
+		return nil, apperrors.NotFound("ride")
+	}
+
+	var distanceKm float64
+	if ride.EstimatedDistanceKm != nil {
+		distanceKm = *ride.EstimatedDistanceKm
+	} else if s.driverCache != nil {
+		gpsKm, err := s.driverCache.GetTripDistance(ctx, trip.DriverID)
+		if err != nil {
+			log.Printf("failed to read trip distance: %v", err)
+		}
+		distanceKm = round(gpsKm)
+	}
+
+	driver, err := s.driverRepo.GetByID(ctx, trip.DriverID)
+	if err != nil {
+		log.Printf("failed to load driver for trip %s: %v", trip.ID, err)
+	}
+
+	now := time.Now()
+	durationMins := billedMinutes(trip, now)
+	fare, err := s.priceTrip(ctx, trip, ride, distanceKm, durationMins, driver != nil && driver.IsEV)
+	if err != nil {
+		return nil, err
+	}
+
+	// Quote the promo rather than redeem it. A code the fare doesn't qualify for
+	// yet is left off and may still apply when the trip ends.
+	if ride.AgreedFare == nil && ride.PromoCode != nil {
+		s.promoService.Quote(ctx, *ride.PromoCode, fare)
+	}
+
+	return &models.FareTick{
+		TripID:       trip.ID,
+		RideID:       ride.ID,
+		DistanceKm:   distanceKm,
+		DurationMins: durationMins,
+		Fare:         fare,
+		Fixed:        ride.AgreedFare != nil,
+		Paused:       trip.Status == models.TripStatusPaused,
+		At:           now,
+	}, nil
+}
+
+// priceTrip charges a trip of distanceKm over durationMins as the trip's ride
+// was booked, before the rider's promo. Ending the trip and the live meter both
+// price through here so the meter shows what the bill will be.
+func (s *tripService) priceTrip(ctx context.Context, trip *models.Trip, ride *models.Ride, distanceKm float64, durationMins int, isEV bool) (*models.FareBreakdown, error) {
+	// Calculate fare; a fare negotiated in bid mode is fixed regardless of the route taken
+	var fare *models.FareBreakdown
+	if ride.AgreedFare != nil {
+		fare = &models.FareBreakdown{BaseFare: *ride.AgreedFare, Total: *ride.AgreedFare}
+	} else {
+		fare = s.pricingService.CalculateActualFare(
+			ctx,
+			ride.VehicleType,
+			distanceKm,
+			durationMins,
+			ride.SurgeMultiplier,
+		)
+	}
+
+	// Holiday and event pricing in effect when the ride was requested, as quoted
+	if ride.AgreedFare == nil {
+		regionCode := ""
+		if ride.RegionCode != nil {
+			regionCode = *ride.RegionCode
+		}
+		s.calendarService.Apply(ctx, fare, ride.CreatedAt, regionCode, ride.VehicleType,
+			models.GeoPoint{Lat: ride.PickupLat, Lng: ride.PickupLng}, models.GeoPoint{Lat: ride.DropoffLat, Lng: ride.DropoffLng})
+	}
+
+	// EV trips may be discounted per region
+	if isEV && ride.AgreedFare == nil {
+		s.applyEVDiscount(ctx, ride, fare)
+	}
+
+	// Time the driver spent waiting at pickup beyond the free allowance is billed
+	if ride.ArrivedAt != nil && trip.StartTime != nil && ride.AgreedFare == nil {
+		s.pricingService.ApplyWaitingFee(ctx, fare, ride.VehicleType, trip.StartTime.Sub(*ride.ArrivedAt))
+	}
+
+	// Parcels are charged for their size class and declared value as quoted at booking
+	if ride.IsDelivery() && ride.AgreedFare == nil {
+		delivery, err := s.deliveryService.GetDelivery(ctx, ride.ID)
+		if err != nil {
+			return nil, err
+		}
+		s.pricingService.ApplyPackageSurcharges(fare, delivery.PackageSize, delivery.DeclaredValue)
+	}
+
+	return fare, nil
+}
+
+// billedMinutes is how long the trip has run at now, less time spent paused
+func billedMinutes(trip *models.Trip, now time.Time) int {
+	mins := int(now.Sub(*trip.StartTime).Minutes()) - (trip.PauseDurationSecs / 60)
+	if mins < 1 {
+		mins = 1
+	}
+	return mins
+}
+
+func (s *tripService) applyEVDiscount(ctx context.Context, ride *models.Ride, fare *models.FareBreakdown) {
+	if ride.RegionCode == nil {
+		return
+	}
+
+	region, err := s.regionService.GetRegion(ctx, *ride.RegionCode)
+	if err != nil {
+		log.Printf("failed to load region for EV discount: %v", err)
+		return
+	}
+	if region != nil {
+		s.pricingService.ApplyEVDiscount(fare, region.Settings.EVDiscountPercent)
+	}
 }
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aditya/go-comet/internal/cache"
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository/memory"
+)
+
+type fixedRouter struct{ route Route }
+
+func (r fixedRouter) Name() string { return "fixed" }
+
+func (r fixedRouter) Route(ctx context.Context, fromLat, fromLng, toLat, toLng float64) (*Route, error) {
+	route := r.route
+	return &route, nil
+}
+
+func TestEndTripBillsRoutedDistance(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	tripRepo := memory.NewTripRepository(store)
+	rideRepo := memory.NewRideRepository(store)
+	driverRepo := memory.NewDriverRepository(store)
+	driverCache := cache.NewMemoryDriverLocationCache()
+	pricingService := NewPricingService(nil)
+	commissionService := NewCommissionService(memory.NewCommissionRepository(store), driverRepo, 20)
+
+	ts := NewTripService(tripRepo, rideRepo, driverRepo, memory.NewUploadRepository(store), memory.NewTripSegmentRepository(store),
+		pricingService, NewPricingCalendarService(memory.NewPricingEventRepository(store)),
+		NewRegionService(memory.NewRegionRepository(store), memory.NewTenantRepository(store)), driverCache,
+		NewTripChainService(memory.NewTransactor(store), rideRepo, memory.NewRideOfferRepository(store), driverCache),
+		NewEarningsService(driverRepo, tripRepo, memory.NewPaymentRepository(store), memory.NewDeductionRepository(store),
+			memory.NewPayoutRepository(store), driverCache, nil, commissionService, 0),
+		NewIncentiveService(memory.NewIncentiveRepository(store), memory.NewTrainingRepository(store), commissionService),
+		NewCooldownService(driverRepo, tripRepo, driverCache, models.CooldownPolicy{}),
+		NewDeliveryService(memory.NewDeliveryRepository(store), rideRepo, memory.NewUploadRepository(store)),
+		NewPromoService(memory.NewPromoRepository(store), pricingService),
+		models.MileageTolerance{}, fixedRouter{route: Route{DistanceKm: 12}}, nil)
+
+	driver := &models.Driver{Name: "Ravi", Phone: "+919900000002", VehicleType: "sedan", Status: models.DriverStatusBusy}
+	if err := driverRepo.Create(ctx, driver); err != nil {
+		t.Fatalf("Create driver: %v", err)
+	}
+	estimateKm := 4.0
+	ride := &models.Ride{
+		UserID: "user-1", DriverID: &driver.ID, VehicleType: "sedan",
+		PickupLat: 12.97, PickupLng: 77.59, DropoffLat: 12.93, DropoffLng: 77.62,
+		SurgeMultiplier: 1, EstimatedDistanceKm: &estimateKm,
+	}
+	if err := rideRepo.Create(ctx, ride); err != nil {
+		t.Fatalf("Create ride: %v", err)
+	}
+	if err := rideRepo.UpdateStatus(ctx, ride.ID, models.RideStatusInProgress); err != nil {
+		t.Fatalf("UpdateStatus: %v", err)
+	}
+	start := time.Now().Add(-10 * time.Minute)
+	trip := &models.Trip{RideID: ride.ID, DriverID: driver.ID, UserID: ride.UserID, Status: models.TripStatusStarted, StartTime: &start}
+	if err := tripRepo.Create(ctx, trip); err != nil {
+		t.Fatalf("Create trip: %v", err)
+	}
+
+	if _, err := ts.EndTrip(ctx, trip.ID, &models.EndTripRequest{EndLat: 12.93, EndLng: 77.62}); err != nil {
+		t.Fatalf("EndTrip: %v", err)
+	}
+	ended, err := tripRepo.GetByID(ctx, trip.ID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if ended.ActualDistanceKm == nil || *ended.ActualDistanceKm != 12 {
+		t.Errorf("actual distance = %v, want routed 12 km", ended.ActualDistanceKm)
+	}
+	// Sedan distance is billed at 17 per km
+	if ended.DistanceFare == nil || *ended.DistanceFare != 204 {
+		t.Errorf("distance fare = %v, want 204", ended.DistanceFare)
+	}
+}
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	apperrors "github.com/aditya/go-comet/internal/errors"
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+	"github.com/aditya/go-comet/internal/storage"
+	"github.com/google/uuid"
+)
+
+type UploadService interface {
+	CreateUpload(ctx context.Context, req *models.CreateUploadRequest) (*models.UploadTicket, error)
+	CompleteUpload(ctx context.Context, id string) (*models.Upload, error)
+	GetUpload(ctx context.Context, id string) (*models.UploadResponse, error)
+}
+
+type uploadService struct {
+	uploadRepo repository.UploadRepository
+	userRepo   repository.UserRepository
+	driverRepo repository.DriverRepository
+	rideRepo   repository.RideRepository
+	store      storage.ObjectStore
+	urlTTL     time.Duration
+}
+
+// NewUploadService creates the upload service. store may be nil when object
+// storage is not configured, in which case uploads are rejected as unavailable.
+func NewUploadService(
+	uploadRepo repository.UploadRepository,
+	userRepo repository.UserRepository,
+	driverRepo repository.DriverRepository,
+	rideRepo repository.RideRepository,
+	store storage.ObjectStore,
+	urlTTL time.Duration,
+) UploadService {
+	return &uploadService{
+		uploadRepo: uploadRepo,
+		userRepo:   userRepo,
+		driverRepo: driverRepo,
+		rideRepo:   rideRepo,
+		store:      store,
+		urlTTL:     urlTTL,
+	}
+}
+
+func (s *uploadService) CreateUpload(ctx context.Context, req *models.CreateUploadRequest) (*models.UploadTicket, error) {
+	if s.store == nil {
+		return nil, apperrors.ServiceUnavailable("uploads_unavailable", "file uploads are not available")
+	}
+
+	policy, ok := models.UploadPolicies[req.Purpose]
+	if !ok {
+		return nil, apperrors.BadRequest("unknown upload purpose")
+	}
+	if !policy.AllowsOwner(req.OwnerType) {
+		return nil, apperrors.BadRequest(fmt.Sprintf("%s uploads cannot belong to a %s", req.Purpose, req.OwnerType))
+	}
+	if !policy.AllowsContentType(req.ContentType) {
+		return nil, apperrors.BadRequest("content type not allowed for " + req.Purpose)
+	}
+	if req.SizeBytes > policy.MaxSizeBytes {
+		return nil, apperrors.BadRequest(fmt.Sprintf("file exceeds maximum size of %d bytes", policy.MaxSizeBytes))
+	}
+
+	if err := s.ensureOwnerExists(ctx, req.OwnerType, req.OwnerID); err != nil {
+		return nil, err
+	}
+
+	id := uuid.New().String()
+	upload := &models.Upload{
+		ID:           id,
+		OwnerType:    req.OwnerType,
+		OwnerID:      req.OwnerID,
+		Purpose:      req.Purpose,
+		ObjectKey:    fmt.Sprintf("%ss/%s/%s/%s", req.OwnerType, req.OwnerID, req.Purpose, id),
+		ContentType:  req.ContentType,
+		MaxSizeBytes: policy.MaxSizeBytes,
+	}
+
+	presigned, err := s.store.PresignPut(ctx, upload.ObjectKey, upload.ContentType, s.urlTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.uploadRepo.Create(ctx, upload); err != nil {
+		return nil, err
+	}
+
+	return &models.UploadTicket{
+		Upload:    upload,
+		Method:    presigned.Method,
+		UploadURL: presigned.URL,
+		Headers:   presigned.Headers,
+		ExpiresAt: presigned.ExpiresAt,
+	}, nil
+}
+
+// CompleteUpload is called by the client once the PUT finished. The stored object
+// is inspected and the upload is verified or rejected against its policy.
+func (s *uploadService) CompleteUpload(ctx context.Context, id string) (*models.Upload, error) {
+	if s.store == nil {
+		return nil, apperrors.ServiceUnavailable("uploads_unavailable", "file uploads are not available")
+	}
+
+	upload, err := s.uploadRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if upload == nil {
+		return nil, apperrors.NotFound("upload")
+	}
+	if upload.Status != models.UploadStatusPending {
+		return upload, nil
+	}
+
+	info, err := s.store.Stat(ctx, upload.ObjectKey)
+	if err == storage.ErrObjectNotFound {
+		return nil, apperrors.BadRequest("file has not been uploaded yet")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	policy := models.UploadPolicies[upload.Purpose]
+	size := info.SizeBytes
+	upload.SizeBytes = &size
+
+	var reason string
+	switch {
+	case info.ContentType != upload.ContentType || !policy.AllowsContentType(info.ContentType):
+		reason = "stored content type " + info.ContentType + " does not match declared type"
+	case info.SizeBytes > upload.MaxSizeBytes:
+		reason = fmt.Sprintf("file size %d exceeds limit of %d bytes", info.SizeBytes, upload.MaxSizeBytes)
+	case info.SizeBytes == 0:
+		reason = "file is empty"
+	}
+
+	if reason != "" {
+		upload.Status = models.UploadStatusRejected
+		upload.RejectReason = &reason
+		log.Printf("upload %s rejected: %s", upload.ID, reason)
+	} else {
+		now := time.Now()
+		upload.Status = models.UploadStatusVerified
+		upload.VerifiedAt = &now
+	}
+
+	if err := s.uploadRepo.UpdateStatus(ctx, upload); err != nil {
+		return nil, err
+	}
+
+	return upload, nil
+}
+
+func (s *uploadService) GetUpload(ctx context.Context, id string) (*models.UploadResponse, error) {
+	upload, err := s.uploadRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if upload == nil {
+		return nil, apperrors.NotFound("upload")
+	}
+
+	response := &models.UploadResponse{Upload: upload}
+	if upload.Status == models.UploadStatusVerified && s.store != nil {
+		presigned, err := s.store.PresignGet(ctx, upload.ObjectKey, s.urlTTL)
+		if err == nil {
+			response.DownloadURL = presigned.URL
+		}
+	}
+
+	return response, nil
+}
+
+func (s *uploadService) ensureOwnerExists(ctx context.Context, ownerType, ownerID string) error {
+	var exists bool
+	switch ownerType {
+	case models.UploadOwnerUser:
+		user, err := s.userRepo.GetByID(ctx, ownerID)
+		if err != nil {
+			return err
+		}
+		exists = user != nil
+	case models.UploadOwnerDriver:
+		driver, err := s.driverRepo.GetByID(ctx, ownerID)
+		if err != nil {
+			return err
+		}
+		exists = driver != nil
+	case models.UploadOwnerRide:
+		ride, err := s.rideRepo.GetByID(ctx, ownerID)
+		if err != nil {
+			return err
+		}
+		exists = ride != nil
+	}
+
+	if !exists {
+		return apperrors.NotFound(ownerType)
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	apperrors "github.com/aditya/go-comet/internal/errors"
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+const vehicleTypeCacheTTL = time.Minute
+
+// VehicleTypeService manages the vehicle type registry
+type VehicleTypeService interface {
+	// Get returns the registered vehicle type with the code, active or not, or nil
+	Get(ctx context.Context, code string) (*models.VehicleTypeDefinition, error)
+	// Active returns the types that can be booked in the region, in display
+	// order; with no region, those that can be booked anywhere
+	Active(ctx context.Context, regionCode string) ([]*models.VehicleTypeDefinition, error)
+	// Codes returns the code of every registered type, active or not
+	Codes(ctx context.Context) ([]string, error)
+	// CheckActive rejects a type that isn't registered or can't be booked in the
+	// region (anywhere, with no region)
+	CheckActive(ctx context.Context, code, regionCode string) error
+	// CheckRegistered rejects codes that aren't registered
+	CheckRegistered(ctx context.Context, codes ...string) error
+	// ListVehicleTypes returns every type including inactive ones, bypassing the cache
+	ListVehicleTypes(ctx context.Context) ([]*models.VehicleTypeDefinition, error)
+	CreateVehicleType(ctx context.Context, req *models.CreateVehicleTypeRequest) (*models.VehicleTypeDefinition, error)
+	UpdateVehicleType(ctx context.Context, code string, req *models.UpdateVehicleTypeRequest) (*models.VehicleTypeDefinition, error)
+}
+
+type vehicleTypeService struct {
+	vehicleTypeRepo repository.VehicleTypeRepository
+
+	mu       sync.RWMutex
+	types    []*models.VehicleTypeDefinition
+	loadedAt time.Time
+}
+
+func NewVehicleTypeService(vehicleTypeRepo repository.VehicleTypeRepository) VehicleTypeService {
+	return &vehicleTypeService{
+		vehicleTypeRepo: vehicleTypeRepo,
+	}
+}
+
+func (s *vehicleTypeService) Get(ctx context.Context, code string) (*models.VehicleTypeDefinition, error) {
+	types, err := s.all(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range types {
+		if v.Code == code {
+			return v, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *vehicleTypeService) Active(ctx context.Context, regionCode string) ([]*models.VehicleTypeDefinition, error) {
+	types, err := s.all(ctx)
+	if err != nil {
+		return nil, err
+	}
+	active := make([]*models.VehicleTypeDefinition, 0, len(types))
+	for _, v := range types {
+		if v.ActiveIn(regionCode) {
+			active = append(active, v)
+		}
+	}
+	return active, nil
+}
+
+func (s *vehicleTypeService) Codes(ctx context.Context) ([]string, error) {
+	types, err := s.all(ctx)
+	if err != nil {
+		return nil, err
+	}
+	codes := make([]string, len(types))
+	for i, v := range types {
+		codes[i] = v.Code
+	}
+	return codes, nil
+}
+
+func (s *vehicleTypeService) CheckActive(ctx context.Context, code, regionCode string) error {
+	v, err := s.Get(ctx, code)
+	if err != nil {
+		return err
+	}
+	if v == nil {
+		return apperrors.BadRequest(fmt.Sprintf("unknown vehicle type %s", code))
+	}
+	if !v.ActiveIn(regionCode) {
+		if regionCode == "" {
+			return apperrors.BadRequest(fmt.Sprintf("%s vehicles aren't accepted", v.Code))
+		}
+		return apperrors.BadRequest(fmt.Sprintf("%s rides are not offered in %s", v.Code, regionCode))
+	}
+	return nil
+}
+
+func (s *vehicleTypeService) CheckRegistered(ctx context.Context, codes ...string) error {
+	for _, code := range codes {
+		v, err := s.Get(ctx, code)
+		if err != nil {
+			return err
+		}
+		if v == nil {
+			return apperrors.BadRequest(fmt.Sprintf("unknown vehicle type %s", code))
+		}
+	}
+	return nil
+}
+
+// all returns every registered type, served from memory and refreshed every
+// vehicleTypeCacheTTL
+func (s *vehicleTypeService) all(ctx context.Context) ([]*models.VehicleTypeDefinition, error) {
+	s.mu.RLock()
+	if time.Since(s.loadedAt) < vehicleTypeCacheTTL {
+		types := s.types
+		s.mu.RUnlock()
+		return types, nil
+	}
+	s.mu.RUnlock()
+
+	types, err := s.vehicleTypeRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.types = types
+	s.loadedAt = time.Now()
+	s.mu.Unlock()
+
+	return types, nil
+}
+
+func (s *vehicleTypeService) ListVehicleTypes(ctx context.Context) ([]*models.VehicleTypeDefinition, error) {
+	return s.vehicleTypeRepo.GetAll(ctx)
+}
+
+func (s *vehicleTypeService) CreateVehicleType(ctx context.Context, req *models.CreateVehicleTypeRequest) (*models.VehicleTypeDefinition, error) {
+	existing, err := s.vehicleTypeRepo.GetByCode(ctx, req.Code)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, apperrors.Conflict("vehicle type already exists")
+	}
+
+	v := &models.VehicleTypeDefinition{
+		Code:           req.Code,
+		Label:          req.Label,
+		Capacity:       req.Capacity,
+		Icon:           req.Icon,
+		Active:         req.Active == nil || *req.Active,
+		Regions:        req.Regions,
+		SortOrder:      req.SortOrder,
+		HelmetRequired: req.HelmetRequired,
+		VehicleFares:   req.Fares,
+	}
+	if err := s.vehicleTypeRepo.Create(ctx, v); err != nil {
+		return nil, err
+	}
+	s.invalidate()
+	return v, nil
+}
+
+func (s *vehicleTypeService) UpdateVehicleType(ctx context.Context, code string, req *models.UpdateVehicleTypeRequest) (*models.VehicleTypeDefinition, error) {
+	v, err := s.vehicleTypeRepo.GetByCode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, apperrors.NotFound("vehicle type")
+	}
+
+	v.Label = req.Label
+	v.Capacity = req.Capacity
+	v.Icon = req.Icon
+	v.Active = req.Active
+	v.Regions = req.Regions
+	v.SortOrder = req.SortOrder
+	v.HelmetRequired = req.HelmetRequired
+	v.VehicleFares = req.Fares
+
+	if err := s.vehicleTypeRepo.Update(ctx, v); err != nil {
+		return nil, err
+	}
+	s.invalidate()
+	return v, nil
+}
+
+// invalidate forces a reload so admin changes apply on this instance immediately
+func (s *vehicleTypeService) invalidate() {
+	s.mu.Lock()
+	s.loadedAt = time.Time{}
+	s.mu.Unlock()
+}
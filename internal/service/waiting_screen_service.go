@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	apperrors "github.com/aditya/go-comet/internal/errors"
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+)
+
+// WaitingScreenService assembles what riders see while waiting for a driver:
+// the match estimate alongside admin-managed safety tips and promo banners
+type WaitingScreenService interface {
+	CreateContent(ctx context.Context, req *models.CreateWaitingContentRequest) (*models.WaitingContent, error)
+	ListContent(ctx context.Context) ([]*models.WaitingContent, error)
+	SetContentActive(ctx context.Context, id string, active bool) (*models.WaitingContent, error)
+	// GetWaitingScreen returns the content for a ride that hasn't started yet
+	GetWaitingScreen(ctx context.Context, rideID string) (*models.WaitingScreen, error)
+}
+
+type waitingScreenService struct {
+	contentRepo   repository.WaitingContentRepository
+	rideRepo      repository.RideRepository
+	promoRepo     repository.PromoRepository
+	rideService   RideService
+	regionService RegionService
+}
+
+func NewWaitingScreenService(
+	contentRepo repository.WaitingContentRepository,
+	rideRepo repository.RideRepository,
+	promoRepo repository.PromoRepository,
+	rideService RideService,
+	regionService RegionService,
+) WaitingScreenService {
+	return &waitingScreenService{
+		contentRepo:   contentRepo,
+		rideRepo:      rideRepo,
+		promoRepo:     promoRepo,
+		rideService:   rideService,
+		regionService: regionService,
+	}
+}
+
+func (s *waitingScreenService) CreateContent(ctx context.Context, req *models.CreateWaitingContentRequest) (*models.WaitingContent, error) {
+	if req.StartsAt != nil && req.EndsAt != nil && !req.StartsAt.Before(*req.EndsAt) {
+		return nil, apperrors.BadRequest("starts_at must be before ends_at")
+	}
+	if req.RegionCode != nil {
+		region, err := s.regionService.GetRegion(ctx, *req.RegionCode)
+		if err != nil {
+			return nil, err
+		}
+		if region == nil {
+			return nil, apperrors.NotFound("region")
+		}
+	}
+
+	content := &models.WaitingContent{
+		Kind:       req.Kind,
+		RegionCode: req.RegionCode,
+		Title:      req.Title,
+		Body:       req.Body,
+		ImageURL:   req.ImageURL,
+		ActionURL:  req.ActionURL,
+		Priority:   req.Priority,
+		StartsAt:   req.StartsAt,
+		EndsAt:     req.EndsAt,
+	}
+	if req.PromoCode != nil {
+		if req.Kind != models.WaitingContentPromoBanner {
+			return nil, apperrors.BadRequest("promo_code only applies to promo banners")
+		}
+		code := normalizePromoCode(*req.PromoCode)
+		promo, err := s.promoRepo.GetByCode(ctx, code)
+		if err != nil {
+			return nil, err
+		}
+		if promo == nil {
+			return nil, apperrors.NotFound("promo code")
+		}
+		content.PromoCode = &code
+	}
+
+	if err := s.contentRepo.Create(ctx, content); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+func (s *waitingScreenService) ListContent(ctx context.Context) ([]*models.WaitingContent, error) {
+	contents, err := s.contentRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if contents == nil {
+		contents = []*models.WaitingContent{}
+	}
+	return contents, nil
+}
+
+func (s *waitingScreenService) SetContentActive(ctx context.Context, id string, active bool) (*models.WaitingContent, error) {
+	content, err := s.contentRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if content == nil {
+		return nil, apperrors.NotFound("waiting content")
+	}
+
+	if err := s.contentRepo.SetActive(ctx, id, active); err != nil {
+		return nil, err
+	}
+	content.Active = active
+	return content, nil
+}
+
+func (s *waitingScreenService) GetWaitingScreen(ctx context.Context, rideID string) (*models.WaitingScreen, error) {
+	ride, err := s.rideRepo.GetByID(ctx, rideID)
+	if err != nil {
+		return nil, err
+	}
+	if ride == nil {
+		return nil, apperrors.NotFound("ride")
+	}
+	switch ride.Status {
+	case models.RideStatusPending, models.RideStatusMatching, models.RideStatusQueued,
+		models.RideStatusDriverAssigned, models.RideStatusDriverArrived:
+	default:
+		return nil, apperrors.BadRequest("ride is no longer waiting for a driver")
+	}
+
+	now := time.Now()
+	screen := &models.WaitingScreen{
+		RideID:       ride.ID,
+		Status:       ride.Status,
+		SafetyTips:   []*models.WaitingItem{},
+		PromoBanners: []*models.WaitingItem{},
+		UpdatedAt:    now,
+	}
+
+	// The rest of the screen still renders if the estimate can't be worked out
+	if ride.Status == models.RideStatusMatching {
+		estimate, err := s.rideService.EstimateMatch(ctx, ride)
+		if err != nil {
+			log.Printf("failed to estimate match time for ride %s: %v", ride.ID, err)
+		} else {
+			screen.MatchEstimate = estimate
+		}
+	}
+
+	regionCode := ""
+	if ride.RegionCode != nil {
+		regionCode = *ride.RegionCode
+	}
+	contents, err := s.contentRepo.ListLive(ctx, regionCode, now)
+	if err != nil {
+		return nil, err
+	}
+	for _, content := range contents {
+		switch content.Kind {
+		case models.WaitingContentSafetyTip:
+			screen.SafetyTips = append(screen.SafetyTips, content.Item())
+		case models.WaitingContentPromoBanner:
+			if s.promoUsable(ctx, content.PromoCode, now) {
+				screen.PromoBanners = append(screen.PromoBanners, content.Item())
+			}
+		}
+	}
+	if len(screen.SafetyTips) == 0 {
+		screen.SafetyTips = models.DefaultSafetyTips
+	}
+	return screen, nil
+}
+
+// promoUsable reports whether a banner's promo can still be redeemed; banners
+// without a promo always show
+func (s *waitingScreenService) promoUsable(ctx context.Context, code *string, now time.Time) bool {
+	if code == nil {
+		return true
+	}
+	promo, err := s.promoRepo.GetByCode(ctx, *code)
+	if err != nil {
+		log.Printf("failed to load promo %s for waiting screen: %v", *code, err)
+		return false
+	}
+	// The ride's fare isn't known here, so the minimum fare isn't checked
+	return promo != nil && promoUnusableReason(promo, promo.MinFare, now) == ""
+}
@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	apperrors "github.com/aditya/go-comet/internal/errors"
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultWalletHistory = 50
+	maxWalletHistory     = 200
+)
+
+// WalletService keeps riders' prepaid balances: topped up from a card or UPI and
+// debited when a wallet ride is paid
+type WalletService interface {
+	GetWallet(ctx context.Context, userID string) (*models.WalletResponse, error)
+	// TopUp charges the rider and credits the wallet. Retrying with the same
+	// idempotency key returns the original top-up.
+	TopUp(ctx context.Context, userID string, req *models.TopUpWalletRequest, idempotencyKey string) (*models.WalletTransaction, error)
+	GetTransactions(ctx context.Context, userID string, limit int) ([]*models.WalletTransaction, error)
+	// CheckBalance returns InsufficientFunds unless the wallet covers amount
+	CheckBalance(ctx context.Context, userID string, amount float64) error
+	// Pay debits a payment from the rider's wallet, failing with InsufficientFunds
+	// if the balance doesn't cover it
+	Pay(ctx context.Context, payment *models.Payment) (*models.WalletTransaction, error)
+	// Refund credits a wallet payment back; refunding twice is a no-op
+	Refund(ctx context.Context, payment *models.Payment) error
+}
+
+type walletService struct {
+	walletRepo repository.WalletRepository
+	userRepo   repository.UserRepository
+}
+
+func NewWalletService(walletRepo repository.WalletRepository, userRepo repository.UserRepository) WalletService {
+	return &walletService{
+		walletRepo: walletRepo,
+		userRepo:   userRepo,
+	}
+}
+
+func (s *walletService) GetWallet(ctx context.Context, userID string) (*models.WalletResponse, error) {
+	if err := s.checkUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	wallet, err := s.walletRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	response := &models.WalletResponse{UserID: userID, Currency: "INR", Transactions: []*models.WalletTransaction{}}
+	if wallet == nil {
+		return response, nil
+	}
+
+	response.Balance = wallet.Balance
+	response.Currency = wallet.Currency
+	txns, err := s.walletRepo.GetTransactions(ctx, userID, defaultWalletHistory)
+	if err != nil {
+		return nil, err
+	}
+	if txns != nil {
+		response.Transactions = txns
+	}
+	return response, nil
+}
+
+func (s *walletService) TopUp(ctx context.Context, userID string, req *models.TopUpWalletRequest, idempotencyKey string) (*models.WalletTransaction, error) {
+	if err := s.checkUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	if idempotencyKey != "" {
+		existing, err := s.walletRepo.GetTransactionByIdempotencyKey(ctx, idempotencyKey)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			if existing.UserID != userID {
+				return nil, apperrors.IdempotencyConflict()
+			}
+			return existing, nil
+		}
+	}
+
+	// Mock PSP charge for the top-up
+	// In real implementation, call payment gateway API
+	pspTxnID := fmt.Sprintf("PSP_%s", uuid.New().String()[:8])
+
+	txn := &models.WalletTransaction{
+		UserID:           userID,
+		TxnType:          models.WalletTxnTopUp,
+		Amount:           round(req.Amount),
+		PSPTransactionID: &pspTxnID,
+	}
+	if idempotencyKey != "" {
+		txn.IdempotencyKey = &idempotencyKey
+	}
+	ok, err := s.walletRepo.Credit(ctx, txn)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		// A concurrent retry with the same key got there first
+		existing, err := s.walletRepo.GetTransactionByIdempotencyKey(ctx, idempotencyKey)
+		if err != nil {
+			return nil, err
+		}
+		if existing == nil {
+			return nil, apperrors.Conflict("top-up was already recorded")
+		}
+		return existing, nil
+	}
+	return txn, nil
+}
+
+func (s *walletService) GetTransactions(ctx context.Context, userID string, limit int) ([]*models.WalletTransaction, error) {
+	if err := s.checkUser(ctx, userID); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = defaultWalletHistory
+	}
+	if limit > maxWalletHistory {
+		limit = maxWalletHistory
+	}
+
+	txns, err := s.walletRepo.GetTransactions(ctx, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	if txns == nil {
+		txns = []*models.WalletTransaction{}
+	}
+	return txns, nil
+}
+
+func (s *walletService) CheckBalance(ctx context.Context, userID string, amount float64) error {
+	wallet, err := s.walletRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if wallet == nil || wallet.Balance < amount {
+		return apperrors.InsufficientFunds()
+	}
+	return nil
+}
+
+func (s *walletService) Pay(ctx context.Context, payment *models.Payment) (*models.WalletTransaction, error) {
+	txn := &models.WalletTransaction{
+		UserID:    payment.UserID,
+		TxnType:   models.WalletTxnPayment,
+		Amount:    -payment.Amount,
+		PaymentID: &payment.ID,
+	}
+	ok, err := s.walletRepo.Debit(ctx, txn)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, apperrors.InsufficientFunds()
+	}
+	return txn, nil
+}
+
+func (s *walletService) Refund(ctx context.Context, payment *models.Payment) error {
+	debit, err := s.walletRepo.GetTransactionByPayment(ctx, payment.ID, models.WalletTxnPayment)
+	if err != nil {
+		return err
+	}
+	if debit == nil {
+		return apperrors.NotFound("wallet payment")
+	}
+
+	_, err = s.walletRepo.Credit(ctx, &models.WalletTransaction{
+		UserID:    payment.UserID,
+		TxnType:   models.WalletTxnRefund,
+		Amount:    -debit.Amount,
+		PaymentID: &payment.ID,
+	})
+	return err
+}
+
+func (s *walletService) checkUser(ctx context.Context, userID string) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return apperrors.NotFound("user")
+	}
+	return nil
+}
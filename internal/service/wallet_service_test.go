@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	apperrors "github.com/aditya/go-comet/internal/errors"
+	"github.com/aditya/go-comet/internal/models"
+	"github.com/aditya/go-comet/internal/repository/memory"
+)
+
+func TestWalletTopUpAndPay(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	userRepo := memory.NewUserRepository(store)
+	ws := NewWalletService(memory.NewWalletRepository(store), userRepo)
+
+	user := &models.User{Name: "Asha", Phone: "+919900000001"}
+	if err := userRepo.Create(ctx, user); err != nil {
+		t.Fatalf("Create user: %v", err)
+	}
+
+	req := &models.TopUpWalletRequest{Amount: 300, Method: "upi"}
+	first, err := ws.TopUp(ctx, user.ID, req, "topup-1")
+	if err != nil {
+		t.Fatalf("TopUp: %v", err)
+	}
+	retry, err := ws.TopUp(ctx, user.ID, req, "topup-1")
+	if err != nil {
+		t.Fatalf("TopUp retry: %v", err)
+	}
+	if retry.ID != first.ID {
+		t.Errorf("retried top-up = %s, want original %s", retry.ID, first.ID)
+	}
+
+	payment := &models.Payment{ID: "payment-1", UserID: user.ID, Amount: 120}
+	if _, err := ws.Pay(ctx, payment); err != nil {
+		t.Fatalf("Pay: %v", err)
+	}
+	wallet, err := ws.GetWallet(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("GetWallet: %v", err)
+	}
+	if wallet.Balance != 180 {
+		t.Errorf("balance = %v, want 180", wallet.Balance)
+	}
+
+	var appErr *apperrors.APIError
+	_, err = ws.Pay(ctx, &models.Payment{ID: "payment-2", UserID: user.ID, Amount: 500})
+	if !errors.As(err, &appErr) || appErr.Code != apperrors.InsufficientFunds().Code {
+		t.Errorf("overdraw error = %v, want insufficient funds", err)
+	}
+
+	// Refunding twice credits once
+	for i := 0; i < 2; i++ {
+		if err := ws.Refund(ctx, payment); err != nil {
+			t.Fatalf("Refund: %v", err)
+		}
+	}
+	wallet, _ = ws.GetWallet(ctx, user.ID)
+	if wallet.Balance != 300 {
+		t.Errorf("balance after refund = %v, want 300", wallet.Balance)
+	}
+}
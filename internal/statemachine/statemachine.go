@@ -0,0 +1,124 @@
+// Package statemachine describes the statuses a kind of record moves through and
+// notifies hooks of every status change, so new states are added in one place
+// instead of in each service that moves records between them.
+package statemachine
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	apperrors "github.com/aditya/go-comet/internal/errors"
+	"github.com/aditya/go-comet/internal/metrics"
+)
+
+// Transition is one status change of a record
+type Transition struct {
+	Entity string
+	ID     string
+	From   string
+	To     string
+	At     time.Time
+}
+
+// Hook is called after a transition has been persisted. Hooks run synchronously
+// on the request path and must be quick.
+type Hook func(ctx context.Context, t Transition)
+
+// Machine holds the allowed status changes of one kind of record
+type Machine struct {
+	entity      string
+	transitions map[string][]string
+
+	mu    sync.RWMutex
+	hooks []Hook
+}
+
+// New returns a machine for entity allowing the listed transitions. Every state
+// must be a key; terminal states map to no next states.
+func New(entity string, transitions map[string][]string) *Machine {
+	return &Machine{entity: entity, transitions: transitions}
+}
+
+// Entity names the kind of record the machine moves
+func (m *Machine) Entity() string {
+	return m.entity
+}
+
+// Can reports whether a record may move from one status to another
+func (m *Machine) Can(from, to string) bool {
+	for _, next := range m.transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Check returns an invalid transition error unless from may move to to
+func (m *Machine) Check(from, to string) error {
+	if !m.Can(from, to) {
+		return apperrors.InvalidTransition(from, to)
+	}
+	return nil
+}
+
+// Known reports whether state is one of the machine's states
+func (m *Machine) Known(state string) bool {
+	_, ok := m.transitions[state]
+	return ok
+}
+
+// IsTerminal reports whether a record in state can no longer change
+func (m *Machine) IsTerminal(state string) bool {
+	next, ok := m.transitions[state]
+	return ok && len(next) == 0
+}
+
+// OnTransition registers a hook run after every transition of the machine
+func (m *Machine) OnTransition(hook Hook) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks = append(m.hooks, hook)
+}
+
+// Apply checks the transition, persists it with apply and then notifies hooks
+func (m *Machine) Apply(ctx context.Context, id, from, to string, apply func() error) error {
+	if err := m.Check(from, to); err != nil {
+		return err
+	}
+	if err := apply(); err != nil {
+		return err
+	}
+	m.Record(ctx, id, from, to)
+	return nil
+}
+
+// Record notifies hooks of a transition persisted by the caller, for changes
+// made inside a transaction or by an update conditional on the current status
+func (m *Machine) Record(ctx context.Context, id, from, to string) {
+	if !m.Can(from, to) {
+		// The write already happened; flag the gap in the map rather than fail
+		log.Printf("%s %s: unexpected transition %s -> %s", m.entity, id, from, to)
+	}
+
+	m.mu.RLock()
+	hooks := m.hooks
+	m.mu.RUnlock()
+
+	t := Transition{Entity: m.entity, ID: id, From: from, To: to, At: time.Now()}
+	for _, hook := range hooks {
+		hook(ctx, t)
+	}
+}
+
+// CountTransitions is a hook counting transitions by entity and status pair
+func CountTransitions(ctx context.Context, t Transition) {
+	metrics.CounterMap("state_transitions").Add(t.Entity+":"+t.From+"->"+t.To, 1)
+}
+
+// LogTransitions is a hook writing each transition to the application log
+func LogTransitions(ctx context.Context, t Transition) {
+	log.Printf("transition: %s %s %s -> %s", t.Entity, t.ID, t.From, t.To)
+}
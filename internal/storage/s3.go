@@ -0,0 +1,243 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aditya/go-comet/internal/httpclient"
+)
+
+const (
+	sigV4Algorithm  = "AWS4-HMAC-SHA256"
+	sigV4Service    = "s3"
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+)
+
+// S3Config configures an S3-compatible store (AWS S3, GCS via HMAC keys, MinIO)
+type S3Config struct {
+	Endpoint  string // e.g. https://s3.ap-south-1.amazonaws.com or https://storage.googleapis.com
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	PathStyle bool // address the bucket in the path instead of the host
+}
+
+type s3Store struct {
+	cfg    S3Config
+	scheme string
+	host   string
+	client *httpclient.Client
+	now    func() time.Time
+}
+
+// NewS3Store returns an ObjectStore signing requests with AWS Signature Version 4
+func NewS3Store(cfg S3Config) (ObjectStore, error) {
+	if cfg.Endpoint == "" || cfg.Bucket == "" || cfg.AccessKey == "" || cfg.SecretKey == "" {
+		return nil, ErrNotConfigured
+	}
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+
+	return &s3Store{
+		cfg:    cfg,
+		scheme: endpoint.Scheme,
+		host:   endpoint.Host,
+		client: httpclient.New(httpclient.Config{Name: "s3", Timeout: 10 * time.Second, MaxRetries: 2}),
+		now:    time.Now,
+	}, nil
+}
+
+func (s *s3Store) PresignPut(ctx context.Context, key, contentType string, expiry time.Duration) (*PresignedRequest, error) {
+	headers := map[string]string{"Content-Type": contentType}
+	return s.presign(http.MethodPut, key, headers, expiry), nil
+}
+
+func (s *s3Store) PresignGet(ctx context.Context, key string, expiry time.Duration) (*PresignedRequest, error) {
+	return s.presign(http.MethodGet, key, nil, expiry), nil
+}
+
+func (s *s3Store) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	presigned := s.presign(http.MethodHead, key, nil, time.Minute)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, presigned.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrObjectNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("stat %s: unexpected status %d", key, resp.StatusCode)
+	}
+
+	size, _ := strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64)
+	return &ObjectInfo{
+		ContentType: resp.Header.Get("Content-Type"),
+		SizeBytes:   size,
+	}, nil
+}
+
+func (s *s3Store) Put(ctx context.Context, key, contentType string, data []byte) error {
+	presigned, err := s.PresignPut(ctx, key, contentType, time.Minute)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, presigned.URL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	for k, v := range presigned.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("put %s: unexpected status %d", key, resp.StatusCode)
+	}
+	return nil
+}
+
+// presign builds a query-string authenticated URL. Any headers passed are signed
+// and must be sent verbatim by the client.
+func (s *s3Store) presign(method, key string, headers map[string]string, expiry time.Duration) *PresignedRequest {
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	dateStamp := now.Format("20060102")
+	scope := strings.Join([]string{dateStamp, s.cfg.Region, sigV4Service, "aws4_request"}, "/")
+
+	host, path := s.host, "/"+escapePath(key)
+	if s.cfg.PathStyle {
+		path = "/" + escapePath(s.cfg.Bucket) + path
+	} else {
+		host = s.cfg.Bucket + "." + host
+	}
+
+	signed := map[string]string{"host": host}
+	for k, v := range headers {
+		signed[strings.ToLower(k)] = strings.TrimSpace(v)
+	}
+	names := make([]string, 0, len(signed))
+	for k := range signed {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + signed[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	query := map[string]string{
+		"X-Amz-Algorithm":     sigV4Algorithm,
+		"X-Amz-Credential":    s.cfg.AccessKey + "/" + scope,
+		"X-Amz-Date":          amzDate,
+		"X-Amz-Expires":       strconv.Itoa(int(expiry.Seconds())),
+		"X-Amz-SignedHeaders": signedHeaders,
+	}
+	canonicalQuery := canonicalQueryString(query)
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		path,
+		canonicalQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		unsignedPayload,
+	}, "\n")
+
+	stringToSign := strings.Join([]string{
+		sigV4Algorithm,
+		amzDate,
+		scope,
+		hexSHA256(canonicalRequest),
+	}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+s.cfg.SecretKey), dateStamp)
+	signingKey = hmacSHA256(signingKey, s.cfg.Region)
+	signingKey = hmacSHA256(signingKey, sigV4Service)
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	return &PresignedRequest{
+		Method:    method,
+		URL:       fmt.Sprintf("%s://%s%s?%s&X-Amz-Signature=%s", s.scheme, host, path, canonicalQuery, signature),
+		Headers:   headers,
+		ExpiresAt: now.Add(expiry),
+	}
+}
+
+func canonicalQueryString(params map[string]string) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, uriEncode(k, true)+"="+uriEncode(params[k], true))
+	}
+	return strings.Join(parts, "&")
+}
+
+func escapePath(p string) string {
+	return uriEncode(p, false)
+}
+
+// uriEncode implements the SigV4 URI encoding: every byte except unreserved
+// characters is percent-encoded; '/' is kept in paths.
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func hexSHA256(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
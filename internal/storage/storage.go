@@ -0,0 +1,36 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrNotConfigured  = errors.New("object storage not configured")
+	ErrObjectNotFound = errors.New("object not found")
+)
+
+// ObjectInfo describes an uploaded object
+type ObjectInfo struct {
+	ContentType string
+	SizeBytes   int64
+}
+
+// PresignedRequest is a time-limited request the client performs directly against storage
+type PresignedRequest struct {
+	Method    string            `json:"method"`
+	URL       string            `json:"url"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+// ObjectStore issues pre-signed URLs so clients upload and download without
+// proxying file bytes through the API servers.
+type ObjectStore interface {
+	PresignPut(ctx context.Context, key, contentType string, expiry time.Duration) (*PresignedRequest, error)
+	PresignGet(ctx context.Context, key string, expiry time.Duration) (*PresignedRequest, error)
+	Stat(ctx context.Context, key string) (*ObjectInfo, error)
+	// Put stores a file generated server-side, e.g. an export
+	Put(ctx context.Context, key, contentType string, data []byte) error
+}
@@ -0,0 +1,46 @@
+// Package tenant carries the brand a request is served under through its
+// context, so repositories and services can scope what they read and write.
+package tenant
+
+import (
+	"context"
+
+	"github.com/aditya/go-comet/internal/models"
+)
+
+type codeContextKey struct{}
+
+type tenantContextKey struct{}
+
+// With returns a copy of ctx scoped to the resolved tenant
+func With(ctx context.Context, t *models.Tenant) context.Context {
+	ctx = context.WithValue(ctx, tenantContextKey{}, t)
+	return WithCode(ctx, t.Code)
+}
+
+// WithCode returns a copy of ctx scoped to a tenant known only by code, e.g. work
+// done on behalf of a stored ride
+func WithCode(ctx context.Context, code string) context.Context {
+	return context.WithValue(ctx, codeContextKey{}, code)
+}
+
+// FromContext returns the resolved tenant, or nil if the request wasn't resolved
+func FromContext(ctx context.Context) *models.Tenant {
+	t, _ := ctx.Value(tenantContextKey{}).(*models.Tenant)
+	return t
+}
+
+// Code returns the tenant the context is scoped to, or "" for unscoped work such
+// as admin requests and background jobs, which see every tenant
+func Code(ctx context.Context) string {
+	code, _ := ctx.Value(codeContextKey{}).(string)
+	return code
+}
+
+// CodeOrDefault returns the tenant new records belong to
+func CodeOrDefault(ctx context.Context) string {
+	if code := Code(ctx); code != "" {
+		return code
+	}
+	return models.DefaultTenant
+}
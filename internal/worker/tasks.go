@@ -0,0 +1,46 @@
+package worker
+
+import (
+	"context"
+	"sync"
+)
+
+// Tasks runs one-off work started by a request but outliving it, such as the
+// first dispatch round of a new ride, so shutdown can wait for it to finish
+type Tasks struct {
+	mu       sync.Mutex
+	wg       sync.WaitGroup
+	draining bool
+}
+
+func NewTasks() *Tasks {
+	return &Tasks{}
+}
+
+// Go runs fn in the background with ctx's values but not its cancellation. It
+// reports whether fn was started: once the instance is draining it isn't, and
+// the work must be left for a periodic job on another instance to pick up.
+func (t *Tasks) Go(ctx context.Context, fn func(ctx context.Context)) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.draining {
+		return false
+	}
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		fn(context.WithoutCancel(ctx))
+	}()
+	return true
+}
+
+// Drain stops new tasks from starting and waits for running ones, giving up
+// when ctx is done
+func (t *Tasks) Drain(ctx context.Context) error {
+	t.mu.Lock()
+	t.draining = true
+	t.mu.Unlock()
+
+	return wait(ctx, &t.wg)
+}
@@ -0,0 +1,185 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// JobFunc is a unit of background work executed on every tick
+type JobFunc func(ctx context.Context) error
+
+type job struct {
+	name     string
+	interval time.Duration
+	run      JobFunc
+}
+
+// Leaser hands each job to one instance at a time
+type Leaser interface {
+	Acquire(ctx context.Context, name, holder string, ttl time.Duration) (bool, error)
+	Release(ctx context.Context, name, holder string) error
+}
+
+// Runner executes registered jobs periodically until its context is cancelled
+type Runner struct {
+	jobs []job
+	wg   sync.WaitGroup
+
+	leases Leaser
+	holder string
+	grace  time.Duration
+}
+
+func NewRunner() *Runner {
+	return &Runner{}
+}
+
+// Register adds a job to run every interval. Jobs with a non-positive interval are skipped.
+func (r *Runner) Register(name string, interval time.Duration, run JobFunc) {
+	if interval <= 0 {
+		log.Printf("worker %s disabled (interval %s)", name, interval)
+		return
+	}
+	r.jobs = append(r.jobs, job{name: name, interval: interval, run: run})
+}
+
+// UseLeases makes every job run on one instance at a time: the holder of its
+// lease. A lease lasts the job's interval plus grace and is renewed on every tick
+// and while a run is in progress, so a lost lease is only taken over after the
+// holder misses a tick. Must be called before Start.
+func (r *Runner) UseLeases(leases Leaser, holder string, grace time.Duration) {
+	r.leases = leases
+	r.holder = holder
+	r.grace = grace
+}
+
+// Start launches one goroutine per job. Cancelling ctx stops the loops, but a run
+// already in progress finishes with an uncancelled context: jobs save progress
+// as they go, so the next tick, here or on another instance, picks up the rest.
+func (r *Runner) Start(ctx context.Context) {
+	for _, j := range r.jobs {
+		r.wg.Add(1)
+		go r.loop(ctx, j)
+	}
+}
+
+// Wait blocks until all job loops have exited
+func (r *Runner) Wait() {
+	r.wg.Wait()
+}
+
+// Drain waits for the job loops to exit after their context is cancelled, giving
+// up when ctx is done
+func (r *Runner) Drain(ctx context.Context) error {
+	return wait(ctx, &r.wg)
+}
+
+func (r *Runner) loop(ctx context.Context, j job) {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	log.Printf("worker %s started (every %s)", j.name, j.interval)
+	leading := false
+	for {
+		select {
+		case <-ctx.Done():
+			if r.leases != nil {
+				r.release(j)
+			}
+			log.Printf("worker %s stopped", j.name)
+			return
+		case <-ticker.C:
+			if r.leases != nil {
+				ok, err := r.leases.Acquire(ctx, j.name, r.holder, j.interval+r.grace)
+				if err != nil {
+					log.Printf("worker %s skipped: lease check failed: %v", j.name, err)
+					continue
+				}
+				if ok != leading {
+					if ok {
+						log.Printf("worker %s now runs on this instance", j.name)
+					} else {
+						log.Printf("worker %s now runs on another instance", j.name)
+					}
+					leading = ok
+				}
+				if !ok {
+					continue
+				}
+			}
+			if err := r.run(ctx, j); err != nil {
+				log.Printf("worker %s failed: %v", j.name, err)
+			}
+		}
+	}
+}
+
+// run executes one tick of j. With leases, the lease is renewed while the run is
+// in progress and the run's context is cancelled if it can't be.
+func (r *Runner) run(ctx context.Context, j job) error {
+	runCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancel()
+
+	if r.leases != nil {
+		go r.heartbeat(runCtx, cancel, j)
+	}
+	return j.run(runCtx)
+}
+
+func (r *Runner) heartbeat(ctx context.Context, cancel context.CancelFunc, j job) {
+	ttl := j.interval + r.grace
+	ticker := time.NewTicker(ttl / 3)
+	defer ticker.Stop()
+
+	held := time.Now().Add(ttl)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			ok, err := r.leases.Acquire(ctx, j.name, r.holder, ttl)
+			switch {
+			case err == nil && ok:
+				held = time.Now().Add(ttl)
+			case err == nil:
+				log.Printf("worker %s lost its lease; stopping the run", j.name)
+				cancel()
+				return
+			case time.Until(held) < ttl/3:
+				log.Printf("worker %s can't renew its lease (%v); stopping the run", j.name, err)
+				cancel()
+				return
+			}
+		}
+	}
+}
+
+// release hands j's lease back so another instance can take over without waiting
+// for it to expire
+func (r *Runner) release(j job) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := r.leases.Release(ctx, j.name, r.holder); err != nil {
+		log.Printf("worker %s failed to release its lease: %v", j.name, err)
+	}
+}
+
+// wait blocks until wg is done or ctx is
+func wait(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS ride_offers_audit ON ride_offers;
+DROP TRIGGER IF EXISTS trips_audit ON trips;
+DROP TRIGGER IF EXISTS rides_audit ON rides;
+DROP FUNCTION IF EXISTS record_ride_audit();
+DROP TABLE IF EXISTS audit_log;
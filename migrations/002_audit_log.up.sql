@@ -0,0 +1,40 @@
+-- Audit log capturing every insert/update of ride-scoped rows.
+-- Populated by triggers so that writes made inside raw transactions
+-- (e.g. AcceptRide) are recorded as well.
+CREATE TABLE audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    entity_type VARCHAR(30) NOT NULL,
+    entity_id UUID NOT NULL,
+    ride_id UUID NOT NULL,
+    operation VARCHAR(10) NOT NULL,
+    snapshot JSONB NOT NULL,
+    recorded_at TIMESTAMP WITH TIME ZONE DEFAULT clock_timestamp()
+);
+
+CREATE INDEX idx_audit_log_ride_recorded ON audit_log(ride_id, recorded_at);
+
+CREATE OR REPLACE FUNCTION record_ride_audit() RETURNS TRIGGER AS $$
+DECLARE
+    audit_ride_id UUID;
+BEGIN
+    IF TG_TABLE_NAME = 'rides' THEN
+        audit_ride_id := NEW.id;
+    ELSE
+        audit_ride_id := NEW.ride_id;
+    END IF;
+
+    INSERT INTO audit_log (entity_type, entity_id, ride_id, operation, snapshot)
+    VALUES (TG_TABLE_NAME, NEW.id, audit_ride_id, TG_OP, row_to_json(NEW)::jsonb);
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER rides_audit AFTER INSERT OR UPDATE ON rides
+    FOR EACH ROW EXECUTE FUNCTION record_ride_audit();
+
+CREATE TRIGGER trips_audit AFTER INSERT OR UPDATE ON trips
+    FOR EACH ROW EXECUTE FUNCTION record_ride_audit();
+
+CREATE TRIGGER ride_offers_audit AFTER INSERT OR UPDATE ON ride_offers
+    FOR EACH ROW EXECUTE FUNCTION record_ride_audit();
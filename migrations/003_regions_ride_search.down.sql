@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_rides_address_fts;
+DROP INDEX IF EXISTS idx_rides_surge;
+DROP INDEX IF EXISTS idx_rides_payment_method;
+DROP INDEX IF EXISTS idx_rides_region_created;
+ALTER TABLE rides DROP COLUMN IF EXISTS region_code;
+DROP TABLE IF EXISTS regions;
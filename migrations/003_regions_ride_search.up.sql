@@ -0,0 +1,27 @@
+-- Operating regions (cities). Bounds are a simple lat/lng bounding box.
+CREATE TABLE regions (
+    code VARCHAR(30) PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    min_lat DECIMAL(10, 8) NOT NULL,
+    min_lng DECIMAL(11, 8) NOT NULL,
+    max_lat DECIMAL(10, 8) NOT NULL,
+    max_lng DECIMAL(11, 8) NOT NULL,
+    active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+INSERT INTO regions (code, name, min_lat, min_lng, max_lat, max_lng) VALUES
+    ('blr', 'Bengaluru', 12.73400000, 77.37900000, 13.17300000, 77.88200000),
+    ('mum', 'Mumbai', 18.89200000, 72.77500000, 19.27000000, 72.98600000),
+    ('del', 'Delhi NCR', 28.40400000, 76.83900000, 28.88300000, 77.34600000);
+
+ALTER TABLE rides ADD COLUMN region_code VARCHAR(30);
+
+-- Admin ride search
+CREATE INDEX idx_rides_region_created ON rides(region_code, created_at DESC);
+CREATE INDEX idx_rides_payment_method ON rides(payment_method);
+CREATE INDEX idx_rides_surge ON rides(surge_multiplier);
+CREATE INDEX idx_rides_address_fts ON rides USING GIN (
+    to_tsvector('simple', coalesce(pickup_address, '') || ' ' || coalesce(dropoff_address, ''))
+);
@@ -0,0 +1,18 @@
+-- Client uploads stored in object storage (documents, photos)
+CREATE TABLE uploads (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    owner_type VARCHAR(20) NOT NULL,
+    owner_id UUID NOT NULL,
+    purpose VARCHAR(30) NOT NULL,
+    object_key TEXT NOT NULL UNIQUE,
+    content_type VARCHAR(100) NOT NULL,
+    max_size_bytes BIGINT NOT NULL,
+    size_bytes BIGINT,
+    status VARCHAR(20) DEFAULT 'pending',
+    reject_reason TEXT,
+    verified_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_uploads_owner ON uploads(owner_type, owner_id, purpose);
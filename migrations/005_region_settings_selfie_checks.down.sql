@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS driver_selfie_checks;
+ALTER TABLE regions DROP COLUMN IF EXISTS settings;
@@ -0,0 +1,15 @@
+-- Per-region feature configuration
+ALTER TABLE regions ADD COLUMN settings JSONB NOT NULL DEFAULT '{}';
+
+-- Driver identity checks performed before going online
+CREATE TABLE driver_selfie_checks (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    driver_id UUID NOT NULL REFERENCES drivers(id),
+    upload_id UUID NOT NULL REFERENCES uploads(id),
+    status VARCHAR(20) NOT NULL,
+    provider VARCHAR(50),
+    match_score DECIMAL(5, 4),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_selfie_checks_driver_created ON driver_selfie_checks(driver_id, created_at DESC);
@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_trips_mileage_status;
+ALTER TABLE trips
+    DROP COLUMN IF EXISTS mileage_review_note,
+    DROP COLUMN IF EXISTS mileage_discrepancy_km,
+    DROP COLUMN IF EXISTS mileage_status,
+    DROP COLUMN IF EXISTS end_odometer_upload_id,
+    DROP COLUMN IF EXISTS end_odometer_km,
+    DROP COLUMN IF EXISTS start_odometer_upload_id,
+    DROP COLUMN IF EXISTS start_odometer_km,
+    DROP COLUMN IF EXISTS gps_distance_km;
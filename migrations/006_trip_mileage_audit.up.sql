@@ -0,0 +1,12 @@
+-- Odometer evidence and GPS mileage audit for trips
+ALTER TABLE trips
+    ADD COLUMN gps_distance_km DECIMAL(10, 3) NOT NULL DEFAULT 0,
+    ADD COLUMN start_odometer_km DECIMAL(10, 1),
+    ADD COLUMN start_odometer_upload_id UUID REFERENCES uploads(id),
+    ADD COLUMN end_odometer_km DECIMAL(10, 1),
+    ADD COLUMN end_odometer_upload_id UUID REFERENCES uploads(id),
+    ADD COLUMN mileage_status VARCHAR(20),
+    ADD COLUMN mileage_discrepancy_km DECIMAL(10, 2),
+    ADD COLUMN mileage_review_note TEXT;
+
+CREATE INDEX idx_trips_mileage_status ON trips(mileage_status) WHERE mileage_status IS NOT NULL;
@@ -0,0 +1,7 @@
+-- Drivers a rider has favorited after a completed trip
+CREATE TABLE favorite_drivers (
+    user_id UUID NOT NULL REFERENCES users(id),
+    driver_id UUID NOT NULL REFERENCES drivers(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (user_id, driver_id)
+);
@@ -0,0 +1,8 @@
+ALTER TABLE drivers
+    DROP COLUMN IF EXISTS verified_at,
+    DROP COLUMN IF EXISTS gender;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS preferred_driver_gender,
+    DROP COLUMN IF EXISTS safety_mode,
+    DROP COLUMN IF EXISTS gender;
@@ -0,0 +1,9 @@
+-- Rider safety mode and the driver attributes it filters on
+ALTER TABLE users
+    ADD COLUMN gender VARCHAR(10),
+    ADD COLUMN safety_mode BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN preferred_driver_gender VARCHAR(10);
+
+ALTER TABLE drivers
+    ADD COLUMN gender VARCHAR(10),
+    ADD COLUMN verified_at TIMESTAMP WITH TIME ZONE;
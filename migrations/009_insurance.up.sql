@@ -0,0 +1,26 @@
+-- Per-trip insurance policies issued by the insurer at trip start
+CREATE TABLE trip_insurance (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    trip_id UUID NOT NULL UNIQUE REFERENCES trips(id),
+    provider VARCHAR(50) NOT NULL,
+    policy_number VARCHAR(100) NOT NULL,
+    coverage JSONB NOT NULL DEFAULT '{}',
+    issued_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Insurance claims raised by riders or drivers against a trip
+CREATE TABLE insurance_claims (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    trip_id UUID NOT NULL REFERENCES trips(id),
+    claimant_type VARCHAR(20) NOT NULL,
+    claimant_id UUID NOT NULL,
+    incident_type VARCHAR(30) NOT NULL,
+    incident_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    description TEXT NOT NULL,
+    status VARCHAR(20) DEFAULT 'received',
+    provider_reference VARCHAR(100),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_insurance_claims_trip ON insurance_claims(trip_id);
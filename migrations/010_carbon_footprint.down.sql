@@ -0,0 +1,2 @@
+ALTER TABLE payments DROP COLUMN IF EXISTS carbon_offset_amount;
+ALTER TABLE trips DROP COLUMN IF EXISTS co2_grams;
@@ -0,0 +1,3 @@
+-- Estimated CO2 emitted per trip, and optional carbon offset donations on payments
+ALTER TABLE trips ADD COLUMN co2_grams DECIMAL(12, 1);
+ALTER TABLE payments ADD COLUMN carbon_offset_amount DECIMAL(10, 2) NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+ALTER TABLE trips DROP COLUMN IF EXISTS ev_discount;
+ALTER TABLE drivers DROP COLUMN IF EXISTS is_ev;
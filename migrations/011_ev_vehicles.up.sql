@@ -0,0 +1,3 @@
+-- Electric vehicles and the per-trip EV discount applied to their fares
+ALTER TABLE drivers ADD COLUMN is_ev BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE trips ADD COLUMN ev_discount DECIMAL(10, 2);
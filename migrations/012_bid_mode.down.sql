@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_rides_bid_matching;
+
+ALTER TABLE ride_offers
+    DROP COLUMN IF EXISTS countered_at,
+    DROP COLUMN IF EXISTS offered_fare;
+
+ALTER TABLE rides
+    DROP COLUMN IF EXISTS agreed_fare,
+    DROP COLUMN IF EXISTS proposed_fare,
+    DROP COLUMN IF EXISTS pricing_mode;
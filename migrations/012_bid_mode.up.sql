@@ -0,0 +1,11 @@
+-- Bid mode: riders propose a fare, drivers counter-offer, rider picks one
+ALTER TABLE rides
+    ADD COLUMN pricing_mode VARCHAR(20) NOT NULL DEFAULT 'standard',
+    ADD COLUMN proposed_fare DECIMAL(10, 2),
+    ADD COLUMN agreed_fare DECIMAL(10, 2);
+
+ALTER TABLE ride_offers
+    ADD COLUMN offered_fare DECIMAL(10, 2),
+    ADD COLUMN countered_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX idx_rides_bid_matching ON rides(created_at) WHERE pricing_mode = 'bid' AND status = 'matching';
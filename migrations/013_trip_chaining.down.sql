@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_ride_offers_queued_driver;
+
+ALTER TABLE ride_offers DROP COLUMN IF EXISTS chained;
@@ -0,0 +1,5 @@
+-- Trip chaining: drivers finishing a trip can be offered a next ride near their dropoff
+ALTER TABLE ride_offers ADD COLUMN chained BOOLEAN NOT NULL DEFAULT false;
+
+-- A driver holds at most one queued next ride
+CREATE UNIQUE INDEX idx_ride_offers_queued_driver ON ride_offers(driver_id) WHERE status = 'queued';
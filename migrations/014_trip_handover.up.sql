@@ -0,0 +1,22 @@
+-- Trip handover: a trip interrupted by a breakdown is continued by a rescue
+-- driver, each driver's leg recorded as a segment
+CREATE TABLE trip_segments (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    trip_id UUID NOT NULL REFERENCES trips(id),
+    driver_id UUID NOT NULL REFERENCES drivers(id),
+    sequence INT NOT NULL,
+    start_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    end_time TIMESTAMP WITH TIME ZONE,
+    start_lat DECIMAL(10, 8) NOT NULL,
+    start_lng DECIMAL(11, 8) NOT NULL,
+    end_lat DECIMAL(10, 8),
+    end_lng DECIMAL(11, 8),
+    distance_km DECIMAL(10, 2),
+    duration_mins INT,
+    fare_share DECIMAL(10, 2),
+    end_reason TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (trip_id, sequence)
+);
+
+CREATE INDEX idx_trip_segments_driver ON trip_segments(driver_id);
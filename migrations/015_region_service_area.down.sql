@@ -0,0 +1 @@
+ALTER TABLE regions DROP COLUMN IF EXISTS service_area;
@@ -0,0 +1,3 @@
+-- Service areas: an optional polygon per region that narrows its bounding box
+-- to the area actually served
+ALTER TABLE regions ADD COLUMN service_area JSONB;
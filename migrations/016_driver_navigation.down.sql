@@ -0,0 +1,6 @@
+ALTER TABLE trips DROP COLUMN IF EXISTS waiting_fee;
+
+ALTER TABLE rides
+    DROP COLUMN IF EXISTS arrived_at,
+    DROP COLUMN IF EXISTS en_route_at,
+    DROP COLUMN IF EXISTS navigation_status;
@@ -0,0 +1,8 @@
+-- Driver progress towards pickup while a ride is assigned; arrived_at starts the
+-- waiting clock billed once the trip starts
+ALTER TABLE rides
+    ADD COLUMN navigation_status VARCHAR(30),
+    ADD COLUMN en_route_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN arrived_at TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE trips ADD COLUMN waiting_fee DECIMAL(10, 2);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_trips_driver_end_time;
+
+ALTER TABLE drivers DROP COLUMN IF EXISTS daily_earnings_goal;
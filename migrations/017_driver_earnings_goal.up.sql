@@ -0,0 +1,3 @@
+ALTER TABLE drivers ADD COLUMN daily_earnings_goal DECIMAL(10, 2);
+
+CREATE INDEX idx_trips_driver_end_time ON trips(driver_id, end_time) WHERE status = 'completed';
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_payments_driver_created;
+
+ALTER TABLE payments
+    DROP COLUMN IF EXISTS driver_earnings,
+    DROP COLUMN IF EXISTS commission_amount,
+    DROP COLUMN IF EXISTS commission_percent;
+
+DROP TABLE IF EXISTS driver_commission_overrides;
@@ -0,0 +1,22 @@
+-- Per-driver commission overrides (new-driver promos, fleet-partner rates)
+-- with effective dates; drivers without one pay the platform default
+CREATE TABLE driver_commission_overrides (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    driver_id UUID NOT NULL REFERENCES drivers(id),
+    kind VARCHAR(20) NOT NULL,
+    commission_percent DECIMAL(5, 2) NOT NULL,
+    effective_from TIMESTAMP WITH TIME ZONE NOT NULL,
+    effective_to TIMESTAMP WITH TIME ZONE,
+    note TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_commission_overrides_driver ON driver_commission_overrides(driver_id, effective_from);
+
+-- Split recorded on each payment at the rate in effect when it was taken
+ALTER TABLE payments
+    ADD COLUMN commission_percent DECIMAL(5, 2),
+    ADD COLUMN commission_amount DECIMAL(10, 2),
+    ADD COLUMN driver_earnings DECIMAL(10, 2);
+
+CREATE INDEX idx_payments_driver_created ON payments(driver_id, created_at) WHERE status = 'completed';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS driver_ledger_entries;
+DROP TABLE IF EXISTS driver_deduction_schedules;
@@ -0,0 +1,34 @@
+-- Recurring deductions (e.g. daily rent on fleet or platform-leased vehicles)
+-- charged against a driver's balance
+CREATE TABLE driver_deduction_schedules (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    driver_id UUID NOT NULL REFERENCES drivers(id),
+    kind VARCHAR(20) NOT NULL,
+    amount DECIMAL(10, 2) NOT NULL,
+    frequency VARCHAR(10) NOT NULL,
+    start_date DATE NOT NULL,
+    end_date DATE,
+    description TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'active',
+    last_charged_on DATE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_deduction_schedules_driver ON driver_deduction_schedules(driver_id);
+CREATE INDEX idx_deduction_schedules_active ON driver_deduction_schedules(start_date) WHERE status = 'active';
+
+-- Driver balance ledger; amounts are signed (deductions are negative)
+CREATE TABLE driver_ledger_entries (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    driver_id UUID NOT NULL REFERENCES drivers(id),
+    entry_type VARCHAR(20) NOT NULL,
+    amount DECIMAL(10, 2) NOT NULL,
+    description TEXT,
+    schedule_id UUID REFERENCES driver_deduction_schedules(id),
+    period_date DATE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (schedule_id, period_date)
+);
+
+CREATE INDEX idx_driver_ledger_driver_created ON driver_ledger_entries(driver_id, created_at);
@@ -0,0 +1 @@
+ALTER TABLE rides DROP COLUMN IF EXISTS rider_note;
@@ -0,0 +1,2 @@
+-- Optional rider note for the driver ("gate code 1234, call on arrival")
+ALTER TABLE rides ADD COLUMN rider_note TEXT;
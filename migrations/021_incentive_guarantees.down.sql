@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_driver_ledger_trip_incentive;
+
+ALTER TABLE driver_ledger_entries
+    DROP COLUMN IF EXISTS incentive_id,
+    DROP COLUMN IF EXISTS trip_id;
+
+DROP TABLE IF EXISTS incentive_guarantees;
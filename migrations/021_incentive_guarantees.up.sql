@@ -0,0 +1,24 @@
+-- Guaranteed minimum driver earnings for trips picked up in a zone during a
+-- time window (e.g. at least 150 for night-time airport pickups)
+CREATE TABLE incentive_guarantees (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(100) NOT NULL,
+    region_code VARCHAR(20),
+    vehicle_type VARCHAR(20),
+    center_lat DECIMAL(10, 8),
+    center_lng DECIMAL(11, 8),
+    radius_km DECIMAL(6, 2),
+    start_minute INT NOT NULL,
+    end_minute INT NOT NULL,
+    min_earnings DECIMAL(10, 2) NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Top-ups are ledger entries against the trip they guarantee
+ALTER TABLE driver_ledger_entries
+    ADD COLUMN trip_id UUID REFERENCES trips(id),
+    ADD COLUMN incentive_id UUID REFERENCES incentive_guarantees(id);
+
+CREATE UNIQUE INDEX idx_driver_ledger_trip_incentive ON driver_ledger_entries(trip_id) WHERE entry_type = 'incentive';
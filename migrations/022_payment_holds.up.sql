@@ -0,0 +1,16 @@
+-- Card pre-authorization holds placed at booking and settled at trip end
+CREATE TABLE payment_holds (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    ride_id UUID NOT NULL UNIQUE REFERENCES rides(id),
+    user_id UUID NOT NULL REFERENCES users(id),
+    amount DECIMAL(10, 2) NOT NULL,
+    currency VARCHAR(3) DEFAULT 'INR',
+    status VARCHAR(20) NOT NULL,
+    psp_authorization_id VARCHAR(100),
+    captured_amount DECIMAL(10, 2),
+    payment_id UUID REFERENCES payments(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_payment_holds_authorized ON payment_holds(created_at) WHERE status = 'authorized';
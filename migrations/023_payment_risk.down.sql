@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS risk_events;
+DROP TABLE IF EXISTS risk_overrides;
+ALTER TABLE rides DROP COLUMN IF EXISTS card_fingerprint;
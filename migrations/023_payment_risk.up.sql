@@ -0,0 +1,32 @@
+-- Card used for a ride, as the PSP's fingerprint, to tell new cards from known ones
+ALTER TABLE rides ADD COLUMN card_fingerprint VARCHAR(64);
+
+CREATE INDEX idx_rides_user_card ON rides(user_id, card_fingerprint) WHERE card_fingerprint IS NOT NULL;
+
+-- Admin exemptions from payment risk rules, e.g. after support verifies a rider
+CREATE TABLE risk_overrides (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id),
+    reason TEXT NOT NULL,
+    granted_by VARCHAR(100) NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    revoked_by VARCHAR(100),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_risk_overrides_user ON risk_overrides(user_id);
+
+-- Every booking a risk rule stopped or an override let through
+CREATE TABLE risk_events (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id),
+    rule VARCHAR(30) NOT NULL,
+    outcome VARCHAR(20) NOT NULL,
+    payment_method VARCHAR(20) NOT NULL,
+    fare DECIMAL(10, 2) NOT NULL,
+    override_id UUID REFERENCES risk_overrides(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_risk_events_user_created ON risk_events(user_id, created_at);
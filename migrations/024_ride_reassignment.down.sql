@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS ride_fare_adjustments;
+ALTER TABLE rides DROP COLUMN IF EXISTS reassigned_at;
+ALTER TABLE rides DROP COLUMN IF EXISTS reassigned_from_driver_id;
+ALTER TABLE rides DROP COLUMN IF EXISTS original_pickup_eta_mins;
+ALTER TABLE rides DROP COLUMN IF EXISTS pickup_eta_mins;
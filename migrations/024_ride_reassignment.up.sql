@@ -0,0 +1,23 @@
+-- Pickup ETA promised when a driver accepts, and the first promise kept across
+-- reassignments so a slower replacement can be compared with it
+ALTER TABLE rides ADD COLUMN pickup_eta_mins INTEGER;
+ALTER TABLE rides ADD COLUMN original_pickup_eta_mins INTEGER;
+ALTER TABLE rides ADD COLUMN reassigned_from_driver_id UUID REFERENCES drivers(id);
+ALTER TABLE rides ADD COLUMN reassigned_at TIMESTAMP WITH TIME ZONE;
+
+-- Fare changes made for the rider after booking, e.g. surge waived when a
+-- reassignment made them wait longer
+CREATE TABLE ride_fare_adjustments (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    ride_id UUID NOT NULL REFERENCES rides(id),
+    reason VARCHAR(30) NOT NULL,
+    original_surge DECIMAL(3, 2) NOT NULL,
+    adjusted_surge DECIMAL(3, 2) NOT NULL,
+    original_fare DECIMAL(10, 2),
+    adjusted_fare DECIMAL(10, 2),
+    original_eta_mins INTEGER,
+    replacement_eta_mins INTEGER,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_ride_fare_adjustments_ride ON ride_fare_adjustments(ride_id);
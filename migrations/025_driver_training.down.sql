@@ -0,0 +1,3 @@
+ALTER TABLE incentive_guarantees DROP COLUMN IF EXISTS required_training;
+ALTER TABLE rides DROP COLUMN IF EXISTS product;
+DROP TABLE IF EXISTS driver_trainings;
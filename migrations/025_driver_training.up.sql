@@ -0,0 +1,16 @@
+-- Training modules a driver has completed, as reported by the training module
+CREATE TABLE driver_trainings (
+    driver_id UUID NOT NULL REFERENCES drivers(id),
+    module VARCHAR(30) NOT NULL,
+    score DECIMAL(5, 2),
+    completed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (driver_id, module)
+);
+
+CREATE INDEX idx_driver_trainings_module ON driver_trainings(module);
+
+-- Product a ride was booked as when it isn't just its vehicle type
+ALTER TABLE rides ADD COLUMN product VARCHAR(20);
+
+-- Guarantees limited to drivers who completed a training module
+ALTER TABLE incentive_guarantees ADD COLUMN required_training VARCHAR(30);
@@ -0,0 +1,2 @@
+ALTER TABLE ride_offers DROP COLUMN IF EXISTS timeout_reasons;
+ALTER TABLE ride_offers DROP COLUMN IF EXISTS timeout_seconds;
@@ -0,0 +1,3 @@
+-- How long the driver was given to respond and which rules shaped it
+ALTER TABLE ride_offers ADD COLUMN timeout_seconds INTEGER;
+ALTER TABLE ride_offers ADD COLUMN timeout_reasons VARCHAR(100);
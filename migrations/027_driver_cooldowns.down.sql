@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_trips_driver_end_time;
+ALTER TABLE drivers DROP COLUMN IF EXISTS cooldown_reason;
+ALTER TABLE drivers DROP COLUMN IF EXISTS cooldown_until;
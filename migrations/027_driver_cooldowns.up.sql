@@ -0,0 +1,5 @@
+-- Mandatory rest after too many back-to-back trips; no offers until it passes
+ALTER TABLE drivers ADD COLUMN cooldown_until TIMESTAMP WITH TIME ZONE;
+ALTER TABLE drivers ADD COLUMN cooldown_reason VARCHAR(30);
+
+CREATE INDEX idx_trips_driver_end_time ON trips(driver_id, end_time DESC) WHERE status = 'completed';
@@ -0,0 +1,4 @@
+ALTER TABLE rides DROP COLUMN IF EXISTS pickup_spot_source;
+ALTER TABLE rides DROP COLUMN IF EXISTS pickup_spot_name;
+ALTER TABLE rides DROP COLUMN IF EXISTS pickup_spot_id;
+DROP TABLE IF EXISTS pickup_spots;
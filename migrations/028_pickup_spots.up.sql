@@ -0,0 +1,18 @@
+-- Curated pickup points such as venue entrances and landmarks riders are steered to
+CREATE TABLE pickup_spots (
+    id UUID PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    lat DECIMAL(10, 8) NOT NULL,
+    lng DECIMAL(11, 8) NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_pickup_spots_location ON pickup_spots(lat, lng) WHERE active = TRUE;
+
+-- Pickup point the rider picked from the suggestions
+ALTER TABLE rides ADD COLUMN pickup_spot_id UUID REFERENCES pickup_spots(id);
+ALTER TABLE rides ADD COLUMN pickup_spot_name VARCHAR(100);
+ALTER TABLE rides ADD COLUMN pickup_spot_source VARCHAR(20);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_pickup_spots_venue;
+ALTER TABLE pickup_spots DROP COLUMN IF EXISTS venue_id;
+DROP TABLE IF EXISTS venues;
@@ -0,0 +1,13 @@
+-- Venues such as airport terminals and malls where riders must be picked up at a
+-- named point inside the venue polygon
+CREATE TABLE venues (
+    id UUID PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    polygon JSONB NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE pickup_spots ADD COLUMN venue_id UUID REFERENCES venues(id);
+CREATE INDEX idx_pickup_spots_venue ON pickup_spots(venue_id) WHERE venue_id IS NOT NULL;
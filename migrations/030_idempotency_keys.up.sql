@@ -0,0 +1,14 @@
+-- Idempotency-Key responses, used instead of Redis while it is unreachable. A row
+-- without a status code is a request still in flight.
+CREATE TABLE idempotency_keys (
+    key VARCHAR(255) PRIMARY KEY,
+    body_hash VARCHAR(64) NOT NULL,
+    status_code INT,
+    content_type VARCHAR(100),
+    body BYTEA,
+    locked_until TIMESTAMP WITH TIME ZONE NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_idempotency_keys_expires ON idempotency_keys(expires_at);
@@ -0,0 +1,15 @@
+DROP INDEX IF EXISTS idx_rides_tenant;
+DROP INDEX IF EXISTS idx_drivers_tenant;
+
+ALTER TABLE drivers DROP CONSTRAINT drivers_tenant_phone_key;
+ALTER TABLE drivers ADD CONSTRAINT drivers_phone_key UNIQUE (phone);
+ALTER TABLE users DROP CONSTRAINT users_tenant_phone_key;
+ALTER TABLE users ADD CONSTRAINT users_phone_key UNIQUE (phone);
+
+ALTER TABLE payment_holds DROP COLUMN tenant_code;
+ALTER TABLE regions DROP COLUMN tenant_code;
+ALTER TABLE rides DROP COLUMN tenant_code;
+ALTER TABLE drivers DROP COLUMN tenant_code;
+ALTER TABLE users DROP COLUMN tenant_code;
+
+DROP TABLE IF EXISTS tenants;
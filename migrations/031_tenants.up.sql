@@ -0,0 +1,33 @@
+-- White-label brands served from one deployment. Requests are resolved to a
+-- tenant by API key or host; anything else belongs to the default tenant.
+CREATE TABLE tenants (
+    code VARCHAR(50) PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    -- SHA-256 of the tenant's API key; the key itself is only shown when issued
+    api_key_hash VARCHAR(64) UNIQUE,
+    hosts TEXT[] NOT NULL DEFAULT '{}',
+    branding JSONB NOT NULL DEFAULT '{}',
+    fare_configs JSONB NOT NULL DEFAULT '{}',
+    psp_url VARCHAR(255),
+    psp_api_key VARCHAR(255),
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO tenants (code, name) VALUES ('default', 'Default');
+
+ALTER TABLE users ADD COLUMN tenant_code VARCHAR(50) NOT NULL DEFAULT 'default' REFERENCES tenants(code);
+ALTER TABLE drivers ADD COLUMN tenant_code VARCHAR(50) NOT NULL DEFAULT 'default' REFERENCES tenants(code);
+ALTER TABLE rides ADD COLUMN tenant_code VARCHAR(50) NOT NULL DEFAULT 'default' REFERENCES tenants(code);
+ALTER TABLE regions ADD COLUMN tenant_code VARCHAR(50) NOT NULL DEFAULT 'default' REFERENCES tenants(code);
+ALTER TABLE payment_holds ADD COLUMN tenant_code VARCHAR(50) NOT NULL DEFAULT 'default' REFERENCES tenants(code);
+
+-- The same phone number may sign up with more than one brand
+ALTER TABLE users DROP CONSTRAINT users_phone_key;
+ALTER TABLE users ADD CONSTRAINT users_tenant_phone_key UNIQUE (tenant_code, phone);
+ALTER TABLE drivers DROP CONSTRAINT drivers_phone_key;
+ALTER TABLE drivers ADD CONSTRAINT drivers_tenant_phone_key UNIQUE (tenant_code, phone);
+
+CREATE INDEX idx_drivers_tenant ON drivers(tenant_code, status);
+CREATE INDEX idx_rides_tenant ON rides(tenant_code, created_at DESC);
@@ -0,0 +1,17 @@
+-- Yearly trip exports drivers download for their tax returns. Files are
+-- generated by a background worker and stored in object storage.
+CREATE TABLE trip_exports (
+    id UUID PRIMARY KEY,
+    driver_id UUID NOT NULL REFERENCES drivers(id),
+    year INT NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    object_key VARCHAR(255),
+    trip_count INT,
+    error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMP WITH TIME ZONE,
+    completed_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_trip_exports_driver ON trip_exports(driver_id, year, created_at DESC);
+CREATE INDEX idx_trip_exports_pending ON trip_exports(created_at) WHERE status = 'pending';
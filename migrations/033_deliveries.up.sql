@@ -0,0 +1,18 @@
+-- Parcel deliveries: a delivery ride carries one parcel from the sender at pickup
+-- to a named recipient, with photo proof at both ends and a code at handover
+CREATE TABLE deliveries (
+    ride_id UUID PRIMARY KEY REFERENCES rides(id),
+    status VARCHAR(20) NOT NULL DEFAULT 'awaiting_pickup',
+    recipient_name VARCHAR(100) NOT NULL,
+    recipient_phone VARCHAR(20) NOT NULL,
+    package_description VARCHAR(200),
+    otp VARCHAR(4) NOT NULL,
+    otp_attempts INT NOT NULL DEFAULT 0,
+    pickup_photo_upload_id UUID REFERENCES uploads(id),
+    picked_up_at TIMESTAMP WITH TIME ZONE,
+    dropoff_photo_upload_id UUID REFERENCES uploads(id),
+    received_by VARCHAR(100),
+    delivered_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
@@ -0,0 +1,6 @@
+ALTER TABLE trips DROP COLUMN IF EXISTS declared_value_surcharge;
+ALTER TABLE trips DROP COLUMN IF EXISTS package_surcharge;
+
+ALTER TABLE deliveries DROP COLUMN IF EXISTS declared_value;
+ALTER TABLE deliveries DROP COLUMN IF EXISTS weight_kg;
+ALTER TABLE deliveries DROP COLUMN IF EXISTS package_size;
@@ -0,0 +1,8 @@
+-- Delivery parcels are priced by size class and may declare a value to be
+-- covered for; both surcharges are itemized on the trip fare
+ALTER TABLE deliveries ADD COLUMN package_size VARCHAR(10) NOT NULL DEFAULT 'small';
+ALTER TABLE deliveries ADD COLUMN weight_kg DECIMAL(5, 2);
+ALTER TABLE deliveries ADD COLUMN declared_value DECIMAL(10, 2);
+
+ALTER TABLE trips ADD COLUMN package_surcharge DECIMAL(10, 2);
+ALTER TABLE trips ADD COLUMN declared_value_surcharge DECIMAL(10, 2);
@@ -0,0 +1,12 @@
+-- Drivers whose Redis state failed to update after a committed change. A worker
+-- rewrites their cache entries from Postgres until it succeeds.
+CREATE TABLE driver_cache_repairs (
+    driver_id UUID PRIMARY KEY REFERENCES drivers(id),
+    reason VARCHAR(50) NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_driver_cache_repairs_due ON driver_cache_repairs(next_attempt_at);
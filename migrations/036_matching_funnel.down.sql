@@ -0,0 +1,5 @@
+ALTER TABLE ride_offers
+    DROP COLUMN IF EXISTS viewed_at,
+    DROP COLUMN IF EXISTS dispatch_round_id;
+
+DROP TABLE IF EXISTS dispatch_rounds;
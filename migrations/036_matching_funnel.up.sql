@@ -0,0 +1,20 @@
+-- One row per dispatch round: drivers found near the pickup and drivers left after
+-- filtering. Offers link back to their round for the offered, viewed and accepted
+-- stages of the funnel.
+CREATE TABLE dispatch_rounds (
+    id UUID PRIMARY KEY,
+    ride_id UUID NOT NULL REFERENCES rides(id),
+    region_code VARCHAR(30),
+    vehicle_type VARCHAR(20) NOT NULL,
+    candidates INT NOT NULL,
+    passed_filters INT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_dispatch_rounds_created ON dispatch_rounds(created_at);
+
+ALTER TABLE ride_offers
+    ADD COLUMN dispatch_round_id UUID REFERENCES dispatch_rounds(id),
+    ADD COLUMN viewed_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX idx_ride_offers_dispatch_round ON ride_offers(dispatch_round_id);
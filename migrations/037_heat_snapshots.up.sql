@@ -0,0 +1,18 @@
+-- Supply and demand per grid cell, captured periodically so historical heat can
+-- be read back by hour of the week. Cells are keyed by their south-west corner.
+CREATE TABLE heat_snapshots (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    cell_lat DECIMAL(10, 8) NOT NULL,
+    cell_lng DECIMAL(11, 8) NOT NULL,
+    vehicle_type VARCHAR(20) NOT NULL,
+    online_drivers INT NOT NULL DEFAULT 0,
+    busy_drivers INT NOT NULL DEFAULT 0,
+    ride_requests INT NOT NULL DEFAULT 0,
+    avg_surge DECIMAL(4, 2),
+    -- 0 is Sunday 00:00-01:00 UTC
+    hour_of_week SMALLINT NOT NULL,
+    captured_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_heat_snapshots_cell ON heat_snapshots(cell_lat, cell_lng, hour_of_week);
+CREATE INDEX idx_heat_snapshots_captured ON heat_snapshots(captured_at);
@@ -0,0 +1,23 @@
+-- What each paid ride earned and cost the platform, written when the fare is
+-- paid so finance can compute take-rate per trip and per region
+CREATE TABLE ride_economics (
+    ride_id UUID PRIMARY KEY REFERENCES rides(id),
+    trip_id UUID NOT NULL REFERENCES trips(id),
+    payment_id UUID NOT NULL REFERENCES payments(id),
+    driver_id UUID NOT NULL REFERENCES drivers(id),
+    region_code VARCHAR(30),
+    vehicle_type VARCHAR(20) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    gross_fare DECIMAL(10, 2) NOT NULL,
+    discounts DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    rider_paid DECIMAL(10, 2) NOT NULL,
+    commission DECIMAL(10, 2) NOT NULL,
+    driver_earnings DECIMAL(10, 2) NOT NULL,
+    incentive_top_up DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    net_revenue DECIMAL(10, 2) NOT NULL,
+    refunded_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_ride_economics_region_created ON ride_economics(region_code, created_at);
@@ -0,0 +1,5 @@
+ALTER TABLE trips
+    DROP COLUMN IF EXISTS pricing_events,
+    DROP COLUMN IF EXISTS event_surcharge;
+
+DROP TABLE IF EXISTS pricing_events;
@@ -0,0 +1,26 @@
+-- Fare multipliers and flat surcharges scheduled for holidays and events,
+-- optionally limited to a region, vehicle type or geofence around a venue
+CREATE TABLE pricing_events (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(100) NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    multiplier DECIMAL(4, 2),
+    surcharge DECIMAL(10, 2),
+    region_code VARCHAR(30),
+    vehicle_type VARCHAR(20),
+    center_lat DECIMAL(10, 8),
+    center_lng DECIMAL(11, 8),
+    radius_km DECIMAL(6, 2),
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_pricing_events_window ON pricing_events(starts_at, ends_at) WHERE active;
+
+-- Calendar charges applied to a trip, labeled with the events behind them
+ALTER TABLE trips
+    ADD COLUMN event_surcharge DECIMAL(10, 2),
+    ADD COLUMN pricing_events JSONB;
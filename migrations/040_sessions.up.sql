@@ -0,0 +1,18 @@
+-- Signed-in devices per user and driver account. Only a hash of each bearer
+-- token is kept; revoked sessions stay for the account's device history.
+CREATE TABLE sessions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    principal_type VARCHAR(10) NOT NULL,
+    principal_id UUID NOT NULL,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    device_id VARCHAR(100) NOT NULL,
+    device_name VARCHAR(100),
+    platform VARCHAR(20),
+    app_version VARCHAR(20),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    last_seen_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    revoked_reason VARCHAR(20)
+);
+
+CREATE INDEX idx_sessions_principal ON sessions(principal_type, principal_id) WHERE revoked_at IS NULL;
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_rides_matching_dispatch;
+
+ALTER TABLE rides
+    DROP COLUMN IF EXISTS last_dispatched_at,
+    DROP COLUMN IF EXISTS match_radius_km,
+    DROP COLUMN IF EXISTS matching_attempts;
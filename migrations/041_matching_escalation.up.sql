@@ -0,0 +1,8 @@
+-- Dispatch rounds a ride has been through and the radius of the latest one,
+-- so unanswered rides can be re-offered further out
+ALTER TABLE rides
+    ADD COLUMN matching_attempts INT NOT NULL DEFAULT 0,
+    ADD COLUMN match_radius_km DECIMAL(5, 2),
+    ADD COLUMN last_dispatched_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX idx_rides_matching_dispatch ON rides(last_dispatched_at) WHERE status = 'matching';
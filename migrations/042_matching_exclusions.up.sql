@@ -0,0 +1,20 @@
+-- Time-boxed exclusions ops apply while investigating a driver or servicing an
+-- area. Rows are kept after expiry or revocation as the audit trail.
+CREATE TABLE matching_exclusions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    kind VARCHAR(10) NOT NULL,
+    driver_id UUID REFERENCES drivers(id),
+    center_lat DECIMAL(10, 8),
+    center_lng DECIMAL(11, 8),
+    radius_km DECIMAL(6, 2),
+    reason TEXT NOT NULL,
+    created_by VARCHAR(100) NOT NULL,
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    revoked_by VARCHAR(100),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_matching_exclusions_window ON matching_exclusions(expires_at) WHERE revoked_at IS NULL;
+CREATE INDEX idx_matching_exclusions_driver ON matching_exclusions(driver_id) WHERE driver_id IS NOT NULL;
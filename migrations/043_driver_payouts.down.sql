@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_driver_ledger_payment_reversal;
+DROP INDEX IF EXISTS idx_driver_ledger_payment_earning;
+
+ALTER TABLE driver_ledger_entries
+    DROP COLUMN IF EXISTS payout_id,
+    DROP COLUMN IF EXISTS payment_id;
+
+DROP TABLE IF EXISTS driver_payouts;
@@ -0,0 +1,21 @@
+-- Money paid out of a driver's balance to their bank account
+CREATE TABLE driver_payouts (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    driver_id UUID NOT NULL REFERENCES drivers(id),
+    amount DECIMAL(10, 2) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'requested',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_driver_payouts_driver_created ON driver_payouts(driver_id, created_at);
+
+-- Paid trips post the driver's share of the fare to their balance, or the
+-- commission they owe when the rider paid them cash; refunds reverse it.
+-- Payouts are debited from the balance when requested.
+ALTER TABLE driver_ledger_entries
+    ADD COLUMN payment_id UUID REFERENCES payments(id),
+    ADD COLUMN payout_id UUID REFERENCES driver_payouts(id);
+
+CREATE UNIQUE INDEX idx_driver_ledger_payment_earning ON driver_ledger_entries(payment_id) WHERE entry_type = 'earning';
+CREATE UNIQUE INDEX idx_driver_ledger_payment_reversal ON driver_ledger_entries(payment_id) WHERE entry_type = 'earning_reversal';
@@ -0,0 +1,24 @@
+-- Prepaid rider balances; wallet rides are paid by debiting them
+CREATE TABLE wallets (
+    user_id UUID PRIMARY KEY REFERENCES users(id),
+    balance DECIMAL(10, 2) NOT NULL DEFAULT 0 CHECK (balance >= 0),
+    currency VARCHAR(3) NOT NULL DEFAULT 'INR',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Every movement on a wallet; amounts are signed (payments are negative)
+CREATE TABLE wallet_transactions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES wallets(user_id),
+    txn_type VARCHAR(20) NOT NULL,
+    amount DECIMAL(10, 2) NOT NULL,
+    balance_after DECIMAL(10, 2) NOT NULL,
+    payment_id UUID REFERENCES payments(id),
+    psp_transaction_id VARCHAR(255),
+    idempotency_key VARCHAR(255) UNIQUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_wallet_transactions_user_created ON wallet_transactions(user_id, created_at);
+CREATE UNIQUE INDEX idx_wallet_transactions_payment ON wallet_transactions(payment_id, txn_type) WHERE payment_id IS NOT NULL;
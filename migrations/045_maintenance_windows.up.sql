@@ -0,0 +1,14 @@
+-- Scheduled maintenance: new rides can't be booked while a window is in effect
+-- and apps show a banner ahead of it. Trips already under way carry on.
+CREATE TABLE maintenance_windows (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    message TEXT NOT NULL,
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_maintenance_windows_window ON maintenance_windows(starts_at, ends_at) WHERE active;
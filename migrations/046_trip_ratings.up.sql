@@ -0,0 +1,15 @@
+-- Ratings riders and drivers leave each other after a trip. The users' and
+-- drivers' rating columns are recalculated from these.
+CREATE TABLE trip_ratings (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    trip_id UUID NOT NULL REFERENCES trips(id),
+    rater_type VARCHAR(10) NOT NULL,
+    rater_id UUID NOT NULL,
+    ratee_id UUID NOT NULL,
+    rating SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    feedback TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (trip_id, rater_type)
+);
+
+CREATE INDEX idx_trip_ratings_ratee ON trip_ratings(rater_type, ratee_id, created_at DESC);
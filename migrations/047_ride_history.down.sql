@@ -0,0 +1,5 @@
+CREATE INDEX IF NOT EXISTS idx_rides_user_id ON rides(user_id);
+CREATE INDEX IF NOT EXISTS idx_rides_driver_id ON rides(driver_id);
+
+DROP INDEX IF EXISTS idx_rides_driver_history;
+DROP INDEX IF EXISTS idx_rides_user_history;
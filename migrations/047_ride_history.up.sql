@@ -0,0 +1,7 @@
+-- Ride history pages through a rider's or driver's rides by (created_at, id),
+-- newest first; these replace the single-column indexes on user_id and driver_id
+CREATE INDEX idx_rides_user_history ON rides(user_id, created_at DESC, id DESC);
+CREATE INDEX idx_rides_driver_history ON rides(driver_id, created_at DESC, id DESC);
+
+DROP INDEX IF EXISTS idx_rides_user_id;
+DROP INDEX IF EXISTS idx_rides_driver_id;
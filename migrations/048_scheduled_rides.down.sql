@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_rides_scheduled;
+
+ALTER TABLE rides DROP COLUMN IF EXISTS scheduled_at;
@@ -0,0 +1,5 @@
+-- Rides booked ahead wait in 'scheduled' until matching starts shortly before
+-- their pickup time
+ALTER TABLE rides ADD COLUMN scheduled_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX idx_rides_scheduled ON rides(scheduled_at) WHERE status = 'scheduled';
@@ -0,0 +1,2 @@
+ALTER TABLE payments DROP COLUMN IF EXISTS cancellation_fee_amount;
+DROP TABLE IF EXISTS cancellation_fees;
@@ -0,0 +1,20 @@
+-- Fees riders owe for cancelling after the free window or once the driver
+-- arrived. Taken from the wallet when it covers them, otherwise added to the
+-- rider's next trip payment.
+CREATE TABLE cancellation_fees (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    ride_id UUID NOT NULL UNIQUE REFERENCES rides(id),
+    user_id UUID NOT NULL REFERENCES users(id),
+    driver_id UUID NOT NULL REFERENCES drivers(id),
+    amount DECIMAL(10, 2) NOT NULL,
+    currency VARCHAR(3) DEFAULT 'INR',
+    status VARCHAR(20) NOT NULL,
+    wallet_txn_id UUID REFERENCES wallet_transactions(id),
+    payment_id UUID REFERENCES payments(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_cancellation_fees_outstanding ON cancellation_fees(user_id) WHERE status = 'outstanding';
+
+ALTER TABLE payments ADD COLUMN cancellation_fee_amount DECIMAL(10, 2) NOT NULL DEFAULT 0;
@@ -0,0 +1,6 @@
+ALTER TABLE payments DROP COLUMN IF EXISTS promo_discount;
+ALTER TABLE trips DROP COLUMN IF EXISTS promo_discount;
+ALTER TABLE rides DROP COLUMN IF EXISTS promo_code;
+
+DROP TABLE IF EXISTS promo_redemptions;
+DROP TABLE IF EXISTS promo_codes;
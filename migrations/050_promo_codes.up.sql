@@ -0,0 +1,31 @@
+-- Promo codes riders enter when booking. The discount is quoted on the estimate
+-- and redeemed when the trip ends; each redemption counts as one use.
+CREATE TABLE promo_codes (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    code VARCHAR(20) NOT NULL UNIQUE,
+    discount_type VARCHAR(10) NOT NULL,
+    value DECIMAL(10, 2) NOT NULL,
+    max_discount DECIMAL(10, 2),
+    min_fare DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    max_uses INTEGER,
+    uses INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE promo_redemptions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    promo_id UUID NOT NULL REFERENCES promo_codes(id),
+    ride_id UUID NOT NULL UNIQUE REFERENCES rides(id),
+    user_id UUID NOT NULL REFERENCES users(id),
+    discount DECIMAL(10, 2) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_promo_redemptions_promo ON promo_redemptions(promo_id);
+
+ALTER TABLE rides ADD COLUMN promo_code VARCHAR(20);
+ALTER TABLE trips ADD COLUMN promo_discount DECIMAL(10, 2);
+ALTER TABLE payments ADD COLUMN promo_discount DECIMAL(10, 2) NOT NULL DEFAULT 0;
@@ -0,0 +1,14 @@
+-- Riders let into or held back from products soft-launched in a region (see
+-- product_rollouts in region settings). One row per rider and outcome, so a
+-- raised percentage records the riders it let in.
+CREATE TABLE rollout_exposures (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    region_code VARCHAR(30) NOT NULL,
+    product VARCHAR(20) NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id),
+    enabled BOOLEAN NOT NULL,
+    percent DECIMAL(5, 2) NOT NULL,
+    source VARCHAR(10) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (region_code, product, user_id, enabled)
+);
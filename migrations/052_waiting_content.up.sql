@@ -0,0 +1,20 @@
+-- Safety tips and promo banners for the rider's matching/waiting screen, managed
+-- by admins so the content can change without an app release
+CREATE TABLE waiting_content (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    kind VARCHAR(20) NOT NULL,
+    region_code VARCHAR(30),
+    title VARCHAR(100) NOT NULL,
+    body TEXT NOT NULL,
+    image_url TEXT,
+    action_url TEXT,
+    promo_code VARCHAR(20),
+    priority INTEGER NOT NULL DEFAULT 0,
+    starts_at TIMESTAMP WITH TIME ZONE,
+    ends_at TIMESTAMP WITH TIME ZONE,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_waiting_content_region ON waiting_content(region_code) WHERE active;
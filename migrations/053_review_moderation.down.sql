@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_trip_ratings_feedback_status;
+
+ALTER TABLE trip_ratings
+    DROP COLUMN IF EXISTS feedback_status,
+    DROP COLUMN IF EXISTS flag_reason,
+    DROP COLUMN IF EXISTS appeal_reason,
+    DROP COLUMN IF EXISTS moderated_at;
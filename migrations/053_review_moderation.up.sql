@@ -0,0 +1,12 @@
+-- Written feedback is moderated before the other side sees it: text the filter
+-- flags waits in an admin queue, and removed feedback can be appealed once
+ALTER TABLE trip_ratings
+    ADD COLUMN feedback_status VARCHAR(20),
+    ADD COLUMN flag_reason VARCHAR(50),
+    ADD COLUMN appeal_reason TEXT,
+    ADD COLUMN moderated_at TIMESTAMP WITH TIME ZONE;
+
+UPDATE trip_ratings SET feedback_status = 'published' WHERE feedback IS NOT NULL;
+
+CREATE INDEX idx_trip_ratings_feedback_status ON trip_ratings(feedback_status, created_at)
+    WHERE feedback_status IN ('flagged', 'appealed');
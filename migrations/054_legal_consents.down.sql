@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS consent_records;
+DROP TABLE IF EXISTS legal_documents;
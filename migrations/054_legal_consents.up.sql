@@ -0,0 +1,30 @@
+-- Versions of the terms, privacy policy and regional addenda, and who accepted
+-- which version. A required new version blocks booking and going online until
+-- it is accepted.
+CREATE TABLE legal_documents (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    doc_type VARCHAR(20) NOT NULL,
+    region_code VARCHAR(30),
+    audience VARCHAR(10) NOT NULL DEFAULT 'all',
+    version VARCHAR(20) NOT NULL,
+    title VARCHAR(100) NOT NULL,
+    url TEXT NOT NULL,
+    required BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_legal_documents_version
+    ON legal_documents(doc_type, COALESCE(region_code, ''), audience, version);
+
+CREATE TABLE consent_records (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    party_type VARCHAR(10) NOT NULL,
+    party_id UUID NOT NULL,
+    document_id UUID NOT NULL REFERENCES legal_documents(id),
+    doc_type VARCHAR(20) NOT NULL,
+    region_code VARCHAR(30),
+    version VARCHAR(20) NOT NULL,
+    ip_address TEXT NOT NULL,
+    accepted_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (party_type, party_id, document_id)
+);
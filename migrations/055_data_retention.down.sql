@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_trips_retention;
+
+ALTER TABLE trips DROP COLUMN IF EXISTS anonymized_at;
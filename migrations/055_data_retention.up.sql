@@ -0,0 +1,5 @@
+-- Finished trips past their region's retention period have GPS detail and
+-- personal data anonymized; fares, distances and payments are kept
+ALTER TABLE trips ADD COLUMN anonymized_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX idx_trips_retention ON trips(end_time) WHERE anonymized_at IS NULL;
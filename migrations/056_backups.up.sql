@@ -0,0 +1,17 @@
+-- Logical backups of the core tables, for deployments without managed Postgres
+-- backups. Each backup is written to object storage by a background worker as
+-- one gzipped JSON-lines file per table plus a manifest with checksums.
+CREATE TABLE backups (
+    id UUID PRIMARY KEY,
+    status VARCHAR(20) NOT NULL,
+    manifest_key VARCHAR(255),
+    row_count INT,
+    snapshot_at TIMESTAMP WITH TIME ZONE,
+    error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMP WITH TIME ZONE,
+    completed_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_backups_created ON backups(created_at DESC);
+CREATE INDEX idx_backups_pending ON backups(created_at) WHERE status = 'pending';
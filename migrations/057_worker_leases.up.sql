@@ -0,0 +1,9 @@
+-- One row per background job, naming the instance allowed to run it. The holder
+-- renews its lease on every tick and while a run is in progress; once it lapses
+-- another instance takes the job over.
+CREATE TABLE worker_leases (
+    name VARCHAR(100) PRIMARY KEY,
+    holder VARCHAR(255) NOT NULL,
+    acquired_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
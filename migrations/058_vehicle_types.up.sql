@@ -0,0 +1,28 @@
+-- Registry of vehicle types, so new ones (bikes, premium cars) can be launched
+-- without a release. Each type is active everywhere or nowhere unless regions
+-- overrides it for a region code; fares are the platform fares before tenant
+-- overrides.
+CREATE TABLE vehicle_types (
+    code VARCHAR(30) PRIMARY KEY,
+    label VARCHAR(50) NOT NULL,
+    capacity INT NOT NULL,
+    icon VARCHAR(255) NOT NULL DEFAULT '',
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    regions JSONB NOT NULL DEFAULT '{}',
+    sort_order INT NOT NULL DEFAULT 0,
+    base_fare DECIMAL(10, 2) NOT NULL,
+    per_km_rate DECIMAL(10, 2) NOT NULL,
+    per_min_rate DECIMAL(10, 2) NOT NULL,
+    min_fare DECIMAL(10, 2) NOT NULL,
+    cancellation_fee DECIMAL(10, 2) NOT NULL,
+    waiting_per_min DECIMAL(10, 2) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO vehicle_types (code, label, capacity, icon, sort_order,
+    base_fare, per_km_rate, per_min_rate, min_fare, cancellation_fee, waiting_per_min) VALUES
+    ('auto', 'Auto', 3, 'auto', 1, 25, 12, 1.0, 30, 25, 1.0),
+    ('mini', 'Mini', 4, 'mini', 2, 40, 14, 1.2, 50, 40, 1.5),
+    ('sedan', 'Sedan', 4, 'sedan', 3, 50, 17, 1.5, 80, 50, 2.0),
+    ('suv', 'SUV', 6, 'suv', 4, 80, 22, 2.0, 120, 80, 2.5);
@@ -0,0 +1,3 @@
+ALTER TABLE trips DROP COLUMN IF EXISTS helmet_confirmed_at;
+DELETE FROM vehicle_types WHERE code = 'bike';
+ALTER TABLE vehicle_types DROP COLUMN IF EXISTS helmet_required;
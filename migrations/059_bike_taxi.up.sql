@@ -0,0 +1,9 @@
+-- Bike taxis: a single-passenger vehicle type whose trips can only start once
+-- the driver confirms both riders wear helmets
+ALTER TABLE vehicle_types ADD COLUMN helmet_required BOOLEAN NOT NULL DEFAULT FALSE;
+
+INSERT INTO vehicle_types (code, label, capacity, icon, sort_order, helmet_required,
+    base_fare, per_km_rate, per_min_rate, min_fare, cancellation_fee, waiting_per_min) VALUES
+    ('bike', 'Bike', 1, 'bike', 0, TRUE, 15, 6, 0.5, 20, 10, 0.5);
+
+ALTER TABLE trips ADD COLUMN helmet_confirmed_at TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS ride_offers_response ON ride_offers;
+DROP FUNCTION IF EXISTS record_offer_response();
+ALTER TABLE drivers DROP COLUMN IF EXISTS offer_response_ms;
+ALTER TABLE ride_offers DROP COLUMN IF EXISTS response_ms;
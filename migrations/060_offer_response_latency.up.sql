@@ -0,0 +1,24 @@
+-- How long drivers take to answer offers. Measured by trigger so responses
+-- written inside raw transactions (e.g. AcceptRide) are recorded as well.
+ALTER TABLE ride_offers ADD COLUMN response_ms INTEGER;
+
+-- Each driver's typical response time, weighted towards recent offers
+ALTER TABLE drivers ADD COLUMN offer_response_ms INTEGER;
+
+CREATE OR REPLACE FUNCTION record_offer_response() RETURNS TRIGGER AS $$
+BEGIN
+    IF OLD.status = 'pending' AND NEW.status IN ('accepted', 'queued', 'declined', 'countered') THEN
+        NEW.response_ms := GREATEST(0, ROUND(EXTRACT(EPOCH FROM COALESCE(NEW.responded_at, NOW()) - NEW.offered_at) * 1000));
+
+        -- Keep models.OfferResponseWeight in step with the weight here
+        UPDATE drivers
+        SET offer_response_ms = CASE WHEN offer_response_ms IS NULL THEN NEW.response_ms
+            ELSE ROUND(offer_response_ms * 0.8 + NEW.response_ms * 0.2) END
+        WHERE id = NEW.driver_id;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER ride_offers_response BEFORE UPDATE ON ride_offers
+    FOR EACH ROW EXECUTE FUNCTION record_offer_response();
@@ -0,0 +1 @@
+ALTER TABLE drivers DROP COLUMN IF EXISTS accepted_payment_methods;
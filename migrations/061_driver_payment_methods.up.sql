@@ -0,0 +1,3 @@
+-- Payment methods a driver takes trips for, e.g. no cash or UPI only; NULL
+-- takes every method
+ALTER TABLE drivers ADD COLUMN accepted_payment_methods TEXT[];
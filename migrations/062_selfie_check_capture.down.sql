@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_selfie_checks_upload;
+ALTER TABLE driver_selfie_checks DROP COLUMN IF EXISTS captured_at;
@@ -0,0 +1,14 @@
+-- Selfie checks run from when the selfie was taken rather than when it was
+-- submitted, and each selfie can back only one check
+ALTER TABLE driver_selfie_checks ADD COLUMN captured_at TIMESTAMP WITH TIME ZONE;
+
+UPDATE driver_selfie_checks c SET captured_at = u.verified_at
+FROM uploads u WHERE u.id = c.upload_id;
+
+-- Resubmissions of the same upload: keep the first check
+DELETE FROM driver_selfie_checks c
+USING driver_selfie_checks earlier
+WHERE c.upload_id = earlier.upload_id
+  AND (c.created_at, c.id) > (earlier.created_at, earlier.id);
+
+CREATE UNIQUE INDEX idx_selfie_checks_upload ON driver_selfie_checks(upload_id);
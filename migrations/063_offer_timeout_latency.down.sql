@@ -0,0 +1,14 @@
+CREATE OR REPLACE FUNCTION record_offer_response() RETURNS TRIGGER AS $$
+BEGIN
+    IF OLD.status = 'pending' AND NEW.status IN ('accepted', 'queued', 'declined', 'countered') THEN
+        NEW.response_ms := GREATEST(0, ROUND(EXTRACT(EPOCH FROM COALESCE(NEW.responded_at, NOW()) - NEW.offered_at) * 1000));
+
+        -- Keep models.OfferResponseWeight in step with the weight here
+        UPDATE drivers
+        SET offer_response_ms = CASE WHEN offer_response_ms IS NULL THEN NEW.response_ms
+            ELSE ROUND(offer_response_ms * 0.8 + NEW.response_ms * 0.2) END
+        WHERE id = NEW.driver_id;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
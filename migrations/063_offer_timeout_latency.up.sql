@@ -0,0 +1,24 @@
+-- Offers left to time out count towards a driver's typical response time as
+-- taking the whole timeout, so ignoring offers doesn't keep the average fast.
+-- Offers expired before their timeout (withdrawn) still don't count.
+CREATE OR REPLACE FUNCTION record_offer_response() RETURNS TRIGGER AS $$
+DECLARE
+    elapsed_ms INTEGER;
+BEGIN
+    IF OLD.status = 'pending' AND NEW.status IN ('accepted', 'queued', 'declined', 'countered') THEN
+        NEW.response_ms := GREATEST(0, ROUND(EXTRACT(EPOCH FROM COALESCE(NEW.responded_at, NOW()) - NEW.offered_at) * 1000));
+        elapsed_ms := NEW.response_ms;
+    ELSIF OLD.status = 'pending' AND NEW.status = 'expired' AND COALESCE(NEW.responded_at, NOW()) >= NEW.expires_at THEN
+        elapsed_ms := GREATEST(0, ROUND(EXTRACT(EPOCH FROM NEW.expires_at - NEW.offered_at) * 1000));
+    END IF;
+
+    IF elapsed_ms IS NOT NULL THEN
+        -- Keep models.OfferResponseWeight in step with the weight here
+        UPDATE drivers
+        SET offer_response_ms = CASE WHEN offer_response_ms IS NULL THEN elapsed_ms
+            ELSE ROUND(offer_response_ms * 0.8 + elapsed_ms * 0.2) END
+        WHERE id = NEW.driver_id;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
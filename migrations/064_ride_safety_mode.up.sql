@@ -0,0 +1,8 @@
+-- Whether the rider booked with safety mode on, so matching only loads the
+-- rider's safety preferences when they apply
+ALTER TABLE rides ADD COLUMN safety_mode BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE rides SET safety_mode = TRUE
+FROM users
+WHERE users.id = rides.user_id AND users.safety_mode
+    AND rides.status IN ('pending', 'scheduled', 'matching');
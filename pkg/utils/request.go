@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	apperrors "github.com/aditya/go-comet/internal/errors"
+)
+
+// DecodeJSON decodes a request body holding a single JSON object into dst. Unknown
+// fields, trailing data and bodies over the size limit are rejected. On failure the
+// error response has been written and false is returned.
+func DecodeJSON(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(dst)
+	if err == nil {
+		// Anything after the object means the client sent something we'd silently ignore
+		if decoder.Decode(&struct{}{}) != io.EOF {
+			err = errTrailingData
+		}
+	}
+	if err != nil {
+		Error(w, decodeError(err))
+		return false
+	}
+	return true
+}
+
+var errTrailingData = errors.New("request body must contain a single JSON object")
+
+func decodeError(err error) *apperrors.APIError {
+	var maxBytesErr *http.MaxBytesError
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return apperrors.RequestTooLarge(maxBytesErr.Limit)
+	case errors.Is(err, io.EOF):
+		return apperrors.BadRequest("request body is required")
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return apperrors.BadRequest("request body is truncated JSON")
+	case errors.As(err, &syntaxErr):
+		return apperrors.BadRequest(fmt.Sprintf("malformed JSON at byte %d", syntaxErr.Offset))
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		return apperrors.BadRequest(fmt.Sprintf("field %q must be %s", typeErr.Field, typeErr.Type))
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		return apperrors.BadRequest("unknown field " + strings.TrimPrefix(err.Error(), "json: unknown field "))
+	case errors.Is(err, errTrailingData):
+		return apperrors.BadRequest(err.Error())
+	default:
+		return apperrors.BadRequest("invalid request body")
+	}
+}
@@ -33,10 +33,14 @@ func Success(w http.ResponseWriter, status int, data interface{}) {
 
 // Error sends an error response
 func Error(w http.ResponseWriter, err *apperrors.APIError) {
-	JSON(w, err.StatusCode, map[string]string{
+	body := map[string]interface{}{
 		"error":   err.Code,
 		"message": err.Message,
-	})
+	}
+	if err.Details != nil {
+		body["details"] = err.Details
+	}
+	JSON(w, err.StatusCode, body)
 }
 
 // BadRequest sends a 400 error
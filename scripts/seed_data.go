@@ -53,6 +53,7 @@ func main() {
 	// Initialize repositories
 	userRepo := repository.NewUserRepository(db.DB)
 	driverRepo := repository.NewDriverRepository(db.DB)
+	vehicleTypeRepo := repository.NewVehicleTypeRepository(db.DB)
 	driverCache := cache.NewDriverLocationCache(redis.Client)
 
 	// Create users
@@ -74,7 +75,20 @@ func main() {
 	log.Printf("Created %d users", len(userIDs))
 
 	// Create drivers
-	vehicleTypes := []string{"auto", "mini", "sedan", "suv"}
+	// Drivers get one of the vehicle types active in the registry
+	registered, err := vehicleTypeRepo.GetAll(ctx)
+	if err != nil {
+		log.Fatalf("Failed to load vehicle types: %v", err)
+	}
+	var vehicleTypes []string
+	for _, vt := range registered {
+		if vt.ActiveIn("") {
+			vehicleTypes = append(vehicleTypes, vt.Code)
+		}
+	}
+	if len(vehicleTypes) == 0 {
+		log.Fatal("No active vehicle types to seed drivers with")
+	}
 	log.Println("Creating 100 drivers...")
 	driverIDs := make([]string, 0)
 